@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/hyperjumptech/hyperwallet/internal"
 	log "github.com/sirupsen/logrus"
@@ -39,6 +40,22 @@ func init() {
 // Main entry point
 func main() {
 
+	// "migrate up|down|status" manages the database schema instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := internal.Migrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// "verify [file]" reports the discrepancies between the balances and the transactions instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		if err := internal.Verify(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// start server
 	internal.StartServer()
 }
@@ -11,4 +11,133 @@ var (
 
 	// ErrStringDataTooLong base error when required data value is too long for db column to insert
 	ErrStringDataTooLong = fmt.Errorf("string data too long")
+
+	// ErrNotInTransaction base error when an operation that requires a database transaction is called outside of one
+	ErrNotInTransaction = fmt.Errorf("operation requires a database transaction")
+
+	// ErrIdempotencyKeyExists base error when an idempotency key is already used by a request within the idempotency window
+	ErrIdempotencyKeyExists = fmt.Errorf("idempotency key already used")
+
+	// ErrInvalidExchangeRate base error when an exchange rate is not a positive decimal number the database can store exactly
+	ErrInvalidExchangeRate = fmt.Errorf("invalid exchange rate")
+
+	// ErrInvalidRoundingMode base error when a rounding mode is not one of half-even, half-up or floor
+	ErrInvalidRoundingMode = fmt.Errorf("invalid rounding mode")
+
+	// ErrAmountOutOfRange base error when an amount does not fit into 64 bit integer
+	ErrAmountOutOfRange = fmt.Errorf("amount out of range")
+
+	// ErrNoEffectiveRate base error when a currency had no exchange unit in effect at the requested time
+	ErrNoEffectiveRate = fmt.Errorf("no exchange rate in effect")
+
+	// ErrFXNotConfigured base error when a multi currency journal needs an FX account that is not configured
+	ErrFXNotConfigured = fmt.Errorf("multi currency journal not configured")
+
+	// ErrFXGainLossLimit base error when a multi currency journal generates a larger FX gain or loss than allowed
+	ErrFXGainLossLimit = fmt.Errorf("fx gain or loss exceeds the limit")
+
+	// ErrInvalidAccountStatus base error when an account status is not one of ACTIVE, FROZEN or CLOSED
+	ErrInvalidAccountStatus = fmt.Errorf("invalid account status")
+
+	// ErrAccountStatusTransition base error when an account can not move from its status into the requested one
+	ErrAccountStatusTransition = fmt.Errorf("account status can not change into the requested status")
+
+	// ErrAccountBalanceNotZero base error when closing an account whose balance is not zero
+	ErrAccountBalanceNotZero = fmt.Errorf("account balance is not zero")
+
+	// ErrAccountHasHolds base error when closing an account that still has active holds
+	ErrAccountHasHolds = fmt.Errorf("account has active holds")
+
+	// ErrAccountFrozen base error when posting to a frozen account
+	ErrAccountFrozen = fmt.Errorf("account is frozen")
+
+	// ErrAccountClosed base error when posting to or changing a closed account
+	ErrAccountClosed = fmt.Errorf("account is closed")
+
+	// ErrInvalidAccountLimits base error when the balance limits of an account contradict each other
+	ErrInvalidAccountLimits = fmt.Errorf("invalid account balance limits")
+
+	// ErrAccountBalanceLimit base error when a posting would take an account balance beyond its limits
+	ErrAccountBalanceLimit = fmt.Errorf("account balance limit exceeded")
+
+	// ErrHoldNotFound base error when a hold does not exist
+	ErrHoldNotFound = fmt.Errorf("hold not found")
+
+	// ErrInvalidHold base error when a hold is placed or captured with an invalid amount, expiry or account
+	ErrInvalidHold = fmt.Errorf("invalid hold")
+
+	// ErrHoldNotHeld base error when capturing or releasing a hold that is already captured, released or expired
+	ErrHoldNotHeld = fmt.Errorf("hold is no longer held")
+
+	// ErrCOANotFound base error when a chart of accounts node does not exist
+	ErrCOANotFound = fmt.Errorf("coa not found")
+
+	// ErrCOAExists base error when creating a chart of accounts node whose code is already used
+	ErrCOAExists = fmt.Errorf("coa already exists")
+
+	// ErrInvalidCOA base error when a chart of accounts node has an invalid code, name, type or parent
+	ErrInvalidCOA = fmt.Errorf("invalid coa")
+
+	// ErrCOAInUse base error when deleting a chart of accounts node that still has child nodes or accounts
+	ErrCOAInUse = fmt.Errorf("coa is in use")
+
+	// ErrCOAAlignmentMismatch base error when an account alignment differs from the normal alignment of its COA
+	ErrCOAAlignmentMismatch = fmt.Errorf("account alignment does not match its coa")
+
+	// ErrInvalidPeriod base error when an accounting period is not written as YYYY-MM
+	ErrInvalidPeriod = fmt.Errorf("invalid accounting period")
+
+	// ErrPeriodClosed base error when posting into, or closing again, an accounting period already closed
+	ErrPeriodClosed = fmt.Errorf("accounting period is closed")
+
+	// ErrPeriodNotEnded base error when closing an accounting period that has not ended yet
+	ErrPeriodNotEnded = fmt.Errorf("accounting period has not ended")
+
+	// ErrRetainedEarningsNotConfigured base error when closing income or expenses of a currency without retained earnings account
+	ErrRetainedEarningsNotConfigured = fmt.Errorf("retained earnings account not configured")
+
+	// ErrInvalidEffectiveTime base error when a journal effective time is in the future or before the backdating window
+	ErrInvalidEffectiveTime = fmt.Errorf("invalid effective time")
+
+	// ErrInvalidDateBasis base error when journals or transactions are listed on a date other than effective or booking
+	ErrInvalidDateBasis = fmt.Errorf("invalid date basis")
+
+	// ErrInvalidReversal base error when a reversal transaction does not take back a leg of the reversed journal
+	ErrInvalidReversal = fmt.Errorf("invalid reversal")
+
+	// ErrReversalExceedsOutstanding base error when a reversal takes back more of a leg than is left to reverse
+	ErrReversalExceedsOutstanding = fmt.Errorf("reversal exceeds the outstanding amount")
+
+	// ErrJournalBatchNotPosted base error for the journals of an all or nothing batch left unposted because another journal of the batch was refused
+	ErrJournalBatchNotPosted = fmt.Errorf("journal batch not posted")
+
+	// ErrInvalidAlignment base error when an alignment is neither DEBIT nor CREDIT
+	ErrInvalidAlignment = fmt.Errorf("invalid alignment")
+
+	// ErrTemplateNotFound base error when a journal template is not found
+	ErrTemplateNotFound = fmt.Errorf("journal template not found")
+
+	// ErrTemplateExists base error when a journal template with the same name already exists
+	ErrTemplateExists = fmt.Errorf("journal template already exists")
+
+	// ErrInvalidTemplate base error when a journal template is missing mandatory fields or its legs are not well written
+	ErrInvalidTemplate = fmt.Errorf("invalid journal template")
+
+	// ErrInvalidTemplateParams base error when the parameters a journal template is executed with are missing or not valid
+	ErrInvalidTemplateParams = fmt.Errorf("invalid journal template parameters")
+
+	// ErrInvalidTransfer base error when a transfer amount, fee schedule or accounts are not valid
+	ErrInvalidTransfer = fmt.Errorf("invalid transfer")
+
+	// ErrTransferFeeNotConfigured base error when a transfer charges a fee in a currency without a configured fee account
+	ErrTransferFeeNotConfigured = fmt.Errorf("transfer fee account not configured")
+
+	// ErrTemplateInUse base error when a journal template is deleted while schedules still post it
+	ErrTemplateInUse = fmt.Errorf("journal template in use")
+
+	// ErrScheduleNotFound base error when a journal schedule is not found
+	ErrScheduleNotFound = fmt.Errorf("journal schedule not found")
+
+	// ErrInvalidSchedule base error when a journal schedule cron expression, time zone or template parameters are not valid
+	ErrInvalidSchedule = fmt.Errorf("invalid journal schedule")
 )
@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperjumptech/hyperwallet/internal/config"
+	"github.com/hyperjumptech/hyperwallet/internal/connector"
+	"github.com/hyperjumptech/hyperwallet/internal/logger"
+	"github.com/hyperjumptech/hyperwallet/migrations"
+)
+
+var migrateLog = srvLog.WithField("fn", "Migrate")
+
+// Migrate runs the migrate sub command against the configured database. args are the arguments following "migrate" :
+//
+//	up [steps]          applies the pending migrations, all of them unless steps is specified
+//	down [steps|all]    rolls back the applied migrations, only the latest one unless steps is specified
+//	status              lists the migrations and whether they are applied
+func Migrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up [steps] | down [steps|all] | status")
+	}
+	logger.ConfigureLogging()
+	config.LoadConfig()
+
+	ctx := context.Background()
+	repo, err := connector.NewDBRepository(config.Get("db.driver"))
+	if err != nil {
+		return err
+	}
+	err = repo.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.Disconnect()
+
+	all, err := migrations.ForDriver(config.Get("db.driver"))
+	if err != nil {
+		return err
+	}
+	migrator := migrations.NewMigrator(repo.DB(), all)
+	return runMigrate(ctx, migrator, all, args)
+}
+
+// migrateUp applies the pending migrations of the configured db.driver to the connected repository.
+func migrateUp(ctx context.Context, repo connector.DBRepository) error {
+	all, err := migrations.ForDriver(config.Get("db.driver"))
+	if err != nil {
+		return err
+	}
+	return runMigrate(ctx, migrations.NewMigrator(repo.DB(), all), all, []string{"up"})
+}
+
+// runMigrate runs the migrate sub command with an already constructed migrator.
+func runMigrate(ctx context.Context, migrator *migrations.Migrator, all []*migrations.Migration, args []string) error {
+	switch args[0] {
+	case "up":
+		steps, err := migrateSteps(args[1:], 0)
+		if err != nil {
+			return err
+		}
+		done, err := migrator.Up(ctx, steps)
+		for _, migration := range done {
+			migrateLog.Infof("applied %d_%s", migration.Version, migration.Name)
+		}
+		if err == nil && len(done) == 0 {
+			migrateLog.Info("database is up to date")
+		}
+		return err
+	case "down":
+		steps, err := migrateSteps(args[1:], 1)
+		if err != nil {
+			return err
+		}
+		done, err := migrator.Down(ctx, steps)
+		for _, migration := range done {
+			migrateLog.Infof("rolled back %d_%s", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		applied, err := migrator.Applied(ctx)
+		if err != nil {
+			return err
+		}
+		isApplied := make(map[int64]bool)
+		for _, version := range applied {
+			isApplied[version] = true
+		}
+		for _, migration := range all {
+			status := "pending"
+			if isApplied[migration.Version] {
+				status = "applied"
+			}
+			fmt.Printf("%04d_%s\t%s\n", migration.Version, migration.Name, status)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown migrate command %s, should be one of up, down or status", args[0])
+}
+
+// migrateSteps parses the optional step count argument. "all" and a missing argument with zero default mean every migration.
+func migrateSteps(args []string, defaultSteps int) (int, error) {
+	if len(args) == 0 {
+		return defaultSteps, nil
+	}
+	if args[0] == "all" {
+		return 0, nil
+	}
+	steps, err := strconv.Atoi(args[0])
+	if err != nil || steps <= 0 {
+		return 0, fmt.Errorf("migration steps should be a positive number or all. got %s", args[0])
+	}
+	return steps, nil
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/hyperjumptech/hyperwallet/internal/config"
 	"github.com/hyperjumptech/hyperwallet/internal/connector"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
 	"github.com/hyperjumptech/hyperwallet/internal/health"
 	"github.com/hyperjumptech/hyperwallet/internal/logger"
 	"github.com/hyperjumptech/hyperwallet/internal/router"
@@ -38,8 +39,14 @@ var (
 	// Address of server
 	address string
 
-	// dbRepo database repository
-	dbRepo connector.MySQLDBRepository
+	// dbRepo database repository of the configured db.driver
+	dbRepo connector.DBRepository
+
+	// stopPurge stops the periodic purge of expired idempotency keys and holds
+	stopPurge context.CancelFunc
+
+	// scheduler posts the due journal schedules, nil if schedule.enabled is false
+	scheduler *accounting.Scheduler
 )
 
 // InitializeServer initializes all server connections
@@ -62,26 +69,105 @@ func InitializeServer() error {
 	appRouter.Router = mux.NewRouter()
 
 	// setup db connection
-	dbRepo = connector.MySQLDBRepository{}
-	err := dbRepo.Connect(ctx)
+	var err error
+	dbRepo, err = connector.NewDBRepository(config.Get("db.driver"))
+	if err != nil {
+		logf.Fatal("could not create db repository. Error: ", err)
+		panic("DB driver not supported. please check log.")
+	}
+	err = dbRepo.Connect(ctx)
 	if err != nil {
 		logf.Fatal("could not connect to db. Error: ", err)
 		panic("DB connection failed. please check log.")
 	}
 
-	accounting.AccountMgr = accounting.NewMySQLAccountManager(&dbRepo)
-	accounting.JournalMgr = accounting.NewMySQLJournalManager(&dbRepo)
-	accounting.TransactionMgr = accounting.NewMySQLTransactionManager(&dbRepo)
-	accounting.ExchangeMgr = accounting.NewMySQLExchangeManager(&dbRepo)
+	if config.GetBoolean("db.migrate") {
+		err = migrateUp(ctx, dbRepo)
+		if err != nil {
+			logf.Fatal("could not migrate db. Error: ", err)
+			panic("DB migration failed. please check log.")
+		}
+	}
+
+	accounting.AccountMgr = accounting.NewMySQLAccountManager(dbRepo)
+	accounting.AccountLifecycleMgr = accounting.AccountMgr.(accounting.AccountLifecycleManager)
+	accounting.JournalMgr = accounting.NewMySQLJournalManager(dbRepo)
+	accounting.TransactionMgr = accounting.NewMySQLTransactionManager(dbRepo)
+	rounding, err := accounting.ParseRoundingMode(config.Get("exchange.rounding"))
+	if err != nil {
+		logf.Fatal("could not read exchange.rounding configuration. Error: ", err)
+		panic("Exchange rounding mode not supported. please check log.")
+	}
+	exchangeManager := accounting.NewMySQLExchangeManager(dbRepo, rounding)
+	accounting.ExchangeMgr = exchangeManager
+	accounting.DenominatorMgr = exchangeManager.(accounting.DenominatorManager)
+	accounting.RateMgr = exchangeManager.(accounting.RateManager)
+	accounting.IdempotencyMgr = accounting.NewMySQLIdempotencyManager(dbRepo, time.Duration(config.GetInt("idempotency.window.minute"))*time.Minute)
 	accounting.UniqueIDGenerator = &acccore.RandomGenUniqueIDGenerator{
 		Length:     16,
 		LowerAlpha: false,
 		UpperAlpha: true,
 		Numeric:    true,
 	}
+	clearingAccounts, err := accounting.ParseClearingAccounts(config.Get("fx.clearing.accounts"))
+	if err != nil {
+		logf.Fatal("could not read fx.clearing.accounts configuration. Error: ", err)
+		panic("FX clearing accounts not valid. please check log.")
+	}
+	accounting.FXMgr = accounting.NewFXManager(accounting.AccountMgr, accounting.RateMgr, accounting.UniqueIDGenerator, accounting.FXConfig{
+		BaseCurrency:     config.Get("fx.base.currency"),
+		ClearingAccounts: clearingAccounts,
+		GainLossAccount:  config.Get("fx.gainloss.account"),
+		GainLossLimit:    int64(config.GetInt("fx.gainloss.limit")),
+		Rounding:         rounding,
+	})
+	accounting.HoldMgr = accounting.NewMySQLHoldManager(dbRepo, accounting.UniqueIDGenerator, time.Duration(config.GetInt("hold.expiry.minute"))*time.Minute)
+	accounting.COAMgr = accounting.NewMySQLCOAManager(dbRepo)
+	accounting.ReportMgr = accounting.NewMySQLReportManager(dbRepo, accounting.RateMgr)
+	accounting.IntegrityMgr = accounting.NewMySQLIntegrityManager(dbRepo)
+	accounting.JournalChainMgr = accounting.NewMySQLJournalChainManager(dbRepo)
+	retainedEarnings, err := accounting.ParseClearingAccounts(config.Get("period.retained.earnings.accounts"))
+	if err != nil {
+		logf.Fatal("could not read period.retained.earnings.accounts configuration. Error: ", err)
+		panic("Retained earnings accounts not valid. please check log.")
+	}
+	accounting.PeriodMgr = accounting.NewMySQLPeriodManager(dbRepo, accounting.UniqueIDGenerator, retainedEarnings)
+	accounting.PostingDateMgr = accounting.NewMySQLPostingDateManager(dbRepo, time.Duration(config.GetInt("journal.backdating.window.minute"))*time.Minute)
+	accounting.ReversalMgr = accounting.NewMySQLReversalManager(dbRepo)
+	accounting.JournalBatchMgr = accounting.NewMySQLJournalBatchManager(dbRepo, config.GetInt("journal.batch.chunk.size"))
+	accounting.JournalBatchMaxSize = config.GetInt("journal.batch.max.size")
+	accounting.TemplateMgr = accounting.NewMySQLTemplateManager(dbRepo, rounding)
+	feeAccounts, err := accounting.ParseClearingAccounts(config.Get("transfer.fee.accounts"))
+	if err != nil {
+		logf.Fatal("could not read transfer.fee.accounts configuration. Error: ", err)
+		panic("Transfer fee accounts not valid. please check log.")
+	}
+	accounting.TransferMgr = accounting.NewTransferManager(accounting.AccountMgr, accounting.TransferConfig{FeeAccounts: feeAccounts, Rounding: rounding})
+	accounting.ScheduleMgr = accounting.NewMySQLScheduleManager(dbRepo, accounting.TemplateMgr, accounting.UniqueIDGenerator)
+
+	err = accounting.DenominatorMgr.LoadDenom(context.WithValue(ctx, contextkeys.XRequestID, "startup"))
+	if err != nil {
+		logf.Fatal("could not load the exchange common denominator. Error: ", err)
+		panic("Loading common denominator failed. please check log.")
+	}
+
+	var purgeCtx context.Context
+	purgeCtx, stopPurge = context.WithCancel(context.Background())
+	go purgeIdempotencyKeys(purgeCtx, time.Duration(config.GetInt("idempotency.purge.interval.minute"))*time.Minute)
+	go expireHolds(purgeCtx, time.Duration(config.GetInt("hold.expire.interval.minute"))*time.Minute)
+
+	if config.GetBoolean("schedule.enabled") {
+		scheduler = accounting.NewScheduler(accounting.ScheduleMgr, accounting.SchedulerConfig{
+			Owner:        schedulerOwner(config.Get("schedule.owner")),
+			PollInterval: time.Duration(config.GetInt("schedule.poll.interval.second")) * time.Second,
+			Lease:        time.Duration(config.GetInt("schedule.lease.minute")) * time.Minute,
+			BatchSize:    config.GetInt("schedule.batch.size"),
+		})
+		scheduler.Start()
+	}
 
 	// setup health monitoring
-	err = health.InitializeHealthCheck(ctx, &dbRepo)
+	err = health.InitializeHealthCheck(ctx, dbRepo)
 	if err != nil {
 		logf.Warn("health monitor error: ", err)
 	}
@@ -105,12 +191,76 @@ func InitializeServer() error {
 func shutdownServer() error {
 	logf := srvLog.WithField("fn", "shutdownServer")
 
+	if stopPurge != nil {
+		stopPurge()
+	}
+	if scheduler != nil {
+		scheduler.Stop()
+		logf.Info("done: scheduler stopped")
+	}
 	dbRepo.Disconnect()
 	logf.Info("done: db closed")
 
 	return nil
 }
 
+// purgeIdempotencyKeys deletes the expired idempotency keys every interval until the context is canceled.
+// Expired keys are never replayed, without the purge they would only pile up in the database.
+func purgeIdempotencyKeys(ctx context.Context, interval time.Duration) {
+	logf := srvLog.WithField("fn", "purgeIdempotencyKeys")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purgeContext := context.WithValue(ctx, contextkeys.XRequestID, "idempotency-purge")
+			deleted, err := accounting.IdempotencyMgr.PurgeExpired(purgeContext)
+			if err != nil {
+				logf.Error("could not purge expired idempotency keys: ", err)
+				continue
+			}
+			logf.Debugf("purged %d expired idempotency keys", deleted)
+		}
+	}
+}
+
+// expireHolds marks the holds past their expiry expired every interval until the context is canceled.
+// Expired holds reserve nothing already, marking them only keeps their status current.
+func expireHolds(ctx context.Context, interval time.Duration) {
+	logf := srvLog.WithField("fn", "expireHolds")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expireContext := context.WithValue(ctx, contextkeys.XRequestID, "hold-expiry")
+			expired, err := accounting.HoldMgr.ExpireHolds(expireContext)
+			if err != nil {
+				logf.Error("could not expire holds: ", err)
+				continue
+			}
+			logf.Debugf("expired %d holds", expired)
+		}
+	}
+}
+
+// schedulerOwner returns the name this replica leases the journal schedules under, the configured owner or else the
+// host name followed by the process ID, so replicas sharing a host still hold distinct leases.
+func schedulerOwner(owner string) string {
+	if len(owner) > 0 {
+		return owner
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "hyperwallet"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
 // StartServer starts listening at given port
 func StartServer() {
 
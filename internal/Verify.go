@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hyperjumptech/hyperwallet/internal/accounting"
+	"github.com/hyperjumptech/hyperwallet/internal/config"
+	"github.com/hyperjumptech/hyperwallet/internal/connector"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/hyperjumptech/hyperwallet/internal/logger"
+)
+
+var verifyLog = srvLog.WithField("fn", "Verify")
+
+// Verify runs the verify sub command against the configured database. args are the arguments following "verify" :
+//
+//	[file]    writes the integrity report as json into the file, or to the standard output if it is not specified
+//
+// It returns an error if the ledger could not be verified, or if discrepancies were found.
+func Verify(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: verify [file]")
+	}
+	logger.ConfigureLogging()
+	config.LoadConfig()
+
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "verify")
+	repo, err := connector.NewDBRepository(config.Get("db.driver"))
+	if err != nil {
+		return err
+	}
+	err = repo.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.Disconnect()
+
+	out := io.Writer(os.Stdout)
+	if len(args) == 1 {
+		file, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return runVerify(ctx, accounting.NewMySQLIntegrityManager(repo), out)
+}
+
+// runVerify verifies the ledger with the integrity manager and writes the report as json into out.
+func runVerify(ctx context.Context, integrityManager accounting.IntegrityManager, out io.Writer) error {
+	report, err := integrityManager.VerifyIntegrity(ctx)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(accounting.NewIntegrityReportEntity(report)); err != nil {
+		return err
+	}
+	if !report.Consistent() {
+		return fmt.Errorf("found %d discrepancies in %d accounts and %d transactions", len(report.Discrepancies), report.Accounts, report.Transactions)
+	}
+	verifyLog.Infof("verified %d accounts and %d transactions, no discrepancies found", report.Accounts, report.Transactions)
+	return nil
+}
@@ -0,0 +1,317 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+)
+
+// AccountStatus is the lifecycle status of an account.
+type AccountStatus string
+
+const (
+	// AccountActive accounts accept postings, every account starts ACTIVE
+	AccountActive AccountStatus = "ACTIVE"
+	// AccountFrozen accounts refuse postings until they are made ACTIVE again
+	AccountFrozen AccountStatus = "FROZEN"
+	// AccountClosed accounts refuse postings for good
+	AccountClosed AccountStatus = "CLOSED"
+)
+
+// ParseAccountStatus parses an account status, case insensitive.
+// Throws ErrInvalidAccountStatus if it is not ACTIVE, FROZEN or CLOSED.
+func ParseAccountStatus(status string) (AccountStatus, error) {
+	switch s := AccountStatus(strings.ToUpper(strings.TrimSpace(status))); s {
+	case AccountActive, AccountFrozen, AccountClosed:
+		return s, nil
+	}
+	return "", fmt.Errorf("%w : %s", hwerrors.ErrInvalidAccountStatus, status)
+}
+
+// checkStatusTransition tells if an account with the specified status and balance may move into the next status.
+// ACTIVE and FROZEN accounts move into each other, a FROZEN account with zero balance may be CLOSED,
+// and a CLOSED account stays closed.
+func checkStatusTransition(current, next AccountStatus, balance, held int64) error {
+	switch {
+	case current == AccountClosed:
+		return fmt.Errorf("%w : %s into %s", hwerrors.ErrAccountStatusTransition, current, next)
+	case next == AccountClosed && current != AccountFrozen:
+		return fmt.Errorf("%w : %s into %s, the account must be frozen first", hwerrors.ErrAccountStatusTransition, current, next)
+	case next == AccountClosed && balance != 0:
+		return fmt.Errorf("%w : %d", hwerrors.ErrAccountBalanceNotZero, balance)
+	case next == AccountClosed && held != 0:
+		return fmt.Errorf("%w : %d held", hwerrors.ErrAccountHasHolds, held)
+	}
+	return nil
+}
+
+// checkPostingStatus tells if an account with the specified status accepts postings.
+func checkPostingStatus(accountNumber string, status AccountStatus) error {
+	switch status {
+	case AccountFrozen:
+		return fmt.Errorf("%w : %s", hwerrors.ErrAccountFrozen, accountNumber)
+	case AccountClosed:
+		return fmt.Errorf("%w : %s", hwerrors.ErrAccountClosed, accountNumber)
+	}
+	return nil
+}
+
+// AccountLimits are the balances a posting may not take an account beyond.
+type AccountLimits struct {
+	// AllowNegative lets the balance go below zero, down to MinBalance if it is set
+	AllowNegative bool
+	// MinBalance is the lowest balance, nil if the balance has no minimum
+	MinBalance *int64
+	// MaxBalance is the highest balance, nil if the balance has no maximum
+	MaxBalance *int64
+}
+
+// DefaultAccountLimits are the limits of an account created without limits, its balance is not limited.
+func DefaultAccountLimits() *AccountLimits {
+	return &AccountLimits{AllowNegative: true}
+}
+
+// Validate makes sure the limits leave the balance room.
+// Throws ErrInvalidAccountLimits if the minimum is above the maximum.
+func (l *AccountLimits) Validate() error {
+	lowest, hasLowest := l.lowest()
+	if hasLowest && l.MaxBalance != nil && lowest > *l.MaxBalance {
+		return fmt.Errorf("%w : the lowest balance %d is above the maximum %d", hwerrors.ErrInvalidAccountLimits, lowest, *l.MaxBalance)
+	}
+	return nil
+}
+
+// lowest returns the lowest balance the limits allow, ok is false if there is none.
+func (l *AccountLimits) lowest() (lowest int64, ok bool) {
+	switch {
+	case l.MinBalance != nil && (l.AllowNegative || *l.MinBalance > 0):
+		return *l.MinBalance, true
+	case !l.AllowNegative:
+		return 0, true
+	}
+	return 0, false
+}
+
+// floor returns the lowest balance less the held amount the limits allow, ok is false if there is none.
+// The holds reserve their amount even on an account whose balance is not limited, its balance less a held amount
+// may not go below zero.
+func (l *AccountLimits) floor(held int64) (floor int64, ok bool) {
+	if lowest, ok := l.lowest(); ok {
+		return lowest, true
+	}
+	return 0, held > 0
+}
+
+// Check returns a BalanceLimitError if a posting changing the account balance into newBalance breaks the limits.
+// The held amount is reserved by the holds of the account, the balance less the held amount may not go below the minimum.
+// A posting that brings a balance already beyond the limits back toward them is allowed.
+func (l *AccountLimits) Check(accountNumber string, balance, newBalance, held int64) error {
+	if lowest, ok := l.floor(held); ok && newBalance-held < lowest && newBalance < balance {
+		return &BalanceLimitError{AccountNumber: accountNumber, Balance: newBalance - held, Limit: lowest}
+	}
+	if l.MaxBalance != nil && newBalance > *l.MaxBalance && newBalance > balance {
+		return &BalanceLimitError{AccountNumber: accountNumber, Balance: newBalance, Limit: *l.MaxBalance, Maximum: true}
+	}
+	return nil
+}
+
+// CheckHold returns a BalanceLimitError if a hold of amount takes the balance less the held amount below the minimum.
+// The held amount is reserved by the other holds of the account.
+func (l *AccountLimits) CheckHold(accountNumber string, balance, held, amount int64) error {
+	if lowest, ok := l.floor(held + amount); ok && balance-held-amount < lowest {
+		return &BalanceLimitError{AccountNumber: accountNumber, Balance: balance - held - amount, Limit: lowest}
+	}
+	return nil
+}
+
+// BalanceLimitError tells which account a posting would take beyond its balance limits, it wraps ErrAccountBalanceLimit.
+type BalanceLimitError struct {
+	// AccountNumber is the account whose limit would be exceeded
+	AccountNumber string
+	// Balance is the balance the posting would leave, less the held amount when it is below the minimum
+	Balance int64
+	// Limit is the minimum or maximum balance of the account
+	Limit int64
+	// Maximum is true if Limit is the maximum balance, false if it is the minimum
+	Maximum bool
+}
+
+// Error describes the exceeded limit.
+func (e *BalanceLimitError) Error() string {
+	if e.Maximum {
+		return fmt.Sprintf("%s : account %s balance would be %d, above its maximum %d", hwerrors.ErrAccountBalanceLimit, e.AccountNumber, e.Balance, e.Limit)
+	}
+	return fmt.Sprintf("%s : account %s balance would be %d, below its minimum %d", hwerrors.ErrAccountBalanceLimit, e.AccountNumber, e.Balance, e.Limit)
+}
+
+// Unwrap returns ErrAccountBalanceLimit.
+func (e *BalanceLimitError) Unwrap() error {
+	return hwerrors.ErrAccountBalanceLimit
+}
+
+// AccountDetails are the account details that may change after the account is created.
+// Nil details are left as they are, non nil Limits replace all the limits of the account.
+type AccountDetails struct {
+	Name        *string
+	Description *string
+	COA         *string
+	Limits      *AccountLimits
+}
+
+// AccountLifecycleManager changes the accounts after they are created. acccore.Account has no status,
+// and acccore.AccountManager.UpdateAccount overwrites the whole account, balance included.
+type AccountLifecycleManager interface {
+	// GetAccountStatus returns the status of the account.
+	// Throws acccore.ErrAccountIDNotFound if the account does not exist.
+	GetAccountStatus(ctx context.Context, accountNumber string) (AccountStatus, error)
+
+	// GetAccountLimits returns the balance limits of the account.
+	// Throws acccore.ErrAccountIDNotFound if the account does not exist.
+	GetAccountLimits(ctx context.Context, accountNumber string) (*AccountLimits, error)
+
+	// PersistAccountWithLimits persists a new account with its balance limits at once, nil limits leave the balance
+	// unlimited. Throws the errors of acccore.AccountManager PersistAccount, or ErrInvalidAccountLimits if the limits
+	// are not valid.
+	PersistAccountWithLimits(ctx context.Context, account acccore.Account, limits *AccountLimits) error
+
+	// UpdateAccountDetails changes the name, description, COA or balance limits of the account and returns the updated account.
+	// Throws acccore.ErrAccountIDNotFound if the account does not exist, ErrAccountClosed if it is closed,
+	// or ErrInvalidAccountLimits if the limits are not valid.
+	UpdateAccountDetails(ctx context.Context, accountNumber string, details *AccountDetails, author string) (acccore.Account, error)
+
+	// SetAccountStatus moves the account into the specified status.
+	// Throws acccore.ErrAccountIDNotFound if the account does not exist, ErrAccountStatusTransition if the account
+	// can not move from its status into the specified one, ErrAccountBalanceNotZero when closing an account with balance,
+	// or ErrAccountHasHolds when closing an account with active holds.
+	SetAccountStatus(ctx context.Context, accountNumber string, status AccountStatus, author string) error
+}
+
+// NewInMemoryAccountLifecycleManager returns an account lifecycle manager that changes the accounts of the
+// specified account manager and keeps their status and balance limits in memory.
+func NewInMemoryAccountLifecycleManager(accountManager acccore.AccountManager) AccountLifecycleManager {
+	return &InMemoryAccountLifecycleManager{
+		accountManager: accountManager,
+		statuses:       make(map[string]AccountStatus),
+		limits:         make(map[string]*AccountLimits),
+	}
+}
+
+// InMemoryAccountLifecycleManager implementation of AccountLifecycleManager that keeps the status and limits in memory.
+// Suitable for testing, the in memory journal manager neither refuses postings to frozen or closed accounts
+// nor enforces the balance limits, and the accounts are closed whether the in memory holds hold them or not.
+type InMemoryAccountLifecycleManager struct {
+	accountManager acccore.AccountManager
+	mutex          sync.Mutex
+	statuses       map[string]AccountStatus
+	limits         map[string]*AccountLimits
+}
+
+// account returns the account and its status.
+func (im *InMemoryAccountLifecycleManager) account(ctx context.Context, accountNumber string) (acccore.Account, AccountStatus, error) {
+	account, err := im.accountManager.GetAccountByID(ctx, accountNumber)
+	if err != nil {
+		return nil, "", err
+	}
+	if account == nil {
+		return nil, "", acccore.ErrAccountIDNotFound
+	}
+	status, ok := im.statuses[accountNumber]
+	if !ok {
+		status = AccountActive
+	}
+	return account, status, nil
+}
+
+// GetAccountStatus returns the status of the account.
+func (im *InMemoryAccountLifecycleManager) GetAccountStatus(ctx context.Context, accountNumber string) (AccountStatus, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	_, status, err := im.account(ctx, accountNumber)
+	return status, err
+}
+
+// GetAccountLimits returns the balance limits of the account.
+func (im *InMemoryAccountLifecycleManager) GetAccountLimits(ctx context.Context, accountNumber string) (*AccountLimits, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	if _, _, err := im.account(ctx, accountNumber); err != nil {
+		return nil, err
+	}
+	if limits, ok := im.limits[accountNumber]; ok {
+		return limits, nil
+	}
+	return DefaultAccountLimits(), nil
+}
+
+// PersistAccountWithLimits persists a new account with its balance limits at once.
+func (im *InMemoryAccountLifecycleManager) PersistAccountWithLimits(ctx context.Context, account acccore.Account, limits *AccountLimits) error {
+	if limits != nil {
+		if err := limits.Validate(); err != nil {
+			return err
+		}
+	}
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	if err := im.accountManager.PersistAccount(ctx, account); err != nil {
+		return err
+	}
+	if limits != nil {
+		im.limits[account.GetAccountNumber()] = limits
+	}
+	return nil
+}
+
+// UpdateAccountDetails changes the name, description, COA or balance limits of the account and returns the updated account.
+func (im *InMemoryAccountLifecycleManager) UpdateAccountDetails(ctx context.Context, accountNumber string, details *AccountDetails, author string) (acccore.Account, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	account, status, err := im.account(ctx, accountNumber)
+	if err != nil {
+		return nil, err
+	}
+	if status == AccountClosed {
+		return nil, fmt.Errorf("%w : %s", hwerrors.ErrAccountClosed, accountNumber)
+	}
+	if details.Limits != nil {
+		if err = details.Limits.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if details.Name != nil {
+		account.SetName(*details.Name)
+	}
+	if details.Description != nil {
+		account.SetDescription(*details.Description)
+	}
+	if details.COA != nil {
+		account.SetCOA(*details.COA)
+	}
+	account.SetUpdateBy(author).SetUpdateTime(time.Now())
+	if err = im.accountManager.UpdateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	if details.Limits != nil {
+		im.limits[accountNumber] = details.Limits
+	}
+	return account, nil
+}
+
+// SetAccountStatus moves the account into the specified status.
+func (im *InMemoryAccountLifecycleManager) SetAccountStatus(ctx context.Context, accountNumber string, status AccountStatus, author string) error {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	account, current, err := im.account(ctx, accountNumber)
+	if err != nil {
+		return err
+	}
+	if err = checkStatusTransition(current, status, account.GetBalance(), 0); err != nil {
+		return err
+	}
+	im.statuses[accountNumber] = status
+	return nil
+}
@@ -0,0 +1,120 @@
+package accounting
+
+import (
+	"errors"
+	"testing"
+
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccountStatus(t *testing.T) {
+	for in, expect := range map[string]AccountStatus{"ACTIVE": AccountActive, "frozen": AccountFrozen, " Closed ": AccountClosed} {
+		status, err := ParseAccountStatus(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, expect, status, in)
+	}
+	for _, in := range []string{"", "OPEN", "DELETED"} {
+		_, err := ParseAccountStatus(in)
+		assert.True(t, errors.Is(err, hwerrors.ErrInvalidAccountStatus), in)
+	}
+}
+
+func TestCheckStatusTransition(t *testing.T) {
+	testData := []struct {
+		current AccountStatus
+		next    AccountStatus
+		balance int64
+		held    int64
+		expect  error
+	}{
+		{AccountActive, AccountFrozen, 100, 100, nil},
+		{AccountFrozen, AccountActive, 100, 0, nil},
+		{AccountActive, AccountActive, 100, 0, nil},
+		{AccountFrozen, AccountClosed, 0, 0, nil},
+		{AccountFrozen, AccountClosed, 100, 0, hwerrors.ErrAccountBalanceNotZero},
+		{AccountFrozen, AccountClosed, -100, 0, hwerrors.ErrAccountBalanceNotZero},
+		{AccountFrozen, AccountClosed, 0, 50, hwerrors.ErrAccountHasHolds},
+		{AccountActive, AccountClosed, 0, 0, hwerrors.ErrAccountStatusTransition},
+		{AccountClosed, AccountActive, 0, 0, hwerrors.ErrAccountStatusTransition},
+		{AccountClosed, AccountFrozen, 0, 0, hwerrors.ErrAccountStatusTransition},
+	}
+	for _, td := range testData {
+		err := checkStatusTransition(td.current, td.next, td.balance, td.held)
+		if td.expect == nil {
+			assert.NoError(t, err, "%s into %s", td.current, td.next)
+		} else {
+			assert.True(t, errors.Is(err, td.expect), "%s into %s with balance %d", td.current, td.next, td.balance)
+		}
+	}
+}
+
+func TestAccountLimits_Validate(t *testing.T) {
+	minus, zero, ten := int64(-100), int64(0), int64(10)
+	assert.NoError(t, DefaultAccountLimits().Validate())
+	assert.NoError(t, (&AccountLimits{AllowNegative: true, MinBalance: &minus, MaxBalance: &zero}).Validate())
+	assert.NoError(t, (&AccountLimits{MinBalance: &minus, MaxBalance: &zero}).Validate(), "a negative minimum is ignored if the balance may not go negative")
+	assert.NoError(t, (&AccountLimits{MinBalance: &ten, MaxBalance: &ten}).Validate())
+	assert.True(t, errors.Is((&AccountLimits{AllowNegative: true, MinBalance: &ten, MaxBalance: &minus}).Validate(), hwerrors.ErrInvalidAccountLimits))
+	assert.True(t, errors.Is((&AccountLimits{MaxBalance: &minus}).Validate(), hwerrors.ErrInvalidAccountLimits))
+}
+
+func TestAccountLimits_Check(t *testing.T) {
+	minus, ten, hundred := int64(-100), int64(10), int64(100)
+	testData := []struct {
+		limits     *AccountLimits
+		balance    int64
+		newBalance int64
+		limit      int64
+		maximum    bool
+		refused    bool
+	}{
+		{DefaultAccountLimits(), 0, -1000, 0, false, false},
+		{&AccountLimits{}, 100, 0, 0, false, false},
+		{&AccountLimits{}, 100, -1, 0, false, true},
+		{&AccountLimits{}, -50, -20, 0, false, false},
+		{&AccountLimits{}, -50, -60, 0, false, true},
+		{&AccountLimits{AllowNegative: true, MinBalance: &minus}, 0, -100, 0, false, false},
+		{&AccountLimits{AllowNegative: true, MinBalance: &minus}, 0, -101, -100, false, true},
+		{&AccountLimits{MinBalance: &ten}, 50, 9, 10, false, true},
+		{&AccountLimits{AllowNegative: true, MaxBalance: &hundred}, 0, 100, 0, false, false},
+		{&AccountLimits{AllowNegative: true, MaxBalance: &hundred}, 0, 101, 100, true, true},
+		{&AccountLimits{AllowNegative: true, MaxBalance: &hundred}, 200, 150, 0, false, false},
+	}
+	for i, td := range testData {
+		err := td.limits.Check("LIMITED", td.balance, td.newBalance, 0)
+		if !td.refused {
+			assert.NoError(t, err, "case %d", i)
+			continue
+		}
+		limitErr := &BalanceLimitError{}
+		if assert.True(t, errors.As(err, &limitErr), "case %d", i) {
+			assert.Equal(t, "LIMITED", limitErr.AccountNumber)
+			assert.Equal(t, td.newBalance, limitErr.Balance)
+			assert.Equal(t, td.limit, limitErr.Limit, "case %d", i)
+			assert.Equal(t, td.maximum, limitErr.Maximum, "case %d", i)
+		}
+		assert.True(t, errors.Is(err, hwerrors.ErrAccountBalanceLimit), "case %d", i)
+		assert.Contains(t, err.Error(), "LIMITED")
+	}
+}
+
+func TestAccountLimits_CheckHeld(t *testing.T) {
+	minus := int64(-100)
+	// the holds reserve their amount on an account whose balance is not limited too
+	assert.NoError(t, DefaultAccountLimits().Check("HELD", 1000, 600, 600))
+	assert.True(t, errors.Is(DefaultAccountLimits().Check("HELD", 1000, 599, 600), hwerrors.ErrAccountBalanceLimit))
+	assert.NoError(t, DefaultAccountLimits().Check("HELD", 1000, 1500, 600))
+	assert.NoError(t, DefaultAccountLimits().CheckHold("HELD", 1000, 600, 400))
+	assert.True(t, errors.Is(DefaultAccountLimits().CheckHold("HELD", 1000, 600, 401), hwerrors.ErrAccountBalanceLimit))
+	assert.True(t, errors.Is(DefaultAccountLimits().CheckHold("HELD", -10, 0, 1), hwerrors.ErrAccountBalanceLimit))
+
+	limits := &AccountLimits{AllowNegative: true, MinBalance: &minus}
+	assert.NoError(t, limits.Check("HELD", 1000, 0, 100))
+	err := limits.Check("HELD", 1000, 0, 101)
+	limitErr := &BalanceLimitError{}
+	if assert.True(t, errors.As(err, &limitErr)) {
+		assert.Equal(t, int64(-101), limitErr.Balance)
+		assert.Equal(t, int64(-100), limitErr.Limit)
+	}
+}
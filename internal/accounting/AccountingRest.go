@@ -1,11 +1,15 @@
 package accounting
 
 import (
+	"bufio"
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/big"
 	"net/http"
@@ -14,6 +18,8 @@ import (
 	"time"
 
 	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/connector"
 	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
 	"github.com/hyperjumptech/hyperwallet/internal/helpers"
 	"github.com/sirupsen/logrus"
@@ -35,6 +41,60 @@ var (
 	// UniqueIDGenerator is the UniqueIDGenerator instance used in all rest endpoint
 	UniqueIDGenerator acccore.UniqueIDGenerator
 
+	// IdempotencyMgr is the idempotency manager instance used by the journal creation rest endpoint
+	IdempotencyMgr IdempotencyManager
+
+	// DenominatorMgr is the denominator manager instance used by the common denominator rest endpoint
+	DenominatorMgr DenominatorManager
+
+	// RateMgr is the rate manager instance used by the currency and exchange rest endpoints
+	RateMgr RateManager
+
+	// FXMgr is the FX manager instance used by the journal creation rest endpoint for multi currency journals
+	FXMgr FXManager
+
+	// AccountLifecycleMgr is the account lifecycle manager instance used by the account update and status rest endpoints
+	AccountLifecycleMgr AccountLifecycleManager
+
+	// HoldMgr is the hold manager instance used by the hold rest endpoints and to tell the account available balance
+	HoldMgr HoldManager
+
+	// COAMgr is the chart of accounts manager instance used by the coa rest endpoints and to check the COA of accounts
+	COAMgr COAManager
+
+	// ReportMgr is the report manager instance used by the report rest endpoints
+	ReportMgr ReportManager
+
+	// IntegrityMgr is the integrity manager instance used by the admin integrity rest endpoint
+	IntegrityMgr IntegrityManager
+	// JournalChainMgr is the journal chain manager instance used by the admin journal chain rest endpoint
+	JournalChainMgr JournalChainManager
+
+	// PeriodMgr is the period manager instance used by the period rest endpoints and to refuse postings into closed periods
+	PeriodMgr PeriodManager
+
+	// PostingDateMgr is the posting date manager instance used to check the effective time of journals, and by the
+	// journal and transaction listing rest endpoints
+	PostingDateMgr PostingDateManager
+
+	// ReversalMgr is the reversal manager instance used by the reversal rest endpoints to tell how much of a journal is left to reverse
+	ReversalMgr ReversalManager
+
+	// JournalBatchMgr is the journal batch manager instance used by the journal batch rest endpoint
+	JournalBatchMgr JournalBatchManager
+
+	// JournalBatchMaxSize is the most journals the journal batch rest endpoint accepts in a batch
+	JournalBatchMaxSize = 1000
+
+	// TemplateMgr is the template manager instance used by the journal template rest endpoints
+	TemplateMgr TemplateManager
+
+	// TransferMgr is the transfer manager instance used by the transfer rest endpoint to prepare the transfer journals
+	TransferMgr TransferManager
+
+	// ScheduleMgr is the schedule manager instance used by the journal schedule rest endpoints and the scheduler
+	ScheduleMgr ScheduleManager
+
 	restLog = logrus.WithField("file", "AccountRest.go")
 
 	// ErrRestPathInvalid base error used to indicate if a URL path is not valid
@@ -44,6 +104,14 @@ var (
 	RestTimeFormat = "2006-01-02T15:04:05"
 )
 
+const (
+	// IdempotencyKeyHeader is the request header a client use to safely retry a journal creation
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is the response header set when the response is replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
 // NewAccountEntity is the structure of request body for creating new Account
 type NewAccountEntity struct {
 	AccountNo   string `json:"account_number"`
@@ -53,172 +121,2271 @@ type NewAccountEntity struct {
 	Currency    string `json:"currency"`
 	Alignment   string `json:"alignment"`
 	Creator     string `json:"creator"`
+	// Limits are the balance limits of the account, its balance is not limited when absent
+	Limits *AccountLimitsEntity `json:"limits"`
+}
+
+// AccountEntity is the structure of response body that contains an account
+type AccountEntity struct {
+	AccountNo   string `json:"account_number"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	COA         string `json:"coa"`
+	Currency    string `json:"currency"`
+	Alignment   string `json:"alignment"`
+	Balance     int64  `json:"balance"`
+	// AvailableBalance is the balance less the amount reserved by the holds of the account
+	AvailableBalance int64  `json:"available_balance"`
+	Status           string `json:"status"`
+	// Limits are the balance limits of the account
+	Limits *AccountLimitsEntity `json:"limits"`
+}
+
+// AccountLimitsEntity is the structure of the balance limits of an Account in request and response bodies
+type AccountLimitsEntity struct {
+	// AllowNegative lets the balance go below zero, down to MinBalance if it is set. Absent means true
+	AllowNegative *bool `json:"allow_negative"`
+	// MinBalance is the lowest balance, null if the balance has no minimum
+	MinBalance *int64 `json:"min_balance"`
+	// MaxBalance is the highest balance, null if the balance has no maximum
+	MaxBalance *int64 `json:"max_balance"`
+}
+
+// toAccountLimits returns the balance limits in the entity, nil if there is no entity.
+func (e *AccountLimitsEntity) toAccountLimits() *AccountLimits {
+	if e == nil {
+		return nil
+	}
+	limits := &AccountLimits{AllowNegative: true, MinBalance: e.MinBalance, MaxBalance: e.MaxBalance}
+	if e.AllowNegative != nil {
+		limits.AllowNegative = *e.AllowNegative
+	}
+	return limits
+}
+
+// UpdateAccountEntity is the structure of request body for updating an Account, absent fields are left as they are
+type UpdateAccountEntity struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	COA         *string `json:"coa"`
+	// Limits replace all the balance limits of the account when present
+	Limits *AccountLimitsEntity `json:"limits"`
+	Author string               `json:"author"`
+}
+
+// SetAccountStatusEntity is the structure of request body for changing the status of an Account
+type SetAccountStatusEntity struct {
+	Status string `json:"status"`
+	Author string `json:"author"`
+}
+
+// PlaceHoldEntity is the structure of request body for placing a hold on an Account
+type PlaceHoldEntity struct {
+	Amount      int64  `json:"amount"`
+	Description string `json:"description"`
+	// ExpiresAt is the RFC3339 time the hold expires, the default hold expiry applies when it is absent
+	ExpiresAt string `json:"expires_at"`
+	Creator   string `json:"creator"`
+}
+
+// CaptureHoldEntity is the structure of request body for capturing a hold
+type CaptureHoldEntity struct {
+	// AccountNumber is the account receiving the captured amount
+	AccountNumber string `json:"account_number"`
+	// Amount is the amount to capture, the whole hold when it is absent
+	Amount      int64  `json:"amount"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+}
+
+// ReleaseHoldEntity is the structure of request body for releasing a hold
+type ReleaseHoldEntity struct {
+	Author string `json:"author"`
+}
+
+// HoldEntity is the structure of response body that contains a hold
+type HoldEntity struct {
+	HoldID         string `json:"hold_id"`
+	AccountNumber  string `json:"account_number"`
+	Amount         int64  `json:"amount"`
+	Description    string `json:"description"`
+	Status         string `json:"status"`
+	ExpiresAt      string `json:"expires_at"`
+	CapturedAmount int64  `json:"captured_amount"`
+	JournalID      string `json:"journal_id"`
+	CreateTime     string `json:"create_time"`
+	CreateBy       string `json:"create_by"`
+	UpdateTime     string `json:"update_time"`
+	UpdateBy       string `json:"update_by"`
+}
+
+// NewCOAEntity is the structure of request body for creating a chart of accounts node
+type NewCOAEntity struct {
+	Code string `json:"code"`
+	// ParentCode is the code of the parent node, the node is a root when it is absent
+	ParentCode string `json:"parent_code"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	// Alignment is the normal alignment of the node, the normal alignment of its type when it is absent
+	Alignment string `json:"alignment"`
+	Creator   string `json:"creator"`
+}
+
+// UpdateCOAEntity is the structure of request body for updating a chart of accounts node, absent fields are left as they are
+type UpdateCOAEntity struct {
+	Name *string `json:"name"`
+	// ParentCode moves the node under another parent when present, an empty parent_code makes it a root
+	ParentCode *string `json:"parent_code"`
+	Author     string  `json:"author"`
+}
+
+// COAEntity is the structure of response body that contains a chart of accounts node
+type COAEntity struct {
+	Code       string `json:"code"`
+	ParentCode string `json:"parent_code"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Alignment  string `json:"alignment"`
+	CreateTime string `json:"create_time"`
+	CreateBy   string `json:"create_by"`
+	UpdateTime string `json:"update_time"`
+	UpdateBy   string `json:"update_by"`
+}
+
+// COABalanceEntity is the structure of response body that contains the aggregated balance of a chart of accounts node
+type COABalanceEntity struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Alignment string `json:"alignment"`
+	// Balances are the balances of the accounts under the node and its descendants, one per currency
+	Balances []*COABalanceItem `json:"balances"`
+}
+
+// COABalanceItem is the aggregated balance of a chart of accounts node in one currency
+type COABalanceItem struct {
+	Currency string `json:"currency"`
+	Balance  int64  `json:"balance"`
+	Accounts int    `json:"accounts"`
+}
+
+// TrialBalanceEntity is the structure of response body that contains a trial balance
+type TrialBalanceEntity struct {
+	At string `json:"at"`
+	// Currency is the only currency reported, empty if all the currencies are
+	Currency   string                      `json:"currency"`
+	Balanced   bool                        `json:"balanced"`
+	Currencies []*TrialBalanceCurrencyItem `json:"currencies"`
+}
+
+// TrialBalanceCurrencyItem is the trial balance of the accounts in one currency
+type TrialBalanceCurrencyItem struct {
+	Currency string                 `json:"currency"`
+	Debit    int64                  `json:"debit"`
+	Credit   int64                  `json:"credit"`
+	Balanced bool                   `json:"balanced"`
+	COAs     []*TrialBalanceCOAItem `json:"coas"`
+}
+
+// TrialBalanceCOAItem is the trial balance of the accounts having the same COA
+type TrialBalanceCOAItem struct {
+	Code     string                     `json:"code"`
+	Name     string                     `json:"name"`
+	Debit    int64                      `json:"debit"`
+	Credit   int64                      `json:"credit"`
+	Accounts []*TrialBalanceAccountItem `json:"accounts"`
+}
+
+// TrialBalanceAccountItem is the total debit and credit of an account in a trial balance
+type TrialBalanceAccountItem struct {
+	AccountNumber string `json:"account_number"`
+	Name          string `json:"name"`
+	Alignment     string `json:"alignment"`
+	Debit         int64  `json:"debit"`
+	Credit        int64  `json:"credit"`
+	Balance       int64  `json:"balance"`
+}
+
+// BalanceSheetEntity is the structure of response body that contains a balance sheet
+type BalanceSheetEntity struct {
+	At string `json:"at"`
+	// Currency is the only currency reported, empty if all the currencies are
+	Currency string `json:"currency"`
+	// ReportingCurrency is the currency the amounts are exchanged into, empty if they are not exchanged
+	ReportingCurrency string             `json:"reporting_currency"`
+	Balanced          bool               `json:"balanced"`
+	Statements        []*StatementEntity `json:"statements"`
+}
+
+// IncomeStatementEntity is the structure of response body that contains an income statement
+type IncomeStatementEntity struct {
+	From  string `json:"from"`
+	Until string `json:"until"`
+	// Currency is the only currency reported, empty if all the currencies are
+	Currency string `json:"currency"`
+	// ReportingCurrency is the currency the amounts are exchanged into, empty if they are not exchanged
+	ReportingCurrency string             `json:"reporting_currency"`
+	Statements        []*StatementEntity `json:"statements"`
+}
+
+// StatementEntity is a financial statement in one currency
+type StatementEntity struct {
+	Currency  string                    `json:"currency"`
+	NetIncome int64                     `json:"net_income"`
+	Balanced  *bool                     `json:"balanced,omitempty"`
+	Sections  []*StatementSectionEntity `json:"sections"`
+}
+
+// StatementSectionEntity is the lines of a financial statement having one chart of accounts type
+type StatementSectionEntity struct {
+	Type  string                 `json:"type"`
+	Total int64                  `json:"total"`
+	Lines []*StatementLineEntity `json:"lines"`
+}
+
+// StatementLineEntity is a chart of accounts node of a financial statement
+type StatementLineEntity struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	ParentCode string `json:"parent_code"`
+	Level      int    `json:"level"`
+	Amount     int64  `json:"amount"`
+}
+
+// IntegrityReportEntity is the structure of response body that contains the discrepancies of the ledger
+type IntegrityReportEntity struct {
+	CheckedAt     string             `json:"checked_at"`
+	Consistent    bool               `json:"consistent"`
+	Accounts      int                `json:"accounts"`
+	Transactions  int                `json:"transactions"`
+	Discrepancies []*DiscrepancyItem `json:"discrepancies"`
+}
+
+// DiscrepancyItem is a single inconsistency of the ledger
+type DiscrepancyItem struct {
+	Kind          string `json:"kind"`
+	AccountNumber string `json:"account_number,omitempty"`
+	JournalID     string `json:"journal_id,omitempty"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	Currency      string `json:"currency,omitempty"`
+	Expected      int64  `json:"expected"`
+	Actual        int64  `json:"actual"`
+	Description   string `json:"description"`
+}
+
+// JournalChainEntity is the structure of response body that contains the verification of the journal hash chain
+type JournalChainEntity struct {
+	From          string                 `json:"from"`
+	Until         string                 `json:"until"`
+	Valid         bool                   `json:"valid"`
+	FirstSequence int64                  `json:"first_sequence"`
+	LastSequence  int64                  `json:"last_sequence"`
+	Journals      int                    `json:"journals"`
+	BrokenLink    *BrokenChainLinkEntity `json:"broken_link,omitempty"`
+}
+
+// BrokenChainLinkEntity is the first link of the journal hash chain that does not verify
+type BrokenChainLinkEntity struct {
+	Sequence  int64  `json:"sequence"`
+	JournalID string `json:"journal_id,omitempty"`
+	Reason    string `json:"reason"`
+	Expected  string `json:"expected,omitempty"`
+	Actual    string `json:"actual,omitempty"`
+}
+
+// ClosePeriodEntity is the structure of request body for closing an accounting period
+type ClosePeriodEntity struct {
+	Creator string `json:"creator"`
+}
+
+// PeriodEntity is the structure of response body that contains an accounting period
+type PeriodEntity struct {
+	Period            string   `json:"period"`
+	Status            string   `json:"status"`
+	Start             string   `json:"start"`
+	End               string   `json:"end"`
+	ClosingJournalIDs []string `json:"closing_journal_ids"`
+	ClosedAt          string   `json:"closed_at,omitempty"`
+	ClosedBy          string   `json:"closed_by,omitempty"`
+}
+
+// ReversalLegEntity is the structure of response body that contains how much of a journal leg was reversed
+type ReversalLegEntity struct {
+	TransactionID string `json:"transaction_id"`
+	AccountNumber string `json:"account_number"`
+	Alignment     string `json:"alignment"`
+	Amount        int64  `json:"amount"`
+	Reversed      int64  `json:"reversed"`
+	Outstanding   int64  `json:"outstanding"`
+}
+
+// JournalReversalEntity is the structure of response body that contains how much of a journal was reversed
+type JournalReversalEntity struct {
+	JournalID     string               `json:"journal_id"`
+	FullyReversed bool                 `json:"fully_reversed"`
+	Legs          []*ReversalLegEntity `json:"legs"`
+}
+
+// JournalBatchEntity is the structure of response body that contains the result of every journal of a batch
+type JournalBatchEntity struct {
+	Mode    string              `json:"mode"`
+	Posted  int                 `json:"posted"`
+	Refused int                 `json:"refused"`
+	Results []*JournalBatchItem `json:"results"`
+}
+
+// JournalBatchItem is the result of a journal of a batch, its status is POSTED, REFUSED, or NOT_POSTED when an
+// all or nothing batch is refused because of another journal
+type JournalBatchItem struct {
+	Index     int    `json:"index"`
+	JournalID string `json:"journal_id,omitempty"`
+	Status    string `json:"status"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// TemplateParamEntity is a named parameter of a journal template, referred to as {name} in the template
+type TemplateParamEntity struct {
+	Name string `json:"name"`
+	// Type is ACCOUNT, AMOUNT, PERCENTAGE or TEXT
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// TemplateLegEntity is a transaction of the journals made from a journal template
+type TemplateLegEntity struct {
+	// Account is an account number, or the {name} of an ACCOUNT parameter
+	Account   string `json:"account"`
+	Alignment string `json:"alignment"`
+	// Amount adds up terms multiplying whole numbers, AMOUNT parameters and percentages, such as {amount} * {fee}%
+	Amount      string `json:"amount"`
+	Description string `json:"description"`
+}
+
+// NewTemplateEntity is the structure of request body for creating a journal template
+type NewTemplateEntity struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Params      []*TemplateParamEntity `json:"params"`
+	Legs        []*TemplateLegEntity   `json:"legs"`
+	Creator     string                 `json:"creator"`
+}
+
+// TemplateEntity is the structure of response body that contains a journal template
+type TemplateEntity struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Params      []*TemplateParamEntity `json:"params"`
+	Legs        []*TemplateLegEntity   `json:"legs"`
+	CreateTime  string                 `json:"create_time"`
+	CreateBy    string                 `json:"create_by"`
+}
+
+// TemplateParamValues are the values of the parameters of a journal template, written as json strings or numbers
+type TemplateParamValues map[string]string
+
+// UnmarshalJSON reads the parameter values, numbers are kept as they are written.
+func (v *TemplateParamValues) UnmarshalJSON(data []byte) error {
+	raw := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	values := make(TemplateParamValues, len(raw))
+	for name, value := range raw {
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			values[name] = text
+			continue
+		}
+		var number json.Number
+		if err := json.Unmarshal(value, &number); err != nil {
+			return fmt.Errorf("value of parameter %s should be a string or a number", name)
+		}
+		values[name] = number.String()
+	}
+	*v = values
+	return nil
+}
+
+// ExecuteTemplateEntity is the structure of request body for making a journal from a journal template
+type ExecuteTemplateEntity struct {
+	Params  TemplateParamValues `json:"params"`
+	Creator string              `json:"creator"`
+	// EffectiveTime is when the journal takes effect on the balances, now if empty
+	EffectiveTime string `json:"effective_time,omitempty"`
+}
+
+// NewTransferEntity is the structure of request body for moving an amount from an account to another
+type NewTransferEntity struct {
+	FromAccount string `json:"from_account"`
+	ToAccount   string `json:"to_account"`
+	Amount      int64  `json:"amount"`
+	// Fee is the fee schedule of the fee charged to the from account on top of the amount, no fee if absent
+	Fee         *FeeScheduleEntity `json:"fee,omitempty"`
+	Description string             `json:"description"`
+	Creator     string             `json:"creator"`
+	// EffectiveTime is when the transfer takes effect on the balances, now if empty
+	EffectiveTime string `json:"effective_time,omitempty"`
+}
+
+// FeeScheduleEntity is the fee schedule of a transfer, a fixed fee plus a percentage of the amount, bounded by a
+// minimum and a maximum when they are not 0
+type FeeScheduleEntity struct {
+	Fixed int64 `json:"fixed"`
+	// Percentage is a decimal number from 0 to 100, such as 0.5
+	Percentage json.Number `json:"percentage,omitempty"`
+	Minimum    int64       `json:"minimum"`
+	Maximum    int64       `json:"maximum"`
+}
+
+// TransferEntity is the structure of response body that contains a transfer and its journal
+type TransferEntity struct {
+	JournalID   string                 `json:"journal_id"`
+	Amount      int64                  `json:"amount"`
+	Fee         int64                  `json:"fee"`
+	FromAccount *TransferAccountEntity `json:"from_account"`
+	ToAccount   *TransferAccountEntity `json:"to_account"`
+	Journal     *JournalDetail         `json:"journal"`
+}
+
+// TransferAccountEntity is an account of a transfer with its balance right after the transfer
+type TransferAccountEntity struct {
+	AccountNumber string `json:"account_number"`
+	Balance       int64  `json:"balance"`
+}
+
+// NewScheduleEntity is the structure of request body for creating a journal schedule
+type NewScheduleEntity struct {
+	TemplateName string `json:"template_name"`
+	// Cron is the cron expression of the times the template is posted, such as 0 1 * * * for every day at 01:00
+	Cron string `json:"cron"`
+	// TimeZone is the IANA time zone the cron expression is read in, UTC if empty
+	TimeZone string              `json:"time_zone,omitempty"`
+	Params   TemplateParamValues `json:"params"`
+	// StartTime is when the occurrences are counted from, now if empty. A start time in the past catches up the
+	// occurrences since then
+	StartTime string `json:"start_time,omitempty"`
+	Creator   string `json:"creator"`
+}
+
+// ScheduleEntity is the structure of response body that contains a journal schedule
+type ScheduleEntity struct {
+	ScheduleID   string            `json:"schedule_id"`
+	TemplateName string            `json:"template_name"`
+	Cron         string            `json:"cron"`
+	TimeZone     string            `json:"time_zone"`
+	Params       map[string]string `json:"params"`
+	NextRunTime  string            `json:"next_run_time"`
+	CreateTime   string            `json:"create_time"`
+	CreateBy     string            `json:"create_by"`
+}
+
+// ScheduleRunEntity is the structure of response body that contains a run of a journal schedule
+type ScheduleRunEntity struct {
+	RunID         string `json:"run_id"`
+	ScheduledTime string `json:"scheduled_time"`
+	StartTime     string `json:"start_time"`
+	FinishTime    string `json:"finish_time,omitempty"`
+	// Status is RUNNING, POSTED or FAILED
+	Status    string `json:"status"`
+	JournalID string `json:"journal_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Runner    string `json:"runner"`
+}
+
+// PaginatedScheduleRunsResponse is the journal schedule run history response paginated
+type PaginatedScheduleRunsResponse struct {
+	Runs       []*ScheduleRunEntity `json:"runs"`
+	Pagination acccore.PageResult   `json:"pagination"`
+}
+
+// PaginatedResponse is the structure of stuff that requires pagination
+type PaginatedResponse struct {
+	Items      interface{}
+	Pagination acccore.PageResult
+}
+
+// TransactionListResponse is the structure response
+type TransactionListResponse struct {
+	Transactions []*TransactionListItem `json:"transactions"`
+	Pagination   acccore.PageResult     `json:"pagination"`
+}
+
+// DrawAccount draws the account activity
+func DrawAccount(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "ListTransactionByAccount")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/accounts/{AccountNumber}/draw", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/accounts/{AccountNumber}/draw. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+
+	var from, until time.Time
+	var page, size int
+
+	qfrom := r.URL.Query()["from"]
+	if qfrom == nil || len(qfrom[0]) == 0 {
+		llog.Errorf("error missing from field")
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "missing from", "missing from", 1)
+		return
+	}
+	from, err = time.Parse(RestTimeFormat, qfrom[0])
+	if err != nil {
+		llog.Errorf("invalid from date format : %s", qfrom[0])
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid from date format", "invalid from date format", 1)
+		return
+	}
+	quntil := r.URL.Query()["until"]
+	if quntil == nil || len(quntil[0]) == 0 {
+		llog.Errorf("error missing until field")
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "missing until", "missing until", 1)
+		return
+	}
+	until, err = time.Parse(RestTimeFormat, quntil[0])
+	if err != nil {
+		llog.Errorf("invalid until date format : %s", quntil[0])
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid until date format", "invalid from date format", 1)
+		return
+	}
+
+	qpage := r.URL.Query()["page"]
+	if qpage == nil || len(qpage[0]) == 0 {
+		llog.Errorf("error missing page field")
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "missing from", "missing from", 1)
+		return
+	}
+	page, err = strconv.Atoi(qpage[0])
+	if err != nil {
+		llog.Errorf("invalid page number format : %s", qpage[0])
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid page number format", "invalid page number format", 1)
+		return
+	}
+
+	qsize := r.URL.Query()["size"]
+	if qsize == nil || len(qsize[0]) == 0 {
+		llog.Errorf("error missing size field")
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "missing until", "missing until", 1)
+		return
+	}
+	size, err = strconv.Atoi(qsize[0])
+	if err != nil {
+		llog.Errorf("invalid size number format : %s", qsize[0])
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid size number format", "invalid size number format", 1)
+		return
+	}
+
+	accountNo := m["AccountNumber"]
+	account, err := AccountMgr.GetAccountByID(r.Context(), accountNo)
+	if err != nil {
+		llog.Errorf("error while calling AccountMgr.GetAccountByID. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		return
+	}
+	if account == nil {
+		llog.Errorf("error account number not found : %s", accountNo)
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "backend error", err.Error(), 2)
+		return
+	}
+
+	str, err := TransactionMgr.RenderTransactionsOnAccount(r.Context(), from, until, account, acccore.PageRequest{
+		PageNo:   page,
+		ItemSize: size,
+	})
+	if err != nil {
+		llog.Errorf("error while calling TransactionMgr.RenderTransactionsOnAccount. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(str))
 }
 
-// AccountEntity is the structure of response body that contains an account
-type AccountEntity struct {
-	AccountNo   string `json:"account_number"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	COA         string `json:"coa"`
-	Currency    string `json:"currency"`
-	Alignment   string `json:"alignment"`
-	Balance     int64  `json:"balance"`
+// GetAccount is the controller to handle retrieval of single account
+func GetAccount(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetAccount")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/accounts/{AccountNumber}", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/accounts/{AccountNumber}. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	accountNo := m["AccountNumber"]
+	account, err := AccountMgr.GetAccountByID(r.Context(), accountNo)
+	if err != nil {
+		llog.Errorf("error while calling AccountMgr.GetAccountByID. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		return
+	}
+	if account == nil {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "account number not found", "account number not found", 3)
+		return
+	}
+	writeAccount(llog, w, r, account)
+}
+
+// writeAccount responds with the account together with its available balance, status and balance limits.
+func writeAccount(llog *logrus.Entry, w http.ResponseWriter, r *http.Request, account acccore.Account) {
+	status, err := AccountLifecycleMgr.GetAccountStatus(r.Context(), account.GetAccountNumber())
+	if err != nil {
+		llog.Errorf("error while calling AccountLifecycleMgr.GetAccountStatus. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		return
+	}
+	limits, err := AccountLifecycleMgr.GetAccountLimits(r.Context(), account.GetAccountNumber())
+	if err != nil {
+		llog.Errorf("error while calling AccountLifecycleMgr.GetAccountLimits. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		return
+	}
+	held, err := HoldMgr.GetHeldAmount(r.Context(), account.GetAccountNumber())
+	if err != nil {
+		llog.Errorf("error while calling HoldMgr.GetHeldAmount. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		return
+	}
+	ret := &AccountEntity{
+		AccountNo:   account.GetAccountNumber(),
+		Name:        account.GetName(),
+		Description: account.GetDescription(),
+		COA:         account.GetCOA(),
+		Currency:    account.GetCurrency(),
+		//Alignment:   account.GetBaseTransactionType(),
+		Balance:          account.GetBalance(),
+		AvailableBalance: account.GetBalance() - held,
+		Status:           string(status),
+		Limits: &AccountLimitsEntity{
+			AllowNegative: &limits.AllowNegative,
+			MinBalance:    limits.MinBalance,
+			MaxBalance:    limits.MaxBalance,
+		},
+	}
+	if account.GetAlignment() == acccore.DEBIT {
+		ret.Alignment = "DEBIT"
+	} else {
+		ret.Alignment = "CREDIT"
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "account "+account.GetAccountNumber(), ret, 0)
+}
+
+// UpdateAccount is the controller to change the name, description, COA or balance limits of an account
+func UpdateAccount(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "UpdateAccount")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/accounts/{AccountNumber}", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/accounts/{AccountNumber}. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	bodyByte, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		llog.Errorf("error while reading body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "error reading body", err.Error(), 0)
+		return
+	}
+	updateEnt := &UpdateAccountEntity{}
+	err = json.Unmarshal(bodyByte, updateEnt)
+	if err != nil {
+		llog.Errorf("error while parsing json body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+	if (updateEnt.Name != nil && len(*updateEnt.Name) == 0) || (updateEnt.Description != nil && len(*updateEnt.Description) == 0) || len(updateEnt.Author) == 0 {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "name and description can not be empty, author is required", 0)
+		return
+	}
+	if updateEnt.COA != nil {
+		account, err := AccountMgr.GetAccountByID(r.Context(), m["AccountNumber"])
+		if err != nil {
+			llog.Errorf("error while calling AccountMgr.GetAccountByID. got : %s", err.Error())
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+			return
+		}
+		if account == nil {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "account number not found", "account number not found", 3)
+			return
+		}
+		if err = COAMgr.CheckAccountCOA(r.Context(), *updateEnt.COA, account.GetAlignment()); err != nil {
+			writeAccountCOAError(llog, w, r, err)
+			return
+		}
+	}
+
+	account, err := AccountLifecycleMgr.UpdateAccountDetails(r.Context(), m["AccountNumber"], &AccountDetails{
+		Name:        updateEnt.Name,
+		Description: updateEnt.Description,
+		COA:         updateEnt.COA,
+		Limits:      updateEnt.Limits.toAccountLimits(),
+	}, updateEnt.Author)
+	if err != nil {
+		writeAccountLifecycleError(r.Context(), w, r, err)
+		return
+	}
+	writeAccount(llog, w, r, account)
+}
+
+// SetAccountStatus is the controller to freeze, unfreeze or close an account
+func SetAccountStatus(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "SetAccountStatus")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/accounts/{AccountNumber}/status", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/accounts/{AccountNumber}/status. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	bodyByte, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		llog.Errorf("error while reading body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "error reading body", err.Error(), 0)
+		return
+	}
+	statusEnt := &SetAccountStatusEntity{}
+	err = json.Unmarshal(bodyByte, statusEnt)
+	if err != nil {
+		llog.Errorf("error while parsing json body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+	status, err := ParseAccountStatus(statusEnt.Status)
+	if err != nil || len(statusEnt.Author) == 0 {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "status must be ACTIVE, FROZEN or CLOSED, author is required", 0)
+		return
+	}
+
+	err = AccountLifecycleMgr.SetAccountStatus(r.Context(), m["AccountNumber"], status, statusEnt.Author)
+	if err != nil {
+		writeAccountLifecycleError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "account "+m["AccountNumber"], string(status), 0)
+}
+
+// writeAccountLifecycleError responds to an account lifecycle manager error.
+func writeAccountLifecycleError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, acccore.ErrAccountIDNotFound):
+		helpers.HTTPResponseBuilder(ctx, w, r, 404, "account number not found", "account number not found", 3)
+	case errors.Is(err, hwerrors.ErrAccountStatusTransition), errors.Is(err, hwerrors.ErrAccountBalanceNotZero), errors.Is(err, hwerrors.ErrAccountHasHolds),
+		errors.Is(err, hwerrors.ErrAccountClosed):
+		helpers.HTTPResponseBuilder(ctx, w, r, 409, "account status conflict", err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrStringDataTooLong), errors.Is(err, hwerrors.ErrInvalidAccountLimits):
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "malformed request", err.Error(), 0)
+	default:
+		helpers.HTTPResponseBuilder(ctx, w, r, 500, "backend error", err.Error(), 2)
+	}
+}
+
+// PlaceHold is the controller to reserve an amount on an account
+func PlaceHold(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "PlaceHold")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/accounts/{AccountNumber}/holds", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/accounts/{AccountNumber}/holds. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	bodyByte, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		llog.Errorf("error while reading body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "error reading body", err.Error(), 0)
+		return
+	}
+	holdEnt := &PlaceHoldEntity{}
+	err = json.Unmarshal(bodyByte, holdEnt)
+	if err != nil {
+		llog.Errorf("error while parsing json body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+	if len(holdEnt.Creator) == 0 {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "creator is required", 0)
+		return
+	}
+	var expiresAt time.Time
+	if len(holdEnt.ExpiresAt) > 0 {
+		expiresAt, err = time.Parse(time.RFC3339, holdEnt.ExpiresAt)
+		if err != nil {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "expires_at should be an RFC3339 time", 0)
+			return
+		}
+	}
+
+	hold, err := HoldMgr.PlaceHold(r.Context(), m["AccountNumber"], holdEnt.Amount, holdEnt.Description, expiresAt, holdEnt.Creator)
+	if err != nil {
+		writeHoldError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "hold "+hold.HoldID, newHoldEntity(hold), 0)
+}
+
+// GetHold is the controller to retrieve a hold
+func GetHold(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetHold")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/holds/{HoldID}", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/holds/{HoldID}. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	hold, err := HoldMgr.GetHold(r.Context(), m["HoldID"])
+	if err != nil {
+		writeHoldError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "hold "+hold.HoldID, newHoldEntity(hold), 0)
+}
+
+// CaptureHold is the controller to post a held amount into a journal
+func CaptureHold(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "CaptureHold")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/holds/{HoldID}/capture", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/holds/{HoldID}/capture. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	bodyByte, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		llog.Errorf("error while reading body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "error reading body", err.Error(), 0)
+		return
+	}
+	captureEnt := &CaptureHoldEntity{}
+	err = json.Unmarshal(bodyByte, captureEnt)
+	if err != nil {
+		llog.Errorf("error while parsing json body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+	if len(captureEnt.AccountNumber) == 0 || len(captureEnt.Author) == 0 {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "account_number and author are required", 0)
+		return
+	}
+
+	hold, err := HoldMgr.CaptureHold(r.Context(), m["HoldID"], captureEnt.AccountNumber, captureEnt.Amount, captureEnt.Description, captureEnt.Author)
+	if err != nil {
+		writeHoldError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "hold "+hold.HoldID, newHoldEntity(hold), 0)
+}
+
+// ReleaseHold is the controller to give a held amount back to its account
+func ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "ReleaseHold")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/holds/{HoldID}/release", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/holds/{HoldID}/release. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	bodyByte, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		llog.Errorf("error while reading body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "error reading body", err.Error(), 0)
+		return
+	}
+	releaseEnt := &ReleaseHoldEntity{}
+	err = json.Unmarshal(bodyByte, releaseEnt)
+	if err != nil {
+		llog.Errorf("error while parsing json body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+	if len(releaseEnt.Author) == 0 {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "author is required", 0)
+		return
+	}
+
+	hold, err := HoldMgr.ReleaseHold(r.Context(), m["HoldID"], releaseEnt.Author)
+	if err != nil {
+		writeHoldError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "hold "+hold.HoldID, newHoldEntity(hold), 0)
+}
+
+// newHoldEntity returns the response body of the hold.
+func newHoldEntity(hold *Hold) *HoldEntity {
+	return &HoldEntity{
+		HoldID:         hold.HoldID,
+		AccountNumber:  hold.AccountNumber,
+		Amount:         hold.Amount,
+		Description:    hold.Description,
+		Status:         string(hold.Status),
+		ExpiresAt:      hold.ExpiresAt.Format(time.RFC3339),
+		CapturedAmount: hold.CapturedAmount,
+		JournalID:      hold.JournalID,
+		CreateTime:     hold.CreatedAt.Format(time.RFC3339),
+		CreateBy:       hold.CreatedBy,
+		UpdateTime:     hold.UpdatedAt.Format(time.RFC3339),
+		UpdateBy:       hold.UpdatedBy,
+	}
+}
+
+// writeHoldError responds to a hold manager error.
+func writeHoldError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	if writeRefusedPosting(ctx, w, r, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, hwerrors.ErrHoldNotFound):
+		helpers.HTTPResponseBuilder(ctx, w, r, 404, "hold not found", err.Error(), 3)
+	case errors.Is(err, acccore.ErrAccountIDNotFound):
+		helpers.HTTPResponseBuilder(ctx, w, r, 404, "account number not found", "account number not found", 3)
+	case errors.Is(err, hwerrors.ErrHoldNotHeld):
+		helpers.HTTPResponseBuilder(ctx, w, r, 409, "hold status conflict", err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrInvalidHold), errors.Is(err, hwerrors.ErrStringDataTooLong),
+		errors.Is(err, acccore.ErrJournalTransactionAccountNotPersist), errors.Is(err, acccore.ErrJournalTransactionMixCurrency):
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "malformed request", err.Error(), 0)
+	default:
+		helpers.HTTPResponseBuilder(ctx, w, r, 500, "backend error", err.Error(), 2)
+	}
+}
+
+// writeAccountCOAError responds to an error checking the COA of an account.
+func writeAccountCOAError(llog *logrus.Entry, w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, hwerrors.ErrCOANotFound) || errors.Is(err, hwerrors.ErrCOAAlignmentMismatch) {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid account coa", err.Error(), 0)
+		return
+	}
+	llog.Errorf("error while calling COAMgr.CheckAccountCOA. got : %s", err.Error())
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+}
+
+// CreateCOA is the controller to create a chart of accounts node
+func CreateCOA(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "CreateCOA")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	bodyByte, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		llog.Errorf("error while reading body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "error reading body", err.Error(), 0)
+		return
+	}
+	coaEnt := &NewCOAEntity{}
+	err = json.Unmarshal(bodyByte, coaEnt)
+	if err != nil {
+		llog.Errorf("error while parsing json body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+	if len(coaEnt.Creator) == 0 {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "creator is required", 0)
+		return
+	}
+	coaType, err := ParseCOAType(coaEnt.Type)
+	if err != nil {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "type must be ASSET, LIABILITY, EQUITY, INCOME or EXPENSE", 0)
+		return
+	}
+	alignment := coaType.NormalAlignment()
+	switch strings.ToUpper(coaEnt.Alignment) {
+	case "":
+	case "DEBIT":
+		alignment = acccore.DEBIT
+	case "CREDIT":
+		alignment = acccore.CREDIT
+	default:
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "alignment must be DEBIT or CREDIT", 0)
+		return
+	}
+
+	coa, err := COAMgr.CreateCOA(r.Context(), &COA{
+		Code:       coaEnt.Code,
+		ParentCode: coaEnt.ParentCode,
+		Name:       coaEnt.Name,
+		Type:       coaType,
+		Alignment:  alignment,
+	}, coaEnt.Creator)
+	if err != nil {
+		writeCOAError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "coa "+coa.Code, newCOAEntity(coa), 0)
+}
+
+// ListCOA is the controller to list the whole chart of accounts
+func ListCOA(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "ListCOA")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	coas, err := COAMgr.ListCOA(r.Context())
+	if err != nil {
+		writeCOAError(r.Context(), w, r, err)
+		return
+	}
+	ret := make([]*COAEntity, 0, len(coas))
+	for _, coa := range coas {
+		ret = append(ret, newCOAEntity(coa))
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "chart of accounts", ret, 0)
+}
+
+// GetCOA is the controller to retrieve a chart of accounts node
+func GetCOA(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetCOA")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/coa/{COACode}", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/coa/{COACode}. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	coa, err := COAMgr.GetCOA(r.Context(), m["COACode"])
+	if err != nil {
+		writeCOAError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "coa "+coa.Code, newCOAEntity(coa), 0)
+}
+
+// UpdateCOA is the controller to rename a chart of accounts node or move it under another parent
+func UpdateCOA(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "UpdateCOA")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/coa/{COACode}", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/coa/{COACode}. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	bodyByte, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		llog.Errorf("error while reading body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "error reading body", err.Error(), 0)
+		return
+	}
+	updateEnt := &UpdateCOAEntity{}
+	err = json.Unmarshal(bodyByte, updateEnt)
+	if err != nil {
+		llog.Errorf("error while parsing json body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+	if len(updateEnt.Author) == 0 {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "author is required", 0)
+		return
+	}
+
+	coa, err := COAMgr.UpdateCOA(r.Context(), m["COACode"], &COADetails{Name: updateEnt.Name, ParentCode: updateEnt.ParentCode}, updateEnt.Author)
+	if err != nil {
+		writeCOAError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "coa "+coa.Code, newCOAEntity(coa), 0)
+}
+
+// DeleteCOA is the controller to delete a chart of accounts node without child nodes or accounts
+func DeleteCOA(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "DeleteCOA")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/coa/{COACode}", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/coa/{COACode}. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	err = COAMgr.DeleteCOA(r.Context(), m["COACode"])
+	if err != nil {
+		writeCOAError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "coa "+m["COACode"], "deleted", 0)
+}
+
+// GetCOABalance is the controller to aggregate the balance of the accounts under a chart of accounts node and its descendants
+func GetCOABalance(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetCOABalance")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/coa/{COACode}/balance", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/coa/{COACode}/balance. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	coa, err := COAMgr.GetCOA(r.Context(), m["COACode"])
+	if err != nil {
+		writeCOAError(r.Context(), w, r, err)
+		return
+	}
+	balances, err := COAMgr.GetCOABalance(r.Context(), coa.Code)
+	if err != nil {
+		writeCOAError(r.Context(), w, r, err)
+		return
+	}
+	ret := &COABalanceEntity{
+		Code:      coa.Code,
+		Name:      coa.Name,
+		Type:      string(coa.Type),
+		Alignment: alignmentName(coa.Alignment),
+		Balances:  make([]*COABalanceItem, 0, len(balances)),
+	}
+	for _, balance := range balances {
+		ret.Balances = append(ret.Balances, &COABalanceItem{Currency: balance.Currency, Balance: balance.Balance, Accounts: balance.Accounts})
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "coa "+coa.Code+" balance", ret, 0)
+}
+
+// newCOAEntity returns the response body of the chart of accounts node.
+func newCOAEntity(coa *COA) *COAEntity {
+	return &COAEntity{
+		Code:       coa.Code,
+		ParentCode: coa.ParentCode,
+		Name:       coa.Name,
+		Type:       string(coa.Type),
+		Alignment:  alignmentName(coa.Alignment),
+		CreateTime: coa.CreatedAt.Format(time.RFC3339),
+		CreateBy:   coa.CreatedBy,
+		UpdateTime: coa.UpdatedAt.Format(time.RFC3339),
+		UpdateBy:   coa.UpdatedBy,
+	}
+}
+
+// writeCOAError responds to a chart of accounts manager error.
+func writeCOAError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, hwerrors.ErrCOANotFound):
+		helpers.HTTPResponseBuilder(ctx, w, r, 404, "coa not found", err.Error(), 3)
+	case errors.Is(err, hwerrors.ErrCOAExists), errors.Is(err, hwerrors.ErrCOAInUse):
+		helpers.HTTPResponseBuilder(ctx, w, r, 409, "coa conflict", err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrInvalidCOA), errors.Is(err, hwerrors.ErrStringDataTooLong):
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "malformed request", err.Error(), 0)
+	default:
+		helpers.HTTPResponseBuilder(ctx, w, r, 500, "backend error", err.Error(), 2)
+	}
+}
+
+// GetTrialBalance is the controller to report the total debit and credit of every account as of a point in time.
+// The format query parameter renders it as json, csv or text, json if it is not specified.
+func GetTrialBalance(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetTrialBalance")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	at, err := parseReportTime(r, "at", time.Now())
+	if err != nil {
+		llog.Errorf("invalid at date format : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid at date format", err.Error(), 1)
+		return
+	}
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if len(format) == 0 {
+		format = "json"
+	}
+	if format != "json" && format != "csv" && format != "text" {
+		llog.Errorf("invalid report format : %s", format)
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid format", "format should be json, csv or text", 1)
+		return
+	}
+	currency := r.URL.Query().Get("currency")
+	if len(currency) > 0 {
+		if _, err := RateMgr.GetCurrencyRate(r.Context(), currency); err != nil {
+			if err == sql.ErrNoRows || err == acccore.ErrCurrencyNotFound {
+				helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "currency not found", "currency not found", 1)
+				return
+			}
+			llog.Errorf("error while calling RateMgr.GetCurrencyRate. got : %s", err.Error())
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+			return
+		}
+	}
+
+	tb, err := ReportMgr.GetTrialBalance(r.Context(), at, currency)
+	if err != nil {
+		llog.Errorf("error while calling ReportMgr.GetTrialBalance. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		return
+	}
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.WriteHeader(http.StatusOK)
+		if err := tb.WriteCSV(w); err != nil {
+			llog.Errorf("error while writing the trial balance csv. got : %s", err.Error())
+		}
+	case "text":
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(tb.Render()))
+	default:
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "trial balance", newTrialBalanceEntity(tb), 0)
+	}
+}
+
+// newTrialBalanceEntity returns the response body of the trial balance.
+func newTrialBalanceEntity(tb *TrialBalance) *TrialBalanceEntity {
+	ret := &TrialBalanceEntity{
+		At:         tb.At.Format(time.RFC3339),
+		Currency:   tb.Currency,
+		Balanced:   tb.Balanced(),
+		Currencies: make([]*TrialBalanceCurrencyItem, 0, len(tb.Currencies)),
+	}
+	for _, currency := range tb.Currencies {
+		currencyItem := &TrialBalanceCurrencyItem{
+			Currency: currency.Currency,
+			Debit:    currency.Debit,
+			Credit:   currency.Credit,
+			Balanced: currency.Balanced(),
+			COAs:     make([]*TrialBalanceCOAItem, 0, len(currency.COAs)),
+		}
+		for _, group := range currency.COAs {
+			coaItem := &TrialBalanceCOAItem{
+				Code:     group.Code,
+				Name:     group.Name,
+				Debit:    group.Debit,
+				Credit:   group.Credit,
+				Accounts: make([]*TrialBalanceAccountItem, 0, len(group.Accounts)),
+			}
+			for _, account := range group.Accounts {
+				coaItem.Accounts = append(coaItem.Accounts, &TrialBalanceAccountItem{
+					AccountNumber: account.AccountNumber,
+					Name:          account.Name,
+					Alignment:     alignmentName(account.Alignment),
+					Debit:         account.Debit,
+					Credit:        account.Credit,
+					Balance:       account.Balance(),
+				})
+			}
+			currencyItem.COAs = append(currencyItem.COAs, coaItem)
+		}
+		ret.Currencies = append(ret.Currencies, currencyItem)
+	}
+	return ret
+}
+
+// GetBalanceSheet is the controller to report the assets, liabilities and equity as of a point in time
+func GetBalanceSheet(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetBalanceSheet")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	at, err := parseReportTime(r, "at", time.Now())
+	if err != nil {
+		llog.Errorf("invalid at date format : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid at date format", err.Error(), 1)
+		return
+	}
+	currency, reportingCurrency, ok := parseReportCurrencies(w, r)
+	if !ok {
+		return
+	}
+	bs, err := ReportMgr.GetBalanceSheet(r.Context(), at, currency, reportingCurrency)
+	if err != nil {
+		llog.Errorf("error while calling ReportMgr.GetBalanceSheet. got : %s", err.Error())
+		if errors.Is(err, hwerrors.ErrNoEffectiveRate) {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "exchange rate not found", err.Error(), 1)
+			return
+		}
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		return
+	}
+	ret := &BalanceSheetEntity{
+		At:                bs.At.Format(time.RFC3339),
+		Currency:          bs.Currency,
+		ReportingCurrency: bs.ReportingCurrency,
+		Balanced:          bs.Balanced(),
+		Statements:        make([]*StatementEntity, 0, len(bs.Statements)),
+	}
+	for _, statement := range bs.Statements {
+		balanced := statement.Balanced()
+		entity := newStatementEntity(statement)
+		entity.Balanced = &balanced
+		ret.Statements = append(ret.Statements, entity)
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "balance sheet", ret, 0)
+}
+
+// GetIncomeStatement is the controller to report the income and expenses of a period
+func GetIncomeStatement(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetIncomeStatement")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	from, err := parseReportTime(r, "from", time.Time{})
+	if err != nil {
+		llog.Errorf("invalid from date format : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid from date format", err.Error(), 1)
+		return
+	}
+	until, err := parseReportTime(r, "until", time.Now())
+	if err != nil {
+		llog.Errorf("invalid until date format : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid until date format", err.Error(), 1)
+		return
+	}
+	if until.Before(from) {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid period", "until is before from", 1)
+		return
+	}
+	currency, reportingCurrency, ok := parseReportCurrencies(w, r)
+	if !ok {
+		return
+	}
+	is, err := ReportMgr.GetIncomeStatement(r.Context(), from, until, currency, reportingCurrency)
+	if err != nil {
+		llog.Errorf("error while calling ReportMgr.GetIncomeStatement. got : %s", err.Error())
+		if errors.Is(err, hwerrors.ErrNoEffectiveRate) {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "exchange rate not found", err.Error(), 1)
+			return
+		}
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		return
+	}
+	ret := &IncomeStatementEntity{
+		From:              is.From.Format(time.RFC3339),
+		Until:             is.Until.Format(time.RFC3339),
+		Currency:          is.Currency,
+		ReportingCurrency: is.ReportingCurrency,
+		Statements:        make([]*StatementEntity, 0, len(is.Statements)),
+	}
+	for _, statement := range is.Statements {
+		ret.Statements = append(ret.Statements, newStatementEntity(statement))
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "income statement", ret, 0)
+}
+
+// parseReportTime parses the time query parameter of a report, the default time if it is not specified.
+func parseReportTime(r *http.Request, name string, defaultTime time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if len(value) == 0 {
+		return defaultTime, nil
+	}
+	t, err := time.Parse(RestTimeFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s should be in YYYY-MM-DDTHH:MM:SS format. got %s", name, value)
+	}
+	return t, nil
+}
+
+// parseReportCurrencies reads the optional currency and reporting_currency query parameters of a report.
+// It responds 404 and returns false if any of them does not exist.
+func parseReportCurrencies(w http.ResponseWriter, r *http.Request) (currency, reportingCurrency string, ok bool) {
+	currency = r.URL.Query().Get("currency")
+	reportingCurrency = r.URL.Query().Get("reporting_currency")
+	for _, code := range []string{currency, reportingCurrency} {
+		if len(code) == 0 {
+			continue
+		}
+		if _, err := RateMgr.GetCurrencyRate(r.Context(), code); err != nil {
+			if err == sql.ErrNoRows || err == acccore.ErrCurrencyNotFound {
+				helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "currency not found", "currency not found : "+code, 1)
+				return "", "", false
+			}
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+			return "", "", false
+		}
+	}
+	return currency, reportingCurrency, true
+}
+
+// newStatementEntity returns the response body of the financial statement.
+func newStatementEntity(statement *FinancialStatement) *StatementEntity {
+	ret := &StatementEntity{
+		Currency:  statement.Currency,
+		NetIncome: statement.NetIncome,
+		Sections:  make([]*StatementSectionEntity, 0, len(statement.Sections)),
+	}
+	for _, section := range statement.Sections {
+		sectionEntity := &StatementSectionEntity{Type: string(section.Type), Total: section.Total, Lines: make([]*StatementLineEntity, 0, len(section.Lines))}
+		for _, line := range section.Lines {
+			sectionEntity.Lines = append(sectionEntity.Lines, &StatementLineEntity{
+				Code:       line.Code,
+				Name:       line.Name,
+				ParentCode: line.ParentCode,
+				Level:      line.Level,
+				Amount:     line.Amount,
+			})
+		}
+		ret.Sections = append(ret.Sections, sectionEntity)
+	}
+	return ret
+}
+
+// GetIntegrity replays the transactions of every account and lists the discrepancies found
+func GetIntegrity(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetIntegrity")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	report, err := IntegrityMgr.VerifyIntegrity(r.Context())
+	if err != nil {
+		llog.Errorf("error while calling IntegrityMgr.VerifyIntegrity. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		return
+	}
+	if !report.Consistent() {
+		llog.Warnf("integrity verification found %d discrepancies", len(report.Discrepancies))
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "integrity report", NewIntegrityReportEntity(report), 0)
+}
+
+// NewIntegrityReportEntity returns the response body of the integrity report, the verify command prints it too.
+func NewIntegrityReportEntity(report *IntegrityReport) *IntegrityReportEntity {
+	ret := &IntegrityReportEntity{
+		CheckedAt:     report.CheckedAt.Format(time.RFC3339),
+		Consistent:    report.Consistent(),
+		Accounts:      report.Accounts,
+		Transactions:  report.Transactions,
+		Discrepancies: make([]*DiscrepancyItem, 0, len(report.Discrepancies)),
+	}
+	for _, discrepancy := range report.Discrepancies {
+		ret.Discrepancies = append(ret.Discrepancies, &DiscrepancyItem{
+			Kind:          string(discrepancy.Kind),
+			AccountNumber: discrepancy.AccountNumber,
+			JournalID:     discrepancy.JournalID,
+			TransactionID: discrepancy.TransactionID,
+			Currency:      discrepancy.Currency,
+			Expected:      discrepancy.Expected,
+			Actual:        discrepancy.Actual,
+			Description:   discrepancy.Description,
+		})
+	}
+	return ret
+}
+
+// GetJournalChain verifies the hash chain of the journals booked within the period and points the first broken link
+func GetJournalChain(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetJournalChain")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	from, err := parseReportTime(r, "from", time.Time{})
+	if err != nil {
+		llog.Errorf("invalid from date format : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid from date format", err.Error(), 1)
+		return
+	}
+	until, err := parseReportTime(r, "until", time.Now())
+	if err != nil {
+		llog.Errorf("invalid until date format : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid until date format", err.Error(), 1)
+		return
+	}
+	if until.Before(from) {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid period", "until is before from", 1)
+		return
+	}
+	verification, err := JournalChainMgr.VerifyJournalChain(r.Context(), from, until)
+	if err != nil {
+		llog.Errorf("error while calling JournalChainMgr.VerifyJournalChain. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		return
+	}
+	ret := &JournalChainEntity{
+		From:          verification.From.Format(RestTimeFormat),
+		Until:         verification.Until.Format(RestTimeFormat),
+		Valid:         verification.Valid(),
+		FirstSequence: verification.FirstSequence,
+		LastSequence:  verification.LastSequence,
+		Journals:      verification.Journals,
+	}
+	if link := verification.BrokenLink; link != nil {
+		llog.Warnf("journal chain is broken at sequence %d : %s", link.Sequence, link.Reason)
+		ret.BrokenLink = &BrokenChainLinkEntity{
+			Sequence:  link.Sequence,
+			JournalID: link.JournalID,
+			Reason:    string(link.Reason),
+			Expected:  link.Expected,
+			Actual:    link.Actual,
+		}
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "journal chain verification", ret, 0)
+}
+
+// ListPeriods lists the accounting periods closed on their own
+func ListPeriods(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "ListPeriods")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	periods, err := PeriodMgr.ListPeriods(r.Context())
+	if err != nil {
+		writePeriodError(r.Context(), w, r, err)
+		return
+	}
+	ret := make([]*PeriodEntity, 0, len(periods))
+	for _, period := range periods {
+		ret = append(ret, newPeriodEntity(period))
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "list of closed periods", ret, 0)
+}
+
+// GetPeriod gets an accounting period, OPEN or CLOSED
+func GetPeriod(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetPeriod")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/periods/{Period}", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/periods/{Period}. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	period, err := PeriodMgr.GetPeriod(r.Context(), m["Period"])
+	if err != nil {
+		writePeriodError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "period "+period.Period, newPeriodEntity(period), 0)
+}
+
+// ClosePeriod closes an accounting period and every period before it, rolling the income and expenses into retained earnings
+func ClosePeriod(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "ClosePeriod")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/periods/{Period}/close", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/periods/{Period}/close. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	bodyByte, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		llog.Errorf("error while reading body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "error reading body", err.Error(), 0)
+		return
+	}
+	closeEnt := &ClosePeriodEntity{}
+	err = json.Unmarshal(bodyByte, closeEnt)
+	if err != nil {
+		llog.Errorf("error while parsing json body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+	if len(closeEnt.Creator) == 0 {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "creator is required", 0)
+		return
+	}
+
+	period, err := PeriodMgr.ClosePeriod(r.Context(), m["Period"], closeEnt.Creator)
+	if err != nil {
+		writePeriodError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "period "+period.Period, newPeriodEntity(period), 0)
+}
+
+// newPeriodEntity returns the response body of the accounting period.
+func newPeriodEntity(period *Period) *PeriodEntity {
+	ret := &PeriodEntity{
+		Period:            period.Period,
+		Status:            string(period.Status),
+		Start:             period.Start.Format(time.RFC3339),
+		End:               period.End.Format(time.RFC3339),
+		ClosingJournalIDs: period.ClosingJournalIDs,
+		ClosedBy:          period.ClosedBy,
+	}
+	if !period.ClosedAt.IsZero() {
+		ret.ClosedAt = period.ClosedAt.Format(time.RFC3339)
+	}
+	return ret
+}
+
+// writePeriodError responds with the status matching the period manager error.
+func writePeriodError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, hwerrors.ErrInvalidPeriod):
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "malformed request", err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrPeriodClosed), errors.Is(err, hwerrors.ErrPeriodNotEnded):
+		helpers.HTTPResponseBuilder(ctx, w, r, 409, "period status conflict", err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrRetainedEarningsNotConfigured):
+		helpers.HTTPResponseBuilder(ctx, w, r, 422, "retained earnings not configured", err.Error(), 0)
+	case writeRefusedPosting(ctx, w, r, err):
+	default:
+		helpers.HTTPResponseBuilder(ctx, w, r, 500, "backend error", err.Error(), 2)
+	}
+}
+
+// CreateTemplate is the controller to create a journal template
+func CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "CreateTemplate")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	bodyByte, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		llog.Errorf("error while reading body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "error reading body", err.Error(), 0)
+		return
+	}
+	templateEnt := &NewTemplateEntity{}
+	err = json.Unmarshal(bodyByte, templateEnt)
+	if err != nil {
+		llog.Errorf("error while parsing json body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+	if len(templateEnt.Creator) == 0 {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "creator is required", 0)
+		return
+	}
+	template := &JournalTemplate{
+		Name:        templateEnt.Name,
+		Description: templateEnt.Description,
+		Params:      make([]*TemplateParam, 0, len(templateEnt.Params)),
+		Legs:        make([]*TemplateLeg, 0, len(templateEnt.Legs)),
+	}
+	for _, param := range templateEnt.Params {
+		paramType, err := ParseTemplateParamType(param.Type)
+		if err != nil {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "parameter type must be ACCOUNT, AMOUNT, PERCENTAGE or TEXT", 0)
+			return
+		}
+		template.Params = append(template.Params, &TemplateParam{Name: param.Name, Type: paramType, Description: param.Description})
+	}
+	for _, leg := range templateEnt.Legs {
+		alignment, err := ParseAlignment(leg.Alignment)
+		if err != nil {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid alignment", fmt.Sprintf("leg on account %s : alignment must be DEBIT or CREDIT", leg.Account), 0)
+			return
+		}
+		template.Legs = append(template.Legs, &TemplateLeg{Account: leg.Account, Alignment: alignment, Amount: leg.Amount, Description: leg.Description})
+	}
+
+	created, err := TemplateMgr.CreateTemplate(r.Context(), template, templateEnt.Creator)
+	if err != nil {
+		writeTemplateError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "template "+created.Name, newTemplateEntity(created), 0)
+}
+
+// ListTemplates is the controller to list every journal template
+func ListTemplates(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "ListTemplates")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	templates, err := TemplateMgr.ListTemplates(r.Context())
+	if err != nil {
+		writeTemplateError(r.Context(), w, r, err)
+		return
+	}
+	ret := make([]*TemplateEntity, 0, len(templates))
+	for _, template := range templates {
+		ret = append(ret, newTemplateEntity(template))
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "journal templates", ret, 0)
+}
+
+// GetTemplate is the controller to retrieve a journal template
+func GetTemplate(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetTemplate")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/templates/{TemplateName}", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/templates/{TemplateName}. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	template, err := TemplateMgr.GetTemplate(r.Context(), m["TemplateName"])
+	if err != nil {
+		writeTemplateError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "template "+template.Name, newTemplateEntity(template), 0)
+}
+
+// DeleteTemplate is the controller to delete a journal template, the journals made from it are left as they are
+func DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "DeleteTemplate")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/templates/{TemplateName}", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/templates/{TemplateName}. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	err = TemplateMgr.DeleteTemplate(r.Context(), m["TemplateName"])
+	if err != nil {
+		writeTemplateError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "template "+m["TemplateName"], "deleted", 0)
+}
+
+// ExecuteTemplate is the controller to make a journal from a journal template with the values of its parameters
+// and post it, like a journal creation request
+func ExecuteTemplate(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "ExecuteTemplate")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/templates/{TemplateName}/execute", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/templates/{TemplateName}/execute. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	bodyByte, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		llog.Errorf("error while reading body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "error reading body", err.Error(), 0)
+		return
+	}
+	executeEnt := &ExecuteTemplateEntity{}
+	err = json.Unmarshal(bodyByte, executeEnt)
+	if err != nil {
+		llog.Errorf("error while parsing json body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+
+	idempotencyKey, requestHash, done := checkIdempotencyKey(r.Context(), w, r, executeEnt)
+	if done {
+		return
+	}
+	reqBod, err := TemplateMgr.NewJournalRequest(r.Context(), m["TemplateName"], executeEnt.Params)
+	if err != nil {
+		writeTemplateError(r.Context(), w, r, err)
+		return
+	}
+	reqBod.Creator, reqBod.EffectiveTime = executeEnt.Creator, executeEnt.EffectiveTime
+	postJournal(w, r, reqBod, idempotencyKey, requestHash)
 }
 
-// PaginatedResponse is the structure of stuff that requires pagination
-type PaginatedResponse struct {
-	Items      interface{}
-	Pagination acccore.PageResult
+// newTemplateEntity returns the response body of the journal template.
+func newTemplateEntity(template *JournalTemplate) *TemplateEntity {
+	ret := &TemplateEntity{
+		Name:        template.Name,
+		Description: template.Description,
+		Params:      make([]*TemplateParamEntity, 0, len(template.Params)),
+		Legs:        make([]*TemplateLegEntity, 0, len(template.Legs)),
+		CreateTime:  template.CreatedAt.Format(time.RFC3339),
+		CreateBy:    template.CreatedBy,
+	}
+	for _, param := range template.Params {
+		ret.Params = append(ret.Params, &TemplateParamEntity{Name: param.Name, Type: string(param.Type), Description: param.Description})
+	}
+	for _, leg := range template.Legs {
+		ret.Legs = append(ret.Legs, &TemplateLegEntity{Account: leg.Account, Alignment: alignmentName(leg.Alignment), Amount: leg.Amount,
+			Description: leg.Description})
+	}
+	return ret
 }
 
-// TransactionListResponse is the structure response
-type TransactionListResponse struct {
-	Transactions []*TransactionListItem `json:"transactions"`
-	Pagination   acccore.PageResult     `json:"pagination"`
+// writeTemplateError responds to a template manager error.
+func writeTemplateError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, hwerrors.ErrTemplateNotFound):
+		helpers.HTTPResponseBuilder(ctx, w, r, 404, "template not found", err.Error(), 3)
+	case errors.Is(err, hwerrors.ErrTemplateExists):
+		helpers.HTTPResponseBuilder(ctx, w, r, 409, "template conflict", err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrTemplateInUse):
+		helpers.HTTPResponseBuilder(ctx, w, r, 409, "template in use", err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrInvalidTemplateParams):
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "invalid template parameters", err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrInvalidTemplate), errors.Is(err, hwerrors.ErrStringDataTooLong):
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "malformed request", err.Error(), 0)
+	default:
+		helpers.HTTPResponseBuilder(ctx, w, r, 500, "backend error", err.Error(), 2)
+	}
 }
 
-// DrawAccount draws the account activity
-func DrawAccount(w http.ResponseWriter, r *http.Request) {
+// CreateTransfer is the controller to move an amount from an account to another, charging the fee of the fee
+// schedule on top of the amount into the fee account of their currency. It answers the journal of the transfer
+// together with the balances of both accounts right after it.
+func CreateTransfer(w http.ResponseWriter, r *http.Request) {
 	requestID := r.Context().Value(contextkeys.XRequestID).(string)
-	llog := restLog.WithField("RequestID", requestID).WithField("function", "ListTransactionByAccount")
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "CreateTransfer")
 	if r.Context().Err() != nil {
 		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
 		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
 		return
 	}
 
-	m, err := helpers.ParsePathParams("/api/v1/accounts/{AccountNumber}/draw", r.URL.Path)
+	bodyByte, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		llog.Errorf("error while processing path template /api/v1/accounts/{AccountNumber}/draw. got : %s", err.Error())
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		llog.Errorf("error while reading body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "error reading body", err.Error(), 0)
 		return
 	}
-
-	var from, until time.Time
-	var page, size int
-
-	qfrom := r.URL.Query()["from"]
-	if qfrom == nil || len(qfrom[0]) == 0 {
-		llog.Errorf("error missing from field")
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "missing from", "missing from", 1)
+	transferEnt := &NewTransferEntity{}
+	err = json.Unmarshal(bodyByte, transferEnt)
+	if err != nil {
+		llog.Errorf("error while parsing json body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
 		return
 	}
-	from, err = time.Parse(RestTimeFormat, qfrom[0])
-	if err != nil {
-		llog.Errorf("invalid from date format : %s", qfrom[0])
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid from date format", "invalid from date format", 1)
+	if len(transferEnt.Creator) == 0 {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "creator is required", 0)
 		return
 	}
-	quntil := r.URL.Query()["until"]
-	if quntil == nil || len(quntil[0]) == 0 {
-		llog.Errorf("error missing until field")
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "missing until", "missing until", 1)
+	transfer := &Transfer{
+		FromAccount: transferEnt.FromAccount,
+		ToAccount:   transferEnt.ToAccount,
+		Amount:      transferEnt.Amount,
+		Description: transferEnt.Description,
+	}
+	if fee := transferEnt.Fee; fee != nil {
+		transfer.Fee = &FeeSchedule{Fixed: fee.Fixed, Minimum: fee.Minimum, Maximum: fee.Maximum}
+		if len(fee.Percentage) > 0 {
+			percentage, ok := new(big.Rat).SetString(fee.Percentage.String())
+			if !ok {
+				helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid transfer", "fee percentage should be a decimal number", 0)
+				return
+			}
+			transfer.Fee.Percentage = percentage
+		}
+	}
+
+	idempotencyKey, requestHash, done := hashIdempotentRequest(r.Context(), w, r, transferEnt)
+	if done || (len(idempotencyKey) > 0 && replayTransfer(r.Context(), w, r, idempotencyKey, requestHash, transferEnt)) {
 		return
 	}
-	until, err = time.Parse(RestTimeFormat, quntil[0])
+	reqBod, err := TransferMgr.PrepareTransfer(r.Context(), transfer)
 	if err != nil {
-		llog.Errorf("invalid until date format : %s", quntil[0])
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid until date format", "invalid from date format", 1)
+		writeTransferError(r.Context(), w, r, err)
 		return
 	}
+	reqBod.Creator, reqBod.EffectiveTime = transferEnt.Creator, transferEnt.EffectiveTime
 
-	qpage := r.URL.Query()["page"]
-	if qpage == nil || len(qpage[0]) == 0 {
-		llog.Errorf("error missing page field")
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "missing from", "missing from", 1)
+	journalContext := context.WithValue(r.Context(), contextkeys.UserIDContextKey, reqBod.Creator)
+	toPersist, err := newJournalFromRequest(journalContext, reqBod)
+	if err != nil {
+		var requestErr *journalRequestError
+		if errors.As(err, &requestErr) {
+			helpers.HTTPResponseBuilder(journalContext, w, r, 400, requestErr.message, err.Error(), 0)
+			return
+		}
+		if !writeRefusedPosting(journalContext, w, r, err) {
+			helpers.HTTPResponseBuilder(journalContext, w, r, 500, "backend error", err.Error(), 2)
+		}
 		return
 	}
-	page, err = strconv.Atoi(qpage[0])
+	err = persistJournal(journalContext, toPersist, idempotencyKey, requestHash, reqBod.Creator)
 	if err != nil {
-		llog.Errorf("invalid page number format : %s", qpage[0])
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid page number format", "invalid page number format", 1)
+		if errors.Is(err, hwerrors.ErrIdempotencyKeyExists) {
+			if !replayTransfer(journalContext, w, r, idempotencyKey, requestHash, transferEnt) {
+				helpers.HTTPResponseBuilder(journalContext, w, r, 409, "idempotency key conflict", err.Error(), 0)
+			}
+			return
+		}
+		if writeRefusedPosting(journalContext, w, r, err) {
+			return
+		}
+		helpers.HTTPResponseBuilder(journalContext, w, r, 400, "invalid transfer", err.Error(), 0)
 		return
 	}
+	writeTransfer(journalContext, w, r, toPersist.GetJournalID(), transferEnt)
+}
 
-	qsize := r.URL.Query()["size"]
-	if qsize == nil || len(qsize[0]) == 0 {
-		llog.Errorf("error missing size field")
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "missing until", "missing until", 1)
+// replayTransfer answers a transfer whose idempotency key was used by an earlier transfer with the journal of the
+// earlier transfer, or a 409 if the earlier transfer had a different payload.
+// It returns false without writing anything if the key is not used within the idempotency window.
+func replayTransfer(ctx context.Context, w http.ResponseWriter, r *http.Request, key, requestHash string, transferEnt *NewTransferEntity) bool {
+	rec, done := findIdempotencyRecord(ctx, w, r, key, requestHash)
+	if rec == nil {
+		return done
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	writeTransfer(ctx, w, r, rec.JournalID, transferEnt)
+	return true
+}
+
+// writeTransfer answers a transfer with its journal, read back with the balances its transactions left the accounts with.
+func writeTransfer(ctx context.Context, w http.ResponseWriter, r *http.Request, journalID string, transferEnt *NewTransferEntity) {
+	journal, err := JournalMgr.GetJournalByID(ctx, journalID)
+	if err != nil || journal == nil {
+		helpers.HTTPResponseBuilder(ctx, w, r, 500, "backend error", fmt.Sprintf("transfer journal %s can not be read back", journalID), 2)
+		return
+	}
+	ret := &TransferEntity{
+		JournalID:   journalID,
+		FromAccount: &TransferAccountEntity{AccountNumber: transferEnt.FromAccount},
+		ToAccount:   &TransferAccountEntity{AccountNumber: transferEnt.ToAccount},
+		Journal:     newJournalDetail(journal),
+	}
+	for _, trx := range journal.GetTransactions() {
+		switch trx.GetAccountNumber() {
+		case transferEnt.FromAccount:
+			ret.FromAccount.Balance = trx.GetAccountBalance()
+			ret.Fee += trx.GetAmount()
+		case transferEnt.ToAccount:
+			ret.ToAccount.Balance = trx.GetAccountBalance()
+			ret.Amount = trx.GetAmount()
+			ret.Fee -= trx.GetAmount()
+		}
+	}
+	helpers.HTTPResponseBuilder(ctx, w, r, 200, "transfer "+journalID, ret, 0)
+}
+
+// writeTransferError responds to a transfer manager error.
+func writeTransferError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, acccore.ErrAccountIDNotFound):
+		helpers.HTTPResponseBuilder(ctx, w, r, 404, "account not found", err.Error(), 3)
+	case errors.Is(err, hwerrors.ErrInvalidTransfer):
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "invalid transfer", err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrTransferFeeNotConfigured):
+		helpers.HTTPResponseBuilder(ctx, w, r, 422, "transfer fee not configured", err.Error(), 0)
+	default:
+		helpers.HTTPResponseBuilder(ctx, w, r, 500, "backend error", err.Error(), 2)
+	}
+}
+
+// CreateSchedule is the controller to create a journal schedule, posting a journal template with the values of its
+// parameters on the times of a cron expression
+func CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "CreateSchedule")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
 		return
 	}
-	size, err = strconv.Atoi(qsize[0])
+
+	bodyByte, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		llog.Errorf("invalid size number format : %s", qsize[0])
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid size number format", "invalid size number format", 1)
+		llog.Errorf("error while reading body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "error reading body", err.Error(), 0)
+		return
+	}
+	scheduleEnt := &NewScheduleEntity{}
+	err = json.Unmarshal(bodyByte, scheduleEnt)
+	if err != nil {
+		llog.Errorf("error while parsing json body. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+	if len(scheduleEnt.Creator) == 0 {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "creator is required", 0)
 		return
 	}
+	var start time.Time
+	if len(scheduleEnt.StartTime) > 0 {
+		if start, err = time.Parse(RestTimeFormat, scheduleEnt.StartTime); err != nil {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid start time", "start time should be written as YYYY-MM-DDTHH:MM:SS", 0)
+			return
+		}
+	}
 
-	accountNo := m["AccountNumber"]
-	account, err := AccountMgr.GetAccountByID(r.Context(), accountNo)
+	created, err := ScheduleMgr.CreateSchedule(r.Context(), &Schedule{
+		TemplateName: scheduleEnt.TemplateName,
+		Cron:         scheduleEnt.Cron,
+		TimeZone:     scheduleEnt.TimeZone,
+		Params:       scheduleEnt.Params,
+	}, start, scheduleEnt.Creator)
 	if err != nil {
-		llog.Errorf("error while calling AccountMgr.GetAccountByID. got : %s", err.Error())
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		writeScheduleError(r.Context(), w, r, err)
 		return
 	}
-	if account == nil {
-		llog.Errorf("error account number not found : %s", accountNo)
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "backend error", err.Error(), 2)
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "schedule "+created.ScheduleID, newScheduleEntity(created), 0)
+}
+
+// ListSchedules is the controller to list every journal schedule
+func ListSchedules(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "ListSchedules")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
 		return
 	}
 
-	str, err := TransactionMgr.RenderTransactionsOnAccount(r.Context(), from, until, account, acccore.PageRequest{
-		PageNo:   page,
-		ItemSize: size,
-	})
+	schedules, err := ScheduleMgr.ListSchedules(r.Context())
 	if err != nil {
-		llog.Errorf("error while calling TransactionMgr.RenderTransactionsOnAccount. got : %s", err.Error())
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		writeScheduleError(r.Context(), w, r, err)
 		return
 	}
+	ret := make([]*ScheduleEntity, 0, len(schedules))
+	for _, schedule := range schedules {
+		ret = append(ret, newScheduleEntity(schedule))
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "journal schedules", ret, 0)
+}
 
-	w.Header().Set("Content-Type", "text/plain")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(str))
+// GetSchedule is the controller to retrieve a journal schedule
+func GetSchedule(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetSchedule")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/schedules/{ScheduleID}", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/schedules/{ScheduleID}. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	schedule, err := ScheduleMgr.GetSchedule(r.Context(), m["ScheduleID"])
+	if err != nil {
+		writeScheduleError(r.Context(), w, r, err)
+		return
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "schedule "+schedule.ScheduleID, newScheduleEntity(schedule), 0)
 }
 
-// GetAccount is the controller to handle retrieval of single account
-func GetAccount(w http.ResponseWriter, r *http.Request) {
+// DeleteSchedule is the controller to delete a journal schedule and its run history, the journals it posted are
+// left as they are
+func DeleteSchedule(w http.ResponseWriter, r *http.Request) {
 	requestID := r.Context().Value(contextkeys.XRequestID).(string)
-	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetAccount")
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "DeleteSchedule")
 	if r.Context().Err() != nil {
 		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
 		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
 		return
 	}
 
-	m, err := helpers.ParsePathParams("/api/v1/accounts/{AccountNumber}", r.URL.Path)
+	m, err := helpers.ParsePathParams("/api/v1/schedules/{ScheduleID}", r.URL.Path)
 	if err != nil {
-		llog.Errorf("error while processing path template /api/v1/accounts/{AccountNumber}. got : %s", err.Error())
+		llog.Errorf("error while processing path template /api/v1/schedules/{ScheduleID}. got : %s", err.Error())
 		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
 		return
 	}
-	accountNo := m["AccountNumber"]
-	account, err := AccountMgr.GetAccountByID(r.Context(), accountNo)
+	err = ScheduleMgr.DeleteSchedule(r.Context(), m["ScheduleID"])
 	if err != nil {
-		llog.Errorf("error while calling AccountMgr.GetAccountByID. got : %s", err.Error())
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
+		writeScheduleError(r.Context(), w, r, err)
 		return
 	}
-	if account == nil {
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "account number not found", "account number not found", 3)
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "schedule "+m["ScheduleID"], "deleted", 0)
+}
+
+// ListScheduleRuns is the controller to list the run history of a journal schedule, latest occurrence first
+func ListScheduleRuns(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "ListScheduleRuns")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
 		return
 	}
-	ret := &AccountEntity{
-		AccountNo:   account.GetAccountNumber(),
-		Name:        account.GetName(),
-		Description: account.GetDescription(),
-		COA:         account.GetCOA(),
-		Currency:    account.GetCurrency(),
-		//Alignment:   account.GetBaseTransactionType(),
-		Balance: account.GetBalance(),
+
+	m, err := helpers.ParsePathParams("/api/v1/schedules/{ScheduleID}/runs", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/schedules/{ScheduleID}/runs. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
 	}
-	if account.GetAlignment() == acccore.DEBIT {
-		ret.Alignment = "DEBIT"
-	} else {
-		ret.Alignment = "CREDIT"
+	pageA, pOk := r.URL.Query()["page"]
+	sizeA, sOk := r.URL.Query()["size"]
+	if !pOk || !sOk {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid request", "either page or size is missing", 0)
+		return
+	}
+	page, perr := strconv.Atoi(pageA[0])
+	size, serr := strconv.Atoi(sizeA[0])
+	if perr != nil || serr != nil {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid request", "either page, size is not number", 0)
+		return
+	}
+
+	pr, runs, err := ScheduleMgr.ListRuns(r.Context(), m["ScheduleID"], acccore.PageRequest{
+		PageNo:   page,
+		ItemSize: size,
+	})
+	if err != nil {
+		writeScheduleError(r.Context(), w, r, err)
+		return
+	}
+	ret := &PaginatedScheduleRunsResponse{Runs: make([]*ScheduleRunEntity, 0, len(runs)), Pagination: pr}
+	for _, run := range runs {
+		ret.Runs = append(ret.Runs, newScheduleRunEntity(run))
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "schedule "+m["ScheduleID"]+" runs", ret, 0)
+}
+
+// newScheduleEntity returns the response body of the journal schedule.
+func newScheduleEntity(schedule *Schedule) *ScheduleEntity {
+	return &ScheduleEntity{
+		ScheduleID:   schedule.ScheduleID,
+		TemplateName: schedule.TemplateName,
+		Cron:         schedule.Cron,
+		TimeZone:     schedule.TimeZone,
+		Params:       schedule.Params,
+		NextRunTime:  schedule.NextRunAt.Format(time.RFC3339),
+		CreateTime:   schedule.CreatedAt.Format(time.RFC3339),
+		CreateBy:     schedule.CreatedBy,
+	}
+}
+
+// newScheduleRunEntity returns the response body of the journal schedule run.
+func newScheduleRunEntity(run *ScheduleRun) *ScheduleRunEntity {
+	ret := &ScheduleRunEntity{
+		RunID:         run.RunID,
+		ScheduledTime: run.ScheduledAt.Format(time.RFC3339),
+		StartTime:     run.StartedAt.Format(time.RFC3339),
+		Status:        string(run.Status),
+		JournalID:     run.JournalID,
+		Error:         run.Error,
+		Runner:        run.Runner,
+	}
+	if !run.FinishedAt.IsZero() {
+		ret.FinishTime = run.FinishedAt.Format(time.RFC3339)
+	}
+	return ret
+}
+
+// writeScheduleError responds to a schedule manager error.
+func writeScheduleError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, hwerrors.ErrScheduleNotFound):
+		helpers.HTTPResponseBuilder(ctx, w, r, 404, "schedule not found", err.Error(), 3)
+	case errors.Is(err, hwerrors.ErrTemplateNotFound):
+		helpers.HTTPResponseBuilder(ctx, w, r, 404, "template not found", err.Error(), 3)
+	case errors.Is(err, hwerrors.ErrInvalidTemplateParams):
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "invalid template parameters", err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrInvalidSchedule), errors.Is(err, hwerrors.ErrStringDataTooLong):
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "invalid schedule", err.Error(), 0)
+	default:
+		helpers.HTTPResponseBuilder(ctx, w, r, 500, "backend error", err.Error(), 2)
 	}
-	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "account "+account.GetAccountNumber(), ret, 0)
 }
 
 // ListTransactionByAccount lists transactions given an account
@@ -291,6 +2458,12 @@ func ListTransactionByAccount(w http.ResponseWriter, r *http.Request) {
 		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid size number format", "invalid size number format", 1)
 		return
 	}
+	basis, err := ParseDateBasis(r.URL.Query().Get("date"))
+	if err != nil {
+		llog.Errorf("invalid date basis : %s", r.URL.Query().Get("date"))
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid date basis", err.Error(), 1)
+		return
+	}
 
 	accountNo := m["AccountNumber"]
 	account, err := AccountMgr.GetAccountByID(r.Context(), accountNo)
@@ -305,12 +2478,12 @@ func ListTransactionByAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, transactions, err := TransactionMgr.ListTransactionsOnAccount(r.Context(), from, until, account, acccore.PageRequest{
+	pr, transactions, err := PostingDateMgr.ListTransactionsOnAccountByDate(r.Context(), basis, from, until, account, acccore.PageRequest{
 		PageNo:   page,
 		ItemSize: size,
 	})
 	if err != nil {
-		llog.Errorf("error while calling TransactionMgr.ListTransactionsOnAccount. got : %s", err.Error())
+		llog.Errorf("error while calling PostingDateMgr.ListTransactionsOnAccountByDate. got : %s", err.Error())
 		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "backend error", err.Error(), 2)
 		return
 	}
@@ -544,17 +2717,31 @@ func CreateAccount(w http.ResponseWriter, r *http.Request) {
 		SetCreateBy(newEnt.Creator).SetCreateTime(time.Now()).SetBalance(0).SetName(newEnt.Name).
 		SetCOA(newEnt.COA).SetCurrency(newEnt.Currency).SetDescription(newEnt.Description)
 
-	if strings.ToUpper(newEnt.Alignment) == "DEBIT" {
-		acc.SetAlignment(acccore.DEBIT)
-	} else {
-		acc.SetAlignment(acccore.CREDIT)
+	alignment, err := ParseAlignment(newEnt.Alignment)
+	if err != nil {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid alignment", err.Error(), 0)
+		return
 	}
+	acc.SetAlignment(alignment)
 
 	if len(acc.GetAccountNumber()) == 0 {
 		acc.SetAccountNumber(UniqueIDGenerator.NewUniqueID())
 	}
 
-	err = AccountMgr.PersistAccount(nctx, acc)
+	if err = COAMgr.CheckAccountCOA(r.Context(), acc.GetCOA(), acc.GetAlignment()); err != nil {
+		writeAccountCOAError(llog, w, r, err)
+		return
+	}
+
+	limits := newEnt.Limits.toAccountLimits()
+	if limits != nil {
+		if err = limits.Validate(); err != nil {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid account limits", err.Error(), 0)
+			return
+		}
+	}
+
+	err = AccountLifecycleMgr.PersistAccountWithLimits(nctx, acc, limits)
 	if err != nil {
 		llog.Errorf("got %s", err.Error())
 		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "error reading body", err.Error(), 0)
@@ -594,6 +2781,11 @@ func GetJournal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", newJournalDetail(j), 1)
+}
+
+// newJournalDetail returns the response body of the journal.
+func newJournalDetail(j acccore.Journal) *JournalDetail {
 	reversedJournal := ""
 	if j.IsReversal() && j.GetReversedJournal() != nil {
 		reversedJournal = j.GetReversedJournal().GetJournalID()
@@ -630,8 +2822,7 @@ func GetJournal(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	retJournal.Transactions = retTrxes
-
-	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", retJournal, 1)
+	return retJournal
 }
 
 // DrawJournal draws the journal activity for easier debugging
@@ -710,8 +2901,13 @@ func ListJournal(w http.ResponseWriter, r *http.Request) {
 		helpers.HTTPResponseBuilder(ctx, w, r, 400, "invalid request", "either page, size is not number", 0)
 		return
 	}
+	basis, err := ParseDateBasis(r.URL.Query().Get("date"))
+	if err != nil {
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "invalid request", err.Error(), 0)
+		return
+	}
 
-	pr, journals, err := JournalMgr.ListJournals(r.Context(), fTime, uTime, acccore.PageRequest{
+	pr, journals, err := PostingDateMgr.ListJournalsByDate(r.Context(), basis, fTime, uTime, acccore.PageRequest{
 		PageNo:   page,
 		ItemSize: size,
 		Sorts:    nil,
@@ -731,6 +2927,16 @@ type CreateReversalRequest struct {
 	Description string `json:"description"`
 	JournalID   string `json:"journal_id"`
 	Creator     string `json:"creator"`
+	// EffectiveTime is when the reversal takes effect on the balances, now if empty
+	EffectiveTime string `json:"effective_time,omitempty"`
+	// Transactions are the amounts of a partial reversal, the outstanding amount of every leg is reversed if empty
+	Transactions []ReversalTransactionInfo `json:"transactions,omitempty"`
+}
+
+// ReversalTransactionInfo is the amount of a transaction of the journal taken back by a partial reversal
+type ReversalTransactionInfo struct {
+	TransactionID string `json:"transaction_id"`
+	Amount        int64  `json:"amount"`
 }
 
 // CreateJournalRequest is the create journal request paylaod
@@ -738,6 +2944,11 @@ type CreateJournalRequest struct {
 	Description  string                `json:"description"`
 	Creator      string                `json:"creator"`
 	Transactions []*TransactionRequest `json:"transactions"`
+	// MultiCurrency allows transactions on accounts of different currencies, balanced by generated FX transactions
+	MultiCurrency bool `json:"multi_currency,omitempty"`
+	// EffectiveTime is when the journal takes effect on the balances, now if empty. The journal is booked now
+	// whatever its effective time, which may be up to the backdating window in the past.
+	EffectiveTime string `json:"effective_time,omitempty"`
 }
 
 // TransactionRequest is the create transaction request payload
@@ -746,6 +2957,8 @@ type TransactionRequest struct {
 	Description   string `json:"description"`
 	Alignment     string `json:"alignment"`
 	Amount        int64  `json:"amount"`
+	// Currency is the currency of the amount, if specified it must be the currency of the account
+	Currency string `json:"currency,omitempty"`
 }
 
 // CreateJournal creates a journal
@@ -764,15 +2977,81 @@ func CreateJournal(w http.ResponseWriter, r *http.Request) {
 		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error when reading body", err.Error(), 0)
 		return
 	}
-	err = json.Unmarshal(bodBytes, &reqBod)
+	err = json.Unmarshal(bodBytes, &reqBod)
+	if err != nil {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+
+	idempotencyKey, requestHash, done := checkIdempotencyKey(r.Context(), w, r, reqBod)
+	if done {
+		return
+	}
+	postJournal(w, r, reqBod, idempotencyKey, requestHash)
+}
+
+// postJournal makes the requested journal and posts it, answering the request with the journal ID.
+func postJournal(w http.ResponseWriter, r *http.Request, reqBod *CreateJournalRequest, idempotencyKey, requestHash string) {
+	journalContext := context.WithValue(r.Context(), contextkeys.UserIDContextKey, reqBod.Creator)
+	toPersist, err := newJournalFromRequest(journalContext, reqBod)
+	if err != nil {
+		var requestErr *journalRequestError
+		if errors.As(err, &requestErr) {
+			helpers.HTTPResponseBuilder(journalContext, w, r, 400, requestErr.message, err.Error(), 0)
+			return
+		}
+		if !writeRefusedPosting(journalContext, w, r, err) {
+			helpers.HTTPResponseBuilder(journalContext, w, r, 500, "backend error", err.Error(), 2)
+		}
+		return
+	}
+
+	err = persistJournal(journalContext, toPersist, idempotencyKey, requestHash, reqBod.Creator)
+	if err != nil {
+		if errors.Is(err, hwerrors.ErrIdempotencyKeyExists) {
+			writeIdempotencyKeyExists(journalContext, w, r, idempotencyKey, requestHash)
+			return
+		}
+		if writeRefusedPosting(journalContext, w, r, err) {
+			return
+		}
+		helpers.HTTPResponseBuilder(journalContext, w, r, 400, "malformed json body", err.Error(), 0)
+		return
+	}
+	helpers.HTTPResponseBuilder(journalContext, w, r, 200, "OK", toPersist.GetJournalID(), 0)
+}
+
+// journalRequestError is the reason a journal can not be made from a journal creation request, with the message
+// the request is answered with and the code of the reason in a batch result.
+type journalRequestError struct {
+	message string
+	code    string
+	err     error
+}
+
+// Error returns the error the journal can not be made because of.
+func (e *journalRequestError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error the journal can not be made because of.
+func (e *journalRequestError) Unwrap() error {
+	return e.err
+}
+
+// newJournalFromRequest makes the requested journal taking effect at its effective time, with the FX transactions
+// balancing it if it is multi currency. The context should identify the creator.
+// Throws a journalRequestError if the request is not valid, or the error of the period manager if the journal falls
+// in a closed period or the period can not be checked.
+func newJournalFromRequest(ctx context.Context, reqBod *CreateJournalRequest) (acccore.Journal, error) {
+	effectiveTime, err := parseEffectiveTime(ctx, reqBod.EffectiveTime)
 	if err != nil {
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed json body", err.Error(), 0)
-		return
+		return nil, &journalRequestError{message: "invalid effective time", code: "INVALID_EFFECTIVE_TIME", err: err}
 	}
 
 	journal := &acccore.BaseJournal{
 		JournalID:       UniqueIDGenerator.NewUniqueID(),
-		JournalingTime:  time.Now(),
+		JournalingTime:  effectiveTime,
 		Description:     reqBod.Description,
 		Reversal:        false,
 		ReversedJournal: nil,
@@ -783,34 +3062,202 @@ func CreateJournal(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, tx := range reqBod.Transactions {
+		alignment, err := ParseAlignment(tx.Alignment)
+		if err != nil {
+			return nil, &journalRequestError{message: "invalid transaction alignment", code: "INVALID_ALIGNMENT",
+				err: fmt.Errorf("transaction on account %s : %w", tx.AccountNumber, err)}
+		}
 		ntx := &acccore.BaseTransaction{
 			TransactionID:   UniqueIDGenerator.NewUniqueID(),
-			TransactionTime: time.Now(),
+			TransactionTime: effectiveTime,
 			AccountNumber:   tx.AccountNumber,
 			JournalID:       journal.JournalID,
 			Description:     tx.Description,
+			TransactionType: alignment,
 			Amount:          tx.Amount,
 			AccountBalance:  0,
 			CreateTime:      time.Now(),
 			CreateBy:        reqBod.Creator,
 		}
-		if strings.ToUpper(tx.Alignment) == "DEBIT" {
-			ntx.TransactionType = acccore.DEBIT
-		} else {
-			ntx.TransactionType = acccore.CREDIT
-		}
 		journal.Transactions = append(journal.Transactions, ntx)
 	}
 
-	journalContext := context.WithValue(r.Context(), contextkeys.UserIDContextKey, reqBod.Creator)
+	for _, tx := range reqBod.Transactions {
+		if len(tx.Currency) == 0 {
+			continue
+		}
+		account, err := AccountMgr.GetAccountByID(ctx, tx.AccountNumber)
+		if err == nil && account != nil && account.GetCurrency() != tx.Currency {
+			return nil, &journalRequestError{message: "invalid transaction currency", code: "INVALID_CURRENCY",
+				err: fmt.Errorf("account %s is in %s, not %s", tx.AccountNumber, account.GetCurrency(), tx.Currency)}
+		}
+	}
+
+	var toPersist acccore.Journal = journal
+	if reqBod.MultiCurrency {
+		toPersist, err = FXMgr.PrepareFXJournal(ctx, journal)
+		if err != nil {
+			return nil, &journalRequestError{message: "invalid multi currency journal", code: "INVALID_MULTI_CURRENCY", err: err}
+		}
+		// the generated FX transactions take effect with the journal
+		setEffectiveTime(toPersist, effectiveTime)
+	}
+
+	if err = PeriodMgr.CheckPostingTime(ctx, earliestPostingTime(toPersist)); err != nil {
+		return nil, err
+	}
+	return toPersist, nil
+}
+
+// CreateJournalBatch posts a batch of journals, sent as a json array or as newline delimited json of journal
+// creation requests. The batch is posted all or nothing, or each journal on its own with mode=best-effort.
+func CreateJournalBatch(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "CreateJournalBatch")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
 
-	err = JournalMgr.PersistJournal(journalContext, journal)
+	mode := r.URL.Query().Get("mode")
+	if len(mode) == 0 {
+		mode = "all-or-nothing"
+	}
+	if mode != "all-or-nothing" && mode != "best-effort" {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid batch mode", fmt.Sprintf("mode %s should be one of all-or-nothing or best-effort", mode), 0)
+		return
+	}
+	allOrNothing := mode == "all-or-nothing"
+	requests, err := readJournalBatch(r.Body, JournalBatchMaxSize)
 	if err != nil {
-		helpers.HTTPResponseBuilder(journalContext, w, r, 400, "malformed json body", err.Error(), 0)
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "invalid journal batch", err.Error(), 0)
+		return
+	}
+
+	ret := &JournalBatchEntity{Mode: mode, Results: make([]*JournalBatchItem, len(requests))}
+	journals := make([]acccore.Journal, 0, len(requests))
+	indexes := make([]int, 0, len(requests))
+	for i, request := range requests {
+		ret.Results[i] = &JournalBatchItem{Index: i, Status: "POSTED"}
+		journal, err := newJournalFromRequest(context.WithValue(r.Context(), contextkeys.UserIDContextKey, request.Creator), request)
+		if err != nil {
+			ret.Results[i].setError(err)
+			continue
+		}
+		ret.Results[i].JournalID = journal.GetJournalID()
+		journals = append(journals, journal)
+		indexes = append(indexes, i)
+	}
+	if allOrNothing && len(journals) < len(requests) {
+		for _, i := range indexes {
+			ret.Results[i].setError(fmt.Errorf("%w : another journal of the batch is not valid", hwerrors.ErrJournalBatchNotPosted))
+		}
+	} else {
+		for i, err := range JournalBatchMgr.PersistJournals(r.Context(), journals, allOrNothing) {
+			if err != nil {
+				ret.Results[indexes[i]].setError(err)
+			}
+		}
+	}
+	for _, item := range ret.Results {
+		switch item.Status {
+		case "POSTED":
+			ret.Posted++
+		case "REFUSED":
+			ret.Refused++
+		}
+	}
+	if allOrNothing && ret.Refused > 0 {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 422, "journal batch refused", ret, 0)
 		return
 	}
-	helpers.HTTPResponseBuilder(journalContext, w, r, 200, "OK", journal.JournalID, 0)
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", ret, 0)
+}
+
+// readJournalBatch reads the journal creation requests of a batch, sent as a json array or as newline delimited
+// json, refusing empty batches and batches of more than maxSize journals.
+func readJournalBatch(body io.Reader, maxSize int) ([]*CreateJournalRequest, error) {
+	reader := bufio.NewReader(body)
+	first, err := reader.Peek(1)
+	for err == nil && strings.TrimSpace(string(first)) == "" {
+		if _, err = reader.Discard(1); err == nil {
+			first, err = reader.Peek(1)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("the batch has no journal")
+		}
+		return nil, err
+	}
+	decoder := json.NewDecoder(reader)
+	array := first[0] == '['
+	if array {
+		if _, err = decoder.Token(); err != nil {
+			return nil, err
+		}
+	}
+	requests := make([]*CreateJournalRequest, 0)
+	for decoder.More() {
+		if len(requests) == maxSize {
+			return nil, fmt.Errorf("the batch has more than %d journals", maxSize)
+		}
+		request := &CreateJournalRequest{}
+		if err = decoder.Decode(request); err != nil {
+			return nil, fmt.Errorf("journal %d : %w", len(requests), err)
+		}
+		requests = append(requests, request)
+	}
+	if array {
+		if _, err = decoder.Token(); err != nil {
+			return nil, err
+		}
+	} else if _, err = decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("journal %d is not a json object", len(requests))
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("the batch has no journal")
+	}
+	return requests, nil
+}
+
+// setError marks the journal refused, or not posted if the error tells another journal of the batch was refused,
+// with the code of the error.
+func (item *JournalBatchItem) setError(err error) {
+	item.JournalID, item.Status, item.ErrorCode, item.Error = "", "REFUSED", journalErrorCode(err), err.Error()
+	if errors.Is(err, hwerrors.ErrJournalBatchNotPosted) {
+		item.Status = "NOT_POSTED"
+	}
+}
 
+// journalErrorCode returns the code telling why a journal of a batch was not posted.
+func journalErrorCode(err error) string {
+	var requestErr *journalRequestError
+	var limitErr *BalanceLimitError
+	switch {
+	case errors.As(err, &requestErr):
+		return requestErr.code
+	case errors.Is(err, hwerrors.ErrJournalBatchNotPosted):
+		return "NOT_POSTED"
+	case errors.As(err, &limitErr):
+		return "BALANCE_LIMIT"
+	case errors.Is(err, hwerrors.ErrAccountFrozen), errors.Is(err, hwerrors.ErrAccountClosed):
+		return "ACCOUNT_NOT_POSTABLE"
+	case errors.Is(err, hwerrors.ErrPeriodClosed):
+		return "PERIOD_CLOSED"
+	case errors.Is(err, acccore.ErrJournalNotBalance):
+		return "JOURNAL_NOT_BALANCED"
+	case errors.Is(err, acccore.ErrJournalTransactionAccountNotPersist):
+		return "ACCOUNT_NOT_FOUND"
+	case errors.Is(err, acccore.ErrJournalTransactionMixCurrency):
+		return "MIXED_CURRENCY"
+	case errors.Is(err, acccore.ErrJournalTransactionAccountDuplicate):
+		return "DUPLICATE_ACCOUNT"
+	case errors.Is(err, acccore.ErrJournalNoTransaction), errors.Is(err, acccore.ErrJournalMissingAuthor):
+		return "INVALID_JOURNAL"
+	}
+	return "INTERNAL_ERROR"
 }
 
 // CreateReversalJournal creates a reversal journal response
@@ -836,62 +3283,274 @@ func CreateReversalJournal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rJournal, err := JournalMgr.GetJournalByID(r.Context(), rBody.JournalID)
+	idempotencyKey, requestHash, done := checkIdempotencyKey(r.Context(), w, r, rBody)
+	if done {
+		return
+	}
+	reversalContext := context.WithValue(r.Context(), contextkeys.UserIDContextKey, rBody.Creator)
+
+	rJournal, err := JournalMgr.GetJournalByID(reversalContext, rBody.JournalID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, acccore.ErrJournalIDNotFound) {
-			helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "journal not found", "journal to reverse not found", 0)
+			helpers.HTTPResponseBuilder(reversalContext, w, r, 404, "journal not found", "journal to reverse not found", 0)
 			return
 		}
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error when fetching journal", err.Error(), 0)
+		helpers.HTTPResponseBuilder(reversalContext, w, r, 500, "internal server error when fetching journal", err.Error(), 0)
 		return
 	}
 	if rJournal == nil {
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "journal not found", "journal to reverse not found", 0)
+		helpers.HTTPResponseBuilder(reversalContext, w, r, 404, "journal not found", "journal to reverse not found", 0)
+		return
+	}
+	effectiveTime, err := parseEffectiveTime(reversalContext, rBody.EffectiveTime)
+	if err == nil && effectiveTime.Before(rJournal.GetJournalingTime()) {
+		err = fmt.Errorf("%w : a reversal can not take effect before the journal it reverses, at %s", hwerrors.ErrInvalidEffectiveTime,
+			rJournal.GetJournalingTime().Format(time.RFC3339))
+	}
+	if err != nil {
+		helpers.HTTPResponseBuilder(reversalContext, w, r, 400, "invalid effective time", err.Error(), 0)
 		return
 	}
 
-	journal := &acccore.BaseJournal{
-		JournalID:       UniqueIDGenerator.NewUniqueID(),
-		JournalingTime:  time.Now(),
-		Reversal:        true,
-		ReversedJournal: rJournal,
-		Description:     rBody.Description,
-		CreatedBy:       rBody.Creator,
-		CreateTime:      time.Now(),
+	var amounts map[string]int64
+	if len(rBody.Transactions) > 0 {
+		amounts = make(map[string]int64, len(rBody.Transactions))
+		for _, trx := range rBody.Transactions {
+			if _, ok := amounts[trx.TransactionID]; ok {
+				helpers.HTTPResponseBuilder(reversalContext, w, r, 400, "invalid reversal", fmt.Sprintf("transaction %s is reversed twice", trx.TransactionID), 0)
+				return
+			}
+			amounts[trx.TransactionID] = trx.Amount
+		}
+	}
+	reversal, err := ReversalMgr.GetJournalReversal(reversalContext, rJournal)
+	if err != nil {
+		helpers.HTTPResponseBuilder(reversalContext, w, r, 500, "internal server error when fetching journal reversal", err.Error(), 0)
+		return
+	}
+	journal, err := reversal.newReversalJournal(UniqueIDGenerator, rJournal, amounts, rBody.Description, rBody.Creator, effectiveTime)
+	if err != nil {
+		if !writeInvalidReversal(reversalContext, w, r, err) {
+			helpers.HTTPResponseBuilder(reversalContext, w, r, 500, "internal server error when reversing journal", err.Error(), 0)
+		}
+		return
 	}
 
-	transacs := make([]acccore.Transaction, 0)
+	if err = PeriodMgr.CheckPostingTime(reversalContext, earliestPostingTime(journal)); err != nil {
+		if !writeRefusedPosting(reversalContext, w, r, err) {
+			helpers.HTTPResponseBuilder(reversalContext, w, r, 500, "backend error", err.Error(), 2)
+		}
+		return
+	}
 
-	// make sure all Transactions have accounts of the same Currency
-	for _, txinfo := range rJournal.GetTransactions() {
-		tx := acccore.DEBIT
-		if txinfo.GetAlignment() == acccore.DEBIT {
-			tx = acccore.CREDIT
+	err = persistJournal(reversalContext, journal, idempotencyKey, requestHash, rBody.Creator)
+	if err != nil {
+		if errors.Is(err, hwerrors.ErrIdempotencyKeyExists) {
+			writeIdempotencyKeyExists(reversalContext, w, r, idempotencyKey, requestHash)
+			return
+		}
+		if writeRefusedPosting(reversalContext, w, r, err) || writeInvalidReversal(reversalContext, w, r, err) {
+			return
 		}
+		helpers.HTTPResponseBuilder(reversalContext, w, r, 500, "internal server error when reversing journal", err.Error(), 0)
+		return
+	}
+	helpers.HTTPResponseBuilder(reversalContext, w, r, 200, "OK", journal.GetJournalID(), 0)
+}
 
-		newTransaction := &acccore.BaseTransaction{
-			TransactionID:   UniqueIDGenerator.NewUniqueID(),
-			TransactionTime: time.Time{},
-			AccountNumber:   txinfo.GetAccountNumber(),
-			JournalID:       journal.JournalID,
-			Description:     fmt.Sprintf("%s - reversed", txinfo.GetDescription()),
-			TransactionType: tx,
-			Amount:          0,
-			AccountBalance:  0,
-			CreateTime:      time.Now(),
-			CreateBy:        rBody.Creator,
+// GetJournalReversal tells how much of every leg of a journal was reversed and is left to reverse
+func GetJournalReversal(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "GetJournalReversal")
+	if r.Context().Err() != nil {
+		llog.Errorf("context is canceled : %s", r.Context().Err().Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	m, err := helpers.ParsePathParams("/api/v1/journals/{JournalID}/reversal", r.URL.Path)
+	if err != nil {
+		llog.Errorf("error while processing path template /api/v1/journals/{JournalID}/reversal. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "path not found", 1)
+		return
+	}
+	j, err := JournalMgr.GetJournalByID(r.Context(), m["JournalID"])
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, acccore.ErrJournalIDNotFound) {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "journal not found", 1)
+			return
+		}
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error", err.Error(), 1)
+		return
+	}
+	if j == nil {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "journal not found", 1)
+		return
+	}
+	reversal, err := ReversalMgr.GetJournalReversal(r.Context(), j)
+	if err != nil {
+		llog.Errorf("error while calling ReversalMgr.GetJournalReversal. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error", err.Error(), 1)
+		return
+	}
+	ret := &JournalReversalEntity{JournalID: reversal.JournalID, FullyReversed: reversal.FullyReversed(), Legs: make([]*ReversalLegEntity, 0, len(reversal.Legs))}
+	for _, leg := range reversal.Legs {
+		align := "DEBIT"
+		if leg.Alignment == acccore.CREDIT {
+			align = "CREDIT"
 		}
-		transacs = append(transacs, newTransaction)
+		ret.Legs = append(ret.Legs, &ReversalLegEntity{
+			TransactionID: leg.TransactionID,
+			AccountNumber: leg.AccountNumber,
+			Alignment:     align,
+			Amount:        leg.Amount,
+			Reversed:      leg.Reversed,
+			Outstanding:   leg.Outstanding(),
+		})
+	}
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "journal "+reversal.JournalID+" reversal", ret, 0)
+}
+
+// writeInvalidReversal responds with a bad request if the error tells the reversal does not fit the reversed journal,
+// or its partial amounts do not balance, and returns false otherwise, leaving the response to the caller.
+func writeInvalidReversal(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, acccore.ErrJournalCanNotDoubleReverse):
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "journal already reversed", err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrInvalidReversal), errors.Is(err, hwerrors.ErrReversalExceedsOutstanding), errors.Is(err, acccore.ErrJournalNotBalance):
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "invalid reversal", err.Error(), 0)
+	default:
+		return false
+	}
+	return true
+}
+
+// parseEffectiveTime returns the effective time of a journal, written in RestTimeFormat, or now if it is empty.
+// Throws ErrInvalidEffectiveTime if it is not well written, in the future or before the backdating window.
+func parseEffectiveTime(ctx context.Context, value string) (time.Time, error) {
+	if len(value) == 0 {
+		return time.Now(), nil
+	}
+	effectiveTime, err := time.Parse(RestTimeFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w : %s should be written as YYYY-MM-DDTHH:MM:SS", hwerrors.ErrInvalidEffectiveTime, value)
+	}
+	if err = PostingDateMgr.CheckEffectiveTime(ctx, effectiveTime); err != nil {
+		return time.Time{}, err
+	}
+	return effectiveTime, nil
+}
+
+// checkIdempotencyKey inspects the Idempotency-Key header of a journal creation request.
+// If the key was used by an earlier request within the idempotency window, the earlier response is replayed when
+// the payload is the same, or a 409 is written when it differs. In both cases done is true and the caller should stop.
+// It returns an empty key if the request has no Idempotency-Key header.
+func checkIdempotencyKey(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (key, requestHash string, done bool) {
+	key, requestHash, done = hashIdempotentRequest(ctx, w, r, request)
+	if done || len(key) == 0 {
+		return key, requestHash, done
+	}
+	return key, requestHash, replayIdempotentResponse(ctx, w, r, key, requestHash)
+}
+
+// hashIdempotentRequest returns the Idempotency-Key header of a request and the hash of its payload, or done if the
+// key is too long and the request is answered. It returns an empty key if the request has no Idempotency-Key header.
+func hashIdempotentRequest(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (key, requestHash string, done bool) {
+	key = r.Header.Get(IdempotencyKeyHeader)
+	if len(key) == 0 {
+		return "", "", false
 	}
+	if len(key) > connector.MaxIdempotencyKeyLength {
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "invalid idempotency key", fmt.Sprintf("idempotency key should not be longer than %d characters", connector.MaxIdempotencyKeyLength), 0)
+		return "", "", true
+	}
+
+	// hash the parsed request rather than the raw body, so formatting differences do not count as a different payload.
+	payload, err := json.Marshal(request)
+	if err != nil {
+		helpers.HTTPResponseBuilder(ctx, w, r, 500, "internal server error", err.Error(), 0)
+		return "", "", true
+	}
+	hash := sha256.Sum256(append([]byte(r.URL.Path+"\n"), payload...))
+	return key, hex.EncodeToString(hash[:]), false
+}
 
-	journal.SetTransactions(transacs)
+// replayIdempotentResponse writes the response of the earlier request that used the idempotency key,
+// or a 409 if that request had a different payload.
+// It returns false without writing anything if the key is not used within the idempotency window.
+func replayIdempotentResponse(ctx context.Context, w http.ResponseWriter, r *http.Request, key, requestHash string) bool {
+	rec, done := findIdempotencyRecord(ctx, w, r, key, requestHash)
+	if rec == nil {
+		return done
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	helpers.HTTPRawResponseBuilder(ctx, w, r, 200, rec.ResponseBody)
+	return true
+}
 
-	err = JournalMgr.PersistJournal(r.Context(), journal)
+// findIdempotencyRecord returns the record of the earlier request with the same payload that used the idempotency key.
+// If the earlier request had a different payload, or the record can not be read, the request is answered and done
+// is true. It returns nil and writes nothing if the key is not used within the idempotency window.
+func findIdempotencyRecord(ctx context.Context, w http.ResponseWriter, r *http.Request, key, requestHash string) (rec *IdempotencyRecord, done bool) {
+	rec, err := IdempotencyMgr.GetIdempotencyRecord(ctx, key)
 	if err != nil {
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error when reversing journal", err.Error(), 0)
+		helpers.HTTPResponseBuilder(ctx, w, r, 500, "internal server error", err.Error(), 0)
+		return nil, true
+	}
+	if rec == nil {
+		return nil, false
+	}
+	if rec.RequestHash != requestHash {
+		helpers.HTTPResponseBuilder(ctx, w, r, 409, "idempotency key conflict", "idempotency key already used with a different request payload", 0)
+		return nil, true
+	}
+	return rec, true
+}
+
+// writeIdempotencyKeyExists answers a request that lost the race for its idempotency key to a concurrent request.
+// The winner's response is replayed, or a 409 is written when the winner's record can not be read back.
+func writeIdempotencyKeyExists(ctx context.Context, w http.ResponseWriter, r *http.Request, key, requestHash string) {
+	if replayIdempotentResponse(ctx, w, r, key, requestHash) {
 		return
 	}
-	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", journal.JournalID, 0)
+	helpers.HTTPResponseBuilder(ctx, w, r, 409, "idempotency key conflict", hwerrors.ErrIdempotencyKeyExists.Error(), 0)
+}
+
+// writeRefusedPosting responds with 422 if the journal was refused because of one of its accounts or its
+// accounting period, it returns false and writes nothing for any other error.
+func writeRefusedPosting(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) bool {
+	var limitErr *BalanceLimitError
+	switch {
+	case errors.As(err, &limitErr):
+		helpers.HTTPResponseBuilder(ctx, w, r, 422, "balance limit exceeded on account "+limitErr.AccountNumber, err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrAccountFrozen), errors.Is(err, hwerrors.ErrAccountClosed):
+		helpers.HTTPResponseBuilder(ctx, w, r, 422, "account does not accept postings", err.Error(), 0)
+	case errors.Is(err, hwerrors.ErrPeriodClosed):
+		helpers.HTTPResponseBuilder(ctx, w, r, 422, "accounting period is closed", err.Error(), 0)
+	default:
+		return false
+	}
+	return true
+}
+
+// persistJournal persists the journal, and if the request carries an idempotency key, the key together with
+// the response the request is about to receive.
+func persistJournal(ctx context.Context, journal acccore.Journal, idempotencyKey, requestHash, creator string) error {
+	if len(idempotencyKey) == 0 {
+		return JournalMgr.PersistJournal(ctx, journal)
+	}
+	respBody, err := helpers.EncodeResponseJSON(200, "OK", journal.GetJournalID(), 0)
+	if err != nil {
+		return err
+	}
+	return IdempotencyMgr.PersistJournal(ctx, journal, &IdempotencyRecord{
+		Key:          idempotencyKey,
+		RequestHash:  requestHash,
+		JournalID:    journal.GetJournalID(),
+		ResponseBody: respBody,
+		CreatedAt:    time.Now(),
+		CreatedBy:    creator,
+	})
 }
 
 // GetTransaction retrieves a transaction from its ID
@@ -944,10 +3603,61 @@ func SetCommonDenominator(w http.ResponseWriter, r *http.Request) {
 		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "Malformed request", "denom must be a number (could be float)", 0)
 		return
 	}
-	ExchangeMgr.SetDenom(r.Context(), big.NewFloat(f))
+	author := r.URL.Query().Get("author")
+	err = DenominatorMgr.PersistDenom(r.Context(), big.NewFloat(f), author)
+	if err != nil {
+		llog.Errorf("error while persisting common denominator. got : %s", err.Error())
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error", err.Error(), 0)
+		return
+	}
 	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", f, 0)
 }
 
+// PaginatedDenominatorHistoryResponse is the common denominator history response paginated
+type PaginatedDenominatorHistoryResponse struct {
+	Changes    []*DenominatorChange `json:"changes"`
+	Pagination acccore.PageResult   `json:"pagination"`
+}
+
+// ListCommonDenominatorHistory lists the values the common denominator was given, who set them and when, latest first
+func ListCommonDenominatorHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := restLog.WithField("RequestID", requestID).WithField("function", "ListCommonDenominatorHistory")
+	if ctx.Err() != nil {
+		llog.Errorf("context is canceled : %s", ctx.Err().Error())
+		helpers.HTTPResponseBuilder(ctx, w, r, 500, "request is canceled", "request is canceled", 0)
+		return
+	}
+
+	pageA, pOk := r.URL.Query()["page"]
+	sizeA, sOk := r.URL.Query()["size"]
+	if !pOk || !sOk {
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "invalid request", "either page or size is missing", 0)
+		return
+	}
+	page, perr := strconv.Atoi(pageA[0])
+	size, serr := strconv.Atoi(sizeA[0])
+	if perr != nil || serr != nil {
+		helpers.HTTPResponseBuilder(ctx, w, r, 400, "invalid request", "either page, size is not number", 0)
+		return
+	}
+
+	pr, changes, err := DenominatorMgr.ListDenomHistory(ctx, acccore.PageRequest{
+		PageNo:   page,
+		ItemSize: size,
+		Sorts:    nil,
+	})
+	if err != nil {
+		helpers.HTTPResponseBuilder(ctx, w, r, 500, "internal server error", err.Error(), 0)
+		return
+	}
+	helpers.HTTPResponseBuilder(ctx, w, r, 200, "OK", &PaginatedDenominatorHistoryResponse{
+		Changes:    changes,
+		Pagination: pr,
+	}, 0)
+}
+
 // GetCommonDenominator returns the current common denominator
 func GetCommonDenominator(w http.ResponseWriter, r *http.Request) {
 	requestID := r.Context().Value(contextkeys.XRequestID).(string)
@@ -994,68 +3704,38 @@ func SetCurrency(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	createNew := false
-
-	cur, err := ExchangeMgr.GetCurrency(r.Context(), m["code"])
+	rate, err := ParseRate(setBody.Exchange.String())
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, acccore.ErrCurrencyNotFound) {
-			createNew = true
-		} else {
-			helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error", err.Error(), 1)
-			return
-		}
-	}
-	if cur == nil {
-		createNew = true
-	}
-
-	if createNew {
-		nCur, err := ExchangeMgr.CreateCurrency(r.Context(), m["code"], setBody.Name, big.NewFloat(setBody.Exchange), setBody.Author)
-		if err != nil {
-			if err == acccore.ErrCurrencyAlreadyPersisted {
-				helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", "currnecy already persist", 1)
-				return
-			}
-			helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error", err.Error(), 1)
-			return
-		}
-		helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", &CurrencyRet{
-			Code:     nCur.GetCode(),
-			Name:     nCur.GetName(),
-			Exchange: nCur.GetExchange(),
-		}, 1)
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 400, "malformed request", err.Error(), 1)
 		return
 	}
-	cur.SetExchange(setBody.Exchange).SetName(setBody.Name)
-	err = ExchangeMgr.UpdateCurrency(r.Context(), m["code"], cur, setBody.Author)
+
+	cur, err := RateMgr.SetCurrencyRate(r.Context(), m["code"], setBody.Name, rate, setBody.Author)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, acccore.ErrCurrencyNotFound) || err.Error() == "currency not found" {
-			helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "currnecy not found", 1)
-			return
-		}
 		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error", err.Error(), 1)
 		return
 	}
 	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", &CurrencyRet{
-		Code:     cur.GetCode(),
-		Name:     cur.GetName(),
-		Exchange: cur.GetExchange(),
+		Code:     cur.Code,
+		Name:     cur.Name,
+		Exchange: FormatRat(cur.Exchange),
 	}, 1)
-
 }
 
 // SetCurrencyBody is the set currency request payload
 type SetCurrencyBody struct {
-	Name     string  `json:"name"`
-	Exchange float64 `json:"exchange"`
-	Author   string  `json:"author"`
+	Name string `json:"name"`
+	// Exchange is the exact exchange unit, either a JSON number or a decimal string such as "0.123"
+	Exchange json.Number `json:"exchange"`
+	Author   string      `json:"author"`
 }
 
 // CurrencyRet is the currency respose
 type CurrencyRet struct {
-	Code     string  `json:"code"`
-	Name     string  `json:"name"`
-	Exchange float64 `json:"exchange"`
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// Exchange is the exact exchange unit written as a decimal string
+	Exchange string `json:"exchange"`
 }
 
 // ListCurrencies lists all the currency
@@ -1067,19 +3747,21 @@ func ListCurrencies(w http.ResponseWriter, r *http.Request) {
 		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "request is canceled", "request is canceled", 0)
 		return
 	}
-	curs, err := ExchangeMgr.ListCurrencies(r.Context())
+	curs, err := RateMgr.ListCurrencyRates(r.Context())
 	if err != nil {
 		if err == sql.ErrNoRows {
 			helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", make([]string, 0), 0)
 			return
 		}
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error", err.Error(), 0)
+		return
 	}
 	arr := make([]*CurrencyRet, 0)
 	for _, c := range curs {
 		arr = append(arr, &CurrencyRet{
-			Code:     c.GetCode(),
-			Name:     c.GetName(),
-			Exchange: c.GetExchange(),
+			Code:     c.Code,
+			Name:     c.Name,
+			Exchange: FormatRat(c.Exchange),
 		})
 	}
 	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", arr, 0)
@@ -1102,19 +3784,21 @@ func GetCurrency(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	curCode, err := ExchangeMgr.GetCurrency(r.Context(), m["code"])
+	cur, err := RateMgr.GetCurrencyRate(r.Context(), m["code"])
 	if err != nil {
 		if err == sql.ErrNoRows || err == acccore.ErrCurrencyNotFound {
 			llog.Errorf("error while processing path template /api/v1/currencies/{code}. got : %s", err.Error())
 			helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "currency not found", 1)
 			return
 		}
+		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error", err.Error(), 1)
+		return
 	}
 
 	cret := &CurrencyRet{
-		Code:     curCode.GetCode(),
-		Name:     curCode.GetName(),
-		Exchange: curCode.GetExchange(),
+		Code:     cur.Code,
+		Name:     cur.Name,
+		Exchange: FormatRat(cur.Exchange),
 	}
 
 	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", cret, 0)
@@ -1144,17 +3828,52 @@ func CalculateExchangeRate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	exc, err := ExchangeMgr.CalculateExchangeRate(r.Context(), cFrom, cTo)
+	at, err := parseExchangeTime(r)
+	if err != nil {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, http.StatusBadRequest, "invalid request", err.Error(), 1)
+		return
+	}
+
+	exc, err := RateMgr.CalculateExactRate(r.Context(), cFrom, cTo, at)
 	if err != nil {
 		if err == acccore.ErrCurrencyNotFound {
 			helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "currency not found", "currency not found", 1)
 			return
 		}
+		if errors.Is(err, hwerrors.ErrNoEffectiveRate) {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "exchange rate not found", err.Error(), 1)
+			return
+		}
 		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error", err.Error(), 1)
 		return
 	}
-	f, _ := exc.Float64()
-	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", f, 1)
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", FormatRat(exc), 1)
+}
+
+// parseExchangeTime parses the optional at query parameter, the time whose exchange units are used.
+// It returns the zero time for the current exchange units if the parameter is not specified.
+func parseExchangeTime(r *http.Request) (time.Time, error) {
+	qat := r.URL.Query().Get("at")
+	if len(qat) == 0 {
+		return time.Time{}, nil
+	}
+	at, err := time.Parse(RestTimeFormat, qat)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("at should be in YYYY-MM-DDTHH:MM:SS format. got %s", qat)
+	}
+	return at, nil
+}
+
+// ExchangeRet is the exchange response
+type ExchangeRet struct {
+	// Amount is the exchanged amount rounded into a whole amount
+	Amount int64 `json:"amount"`
+	// Exact is the exchanged amount before rounding, a decimal string or a fraction such as "1/3"
+	Exact string `json:"exact"`
+	// Residue is what the rounding took away from the exact amount, exact minus amount
+	Residue string `json:"residue"`
+	// Rounding is the rounding mode used
+	Rounding RoundingMode `json:"rounding"`
 }
 
 // CalculateExchange calculates the exchange betwee two currencies
@@ -1181,21 +3900,49 @@ func CalculateExchange(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	amnt, err := strconv.Atoi(cAmt)
+	amnt, err := strconv.ParseInt(cAmt, 10, 64)
 	if err != nil {
 		llog.Error("error, couldn't convert the amount: ", cAmt)
 		helpers.HTTPResponseBuilder(r.Context(), w, r, http.StatusBadRequest, "path not valid", "path not valid", 400)
 		return
 	}
 
-	res, err := ExchangeMgr.CalculateExchange(r.Context(), cFrom, cTo, int64(amnt))
+	var rounding RoundingMode
+	if qrounding := r.URL.Query().Get("rounding"); len(qrounding) > 0 {
+		rounding, err = ParseRoundingMode(qrounding)
+		if err != nil {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, http.StatusBadRequest, "invalid request", err.Error(), 1)
+			return
+		}
+	}
+
+	at, err := parseExchangeTime(r)
+	if err != nil {
+		helpers.HTTPResponseBuilder(r.Context(), w, r, http.StatusBadRequest, "invalid request", err.Error(), 1)
+		return
+	}
+
+	res, err := RateMgr.CalculateExactExchange(r.Context(), cFrom, cTo, amnt, rounding, at)
 	if err != nil {
 		if err == sql.ErrNoRows || err == acccore.ErrCurrencyNotFound {
 			helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "path not found", "currency not found", 1)
 			return
 		}
+		if errors.Is(err, hwerrors.ErrNoEffectiveRate) {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, 404, "exchange rate not found", err.Error(), 1)
+			return
+		}
+		if errors.Is(err, hwerrors.ErrAmountOutOfRange) {
+			helpers.HTTPResponseBuilder(r.Context(), w, r, http.StatusBadRequest, "invalid request", err.Error(), 1)
+			return
+		}
 		helpers.HTTPResponseBuilder(r.Context(), w, r, 500, "internal server error", err.Error(), 1)
 		return
 	}
-	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", res, 0)
+	helpers.HTTPResponseBuilder(r.Context(), w, r, 200, "OK", &ExchangeRet{
+		Amount:   res.Amount,
+		Exact:    FormatRat(res.Exact),
+		Residue:  FormatRat(res.Residue),
+		Rounding: res.Rounding,
+	}, 0)
 }
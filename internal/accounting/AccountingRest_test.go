@@ -8,14 +8,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 	"unsafe"
 
 	"github.com/gorilla/mux"
 	"github.com/hyperjumptech/acccore"
-	"github.com/hyperjumptech/hyperwallet/internal/config"
-	"github.com/hyperjumptech/hyperwallet/internal/connector"
 	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
 	"github.com/hyperjumptech/hyperwallet/internal/middlewares"
 	"github.com/sirupsen/logrus"
@@ -27,10 +26,1223 @@ var (
 	accountManager     acccore.AccountManager
 	transactionManager acccore.TransactionManager
 	exchangeManager    acccore.ExchangeManager
+	idempotencyManager IdempotencyManager
+	denominatorManager DenominatorManager
+	rateManager        RateManager
 	uniqueIDGenerator  acccore.UniqueIDGenerator
 	Router             *mux.Router
+
+	accountLifecycleManager AccountLifecycleManager
+	holdManager             HoldManager
+	coaManager              COAManager
+	reportManager           ReportManager
+	integrityManager        IntegrityManager
+	journalChainManager     JournalChainManager
+	periodManager           PeriodManager
+	postingDateManager      PostingDateManager
+	reversalManager         ReversalManager
+	journalBatchManager     JournalBatchManager
+	templateManager         TemplateManager
+	scheduleManager         ScheduleManager
 )
 
+func RunningTestMultiCurrencyJournal(t *testing.T) {
+	postJournal := func(body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "http://localhost/api/v1/journals", bytes.NewBuffer([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	body := func(multiCurrency bool, pointCurrency string) string {
+		return fmt.Sprintf(`
+{
+  "description": "Exchange Point To Gold",
+  "multi_currency": %t,
+  "transactions": [
+    {
+      "account_number": "%s",
+      "description": "Receive Gold",
+      "alignment": "DEBIT",
+      "amount": 100,
+      "currency": "GOLD"
+    },
+	{
+      "account_number": "%s",
+      "description": "Pay Point",
+      "alignment": "CREDIT",
+      "amount": 1000,
+      "currency": "%s"
+    }
+  ],
+  "creator": "max"
+}
+`, multiCurrency, FerdinandGoldAccountNo, FerdinandPointAccountNo, pointCurrency)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, postJournal(body(true, "GOLD")).Code)
+	if testing.Short() {
+		t.Skip("the in memory journal manager does not persist multi currency journals")
+	}
+	assert.Equal(t, http.StatusOK, postJournal(body(true, "POINT")).Code)
+
+	MakeFetchIndividualAccountTest(FerdinandGoldAccountNo, "Ferdinand Gold", "1.1.2", "GOLD", "DEBIT", 451100, http.StatusOK, "SUCCESS")(t)
+	MakeFetchIndividualAccountTest(FerdinandPointAccountNo, "Ferdinand Point", "1.2.2", "POINT", "DEBIT", -1000, http.StatusOK, "SUCCESS")(t)
+	MakeFetchIndividualAccountTest(GoldReserveAccountNo, "Gold Reserve", "1.1.1", "GOLD", "DEBIT", 1199900, http.StatusOK, "SUCCESS")(t)
+}
+
+func RunningTestAccountLifecycle(t *testing.T) {
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewBuffer([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	accountPath := "/api/v1/accounts/" + BudhiPointAccountNo
+	expectStatus := func(status string) {
+		recorder := send(http.MethodGet, accountPath, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		bodyObj := &IndividualAccountResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &bodyObj))
+		assert.Equal(t, status, bodyObj.Data.Status)
+	}
+
+	recorder := send(http.MethodPatch, accountPath, `{"name": "Budhi Points", "author": "max"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	bodyObj := &IndividualAccountResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &bodyObj))
+	assert.Equal(t, "Budhi Points", bodyObj.Data.Name)
+	assert.Equal(t, "Budhi Point Account", bodyObj.Data.Description)
+	assert.Equal(t, "ACTIVE", bodyObj.Data.Status)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPatch, accountPath, `{"name": ""}`).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodPatch, "/api/v1/accounts/NOSUCHACCOUNT", `{"name": "x", "author": "max"}`).Code)
+
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPut, accountPath+"/status", `{"status": "OPEN", "author": "max"}`).Code)
+	assert.Equal(t, http.StatusConflict, send(http.MethodPut, accountPath+"/status", `{"status": "CLOSED", "author": "max"}`).Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPut, accountPath+"/status", `{"status": "FROZEN", "author": "max"}`).Code)
+	expectStatus("FROZEN")
+	if !testing.Short() {
+		recorder = send(http.MethodPost, "/api/v1/journals", fmt.Sprintf(`{"description": "Posting To Frozen", "creator": "max", "transactions": [
+			{"account_number": "%s", "description": "Frozen", "alignment": "DEBIT", "amount": 1000},
+			{"account_number": "%s", "description": "Frozen", "alignment": "CREDIT", "amount": 1000}]}`, BudhiPointAccountNo, PointReserveAccountNo))
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	}
+	assert.Equal(t, http.StatusOK, send(http.MethodPut, accountPath+"/status", `{"status": "CLOSED", "author": "max"}`).Code)
+	expectStatus("CLOSED")
+	assert.Equal(t, http.StatusConflict, send(http.MethodPatch, accountPath, `{"name": "Budhi Closed Points", "author": "max"}`).Code)
+	assert.Equal(t, http.StatusConflict, send(http.MethodPut, accountPath+"/status", `{"status": "ACTIVE", "author": "max"}`).Code)
+
+	// an account with balance can be frozen but not closed
+	goldStatusPath := "/api/v1/accounts/" + BudhiGoldAccountNo + "/status"
+	assert.Equal(t, http.StatusOK, send(http.MethodPut, goldStatusPath, `{"status": "FROZEN", "author": "max"}`).Code)
+	assert.Equal(t, http.StatusConflict, send(http.MethodPut, goldStatusPath, `{"status": "CLOSED", "author": "max"}`).Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPut, goldStatusPath, `{"status": "ACTIVE", "author": "max"}`).Code)
+}
+
+func RunningTestAccountLimits(t *testing.T) {
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewBuffer([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	recorder := send(http.MethodPost, "/api/v1/accounts", `{"account_number": "GOLDNOOVERDRAFT", "name": "No Overdraft", "description": "Gold account that may not go negative",
+		"coa": "1.1.2", "currency": "GOLD", "alignment": "DEBIT", "creator": "max", "limits": {"allow_negative": false}}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = send(http.MethodPost, "/api/v1/accounts", `{"account_number": "GOLDBADLIMITS", "name": "Bad Limits", "description": "Gold account without room",
+		"coa": "1.1.2", "currency": "GOLD", "alignment": "DEBIT", "creator": "max", "limits": {"min_balance": 100, "max_balance": 10}}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send(http.MethodGet, "/api/v1/accounts/GOLDNOOVERDRAFT", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	bodyObj := &IndividualAccountResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &bodyObj))
+	if assert.NotNil(t, bodyObj.Data.Limits) {
+		assert.False(t, *bodyObj.Data.Limits.AllowNegative)
+		assert.Nil(t, bodyObj.Data.Limits.MinBalance)
+		assert.Nil(t, bodyObj.Data.Limits.MaxBalance)
+	}
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPatch, "/api/v1/accounts/GOLDNOOVERDRAFT", `{"limits": {"allow_negative": false, "max_balance": -1}, "author": "max"}`).Code)
+
+	if !testing.Short() {
+		recorder = send(http.MethodPost, "/api/v1/journals", fmt.Sprintf(`{"description": "Overdraft", "creator": "max", "transactions": [
+			{"account_number": "%s", "description": "Overdraft", "alignment": "DEBIT", "amount": 1000},
+			{"account_number": "GOLDNOOVERDRAFT", "description": "Overdraft", "alignment": "CREDIT", "amount": 1000}]}`, BudhiGoldAccountNo))
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "GOLDNOOVERDRAFT")
+	}
+
+	recorder = send(http.MethodPatch, "/api/v1/accounts/GOLDNOOVERDRAFT", `{"limits": {"min_balance": -500}, "author": "max"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	bodyObj = &IndividualAccountResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &bodyObj))
+	if assert.NotNil(t, bodyObj.Data.Limits) {
+		assert.True(t, *bodyObj.Data.Limits.AllowNegative)
+		assert.Equal(t, int64(-500), *bodyObj.Data.Limits.MinBalance)
+	}
+}
+
+type HoldResponse struct {
+	Message   string      `json:"message"`
+	Status    string      `json:"status"`
+	Data      *HoldEntity `json:"data"`
+	ErrorCode int         `json:"error_code"`
+}
+
+func RunningTestAccountHolds(t *testing.T) {
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewBuffer([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	accountPath := "/api/v1/accounts/" + BudhiGoldAccountNo
+	getAccount := func() *AccountIndividual {
+		recorder := send(http.MethodGet, accountPath, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		bodyObj := &IndividualAccountResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &bodyObj))
+		return bodyObj.Data
+	}
+	before := getAccount()
+	assert.Equal(t, before.Balance, before.AvailableBalance)
+
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, accountPath+"/holds", `{"amount": 0, "description": "Nothing", "creator": "max"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, accountPath+"/holds", `{"amount": 100, "description": "Yesterday", "expires_at": "2000-01-01T00:00:00Z", "creator": "max"}`).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodPost, "/api/v1/accounts/NOSUCHACCOUNT/holds", `{"amount": 100, "description": "Nowhere", "creator": "max"}`).Code)
+
+	recorder := send(http.MethodPost, accountPath+"/holds", `{"amount": 10000, "description": "Gold Checkout", "creator": "max"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	holdObj := &HoldResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &holdObj))
+	assert.Equal(t, "HELD", holdObj.Data.Status)
+	holdPath := "/api/v1/holds/" + holdObj.Data.HoldID
+	held := getAccount()
+	assert.Equal(t, before.Balance, held.Balance)
+	assert.Equal(t, before.Balance-10000, held.AvailableBalance)
+
+	recorder = send(http.MethodPost, holdPath+"/capture", fmt.Sprintf(`{"account_number": "%s", "amount": 4000, "author": "max"}`, GoldReserveAccountNo))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = send(http.MethodGet, holdPath, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	holdObj = &HoldResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &holdObj))
+	assert.Equal(t, "CAPTURED", holdObj.Data.Status)
+	assert.Equal(t, int64(4000), holdObj.Data.CapturedAmount)
+	assert.NotEmpty(t, holdObj.Data.JournalID)
+	captured := getAccount()
+	assert.Equal(t, before.Balance-4000, captured.Balance)
+	assert.Equal(t, captured.Balance, captured.AvailableBalance)
+
+	assert.Equal(t, http.StatusConflict, send(http.MethodPost, holdPath+"/release", `{"author": "max"}`).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/api/v1/holds/NOSUCHHOLD", "").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodPost, "/api/v1/holds/NOSUCHHOLD/release", `{"author": "max"}`).Code)
+
+	recorder = send(http.MethodPost, accountPath+"/holds", `{"amount": 500, "description": "Cancelled Gold Checkout", "creator": "max"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	holdObj = &HoldResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &holdObj))
+	recorder = send(http.MethodPost, "/api/v1/holds/"+holdObj.Data.HoldID+"/release", `{"author": "max"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "RELEASED")
+	released := getAccount()
+	assert.Equal(t, captured.Balance, released.AvailableBalance)
+}
+
+func RunningTestCreateChartOfAccounts(t *testing.T) {
+	for _, node := range []struct{ code, parent, name, coaType string }{
+		{"1", "", "Assets", "ASSET"},
+		{"1.1", "1", "Gold Assets", "ASSET"},
+		{"1.1.1", "1.1", "Gold Reserves", "ASSET"},
+		{"1.1.2", "1.1", "Gold Wallets", "ASSET"},
+		{"1.2", "1", "Point Assets", "ASSET"},
+		{"1.2.1", "1.2", "Point Reserves", "ASSET"},
+		{"1.2.2", "1.2", "Point Wallets", "ASSET"},
+		{"2", "", "Liabilities", "LIABILITY"},
+		{"2.1", "2", "Gold Liabilities", "LIABILITY"},
+		{"2.1.1", "2.1", "Gold Commitments", "LIABILITY"},
+		{"2.2", "2", "Point Liabilities", "LIABILITY"},
+		{"2.2.1", "2.2", "Point Commitments", "LIABILITY"},
+	} {
+		body := fmt.Sprintf(`{"code": "%s", "parent_code": "%s", "name": "%s", "type": "%s", "creator": "max"}`, node.code, node.parent, node.name, node.coaType)
+		req, err := http.NewRequest(http.MethodPost, "http://localhost/api/v1/coa", bytes.NewBuffer([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, node.code)
+	}
+}
+
+type COAResponse struct {
+	Message   string     `json:"message"`
+	Status    string     `json:"status"`
+	Data      *COAEntity `json:"data"`
+	ErrorCode int        `json:"error_code"`
+}
+
+type COABalanceResponse struct {
+	Message   string            `json:"message"`
+	Status    string            `json:"status"`
+	Data      *COABalanceEntity `json:"data"`
+	ErrorCode int               `json:"error_code"`
+}
+
+func RunningTestChartOfAccounts(t *testing.T) {
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewBuffer([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	// accounts must be under an existing node sharing their alignment
+	recorder := send(http.MethodPost, "/api/v1/accounts", `{"account_number": "GOLDNOCOA", "name": "No COA", "description": "Gold account outside the chart",
+		"coa": "9.9", "currency": "GOLD", "alignment": "DEBIT", "creator": "max"}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = send(http.MethodPost, "/api/v1/accounts", `{"account_number": "GOLDMISALIGNED", "name": "Misaligned", "description": "Credit gold account under the assets",
+		"coa": "1.1.2", "currency": "GOLD", "alignment": "CREDIT", "creator": "max"}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPatch, "/api/v1/accounts/"+BudhiGoldAccountNo, `{"coa": "2.1.1", "author": "max"}`).Code)
+
+	recorder = send(http.MethodGet, "/api/v1/coa", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	listObj := &struct {
+		Data []*COAEntity `json:"data"`
+	}{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &listObj))
+	if assert.Len(t, listObj.Data, 12) {
+		assert.Equal(t, "1", listObj.Data[0].Code)
+		assert.Equal(t, "1.1", listObj.Data[1].Code)
+		assert.Equal(t, "1", listObj.Data[1].ParentCode)
+	}
+	recorder = send(http.MethodGet, "/api/v1/coa/2.1", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	coaObj := &COAResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &coaObj))
+	assert.Equal(t, "LIABILITY", coaObj.Data.Type)
+	assert.Equal(t, "CREDIT", coaObj.Data.Alignment)
+
+	assert.Equal(t, http.StatusConflict, send(http.MethodPost, "/api/v1/coa", `{"code": "1.1", "parent_code": "1", "name": "Again", "type": "ASSET", "creator": "max"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/api/v1/coa", `{"code": "1.3", "parent_code": "1", "name": "Debts", "type": "LIABILITY", "creator": "max"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/api/v1/coa", `{"code": "3", "name": "Others", "type": "OTHER", "creator": "max"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/api/v1/coa", `{"code": "1.%", "parent_code": "1", "name": "Wildcard", "type": "ASSET", "creator": "max"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPatch, "/api/v1/coa/1.1", `{"parent_code": "1.1.1", "author": "max"}`).Code)
+	recorder = send(http.MethodPatch, "/api/v1/coa/1.1", `{"name": "Gold Holdings", "author": "max"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "Gold Holdings")
+
+	assert.Equal(t, http.StatusConflict, send(http.MethodDelete, "/api/v1/coa/1", "").Code)
+	assert.Equal(t, http.StatusConflict, send(http.MethodDelete, "/api/v1/coa/1.1.2", "").Code)
+	recorder = send(http.MethodPost, "/api/v1/coa", `{"code": "1.9", "parent_code": "1", "name": "Unused Contra Assets", "type": "ASSET", "alignment": "CREDIT", "creator": "max"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"alignment":"CREDIT"`)
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, "/api/v1/coa/1.9", "").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/api/v1/coa/1.9", "").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/api/v1/coa/1.9/balance", "").Code)
+
+	// the gold assets roll up the balance of every gold account under them
+	var goldAssets int64
+	for _, accountNo := range []string{GoldReserveAccountNo, FerdinandGoldAccountNo, BudhiGoldAccountNo, "GOLDNOOVERDRAFT"} {
+		recorder = send(http.MethodGet, "/api/v1/accounts/"+accountNo, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		accountObj := &IndividualAccountResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &accountObj))
+		goldAssets += accountObj.Data.Balance
+	}
+	recorder = send(http.MethodGet, "/api/v1/coa/1.1/balance", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	balanceObj := &COABalanceResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &balanceObj))
+	if assert.Len(t, balanceObj.Data.Balances, 1) {
+		assert.Equal(t, "GOLD", balanceObj.Data.Balances[0].Currency)
+		assert.Equal(t, goldAssets, balanceObj.Data.Balances[0].Balance)
+		assert.Equal(t, 4, balanceObj.Data.Balances[0].Accounts)
+	}
+	recorder = send(http.MethodGet, "/api/v1/coa/1/balance", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	balanceObj = &COABalanceResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &balanceObj))
+	if assert.Len(t, balanceObj.Data.Balances, 2) {
+		assert.Equal(t, "GOLD", balanceObj.Data.Balances[0].Currency)
+		assert.Equal(t, goldAssets, balanceObj.Data.Balances[0].Balance)
+		assert.Equal(t, "POINT", balanceObj.Data.Balances[1].Currency)
+	}
+}
+
+type TrialBalanceResponse struct {
+	Message   string              `json:"message"`
+	Status    string              `json:"status"`
+	Data      *TrialBalanceEntity `json:"data"`
+	ErrorCode int                 `json:"error_code"`
+}
+
+func RunningTestTrialBalance(t *testing.T) {
+	get := func(query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost/api/v1/reports/trial-balance"+query, nil)
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := get("")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	tbObj := &TrialBalanceResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &tbObj))
+	assert.True(t, tbObj.Data.Balanced)
+	if assert.Len(t, tbObj.Data.Currencies, 2) {
+		gold := tbObj.Data.Currencies[0]
+		assert.Equal(t, "GOLD", gold.Currency)
+		assert.True(t, gold.Balanced)
+		assert.Equal(t, gold.Debit, gold.Credit)
+		assert.NotZero(t, gold.Debit)
+		assert.Equal(t, "POINT", tbObj.Data.Currencies[1].Currency)
+		// the totals leave every account with its balance
+		for _, group := range gold.COAs {
+			for _, account := range group.Accounts {
+				req, err := http.NewRequest(http.MethodGet, "http://localhost/api/v1/accounts/"+account.AccountNumber, nil)
+				assert.NoError(t, err)
+				req.Header.Add("Authorization", middlewares.GenHMAC())
+				accountRecorder := httptest.NewRecorder()
+				Router.ServeHTTP(accountRecorder, req)
+				accountObj := &IndividualAccountResponse{}
+				assert.NoError(t, json.Unmarshal(accountRecorder.Body.Bytes(), &accountObj))
+				assert.Equal(t, accountObj.Data.Balance, account.Balance, account.AccountNumber)
+				assert.Equal(t, group.Code, accountObj.Data.COA)
+			}
+		}
+		if assert.NotEmpty(t, gold.COAs) {
+			assert.Equal(t, "1.1.1", gold.COAs[0].Code)
+			assert.Equal(t, "Gold Reserves", gold.COAs[0].Name)
+		}
+	}
+
+	recorder = get("?currency=GOLD&at=2000-01-01T00:00:00")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	tbObj = &TrialBalanceResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &tbObj))
+	assert.Equal(t, "GOLD", tbObj.Data.Currency)
+	if assert.Len(t, tbObj.Data.Currencies, 1) {
+		assert.Zero(t, tbObj.Data.Currencies[0].Debit)
+		assert.Zero(t, tbObj.Data.Currencies[0].Credit)
+	}
+
+	recorder = get("?currency=GOLD&format=csv")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(recorder.Body.String(), "currency,coa,coa_name,account_number,account_name,alignment,debit,credit,balance\n"))
+	assert.Contains(t, recorder.Body.String(), "GOLD,,,TOTAL,,,")
+	recorder = get("?format=text")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "Balanced         : true")
+
+	assert.Equal(t, http.StatusBadRequest, get("?format=xml").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?at=yesterday").Code)
+	assert.Equal(t, http.StatusNotFound, get("?currency=NOSUCH").Code)
+}
+
+type BalanceSheetResponse struct {
+	Message   string              `json:"message"`
+	Status    string              `json:"status"`
+	Data      *BalanceSheetEntity `json:"data"`
+	ErrorCode int                 `json:"error_code"`
+}
+
+type IncomeStatementResponse struct {
+	Message   string                 `json:"message"`
+	Status    string                 `json:"status"`
+	Data      *IncomeStatementEntity `json:"data"`
+	ErrorCode int                    `json:"error_code"`
+}
+
+func RunningTestFinancialStatements(t *testing.T) {
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewBuffer([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	getBalanceSheet := func(query string) *BalanceSheetEntity {
+		recorder := send(http.MethodGet, "/api/v1/reports/balance-sheet"+query, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		bsObj := &BalanceSheetResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &bsObj))
+		return bsObj.Data
+	}
+
+	before := time.Now().Add(-time.Second).Format(RestTimeFormat)
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/api/v1/coa", `{"code": "4", "name": "Fees", "type": "INCOME", "creator": "max"}`).Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/api/v1/accounts", `{"account_number": "GOLDFEES", "name": "Gold Fees", "description": "Gold fee income",
+		"coa": "4", "currency": "GOLD", "alignment": "CREDIT", "creator": "max"}`).Code)
+	recorder := send(http.MethodPost, "/api/v1/journals", fmt.Sprintf(`{"description": "Gold Fee", "creator": "max", "transactions": [
+		{"account_number": "%s", "description": "Fee received", "alignment": "DEBIT", "amount": 100},
+		{"account_number": "GOLDFEES", "description": "Fee earned", "alignment": "CREDIT", "amount": 100}]}`, GoldReserveAccountNo))
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	bs := getBalanceSheet("")
+	assert.True(t, bs.Balanced)
+	if assert.Len(t, bs.Statements, 2) {
+		gold := bs.Statements[0]
+		assert.Equal(t, "GOLD", gold.Currency)
+		assert.Equal(t, int64(100), gold.NetIncome)
+		if assert.Len(t, gold.Sections, 3) {
+			assets, liabilities := gold.Sections[0], gold.Sections[1]
+			assert.Equal(t, "ASSET", assets.Type)
+			assert.Equal(t, assets.Total, liabilities.Total+gold.Sections[2].Total+gold.NetIncome)
+			assert.Equal(t, "1", assets.Lines[0].Code)
+			assert.Equal(t, assets.Total, assets.Lines[0].Amount)
+			assert.Equal(t, "1.1", assets.Lines[1].Code)
+			assert.Equal(t, 1, assets.Lines[1].Level)
+			// the point assets are not gold
+			assert.Equal(t, assets.Lines[0].Amount, assets.Lines[1].Amount)
+			assert.Equal(t, "EQUITY", gold.Sections[2].Type)
+			assert.Empty(t, gold.Sections[2].Lines)
+		}
+		assert.Equal(t, "POINT", bs.Statements[1].Currency)
+		assert.Zero(t, bs.Statements[1].NetIncome)
+	}
+	bs = getBalanceSheet("?currency=POINT&at=2000-01-01T00:00:00")
+	if assert.Len(t, bs.Statements, 1) {
+		assert.Zero(t, bs.Statements[0].Sections[0].Total)
+	}
+
+	recorder = send(http.MethodGet, "/api/v1/reports/income-statement?currency=GOLD&from="+before, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	isObj := &IncomeStatementResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &isObj))
+	if assert.Len(t, isObj.Data.Statements, 1) {
+		gold := isObj.Data.Statements[0]
+		assert.Equal(t, int64(100), gold.NetIncome)
+		assert.Nil(t, gold.Balanced)
+		if assert.Len(t, gold.Sections, 2) {
+			assert.Equal(t, "INCOME", gold.Sections[0].Type)
+			assert.Equal(t, int64(100), gold.Sections[0].Total)
+			assert.Equal(t, []*StatementLineEntity{{Code: "4", Name: "Fees", Level: 0, Amount: 100}}, gold.Sections[0].Lines)
+			assert.Zero(t, gold.Sections[1].Total)
+		}
+	}
+	recorder = send(http.MethodGet, "/api/v1/reports/income-statement?currency=GOLD&until="+before, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	isObj = &IncomeStatementResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &isObj))
+	if assert.Len(t, isObj.Data.Statements, 1) {
+		assert.Zero(t, isObj.Data.Statements[0].NetIncome)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodGet, "/api/v1/reports/balance-sheet?at=today", "").Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodGet, "/api/v1/reports/income-statement?from=2021-01-02T00:00:00&until=2021-01-01T00:00:00", "").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/api/v1/reports/balance-sheet?reporting_currency=NOSUCH", "").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/api/v1/reports/income-statement?currency=NOSUCH", "").Code)
+
+	bs = getBalanceSheet("?reporting_currency=GOLD")
+	if assert.Len(t, bs.Statements, 1) {
+		assert.Equal(t, "GOLD", bs.Statements[0].Currency)
+		assert.Equal(t, int64(100), bs.Statements[0].NetIncome)
+		assert.Equal(t, "GOLD", bs.ReportingCurrency)
+	}
+}
+
+type IntegrityReportResponse struct {
+	Message   string                 `json:"message"`
+	Status    string                 `json:"status"`
+	Data      *IntegrityReportEntity `json:"data"`
+	ErrorCode int                    `json:"error_code"`
+}
+
+func RunningTestIntegrity(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/api/v1/admin/integrity", nil)
+	assert.NoError(t, err)
+	req.Header.Add("Authorization", middlewares.GenHMAC())
+	recorder := httptest.NewRecorder()
+	Router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	reportObj := &IntegrityReportResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &reportObj))
+	assert.True(t, reportObj.Data.Consistent, recorder.Body.String())
+	assert.Empty(t, reportObj.Data.Discrepancies)
+	assert.GreaterOrEqual(t, reportObj.Data.Accounts, 9)
+	assert.Greater(t, reportObj.Data.Transactions, 0)
+}
+
+type JournalChainResponse struct {
+	Message   string              `json:"message"`
+	Status    string              `json:"status"`
+	Data      *JournalChainEntity `json:"data"`
+	ErrorCode int                 `json:"error_code"`
+}
+
+func RunningTestJournalChain(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the in memory journal manager does not chain journals")
+	}
+	get := func(query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost/api/v1/admin/journal-chain"+query, nil)
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := get("")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	chainObj := &JournalChainResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &chainObj))
+	assert.True(t, chainObj.Data.Valid, recorder.Body.String())
+	assert.Nil(t, chainObj.Data.BrokenLink)
+	assert.Equal(t, int64(1), chainObj.Data.FirstSequence)
+	assert.Greater(t, chainObj.Data.Journals, 0)
+	assert.Equal(t, chainObj.Data.LastSequence, int64(chainObj.Data.Journals))
+
+	recorder = get("?from=2021-01-01T00:00:00&until=2021-01-02T00:00:00")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	chainObj = &JournalChainResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &chainObj))
+	assert.True(t, chainObj.Data.Valid)
+	assert.Zero(t, chainObj.Data.Journals)
+
+	assert.Equal(t, http.StatusBadRequest, get("?from=yesterday").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?from=2021-01-02T00:00:00&until=2021-01-01T00:00:00").Code)
+}
+
+type PeriodResponse struct {
+	Message   string        `json:"message"`
+	Status    string        `json:"status"`
+	Data      *PeriodEntity `json:"data"`
+	ErrorCode int           `json:"error_code"`
+}
+
+type PeriodListResponse struct {
+	Message   string          `json:"message"`
+	Status    string          `json:"status"`
+	Data      []*PeriodEntity `json:"data"`
+	ErrorCode int             `json:"error_code"`
+}
+
+func RunningTestPeriods(t *testing.T) {
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "http://localhost/api/v1/periods"+path, bytes.NewReader([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	period := func(recorder *httptest.ResponseRecorder) *PeriodEntity {
+		periodObj := &PeriodResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &periodObj))
+		return periodObj.Data
+	}
+
+	recorder := call(http.MethodGet, "/2000-01", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "OPEN", period(recorder).Status)
+	assert.Equal(t, "2000-02-01T00:00:00Z", period(recorder).End)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodGet, "/2000-13", "").Code)
+
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/2000-01/close", `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/2000-1/close", `{"creator":"TESTING"}`).Code)
+	assert.Equal(t, http.StatusConflict, call(http.MethodPost, "/"+time.Now().UTC().Format(PeriodFormat)+"/close", `{"creator":"TESTING"}`).Code)
+
+	// nothing was posted in january 2000, closing it posts no journal
+	recorder = call(http.MethodPost, "/2000-01/close", `{"creator":"TESTING"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, "CLOSED", period(recorder).Status)
+	assert.Equal(t, "TESTING", period(recorder).ClosedBy)
+	assert.Empty(t, period(recorder).ClosingJournalIDs)
+	assert.Equal(t, http.StatusConflict, call(http.MethodPost, "/2000-01/close", `{"creator":"TESTING"}`).Code)
+	recorder = call(http.MethodGet, "/1999-12", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "CLOSED", period(recorder).Status)
+	assert.Empty(t, period(recorder).ClosedBy)
+
+	recorder = call(http.MethodGet, "", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	listObj := &PeriodListResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &listObj))
+	if assert.Len(t, listObj.Data, 1) {
+		assert.Equal(t, "2000-01", listObj.Data[0].Period)
+	}
+}
+
+type EffectiveJournalListResponse struct {
+	Message string `json:"message"`
+	Data    struct {
+		Journals []struct {
+			JournalID      string    `json:"journal_id"`
+			JournalingTime time.Time `json:"journaling_time"`
+		} `json:"journals"`
+	} `json:"data"`
+}
+
+type EffectiveTransactionListResponse struct {
+	Message string                   `json:"message"`
+	Data    *TransactionListResponse `json:"data"`
+}
+
+func RunningTestEffectiveDates(t *testing.T) {
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewReader([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	postJournal := func(effectiveTime string) *httptest.ResponseRecorder {
+		return call(http.MethodPost, "/api/v1/journals", fmt.Sprintf(`{
+  "description": "Backdated transfer",
+  "effective_time": "%s",
+  "transactions": [
+    {"account_number": "%s", "description": "Backdated receive", "alignment": "DEBIT", "amount": 10},
+    {"account_number": "%s", "description": "Backdated send", "alignment": "CREDIT", "amount": 10}
+  ],
+  "creator": "max"
+}`, effectiveTime, BudhiGoldAccountNo, FerdinandGoldAccountNo))
+	}
+	// range returns the from and until query parameters of a minute around the time
+	around := func(at time.Time) string {
+		return fmt.Sprintf("from=%s&until=%s", at.Add(-time.Minute).Format(RestTimeFormat), at.Add(time.Minute).Format(RestTimeFormat))
+	}
+
+	bookedAt := time.Now().UTC()
+	effective := bookedAt.Add(-2 * time.Hour).Truncate(time.Second)
+	recorder := postJournal(effective.Format(RestTimeFormat))
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	created := &CreateAccountResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	journalID := created.Data
+
+	recorder = call(http.MethodGet, "/api/v1/journals/"+journalID, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	journalObj := &struct {
+		Data *JournalDetail `json:"data"`
+	}{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &journalObj))
+	journalingTime, err := time.Parse(time.RFC3339, journalObj.Data.JournalingTime)
+	assert.NoError(t, err)
+	// the in memory journal manager dates the journals and transactions when they are persisted
+	if !testing.Short() {
+		assert.True(t, effective.Equal(journalingTime), journalObj.Data.JournalingTime)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, postJournal(bookedAt.Add(time.Hour).Format(RestTimeFormat)).Code)
+	assert.Equal(t, http.StatusBadRequest, postJournal(bookedAt.Add(-48*time.Hour).Format(RestTimeFormat)).Code)
+	assert.Equal(t, http.StatusBadRequest, postJournal("yesterday").Code)
+	recorder = call(http.MethodPost, "/api/v1/journals/reversal", fmt.Sprintf(`{"description": "Early reversal", "journal_id": "%s", "creator": "max", "effective_time": "%s"}`,
+		journalID, effective.Add(-time.Hour).Format(RestTimeFormat)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	listJournals := func(query string) []string {
+		recorder := call(http.MethodGet, "/api/v1/journals?page=1&size=50&"+query, "")
+		assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		listObj := &EffectiveJournalListResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &listObj))
+		ids := make([]string, 0)
+		for _, journal := range listObj.Data.Journals {
+			ids = append(ids, journal.JournalID)
+		}
+		return ids
+	}
+	assert.Contains(t, listJournals("date=booking&"+around(bookedAt)), journalID)
+	if !testing.Short() {
+		assert.Contains(t, listJournals(around(effective)), journalID)
+		assert.Contains(t, listJournals("date=effective&"+around(effective)), journalID)
+		assert.NotContains(t, listJournals("date=booking&"+around(effective)), journalID)
+	}
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodGet, "/api/v1/journals?page=1&size=50&date=posting&"+around(effective), "").Code)
+
+	listTransactions := func(query string) []string {
+		recorder := call(http.MethodGet, "/api/v1/accounts/"+BudhiGoldAccountNo+"/transactions?page=1&size=50&"+query, "")
+		assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		listObj := &EffectiveTransactionListResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &listObj))
+		ids := make([]string, 0)
+		for _, trx := range listObj.Data.Transactions {
+			ids = append(ids, trx.JournalID)
+		}
+		return ids
+	}
+	assert.Contains(t, listTransactions("date=booking&"+around(bookedAt)), journalID)
+	if !testing.Short() {
+		assert.Contains(t, listTransactions(around(effective)), journalID)
+		assert.NotContains(t, listTransactions("date=booking&"+around(effective)), journalID)
+	}
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodGet, "/api/v1/accounts/"+BudhiGoldAccountNo+"/transactions?page=1&size=50&date=posting&"+around(effective), "").Code)
+}
+
+type JournalReversalResponse struct {
+	Message string                 `json:"message"`
+	Data    *JournalReversalEntity `json:"data"`
+}
+
+func RunningTestReversals(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the in memory journal manager looks up the reversal journal rather than the journal it reverses, and refuses every reversal")
+	}
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewReader([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	recorder := call(http.MethodPost, "/api/v1/journals", fmt.Sprintf(`{
+  "description": "Transfer to reverse",
+  "transactions": [
+    {"account_number": "%s", "description": "Reversible receive", "alignment": "DEBIT", "amount": 100},
+    {"account_number": "%s", "description": "Reversible send", "alignment": "CREDIT", "amount": 100}
+  ],
+  "creator": "max"
+}`, BudhiGoldAccountNo, FerdinandGoldAccountNo))
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	created := &CreateAccountResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	journalID := created.Data
+
+	status := func() *JournalReversalEntity {
+		recorder := call(http.MethodGet, "/api/v1/journals/"+journalID+"/reversal", "")
+		assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		statusObj := &JournalReversalResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &statusObj))
+		return statusObj.Data
+	}
+	legs := make(map[string]string)
+	reversal := status()
+	assert.False(t, reversal.FullyReversed)
+	if assert.Len(t, reversal.Legs, 2) {
+		for _, leg := range reversal.Legs {
+			assert.Equal(t, int64(100), leg.Outstanding)
+			legs[leg.Alignment] = leg.TransactionID
+		}
+	}
+	reverse := func(transactions string) *httptest.ResponseRecorder {
+		return call(http.MethodPost, "/api/v1/journals/reversal", fmt.Sprintf(`{"description": "Reversal", "journal_id": "%s", "creator": "max"%s}`,
+			journalID, transactions))
+	}
+	partial := func(debit, credit int64) string {
+		return fmt.Sprintf(`, "transactions": [{"transaction_id": "%s", "amount": %d}, {"transaction_id": "%s", "amount": %d}]`,
+			legs["DEBIT"], debit, legs["CREDIT"], credit)
+	}
+
+	recorder = reverse(partial(30, 30))
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	recorder = call(http.MethodGet, "/api/v1/journals/"+created.Data, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	journalObj := &struct {
+		Data *JournalDetail `json:"data"`
+	}{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &journalObj))
+	assert.True(t, journalObj.Data.Reversal)
+	assert.Equal(t, journalID, journalObj.Data.ReversedJournal)
+	if assert.Len(t, journalObj.Data.Transactions, 2) {
+		for _, trx := range journalObj.Data.Transactions {
+			assert.Equal(t, int64(30), trx.Amount)
+		}
+	}
+	for _, leg := range status().Legs {
+		assert.Equal(t, int64(30), leg.Reversed)
+		assert.Equal(t, int64(70), leg.Outstanding)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, reverse(partial(80, 80)).Code)
+	assert.Equal(t, http.StatusBadRequest, reverse(partial(10, 20)).Code)
+	assert.Equal(t, http.StatusBadRequest, reverse(partial(0, 0)).Code)
+	assert.Equal(t, http.StatusBadRequest, reverse(`, "transactions": [{"transaction_id": "NOTALEG", "amount": 10}]`).Code)
+
+	// a full reversal takes back what is left
+	recorder = reverse("")
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	reversal = status()
+	assert.True(t, reversal.FullyReversed)
+	for _, leg := range reversal.Legs {
+		assert.Equal(t, int64(100), leg.Reversed)
+		assert.Zero(t, leg.Outstanding)
+	}
+	assert.Equal(t, http.StatusBadRequest, reverse("").Code)
+	assert.Equal(t, http.StatusBadRequest, reverse(partial(1, 1)).Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodGet, "/api/v1/journals/NOTAJOURNAL/reversal", "").Code)
+}
+
+type JournalBatchResponse struct {
+	Message string              `json:"message"`
+	Data    *JournalBatchEntity `json:"data"`
+}
+
+func RunningTestJournalBatch(t *testing.T) {
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewReader([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	journal := func(debit, credit string, amount int64) string {
+		return fmt.Sprintf(`{"description": "Batch transfer", "creator": "max", "transactions": [
+    {"account_number": "%s", "description": "Batch receive", "alignment": "DEBIT", "amount": %d},
+    {"account_number": "%s", "description": "Batch send", "alignment": "CREDIT", "amount": %d}]}`, debit, amount, credit, amount)
+	}
+	postBatch := func(mode, body string, expectedStatus int) *JournalBatchEntity {
+		recorder := call(http.MethodPost, "/api/v1/journals/batch"+mode, body)
+		assert.Equal(t, expectedStatus, recorder.Code, recorder.Body.String())
+		batchObj := &JournalBatchResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &batchObj))
+		return batchObj.Data
+	}
+	balance := func(accountNumber string) int64 {
+		recorder := call(http.MethodGet, "/api/v1/accounts/"+accountNumber, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		accountObj := &IndividualAccountResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &accountObj))
+		return accountObj.Data.Balance
+	}
+	budhi, ferdinand := balance(BudhiGoldAccountNo), balance(FerdinandGoldAccountNo)
+
+	batch := postBatch("", fmt.Sprintf("[%s, %s]", journal(BudhiGoldAccountNo, FerdinandGoldAccountNo, 5), journal(FerdinandGoldAccountNo, BudhiGoldAccountNo, 2)), http.StatusOK)
+	assert.Equal(t, "all-or-nothing", batch.Mode)
+	assert.Equal(t, 2, batch.Posted)
+	for i, item := range batch.Results {
+		assert.Equal(t, i, item.Index)
+		assert.Equal(t, "POSTED", item.Status)
+		assert.Equal(t, http.StatusOK, call(http.MethodGet, "/api/v1/journals/"+item.JournalID, "").Code)
+	}
+	assert.Equal(t, budhi+3, balance(BudhiGoldAccountNo))
+	assert.Equal(t, ferdinand-3, balance(FerdinandGoldAccountNo))
+
+	// a journal that is not valid leaves the whole batch unposted
+	batch = postBatch("?mode=all-or-nothing", fmt.Sprintf("[%s, %s]", journal(BudhiGoldAccountNo, FerdinandGoldAccountNo, 5),
+		strings.Replace(journal(BudhiGoldAccountNo, FerdinandGoldAccountNo, 5), `"creator"`, `"effective_time": "yesterday", "creator"`, 1)), http.StatusUnprocessableEntity)
+	assert.Zero(t, batch.Posted)
+	assert.Equal(t, 1, batch.Refused)
+	assert.Equal(t, "NOT_POSTED", batch.Results[0].Status)
+	assert.Empty(t, batch.Results[0].JournalID)
+	assert.Equal(t, "REFUSED", batch.Results[1].Status)
+	assert.Equal(t, "INVALID_EFFECTIVE_TIME", batch.Results[1].ErrorCode)
+	assert.Equal(t, budhi+3, balance(BudhiGoldAccountNo))
+
+	// the same journals sent as newline delimited json, each posted on its own
+	batch = postBatch("?mode=best-effort", strings.Join([]string{
+		strings.ReplaceAll(journal(BudhiGoldAccountNo, FerdinandGoldAccountNo, 4), "\n", ""),
+		strings.ReplaceAll(journal(BudhiGoldAccountNo, "NOTANACCOUNT", 4), "\n", ""),
+		strings.ReplaceAll(journal(BudhiGoldAccountNo, BudhiGoldAccountNo, 4), "\n", ""),
+	}, "\n"), http.StatusOK)
+	assert.Equal(t, 1, batch.Posted)
+	assert.Equal(t, 2, batch.Refused)
+	assert.Equal(t, "POSTED", batch.Results[0].Status)
+	assert.NotEmpty(t, batch.Results[0].JournalID)
+	assert.Equal(t, "ACCOUNT_NOT_FOUND", batch.Results[1].ErrorCode)
+	assert.Equal(t, "DUPLICATE_ACCOUNT", batch.Results[2].ErrorCode)
+	assert.Equal(t, budhi+7, balance(BudhiGoldAccountNo))
+
+	// a journal refused when persisted rolls back the journals posted before it
+	batch = postBatch("", fmt.Sprintf("[%s, %s]", journal(BudhiGoldAccountNo, FerdinandGoldAccountNo, 6), journal(BudhiGoldAccountNo, "NOTANACCOUNT", 6)), http.StatusUnprocessableEntity)
+	assert.Equal(t, "ACCOUNT_NOT_FOUND", batch.Results[1].ErrorCode)
+	// the in memory journal manager can not roll back the journals posted before
+	if !testing.Short() {
+		assert.Equal(t, "NOT_POSTED", batch.Results[0].Status)
+		assert.Equal(t, budhi+7, balance(BudhiGoldAccountNo))
+	}
+
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/journals/batch?mode=sometimes", "[]").Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/journals/batch", "[]").Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/journals/batch", "[{]").Code)
+	maxSize := JournalBatchMaxSize
+	JournalBatchMaxSize = 1
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/journals/batch",
+		fmt.Sprintf("[%s, %s]", journal(BudhiGoldAccountNo, FerdinandGoldAccountNo, 1), journal(BudhiGoldAccountNo, FerdinandGoldAccountNo, 1))).Code)
+	JournalBatchMaxSize = maxSize
+}
+
+type TemplateResponse struct {
+	Message string          `json:"message"`
+	Data    *TemplateEntity `json:"data"`
+}
+
+type JournalIDResponse struct {
+	Message string `json:"message"`
+	Data    string `json:"data"`
+}
+
+func RunningTestJournalTemplates(t *testing.T) {
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewReader([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	balance := func(accountNumber string) int64 {
+		recorder := call(http.MethodGet, "/api/v1/accounts/"+accountNumber, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		accountObj := &IndividualAccountResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &accountObj))
+		return accountObj.Data.Balance
+	}
+	template := fmt.Sprintf(`{"name": "p2p-fee", "description": "Transfer {note}", "creator": "max",
+    "params": [{"name": "from", "type": "ACCOUNT"}, {"name": "to", "type": "ACCOUNT"},
+        {"name": "amount", "type": "AMOUNT"}, {"name": "fee", "type": "PERCENTAGE"}, {"name": "note", "type": "TEXT"}],
+    "legs": [{"account": "{from}", "alignment": "CREDIT", "amount": "{amount} + {amount} * {fee}%%", "description": "Send {note}"},
+        {"account": "{to}", "alignment": "DEBIT", "amount": "{amount}", "description": "Receive {note}"},
+        {"account": "%s", "alignment": "debit", "amount": "{amount} * {fee}%%", "description": "Fee"}]}`, GoldReserveAccountNo)
+	recorder := call(http.MethodPost, "/api/v1/templates", template)
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	templateObj := &TemplateResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &templateObj))
+	assert.Equal(t, "p2p-fee", templateObj.Data.Name)
+	assert.Len(t, templateObj.Data.Params, 5)
+	assert.Equal(t, "DEBIT", templateObj.Data.Legs[2].Alignment)
+	assert.Equal(t, http.StatusConflict, call(http.MethodPost, "/api/v1/templates", template).Code)
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/api/v1/templates/p2p-fee", "").Code)
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/api/v1/templates", "").Code)
+
+	budhi, ferdinand, reserve := balance(BudhiGoldAccountNo), balance(FerdinandGoldAccountNo), balance(GoldReserveAccountNo)
+	recorder = call(http.MethodPost, "/api/v1/templates/p2p-fee/execute", fmt.Sprintf(`{"creator": "max",
+    "params": {"from": "%s", "to": "%s", "amount": 1000, "fee": "1.25", "note": "rent"}}`, BudhiGoldAccountNo, FerdinandGoldAccountNo))
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	journalObj := &JournalIDResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &journalObj))
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/api/v1/journals/"+journalObj.Data, "").Code)
+	// the 12.5 fee is rounded half to even the same way on both legs
+	assert.Equal(t, budhi-1012, balance(BudhiGoldAccountNo))
+	assert.Equal(t, ferdinand+1000, balance(FerdinandGoldAccountNo))
+	assert.Equal(t, reserve+12, balance(GoldReserveAccountNo))
+
+	execute := func(params string) int {
+		return call(http.MethodPost, "/api/v1/templates/p2p-fee/execute", fmt.Sprintf(`{"creator": "max", "params": {%s}}`, params)).Code
+	}
+	accounts := fmt.Sprintf(`"from": "%s", "to": "%s", "note": "rent"`, BudhiGoldAccountNo, FerdinandGoldAccountNo)
+	assert.Equal(t, http.StatusBadRequest, execute(accounts+`, "amount": 1000`))
+	assert.Equal(t, http.StatusBadRequest, execute(accounts+`, "amount": 10.5, "fee": 1`))
+	assert.Equal(t, http.StatusBadRequest, execute(accounts+`, "amount": 1000, "fee": 101`))
+	assert.Equal(t, http.StatusBadRequest, execute(accounts+`, "amount": 1000, "fee": 1, "tip": 5`))
+	assert.Equal(t, http.StatusBadRequest, execute(accounts+`, "amount": 1000, "fee": true`))
+	assert.Equal(t, http.StatusNotFound, call(http.MethodPost, "/api/v1/templates/none/execute", `{"creator": "max", "params": {}}`).Code)
+	assert.Equal(t, budhi-1012, balance(BudhiGoldAccountNo))
+
+	// alignments other than DEBIT or CREDIT are refused rather than taken as CREDIT
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/templates", strings.Replace(
+		strings.Replace(template, "p2p-fee", "p2p-typo", 1), `"alignment": "DEBIT"`, `"alignment": "DEBT"`, 1)).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/templates", `{"name": "Not A Name", "creator": "max"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/journals", fmt.Sprintf(`{"description": "Typo", "creator": "max", "transactions": [
+    {"account_number": "%s", "description": "Receive", "alignment": "DEBT", "amount": 1},
+    {"account_number": "%s", "description": "Send", "alignment": "CREDIT", "amount": 1}]}`, BudhiGoldAccountNo, FerdinandGoldAccountNo)).Code)
+	assert.Equal(t, budhi-1012, balance(BudhiGoldAccountNo))
+
+	assert.Equal(t, http.StatusOK, call(http.MethodDelete, "/api/v1/templates/p2p-fee", "").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodGet, "/api/v1/templates/p2p-fee", "").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodDelete, "/api/v1/templates/p2p-fee", "").Code)
+}
+
+type TransferResponse struct {
+	Message string          `json:"message"`
+	Data    *TransferEntity `json:"data"`
+}
+
+func RunningTestTransfers(t *testing.T) {
+	call := func(body, idempotencyKey string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "http://localhost/api/v1/transfers", bytes.NewReader([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		if len(idempotencyKey) > 0 {
+			req.Header.Add(IdempotencyKeyHeader, idempotencyKey)
+		}
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	transfer := func(from, to string, amount int64, fee string) string {
+		return fmt.Sprintf(`{"from_account": "%s", "to_account": "%s", "amount": %d, %s "creator": "max"}`, from, to, amount, fee)
+	}
+	balance := func(accountNumber string) int64 {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost/api/v1/accounts/"+accountNumber, nil)
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		accountObj := &IndividualAccountResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &accountObj))
+		return accountObj.Data.Balance
+	}
+	budhi, ferdinand, reserve := balance(BudhiGoldAccountNo), balance(FerdinandGoldAccountNo), balance(GoldReserveAccountNo)
+
+	// 0.5% of 2,500 is 12.5, rounded half to even, plus the fixed fee
+	body := transfer(BudhiGoldAccountNo, FerdinandGoldAccountNo, 2500, `"fee": {"fixed": 3, "percentage": 0.5},`)
+	recorder := call(body, "transfer-1")
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	transferObj := &TransferResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &transferObj))
+	assert.Equal(t, int64(2500), transferObj.Data.Amount)
+	assert.Equal(t, int64(15), transferObj.Data.Fee)
+	assert.Equal(t, transferObj.Data.JournalID, transferObj.Data.Journal.JournalID)
+	assert.Len(t, transferObj.Data.Journal.Transactions, 3)
+	assert.Equal(t, budhi-2515, balance(BudhiGoldAccountNo))
+	assert.Equal(t, ferdinand+2500, balance(FerdinandGoldAccountNo))
+	assert.Equal(t, reserve+15, balance(GoldReserveAccountNo))
+	// the in memory transactions do not keep the balance they leave their account with
+	if !testing.Short() {
+		assert.Equal(t, budhi-2515, transferObj.Data.FromAccount.Balance)
+		assert.Equal(t, ferdinand+2500, transferObj.Data.ToAccount.Balance)
+	}
+
+	// a retry is answered with the same transfer without posting it again
+	recorder = call(body, "transfer-1")
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, "true", recorder.Header().Get(IdempotentReplayedHeader))
+	replayed := &TransferResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &replayed))
+	// the in memory journals do not keep the order of their transactions
+	assert.ElementsMatch(t, transferObj.Data.Journal.Transactions, replayed.Data.Journal.Transactions)
+	replayed.Data.Journal.Transactions = transferObj.Data.Journal.Transactions
+	assert.Equal(t, transferObj.Data, replayed.Data)
+	assert.Equal(t, budhi-2515, balance(BudhiGoldAccountNo))
+	assert.Equal(t, http.StatusConflict, call(transfer(BudhiGoldAccountNo, FerdinandGoldAccountNo, 1, ""), "transfer-1").Code)
+
+	// without a fee schedule nothing goes to the fee account, the maximum bounds the fee
+	recorder = call(transfer(FerdinandGoldAccountNo, BudhiGoldAccountNo, 500, ""), "")
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &transferObj))
+	assert.Zero(t, transferObj.Data.Fee)
+	assert.Len(t, transferObj.Data.Journal.Transactions, 2)
+	recorder = call(transfer(FerdinandGoldAccountNo, BudhiGoldAccountNo, 500, `"fee": {"percentage": "10", "maximum": 20},`), "")
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &transferObj))
+	assert.Equal(t, int64(20), transferObj.Data.Fee)
+	assert.Equal(t, reserve+35, balance(GoldReserveAccountNo))
+
+	assert.Equal(t, http.StatusBadRequest, call(transfer(BudhiGoldAccountNo, FerdinandGoldAccountNo, 0, ""), "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(transfer(BudhiGoldAccountNo, BudhiGoldAccountNo, 10, ""), "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(transfer(BudhiGoldAccountNo, BudhiPointAccountNo, 10, ""), "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(transfer(BudhiGoldAccountNo, GoldCommitmentAccountNo, 10, ""), "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(transfer(BudhiGoldAccountNo, FerdinandGoldAccountNo, 10, `"fee": {"percentage": 101},`), "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(transfer(BudhiGoldAccountNo, FerdinandGoldAccountNo, 10, `"fee": {"percentage": "much"},`), "").Code)
+	assert.Equal(t, http.StatusNotFound, call(transfer(BudhiGoldAccountNo, "NOTANACCOUNT", 10, ""), "").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, call(transfer(BudhiPointAccountNo, FerdinandPointAccountNo, 10, `"fee": {"fixed": 1},`), "").Code)
+	assert.Equal(t, ferdinand+1500-20, balance(FerdinandGoldAccountNo))
+}
+
+type ScheduleResponse struct {
+	Message string          `json:"message"`
+	Data    *ScheduleEntity `json:"data"`
+}
+
+type ScheduleRunsResponse struct {
+	Message string                         `json:"message"`
+	Data    *PaginatedScheduleRunsResponse `json:"data"`
+}
+
+func RunningTestJournalSchedules(t *testing.T) {
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewReader([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	balance := func(accountNumber string) int64 {
+		recorder := call(http.MethodGet, "/api/v1/accounts/"+accountNumber, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		accountObj := &IndividualAccountResponse{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &accountObj))
+		return accountObj.Data.Balance
+	}
+	schedule := func(from string, start time.Time) string {
+		return fmt.Sprintf(`{"template_name": "allowance", "cron": "0 * * * *", "start_time": "%s", "creator": "max",
+    "params": {"from": "%s", "to": "%s", "amount": 100}}`, start.UTC().Format(RestTimeFormat), from, FerdinandGoldAccountNo)
+	}
+	recorder := call(http.MethodPost, "/api/v1/templates", `{"name": "allowance", "description": "Allowance", "creator": "max",
+    "params": [{"name": "from", "type": "ACCOUNT"}, {"name": "to", "type": "ACCOUNT"}, {"name": "amount", "type": "AMOUNT"}],
+    "legs": [{"account": "{from}", "alignment": "CREDIT", "amount": "{amount}", "description": "Send allowance"},
+        {"account": "{to}", "alignment": "DEBIT", "amount": "{amount}", "description": "Receive allowance"}]}`)
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	// the schedule starting 90 minutes ago has missed one occurrence, the one starting 30 minutes ago none
+	hour := time.Now().Truncate(time.Hour)
+	recorder = call(http.MethodPost, "/api/v1/schedules", schedule(BudhiGoldAccountNo, hour.Add(-90*time.Minute)))
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	scheduleObj := &ScheduleResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &scheduleObj))
+	scheduleID := scheduleObj.Data.ScheduleID
+	assert.Equal(t, "UTC", scheduleObj.Data.TimeZone)
+	assert.Equal(t, hour.Add(-time.Hour).UTC().Format(time.RFC3339), scheduleObj.Data.NextRunTime)
+	recorder = call(http.MethodPost, "/api/v1/schedules", schedule("NOTANACCOUNT", hour.Add(-30*time.Minute)))
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &scheduleObj))
+	failingID := scheduleObj.Data.ScheduleID
+
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/api/v1/schedules", "").Code)
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/api/v1/schedules/"+scheduleID, "").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodGet, "/api/v1/schedules/NOTASCHEDULE", "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/schedules", strings.Replace(schedule(BudhiGoldAccountNo, hour), "0 * * * *", "hourly", 1)).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/schedules", strings.Replace(schedule(BudhiGoldAccountNo, hour), `"amount": 100`, `"amount": "lots"`, 1)).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/schedules", strings.Replace(schedule(BudhiGoldAccountNo, hour), `"creator": "max"`, `"time_zone": "Mars/Olympus", "creator": "max"`, 1)).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/schedules", strings.Replace(schedule(BudhiGoldAccountNo, hour), hour.UTC().Format(RestTimeFormat), "yesterday", 1)).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/schedules", "{").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodPost, "/api/v1/schedules", strings.Replace(schedule(BudhiGoldAccountNo, hour), "allowance", "none", 1)).Code)
+
+	// every call posts one due occurrence of every schedule, the failed journal is kept in the run history
+	budhi, ferdinand := balance(BudhiGoldAccountNo), balance(FerdinandGoldAccountNo)
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "scheduler")
+	scheduler := NewScheduler(ScheduleMgr, SchedulerConfig{Owner: "replica-a", Lease: time.Minute, BatchSize: 10})
+	runs, err := scheduler.RunDue(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, runs, 2)
+	runs, err = scheduler.RunDue(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, runs, 1)
+	runs, err = scheduler.RunDue(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, runs, 0)
+	assert.Equal(t, budhi-200, balance(BudhiGoldAccountNo))
+	assert.Equal(t, ferdinand+200, balance(FerdinandGoldAccountNo))
+
+	recorder = call(http.MethodGet, "/api/v1/schedules/"+scheduleID+"/runs?page=1&size=10", "")
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	runsObj := &ScheduleRunsResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &runsObj))
+	assert.Equal(t, 2, runsObj.Data.Pagination.TotalEntries)
+	assert.Equal(t, hour.UTC().Format(time.RFC3339), runsObj.Data.Runs[0].ScheduledTime)
+	assert.Equal(t, "POSTED", runsObj.Data.Runs[0].Status)
+	assert.Equal(t, "replica-a", runsObj.Data.Runs[0].Runner)
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/api/v1/journals/"+runsObj.Data.Runs[0].JournalID, "").Code)
+	recorder = call(http.MethodGet, "/api/v1/schedules/"+failingID+"/runs?page=1&size=10", "")
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	runsObj = &ScheduleRunsResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &runsObj))
+	assert.Len(t, runsObj.Data.Runs, 1)
+	assert.Equal(t, "FAILED", runsObj.Data.Runs[0].Status)
+	assert.Empty(t, runsObj.Data.Runs[0].JournalID)
+	assert.NotEmpty(t, runsObj.Data.Runs[0].Error)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodGet, "/api/v1/schedules/"+scheduleID+"/runs", "").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodGet, "/api/v1/schedules/NOTASCHEDULE/runs?page=1&size=10", "").Code)
+
+	// the in memory templates are deleted whether a schedule posts them or not
+	if !testing.Short() {
+		assert.Equal(t, http.StatusConflict, call(http.MethodDelete, "/api/v1/templates/allowance", "").Code)
+	}
+	assert.Equal(t, http.StatusOK, call(http.MethodDelete, "/api/v1/schedules/"+scheduleID, "").Code)
+	assert.Equal(t, http.StatusOK, call(http.MethodDelete, "/api/v1/schedules/"+failingID, "").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodDelete, "/api/v1/schedules/"+failingID, "").Code)
+	assert.Equal(t, http.StatusOK, call(http.MethodDelete, "/api/v1/templates/allowance", "").Code)
+}
+
 func TestRestAll(t *testing.T) {
 
 	logrus.SetLevel(logrus.DebugLevel)
@@ -44,6 +1256,10 @@ func TestRestAll(t *testing.T) {
 		transactionManager = &acccore.InMemoryTransactionManager{}
 		journalManager = &acccore.InMemoryJournalManager{}
 		exchangeManager = acccore.NewInMemoryExchangeManager()
+		idempotencyManager = NewInMemoryIdempotencyManager(journalManager, 24*time.Hour)
+		denominatorManager = NewInMemoryDenominatorManager(exchangeManager)
+		rateManager = NewInMemoryRateManager(RoundHalfEven)
+		accountLifecycleManager = NewInMemoryAccountLifecycleManager(accountManager)
 		uniqueIDGenerator = &acccore.RandomGenUniqueIDGenerator{
 			Length:        16,
 			LowerAlpha:    false,
@@ -51,24 +1267,29 @@ func TestRestAll(t *testing.T) {
 			Numeric:       true,
 			CharSetBuffer: nil,
 		}
+		holdManager = NewInMemoryHoldManager(accountManager, journalManager, uniqueIDGenerator, time.Hour)
+		coaManager = NewInMemoryCOAManager(accountManager)
+		reportManager = NewInMemoryReportManager(accountManager, transactionManager, coaManager, rateManager)
+		integrityManager = NewInMemoryIntegrityManager(accountManager, transactionManager, journalManager)
+		periodManager = NewInMemoryPeriodManager(journalManager, reportManager, coaManager, uniqueIDGenerator, map[string]string{"GOLD": "GOLDCOMMIT"})
+		postingDateManager = NewInMemoryPostingDateManager(journalManager, transactionManager, 24*time.Hour)
+		reversalManager = NewInMemoryReversalManager(journalManager)
+		journalBatchManager = NewInMemoryJournalBatchManager(journalManager)
+		templateManager = NewInMemoryTemplateManager(RoundHalfEven)
+		scheduleManager = NewInMemoryScheduleManager(templateManager, uniqueIDGenerator)
 		acccore.ClearInMemoryTables()
 	} else {
 		t.Log("Running test in normal mode")
-		config.GetInt("")
-		config.Set("db.host", "localhost")
-		config.Set("db.port", "6603")
-		config.Set("db.user", "devuser")
-		config.Set("db.password", "devuser")
-		config.Set("db.name", "devdb")
-
-		repo := &connector.MySQLDBRepository{}
-		assert.NoError(t, repo.Connect(ctx))
-		assert.NoError(t, repo.ClearTables(ctx))
+		repo := connectTestRepository(ctx, t)
 
 		journalManager = NewMySQLJournalManager(repo)
 		accountManager = NewMySQLAccountManager(repo)
 		transactionManager = NewMySQLTransactionManager(repo)
-		exchangeManager = NewMySQLExchangeManager(repo)
+		exchangeManager = NewMySQLExchangeManager(repo, RoundHalfEven)
+		idempotencyManager = NewMySQLIdempotencyManager(repo, 24*time.Hour)
+		denominatorManager = exchangeManager.(DenominatorManager)
+		rateManager = exchangeManager.(RateManager)
+		accountLifecycleManager = accountManager.(AccountLifecycleManager)
 		uniqueIDGenerator = &acccore.RandomGenUniqueIDGenerator{
 			Length:        16,
 			LowerAlpha:    false,
@@ -76,31 +1297,106 @@ func TestRestAll(t *testing.T) {
 			Numeric:       true,
 			CharSetBuffer: nil,
 		}
+		holdManager = NewMySQLHoldManager(repo, uniqueIDGenerator, time.Hour)
+		coaManager = NewMySQLCOAManager(repo)
+		reportManager = NewMySQLReportManager(repo, rateManager)
+		integrityManager = NewMySQLIntegrityManager(repo)
+		journalChainManager = NewMySQLJournalChainManager(repo)
+		periodManager = NewMySQLPeriodManager(repo, uniqueIDGenerator, map[string]string{"GOLD": "GOLDCOMMIT"})
+		postingDateManager = NewMySQLPostingDateManager(repo, 24*time.Hour)
+		reversalManager = NewMySQLReversalManager(repo)
+		journalBatchManager = NewMySQLJournalBatchManager(repo, 2)
+		templateManager = NewMySQLTemplateManager(repo, RoundHalfEven)
+		scheduleManager = NewMySQLScheduleManager(repo, templateManager, uniqueIDGenerator)
 	}
 
 	AccountMgr = accountManager
 	JournalMgr = journalManager
 	TransactionMgr = transactionManager
 	ExchangeMgr = exchangeManager
+	IdempotencyMgr = idempotencyManager
+	DenominatorMgr = denominatorManager
+	RateMgr = rateManager
+	AccountLifecycleMgr = accountLifecycleManager
+	HoldMgr = holdManager
+	COAMgr = coaManager
+	ReportMgr = reportManager
+	IntegrityMgr = integrityManager
+	JournalChainMgr = journalChainManager
+	PeriodMgr = periodManager
+	PostingDateMgr = postingDateManager
+	ReversalMgr = reversalManager
+	JournalBatchMgr = journalBatchManager
+	TemplateMgr = templateManager
+	ScheduleMgr = scheduleManager
+	TransferMgr = NewTransferManager(accountManager, TransferConfig{FeeAccounts: map[string]string{"GOLD": "GOLDRESERVE"}, Rounding: RoundHalfEven})
 	UniqueIDGenerator = uniqueIDGenerator
+	FXMgr = NewFXManager(accountManager, rateManager, uniqueIDGenerator, FXConfig{
+		BaseCurrency:     "GOLD",
+		ClearingAccounts: map[string]string{"GOLD": "GOLDRESERVE", "POINT": "POINTRESERVE"},
+		GainLossAccount:  "GOLDCOMMIT",
+		Rounding:         RoundHalfEven,
+	})
 
 	Router = mux.NewRouter()
 
 	Router.Use(middlewares.SetupContextMiddleware, middlewares.Logger, middlewares.HMACMiddleware)
 	Router.HandleFunc("/api/v1/accounts/{AccountNumber}", GetAccount).Methods("GET")
+	Router.HandleFunc("/api/v1/accounts/{AccountNumber}", UpdateAccount).Methods("PATCH")
+	Router.HandleFunc("/api/v1/accounts/{AccountNumber}/status", SetAccountStatus).Methods("PUT")
 	Router.HandleFunc("/api/v1/accounts/{AccountNumber}/transactions", ListTransactionByAccount).Methods("GET")
+	Router.HandleFunc("/api/v1/accounts/{AccountNumber}/holds", PlaceHold).Methods("POST")
 	Router.HandleFunc("/api/v1/accounts", FindAccount).Methods("GET")
 	Router.HandleFunc("/api/v1/accounts", CreateAccount).Methods("POST")
 
 	Router.HandleFunc("/api/v1/journals", CreateJournal).Methods("POST")
 	Router.HandleFunc("/api/v1/journals", ListJournal).Methods("GET")
 	Router.HandleFunc("/api/v1/journals/reversal", CreateReversalJournal).Methods("POST")
+	Router.HandleFunc("/api/v1/journals/batch", CreateJournalBatch).Methods("POST")
 	Router.HandleFunc("/api/v1/journals/{JournalID}", GetJournal).Methods("GET")
+	Router.HandleFunc("/api/v1/journals/{JournalID}/reversal", GetJournalReversal).Methods("GET")
 
 	Router.HandleFunc("/api/v1/transactions/{TransactionID}", GetTransaction).Methods("GET")
 
+	Router.HandleFunc("/api/v1/transfers", CreateTransfer).Methods("POST")
+
+	Router.HandleFunc("/api/v1/templates", ListTemplates).Methods("GET")
+	Router.HandleFunc("/api/v1/templates", CreateTemplate).Methods("POST")
+	Router.HandleFunc("/api/v1/templates/{TemplateName}", GetTemplate).Methods("GET")
+	Router.HandleFunc("/api/v1/templates/{TemplateName}", DeleteTemplate).Methods("DELETE")
+	Router.HandleFunc("/api/v1/templates/{TemplateName}/execute", ExecuteTemplate).Methods("POST")
+
+	Router.HandleFunc("/api/v1/schedules", ListSchedules).Methods("GET")
+	Router.HandleFunc("/api/v1/schedules", CreateSchedule).Methods("POST")
+	Router.HandleFunc("/api/v1/schedules/{ScheduleID}", GetSchedule).Methods("GET")
+	Router.HandleFunc("/api/v1/schedules/{ScheduleID}", DeleteSchedule).Methods("DELETE")
+	Router.HandleFunc("/api/v1/schedules/{ScheduleID}/runs", ListScheduleRuns).Methods("GET")
+
+	Router.HandleFunc("/api/v1/holds/{HoldID}", GetHold).Methods("GET")
+	Router.HandleFunc("/api/v1/holds/{HoldID}/capture", CaptureHold).Methods("POST")
+	Router.HandleFunc("/api/v1/holds/{HoldID}/release", ReleaseHold).Methods("POST")
+
+	Router.HandleFunc("/api/v1/coa", ListCOA).Methods("GET")
+	Router.HandleFunc("/api/v1/coa", CreateCOA).Methods("POST")
+	Router.HandleFunc("/api/v1/coa/{COACode}", GetCOA).Methods("GET")
+	Router.HandleFunc("/api/v1/coa/{COACode}", UpdateCOA).Methods("PATCH")
+	Router.HandleFunc("/api/v1/coa/{COACode}", DeleteCOA).Methods("DELETE")
+	Router.HandleFunc("/api/v1/coa/{COACode}/balance", GetCOABalance).Methods("GET")
+
+	Router.HandleFunc("/api/v1/reports/trial-balance", GetTrialBalance).Methods("GET")
+	Router.HandleFunc("/api/v1/reports/balance-sheet", GetBalanceSheet).Methods("GET")
+	Router.HandleFunc("/api/v1/reports/income-statement", GetIncomeStatement).Methods("GET")
+
+	Router.HandleFunc("/api/v1/periods", ListPeriods).Methods("GET")
+	Router.HandleFunc("/api/v1/periods/{Period}", GetPeriod).Methods("GET")
+	Router.HandleFunc("/api/v1/periods/{Period}/close", ClosePeriod).Methods("POST")
+
+	Router.HandleFunc("/api/v1/admin/integrity", GetIntegrity).Methods("GET")
+	Router.HandleFunc("/api/v1/admin/journal-chain", GetJournalChain).Methods("GET")
+
 	Router.HandleFunc("/api/v1/exchange/denom", GetCommonDenominator).Methods("GET")
 	Router.HandleFunc("/api/v1/exchange/denom", SetCommonDenominator).Methods("PUT")
+	Router.HandleFunc("/api/v1/exchange/denom/history", ListCommonDenominatorHistory).Methods("GET")
 
 	Router.HandleFunc("/api/v1/currencies", ListCurrencies).Methods("GET")
 	Router.HandleFunc("/api/v1/currencies/{code}", GetCurrency).Methods("GET")
@@ -113,8 +1409,8 @@ func TestRestAll(t *testing.T) {
 
 	t.Run("Test Listing Empty Currencies", RunningTestListCurrenciesEmpty)
 
-	t.Run("Test Create GOLD Currencies", MakeTestCreateCurrency("GOLD", "Gold Currency", 1.0, "max", http.StatusOK, "SUCCESS"))
-	t.Run("Test Create POINT Currencies", MakeTestCreateCurrency("POINT", "Point Currency", 10.0, "max", http.StatusOK, "SUCCESS"))
+	t.Run("Test Create GOLD Currencies", MakeTestCreateCurrency("GOLD", "Gold Currency", "1", "max", http.StatusOK, "SUCCESS"))
+	t.Run("Test Create POINT Currencies", MakeTestCreateCurrency("POINT", "Point Currency", "10", "max", http.StatusOK, "SUCCESS"))
 
 	t.Run("Test Listing Currencies", RunningTestListCurrenciesContainsGoldPoint)
 	t.Run("Test Get Individual Currencies", RunningTestFetchIndividualCurrency)
@@ -122,6 +1418,7 @@ func TestRestAll(t *testing.T) {
 	t.Run("Testing Exchange", RunningTestExchange)
 
 	t.Run("Test Listing Empty Accounts", RunningTestListAccountEmpty)
+	t.Run("Test Creating Chart Of Accounts", RunningTestCreateChartOfAccounts)
 
 	t.Run("Test Creating GoldReserve Accounts",
 		MakeCreateAccountTest("GOLDRESERVE", "Gold Reserve", "Gold Reservation",
@@ -180,16 +1477,37 @@ func TestRestAll(t *testing.T) {
 			BudhiGoldAccountNo, "Receive From Ferdinand",
 			FerdinandGoldAccountNo, "Send To Budhi",
 			50000))
+
+	t.Run("Test Idempotent Budhi TransferTo Ferdinand 1,000 Gold", RunningTestIdempotentJournal)
+	t.Run("Test Ferdinand Exchange 1,000 Point To 100 Gold", RunningTestMultiCurrencyJournal)
+	t.Run("Test Account Lifecycle", RunningTestAccountLifecycle)
+	t.Run("Test Account Balance Limits", RunningTestAccountLimits)
+	t.Run("Test Account Holds", RunningTestAccountHolds)
+	t.Run("Test Chart Of Accounts", RunningTestChartOfAccounts)
+	t.Run("Test Trial Balance", RunningTestTrialBalance)
+	t.Run("Test Financial Statements", RunningTestFinancialStatements)
+	t.Run("Test Integrity", RunningTestIntegrity)
+	t.Run("Test Journal Chain", RunningTestJournalChain)
+	t.Run("Test Effective Dates", RunningTestEffectiveDates)
+	t.Run("Test Reversals", RunningTestReversals)
+	t.Run("Test Journal Batch", RunningTestJournalBatch)
+	t.Run("Test Journal Templates", RunningTestJournalTemplates)
+	t.Run("Test Transfers", RunningTestTransfers)
+	t.Run("Test Journal Schedules", RunningTestJournalSchedules)
+	t.Run("Test Periods", RunningTestPeriods)
 }
 
 type AccountIndividual struct {
-	AccountNumber string `json:"account_number"`
-	Name          string `json:"name"`
-	Description   string `json:"description"`
-	COA           string `json:"coa"`
-	Currency      string `json:"currency"`
-	Alignment     string `json:"alignment"`
-	Balance       int64  `json:"balance"`
+	AccountNumber    string               `json:"account_number"`
+	Name             string               `json:"name"`
+	Description      string               `json:"description"`
+	COA              string               `json:"coa"`
+	Currency         string               `json:"currency"`
+	Alignment        string               `json:"alignment"`
+	Balance          int64                `json:"balance"`
+	Status           string               `json:"status"`
+	Limits           *AccountLimitsEntity `json:"limits"`
+	AvailableBalance int64                `json:"available_balance"`
 }
 type IndividualAccountResponse struct {
 	Message   string             `json:"message"`
@@ -278,6 +1596,63 @@ func MakeJournalTest(desc, accDebit, descDebit, accCredit, descCredit string, am
 	}
 }
 
+func RunningTestIdempotentJournal(t *testing.T) {
+	postJournal := func(path, key, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "http://localhost"+path, bytes.NewBuffer([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", middlewares.GenHMAC())
+		req.Header.Add(IdempotencyKeyHeader, key)
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	body := fmt.Sprintf(`
+{
+  "description": "Transfer Gold",
+  "transactions": [
+    {
+      "account_number": "%s",
+      "description": "Receive From Budhi",
+      "alignment": "DEBIT",
+      "amount": 1000
+    },
+	{
+      "account_number": "%s",
+      "description": "Send To Ferdinand",
+      "alignment": "CREDIT",
+      "amount": 1000
+    }
+  ],
+  "creator": "max"
+}
+`, FerdinandGoldAccountNo, BudhiGoldAccountNo)
+
+	first := postJournal("/api/v1/journals", "budhi-to-ferdinand-1", body)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	// the retry is answered from the first response, without posting the journal again
+	retry := postJournal("/api/v1/journals", "budhi-to-ferdinand-1", strings.ReplaceAll(body, "\n", ""))
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+
+	conflicting := postJournal("/api/v1/journals", "budhi-to-ferdinand-1", strings.ReplaceAll(body, "1000", "2000"))
+	assert.Equal(t, http.StatusConflict, conflicting.Code)
+
+	firstObj := &CreateAccountResponse{}
+	assert.NoError(t, json.Unmarshal(first.Body.Bytes(), &firstObj))
+	reversal := postJournal("/api/v1/journals/reversal", "budhi-to-ferdinand-1",
+		fmt.Sprintf(`{"journal_id": "%s", "description": "reversing", "creator": "max"}`, firstObj.Data))
+	assert.Equal(t, http.StatusConflict, reversal.Code)
+
+	tooLong := postJournal("/api/v1/journals", strings.Repeat("K", 65), body)
+	assert.Equal(t, http.StatusBadRequest, tooLong.Code)
+
+	MakeFetchIndividualAccountTest(BudhiGoldAccountNo, "Budhi Gold", "1.1.2", "GOLD", "DEBIT", 349000, http.StatusOK, "SUCCESS")(t)
+	MakeFetchIndividualAccountTest(FerdinandGoldAccountNo, "Ferdinand Gold", "1.1.2", "GOLD", "DEBIT", 451000, http.StatusOK, "SUCCESS")(t)
+}
+
 func MakeCreateAccountTest(accountNo, name, description, coa, currency, alignment, creator string, expectCode int, targetVar *string) func(t *testing.T) {
 	return func(t *testing.T) {
 		hmac := middlewares.GenHMAC()
@@ -386,9 +1761,9 @@ func RunningTestListAccountFilled(t *testing.T) {
 }
 
 type ListCurrencyItem struct {
-	Code     string  `json:"code"`
-	Name     string  `json:"name"`
-	Exchange float64 `json:"exchange"`
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Exchange string `json:"exchange"`
 }
 
 type ListCurrencyResponse struct {
@@ -431,8 +1806,8 @@ func RunningTestListCurrenciesContainsGoldPoint(t *testing.T) {
 	assert.Equal(t, "POINT", bodyObj.Data[1].Code)
 	assert.Equal(t, "Gold Currency", bodyObj.Data[0].Name)
 	assert.Equal(t, "Point Currency", bodyObj.Data[1].Name)
-	assert.Equal(t, 1.0, bodyObj.Data[0].Exchange)
-	assert.Equal(t, 10.0, bodyObj.Data[1].Exchange)
+	assert.Equal(t, "1", bodyObj.Data[0].Exchange)
+	assert.Equal(t, "10", bodyObj.Data[1].Exchange)
 	assert.Equal(t, "SUCCESS", bodyObj.Status)
 }
 
@@ -443,10 +1818,10 @@ type CreateCurrencyResponse struct {
 	ErrorCode int              `json:"error_code"`
 }
 
-func MakeTestCreateCurrency(code, name string, exchange float64, author string, expectCode int, expectStatus string) func(t *testing.T) {
+func MakeTestCreateCurrency(code, name string, exchange string, author string, expectCode int, expectStatus string) func(t *testing.T) {
 	return func(t *testing.T) {
 		hmac := middlewares.GenHMAC()
-		body1 := fmt.Sprintf(`{"name":"%s", "exchange":%f, "author":"%s"}`, name, exchange, author)
+		body1 := fmt.Sprintf(`{"name":"%s", "exchange":"%s", "author":"%s"}`, name, exchange, author)
 		req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("http://localhost/api/v1/currencies/%s", code), bytes.NewBuffer([]byte(body1)))
 		assert.NoError(t, err)
 		req.Header.Add("Authorization", hmac)
@@ -478,7 +1853,7 @@ func RunningTestFetchIndividualCurrency(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "GOLD", bodyObj.Data.Code)
 	assert.Equal(t, "Gold Currency", bodyObj.Data.Name)
-	assert.Equal(t, 1.0, bodyObj.Data.Exchange)
+	assert.Equal(t, "1", bodyObj.Data.Exchange)
 
 	req, err = http.NewRequest(http.MethodGet, "http://localhost/api/v1/currencies/EMERALD", nil)
 	assert.NoError(t, err)
@@ -495,6 +1870,13 @@ type ExchangeDenominatorResponse struct {
 	ErrorCode int     `json:"error_code"`
 }
 
+type ExchangeDenominatorHistoryResponse struct {
+	Message   string                              `json:"message"`
+	Status    string                              `json:"status"`
+	Data      PaginatedDenominatorHistoryResponse `json:"data"`
+	ErrorCode int                                 `json:"error_code"`
+}
+
 func RunningTestCommonDenominator(t *testing.T) {
 	hmac := middlewares.GenHMAC()
 	req, err := http.NewRequest(http.MethodGet, "http://localhost/api/v1/exchange/denom", nil)
@@ -510,7 +1892,7 @@ func RunningTestCommonDenominator(t *testing.T) {
 	assert.Equal(t, "SUCCESS", bodyObj.Status)
 	assert.Equal(t, 1.0, bodyObj.Data)
 
-	req, err = http.NewRequest(http.MethodPut, "http://localhost/api/v1/exchange/denom?denom=0.123", nil)
+	req, err = http.NewRequest(http.MethodPut, "http://localhost/api/v1/exchange/denom?denom=0.123&author=max", nil)
 	assert.NoError(t, err)
 	req.Header.Add("Authorization", hmac)
 	recorder = httptest.NewRecorder()
@@ -535,59 +1917,98 @@ func RunningTestCommonDenominator(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "SUCCESS", bodyObj.Status)
 	assert.Equal(t, 0.123, bodyObj.Data)
-}
 
-func RunningTestExchange(t *testing.T) {
-	hmac := middlewares.GenHMAC()
-	req, err := http.NewRequest(http.MethodGet, "http://localhost/api/v1/exchange/GOLD/POINT", nil)
+	req, err = http.NewRequest(http.MethodGet, "http://localhost/api/v1/exchange/denom/history?page=1&size=10", nil)
 	assert.NoError(t, err)
 	req.Header.Add("Authorization", hmac)
-	recorder := httptest.NewRecorder()
+	recorder = httptest.NewRecorder()
 	Router.ServeHTTP(recorder, req)
 	assert.Equal(t, http.StatusOK, recorder.Code)
 
-	bodyObj := &ExchangeDenominatorResponse{}
-	err = json.Unmarshal(recorder.Body.Bytes(), &bodyObj)
+	historyObj := &ExchangeDenominatorHistoryResponse{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &historyObj)
 	assert.NoError(t, err)
-	assert.Equal(t, "SUCCESS", bodyObj.Status)
-	assert.Equal(t, 10.0, bodyObj.Data)
+	assert.Equal(t, "SUCCESS", historyObj.Status)
+	assert.Equal(t, 1, historyObj.Data.Pagination.TotalEntries)
+	if assert.Len(t, historyObj.Data.Changes, 1) {
+		assert.Equal(t, 0.123, historyObj.Data.Changes[0].Denom)
+		assert.Equal(t, "max", historyObj.Data.Changes[0].ChangedBy)
+		assert.False(t, historyObj.Data.Changes[0].ChangedAt.IsZero())
+	}
+}
 
-	req, err = http.NewRequest(http.MethodGet, "http://localhost/api/v1/exchange/POINT/GOLD", nil)
-	assert.NoError(t, err)
-	req.Header.Add("Authorization", hmac)
-	recorder = httptest.NewRecorder()
-	Router.ServeHTTP(recorder, req)
-	assert.Equal(t, http.StatusOK, recorder.Code)
+type ExchangeRateResponse struct {
+	Message   string `json:"message"`
+	Status    string `json:"status"`
+	Data      string `json:"data"`
+	ErrorCode int    `json:"error_code"`
+}
 
-	bodyObj = &ExchangeDenominatorResponse{}
-	err = json.Unmarshal(recorder.Body.Bytes(), &bodyObj)
-	assert.NoError(t, err)
-	assert.Equal(t, "SUCCESS", bodyObj.Status)
-	assert.Equal(t, 0.1, bodyObj.Data)
+type ExchangeResponse struct {
+	Message   string      `json:"message"`
+	Status    string      `json:"status"`
+	Data      ExchangeRet `json:"data"`
+	ErrorCode int         `json:"error_code"`
+}
 
-	req, err = http.NewRequest(http.MethodGet, "http://localhost/api/v1/exchange/GOLD/POINT/100", nil)
-	assert.NoError(t, err)
-	req.Header.Add("Authorization", hmac)
-	recorder = httptest.NewRecorder()
-	Router.ServeHTTP(recorder, req)
-	assert.Equal(t, http.StatusOK, recorder.Code)
+func RunningTestExchange(t *testing.T) {
+	t.Run("Test Create SILVER Currencies", MakeTestCreateCurrency("SILVER", "Silver Currency", "0.3", "max", http.StatusOK, "SUCCESS"))
 
-	bodyObj = &ExchangeDenominatorResponse{}
-	err = json.Unmarshal(recorder.Body.Bytes(), &bodyObj)
-	assert.NoError(t, err)
-	assert.Equal(t, "SUCCESS", bodyObj.Status)
-	assert.Equal(t, 1000.0, bodyObj.Data)
+	hmac := middlewares.GenHMAC()
+	for path, expect := range map[string]string{"GOLD/POINT": "10", "POINT/GOLD": "0.1", "POINT/SILVER": "0.03", "SILVER/GOLD": "10/3"} {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost/api/v1/exchange/"+path, nil)
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", hmac)
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
 
-	req, err = http.NewRequest(http.MethodGet, "http://localhost/api/v1/exchange/POINT/GOLD/100", nil)
-	assert.NoError(t, err)
-	req.Header.Add("Authorization", hmac)
-	recorder = httptest.NewRecorder()
-	Router.ServeHTTP(recorder, req)
-	assert.Equal(t, http.StatusOK, recorder.Code)
+		bodyObj := &ExchangeRateResponse{}
+		err = json.Unmarshal(recorder.Body.Bytes(), &bodyObj)
+		assert.NoError(t, err)
+		assert.Equal(t, "SUCCESS", bodyObj.Status)
+		assert.Equal(t, expect, bodyObj.Data, path)
+	}
 
-	bodyObj = &ExchangeDenominatorResponse{}
-	err = json.Unmarshal(recorder.Body.Bytes(), &bodyObj)
-	assert.NoError(t, err)
-	assert.Equal(t, "SUCCESS", bodyObj.Status)
-	assert.Equal(t, 10.0, bodyObj.Data)
+	for path, expect := range map[string]ExchangeRet{
+		"GOLD/POINT/100":                     {Amount: 1000, Exact: "1000", Residue: "0", Rounding: RoundHalfEven},
+		"POINT/GOLD/100":                     {Amount: 10, Exact: "10", Residue: "0", Rounding: RoundHalfEven},
+		"POINT/SILVER/150":                   {Amount: 4, Exact: "4.5", Residue: "0.5", Rounding: RoundHalfEven},
+		"POINT/SILVER/150?rounding=half-up":  {Amount: 5, Exact: "4.5", Residue: "-0.5", Rounding: RoundHalfUp},
+		"POINT/SILVER/250?rounding=half-up":  {Amount: 8, Exact: "7.5", Residue: "-0.5", Rounding: RoundHalfUp},
+		"POINT/SILVER/250":                   {Amount: 8, Exact: "7.5", Residue: "-0.5", Rounding: RoundHalfEven},
+		"SILVER/GOLD/1?rounding=floor":       {Amount: 3, Exact: "10/3", Residue: "1/3", Rounding: RoundFloor},
+		"SILVER/GOLD/-1?rounding=floor":      {Amount: -4, Exact: "-10/3", Residue: "2/3", Rounding: RoundFloor},
+		"SILVER/GOLD/-150?rounding=half-up":  {Amount: -500, Exact: "-500", Residue: "0", Rounding: RoundHalfUp},
+		"POINT/SILVER/-150?rounding=half-up": {Amount: -5, Exact: "-4.5", Residue: "0.5", Rounding: RoundHalfUp},
+	} {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost/api/v1/exchange/"+path, nil)
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", hmac)
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		bodyObj := &ExchangeResponse{}
+		err = json.Unmarshal(recorder.Body.Bytes(), &bodyObj)
+		assert.NoError(t, err)
+		assert.Equal(t, "SUCCESS", bodyObj.Status)
+		assert.Equal(t, expect, bodyObj.Data, path)
+	}
+
+	for path, expectCode := range map[string]int{
+		"POINT/SILVER/150?rounding=ceiling":     http.StatusBadRequest,
+		"GOLD/POINT?at=yesterday":               http.StatusBadRequest,
+		"GOLD/POINT?at=2000-01-01T00:00:00":     http.StatusNotFound,
+		"GOLD/POINT/100?at=2000-01-01T00:00:00": http.StatusNotFound,
+		"GOLD/POINT?at=2999-01-01T00:00:00":     http.StatusOK,
+		"GOLD/POINT/100?at=2999-01-01T00:00:00": http.StatusOK,
+	} {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost/api/v1/exchange/"+path, nil)
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", hmac)
+		recorder := httptest.NewRecorder()
+		Router.ServeHTTP(recorder, req)
+		assert.Equal(t, expectCode, recorder.Code, path)
+	}
 }
@@ -0,0 +1,381 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+)
+
+// COAType is the type of a chart of accounts node.
+type COAType string
+
+const (
+	// COAAsset nodes hold what the ledger owns
+	COAAsset COAType = "ASSET"
+	// COALiability nodes hold what the ledger owes
+	COALiability COAType = "LIABILITY"
+	// COAEquity nodes hold the owners stake
+	COAEquity COAType = "EQUITY"
+	// COAIncome nodes hold the earnings
+	COAIncome COAType = "INCOME"
+	// COAExpense nodes hold the spendings
+	COAExpense COAType = "EXPENSE"
+)
+
+// ParseCOAType parses a chart of accounts node type, case insensitive.
+// Throws ErrInvalidCOA if it is not ASSET, LIABILITY, EQUITY, INCOME or EXPENSE.
+func ParseCOAType(coaType string) (COAType, error) {
+	switch t := COAType(strings.ToUpper(strings.TrimSpace(coaType))); t {
+	case COAAsset, COALiability, COAEquity, COAIncome, COAExpense:
+		return t, nil
+	}
+	return "", fmt.Errorf("%w : unknown type %s", hwerrors.ErrInvalidCOA, coaType)
+}
+
+// NormalAlignment returns the alignment increasing the balance of the accounts of the type,
+// DEBIT for assets and expenses, CREDIT for the others.
+func (t COAType) NormalAlignment() acccore.Alignment {
+	if t == COAAsset || t == COAExpense {
+		return acccore.DEBIT
+	}
+	return acccore.CREDIT
+}
+
+// alignmentName returns DEBIT or CREDIT.
+func alignmentName(alignment acccore.Alignment) string {
+	if alignment == acccore.DEBIT {
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+// ParseAlignment parses an alignment, case insensitive.
+// Throws ErrInvalidAlignment if it is neither DEBIT nor CREDIT.
+func ParseAlignment(alignment string) (acccore.Alignment, error) {
+	switch strings.ToUpper(strings.TrimSpace(alignment)) {
+	case "DEBIT":
+		return acccore.DEBIT, nil
+	case "CREDIT":
+		return acccore.CREDIT, nil
+	}
+	return acccore.CREDIT, fmt.Errorf("%w : %s, should be DEBIT or CREDIT", hwerrors.ErrInvalidAlignment, alignment)
+}
+
+// COA is a node of the chart of accounts tree. Accounts refer to the node through their COA code.
+type COA struct {
+	Code string
+	// ParentCode is the code of the parent node, empty for a root node
+	ParentCode string
+	Name       string
+	// Type is the type of the node, a child node has the type of its parent
+	Type COAType
+	// Alignment is the normal alignment of the node, the accounts of the node must share it
+	Alignment acccore.Alignment
+	CreatedAt time.Time
+	CreatedBy string
+	UpdatedAt time.Time
+	UpdatedBy string
+}
+
+// validCOACode matches the COA codes, they never contain the wildcards of a LIKE pattern.
+var validCOACode = regexp.MustCompile(`^[A-Za-z0-9.\-]{1,10}$`)
+
+// validate makes sure the node has a code, a name and a type.
+func (c *COA) validate() error {
+	if !validCOACode.MatchString(c.Code) {
+		return fmt.Errorf("%w : code %s should be 1 to 10 letters, digits, dots or dashes", hwerrors.ErrInvalidCOA, c.Code)
+	}
+	if len(c.Name) == 0 {
+		return fmt.Errorf("%w : name is required", hwerrors.ErrInvalidCOA)
+	}
+	_, err := ParseCOAType(string(c.Type))
+	return err
+}
+
+// checkCOAParent makes sure the parent of the node exists, has the type of the node, and is not the node
+// or one of its descendants. nodes holds the whole chart of accounts by code.
+func checkCOAParent(code, parentCode string, coaType COAType, nodes map[string]*COA) error {
+	if len(parentCode) == 0 {
+		return nil
+	}
+	parent, ok := nodes[parentCode]
+	if !ok {
+		return fmt.Errorf("%w : parent %s not found", hwerrors.ErrInvalidCOA, parentCode)
+	}
+	if parent.Type != coaType {
+		return fmt.Errorf("%w : %s node can not be under %s node %s", hwerrors.ErrInvalidCOA, coaType, parent.Type, parentCode)
+	}
+	for ancestor := parent; ancestor != nil; ancestor = nodes[ancestor.ParentCode] {
+		if ancestor.Code == code {
+			return fmt.Errorf("%w : %s can not be under its own descendant %s", hwerrors.ErrInvalidCOA, code, parentCode)
+		}
+	}
+	return nil
+}
+
+// coaSubtree returns the code of the node followed by the codes of all its descendants.
+func coaSubtree(code string, nodes []*COA) []string {
+	children := make(map[string][]string)
+	for _, node := range nodes {
+		children[node.ParentCode] = append(children[node.ParentCode], node.Code)
+	}
+	ret := []string{code}
+	for i := 0; i < len(ret); i++ {
+		ret = append(ret, children[ret[i]]...)
+	}
+	return ret
+}
+
+// checkAccountCOA makes sure an account with the alignment may be under the node.
+func checkAccountCOA(coa *COA, alignment acccore.Alignment) error {
+	if coa.Alignment != alignment {
+		return fmt.Errorf("%w : coa %s is %s, the account is %s", hwerrors.ErrCOAAlignmentMismatch, coa.Code, alignmentName(coa.Alignment), alignmentName(alignment))
+	}
+	return nil
+}
+
+// COABalance is the aggregated balance of the accounts under a chart of accounts node in one currency.
+type COABalance struct {
+	Currency string
+	// Balance is in the node alignment, the balance of the accounts aligned the other way is subtracted
+	Balance int64
+	// Accounts is the number of accounts aggregated
+	Accounts int
+}
+
+// coaBalances aggregates the balances of the accounts under a node per currency.
+type coaBalances struct {
+	alignment  acccore.Alignment
+	currencies map[string]*COABalance
+}
+
+// newCOABalances returns an empty aggregation in the alignment of the node.
+func newCOABalances(alignment acccore.Alignment) *coaBalances {
+	return &coaBalances{alignment: alignment, currencies: make(map[string]*COABalance)}
+}
+
+// add aggregates the total balance of a number of accounts sharing a currency and an alignment.
+func (b *coaBalances) add(currency string, alignment acccore.Alignment, balance int64, accounts int) {
+	total, ok := b.currencies[currency]
+	if !ok {
+		total = &COABalance{Currency: currency}
+		b.currencies[currency] = total
+	}
+	if alignment == b.alignment {
+		total.Balance += balance
+	} else {
+		total.Balance -= balance
+	}
+	total.Accounts += accounts
+}
+
+// list returns the aggregated balances sorted by currency.
+func (b *coaBalances) list() []*COABalance {
+	ret := make([]*COABalance, 0, len(b.currencies))
+	for _, total := range b.currencies {
+		ret = append(ret, total)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Currency < ret[j].Currency })
+	return ret
+}
+
+// COADetails are the chart of accounts node details that may change after the node is created.
+// Nil details are left as they are, an empty ParentCode makes the node a root.
+type COADetails struct {
+	Name       *string
+	ParentCode *string
+}
+
+// COAManager keeps the chart of accounts, the tree of nodes the accounts are classified under.
+type COAManager interface {
+	// CreateCOA creates the node and returns it. A node without alignment takes the normal alignment of its type.
+	// Throws ErrCOAExists if the code is already used, or ErrInvalidCOA if the code, name, type or parent is not valid.
+	CreateCOA(ctx context.Context, coa *COA, author string) (*COA, error)
+
+	// GetCOA returns the node.
+	// Throws ErrCOANotFound if the node does not exist.
+	GetCOA(ctx context.Context, code string) (*COA, error)
+
+	// ListCOA returns the whole chart of accounts sorted by code.
+	ListCOA(ctx context.Context) ([]*COA, error)
+
+	// UpdateCOA changes the name or parent of the node and returns the updated node.
+	// Throws ErrCOANotFound if the node does not exist, or ErrInvalidCOA if the name or parent is not valid.
+	UpdateCOA(ctx context.Context, code string, details *COADetails, author string) (*COA, error)
+
+	// DeleteCOA deletes the node.
+	// Throws ErrCOANotFound if the node does not exist, or ErrCOAInUse if it has child nodes or accounts.
+	DeleteCOA(ctx context.Context, code string) error
+
+	// CheckAccountCOA makes sure an account with the alignment may be under the node.
+	// Throws ErrCOANotFound if the node does not exist, or ErrCOAAlignmentMismatch if its alignment differs.
+	CheckAccountCOA(ctx context.Context, code string, alignment acccore.Alignment) error
+
+	// GetCOABalance returns the aggregated balance of the accounts under the node and all its descendants, per currency.
+	// Throws ErrCOANotFound if the node does not exist.
+	GetCOABalance(ctx context.Context, code string) ([]*COABalance, error)
+}
+
+// NewInMemoryCOAManager returns a chart of accounts manager that keeps the nodes in memory and finds
+// the accounts of the nodes with the account manager.
+func NewInMemoryCOAManager(accountManager acccore.AccountManager) COAManager {
+	return &InMemoryCOAManager{accountManager: accountManager, nodes: make(map[string]*COA)}
+}
+
+// InMemoryCOAManager implementation of COAManager that keeps the chart of accounts in memory.
+// Suitable for testing, the nodes are lost when the application stops.
+type InMemoryCOAManager struct {
+	accountManager acccore.AccountManager
+	mutex          sync.Mutex
+	nodes          map[string]*COA
+}
+
+// list returns copies of the nodes sorted by code.
+func (im *InMemoryCOAManager) list() []*COA {
+	ret := make([]*COA, 0, len(im.nodes))
+	for _, node := range im.nodes {
+		copied := *node
+		ret = append(ret, &copied)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Code < ret[j].Code })
+	return ret
+}
+
+// node returns a copy of the node.
+func (im *InMemoryCOAManager) node(code string) (*COA, error) {
+	node, ok := im.nodes[code]
+	if !ok {
+		return nil, fmt.Errorf("%w : %s", hwerrors.ErrCOANotFound, code)
+	}
+	copied := *node
+	return &copied, nil
+}
+
+// accounts returns all the accounts of the node.
+func (im *InMemoryCOAManager) accounts(ctx context.Context, code string) ([]acccore.Account, error) {
+	result, accounts, err := im.accountManager.ListAccountByCOA(ctx, code, acccore.PageRequest{PageNo: 1, ItemSize: 100})
+	if err != nil || result.TotalEntries <= len(accounts) {
+		return accounts, err
+	}
+	_, accounts, err = im.accountManager.ListAccountByCOA(ctx, code, acccore.PageRequest{PageNo: 1, ItemSize: result.TotalEntries})
+	return accounts, err
+}
+
+// CreateCOA creates the node and returns it.
+func (im *InMemoryCOAManager) CreateCOA(ctx context.Context, coa *COA, author string) (*COA, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	if err := coa.validate(); err != nil {
+		return nil, err
+	}
+	if _, ok := im.nodes[coa.Code]; ok {
+		return nil, fmt.Errorf("%w : %s", hwerrors.ErrCOAExists, coa.Code)
+	}
+	if err := checkCOAParent(coa.Code, coa.ParentCode, coa.Type, im.nodes); err != nil {
+		return nil, err
+	}
+	node := *coa
+	node.CreatedAt, node.CreatedBy = time.Now(), author
+	node.UpdatedAt, node.UpdatedBy = node.CreatedAt, author
+	im.nodes[node.Code] = &node
+	return im.node(node.Code)
+}
+
+// GetCOA returns the node.
+func (im *InMemoryCOAManager) GetCOA(ctx context.Context, code string) (*COA, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	return im.node(code)
+}
+
+// ListCOA returns the whole chart of accounts sorted by code.
+func (im *InMemoryCOAManager) ListCOA(ctx context.Context) ([]*COA, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	return im.list(), nil
+}
+
+// UpdateCOA changes the name or parent of the node and returns the updated node.
+func (im *InMemoryCOAManager) UpdateCOA(ctx context.Context, code string, details *COADetails, author string) (*COA, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	node, err := im.node(code)
+	if err != nil {
+		return nil, err
+	}
+	if details.Name != nil {
+		node.Name = *details.Name
+	}
+	if details.ParentCode != nil {
+		node.ParentCode = *details.ParentCode
+	}
+	if err = node.validate(); err != nil {
+		return nil, err
+	}
+	if err = checkCOAParent(node.Code, node.ParentCode, node.Type, im.nodes); err != nil {
+		return nil, err
+	}
+	node.UpdatedAt, node.UpdatedBy = time.Now(), author
+	im.nodes[code] = node
+	return im.node(code)
+}
+
+// DeleteCOA deletes the node.
+func (im *InMemoryCOAManager) DeleteCOA(ctx context.Context, code string) error {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	if _, err := im.node(code); err != nil {
+		return err
+	}
+	if subtree := coaSubtree(code, im.list()); len(subtree) > 1 {
+		return fmt.Errorf("%w : %s has child node %s", hwerrors.ErrCOAInUse, code, subtree[1])
+	}
+	accounts, err := im.accounts(ctx, code)
+	if err != nil {
+		return err
+	}
+	if len(accounts) > 0 {
+		return fmt.Errorf("%w : %s has account %s", hwerrors.ErrCOAInUse, code, accounts[0].GetAccountNumber())
+	}
+	delete(im.nodes, code)
+	return nil
+}
+
+// CheckAccountCOA makes sure an account with the alignment may be under the node.
+func (im *InMemoryCOAManager) CheckAccountCOA(ctx context.Context, code string, alignment acccore.Alignment) error {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	node, err := im.node(code)
+	if err != nil {
+		return err
+	}
+	return checkAccountCOA(node, alignment)
+}
+
+// GetCOABalance returns the aggregated balance of the accounts under the node and all its descendants, per currency.
+func (im *InMemoryCOAManager) GetCOABalance(ctx context.Context, code string) ([]*COABalance, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	node, err := im.node(code)
+	if err != nil {
+		return nil, err
+	}
+	balances := newCOABalances(node.Alignment)
+	for _, subCode := range coaSubtree(code, im.list()) {
+		accounts, err := im.accounts(ctx, subCode)
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range accounts {
+			balances.add(account.GetCurrency(), account.GetAlignment(), account.GetBalance(), 1)
+		}
+	}
+	return balances.list(), nil
+}
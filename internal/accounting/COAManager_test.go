@@ -0,0 +1,120 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCOAType(t *testing.T) {
+	coaType, err := ParseCOAType(" expense ")
+	assert.NoError(t, err)
+	assert.Equal(t, COAExpense, coaType)
+	assert.Equal(t, acccore.DEBIT, coaType.NormalAlignment())
+	assert.Equal(t, acccore.CREDIT, COAIncome.NormalAlignment())
+	_, err = ParseCOAType("REVENUE")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidCOA))
+}
+
+func TestParseAlignment(t *testing.T) {
+	alignment, err := ParseAlignment(" debit ")
+	assert.NoError(t, err)
+	assert.Equal(t, acccore.DEBIT, alignment)
+	alignment, err = ParseAlignment("CREDIT")
+	assert.NoError(t, err)
+	assert.Equal(t, acccore.CREDIT, alignment)
+	for _, invalid := range []string{"", "DEBT", "D"} {
+		_, err = ParseAlignment(invalid)
+		assert.True(t, errors.Is(err, hwerrors.ErrInvalidAlignment), invalid)
+	}
+}
+
+func TestCheckCOAParent(t *testing.T) {
+	nodes := map[string]*COA{
+		"1":     {Code: "1", Type: COAAsset},
+		"1.1":   {Code: "1.1", ParentCode: "1", Type: COAAsset},
+		"1.1.1": {Code: "1.1.1", ParentCode: "1.1", Type: COAAsset},
+		"2":     {Code: "2", Type: COALiability},
+	}
+	assert.NoError(t, checkCOAParent("1.2", "1", COAAsset, nodes))
+	assert.NoError(t, checkCOAParent("3", "", COAEquity, nodes))
+	assert.True(t, errors.Is(checkCOAParent("1.2", "9", COAAsset, nodes), hwerrors.ErrInvalidCOA))
+	assert.True(t, errors.Is(checkCOAParent("2.1", "1", COALiability, nodes), hwerrors.ErrInvalidCOA))
+	assert.True(t, errors.Is(checkCOAParent("1", "1.1.1", COAAsset, nodes), hwerrors.ErrInvalidCOA))
+	assert.True(t, errors.Is(checkCOAParent("1.1", "1.1", COAAsset, nodes), hwerrors.ErrInvalidCOA))
+
+	assert.Equal(t, []string{"1", "1.1", "1.1.1"}, coaSubtree("1", []*COA{nodes["1"], nodes["1.1"], nodes["1.1.1"], nodes["2"]}))
+	assert.Equal(t, []string{"2"}, coaSubtree("2", []*COA{nodes["1"], nodes["1.1"], nodes["1.1.1"], nodes["2"]}))
+}
+
+func TestInMemoryCOAManager(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	acccore.ClearInMemoryTables()
+	accountManager := &acccore.InMemoryAccountManager{}
+	coaManager := NewInMemoryCOAManager(accountManager)
+
+	for _, coa := range []*COA{
+		{Code: "1", Name: "Assets", Type: COAAsset, Alignment: acccore.DEBIT},
+		{Code: "1.1", ParentCode: "1", Name: "Cash", Type: COAAsset, Alignment: acccore.DEBIT},
+		{Code: "1.2", ParentCode: "1", Name: "Accumulated Depreciation", Type: COAAsset, Alignment: acccore.CREDIT},
+		{Code: "2", Name: "Liabilities", Type: COALiability, Alignment: acccore.CREDIT},
+		{Code: "5", Name: "Expenses", Type: COAExpense, Alignment: acccore.DEBIT},
+	} {
+		_, err := coaManager.CreateCOA(ctx, coa, "TESTING")
+		assert.NoError(t, err, coa.Code)
+	}
+	_, err := coaManager.CreateCOA(ctx, &COA{Code: "1", Name: "Again", Type: COAAsset}, "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrCOAExists))
+	_, err = coaManager.CreateCOA(ctx, &COA{Code: "1.3", ParentCode: "2", Name: "Misplaced", Type: COAAsset}, "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidCOA))
+	_, err = coaManager.CreateCOA(ctx, &COA{Code: "1_3", ParentCode: "1", Name: "Wildcard", Type: COAAsset}, "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidCOA))
+
+	assert.NoError(t, coaManager.CheckAccountCOA(ctx, "1.2", acccore.CREDIT))
+	assert.True(t, errors.Is(coaManager.CheckAccountCOA(ctx, "1.2", acccore.DEBIT), hwerrors.ErrCOAAlignmentMismatch))
+	assert.True(t, errors.Is(coaManager.CheckAccountCOA(ctx, "9", acccore.DEBIT), hwerrors.ErrCOANotFound))
+
+	for number, coa := range map[string]*COA{"CASH": {Code: "1.1", Alignment: acccore.DEBIT}, "DEPRECIATION": {Code: "1.2", Alignment: acccore.CREDIT},
+		"LOAN": {Code: "2", Alignment: acccore.CREDIT}, "DEPRECIATIONEXPENSE": {Code: "5", Alignment: acccore.DEBIT}} {
+		account := &acccore.BaseAccount{}
+		account.SetAccountNumber(number).SetName(number).SetDescription(number + " test account").SetCOA(coa.Code).
+			SetCurrency("IDR").SetAlignment(coa.Alignment).SetCreateBy("TESTING")
+		assert.NoError(t, accountManager.PersistAccount(ctx, account))
+	}
+	journalManager := &acccore.InMemoryJournalManager{}
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("borrowing", "CASH", "LOAN", 1000)))
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("depreciating", "DEPRECIATIONEXPENSE", "DEPRECIATION", 100)))
+
+	// the contra asset is subtracted from the assets
+	balances, err := coaManager.GetCOABalance(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, []*COABalance{{Currency: "IDR", Balance: 900, Accounts: 2}}, balances)
+	balances, err = coaManager.GetCOABalance(ctx, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, []*COABalance{{Currency: "IDR", Balance: 1000, Accounts: 1}}, balances)
+
+	name, parent := "Current Assets", "1.1"
+	_, err = coaManager.UpdateCOA(ctx, "1", &COADetails{ParentCode: &parent}, "UPDATER")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidCOA))
+	coa, err := coaManager.UpdateCOA(ctx, "1.1", &COADetails{Name: &name}, "UPDATER")
+	assert.NoError(t, err)
+	assert.Equal(t, "Current Assets", coa.Name)
+	assert.Equal(t, "1", coa.ParentCode)
+	assert.Equal(t, "UPDATER", coa.UpdatedBy)
+
+	assert.True(t, errors.Is(coaManager.DeleteCOA(ctx, "1"), hwerrors.ErrCOAInUse))
+	assert.True(t, errors.Is(coaManager.DeleteCOA(ctx, "1.1"), hwerrors.ErrCOAInUse))
+	_, err = coaManager.CreateCOA(ctx, &COA{Code: "3", Name: "Equity", Type: COAEquity, Alignment: acccore.CREDIT}, "TESTING")
+	assert.NoError(t, err)
+	assert.NoError(t, coaManager.DeleteCOA(ctx, "3"))
+	_, err = coaManager.GetCOA(ctx, "3")
+	assert.True(t, errors.Is(err, hwerrors.ErrCOANotFound))
+	coas, err := coaManager.ListCOA(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, coas, 5)
+}
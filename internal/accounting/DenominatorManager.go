@@ -0,0 +1,82 @@
+package accounting
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+)
+
+// CommonDenominatorSetting is the name of the setting that keeps the exchange common denominator
+const CommonDenominatorSetting = "exchange.denominator"
+
+// DenominatorChange is one value the exchange common denominator was given.
+type DenominatorChange struct {
+	// Denom is the common denominator value
+	Denom float64 `json:"denom"`
+	// ChangedAt is the time the common denominator was set to the value
+	ChangedAt time.Time `json:"changed_at"`
+	// ChangedBy is who set the common denominator to the value
+	ChangedBy string `json:"changed_by"`
+}
+
+// DenominatorManager keeps the exchange common denominator and the history of its changes.
+// acccore.ExchangeManager.SetDenom can not report a failure nor tell who made the change, so changes made
+// through the rest endpoint go through this manager.
+type DenominatorManager interface {
+	// LoadDenom reads the persisted common denominator, it is called once when the application starts.
+	// Throws error if the common denominator can not be read or is not a valid number.
+	LoadDenom(ctx context.Context) error
+
+	// PersistDenom sets the common denominator into the specified value and records who set it.
+	PersistDenom(ctx context.Context, denom *big.Float, author string) error
+
+	// ListDenomHistory lists the values the common denominator was given in paginated fashion, latest first.
+	ListDenomHistory(ctx context.Context, request acccore.PageRequest) (acccore.PageResult, []*DenominatorChange, error)
+}
+
+// NewInMemoryDenominatorManager returns a denominator manager that sets the common denominator of the
+// specified exchange manager and keeps the history of its changes in memory.
+func NewInMemoryDenominatorManager(exchangeManager acccore.ExchangeManager) DenominatorManager {
+	return &InMemoryDenominatorManager{
+		exchangeManager: exchangeManager,
+		history:         make([]*DenominatorChange, 0),
+	}
+}
+
+// InMemoryDenominatorManager implementation of DenominatorManager that keeps the history in memory.
+// Suitable for testing, the history is lost when the application stops.
+type InMemoryDenominatorManager struct {
+	exchangeManager acccore.ExchangeManager
+	mutex           sync.Mutex
+	history         []*DenominatorChange
+}
+
+// LoadDenom does nothing, nothing is persisted in memory.
+func (im *InMemoryDenominatorManager) LoadDenom(ctx context.Context) error {
+	return nil
+}
+
+// PersistDenom sets the common denominator into the specified value and records who set it.
+func (im *InMemoryDenominatorManager) PersistDenom(ctx context.Context, denom *big.Float, author string) error {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	im.exchangeManager.SetDenom(ctx, denom)
+	f, _ := denom.Float64()
+	im.history = append([]*DenominatorChange{{Denom: f, ChangedAt: time.Now(), ChangedBy: author}}, im.history...)
+	return nil
+}
+
+// ListDenomHistory lists the values the common denominator was given in paginated fashion, latest first.
+func (im *InMemoryDenominatorManager) ListDenomHistory(ctx context.Context, request acccore.PageRequest) (acccore.PageResult, []*DenominatorChange, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	pResult := acccore.PageResultFor(request, len(im.history))
+	ret := make([]*DenominatorChange, 0)
+	for i := pResult.Offset; i < len(im.history) && len(ret) < pResult.PageSize; i++ {
+		ret = append(ret, im.history[i])
+	}
+	return pResult, ret, nil
+}
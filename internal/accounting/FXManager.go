@@ -0,0 +1,227 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+)
+
+// FXConfig configures the multi currency journals.
+type FXConfig struct {
+	// BaseCurrency is the currency every transaction is valued in to verify the journal balances
+	BaseCurrency string
+	// ClearingAccounts maps a currency code to the account that receives the clearing transactions in that currency
+	ClearingAccounts map[string]string
+	// GainLossAccount is the account, in the base currency, that receives the FX gain or loss
+	GainLossAccount string
+	// GainLossLimit is the largest gain or loss, in the base currency, a journal may generate. 0 means no limit
+	GainLossLimit int64
+	// Rounding is the rounding mode of the amounts valued in the base currency
+	Rounding RoundingMode
+}
+
+// ParseClearingAccounts parses the clearing accounts written as comma separated currency:account pairs,
+// such as "USD:1000001,IDR:1000002". The retained earnings accounts of the period closing are written the same way.
+func ParseClearingAccounts(accounts string) (map[string]string, error) {
+	ret := make(map[string]string)
+	for _, pair := range strings.Split(accounts, ",") {
+		if len(strings.TrimSpace(pair)) == 0 {
+			continue
+		}
+		currencyAccount := strings.Split(pair, ":")
+		if len(currencyAccount) != 2 || len(strings.TrimSpace(currencyAccount[0])) == 0 || len(strings.TrimSpace(currencyAccount[1])) == 0 {
+			return nil, fmt.Errorf("currency account %s should be written as currency:account", pair)
+		}
+		ret[strings.TrimSpace(currencyAccount[0])] = strings.TrimSpace(currencyAccount[1])
+	}
+	return ret, nil
+}
+
+// FXJournal is a journal whose transactions are on accounts of different currencies. The generated clearing
+// transactions make each currency balance on its own, the sql journal manager persists it where it would refuse
+// a plain journal mixing currencies.
+type FXJournal struct {
+	acccore.Journal
+	// BaseCurrency is the currency the transactions are valued in
+	BaseCurrency string
+	// BaseAmount is the total credit of the journal valued in the base currency
+	BaseAmount int64
+	// GainLoss is the FX gain, or the loss if negative, valued in the base currency
+	GainLoss int64
+}
+
+// FXManager prepares the multi currency journals.
+type FXManager interface {
+	// PrepareFXJournal values every transaction of the journal in the base currency with the current exchange rates,
+	// books the difference between the debit and credit value into the gain/loss account, and appends a clearing
+	// transaction for each currency that does not balance on its own.
+	// Throws ErrFXNotConfigured if an account the journal needs is not configured,
+	// or ErrFXGainLossLimit if the gain or loss is larger than the configured limit.
+	PrepareFXJournal(ctx context.Context, journal acccore.Journal) (*FXJournal, error)
+}
+
+// NewFXManager returns an FX manager that finds the account currencies with the account manager and values
+// them with the rate manager. Generated transactions are IDed by the unique ID generator.
+func NewFXManager(accountManager acccore.AccountManager, rateManager RateManager, idGenerator acccore.UniqueIDGenerator, config FXConfig) FXManager {
+	return &fxManager{
+		accountManager: accountManager,
+		rateManager:    rateManager,
+		idGenerator:    idGenerator,
+		config:         config,
+	}
+}
+
+type fxManager struct {
+	accountManager acccore.AccountManager
+	rateManager    RateManager
+	idGenerator    acccore.UniqueIDGenerator
+	config         FXConfig
+}
+
+// PrepareFXJournal values every transaction of the journal in the base currency with the current exchange rates,
+// books the difference between the debit and credit value into the gain/loss account, and appends a clearing
+// transaction for each currency that does not balance on its own.
+func (fm *fxManager) PrepareFXJournal(ctx context.Context, journal acccore.Journal) (*FXJournal, error) {
+	if len(fm.config.BaseCurrency) == 0 || len(fm.config.GainLossAccount) == 0 {
+		return nil, fmt.Errorf("%w : base currency and gain/loss account are required", hwerrors.ErrFXNotConfigured)
+	}
+	if len(journal.GetTransactions()) == 0 {
+		return nil, acccore.ErrJournalNoTransaction
+	}
+
+	// the exchange rate of each currency toward the base currency
+	rates := make(map[string]*big.Rat)
+	rateOf := func(currency string) (*big.Rat, error) {
+		if rate, ok := rates[currency]; ok {
+			return rate, nil
+		}
+		rate, err := fm.rateManager.CalculateExactRate(ctx, currency, fm.config.BaseCurrency, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		rates[currency] = rate
+		return rate, nil
+	}
+
+	// net is the debit minus credit of each currency, value is the same valued in the base currency
+	net := make(map[string]int64)
+	value := new(big.Rat)
+	for _, trx := range journal.GetTransactions() {
+		account, err := fm.accountManager.GetAccountByID(ctx, trx.GetAccountNumber())
+		if err != nil || account == nil {
+			return nil, acccore.ErrJournalTransactionAccountNotPersist
+		}
+		rate, err := rateOf(account.GetCurrency())
+		if err != nil {
+			return nil, err
+		}
+		amount := trx.GetAmount()
+		if trx.GetAlignment() == acccore.CREDIT {
+			amount = -amount
+		}
+		net[account.GetCurrency()] += amount
+		value.Add(value, new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate))
+	}
+
+	ret := &FXJournal{Journal: journal, BaseCurrency: fm.config.BaseCurrency}
+	transactions := journal.GetTransactions()
+	if len(net) < 2 {
+		// nothing is exchanged, the journal has to balance on its own.
+		return ret, fm.valueCredit(ctx, ret, rateOf)
+	}
+
+	// a debit worth more than the credit is a gain, booked as a credit of the gain/loss account
+	gainLoss := fm.config.Rounding.Round(value)
+	if !gainLoss.IsInt64() {
+		return nil, fmt.Errorf("%w : gain/loss %s", hwerrors.ErrAmountOutOfRange, FormatRat(value))
+	}
+	ret.GainLoss = gainLoss.Int64()
+	if fm.config.GainLossLimit > 0 && (ret.GainLoss > fm.config.GainLossLimit || -ret.GainLoss > fm.config.GainLossLimit) {
+		return nil, fmt.Errorf("%w : %d %s, the limit is %d", hwerrors.ErrFXGainLossLimit, ret.GainLoss, fm.config.BaseCurrency, fm.config.GainLossLimit)
+	}
+	if ret.GainLoss != 0 {
+		account, err := fm.accountManager.GetAccountByID(ctx, fm.config.GainLossAccount)
+		if err != nil || account == nil || account.GetCurrency() != fm.config.BaseCurrency {
+			return nil, fmt.Errorf("%w : gain/loss account %s should be an account in %s", hwerrors.ErrFXNotConfigured, fm.config.GainLossAccount, fm.config.BaseCurrency)
+		}
+		description := "FX gain"
+		if ret.GainLoss < 0 {
+			description = "FX loss"
+		}
+		transactions = append(transactions, fm.newTransaction(journal, fm.config.GainLossAccount, description, -ret.GainLoss))
+		net[fm.config.BaseCurrency] -= ret.GainLoss
+	}
+
+	// clearing transactions are appended in currency order, so the same journal always gets the same transactions.
+	currencies := make([]string, 0, len(net))
+	for currency := range net {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		if net[currency] == 0 {
+			continue
+		}
+		clearing, ok := fm.config.ClearingAccounts[currency]
+		if !ok {
+			return nil, fmt.Errorf("%w : no clearing account for %s", hwerrors.ErrFXNotConfigured, currency)
+		}
+		account, err := fm.accountManager.GetAccountByID(ctx, clearing)
+		if err != nil || account == nil || account.GetCurrency() != currency {
+			return nil, fmt.Errorf("%w : clearing account %s should be an account in %s", hwerrors.ErrFXNotConfigured, clearing, currency)
+		}
+		transactions = append(transactions, fm.newTransaction(journal, clearing, "FX clearing "+currency, -net[currency]))
+	}
+	journal.SetTransactions(transactions)
+	return ret, fm.valueCredit(ctx, ret, rateOf)
+}
+
+// valueCredit sets the base amount of the journal, the total of its credit transactions valued in the base currency.
+func (fm *fxManager) valueCredit(ctx context.Context, journal *FXJournal, rateOf func(currency string) (*big.Rat, error)) error {
+	credit := new(big.Rat)
+	for _, trx := range journal.GetTransactions() {
+		if trx.GetAlignment() != acccore.CREDIT {
+			continue
+		}
+		account, err := fm.accountManager.GetAccountByID(ctx, trx.GetAccountNumber())
+		if err != nil || account == nil {
+			return acccore.ErrJournalTransactionAccountNotPersist
+		}
+		rate, err := rateOf(account.GetCurrency())
+		if err != nil {
+			return err
+		}
+		credit.Add(credit, new(big.Rat).Mul(new(big.Rat).SetInt64(trx.GetAmount()), rate))
+	}
+	baseAmount := fm.config.Rounding.Round(credit)
+	if !baseAmount.IsInt64() {
+		return fmt.Errorf("%w : journal amount %s", hwerrors.ErrAmountOutOfRange, FormatRat(credit))
+	}
+	journal.BaseAmount = baseAmount.Int64()
+	return nil
+}
+
+// newTransaction creates a generated transaction of the journal, a debit if amount is positive or a credit if negative.
+func (fm *fxManager) newTransaction(journal acccore.Journal, accountNumber, description string, amount int64) acccore.Transaction {
+	alignment := acccore.DEBIT
+	if amount < 0 {
+		alignment, amount = acccore.CREDIT, -amount
+	}
+	return &acccore.BaseTransaction{
+		TransactionID:   fm.idGenerator.NewUniqueID(),
+		TransactionTime: time.Now(),
+		AccountNumber:   accountNumber,
+		JournalID:       journal.GetJournalID(),
+		Description:     description,
+		TransactionType: alignment,
+		Amount:          amount,
+		CreateTime:      time.Now(),
+		CreateBy:        journal.GetCreateBy(),
+	}
+}
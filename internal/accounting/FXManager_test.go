@@ -0,0 +1,142 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+// testLeg is a transaction of a journal made by makeTestLegsJournal
+type testLeg struct {
+	account   string
+	alignment acccore.Alignment
+	amount    int64
+}
+
+// makeTestLegsJournal creates an un-persisted journal with the specified transactions.
+func makeTestLegsJournal(description string, legs ...testLeg) acccore.Journal {
+	journal := &acccore.BaseJournal{
+		JournalID:      testIDGenerator.NewUniqueID(),
+		JournalingTime: time.Now(),
+		Description:    description,
+		CreateTime:     time.Now(),
+		CreatedBy:      "TESTING",
+	}
+	transactions := make([]acccore.Transaction, 0, len(legs))
+	for _, leg := range legs {
+		transactions = append(transactions, &acccore.BaseTransaction{TransactionID: testIDGenerator.NewUniqueID(), TransactionTime: time.Now(),
+			AccountNumber: leg.account, JournalID: journal.JournalID, Description: description, TransactionType: leg.alignment,
+			Amount: leg.amount, CreateBy: "TESTING"})
+	}
+	journal.SetTransactions(transactions)
+	return journal
+}
+
+// generatedLegs returns the transactions the FX manager appended after the first n, as legs.
+func generatedLegs(journal acccore.Journal, n int) []testLeg {
+	ret := make([]testLeg, 0)
+	for _, trx := range journal.GetTransactions()[n:] {
+		ret = append(ret, testLeg{account: trx.GetAccountNumber(), alignment: trx.GetAlignment(), amount: trx.GetAmount()})
+	}
+	return ret
+}
+
+func TestParseClearingAccounts(t *testing.T) {
+	accounts, err := ParseClearingAccounts(" USD:1000001 , IDR:1000002,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"USD": "1000001", "IDR": "1000002"}, accounts)
+
+	accounts, err = ParseClearingAccounts("")
+	assert.NoError(t, err)
+	assert.Empty(t, accounts)
+
+	for _, invalid := range []string{"USD", "USD:", ":1000001", "USD:1:2"} {
+		_, err = ParseClearingAccounts(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestFXManager_PrepareFXJournal(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	acccore.ClearInMemoryTables()
+	accountManager := &acccore.InMemoryAccountManager{}
+	for number, currency := range map[string]string{"USDWALLET": "USD", "IDRWALLET": "IDR", "USDCLEAR": "USD", "IDRCLEAR": "IDR", "FXGAINLOSS": "IDR"} {
+		account := &acccore.BaseAccount{}
+		account.SetAccountNumber(number).SetName(number).SetDescription(number + " test account").
+			SetCurrency(currency).SetAlignment(acccore.DEBIT).SetCreateBy("TESTING")
+		assert.NoError(t, accountManager.PersistAccount(ctx, account))
+	}
+	rateManager := NewInMemoryRateManager(RoundHalfEven)
+	for code, exchange := range map[string]string{"USD": "1", "IDR": "14500"} {
+		rate, err := ParseRate(exchange)
+		assert.NoError(t, err)
+		_, err = rateManager.SetCurrencyRate(ctx, code, code, rate, "TESTING")
+		assert.NoError(t, err)
+	}
+	config := FXConfig{
+		BaseCurrency:     "IDR",
+		ClearingAccounts: map[string]string{"USD": "USDCLEAR", "IDR": "IDRCLEAR"},
+		GainLossAccount:  "FXGAINLOSS",
+		Rounding:         RoundHalfEven,
+	}
+	fxManager := NewFXManager(accountManager, rateManager, testIDGenerator, config)
+
+	t.Run("balanced exchange is cleared without gain or loss", func(t *testing.T) {
+		journal, err := fxManager.PrepareFXJournal(ctx, makeTestLegsJournal("buy dollars",
+			testLeg{"USDWALLET", acccore.DEBIT, 100}, testLeg{"IDRWALLET", acccore.CREDIT, 1450000}))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), journal.GainLoss)
+		assert.Equal(t, int64(2900000), journal.BaseAmount)
+		assert.Equal(t, []testLeg{{"IDRCLEAR", acccore.DEBIT, 1450000}, {"USDCLEAR", acccore.CREDIT, 100}}, generatedLegs(journal, 2))
+	})
+
+	t.Run("cheaper exchange books a gain", func(t *testing.T) {
+		journal, err := fxManager.PrepareFXJournal(ctx, makeTestLegsJournal("buy dollars cheap",
+			testLeg{"USDWALLET", acccore.DEBIT, 100}, testLeg{"IDRWALLET", acccore.CREDIT, 1440000}))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10000), journal.GainLoss)
+		assert.Equal(t, []testLeg{{"FXGAINLOSS", acccore.CREDIT, 10000}, {"IDRCLEAR", acccore.DEBIT, 1450000}, {"USDCLEAR", acccore.CREDIT, 100}}, generatedLegs(journal, 2))
+	})
+
+	t.Run("dearer exchange books a loss", func(t *testing.T) {
+		journal, err := fxManager.PrepareFXJournal(ctx, makeTestLegsJournal("sell dollars cheap",
+			testLeg{"IDRWALLET", acccore.DEBIT, 1440000}, testLeg{"USDWALLET", acccore.CREDIT, 100}))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(-10000), journal.GainLoss)
+		assert.Equal(t, []testLeg{{"FXGAINLOSS", acccore.DEBIT, 10000}, {"IDRCLEAR", acccore.CREDIT, 1450000}, {"USDCLEAR", acccore.DEBIT, 100}}, generatedLegs(journal, 2))
+	})
+
+	t.Run("single currency journal is left alone", func(t *testing.T) {
+		journal, err := fxManager.PrepareFXJournal(ctx, makeTestLegsJournal("rupiah only",
+			testLeg{"IDRWALLET", acccore.DEBIT, 1000}, testLeg{"IDRCLEAR", acccore.CREDIT, 1000}))
+		assert.NoError(t, err)
+		assert.Len(t, journal.GetTransactions(), 2)
+		assert.Equal(t, int64(1000), journal.BaseAmount)
+	})
+
+	t.Run("gain beyond the limit is refused", func(t *testing.T) {
+		limited := config
+		limited.GainLossLimit = 5000
+		_, err := NewFXManager(accountManager, rateManager, testIDGenerator, limited).PrepareFXJournal(ctx, makeTestLegsJournal("typo",
+			testLeg{"USDWALLET", acccore.DEBIT, 100}, testLeg{"IDRWALLET", acccore.CREDIT, 1440000}))
+		assert.True(t, errors.Is(err, hwerrors.ErrFXGainLossLimit))
+	})
+
+	t.Run("currency without clearing account is refused", func(t *testing.T) {
+		missing := config
+		missing.ClearingAccounts = map[string]string{"IDR": "IDRCLEAR"}
+		_, err := NewFXManager(accountManager, rateManager, testIDGenerator, missing).PrepareFXJournal(ctx, makeTestLegsJournal("no clearing",
+			testLeg{"USDWALLET", acccore.DEBIT, 100}, testLeg{"IDRWALLET", acccore.CREDIT, 1450000}))
+		assert.True(t, errors.Is(err, hwerrors.ErrFXNotConfigured))
+
+		_, err = NewFXManager(accountManager, rateManager, testIDGenerator, FXConfig{}).PrepareFXJournal(ctx, makeTestLegsJournal("no config",
+			testLeg{"USDWALLET", acccore.DEBIT, 100}, testLeg{"IDRWALLET", acccore.CREDIT, 1450000}))
+		assert.True(t, errors.Is(err, hwerrors.ErrFXNotConfigured))
+	})
+}
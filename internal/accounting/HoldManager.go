@@ -0,0 +1,299 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+)
+
+// HoldStatus is the status of a hold.
+type HoldStatus string
+
+const (
+	// HoldHeld holds reserve their amount on the account until they are captured, released or expire
+	HoldHeld HoldStatus = "HELD"
+	// HoldCaptured holds were posted into a journal
+	HoldCaptured HoldStatus = "CAPTURED"
+	// HoldReleased holds were given back without posting
+	HoldReleased HoldStatus = "RELEASED"
+	// HoldExpired holds were neither captured nor released before they expired
+	HoldExpired HoldStatus = "EXPIRED"
+)
+
+// Hold reserves an amount of an account balance, the account available balance is its balance less the held amounts.
+// Capturing the hold posts a journal that takes the amount from the account, releasing it posts nothing.
+type Hold struct {
+	HoldID        string
+	AccountNumber string
+	// Amount is the amount reserved, in the account currency
+	Amount      int64
+	Description string
+	Status      HoldStatus
+	// ExpiresAt is the time a HELD hold stops reserving its amount
+	ExpiresAt time.Time
+	// CapturedAmount is the amount posted when the hold was captured, the rest of the amount was released
+	CapturedAmount int64
+	// JournalID is the journal posted when the hold was captured
+	JournalID string
+	CreatedAt time.Time
+	CreatedBy string
+	UpdatedAt time.Time
+	UpdatedBy string
+}
+
+// isHeld tells if the hold still reserves its amount at the specified time.
+func (h *Hold) isHeld(at time.Time) bool {
+	return h.Status == HoldHeld && h.ExpiresAt.After(at)
+}
+
+// current returns a copy of the hold, EXPIRED if it is HELD past its expiry.
+func (h *Hold) current() *Hold {
+	ret := *h
+	if ret.Status == HoldHeld && !ret.isHeld(time.Now()) {
+		ret.Status = HoldExpired
+	}
+	return &ret
+}
+
+// checkHeld makes sure the hold can still be captured or released.
+func (h *Hold) checkHeld() error {
+	if current := h.current(); current.Status != HoldHeld {
+		return fmt.Errorf("%w : hold %s is %s", hwerrors.ErrHoldNotHeld, h.HoldID, current.Status)
+	}
+	return nil
+}
+
+// HoldManager reserves funds on accounts before they are posted.
+type HoldManager interface {
+	// PlaceHold reserves the amount on the account until expiresAt, or until the default hold expiry if expiresAt is zero.
+	// Throws acccore.ErrAccountIDNotFound if the account does not exist, ErrInvalidHold if the amount is not positive
+	// or the expiry has passed, ErrAccountFrozen or ErrAccountClosed if the account refuses postings,
+	// or a BalanceLimitError if the available balance would go below the account minimum.
+	PlaceHold(ctx context.Context, accountNumber string, amount int64, description string, expiresAt time.Time, author string) (*Hold, error)
+
+	// GetHold returns the hold. A HELD hold past its expiry is returned EXPIRED.
+	// Throws ErrHoldNotFound if the hold does not exist.
+	GetHold(ctx context.Context, holdID string) (*Hold, error)
+
+	// CaptureHold posts a journal taking the amount, or the whole hold if amount is 0, from the held account into
+	// the counter account. The rest of the hold is released.
+	// Throws ErrHoldNotFound if the hold does not exist, ErrHoldNotHeld if it is no longer held, ErrInvalidHold if the
+	// amount is negative or larger than the hold, or the error of the journal manager if the journal is refused.
+	CaptureHold(ctx context.Context, holdID, counterAccount string, amount int64, description, author string) (*Hold, error)
+
+	// ReleaseHold gives the held amount back to the account without posting.
+	// Throws ErrHoldNotFound if the hold does not exist, or ErrHoldNotHeld if it is no longer held.
+	ReleaseHold(ctx context.Context, holdID, author string) (*Hold, error)
+
+	// GetHeldAmount returns the total amount the holds of the account reserve.
+	GetHeldAmount(ctx context.Context, accountNumber string) (int64, error)
+
+	// ExpireHolds moves the HELD holds past their expiry into EXPIRED.
+	// Expired holds reserve nothing whether they are moved or not, expiring them only keeps their status current.
+	// It returns the number of holds expired.
+	ExpireHolds(ctx context.Context) (int64, error)
+}
+
+// checkHoldAmount makes sure the hold is placed with a positive amount and a future expiry.
+func checkHoldAmount(amount int64, expiresAt time.Time) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w : amount %d should be positive", hwerrors.ErrInvalidHold, amount)
+	}
+	if !expiresAt.After(time.Now()) {
+		return fmt.Errorf("%w : expiry %s has passed", hwerrors.ErrInvalidHold, expiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// captureAmount returns the amount of the hold to capture, the whole hold if amount is 0.
+func captureAmount(hold *Hold, counterAccount string, amount int64) (int64, error) {
+	if counterAccount == hold.AccountNumber {
+		return 0, fmt.Errorf("%w : hold %s can not be captured into its own account", hwerrors.ErrInvalidHold, hold.HoldID)
+	}
+	if amount == 0 {
+		return hold.Amount, nil
+	}
+	if amount < 0 || amount > hold.Amount {
+		return 0, fmt.Errorf("%w : capture amount %d should be positive and at most the held %d", hwerrors.ErrInvalidHold, amount, hold.Amount)
+	}
+	return amount, nil
+}
+
+// newCaptureJournal creates the journal taking amount from the held account, whose alignment is specified, into the counter account.
+func newCaptureJournal(idGenerator acccore.UniqueIDGenerator, hold *Hold, alignment acccore.Alignment, counterAccount string, amount int64, description, author string) acccore.Journal {
+	if len(description) == 0 {
+		description = "Capture of hold " + hold.HoldID
+	}
+	// the held account is taken from, so its transaction goes against its alignment
+	heldAlignment, counterAlignment := acccore.CREDIT, acccore.DEBIT
+	if alignment == acccore.CREDIT {
+		heldAlignment, counterAlignment = acccore.DEBIT, acccore.CREDIT
+	}
+	journal := &acccore.BaseJournal{
+		JournalID:      idGenerator.NewUniqueID(),
+		JournalingTime: time.Now(),
+		Description:    description,
+		CreateTime:     time.Now(),
+		CreatedBy:      author,
+	}
+	journal.SetTransactions([]acccore.Transaction{
+		&acccore.BaseTransaction{TransactionID: idGenerator.NewUniqueID(), TransactionTime: time.Now(), AccountNumber: hold.AccountNumber,
+			JournalID: journal.JournalID, Description: description, TransactionType: heldAlignment, Amount: amount, CreateTime: time.Now(), CreateBy: author},
+		&acccore.BaseTransaction{TransactionID: idGenerator.NewUniqueID(), TransactionTime: time.Now(), AccountNumber: counterAccount,
+			JournalID: journal.JournalID, Description: description, TransactionType: counterAlignment, Amount: amount, CreateTime: time.Now(), CreateBy: author},
+	})
+	return journal
+}
+
+// NewInMemoryHoldManager returns a hold manager that keeps the holds in memory, finds the accounts with the account
+// manager and captures holds with the journal manager. Holds placed without expiry expire after defaultExpiry.
+func NewInMemoryHoldManager(accountManager acccore.AccountManager, journalManager acccore.JournalManager, idGenerator acccore.UniqueIDGenerator, defaultExpiry time.Duration) HoldManager {
+	return &InMemoryHoldManager{
+		accountManager: accountManager,
+		journalManager: journalManager,
+		idGenerator:    idGenerator,
+		defaultExpiry:  defaultExpiry,
+		holds:          make(map[string]*Hold),
+	}
+}
+
+// InMemoryHoldManager implementation of HoldManager that keeps the holds in memory.
+// Suitable for testing, holds are lost when the application stops and the account balance limits are not enforced.
+type InMemoryHoldManager struct {
+	accountManager acccore.AccountManager
+	journalManager acccore.JournalManager
+	idGenerator    acccore.UniqueIDGenerator
+	defaultExpiry  time.Duration
+	mutex          sync.Mutex
+	holds          map[string]*Hold
+}
+
+// PlaceHold reserves the amount on the account until expiresAt, or until the default hold expiry if expiresAt is zero.
+func (im *InMemoryHoldManager) PlaceHold(ctx context.Context, accountNumber string, amount int64, description string, expiresAt time.Time, author string) (*Hold, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(im.defaultExpiry)
+	}
+	if err := checkHoldAmount(amount, expiresAt); err != nil {
+		return nil, err
+	}
+	account, err := im.accountManager.GetAccountByID(ctx, accountNumber)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, acccore.ErrAccountIDNotFound
+	}
+	hold := &Hold{
+		HoldID:        im.idGenerator.NewUniqueID(),
+		AccountNumber: accountNumber,
+		Amount:        amount,
+		Description:   description,
+		Status:        HoldHeld,
+		ExpiresAt:     expiresAt,
+		CreatedAt:     time.Now(),
+		CreatedBy:     author,
+		UpdatedAt:     time.Now(),
+		UpdatedBy:     author,
+	}
+	im.holds[hold.HoldID] = hold
+	return hold.current(), nil
+}
+
+// hold returns the kept hold.
+func (im *InMemoryHoldManager) hold(holdID string) (*Hold, error) {
+	hold, ok := im.holds[holdID]
+	if !ok {
+		return nil, fmt.Errorf("%w : %s", hwerrors.ErrHoldNotFound, holdID)
+	}
+	return hold, nil
+}
+
+// GetHold returns the hold. A HELD hold past its expiry is returned EXPIRED.
+func (im *InMemoryHoldManager) GetHold(ctx context.Context, holdID string) (*Hold, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	hold, err := im.hold(holdID)
+	if err != nil {
+		return nil, err
+	}
+	return hold.current(), nil
+}
+
+// CaptureHold posts a journal taking the amount, or the whole hold if amount is 0, from the held account into the counter account.
+func (im *InMemoryHoldManager) CaptureHold(ctx context.Context, holdID, counterAccount string, amount int64, description, author string) (*Hold, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	hold, err := im.hold(holdID)
+	if err != nil {
+		return nil, err
+	}
+	if err = hold.checkHeld(); err != nil {
+		return nil, err
+	}
+	amount, err = captureAmount(hold, counterAccount, amount)
+	if err != nil {
+		return nil, err
+	}
+	account, err := im.accountManager.GetAccountByID(ctx, hold.AccountNumber)
+	if err != nil {
+		return nil, err
+	}
+	journal := newCaptureJournal(im.idGenerator, hold, account.GetAlignment(), counterAccount, amount, description, author)
+	if err = im.journalManager.PersistJournal(ctx, journal); err != nil {
+		return nil, err
+	}
+	hold.Status, hold.CapturedAmount, hold.JournalID = HoldCaptured, amount, journal.GetJournalID()
+	hold.UpdatedAt, hold.UpdatedBy = time.Now(), author
+	return hold.current(), nil
+}
+
+// ReleaseHold gives the held amount back to the account without posting.
+func (im *InMemoryHoldManager) ReleaseHold(ctx context.Context, holdID, author string) (*Hold, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	hold, err := im.hold(holdID)
+	if err != nil {
+		return nil, err
+	}
+	if err = hold.checkHeld(); err != nil {
+		return nil, err
+	}
+	hold.Status, hold.UpdatedAt, hold.UpdatedBy = HoldReleased, time.Now(), author
+	return hold.current(), nil
+}
+
+// GetHeldAmount returns the total amount the holds of the account reserve.
+func (im *InMemoryHoldManager) GetHeldAmount(ctx context.Context, accountNumber string) (int64, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	held := int64(0)
+	for _, hold := range im.holds {
+		if hold.AccountNumber == accountNumber && hold.isHeld(time.Now()) {
+			held += hold.Amount
+		}
+	}
+	return held, nil
+}
+
+// ExpireHolds moves the HELD holds past their expiry into EXPIRED.
+func (im *InMemoryHoldManager) ExpireHolds(ctx context.Context) (int64, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	expired := int64(0)
+	for _, hold := range im.holds {
+		if hold.Status == HoldHeld && !hold.isHeld(time.Now()) {
+			hold.Status, hold.UpdatedAt, hold.UpdatedBy = HoldExpired, time.Now(), holdExpiryAuthor
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// holdExpiryAuthor is the author of the holds moved into EXPIRED.
+const holdExpiryAuthor = "hold-expiry"
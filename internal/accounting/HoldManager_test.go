@@ -0,0 +1,98 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCaptureJournal(t *testing.T) {
+	hold := &Hold{HoldID: "HOLD1", AccountNumber: "WALLET", Amount: 100}
+	journal := newCaptureJournal(testIDGenerator, hold, acccore.DEBIT, "MERCHANT", 60, "", "TESTING")
+	assert.Equal(t, "Capture of hold HOLD1", journal.GetDescription())
+	assert.Equal(t, []testLeg{{"WALLET", acccore.CREDIT, 60}, {"MERCHANT", acccore.DEBIT, 60}}, generatedLegs(journal, 0))
+
+	journal = newCaptureJournal(testIDGenerator, hold, acccore.CREDIT, "MERCHANT", 100, "checkout", "TESTING")
+	assert.Equal(t, "checkout", journal.GetDescription())
+	assert.Equal(t, []testLeg{{"WALLET", acccore.DEBIT, 100}, {"MERCHANT", acccore.CREDIT, 100}}, generatedLegs(journal, 0))
+}
+
+func TestInMemoryHoldManager(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	acccore.ClearInMemoryTables()
+	accountManager := &acccore.InMemoryAccountManager{}
+	for _, number := range []string{"WALLET", "MERCHANT", "RESERVE"} {
+		account := &acccore.BaseAccount{}
+		account.SetAccountNumber(number).SetName(number).SetDescription(number + " test account").
+			SetCurrency("IDR").SetAlignment(acccore.DEBIT).SetCreateBy("TESTING")
+		assert.NoError(t, accountManager.PersistAccount(ctx, account))
+	}
+	journalManager := &acccore.InMemoryJournalManager{}
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("topup", "WALLET", "RESERVE", 1000)))
+	holdManager := NewInMemoryHoldManager(accountManager, journalManager, testIDGenerator, time.Hour)
+
+	_, err := holdManager.PlaceHold(ctx, "WALLET", 0, "nothing", time.Time{}, "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidHold))
+	_, err = holdManager.PlaceHold(ctx, "WALLET", 100, "expired", time.Now().Add(-time.Minute), "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidHold))
+	_, err = holdManager.PlaceHold(ctx, "NOSUCHACCOUNT", 100, "nowhere", time.Time{}, "TESTING")
+	assert.Equal(t, acccore.ErrAccountIDNotFound, err)
+
+	captured, err := holdManager.PlaceHold(ctx, "WALLET", 300, "checkout", time.Time{}, "TESTING")
+	assert.NoError(t, err)
+	assert.Equal(t, HoldHeld, captured.Status)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), captured.ExpiresAt, time.Minute)
+	released, err := holdManager.PlaceHold(ctx, "WALLET", 200, "cancelled checkout", time.Now().Add(time.Minute), "TESTING")
+	assert.NoError(t, err)
+	held, err := holdManager.GetHeldAmount(ctx, "WALLET")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), held)
+
+	_, err = holdManager.CaptureHold(ctx, captured.HoldID, "MERCHANT", 301, "", "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidHold))
+	_, err = holdManager.CaptureHold(ctx, captured.HoldID, "WALLET", 0, "", "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidHold))
+	captured, err = holdManager.CaptureHold(ctx, captured.HoldID, "MERCHANT", 250, "", "TESTING")
+	assert.NoError(t, err)
+	assert.Equal(t, HoldCaptured, captured.Status)
+	assert.Equal(t, int64(250), captured.CapturedAmount)
+	account, err := accountManager.GetAccountByID(ctx, "WALLET")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(750), account.GetBalance())
+	_, err = holdManager.CaptureHold(ctx, captured.HoldID, "MERCHANT", 0, "", "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrHoldNotHeld))
+
+	released, err = holdManager.ReleaseHold(ctx, released.HoldID, "TESTING")
+	assert.NoError(t, err)
+	assert.Equal(t, HoldReleased, released.Status)
+	_, err = holdManager.ReleaseHold(ctx, released.HoldID, "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrHoldNotHeld))
+	held, err = holdManager.GetHeldAmount(ctx, "WALLET")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), held)
+
+	// a hold past its expiry reserves nothing and can no longer be captured, even before it is marked expired
+	expiring, err := holdManager.PlaceHold(ctx, "WALLET", 100, "expiring", time.Now().Add(time.Minute), "TESTING")
+	assert.NoError(t, err)
+	holdManager.(*InMemoryHoldManager).holds[expiring.HoldID].ExpiresAt = time.Now().Add(-time.Second)
+	held, err = holdManager.GetHeldAmount(ctx, "WALLET")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), held)
+	expiring, err = holdManager.GetHold(ctx, expiring.HoldID)
+	assert.NoError(t, err)
+	assert.Equal(t, HoldExpired, expiring.Status)
+	_, err = holdManager.CaptureHold(ctx, expiring.HoldID, "MERCHANT", 0, "", "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrHoldNotHeld))
+	expired, err := holdManager.ExpireHolds(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), expired)
+
+	_, err = holdManager.GetHold(ctx, "NOSUCHHOLD")
+	assert.True(t, errors.Is(err, hwerrors.ErrHoldNotFound))
+}
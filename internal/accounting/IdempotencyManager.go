@@ -0,0 +1,105 @@
+package accounting
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+)
+
+// IdempotencyRecord remembers the outcome of a request made with an Idempotency-Key header,
+// so a retry of the same request can be answered without posting the journal again.
+type IdempotencyRecord struct {
+	// Key is the client supplied idempotency key
+	Key string
+	// RequestHash is the hash of the request payload that first used the key
+	RequestHash string
+	// JournalID is the journal created by the request that first used the key
+	JournalID string
+	// ResponseBody is the exact response body returned to the request that first used the key
+	ResponseBody []byte
+	// CreatedAt is the time the key is first used
+	CreatedAt time.Time
+	// CreatedBy is the creator of the journal
+	CreatedBy string
+}
+
+// IdempotencyManager keeps track of idempotency keys used when creating journals.
+type IdempotencyManager interface {
+	// GetIdempotencyRecord retrieves the record of the specified key.
+	// It returns nil if the key is never used or it was used longer ago than the idempotency window.
+	GetIdempotencyRecord(ctx context.Context, key string) (*IdempotencyRecord, error)
+
+	// PersistJournal persists the journal together with its idempotency record, either both are persisted or none.
+	// Throws ErrIdempotencyKeyExists if the key in the record is already used within the idempotency window.
+	PersistJournal(ctx context.Context, journal acccore.Journal, record *IdempotencyRecord) error
+
+	// PurgeExpired permanently deletes the records that were created longer ago than the idempotency window.
+	// Expired records are never replayed, purging only keeps them from piling up.
+	// It returns the number of records deleted.
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+// NewInMemoryIdempotencyManager returns an idempotency manager that keeps its records in memory
+// and persists journals using the specified journal manager.
+func NewInMemoryIdempotencyManager(journalManager acccore.JournalManager, window time.Duration) IdempotencyManager {
+	return &InMemoryIdempotencyManager{
+		journalManager: journalManager,
+		window:         window,
+		records:        make(map[string]*IdempotencyRecord),
+	}
+}
+
+// InMemoryIdempotencyManager implementation of IdempotencyManager that keeps records in memory.
+// Suitable for testing, records are lost when the application stops.
+type InMemoryIdempotencyManager struct {
+	journalManager acccore.JournalManager
+	window         time.Duration
+	mutex          sync.Mutex
+	records        map[string]*IdempotencyRecord
+}
+
+// GetIdempotencyRecord retrieves the record of the specified key.
+// It returns nil if the key is never used or it was used longer ago than the idempotency window.
+func (im *InMemoryIdempotencyManager) GetIdempotencyRecord(ctx context.Context, key string) (*IdempotencyRecord, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	rec, ok := im.records[key]
+	if !ok || time.Since(rec.CreatedAt) > im.window {
+		return nil, nil
+	}
+	return rec, nil
+}
+
+// PersistJournal persists the journal together with its idempotency record, either both are persisted or none.
+// Throws ErrIdempotencyKeyExists if the key in the record is already used within the idempotency window.
+func (im *InMemoryIdempotencyManager) PersistJournal(ctx context.Context, journal acccore.Journal, record *IdempotencyRecord) error {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	if rec, ok := im.records[record.Key]; ok && time.Since(rec.CreatedAt) <= im.window {
+		return hwerrors.ErrIdempotencyKeyExists
+	}
+	err := im.journalManager.PersistJournal(ctx, journal)
+	if err != nil {
+		return err
+	}
+	im.records[record.Key] = record
+	return nil
+}
+
+// PurgeExpired permanently deletes the records that were created longer ago than the idempotency window.
+// It returns the number of records deleted.
+func (im *InMemoryIdempotencyManager) PurgeExpired(ctx context.Context) (int64, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	deleted := int64(0)
+	for key, rec := range im.records {
+		if time.Since(rec.CreatedAt) > im.window {
+			delete(im.records, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
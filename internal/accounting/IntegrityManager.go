@@ -0,0 +1,289 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+)
+
+// DiscrepancyKind is the kind of inconsistency found by the integrity verification.
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyBalanceMismatch accounts whose balance differs from the replay of their transactions
+	DiscrepancyBalanceMismatch DiscrepancyKind = "BALANCE_MISMATCH"
+	// DiscrepancySnapshotMismatch transactions whose balance snapshot differs from the replayed account balance
+	DiscrepancySnapshotMismatch DiscrepancyKind = "SNAPSHOT_MISMATCH"
+	// DiscrepancyUnbalancedJournal journals whose debit and credit differ in a currency
+	DiscrepancyUnbalancedJournal DiscrepancyKind = "UNBALANCED_JOURNAL"
+	// DiscrepancyOrphanTransaction transactions whose account or journal does not exist
+	DiscrepancyOrphanTransaction DiscrepancyKind = "ORPHAN_TRANSACTION"
+)
+
+// Discrepancy is a single inconsistency of the ledger.
+type Discrepancy struct {
+	Kind          DiscrepancyKind
+	AccountNumber string
+	JournalID     string
+	TransactionID string
+	Currency      string
+	// Expected is the replayed balance of a mismatch, or the debit of an unbalanced journal
+	Expected int64
+	// Actual is the recorded balance of a mismatch, the credit of an unbalanced journal, or the amount of an orphan transaction
+	Actual      int64
+	Description string
+}
+
+// IntegrityReport lists every discrepancy found by replaying the transactions of every account.
+type IntegrityReport struct {
+	CheckedAt time.Time
+	// Accounts is the number of accounts replayed
+	Accounts int
+	// Transactions is the number of transactions replayed
+	Transactions  int
+	Discrepancies []*Discrepancy
+}
+
+// Consistent tells if no discrepancy was found.
+func (r *IntegrityReport) Consistent() bool {
+	return len(r.Discrepancies) == 0
+}
+
+// integrityAccount is the part of an account the replay needs.
+type integrityAccount struct {
+	AccountNumber string
+	Currency      string
+	Alignment     acccore.Alignment
+	Balance       int64
+}
+
+// integrityTransaction is the part of a transaction the replay needs.
+type integrityTransaction struct {
+	TransactionID   string
+	AccountNumber   string
+	JournalID       string
+	TransactionTime time.Time
+	CreatedAt       time.Time
+	Alignment       acccore.Alignment
+	Amount          int64
+	Balance         int64
+}
+
+// sameTime tells if two transactions were posted at the same recorded time, their order is then unknown.
+func (t *integrityTransaction) sameTime(other *integrityTransaction) bool {
+	return t.TransactionTime.Equal(other.TransactionTime) && t.CreatedAt.Equal(other.CreatedAt)
+}
+
+// replayAccount replays the transactions of the account from a zero balance in the order they were booked, adding
+// a discrepancy for every transaction whose balance snapshot differs from the replayed balance, and one if the
+// account balance differs from the balance after the last transaction. Snapshots are taken when booking, a
+// backdated transaction follows the transactions booked before it whatever its transaction time.
+// Transactions posted at the same recorded time are replayed in the order their snapshots agree with, the
+// recorded times are too coarse to order postings made within the same second.
+func (r *IntegrityReport) replayAccount(account *integrityAccount, transactions []*integrityTransaction) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		if !transactions[i].CreatedAt.Equal(transactions[j].CreatedAt) {
+			return transactions[i].CreatedAt.Before(transactions[j].CreatedAt)
+		}
+		return transactions[i].TransactionTime.Before(transactions[j].TransactionTime)
+	})
+	next := func(balance int64, trx *integrityTransaction) int64 {
+		if trx.Alignment == account.Alignment {
+			return balance + trx.Amount
+		}
+		return balance - trx.Amount
+	}
+	balance := int64(0)
+	for i, trx := range transactions {
+		for j := i + 1; j < len(transactions) && transactions[j].sameTime(trx); j++ {
+			if next(balance, trx) == trx.Balance {
+				break
+			}
+			if next(balance, transactions[j]) == transactions[j].Balance {
+				transactions[i], transactions[j] = transactions[j], transactions[i]
+				trx = transactions[i]
+				break
+			}
+		}
+		balance = next(balance, trx)
+		if trx.Balance != balance {
+			r.Discrepancies = append(r.Discrepancies, &Discrepancy{
+				Kind:          DiscrepancySnapshotMismatch,
+				AccountNumber: account.AccountNumber,
+				JournalID:     trx.JournalID,
+				TransactionID: trx.TransactionID,
+				Currency:      account.Currency,
+				Expected:      balance,
+				Actual:        trx.Balance,
+				Description:   fmt.Sprintf("transaction %s records balance %d, replaying gives %d", trx.TransactionID, trx.Balance, balance),
+			})
+		}
+	}
+	if account.Balance != balance {
+		r.Discrepancies = append(r.Discrepancies, &Discrepancy{
+			Kind:          DiscrepancyBalanceMismatch,
+			AccountNumber: account.AccountNumber,
+			Currency:      account.Currency,
+			Expected:      balance,
+			Actual:        account.Balance,
+			Description:   fmt.Sprintf("account %s balance is %d, replaying its %d transactions gives %d", account.AccountNumber, account.Balance, len(transactions), balance),
+		})
+	}
+	r.Accounts++
+	r.Transactions += len(transactions)
+}
+
+// addUnbalancedJournal reports a journal whose debit and credit differ in the currency.
+func (r *IntegrityReport) addUnbalancedJournal(journalID, currency string, debit, credit int64) {
+	r.Discrepancies = append(r.Discrepancies, &Discrepancy{
+		Kind:        DiscrepancyUnbalancedJournal,
+		JournalID:   journalID,
+		Currency:    currency,
+		Expected:    debit,
+		Actual:      credit,
+		Description: fmt.Sprintf("journal %s debits %d and credits %d %s", journalID, debit, credit, currency),
+	})
+}
+
+// addOrphanTransaction reports a transaction whose account or journal does not exist.
+func (r *IntegrityReport) addOrphanTransaction(trx *integrityTransaction, missing string) {
+	r.Discrepancies = append(r.Discrepancies, &Discrepancy{
+		Kind:          DiscrepancyOrphanTransaction,
+		AccountNumber: trx.AccountNumber,
+		JournalID:     trx.JournalID,
+		TransactionID: trx.TransactionID,
+		Actual:        trx.Amount,
+		Description:   fmt.Sprintf("transaction %s belongs to %s that does not exist", trx.TransactionID, missing),
+	})
+}
+
+// IntegrityManager verifies the denormalized balances of the ledger agree with its transactions.
+type IntegrityManager interface {
+	// VerifyIntegrity replays the transactions of every account in order and reports the accounts and
+	// transaction snapshots whose balance differs from the replay, the journals not balanced in some
+	// currency and the transactions whose account or journal does not exist.
+	// Discrepancies are reported, only failing to read the ledger returns an error.
+	VerifyIntegrity(ctx context.Context) (*IntegrityReport, error)
+}
+
+// NewInMemoryIntegrityManager returns an integrity manager that reads the accounts, transactions and journals
+// from the specified managers.
+func NewInMemoryIntegrityManager(accountManager acccore.AccountManager, transactionManager acccore.TransactionManager,
+	journalManager acccore.JournalManager) IntegrityManager {
+	return &InMemoryIntegrityManager{
+		accountManager:     accountManager,
+		transactionManager: transactionManager,
+		journalManager:     journalManager,
+	}
+}
+
+// InMemoryIntegrityManager implementation of IntegrityManager on top of the in memory managers.
+// Suitable for testing, the in memory journal manager never leaves a transaction without its account.
+type InMemoryIntegrityManager struct {
+	accountManager     acccore.AccountManager
+	transactionManager acccore.TransactionManager
+	journalManager     acccore.JournalManager
+}
+
+// VerifyIntegrity replays the transactions of every account in order and reports every discrepancy found.
+func (im *InMemoryIntegrityManager) VerifyIntegrity(ctx context.Context) (*IntegrityReport, error) {
+	report := &IntegrityReport{CheckedAt: time.Now(), Discrepancies: make([]*Discrepancy, 0)}
+	result, accounts, err := im.accountManager.ListAccounts(ctx, acccore.PageRequest{PageNo: 1, ItemSize: 100})
+	if err != nil {
+		return nil, err
+	}
+	if result.TotalEntries > len(accounts) {
+		if _, accounts, err = im.accountManager.ListAccounts(ctx, acccore.PageRequest{PageNo: 1, ItemSize: result.TotalEntries}); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].GetAccountNumber() < accounts[j].GetAccountNumber()
+	})
+	journals := make(map[string][]*integrityTransaction)
+	currencies := make(map[string]string)
+	for _, account := range accounts {
+		until := time.Now()
+		result, transactions, err := im.transactionManager.ListTransactionsOnAccount(ctx, time.Time{}, until, account, acccore.PageRequest{PageNo: 1, ItemSize: 100})
+		if err != nil {
+			return nil, err
+		}
+		if result.TotalEntries > len(transactions) {
+			if _, transactions, err = im.transactionManager.ListTransactionsOnAccount(ctx, time.Time{}, until, account, acccore.PageRequest{PageNo: 1, ItemSize: result.TotalEntries}); err != nil {
+				return nil, err
+			}
+		}
+		replayed := make([]*integrityTransaction, 0, len(transactions))
+		for _, transaction := range transactions {
+			trx := &integrityTransaction{
+				TransactionID:   transaction.GetTransactionID(),
+				AccountNumber:   account.GetAccountNumber(),
+				JournalID:       transaction.GetJournalID(),
+				TransactionTime: transaction.GetTransactionTime(),
+				CreatedAt:       transaction.GetCreateTime(),
+				Alignment:       transaction.GetAlignment(),
+				Amount:          transaction.GetAmount(),
+				Balance:         transaction.GetAccountBalance(),
+			}
+			replayed = append(replayed, trx)
+			journals[trx.JournalID] = append(journals[trx.JournalID], trx)
+		}
+		currencies[account.GetAccountNumber()] = account.GetCurrency()
+		report.replayAccount(&integrityAccount{
+			AccountNumber: account.GetAccountNumber(),
+			Currency:      account.GetCurrency(),
+			Alignment:     account.GetAlignment(),
+			Balance:       account.GetBalance(),
+		}, replayed)
+	}
+
+	journalIDs := make([]string, 0, len(journals))
+	for journalID := range journals {
+		journalIDs = append(journalIDs, journalID)
+	}
+	sort.Strings(journalIDs)
+	for _, journalID := range journalIDs {
+		exist, err := im.journalManager.IsJournalIDExist(ctx, journalID)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			for _, trx := range journals[journalID] {
+				report.addOrphanTransaction(trx, "journal "+journalID)
+			}
+			continue
+		}
+		debits, credits := make(map[string]int64), make(map[string]int64)
+		for _, trx := range journals[journalID] {
+			if trx.Alignment == acccore.DEBIT {
+				debits[currencies[trx.AccountNumber]] += trx.Amount
+			} else {
+				credits[currencies[trx.AccountNumber]] += trx.Amount
+			}
+		}
+		for _, currency := range sortedCurrencies(debits, credits) {
+			if debits[currency] != credits[currency] {
+				report.addUnbalancedJournal(journalID, currency, debits[currency], credits[currency])
+			}
+		}
+	}
+	return report, nil
+}
+
+// sortedCurrencies returns the currencies of the debit and credit sums in order.
+func sortedCurrencies(debits, credits map[string]int64) []string {
+	currencies := make([]string, 0, len(debits)+len(credits))
+	for currency := range debits {
+		currencies = append(currencies, currency)
+	}
+	for currency := range credits {
+		if _, ok := debits[currency]; !ok {
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Strings(currencies)
+	return currencies
+}
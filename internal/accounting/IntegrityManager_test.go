@@ -0,0 +1,84 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntegrityReport_ReplayAccount(t *testing.T) {
+	first := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Second)
+	report := &IntegrityReport{}
+	// the first two were posted within the same second, their snapshots tell their order
+	report.replayAccount(&integrityAccount{AccountNumber: "WALLET", Currency: "IDR", Alignment: acccore.DEBIT, Balance: 100}, []*integrityTransaction{
+		{TransactionID: "T3", JournalID: "J3", TransactionTime: second, Alignment: acccore.DEBIT, Amount: 50, Balance: 999},
+		{TransactionID: "T2", JournalID: "J2", TransactionTime: first, Alignment: acccore.CREDIT, Amount: 30, Balance: 70},
+		{TransactionID: "T1", JournalID: "J1", TransactionTime: first, Alignment: acccore.DEBIT, Amount: 100, Balance: 100},
+	})
+	assert.Equal(t, 1, report.Accounts)
+	assert.Equal(t, 3, report.Transactions)
+	assert.False(t, report.Consistent())
+	if assert.Len(t, report.Discrepancies, 2) {
+		assert.Equal(t, DiscrepancySnapshotMismatch, report.Discrepancies[0].Kind)
+		assert.Equal(t, "T3", report.Discrepancies[0].TransactionID)
+		assert.Equal(t, int64(120), report.Discrepancies[0].Expected)
+		assert.Equal(t, int64(999), report.Discrepancies[0].Actual)
+		assert.Equal(t, DiscrepancyBalanceMismatch, report.Discrepancies[1].Kind)
+		assert.Equal(t, "WALLET", report.Discrepancies[1].AccountNumber)
+		assert.Equal(t, int64(120), report.Discrepancies[1].Expected)
+		assert.Equal(t, int64(100), report.Discrepancies[1].Actual)
+	}
+
+	report = &IntegrityReport{}
+	report.replayAccount(&integrityAccount{AccountNumber: "LOAN", Alignment: acccore.CREDIT, Balance: 70}, []*integrityTransaction{
+		{TransactionID: "T1", TransactionTime: first, Alignment: acccore.CREDIT, Amount: 100, Balance: 100},
+		{TransactionID: "T2", TransactionTime: second, Alignment: acccore.DEBIT, Amount: 30, Balance: 70},
+	})
+	assert.True(t, report.Consistent())
+}
+
+func TestInMemoryIntegrityManager(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	acccore.ClearInMemoryTables()
+	accountManager := &acccore.InMemoryAccountManager{}
+	for number, alignment := range map[string]acccore.Alignment{"CASH": acccore.DEBIT, "LOAN": acccore.CREDIT} {
+		account := &acccore.BaseAccount{}
+		account.SetAccountNumber(number).SetName(number).SetDescription(number + " test account").
+			SetCurrency("IDR").SetAlignment(alignment).SetCreateBy("TESTING")
+		assert.NoError(t, accountManager.PersistAccount(ctx, account))
+	}
+	journalManager := &acccore.InMemoryJournalManager{}
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("borrowing", "CASH", "LOAN", 1000)))
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("repaying", "LOAN", "CASH", 400)))
+	// more transactions than a single page of the account listing
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("installment", "LOAN", "CASH", 1)))
+	}
+	integrityManager := NewInMemoryIntegrityManager(accountManager, pagedTransactionManager{&acccore.InMemoryTransactionManager{}}, journalManager)
+
+	report, err := integrityManager.VerifyIntegrity(ctx)
+	assert.NoError(t, err)
+	assert.True(t, report.Consistent())
+	assert.Equal(t, 2, report.Accounts)
+	assert.Equal(t, 204, report.Transactions)
+
+	account, err := accountManager.GetAccountByID(ctx, "CASH")
+	assert.NoError(t, err)
+	account.SetBalance(650)
+	assert.NoError(t, accountManager.UpdateAccount(ctx, account))
+	report, err = integrityManager.VerifyIntegrity(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Discrepancy{{
+		Kind:          DiscrepancyBalanceMismatch,
+		AccountNumber: "CASH",
+		Currency:      "IDR",
+		Expected:      500,
+		Actual:        650,
+		Description:   "account CASH balance is 650, replaying its 102 transactions gives 500",
+	}}, report.Discrepancies)
+}
@@ -0,0 +1,74 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+)
+
+// batchChunks splits a batch of the specified size into chunks of at most chunkSize journals, returning the start
+// and end index of every chunk. A chunk size of zero or less makes the whole batch a single chunk.
+func batchChunks(size, chunkSize int) [][2]int {
+	if chunkSize <= 0 {
+		chunkSize = size
+	}
+	ret := make([][2]int, 0)
+	for start := 0; start < size; start += chunkSize {
+		end := start + chunkSize
+		if end > size {
+			end = size
+		}
+		ret = append(ret, [2]int{start, end})
+	}
+	return ret
+}
+
+// notPosted sets the error of every journal of the batch left unposted because the journal at index failed was
+// refused, starting at index from. Journals with an error of their own keep it.
+func notPosted(errs []error, from, failed int) {
+	for i := from; i < len(errs); i++ {
+		if i != failed && errs[i] == nil {
+			errs[i] = fmt.Errorf("%w : journal %d of the batch was refused", hwerrors.ErrJournalBatchNotPosted, failed)
+		}
+	}
+}
+
+// batchContext returns the context a journal of a batch is persisted in, identifying its author.
+func batchContext(ctx context.Context, journal acccore.Journal) context.Context {
+	return context.WithValue(ctx, contextkeys.UserIDContextKey, journal.GetCreateBy())
+}
+
+// JournalBatchManager posts many journals at once, either all or nothing, or each on its own.
+type JournalBatchManager interface {
+	// PersistJournals persists the journals in order and returns the error of every journal, nil if it was posted.
+	// If allOrNothing, the first journal refused leaves the whole batch unposted, every other journal then gets
+	// ErrJournalBatchNotPosted. Otherwise every journal is posted or refused on its own.
+	PersistJournals(ctx context.Context, journals []acccore.Journal, allOrNothing bool) []error
+}
+
+// NewInMemoryJournalBatchManager returns a journal batch manager posting the journals one by one with the journal manager.
+func NewInMemoryJournalBatchManager(journalManager acccore.JournalManager) JournalBatchManager {
+	return &InMemoryJournalBatchManager{journalManager: journalManager}
+}
+
+// InMemoryJournalBatchManager implementation of JournalBatchManager on top of the in memory journal manager.
+// Suitable for testing, the in memory journal manager can not roll back, an all or nothing batch stops at the first
+// journal refused but the journals before it stay posted.
+type InMemoryJournalBatchManager struct {
+	journalManager acccore.JournalManager
+}
+
+// PersistJournals persists the journals in order and returns the error of every journal.
+func (im *InMemoryJournalBatchManager) PersistJournals(ctx context.Context, journals []acccore.Journal, allOrNothing bool) []error {
+	errs := make([]error, len(journals))
+	for i, journal := range journals {
+		if errs[i] = im.journalManager.PersistJournal(batchContext(ctx, journal), journal); errs[i] != nil && allOrNothing {
+			notPosted(errs, i+1, i)
+			break
+		}
+	}
+	return errs
+}
@@ -0,0 +1,64 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchChunks(t *testing.T) {
+	assert.Equal(t, [][2]int{{0, 2}, {2, 4}, {4, 5}}, batchChunks(5, 2))
+	assert.Equal(t, [][2]int{{0, 4}}, batchChunks(4, 4))
+	assert.Equal(t, [][2]int{{0, 3}}, batchChunks(3, 0))
+	assert.Empty(t, batchChunks(0, 2))
+}
+
+func TestNotPosted(t *testing.T) {
+	errs := []error{nil, acccore.ErrJournalNotBalance, nil, acccore.ErrAccountIDNotFound, nil}
+	notPosted(errs, 2, 1)
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], acccore.ErrJournalNotBalance)
+	assert.ErrorIs(t, errs[2], hwerrors.ErrJournalBatchNotPosted)
+	assert.ErrorIs(t, errs[3], acccore.ErrAccountIDNotFound)
+	assert.ErrorIs(t, errs[4], hwerrors.ErrJournalBatchNotPosted)
+}
+
+func TestInMemoryJournalBatchManager(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	acccore.ClearInMemoryTables()
+	accountManager := &acccore.InMemoryAccountManager{}
+	for number, alignment := range map[string]acccore.Alignment{"CASH": acccore.DEBIT, "LOAN": acccore.CREDIT} {
+		account := &acccore.BaseAccount{}
+		account.SetAccountNumber(number).SetName(number).SetDescription(number + " test account").
+			SetCurrency("IDR").SetAlignment(alignment).SetCreateBy("TESTING")
+		assert.NoError(t, accountManager.PersistAccount(ctx, account))
+	}
+	batchManager := NewInMemoryJournalBatchManager(&acccore.InMemoryJournalManager{})
+
+	errs := batchManager.PersistJournals(ctx, []acccore.Journal{
+		makeTestJournal("borrowing", "CASH", "LOAN", 1000),
+		makeTestJournal("borrowing elsewhere", "CASH", "BANK", 1000),
+		makeTestJournal("repaying", "LOAN", "CASH", 400),
+	}, false)
+	if assert.Len(t, errs, 3) {
+		assert.NoError(t, errs[0])
+		assert.ErrorIs(t, errs[1], acccore.ErrJournalTransactionAccountNotPersist)
+		assert.NoError(t, errs[2])
+	}
+
+	errs = batchManager.PersistJournals(ctx, []acccore.Journal{
+		makeTestJournal("borrowing elsewhere", "CASH", "BANK", 1000),
+		makeTestJournal("repaying", "LOAN", "CASH", 400),
+	}, true)
+	if assert.Len(t, errs, 2) {
+		assert.ErrorIs(t, errs[0], acccore.ErrJournalTransactionAccountNotPersist)
+		assert.ErrorIs(t, errs[1], hwerrors.ErrJournalBatchNotPosted)
+	}
+	account, err := accountManager.GetAccountByID(ctx, "CASH")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(600), account.GetBalance())
+}
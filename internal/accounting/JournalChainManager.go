@@ -0,0 +1,128 @@
+package accounting
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/hyperjumptech/hyperwallet/internal/connector"
+)
+
+// ChainBreak is the reason a link of the journal hash chain is broken.
+type ChainBreak string
+
+const (
+	// ChainBreakMissingJournal a journal of the chain was deleted
+	ChainBreakMissingJournal ChainBreak = "MISSING_JOURNAL"
+	// ChainBreakPreviousHashMismatch the previous hash recorded by a journal is not the hash of the journal before it
+	ChainBreakPreviousHashMismatch ChainBreak = "PREVIOUS_HASH_MISMATCH"
+	// ChainBreakHashMismatch the journal or its transactions were edited after they were hashed
+	ChainBreakHashMismatch ChainBreak = "HASH_MISMATCH"
+)
+
+// BrokenChainLink is the first link of the journal hash chain that does not verify.
+type BrokenChainLink struct {
+	Sequence  int64
+	JournalID string
+	Reason    ChainBreak
+	// Expected is the hash the link should have, computed from the chain
+	Expected string
+	// Actual is the hash recorded in the link
+	Actual string
+}
+
+// ChainVerification is the result of verifying the journal hash chain over a period.
+type ChainVerification struct {
+	From  time.Time
+	Until time.Time
+	// FirstSequence and LastSequence are the chain sequences of the first and last journals of the period
+	FirstSequence int64
+	LastSequence  int64
+	// Journals is the number of journals verified before the chain broke, or in the period if it did not
+	Journals   int
+	BrokenLink *BrokenChainLink
+}
+
+// Valid tells if every link of the chain in the period verifies.
+func (v *ChainVerification) Valid() bool {
+	return v.BrokenLink == nil
+}
+
+// chainedJournal is the content of a journal hashed into the chain.
+type chainedJournal struct {
+	JournalID         string
+	JournalingTime    string
+	Description       string
+	IsReversal        bool
+	ReversedJournalID string
+	TotalAmount       int64
+	CreatedAt         string
+	CreatedBy         string
+	ChainSequence     int64
+	PreviousHash      string
+	Transactions      []*chainedTransaction
+}
+
+// chainedTransaction is the content of a transaction hashed into the chain with its journal.
+type chainedTransaction struct {
+	TransactionID   string
+	TransactionTime string
+	AccountNumber   string
+	Alignment       string
+	Amount          int64
+	Balance         int64
+	Description     string
+	CreatedAt       string
+	CreatedBy       string
+}
+
+// journalChainHash returns the hex SHA-256 hash of the journal, its transactions and the hash of the journal
+// before it in the chain. Times are hashed in UTC, and transactions in the order of their IDs, so the hash
+// only depends on the stored values.
+func journalChainHash(journal *connector.JournalRecord, transactions []*connector.TransactionRecord) string {
+	chained := &chainedJournal{
+		JournalID:         journal.JournalID,
+		JournalingTime:    journal.JournalingTime.UTC().Format(time.RFC3339Nano),
+		Description:       journal.Description,
+		IsReversal:        journal.IsReversal,
+		ReversedJournalID: journal.ReversedJournalID,
+		TotalAmount:       journal.TotalAmount,
+		CreatedAt:         journal.CreatedAt.UTC().Format(time.RFC3339Nano),
+		CreatedBy:         journal.CreatedBy,
+		ChainSequence:     journal.ChainSequence,
+		PreviousHash:      journal.PreviousHash,
+		Transactions:      make([]*chainedTransaction, 0, len(transactions)),
+	}
+	for _, trx := range transactions {
+		chained.Transactions = append(chained.Transactions, &chainedTransaction{
+			TransactionID:   trx.TransactionID,
+			TransactionTime: trx.TransactionTime.UTC().Format(time.RFC3339Nano),
+			AccountNumber:   trx.AccountNumber,
+			Alignment:       trx.Alignment,
+			Amount:          trx.Amount,
+			Balance:         trx.Balance,
+			Description:     trx.Description,
+			CreatedAt:       trx.CreatedAt.UTC().Format(time.RFC3339Nano),
+			CreatedBy:       trx.CreatedBy,
+		})
+	}
+	sort.Slice(chained.Transactions, func(i, j int) bool {
+		return chained.Transactions[i].TransactionID < chained.Transactions[j].TransactionID
+	})
+	content, _ := json.Marshal(chained)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// JournalChainManager verifies the journals persisted were not edited afterwards.
+// Every journal is hashed with its transactions and the hash of the journal persisted before it,
+// editing or deleting a journal breaks the chain from that journal on.
+type JournalChainManager interface {
+	// VerifyJournalChain recomputes the hash of every journal chained and booked within the period, and returns the
+	// first broken link found. Journals persisted before the chain was introduced are not chained.
+	// A broken chain is reported, only failing to read the journals returns an error.
+	VerifyJournalChain(ctx context.Context, from, until time.Time) (*ChainVerification, error)
+}
@@ -0,0 +1,34 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperjumptech/hyperwallet/internal/connector"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalChainHash(t *testing.T) {
+	at := time.Date(2021, 3, 1, 7, 0, 0, 0, time.FixedZone("WIB", 7*60*60))
+	journal := &connector.JournalRecord{JournalID: "J1", JournalingTime: at, Description: "borrowing", TotalAmount: 100,
+		CreatedAt: at, CreatedBy: "TESTING", ChainSequence: 2, PreviousHash: "previous"}
+	transactions := []*connector.TransactionRecord{
+		{TransactionID: "T1", TransactionTime: at, AccountNumber: "CASH", JournalID: "J1", Alignment: "DEBIT", Amount: 100, Balance: 100, CreatedAt: at},
+		{TransactionID: "T2", TransactionTime: at, AccountNumber: "LOAN", JournalID: "J1", Alignment: "CREDIT", Amount: 100, Balance: 100, CreatedAt: at},
+	}
+	hash := journalChainHash(journal, transactions)
+	assert.Len(t, hash, 64)
+
+	// the same stored values read in another order and time zone hash the same
+	utc := *journal
+	utc.JournalingTime, utc.CreatedAt = at.UTC(), at.UTC()
+	assert.Equal(t, hash, journalChainHash(&utc, []*connector.TransactionRecord{transactions[1], transactions[0]}))
+
+	edited := *journal
+	edited.Description = "lending"
+	assert.NotEqual(t, hash, journalChainHash(&edited, transactions))
+	relinked := *journal
+	relinked.PreviousHash = "another"
+	assert.NotEqual(t, hash, journalChainHash(&relinked, transactions))
+	assert.NotEqual(t, hash, journalChainHash(journal, transactions[:1]))
+}
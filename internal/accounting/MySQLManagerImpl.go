@@ -6,11 +6,16 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"html"
 	"math/big"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
 	"github.com/hyperjumptech/hyperwallet/internal/connector"
 	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
 	"github.com/olekukonko/tablewriter"
@@ -45,10 +50,50 @@ func (jm *MySQLJournalManager) NewJournal(ctx context.Context) acccore.Journal {
 //    3.Each of this account must belong to the same Currency
 //    4.Balanced. The total sum of DEBIT and total sum of CREDIT is equal.
 //    5.No duplicate transaction that belongs to the same Account.
+// An FXJournal may have accounts of different currencies, its transactions must balance within each currency instead.
+// Postings to FROZEN or CLOSED accounts are refused with ErrAccountFrozen or ErrAccountClosed, and postings taking
+// an account balance beyond its limits, or its balance less the held amount below its minimum, with a BalanceLimitError.
 // If your database support 2 phased commit, you can make all balance changes in
 // accounts and transactions. If your db do not support this, you can implement your own 2 phase commits mechanism
 // on the CommitJournal and CancelJournal
 func (jm *MySQLJournalManager) PersistJournal(ctx context.Context, journalToPersist acccore.Journal) error {
+	accounts, err := journalAccounts(ctx, jm.repo, []acccore.Journal{journalToPersist})
+	if err != nil {
+		return err
+	}
+	return jm.persistJournal(ctx, journalToPersist, accounts)
+}
+
+// journalAccounts reads, in a single query, the accounts the transactions of the journals are on, by account number.
+// Accounts that do not exist are left out.
+func journalAccounts(ctx context.Context, repo connector.DBRepository, journals []acccore.Journal) (map[string]*connector.AccountRecord, error) {
+	accountNumbers := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, journal := range journals {
+		if journal == nil {
+			continue
+		}
+		for _, trx := range journal.GetTransactions() {
+			if !seen[trx.GetAccountNumber()] {
+				seen[trx.GetAccountNumber()] = true
+				accountNumbers = append(accountNumbers, trx.GetAccountNumber())
+			}
+		}
+	}
+	recs, err := repo.ListAccountByNumbers(ctx, accountNumbers)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]*connector.AccountRecord, len(recs))
+	for _, rec := range recs {
+		ret[rec.AccountNumber] = rec
+	}
+	return ret, nil
+}
+
+// persistJournal records the journal like PersistJournal does, telling whether the accounts exist and their currency
+// from the accounts read beforehand. Their balances are read again under lock.
+func (jm *MySQLJournalManager) persistJournal(ctx context.Context, journalToPersist acccore.Journal, accounts map[string]*connector.AccountRecord) error {
 	requestID := ctx.Value(contextkeys.XRequestID).(string)
 	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "PersistJournal")
 
@@ -109,6 +154,8 @@ func (jm *MySQLJournalManager) PersistJournal(ctx context.Context, journalToPers
 	}
 
 	// 5. Make sure transactions are balanced.
+	//    An FXJournal is balanced within each currency in step 8.
+	fxJournal, isFX := journalToPersist.(*FXJournal)
 	var creditSum, debitSum int64
 	for _, trx := range journalToPersist.GetTransactions() {
 		if trx.GetAlignment() == acccore.DEBIT {
@@ -118,7 +165,7 @@ func (jm *MySQLJournalManager) PersistJournal(ctx context.Context, journalToPers
 			creditSum += trx.GetAmount()
 		}
 	}
-	if creditSum != debitSum {
+	if !isFX && creditSum != debitSum {
 		lLog.Errorf("error persisting journal %s. debit (%d) != credit (%d). journal not balance", journalToPersist.GetJournalID(), debitSum, creditSum)
 		return acccore.ErrJournalNotBalance
 	}
@@ -135,157 +182,231 @@ func (jm *MySQLJournalManager) PersistJournal(ctx context.Context, journalToPers
 
 	// 7. Make sure transactions are all belong to existing accounts
 	for _, trx := range journalToPersist.GetTransactions() {
-		account, err := jm.repo.GetAccount(ctx, trx.GetAccountNumber())
-		if err != nil || account == nil {
+		if _, ok := accounts[trx.GetAccountNumber()]; !ok {
 			lLog.Errorf("error persisting journal %s. theres a transaction belong to non existent account (%s)", journalToPersist.GetJournalID(), trx.GetAccountNumber())
 			return acccore.ErrJournalTransactionAccountNotPersist
 		}
 	}
 
-	// 8. Make sure transactions are all have the same currency
+	// 8. Make sure transactions are all have the same currency, or for an FXJournal, balanced within each currency
 	var currency string
+	currencyNet := make(map[string]int64)
 	for idx, trx := range journalToPersist.GetTransactions() {
-		account, err := jm.repo.GetAccount(ctx, trx.GetAccountNumber())
-		if err != nil || account == nil {
-			return acccore.ErrAccountIDNotFound
+		cur := accounts[trx.GetAccountNumber()].CurrencyCode
+		if trx.GetAlignment() == acccore.DEBIT {
+			currencyNet[cur] += trx.GetAmount()
+		} else {
+			currencyNet[cur] -= trx.GetAmount()
 		}
-		cur := account.CurrencyCode
 		if idx == 0 {
 			currency = cur
 		} else {
-			if cur != currency {
+			if cur != currency && !isFX {
 				lLog.Errorf("error persisting journal %s. transactions here uses account with different currencies", journalToPersist.GetJournalID())
 				return acccore.ErrJournalTransactionMixCurrency
 			}
 		}
 	}
+	if isFX {
+		for cur, net := range currencyNet {
+			if net != 0 {
+				lLog.Errorf("error persisting journal %s. debit and credit in %s differ by %d. journal not balance", journalToPersist.GetJournalID(), cur, net)
+				return acccore.ErrJournalNotBalance
+			}
+		}
+		// the credits of different currencies do not add up, the journal amount is their value in the base currency.
+		creditSum = fxJournal.BaseAmount
+	}
 
-	// 9. If this is a reversal journal, make sure the journal being reversed have not been reversed before.
+	// 9. If this is a reversal journal, make sure the journal being reversed have not been reversed in full before.
+	//    How much of each leg is left to reverse is checked again under the chain lock.
 	if journalToPersist.GetReversedJournal() != nil {
-		reversed, err := jm.IsJournalIDReversed(ctx, journalToPersist.GetJournalID())
+		reversedJournalID := journalToPersist.GetReversedJournal().GetJournalID()
+		reversed, err := jm.IsJournalIDReversed(ctx, reversedJournalID)
 		if err != nil {
 			return err
 		}
 		if reversed {
-			lLog.Errorf("error persisting journal %s. this journal try to make reverse transaction on journals thats already reversed %s", journalToPersist.GetJournalID(), journalToPersist.GetJournalID())
+			lLog.Errorf("error persisting journal %s. this journal try to make reverse transaction on journals thats already reversed %s", journalToPersist.GetJournalID(), reversedJournalID)
 			return acccore.ErrJournalCanNotDoubleReverse
 		}
 	}
 
 	// ALL is OK. So lets start persisting.
-
-	// BEGIN transaction
-	tx, err := jm.repo.DB().BeginTxx(ctx, &sql.TxOptions{
-		// todo investigate the use of this.
-		Isolation: 0,
-		ReadOnly:  false,
-	})
-	if err != nil {
-		lLog.Errorf("error creating transaction. got %s", err.Error())
-		return err
-	}
-
-	// 1. Save the Journal
-	journalToInsert := &connector.JournalRecord{
-		JournalID:         journalToPersist.GetJournalID(),
-		JournalingTime:    time.Now(),
-		Description:       journalToPersist.GetDescription(),
-		IsReversal:        false,
-		ReversedJournalID: "",
-		TotalAmount:       creditSum,
-		CreatedAt:         time.Now(),
-		CreatedBy:         journalToPersist.GetCreateBy(),
-	}
-
-	if journalToPersist.GetReversedJournal() != nil {
-		journalToInsert.ReversedJournalID = journalToPersist.GetReversedJournal().GetJournalID()
-		journalToInsert.IsReversal = true
-	}
-
-	journalID, err := jm.repo.InsertJournal(ctx, journalToInsert)
-	if err != nil {
-		lLog.Errorf("error inserting new journal %s . got %s. rolling back transaction.", journalToInsert.JournalID, err.Error())
-		err = tx.Rollback()
+	// Every write goes through txRepo, so the journal, its transactions and the account balances
+	// are either all committed or all rolled back.
+	return jm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		// 1. Lock the end of the hash chain, journals are chained one at a time.
+		//    The chain is always locked before the accounts, so it can not take part in a lock cycle.
+		chain, err := txRepo.GetJournalChainForUpdate(ctx)
 		if err != nil {
-			lLog.Errorf("error rolling back transaction. got %s", err.Error())
+			lLog.Errorf("error locking the journal chain in transaction. got %s. rolling back transaction.", err.Error())
+			return err
 		}
-		return err
-	}
 
-	// 2 Save the Transactions
-	for _, trx := range journalToPersist.GetTransactions() {
-		transactionToInsert := &connector.TransactionRecord{
-			TransactionID:   trx.GetTransactionID(),
-			TransactionTime: trx.GetTransactionTime(),
-			AccountNumber:   trx.GetAccountNumber(),
-			JournalID:       journalID,
-			Description:     trx.GetDescription(),
-			//Alignment:     string(trx.GetTransactionType()),
-			Amount:    trx.GetAmount(),
-			Balance:   trx.GetAccountBalance(),
-			CreatedAt: time.Now(),
-			CreatedBy: trx.GetCreateBy(),
+		// 2. Refuse postings into a closed period, a period is closed under the chain lock so it can not be closed meanwhile
+		lastClosed, err := lastClosedPeriod(ctx, txRepo)
+		if err != nil {
+			lLog.Errorf("error reading the last closed period in transaction. got %s. rolling back transaction.", err.Error())
+			return err
 		}
-
-		if trx.GetAlignment() == acccore.DEBIT {
-			transactionToInsert.Alignment = "DEBIT"
-		} else {
-			transactionToInsert.Alignment = "CREDIT"
+		if err := checkPostingTime(lastClosed, earliestPostingTime(journalToPersist)); err != nil {
+			lLog.Errorf("error persisting journal %s. got %s. rolling back transaction.", journalToPersist.GetJournalID(), err.Error())
+			return err
 		}
 
-		account, err := jm.repo.GetAccount(ctx, trx.GetAccountNumber())
-		if err != nil {
-			lLog.Errorf("error retrieving account %s in transaction. got %s. rolling back transaction.", trx.GetAccountNumber(), err.Error())
-			err = tx.Rollback()
+		// 3. Refuse reversals taking back more than is left of the reversed journal, journals are persisted one at
+		//    a time under the chain lock so two partial reversals can not both take back the same amount
+		if journalToPersist.GetReversedJournal() != nil {
+			reversal, err := journalReversal(ctx, txRepo, journalToPersist.GetReversedJournal().GetJournalID())
 			if err != nil {
-				lLog.Errorf("error rolling back transaction. got %s", err.Error())
+				lLog.Errorf("error reading the reversal of journal %s in transaction. got %s. rolling back transaction.", journalToPersist.GetReversedJournal().GetJournalID(), err.Error())
+				return err
+			}
+			if err := reversal.checkReversal(journalToPersist); err != nil {
+				lLog.Errorf("error persisting journal %s. got %s. rolling back transaction.", journalToPersist.GetJournalID(), err.Error())
+				return err
 			}
-			return err
 		}
-		balance, accountTrxType := account.Balance, account.Alignment
 
-		newBalance := int64(0)
-		if transactionToInsert.Alignment == accountTrxType {
-			newBalance = balance + transactionToInsert.Amount
-		} else {
-			newBalance = balance - transactionToInsert.Amount
+		// 4. Save the Journal, its creation time is taken under the chain lock so the chain follows the booking time
+		journalToInsert := &connector.JournalRecord{
+			JournalID:         journalToPersist.GetJournalID(),
+			JournalingTime:    journalToPersist.GetJournalingTime(),
+			Description:       journalToPersist.GetDescription(),
+			IsReversal:        false,
+			ReversedJournalID: "",
+			TotalAmount:       creditSum,
+			CreatedAt:         time.Now(),
+			CreatedBy:         journalToPersist.GetCreateBy(),
+			ChainSequence:     chain.LastSequence + 1,
+			PreviousHash:      chain.LastHash,
+		}
+
+		if journalToPersist.GetReversedJournal() != nil {
+			journalToInsert.ReversedJournalID = journalToPersist.GetReversedJournal().GetJournalID()
+			journalToInsert.IsReversal = true
 		}
-		transactionToInsert.Balance = newBalance
 
-		_, err = jm.repo.InsertTransaction(ctx, transactionToInsert)
+		journalID, err := txRepo.InsertJournal(ctx, journalToInsert)
 		if err != nil {
-			lLog.Errorf("error inserting new transaction %s in transaction. got %s. rolling back transaction.", transactionToInsert.TransactionID, err.Error())
-			err = tx.Rollback()
+			lLog.Errorf("error inserting new journal %s . got %s. rolling back transaction.", journalToInsert.JournalID, err.Error())
+			return err
+		}
+
+		// 5. Lock every account this journal touches before reading its balance, so concurrent postings
+		//    on the same account are serialized instead of overwriting each other's balance.
+		//    Locks are always taken in account number order, two journals sharing accounts therefore
+		//    can never wait on each other in a cycle.
+		accountNumbers := make([]string, 0, len(journalToPersist.GetTransactions()))
+		for _, trx := range journalToPersist.GetTransactions() {
+			accountNumbers = append(accountNumbers, trx.GetAccountNumber())
+		}
+		sort.Strings(accountNumbers)
+		lockedAccounts := make(map[string]*connector.AccountRecord)
+		for _, accountNumber := range accountNumbers {
+			account, err := txRepo.GetAccountForUpdate(ctx, accountNumber)
 			if err != nil {
-				lLog.Errorf("error rolling back transaction. got %s", err.Error())
+				lLog.Errorf("error locking account %s in transaction. got %s. rolling back transaction.", accountNumber, err.Error())
+				return err
 			}
-			return err
+			if account == nil {
+				lLog.Errorf("error account %s disappeared in transaction. rolling back transaction.", accountNumber)
+				return acccore.ErrJournalTransactionAccountNotPersist
+			}
+			// checked under the lock, an account can not be frozen or closed until this journal is committed
+			if err := checkPostingStatus(accountNumber, AccountStatus(account.Status)); err != nil {
+				lLog.Errorf("error persisting journal %s. got %s. rolling back transaction.", journalToPersist.GetJournalID(), err.Error())
+				return err
+			}
+			lockedAccounts[accountNumber] = account
 		}
 
-		// Update Account Balance.
-		// UPDATE ACCOUNT SET BALANCE = {newBalance},  UPDATEDBY = {trx.GetCreateBy()}, UPDATE_TIME = {time.Now()} WHERE ACCOUNT_ID = {trx.GetAccountNumber()}
-		account.Balance = newBalance
-		account.UpdatedAt = time.Now()
-		account.UpdatedBy = trx.GetCreateBy()
-		err = jm.repo.UpdateAccount(ctx, account)
-		if err != nil {
-			lLog.Errorf("error updating account %s in transaction. got %s. rolling back transaction.", account.AccountNumber, err.Error())
-			err = tx.Rollback()
+		// 6. Save the Transactions
+		for _, trx := range journalToPersist.GetTransactions() {
+			transactionToInsert := &connector.TransactionRecord{
+				TransactionID:   trx.GetTransactionID(),
+				TransactionTime: trx.GetTransactionTime(),
+				AccountNumber:   trx.GetAccountNumber(),
+				JournalID:       journalID,
+				Description:     trx.GetDescription(),
+				//Alignment:     string(trx.GetTransactionType()),
+				Amount:    trx.GetAmount(),
+				Balance:   trx.GetAccountBalance(),
+				CreatedAt: time.Now(),
+				CreatedBy: trx.GetCreateBy(),
+			}
+
+			if trx.GetAlignment() == acccore.DEBIT {
+				transactionToInsert.Alignment = "DEBIT"
+			} else {
+				transactionToInsert.Alignment = "CREDIT"
+			}
+
+			account := lockedAccounts[trx.GetAccountNumber()]
+			balance, accountTrxType := account.Balance, account.Alignment
+
+			newBalance := int64(0)
+			if transactionToInsert.Alignment == accountTrxType {
+				newBalance = balance + transactionToInsert.Amount
+			} else {
+				newBalance = balance - transactionToInsert.Amount
+			}
+			transactionToInsert.Balance = newBalance
+			// the holds reserve their amount whether the balance has a minimum or not
+			held, err := txRepo.SumAccountHolds(ctx, account.AccountNumber, time.Now())
+			if err != nil {
+				lLog.Errorf("error summing the holds of account %s in transaction. got %s. rolling back transaction.", account.AccountNumber, err.Error())
+				return err
+			}
+			if err = accountRecordLimits(account).Check(account.AccountNumber, balance, newBalance, held); err != nil {
+				lLog.Errorf("error persisting journal %s. got %s. rolling back transaction.", journalToPersist.GetJournalID(), err.Error())
+				return err
+			}
+
+			_, err = txRepo.InsertTransaction(ctx, transactionToInsert)
+			if err != nil {
+				lLog.Errorf("error inserting new transaction %s in transaction. got %s. rolling back transaction.", transactionToInsert.TransactionID, err.Error())
+				return err
+			}
+
+			// Update Account Balance.
+			// UPDATE ACCOUNT SET BALANCE = {newBalance},  UPDATEDBY = {trx.GetCreateBy()}, UPDATE_TIME = {time.Now()} WHERE ACCOUNT_ID = {trx.GetAccountNumber()}
+			account.Balance = newBalance
+			account.UpdatedAt = time.Now()
+			account.UpdatedBy = trx.GetCreateBy()
+			err = txRepo.UpdateAccount(ctx, account)
 			if err != nil {
-				lLog.Errorf("error rolling back transaction. got %s", err.Error())
+				lLog.Errorf("error updating account %s in transaction. got %s. rolling back transaction.", account.AccountNumber, err.Error())
+				return err
 			}
+		}
+
+		// 7. Hash the journal as it is stored, and make it the end of the chain
+		if err := chainJournal(ctx, txRepo, journalID); err != nil {
+			lLog.Errorf("error chaining journal %s in transaction. got %s. rolling back transaction.", journalID, err.Error())
 			return err
 		}
-	}
+		return nil
+	})
+}
 
-	// COMMIT transaction
-	err = tx.Commit()
+// chainJournal hashes the stored journal and its transactions and moves the end of the hash chain to it.
+// The stored values are hashed, rather than the ones written, as the databases round the times differently.
+func chainJournal(ctx context.Context, txRepo connector.DBRepository, journalID string) error {
+	journal, err := txRepo.GetJournal(ctx, journalID)
 	if err != nil {
-		lLog.Errorf("error committing transaction. got %s", err.Error())
 		return err
 	}
-
-	return nil
+	transactions, err := txRepo.ListTransactionByJournalID(ctx, journalID)
+	if err != nil {
+		return err
+	}
+	journal.ChainHash = journalChainHash(journal, transactions)
+	if err := txRepo.UpdateJournalHash(ctx, journalID, journal.ChainHash); err != nil {
+		return err
+	}
+	return txRepo.UpdateJournalChain(ctx, &connector.JournalChainRecord{LastSequence: journal.ChainSequence, LastHash: journal.ChainHash})
 }
 
 // CommitJournal will commit the journal into the system
@@ -306,19 +427,27 @@ func (jm *MySQLJournalManager) CancelJournal(ctx context.Context, journalToCance
 	return nil
 }
 
-// IsJournalIDReversed check if the journal with specified ID has been reversed
+// IsJournalIDReversed check if the journal with specified ID has been reversed in full, a journal reversed
+// in part still has some amount left to reverse.
 func (jm *MySQLJournalManager) IsJournalIDReversed(ctx context.Context, journalID string) (bool, error) {
 	requestID := ctx.Value(contextkeys.XRequestID).(string)
 	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "IsJournalIdReversed")
 	// SELECT COUNT(*) FROM JOURNAL WHERE REVERSED_JOURNAL_ID = {journalID}
 	// return false if COUNT = 0
-	// return true if COUNT > 0
 	journal, err := jm.repo.GetJournalByReversalID(ctx, journalID)
-	if err != nil || journal == nil {
+	if err != nil {
 		lLog.Errorf("error while calling GetJournalByReversalID. got %s", err.Error())
+		return false, err
+	}
+	if journal == nil {
 		return false, nil
 	}
-	return true, nil
+	reversal, err := journalReversal(ctx, jm.repo, journalID)
+	if err != nil {
+		lLog.Errorf("error while reading the reversal of journal %s. got %s", journalID, err.Error())
+		return false, err
+	}
+	return reversal.FullyReversed(), nil
 }
 
 // IsJournalIDExist will check if a journal ID/number exist in the database.
@@ -387,21 +516,26 @@ func (jm *MySQLJournalManager) GetJournalByID(ctx context.Context, journalID str
 	return ret, nil
 }
 
-// ListJournals retrieve list of journals with transaction date between the `from` and `until` time range inclusive.
+// ListJournals retrieve list of journals with journaling time between the `from` and `until` time range.
 // This function uses pagination.
 func (jm *MySQLJournalManager) ListJournals(ctx context.Context, from time.Time, until time.Time, request acccore.PageRequest) (acccore.PageResult, []acccore.Journal, error) {
+	return jm.listJournals(ctx, connector.EffectiveDate, from, until, request)
+}
+
+// listJournals retrieve list of journals whose date selected by the basis is within the time range.
+func (jm *MySQLJournalManager) listJournals(ctx context.Context, basis connector.DateBasis, from time.Time, until time.Time, request acccore.PageRequest) (acccore.PageResult, []acccore.Journal, error) {
 	requestID := ctx.Value(contextkeys.XRequestID).(string)
 	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "ListJournals")
 
-	count, err := jm.repo.CountJournalByTimeRange(ctx, from, until)
+	count, err := jm.repo.CountJournalByTimeRange(ctx, basis, from, until)
 	if err != nil {
 		lLog.Errorf("error while calling jm.repo.CountJournalByTimeRange. got %s", err.Error())
 		return acccore.PageResult{}, nil, err
 	}
 	pResult := acccore.PageResultFor(request, count)
-	jRecords, err := jm.repo.ListJournal(ctx, "journaling_time", pResult.Offset, pResult.PageSize)
+	jRecords, err := jm.repo.ListJournalByTimeRange(ctx, basis, from, until, pResult.Offset, pResult.PageSize)
 	if err != nil {
-		lLog.Errorf("error while calling jm.repo.ListJournal. got %s", err.Error())
+		lLog.Errorf("error while calling jm.repo.ListJournalByTimeRange. got %s", err.Error())
 		return acccore.PageResult{}, nil, err
 	}
 	ret := make([]acccore.Journal, 0)
@@ -505,16 +639,21 @@ func (am *MySQLTransactionManager) GetTransactionByID(ctx context.Context, id st
 // that transaction happens between the `from` and `until` time range.
 // This function uses pagination
 func (am *MySQLTransactionManager) ListTransactionsOnAccount(ctx context.Context, from time.Time, until time.Time, account acccore.Account, request acccore.PageRequest) (acccore.PageResult, []acccore.Transaction, error) {
+	return am.listTransactionsOnAccount(ctx, connector.EffectiveDate, from, until, account, request)
+}
+
+// listTransactionsOnAccount retrieves list of transactions of the account whose date selected by the basis is within the time range.
+func (am *MySQLTransactionManager) listTransactionsOnAccount(ctx context.Context, basis connector.DateBasis, from time.Time, until time.Time, account acccore.Account, request acccore.PageRequest) (acccore.PageResult, []acccore.Transaction, error) {
 	requestID := ctx.Value(contextkeys.XRequestID).(string)
 	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "ListTransactionsOnAccount")
 
-	count, err := am.repo.CountTransactionByAccountNumber(ctx, account.GetAccountNumber(), from, until)
+	count, err := am.repo.CountTransactionByAccountNumber(ctx, account.GetAccountNumber(), basis, from, until)
 	if err != nil {
 		lLog.Errorf("error while calling am.repo.CountTransactionByAccountNumber. got %s", err.Error())
 		return acccore.PageResult{}, nil, err
 	}
 	pageResult := acccore.PageResultFor(request, count)
-	records, err := am.repo.ListTransactionByAccountNumber(ctx, account.GetAccountNumber(), from, until, pageResult.Offset, pageResult.PageSize)
+	records, err := am.repo.ListTransactionByAccountNumber(ctx, account.GetAccountNumber(), basis, from, until, pageResult.Offset, pageResult.PageSize)
 	if err != nil {
 		lLog.Errorf("error while calling am.repo.ListTransactionByAccountNumber. got %s", err.Error())
 		return acccore.PageResult{}, nil, err
@@ -581,7 +720,8 @@ func NewMySQLAccountManager(repo connector.DBRepository) acccore.AccountManager
 	return &MySQLAccountManager{repo: repo}
 }
 
-// MySQLAccountManager implementation of AccountManager using Account table in MySQL
+// MySQLAccountManager implementation of AccountManager using Account table in MySQL.
+// It also implements AccountLifecycleManager.
 type MySQLAccountManager struct {
 	repo connector.DBRepository
 }
@@ -594,6 +734,23 @@ func (am *MySQLAccountManager) NewAccount(ctx context.Context) acccore.Account {
 // PersistAccount will save the account into database.
 // will throw error if the account already persisted
 func (am *MySQLAccountManager) PersistAccount(ctx context.Context, AccountToPersist acccore.Account) error {
+	return am.persistAccount(ctx, AccountToPersist, DefaultAccountLimits())
+}
+
+// PersistAccountWithLimits persists a new account with its balance limits in the same row insert, so the account
+// never exists without its limits.
+func (am *MySQLAccountManager) PersistAccountWithLimits(ctx context.Context, account acccore.Account, limits *AccountLimits) error {
+	if limits == nil {
+		return am.persistAccount(ctx, account, DefaultAccountLimits())
+	}
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+	return am.persistAccount(ctx, account, limits)
+}
+
+// persistAccount inserts the new account with the specified balance limits.
+func (am *MySQLAccountManager) persistAccount(ctx context.Context, AccountToPersist acccore.Account, limits *AccountLimits) error {
 	requestID := ctx.Value(contextkeys.XRequestID).(string)
 	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "PersistAccount")
 
@@ -632,6 +789,10 @@ func (am *MySQLAccountManager) PersistAccount(ctx context.Context, AccountToPers
 		CreatedBy: AccountToPersist.GetCreateBy(),
 		UpdatedAt: time.Now(),
 		UpdatedBy: AccountToPersist.GetUpdateBy(),
+		// the limits are inserted with the account, it never exists without them
+		AllowNegative: limits.AllowNegative,
+		MinBalance:    limits.MinBalance,
+		MaxBalance:    limits.MaxBalance,
 	}
 	if AccountToPersist.GetAlignment() == acccore.DEBIT {
 		ar.Alignment = "DEBIT"
@@ -662,13 +823,13 @@ func (am *MySQLAccountManager) UpdateAccount(ctx context.Context, AccountToUpdat
 		return acccore.ErrAccountMissingCreator
 	}
 
-	// First make sure that The account have never been created in DB.
-	exist, err := am.IsAccountIDExist(ctx, AccountToUpdate.GetAccountNumber())
+	// First make sure that The account have been created in DB.
+	existing, err := am.repo.GetAccount(ctx, AccountToUpdate.GetAccountNumber())
 	if err != nil {
-		lLog.Errorf("error while calling am.IsAccountIdExist. got %s", err.Error())
+		lLog.Errorf("error while calling am.repo.GetAccount. got %s", err.Error())
 		return err
 	}
-	if !exist {
+	if existing == nil {
 		lLog.Errorf("error account is not persisted")
 		return acccore.ErrAccountIsNotPersisted
 	}
@@ -685,6 +846,11 @@ func (am *MySQLAccountManager) UpdateAccount(ctx context.Context, AccountToUpdat
 		CreatedBy: AccountToUpdate.GetCreateBy(),
 		UpdatedAt: time.Now(),
 		UpdatedBy: AccountToUpdate.GetUpdateBy(),
+		// acccore.Account has neither status nor limits, they only change through the AccountLifecycleManager
+		Status:        existing.Status,
+		AllowNegative: existing.AllowNegative,
+		MinBalance:    existing.MinBalance,
+		MaxBalance:    existing.MaxBalance,
 	}
 	if AccountToUpdate.GetAlignment() == acccore.DEBIT {
 		ar.Alignment = "DEBIT"
@@ -740,6 +906,127 @@ func (am *MySQLAccountManager) GetAccountByID(ctx context.Context, id string) (a
 	return ret, nil
 }
 
+// GetAccountStatus returns the status of the account.
+func (am *MySQLAccountManager) GetAccountStatus(ctx context.Context, accountNumber string) (AccountStatus, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "GetAccountStatus")
+
+	rec, err := am.repo.GetAccount(ctx, accountNumber)
+	if err != nil {
+		lLog.Errorf("error while calling am.repo.GetAccount. got %s", err.Error())
+		return "", err
+	}
+	if rec == nil {
+		return "", acccore.ErrAccountIDNotFound
+	}
+	return AccountStatus(rec.Status), nil
+}
+
+// GetAccountLimits returns the balance limits of the account.
+func (am *MySQLAccountManager) GetAccountLimits(ctx context.Context, accountNumber string) (*AccountLimits, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "GetAccountLimits")
+
+	rec, err := am.repo.GetAccount(ctx, accountNumber)
+	if err != nil {
+		lLog.Errorf("error while calling am.repo.GetAccount. got %s", err.Error())
+		return nil, err
+	}
+	if rec == nil {
+		return nil, acccore.ErrAccountIDNotFound
+	}
+	return accountRecordLimits(rec), nil
+}
+
+// accountRecordLimits returns the balance limits kept in the account record.
+func accountRecordLimits(rec *connector.AccountRecord) *AccountLimits {
+	return &AccountLimits{AllowNegative: rec.AllowNegative, MinBalance: rec.MinBalance, MaxBalance: rec.MaxBalance}
+}
+
+// UpdateAccountDetails changes the name, description, COA or balance limits of the account and returns the updated account.
+// The account row is locked while it is changed, so a concurrent posting never has its balance overwritten.
+func (am *MySQLAccountManager) UpdateAccountDetails(ctx context.Context, accountNumber string, details *AccountDetails, author string) (acccore.Account, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "UpdateAccountDetails")
+
+	if details.Limits != nil {
+		if err := details.Limits.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	err := am.changeAccount(context.WithValue(ctx, contextkeys.UserIDContextKey, author), accountNumber, func(txRepo connector.DBRepository, rec *connector.AccountRecord) error {
+		if AccountStatus(rec.Status) == AccountClosed {
+			return fmt.Errorf("%w : %s", hwerrors.ErrAccountClosed, accountNumber)
+		}
+		if details.Name != nil {
+			rec.Name = *details.Name
+		}
+		if details.Description != nil {
+			rec.Description = *details.Description
+		}
+		if details.COA != nil {
+			rec.Coa = *details.COA
+		}
+		if details.Limits != nil {
+			rec.AllowNegative, rec.MinBalance, rec.MaxBalance = details.Limits.AllowNegative, details.Limits.MinBalance, details.Limits.MaxBalance
+		}
+		return nil
+	})
+	if err != nil {
+		lLog.Errorf("error while updating account %s. got %s", accountNumber, err.Error())
+		return nil, err
+	}
+	return am.GetAccountByID(ctx, accountNumber)
+}
+
+// SetAccountStatus moves the account into the specified status.
+// The account row is locked while it is changed, so an account is never closed while a posting changes its balance
+// or a hold is placed on it.
+func (am *MySQLAccountManager) SetAccountStatus(ctx context.Context, accountNumber string, status AccountStatus, author string) error {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "SetAccountStatus")
+
+	err := am.changeAccount(context.WithValue(ctx, contextkeys.UserIDContextKey, author), accountNumber, func(txRepo connector.DBRepository, rec *connector.AccountRecord) error {
+		held := int64(0)
+		if status == AccountClosed {
+			var err error
+			if held, err = txRepo.SumAccountHolds(ctx, accountNumber, time.Now()); err != nil {
+				return err
+			}
+		}
+		if err := checkStatusTransition(AccountStatus(rec.Status), status, rec.Balance, held); err != nil {
+			return err
+		}
+		rec.Status = string(status)
+		return nil
+	})
+	if err != nil {
+		lLog.Errorf("error while setting account %s status into %s. got %s", accountNumber, status, err.Error())
+	}
+	return err
+}
+
+// changeAccount locks the account, lets change modify it and writes it back, all in one database transaction.
+func (am *MySQLAccountManager) changeAccount(ctx context.Context, accountNumber string, change func(txRepo connector.DBRepository, rec *connector.AccountRecord) error) error {
+	return am.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		rec, err := txRepo.GetAccountForUpdate(ctx, accountNumber)
+		if err != nil {
+			return err
+		}
+		if rec == nil {
+			return acccore.ErrAccountIDNotFound
+		}
+		// the record holds the escaped text, UpdateAccount escapes it again
+		rec.Name = html.UnescapeString(rec.Name)
+		rec.Description = html.UnescapeString(rec.Description)
+		rec.Coa = html.UnescapeString(rec.Coa)
+		if err = change(txRepo, rec); err != nil {
+			return err
+		}
+		return txRepo.UpdateAccount(ctx, rec)
+	})
+}
+
 // ListAccounts list all account in the database.
 // This function uses pagination
 func (am *MySQLAccountManager) ListAccounts(ctx context.Context, request acccore.PageRequest) (acccore.PageResult, []acccore.Account, error) {
@@ -849,14 +1136,22 @@ func (am *MySQLAccountManager) FindAccounts(ctx context.Context, nameLike string
 	return pResult, ret, nil
 }
 
-// NewMySQLExchangeManager new sqlexcnage amanager
-func NewMySQLExchangeManager(repo connector.DBRepository) acccore.ExchangeManager {
-	return &MySQLExchangeManager{repo: repo, commonDenominator: 1.0}
+// NewMySQLExchangeManager new sqlexcnage amanager, exchanged amounts are rounded with the specified rounding mode.
+func NewMySQLExchangeManager(repo connector.DBRepository, rounding RoundingMode) acccore.ExchangeManager {
+	return &MySQLExchangeManager{repo: repo, rounding: rounding, commonDenominator: 1.0}
 }
 
-// MySQLExchangeManager is the manager struct
+// MySQLExchangeManager is the manager struct. It also implements DenominatorManager,
+// the common denominator is kept in the settings table so every instance of the application shares it,
+// and RateManager.
 type MySQLExchangeManager struct {
-	repo              connector.DBRepository
+	repo     connector.DBRepository
+	rounding RoundingMode
+
+	// commonDenominatorMutex guards commonDenominator
+	commonDenominatorMutex sync.Mutex
+	// commonDenominator is the common denominator last read from the database,
+	// used when the database can not be read.
 	commonDenominator float64
 }
 
@@ -880,47 +1175,123 @@ func (am *MySQLExchangeManager) IsCurrencyExist(ctx context.Context, currency st
 
 // GetDenom get the current common denominator used in the exchange
 func (am *MySQLExchangeManager) GetDenom(ctx context.Context) *big.Float {
-	//requestID := ctx.Value(contextkeys.XRequestID).(string)
-	//lLog := dbLog.WithField("RequestID", requestID).WithField("function", "GetDenom")
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "GetDenom")
 
-	return big.NewFloat(am.commonDenominator)
+	am.commonDenominatorMutex.Lock()
+	defer am.commonDenominatorMutex.Unlock()
+	denom, err := am.readDenom(ctx)
+	if err != nil {
+		lLog.Errorf("error while reading the common denominator, using the last known value %f. got %s", am.commonDenominator, err.Error())
+		return big.NewFloat(am.commonDenominator)
+	}
+	am.commonDenominator = denom
+	return big.NewFloat(denom)
 }
 
-// SetDenom set the current common denominator value into the specified value
+// SetDenom set the current common denominator value into the specified value.
+// The change is recorded as made by the user in the context, use PersistDenom to learn whether it is persisted.
 func (am *MySQLExchangeManager) SetDenom(ctx context.Context, denom *big.Float) {
-	//requestID := ctx.Value(contextkeys.XRequestID).(string)
-	//lLog := dbLog.WithField("RequestID", requestID).WithField("function", "SetDenom")
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "SetDenom")
 
-	f, _ := denom.Float64()
-	am.commonDenominator = f
+	author, _ := ctx.Value(contextkeys.UserIDContextKey).(string)
+	err := am.PersistDenom(ctx, denom, author)
+	if err != nil {
+		lLog.Errorf("error while calling am.PersistDenom. got %s", err.Error())
+	}
 }
 
-// GetCurrency retrieve currency data indicated by the code argument
-func (am *MySQLExchangeManager) GetCurrency(ctx context.Context, code string) (acccore.Currency, error) {
+// LoadDenom reads the persisted common denominator, it is called once when the application starts.
+// Throws error if the common denominator can not be read or is not a valid number.
+func (am *MySQLExchangeManager) LoadDenom(ctx context.Context) error {
+	am.commonDenominatorMutex.Lock()
+	defer am.commonDenominatorMutex.Unlock()
+	denom, err := am.readDenom(ctx)
+	if err != nil {
+		return err
+	}
+	am.commonDenominator = denom
+	return nil
+}
+
+// PersistDenom sets the common denominator into the specified value and records who set it.
+func (am *MySQLExchangeManager) PersistDenom(ctx context.Context, denom *big.Float, author string) error {
 	requestID := ctx.Value(contextkeys.XRequestID).(string)
-	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetCurrency")
+	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "PersistDenom")
 
-	rec, err := am.repo.GetCurrency(ctx, code)
+	am.commonDenominatorMutex.Lock()
+	defer am.commonDenominatorMutex.Unlock()
+	err := am.repo.SetSetting(ctx, &connector.SettingRecord{
+		Name:      CommonDenominatorSetting,
+		Value:     denom.Text('g', -1),
+		UpdatedAt: time.Now(),
+		UpdatedBy: author,
+	})
 	if err != nil {
-		llog.Errorf("error while calling am.repo.GetCurrency. got %s", err.Error())
-		return nil, err
+		lLog.Errorf("error while calling am.repo.SetSetting. got %s", err.Error())
+		return err
 	}
+	am.commonDenominator, _ = denom.Float64()
+	return nil
+}
 
-	if rec == nil {
-		return nil, acccore.ErrCurrencyNotFound
+// ListDenomHistory lists the values the common denominator was given in paginated fashion, latest first.
+func (am *MySQLExchangeManager) ListDenomHistory(ctx context.Context, request acccore.PageRequest) (acccore.PageResult, []*DenominatorChange, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "ListDenomHistory")
+
+	count, err := am.repo.CountSettingHistory(ctx, CommonDenominatorSetting)
+	if err != nil {
+		lLog.Errorf("error while calling am.repo.CountSettingHistory. got %s", err.Error())
+		return acccore.PageResult{}, nil, err
+	}
+	pResult := acccore.PageResultFor(request, count)
+	records, err := am.repo.ListSettingHistory(ctx, CommonDenominatorSetting, pResult.Offset, pResult.PageSize)
+	if err != nil {
+		lLog.Errorf("error while calling am.repo.ListSettingHistory. got %s", err.Error())
+		return acccore.PageResult{}, nil, err
+	}
+	ret := make([]*DenominatorChange, 0)
+	for _, rec := range records {
+		denom, err := strconv.ParseFloat(rec.Value, 64)
+		if err != nil {
+			lLog.Errorf("error while parsing common denominator %s set at %s. got %s. skipping", rec.Value, rec.CreatedAt, err.Error())
+			continue
+		}
+		ret = append(ret, &DenominatorChange{Denom: denom, ChangedAt: rec.CreatedAt, ChangedBy: rec.CreatedBy})
 	}
+	return pResult, ret, nil
+}
 
-	ret := &acccore.BaseCurrency{
-		Code:       rec.Code,
-		Name:       rec.Name,
-		Exchange:   rec.Exchange,
-		CreateTime: rec.CreatedAt,
-		CreateBy:   rec.CreatedBy,
-		UpdateTime: rec.UpdatedAt,
-		UpdateBy:   rec.UpdatedBy,
+// readDenom reads the persisted common denominator, which is 1 until it is set.
+func (am *MySQLExchangeManager) readDenom(ctx context.Context) (float64, error) {
+	rec, err := am.repo.GetSetting(ctx, CommonDenominatorSetting)
+	if err != nil {
+		return 0, err
+	}
+	if rec == nil {
+		return 1.0, nil
 	}
+	denom, err := strconv.ParseFloat(rec.Value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("common denominator %s is not a number. got %w", rec.Value, err)
+	}
+	return denom, nil
+}
 
-	return ret, nil
+// GetCurrency retrieve currency data indicated by the code argument.
+// The exchange unit is rounded into float64, use GetCurrencyRate for the exact one.
+func (am *MySQLExchangeManager) GetCurrency(ctx context.Context, code string) (acccore.Currency, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetCurrency")
+
+	cur, err := am.GetCurrencyRate(ctx, code)
+	if err != nil {
+		llog.Errorf("error while calling am.GetCurrencyRate. got %s", err.Error())
+		return nil, err
+	}
+	return baseCurrency(cur), nil
 }
 
 // CreateCurrency set the specified value as denominator value for that speciffic Currency.
@@ -928,11 +1299,16 @@ func (am *MySQLExchangeManager) GetCurrency(ctx context.Context, code string) (a
 func (am *MySQLExchangeManager) CreateCurrency(ctx context.Context, code, name string, exchange *big.Float, author string) (acccore.Currency, error) {
 	requestID := ctx.Value(contextkeys.XRequestID).(string)
 	llog := dbLog.WithField("RequestID", requestID).WithField("function", "CreateCurrency")
-	ex, _ := exchange.Float64()
+
+	rate, err := ParseRate(exchange.Text('g', -1))
+	if err != nil {
+		llog.Errorf("error while parsing exchange %s. got %s", exchange.Text('g', -1), err.Error())
+		return nil, err
+	}
 	rec := &connector.CurrenciesRecord{
 		Code:      code,
 		Name:      name,
-		Exchange:  ex,
+		Exchange:  FormatRat(rate),
 		CreatedAt: time.Now(),
 		CreatedBy: author,
 		UpdatedAt: time.Now(),
@@ -943,15 +1319,9 @@ func (am *MySQLExchangeManager) CreateCurrency(ctx context.Context, code, name s
 		llog.Errorf("error while calling am.repo.InsertCurrency. got %s", err.Error())
 		return nil, err
 	}
-	return &acccore.BaseCurrency{
-		Code:       key,
-		Name:       name,
-		Exchange:   ex,
-		CreateTime: rec.CreatedAt,
-		CreateBy:   rec.CreatedBy,
-		UpdateTime: rec.UpdatedAt,
-		UpdateBy:   rec.UpdatedBy,
-	}, nil
+	rec.Code = key
+	return baseCurrency(&CurrencyRate{Code: rec.Code, Name: rec.Name, Exchange: rate, CreatedAt: rec.CreatedAt,
+		CreatedBy: rec.CreatedBy, UpdatedAt: rec.UpdatedAt, UpdatedBy: rec.UpdatedBy}), nil
 }
 
 // UpdateCurrency updates the currency data
@@ -960,17 +1330,22 @@ func (am *MySQLExchangeManager) UpdateCurrency(ctx context.Context, code string,
 	requestID := ctx.Value(contextkeys.XRequestID).(string)
 	llog := dbLog.WithField("RequestID", requestID).WithField("function", "UpdateCurrency")
 
+	rate, err := ParseRate(strconv.FormatFloat(currency.GetExchange(), 'g', -1, 64))
+	if err != nil {
+		llog.Errorf("error while parsing exchange %f. got %s", currency.GetExchange(), err.Error())
+		return err
+	}
 	rec := &connector.CurrenciesRecord{
 		Code:      code,
 		Name:      currency.GetName(),
-		Exchange:  currency.GetExchange(),
+		Exchange:  FormatRat(rate),
 		CreatedAt: currency.GetCreateTime(),
 		CreatedBy: currency.GetCreateBy(),
 		UpdatedAt: currency.GetUpdateTime(),
 		UpdatedBy: currency.GetUpdateBy(),
 	}
 
-	err := am.repo.UpdateCurrency(ctx, rec)
+	err = am.repo.UpdateCurrency(ctx, rec)
 	if err != nil {
 		llog.Errorf("error while calling am.repo.UpdateCurrency. got %s", err.Error())
 		return err
@@ -985,72 +1360,1737 @@ func (am *MySQLExchangeManager) CalculateExchangeRate(ctx context.Context, fromC
 	requestID := ctx.Value(contextkeys.XRequestID).(string)
 	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "CalculateExchangeRate")
 
-	from, err := am.GetCurrency(ctx, fromCurrency)
-	if err != nil {
-		lLog.Errorf("error while calling am.repo.GetCurrency. got %s", err.Error())
-		return nil, err
-	}
-	if from == nil {
-		lLog.Errorf("error no currency with code %s", fromCurrency)
-		return nil, acccore.ErrCurrencyNotFound
-	}
-
-	to, err := am.GetCurrency(ctx, toCurrency)
+	rate, err := am.CalculateExactRate(ctx, fromCurrency, toCurrency, time.Time{})
 	if err != nil {
-		lLog.Errorf("error while calling am.repo.GetCurrency. got %s", err.Error())
+		lLog.Errorf("error while calling am.CalculateExactRate. got %s", err.Error())
 		return nil, err
 	}
-	if to == nil {
-		lLog.Errorf("error no currency with code %s", toCurrency)
-		return nil, acccore.ErrCurrencyNotFound
-	}
-
-	m1 := new(big.Float).Quo(am.GetDenom(ctx), big.NewFloat(from.GetExchange()))
-	m2 := new(big.Float).Mul(m1, big.NewFloat(to.GetExchange()))
-	m3 := new(big.Float).Quo(m2, am.GetDenom(ctx))
-	return m3, nil
+	return new(big.Float).SetRat(rate), nil
 }
 
 // CalculateExchange gets the currency exchange value for the amount of fromCurrency into toCurrency.
 // If any of the currency is not exist, an error should be returned.
 // if from and to currency is equal, the returned amount must be equal to the amount in the argument.
+// The amount is rounded with the configured rounding mode, use CalculateExactExchange to learn the rounding residue.
 func (am *MySQLExchangeManager) CalculateExchange(ctx context.Context, fromCurrency, toCurrency string, amount int64) (int64, error) {
 	requestID := ctx.Value(contextkeys.XRequestID).(string)
 	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "CalculateExchange")
 
-	exchange, err := am.CalculateExchangeRate(ctx, fromCurrency, toCurrency)
+	result, err := am.CalculateExactExchange(ctx, fromCurrency, toCurrency, amount, "", time.Time{})
 	if err != nil {
-		lLog.Errorf("error while calling am.CalculateExchangeRate. got %s", err.Error())
+		lLog.Errorf("error while calling am.CalculateExactExchange. got %s", err.Error())
 		return 0, err
 	}
-	m1 := new(big.Float).Mul(exchange, big.NewFloat(float64(amount)))
-	f, _ := m1.Float64()
-	return int64(f), nil
+	return result.Amount, nil
 }
 
 // ListCurrencies will list all currencies.
+// The exchange units are rounded into float64, use ListCurrencyRates for the exact ones.
 func (am *MySQLExchangeManager) ListCurrencies(ctx context.Context) ([]acccore.Currency, error) {
 	requestID := ctx.Value(contextkeys.XRequestID).(string)
 	llog := dbLog.WithField("RequestID", requestID).WithField("function", "ListCurrencies")
 
-	records, err := am.repo.ListCurrency(ctx, "code", 0, 1000)
+	curs, err := am.ListCurrencyRates(ctx)
 	if err != nil {
-		llog.Errorf("error while calling am.repo.ListCurrency. got %s", err.Error())
+		llog.Errorf("error while calling am.ListCurrencyRates. got %s", err.Error())
 		return nil, err
 	}
 
 	rets := make([]acccore.Currency, 0)
-	for _, rec := range records {
-		cur := &acccore.BaseCurrency{
-			Code:       rec.Code,
-			Name:       rec.Name,
-			Exchange:   rec.Exchange,
-			CreateTime: rec.CreatedAt,
-			CreateBy:   rec.CreatedBy,
-			UpdateTime: rec.UpdatedAt,
-			UpdateBy:   rec.UpdatedBy,
-		}
-		rets = append(rets, cur)
+	for _, cur := range curs {
+		rets = append(rets, baseCurrency(cur))
 	}
 	return rets, nil
 }
+
+// ListCurrencyRates lists all currencies ordered by their code.
+func (am *MySQLExchangeManager) ListCurrencyRates(ctx context.Context) ([]*CurrencyRate, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "ListCurrencyRates")
+
+	records, err := am.repo.ListCurrency(ctx, "code", 0, 1000)
+	if err != nil {
+		llog.Errorf("error while calling am.repo.ListCurrency. got %s", err.Error())
+		return nil, err
+	}
+
+	rets := make([]*CurrencyRate, 0)
+	for _, rec := range records {
+		cur, err := currencyRate(rec)
+		if err != nil {
+			llog.Errorf("error while reading currency %s. got %s. skipping", rec.Code, err.Error())
+			continue
+		}
+		rets = append(rets, cur)
+	}
+	return rets, nil
+}
+
+// GetCurrencyRate retrieves the currency indicated by the code argument.
+// Throws acccore.ErrCurrencyNotFound if there is no such currency.
+func (am *MySQLExchangeManager) GetCurrencyRate(ctx context.Context, code string) (*CurrencyRate, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetCurrencyRate")
+
+	rec, err := am.repo.GetCurrency(ctx, code)
+	if err != nil {
+		llog.Errorf("error while calling am.repo.GetCurrency. got %s", err.Error())
+		return nil, err
+	}
+	if rec == nil {
+		return nil, acccore.ErrCurrencyNotFound
+	}
+	return currencyRate(rec)
+}
+
+// SetCurrencyRate creates the currency indicated by the code argument, or updates its name and exchange unit if it exists.
+func (am *MySQLExchangeManager) SetCurrencyRate(ctx context.Context, code, name string, exchange *big.Rat, author string) (*CurrencyRate, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "SetCurrencyRate")
+
+	existing, err := am.repo.GetCurrency(ctx, code)
+	if err != nil && !errors.Is(err, acccore.ErrCurrencyNotFound) {
+		llog.Errorf("error while calling am.repo.GetCurrency. got %s", err.Error())
+		return nil, err
+	}
+	now := time.Now()
+	rec := &connector.CurrenciesRecord{
+		Code:      code,
+		Name:      name,
+		Exchange:  FormatRat(exchange),
+		CreatedAt: now,
+		CreatedBy: author,
+		UpdatedAt: now,
+		UpdatedBy: author,
+	}
+	if existing == nil {
+		_, err = am.repo.InsertCurrency(ctx, rec)
+	} else {
+		rec.CreatedAt, rec.CreatedBy = existing.CreatedAt, existing.CreatedBy
+		err = am.repo.UpdateCurrency(ctx, rec)
+	}
+	if err != nil {
+		llog.Errorf("error while saving currency %s. got %s", code, err.Error())
+		return nil, err
+	}
+	return &CurrencyRate{Code: rec.Code, Name: rec.Name, Exchange: new(big.Rat).Set(exchange), CreatedAt: rec.CreatedAt,
+		CreatedBy: rec.CreatedBy, UpdatedAt: rec.UpdatedAt, UpdatedBy: rec.UpdatedBy}, nil
+}
+
+// CalculateExactRate returns the exact rate for exchanging fromCurrency into toCurrency with the exchange units
+// in effect at the specified time, or with the current ones if at is the zero time.
+// Throws acccore.ErrCurrencyNotFound if any of the currency does not exist,
+// or ErrNoEffectiveRate if any of the currency had no exchange unit at that time.
+func (am *MySQLExchangeManager) CalculateExactRate(ctx context.Context, fromCurrency, toCurrency string, at time.Time) (*big.Rat, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	lLog := dbLog.WithField("RequestID", requestID).WithField("function", "CalculateExactRate")
+
+	from, err := am.rateAt(ctx, fromCurrency, at)
+	if err != nil {
+		lLog.Errorf("error while getting currency %s. got %s", fromCurrency, err.Error())
+		return nil, err
+	}
+	to, err := am.rateAt(ctx, toCurrency, at)
+	if err != nil {
+		lLog.Errorf("error while getting currency %s. got %s", toCurrency, err.Error())
+		return nil, err
+	}
+	// (denom / from) * to / denom, the common denominator cancels out
+	return new(big.Rat).Quo(to, from), nil
+}
+
+// CalculateExactExchange exchanges the amount of fromCurrency into toCurrency with the exchange units in effect
+// at the specified time, or with the current ones if at is the zero time. The exchanged amount is rounded
+// with the rounding mode, or with the configured rounding mode if rounding is empty.
+// Throws acccore.ErrCurrencyNotFound if any of the currency does not exist,
+// or ErrNoEffectiveRate if any of the currency had no exchange unit at that time.
+func (am *MySQLExchangeManager) CalculateExactExchange(ctx context.Context, fromCurrency, toCurrency string, amount int64, rounding RoundingMode, at time.Time) (*ExchangeResult, error) {
+	rate, err := am.CalculateExactRate(ctx, fromCurrency, toCurrency, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(rounding) == 0 {
+		rounding = am.rounding
+	}
+	return exchangeAmount(amount, rate, rounding)
+}
+
+// rateAt returns the exchange unit of the currency in effect at the specified time, the current one if at is zero.
+func (am *MySQLExchangeManager) rateAt(ctx context.Context, code string, at time.Time) (*big.Rat, error) {
+	cur, err := am.GetCurrencyRate(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if at.IsZero() {
+		return cur.Exchange, nil
+	}
+	rec, err := am.repo.GetCurrencyRateAt(ctx, code, at)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("%w : currency %s at %s", hwerrors.ErrNoEffectiveRate, code, at.Format(time.RFC3339))
+	}
+	rate, ok := new(big.Rat).SetString(rec.Exchange)
+	if !ok {
+		return nil, fmt.Errorf("%w : currency %s has exchange %s since %s", hwerrors.ErrInvalidExchangeRate, code, rec.Exchange, rec.EffectiveAt.Format(time.RFC3339))
+	}
+	return rate, nil
+}
+
+// currencyRate reads the exact exchange unit of a currency record.
+func currencyRate(rec *connector.CurrenciesRecord) (*CurrencyRate, error) {
+	rate, ok := new(big.Rat).SetString(rec.Exchange)
+	if !ok {
+		return nil, fmt.Errorf("%w : currency %s has exchange %s", hwerrors.ErrInvalidExchangeRate, rec.Code, rec.Exchange)
+	}
+	return &CurrencyRate{Code: rec.Code, Name: rec.Name, Exchange: rate, CreatedAt: rec.CreatedAt,
+		CreatedBy: rec.CreatedBy, UpdatedAt: rec.UpdatedAt, UpdatedBy: rec.UpdatedBy}, nil
+}
+
+// baseCurrency converts a currency into the acccore one, rounding its exchange unit into float64.
+func baseCurrency(cur *CurrencyRate) *acccore.BaseCurrency {
+	exchange, _ := cur.Exchange.Float64()
+	return &acccore.BaseCurrency{
+		Code:       cur.Code,
+		Name:       cur.Name,
+		Exchange:   exchange,
+		CreateTime: cur.CreatedAt,
+		CreateBy:   cur.CreatedBy,
+		UpdateTime: cur.UpdatedAt,
+		UpdateBy:   cur.UpdatedBy,
+	}
+}
+
+// IDEMPOTENCY MANAGER ------------------------------------------------------------------
+
+// NewMySQLIdempotencyManager returns new SQL Idempotency Manager.
+// Records older than the window are considered expired and their key may be used again.
+func NewMySQLIdempotencyManager(repo connector.DBRepository, window time.Duration) IdempotencyManager {
+	return &MySQLIdempotencyManager{repo: repo, window: window}
+}
+
+// MySQLIdempotencyManager implementation of IdempotencyManager using IdempotencyKey table in MySQL
+type MySQLIdempotencyManager struct {
+	repo   connector.DBRepository
+	window time.Duration
+}
+
+// GetIdempotencyRecord retrieves the record of the specified key.
+// It returns nil if the key is never used or it was used longer ago than the idempotency window.
+func (im *MySQLIdempotencyManager) GetIdempotencyRecord(ctx context.Context, key string) (*IdempotencyRecord, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetIdempotencyRecord")
+
+	rec, err := im.repo.GetIdempotencyKey(ctx, key)
+	if err != nil {
+		llog.Errorf("error while calling im.repo.GetIdempotencyKey. got %s", err.Error())
+		return nil, err
+	}
+	if rec == nil || time.Since(rec.CreatedAt) > im.window {
+		return nil, nil
+	}
+	return &IdempotencyRecord{
+		Key:          rec.IdempotencyKey,
+		RequestHash:  rec.RequestHash,
+		JournalID:    rec.JournalID,
+		ResponseBody: []byte(rec.ResponseBody),
+		CreatedAt:    rec.CreatedAt,
+		CreatedBy:    rec.CreatedBy,
+	}, nil
+}
+
+// PersistJournal persists the journal together with its idempotency record in a single database transaction.
+// Throws ErrIdempotencyKeyExists if the key in the record is already used within the idempotency window.
+// The key is claimed before the journal is posted, so of two concurrent requests using the same key the second
+// waits on the key's primary key until the first finishes, then fails with ErrIdempotencyKeyExists without posting.
+func (im *MySQLIdempotencyManager) PersistJournal(ctx context.Context, journal acccore.Journal, record *IdempotencyRecord) error {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "PersistJournal")
+
+	return im.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		existing, err := txRepo.GetIdempotencyKey(ctx, record.Key)
+		if err != nil {
+			llog.Errorf("error while calling txRepo.GetIdempotencyKey. got %s", err.Error())
+			return err
+		}
+		if existing != nil {
+			if time.Since(existing.CreatedAt) <= im.window {
+				return hwerrors.ErrIdempotencyKeyExists
+			}
+			// only expired records are deleted, a concurrent request that re-used the key meanwhile keeps its record.
+			_, err = txRepo.DeleteIdempotencyKeysBefore(ctx, time.Now().Add(-im.window))
+			if err != nil {
+				llog.Errorf("error while calling txRepo.DeleteIdempotencyKeysBefore. got %s", err.Error())
+				return err
+			}
+		}
+
+		_, err = txRepo.InsertIdempotencyKey(ctx, &connector.IdempotencyKeyRecord{
+			IdempotencyKey: record.Key,
+			RequestHash:    record.RequestHash,
+			JournalID:      record.JournalID,
+			ResponseBody:   string(record.ResponseBody),
+			CreatedAt:      record.CreatedAt,
+			CreatedBy:      record.CreatedBy,
+		})
+		if err != nil {
+			if !errors.Is(err, hwerrors.ErrIdempotencyKeyExists) {
+				llog.Errorf("error while calling txRepo.InsertIdempotencyKey. got %s", err.Error())
+			}
+			return err
+		}
+
+		return NewMySQLJournalManager(txRepo).PersistJournal(ctx, journal)
+	})
+}
+
+// PurgeExpired permanently deletes the records that were created longer ago than the idempotency window.
+// It returns the number of records deleted.
+func (im *MySQLIdempotencyManager) PurgeExpired(ctx context.Context) (int64, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "PurgeExpired")
+
+	deleted, err := im.repo.DeleteIdempotencyKeysBefore(ctx, time.Now().Add(-im.window))
+	if err != nil {
+		llog.Errorf("error while calling im.repo.DeleteIdempotencyKeysBefore. got %s", err.Error())
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// HOLD MANAGER ------------------------------------------------------------------
+
+// NewMySQLHoldManager returns new SQL Hold Manager. Captured holds are posted with the sql journal manager,
+// holds placed without expiry expire after defaultExpiry.
+func NewMySQLHoldManager(repo connector.DBRepository, idGenerator acccore.UniqueIDGenerator, defaultExpiry time.Duration) HoldManager {
+	return &MySQLHoldManager{repo: repo, idGenerator: idGenerator, defaultExpiry: defaultExpiry}
+}
+
+// MySQLHoldManager implementation of HoldManager using AccountHolds table in MySQL
+type MySQLHoldManager struct {
+	repo          connector.DBRepository
+	idGenerator   acccore.UniqueIDGenerator
+	defaultExpiry time.Duration
+}
+
+// holdFromRecord returns the hold kept in the hold record.
+func holdFromRecord(rec *connector.HoldRecord) *Hold {
+	return &Hold{
+		HoldID:         rec.HoldID,
+		AccountNumber:  rec.AccountNumber,
+		Amount:         rec.Amount,
+		Description:    rec.Description,
+		Status:         HoldStatus(rec.Status),
+		ExpiresAt:      rec.ExpiresAt,
+		CapturedAmount: rec.CapturedAmount,
+		JournalID:      rec.JournalID,
+		CreatedAt:      rec.CreatedAt,
+		CreatedBy:      rec.CreatedBy,
+		UpdatedAt:      rec.UpdatedAt,
+		UpdatedBy:      rec.UpdatedBy,
+	}
+}
+
+// PlaceHold reserves the amount on the account until expiresAt, or until the default hold expiry if expiresAt is zero.
+// The account row is locked while the hold is placed, so a concurrent posting can not spend the amount meanwhile.
+func (hm *MySQLHoldManager) PlaceHold(ctx context.Context, accountNumber string, amount int64, description string, expiresAt time.Time, author string) (*Hold, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "PlaceHold")
+
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(hm.defaultExpiry)
+	}
+	if err := checkHoldAmount(amount, expiresAt); err != nil {
+		return nil, err
+	}
+	rec := &connector.HoldRecord{
+		HoldID:        hm.idGenerator.NewUniqueID(),
+		AccountNumber: accountNumber,
+		Amount:        amount,
+		Description:   description,
+		Status:        string(HoldHeld),
+		ExpiresAt:     expiresAt,
+		CreatedAt:     time.Now(),
+		CreatedBy:     author,
+	}
+	err := hm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		account, err := txRepo.GetAccountForUpdate(ctx, accountNumber)
+		if err != nil {
+			return err
+		}
+		if account == nil {
+			return acccore.ErrAccountIDNotFound
+		}
+		if err = checkPostingStatus(accountNumber, AccountStatus(account.Status)); err != nil {
+			return err
+		}
+		held, err := txRepo.SumAccountHolds(ctx, accountNumber, time.Now())
+		if err != nil {
+			return err
+		}
+		if err = accountRecordLimits(account).CheckHold(accountNumber, account.Balance, held, amount); err != nil {
+			return err
+		}
+		_, err = txRepo.InsertHold(ctx, rec)
+		return err
+	})
+	if err != nil {
+		llog.Errorf("error while placing hold on account %s. got %s", accountNumber, err.Error())
+		return nil, err
+	}
+	return hm.GetHold(ctx, rec.HoldID)
+}
+
+// GetHold returns the hold. A HELD hold past its expiry is returned EXPIRED.
+func (hm *MySQLHoldManager) GetHold(ctx context.Context, holdID string) (*Hold, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetHold")
+
+	rec, err := hm.repo.GetHold(ctx, holdID)
+	if err != nil {
+		llog.Errorf("error while calling hm.repo.GetHold. got %s", err.Error())
+		return nil, err
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("%w : %s", hwerrors.ErrHoldNotFound, holdID)
+	}
+	return holdFromRecord(rec).current(), nil
+}
+
+// CaptureHold posts a journal taking the amount, or the whole hold if amount is 0, from the held account into
+// the counter account. The rest of the hold is released.
+// The hold is captured and the journal posted in a single database transaction, a refused journal leaves the hold held.
+func (hm *MySQLHoldManager) CaptureHold(ctx context.Context, holdID, counterAccount string, amount int64, description, author string) (*Hold, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "CaptureHold")
+
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, author)
+	err := hm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		rec, err := txRepo.GetHold(ctx, holdID)
+		if err != nil {
+			return err
+		}
+		if rec == nil {
+			return fmt.Errorf("%w : %s", hwerrors.ErrHoldNotFound, holdID)
+		}
+		hold := holdFromRecord(rec)
+		if err = hold.checkHeld(); err != nil {
+			return err
+		}
+		amount, err = captureAmount(hold, counterAccount, amount)
+		if err != nil {
+			return err
+		}
+		account, err := txRepo.GetAccount(ctx, hold.AccountNumber)
+		if err != nil {
+			return err
+		}
+		if account == nil {
+			return acccore.ErrAccountIDNotFound
+		}
+		alignment := acccore.DEBIT
+		if strings.ToUpper(account.Alignment) != "DEBIT" {
+			alignment = acccore.CREDIT
+		}
+		journal := newCaptureJournal(hm.idGenerator, hold, alignment, counterAccount, amount, description, author)
+
+		// the hold stops reserving its amount before the journal is posted, the posting may spend it.
+		rec.Status, rec.CapturedAmount, rec.JournalID = string(HoldCaptured), amount, journal.GetJournalID()
+		rec.UpdatedAt, rec.UpdatedBy = time.Now(), author
+		updated, err := txRepo.UpdateHoldStatus(ctx, rec, string(HoldHeld))
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return fmt.Errorf("%w : hold %s was changed meanwhile", hwerrors.ErrHoldNotHeld, holdID)
+		}
+		return NewMySQLJournalManager(txRepo).PersistJournal(ctx, journal)
+	})
+	if err != nil {
+		llog.Errorf("error while capturing hold %s. got %s", holdID, err.Error())
+		return nil, err
+	}
+	return hm.GetHold(ctx, holdID)
+}
+
+// ReleaseHold gives the held amount back to the account without posting.
+func (hm *MySQLHoldManager) ReleaseHold(ctx context.Context, holdID, author string) (*Hold, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "ReleaseHold")
+
+	hold, err := hm.GetHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if err = hold.checkHeld(); err != nil {
+		return nil, err
+	}
+	updated, err := hm.repo.UpdateHoldStatus(ctx, &connector.HoldRecord{
+		HoldID:    holdID,
+		Status:    string(HoldReleased),
+		UpdatedAt: time.Now(),
+		UpdatedBy: author,
+	}, string(HoldHeld))
+	if err != nil {
+		llog.Errorf("error while calling hm.repo.UpdateHoldStatus. got %s", err.Error())
+		return nil, err
+	}
+	if !updated {
+		return nil, fmt.Errorf("%w : hold %s was changed meanwhile", hwerrors.ErrHoldNotHeld, holdID)
+	}
+	return hm.GetHold(ctx, holdID)
+}
+
+// GetHeldAmount returns the total amount the holds of the account reserve.
+func (hm *MySQLHoldManager) GetHeldAmount(ctx context.Context, accountNumber string) (int64, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetHeldAmount")
+
+	held, err := hm.repo.SumAccountHolds(ctx, accountNumber, time.Now())
+	if err != nil {
+		llog.Errorf("error while calling hm.repo.SumAccountHolds. got %s", err.Error())
+		return 0, err
+	}
+	return held, nil
+}
+
+// ExpireHolds moves the HELD holds past their expiry into EXPIRED.
+// It returns the number of holds expired.
+func (hm *MySQLHoldManager) ExpireHolds(ctx context.Context) (int64, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "ExpireHolds")
+
+	expired, err := hm.repo.ExpireHolds(ctx, time.Now(), holdExpiryAuthor)
+	if err != nil {
+		llog.Errorf("error while calling hm.repo.ExpireHolds. got %s", err.Error())
+		return 0, err
+	}
+	return expired, nil
+}
+
+// COA MANAGER ------------------------------------------------------------------
+
+// NewMySQLCOAManager returns new SQL COA Manager.
+func NewMySQLCOAManager(repo connector.DBRepository) COAManager {
+	return &MySQLCOAManager{repo: repo}
+}
+
+// MySQLCOAManager implementation of COAManager using ChartOfAccounts table in MySQL
+type MySQLCOAManager struct {
+	repo connector.DBRepository
+}
+
+// coaFromRecord returns the chart of accounts node kept in the coa record.
+func coaFromRecord(rec *connector.CoaRecord) *COA {
+	coa := &COA{
+		Code:       rec.Code,
+		ParentCode: rec.ParentCode,
+		Name:       html.UnescapeString(rec.Name),
+		Type:       COAType(rec.Type),
+		Alignment:  acccore.CREDIT,
+		CreatedAt:  rec.CreatedAt,
+		CreatedBy:  rec.CreatedBy,
+		UpdatedAt:  rec.UpdatedAt,
+		UpdatedBy:  rec.UpdatedBy,
+	}
+	if rec.Alignment == "DEBIT" {
+		coa.Alignment = acccore.DEBIT
+	}
+	return coa
+}
+
+// listCOA returns the whole chart of accounts sorted by code, together with the nodes by their code.
+func listCOA(ctx context.Context, repo connector.DBRepository) ([]*COA, map[string]*COA, error) {
+	recs, err := repo.ListCoa(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	nodes := make([]*COA, 0, len(recs))
+	byCode := make(map[string]*COA, len(recs))
+	for _, rec := range recs {
+		node := coaFromRecord(rec)
+		nodes = append(nodes, node)
+		byCode[node.Code] = node
+	}
+	return nodes, byCode, nil
+}
+
+// CreateCOA creates the node and returns it.
+func (cm *MySQLCOAManager) CreateCOA(ctx context.Context, coa *COA, author string) (*COA, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "CreateCOA")
+
+	if err := coa.validate(); err != nil {
+		return nil, err
+	}
+	err := cm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		_, nodes, err := listCOA(ctx, txRepo)
+		if err != nil {
+			return err
+		}
+		if _, ok := nodes[coa.Code]; ok {
+			return fmt.Errorf("%w : %s", hwerrors.ErrCOAExists, coa.Code)
+		}
+		if err = checkCOAParent(coa.Code, coa.ParentCode, coa.Type, nodes); err != nil {
+			return err
+		}
+		_, err = txRepo.InsertCoa(ctx, &connector.CoaRecord{
+			Code:       coa.Code,
+			ParentCode: coa.ParentCode,
+			Name:       coa.Name,
+			Type:       string(coa.Type),
+			Alignment:  alignmentName(coa.Alignment),
+			CreatedAt:  time.Now(),
+			CreatedBy:  author,
+		})
+		return err
+	})
+	if err != nil {
+		llog.Errorf("error while creating coa %s. got %s", coa.Code, err.Error())
+		return nil, err
+	}
+	return cm.GetCOA(ctx, coa.Code)
+}
+
+// GetCOA returns the node.
+func (cm *MySQLCOAManager) GetCOA(ctx context.Context, code string) (*COA, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetCOA")
+
+	rec, err := cm.repo.GetCoa(ctx, code)
+	if err != nil {
+		llog.Errorf("error while calling cm.repo.GetCoa. got %s", err.Error())
+		return nil, err
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("%w : %s", hwerrors.ErrCOANotFound, code)
+	}
+	return coaFromRecord(rec), nil
+}
+
+// ListCOA returns the whole chart of accounts sorted by code.
+func (cm *MySQLCOAManager) ListCOA(ctx context.Context) ([]*COA, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "ListCOA")
+
+	nodes, _, err := listCOA(ctx, cm.repo)
+	if err != nil {
+		llog.Errorf("error while calling cm.repo.ListCoa. got %s", err.Error())
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// UpdateCOA changes the name or parent of the node and returns the updated node.
+func (cm *MySQLCOAManager) UpdateCOA(ctx context.Context, code string, details *COADetails, author string) (*COA, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "UpdateCOA")
+
+	err := cm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		_, nodes, err := listCOA(ctx, txRepo)
+		if err != nil {
+			return err
+		}
+		node, ok := nodes[code]
+		if !ok {
+			return fmt.Errorf("%w : %s", hwerrors.ErrCOANotFound, code)
+		}
+		if details.Name != nil {
+			node.Name = *details.Name
+		}
+		if details.ParentCode != nil {
+			node.ParentCode = *details.ParentCode
+		}
+		if err = node.validate(); err != nil {
+			return err
+		}
+		if err = checkCOAParent(node.Code, node.ParentCode, node.Type, nodes); err != nil {
+			return err
+		}
+		return txRepo.UpdateCoa(ctx, &connector.CoaRecord{
+			Code:       node.Code,
+			ParentCode: node.ParentCode,
+			Name:       node.Name,
+			UpdatedAt:  time.Now(),
+			UpdatedBy:  author,
+		})
+	})
+	if err != nil {
+		llog.Errorf("error while updating coa %s. got %s", code, err.Error())
+		return nil, err
+	}
+	return cm.GetCOA(ctx, code)
+}
+
+// DeleteCOA deletes the node.
+func (cm *MySQLCOAManager) DeleteCOA(ctx context.Context, code string) error {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "DeleteCOA")
+
+	err := cm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		nodes, byCode, err := listCOA(ctx, txRepo)
+		if err != nil {
+			return err
+		}
+		if _, ok := byCode[code]; !ok {
+			return fmt.Errorf("%w : %s", hwerrors.ErrCOANotFound, code)
+		}
+		if subtree := coaSubtree(code, nodes); len(subtree) > 1 {
+			return fmt.Errorf("%w : %s has child node %s", hwerrors.ErrCOAInUse, code, subtree[1])
+		}
+		accounts, err := txRepo.CountAccountByCoa(ctx, code)
+		if err != nil {
+			return err
+		}
+		if accounts > 0 {
+			return fmt.Errorf("%w : %s has %d accounts", hwerrors.ErrCOAInUse, code, accounts)
+		}
+		return txRepo.DeleteCoa(ctx, code)
+	})
+	if err != nil {
+		llog.Errorf("error while deleting coa %s. got %s", code, err.Error())
+	}
+	return err
+}
+
+// CheckAccountCOA makes sure an account with the alignment may be under the node.
+func (cm *MySQLCOAManager) CheckAccountCOA(ctx context.Context, code string, alignment acccore.Alignment) error {
+	coa, err := cm.GetCOA(ctx, code)
+	if err != nil {
+		return err
+	}
+	return checkAccountCOA(coa, alignment)
+}
+
+// GetCOABalance returns the aggregated balance of the accounts under the node and all its descendants, per currency.
+func (cm *MySQLCOAManager) GetCOABalance(ctx context.Context, code string) ([]*COABalance, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetCOABalance")
+
+	nodes, byCode, err := listCOA(ctx, cm.repo)
+	if err != nil {
+		llog.Errorf("error while calling cm.repo.ListCoa. got %s", err.Error())
+		return nil, err
+	}
+	node, ok := byCode[code]
+	if !ok {
+		return nil, fmt.Errorf("%w : %s", hwerrors.ErrCOANotFound, code)
+	}
+	sums, err := cm.repo.SumAccountBalanceByCoa(ctx, coaSubtree(code, nodes))
+	if err != nil {
+		llog.Errorf("error while calling cm.repo.SumAccountBalanceByCoa. got %s", err.Error())
+		return nil, err
+	}
+	balances := newCOABalances(node.Alignment)
+	for _, sum := range sums {
+		alignment := acccore.CREDIT
+		if sum.Alignment == "DEBIT" {
+			alignment = acccore.DEBIT
+		}
+		balances.add(sum.CurrencyCode, alignment, sum.Balance, sum.Accounts)
+	}
+	return balances.list(), nil
+}
+
+// REPORT MANAGER ------------------------------------------------------------------
+
+// NewMySQLReportManager returns new SQL Report Manager, the amounts are exchanged with the exchange units of the rate manager.
+func NewMySQLReportManager(repo connector.DBRepository, rateManager RateManager) ReportManager {
+	return &MySQLReportManager{repo: repo, rateManager: rateManager}
+}
+
+// MySQLReportManager implementation of ReportManager summing the Transactions table in MySQL
+type MySQLReportManager struct {
+	repo        connector.DBRepository
+	rateManager RateManager
+}
+
+// GetTrialBalance returns the total debit and credit of the transactions of every account made at or before the specified time.
+func (rm *MySQLReportManager) GetTrialBalance(ctx context.Context, at time.Time, currency string) (*TrialBalance, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetTrialBalance")
+
+	recs, err := rm.repo.ListAccountTotals(ctx, at, currency)
+	if err != nil {
+		llog.Errorf("error while calling rm.repo.ListAccountTotals. got %s", err.Error())
+		return nil, err
+	}
+	_, nodes, err := listCOA(ctx, rm.repo)
+	if err != nil {
+		llog.Errorf("error while calling rm.repo.ListCoa. got %s", err.Error())
+		return nil, err
+	}
+	totals := make([]*TrialBalanceAccount, 0, len(recs))
+	for _, rec := range recs {
+		total := &TrialBalanceAccount{
+			AccountNumber: rec.AccountNumber,
+			Name:          html.UnescapeString(rec.Name),
+			COA:           html.UnescapeString(rec.Coa),
+			Currency:      rec.CurrencyCode,
+			Alignment:     acccore.CREDIT,
+			Debit:         rec.Debit,
+			Credit:        rec.Credit,
+		}
+		if rec.Alignment == "DEBIT" {
+			total.Alignment = acccore.DEBIT
+		}
+		totals = append(totals, total)
+	}
+	return newTrialBalance(at, currency, totals, nodes), nil
+}
+
+// GetBalanceSheet returns the balance sheet of the transactions made at or before the specified time.
+func (rm *MySQLReportManager) GetBalanceSheet(ctx context.Context, at time.Time, currency, reportingCurrency string) (*BalanceSheet, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetBalanceSheet")
+
+	tb, err := rm.GetTrialBalance(ctx, at, currency)
+	if err != nil {
+		return nil, err
+	}
+	coas, _, err := listCOA(ctx, rm.repo)
+	if err != nil {
+		llog.Errorf("error while calling rm.repo.ListCoa. got %s", err.Error())
+		return nil, err
+	}
+	bs, err := newBalanceSheet(ctx, tb, coas, reportingCurrency, rm.rateManager)
+	if err != nil {
+		llog.Errorf("error while exchanging the balance sheet into %s. got %s", reportingCurrency, err.Error())
+		return nil, err
+	}
+	return bs, nil
+}
+
+// GetIncomeStatement returns the income statement of the transactions made after from until the until time.
+func (rm *MySQLReportManager) GetIncomeStatement(ctx context.Context, from, until time.Time, currency, reportingCurrency string) (*IncomeStatement, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetIncomeStatement")
+
+	fromTB, err := rm.GetTrialBalance(ctx, from, currency)
+	if err != nil {
+		return nil, err
+	}
+	untilTB, err := rm.GetTrialBalance(ctx, until, currency)
+	if err != nil {
+		return nil, err
+	}
+	coas, _, err := listCOA(ctx, rm.repo)
+	if err != nil {
+		llog.Errorf("error while calling rm.repo.ListCoa. got %s", err.Error())
+		return nil, err
+	}
+	is, err := newIncomeStatement(ctx, fromTB, untilTB, coas, reportingCurrency, rm.rateManager)
+	if err != nil {
+		llog.Errorf("error while exchanging the income statement into %s. got %s", reportingCurrency, err.Error())
+		return nil, err
+	}
+	return is, nil
+}
+
+// INTEGRITY MANAGER ------------------------------------------------------------------
+
+// NewMySQLIntegrityManager returns new SQL Integrity Manager
+func NewMySQLIntegrityManager(repo connector.DBRepository) IntegrityManager {
+	return &MySQLIntegrityManager{repo: repo}
+}
+
+// MySQLIntegrityManager implementation of IntegrityManager replaying the Transactions table in MySQL
+type MySQLIntegrityManager struct {
+	repo connector.DBRepository
+}
+
+// toIntegrityTransaction converts a transaction record for the replay.
+func toIntegrityTransaction(rec *connector.TransactionRecord) *integrityTransaction {
+	trx := &integrityTransaction{
+		TransactionID:   rec.TransactionID,
+		AccountNumber:   rec.AccountNumber,
+		JournalID:       rec.JournalID,
+		TransactionTime: rec.TransactionTime,
+		CreatedAt:       rec.CreatedAt,
+		Alignment:       acccore.CREDIT,
+		Amount:          rec.Amount,
+		Balance:         rec.Balance,
+	}
+	if rec.Alignment == "DEBIT" {
+		trx.Alignment = acccore.DEBIT
+	}
+	return trx
+}
+
+// VerifyIntegrity replays the transactions of every account in order and reports every discrepancy found.
+// Each account is locked while its transactions are read, a journal posted meanwhile can not show up as a mismatch.
+func (vm *MySQLIntegrityManager) VerifyIntegrity(ctx context.Context) (*IntegrityReport, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "VerifyIntegrity")
+
+	report := &IntegrityReport{CheckedAt: time.Now(), Discrepancies: make([]*Discrepancy, 0)}
+	orphans, err := vm.repo.ListOrphanTransactions(ctx)
+	if err != nil {
+		llog.Errorf("error while calling vm.repo.ListOrphanTransactions. got %s", err.Error())
+		return nil, err
+	}
+	for _, rec := range orphans {
+		account, err := vm.repo.GetAccount(ctx, rec.AccountNumber)
+		if err != nil {
+			llog.Errorf("error while calling vm.repo.GetAccount. got %s", err.Error())
+			return nil, err
+		}
+		if account == nil {
+			report.addOrphanTransaction(toIntegrityTransaction(rec), "account "+rec.AccountNumber)
+		} else {
+			report.addOrphanTransaction(toIntegrityTransaction(rec), "journal "+rec.JournalID)
+		}
+	}
+
+	for offset := 0; ; offset += 100 {
+		accounts, err := vm.repo.ListAccount(ctx, "account_number", offset, 100)
+		if err != nil {
+			llog.Errorf("error while calling vm.repo.ListAccount. got %s", err.Error())
+			return nil, err
+		}
+		if len(accounts) == 0 {
+			break
+		}
+		for _, listed := range accounts {
+			err = vm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+				rec, err := txRepo.GetAccountForUpdate(ctx, listed.AccountNumber)
+				if err != nil || rec == nil {
+					return err
+				}
+				recs, err := txRepo.ListAccountTransactions(ctx, rec.AccountNumber)
+				if err != nil {
+					return err
+				}
+				account := &integrityAccount{AccountNumber: rec.AccountNumber, Currency: rec.CurrencyCode, Alignment: acccore.CREDIT, Balance: rec.Balance}
+				if rec.Alignment == "DEBIT" {
+					account.Alignment = acccore.DEBIT
+				}
+				transactions := make([]*integrityTransaction, 0, len(recs))
+				for _, trx := range recs {
+					transactions = append(transactions, toIntegrityTransaction(trx))
+				}
+				report.replayAccount(account, transactions)
+				return nil
+			})
+			if err != nil {
+				llog.Errorf("error while replaying account %s. got %s", listed.AccountNumber, err.Error())
+				return nil, err
+			}
+		}
+	}
+
+	journals, err := vm.repo.ListUnbalancedJournalTotals(ctx)
+	if err != nil {
+		llog.Errorf("error while calling vm.repo.ListUnbalancedJournalTotals. got %s", err.Error())
+		return nil, err
+	}
+	for _, rec := range journals {
+		report.addUnbalancedJournal(rec.JournalID, rec.CurrencyCode, rec.Debit, rec.Credit)
+	}
+	return report, nil
+}
+
+// JOURNAL CHAIN MANAGER ------------------------------------------------------------------
+
+// NewMySQLJournalChainManager returns new SQL Journal Chain Manager
+func NewMySQLJournalChainManager(repo connector.DBRepository) JournalChainManager {
+	return &MySQLJournalChainManager{repo: repo}
+}
+
+// MySQLJournalChainManager implementation of JournalChainManager verifying the hashes stored in the Journals table in MySQL
+type MySQLJournalChainManager struct {
+	repo connector.DBRepository
+}
+
+// VerifyJournalChain recomputes the hash of every journal chained within the period, in chain order,
+// and returns the first broken link found.
+func (cm *MySQLJournalChainManager) VerifyJournalChain(ctx context.Context, from, until time.Time) (*ChainVerification, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "VerifyJournalChain")
+
+	verification := &ChainVerification{From: from, Until: until}
+	first, last, err := cm.repo.GetJournalChainRange(ctx, from, until)
+	if err != nil {
+		llog.Errorf("error while calling cm.repo.GetJournalChainRange. got %s", err.Error())
+		return nil, err
+	}
+	if first == 0 {
+		return verification, nil
+	}
+	verification.FirstSequence, verification.LastSequence = first, last
+
+	// the first journal of the period links to the journal before it
+	previousHash := ""
+	if first > 1 {
+		previous, err := cm.repo.ListJournalBySequence(ctx, first-1, first-1)
+		if err != nil {
+			llog.Errorf("error while calling cm.repo.ListJournalBySequence. got %s", err.Error())
+			return nil, err
+		}
+		if len(previous) == 0 {
+			verification.BrokenLink = &BrokenChainLink{Sequence: first - 1, Reason: ChainBreakMissingJournal}
+			return verification, nil
+		}
+		previousHash = previous[0].ChainHash
+	}
+
+	sequence := first
+	for sequence <= last {
+		journals, err := cm.repo.ListJournalBySequence(ctx, sequence, sequence+99)
+		if err != nil {
+			llog.Errorf("error while calling cm.repo.ListJournalBySequence. got %s", err.Error())
+			return nil, err
+		}
+		for _, journal := range journals {
+			if sequence > last {
+				break
+			}
+			if journal.ChainSequence != sequence {
+				verification.BrokenLink = &BrokenChainLink{Sequence: sequence, Reason: ChainBreakMissingJournal}
+				return verification, nil
+			}
+			if journal.PreviousHash != previousHash {
+				verification.BrokenLink = &BrokenChainLink{Sequence: sequence, JournalID: journal.JournalID,
+					Reason: ChainBreakPreviousHashMismatch, Expected: previousHash, Actual: journal.PreviousHash}
+				return verification, nil
+			}
+			transactions, err := cm.repo.ListTransactionByJournalID(ctx, journal.JournalID)
+			if err != nil {
+				llog.Errorf("error while calling cm.repo.ListTransactionByJournalID. got %s", err.Error())
+				return nil, err
+			}
+			if hash := journalChainHash(journal, transactions); hash != journal.ChainHash {
+				verification.BrokenLink = &BrokenChainLink{Sequence: sequence, JournalID: journal.JournalID,
+					Reason: ChainBreakHashMismatch, Expected: hash, Actual: journal.ChainHash}
+				return verification, nil
+			}
+			previousHash = journal.ChainHash
+			verification.Journals++
+			sequence++
+		}
+		if len(journals) == 0 && sequence <= last {
+			verification.BrokenLink = &BrokenChainLink{Sequence: sequence, Reason: ChainBreakMissingJournal}
+			return verification, nil
+		}
+	}
+	return verification, nil
+}
+
+// PERIOD MANAGER ------------------------------------------------------------------
+
+// NewMySQLPeriodManager returns new SQL Period Manager. The closing journals are posted with the sql journal manager
+// against the retained earnings account of each currency.
+func NewMySQLPeriodManager(repo connector.DBRepository, idGenerator acccore.UniqueIDGenerator, retainedEarnings map[string]string) PeriodManager {
+	return &MySQLPeriodManager{repo: repo, idGenerator: idGenerator, retainedEarnings: retainedEarnings}
+}
+
+// MySQLPeriodManager implementation of PeriodManager using AccountingPeriods table in MySQL
+type MySQLPeriodManager struct {
+	repo             connector.DBRepository
+	idGenerator      acccore.UniqueIDGenerator
+	retainedEarnings map[string]string
+}
+
+// periodFromRecord returns the closed period kept in the period record.
+func periodFromRecord(rec *connector.PeriodRecord) (*Period, error) {
+	period, err := parsePeriod(rec.Period)
+	if err != nil {
+		return nil, err
+	}
+	period.Status, period.ClosedAt, period.ClosedBy = PeriodClosed, rec.ClosedAt, rec.ClosedBy
+	if len(rec.ClosingJournalIDs) > 0 {
+		period.ClosingJournalIDs = strings.Split(rec.ClosingJournalIDs, ",")
+	}
+	return period, nil
+}
+
+// lastClosedPeriod returns the last closed period, or an empty string if no period is closed.
+func lastClosedPeriod(ctx context.Context, repo connector.DBRepository) (string, error) {
+	rec, err := repo.GetLastClosedPeriod(ctx)
+	if err != nil || rec == nil {
+		return "", err
+	}
+	return rec.Period, nil
+}
+
+// GetPeriod returns the accounting period, CLOSED if it is the last closed period or before it.
+func (pm *MySQLPeriodManager) GetPeriod(ctx context.Context, period string) (*Period, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetPeriod")
+
+	ret, err := parsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := pm.repo.GetPeriod(ctx, period)
+	if err != nil {
+		llog.Errorf("error while calling pm.repo.GetPeriod. got %s", err.Error())
+		return nil, err
+	}
+	if rec != nil {
+		return periodFromRecord(rec)
+	}
+	lastClosed, err := lastClosedPeriod(ctx, pm.repo)
+	if err != nil {
+		llog.Errorf("error while calling pm.repo.GetLastClosedPeriod. got %s", err.Error())
+		return nil, err
+	}
+	if checkPostingTime(lastClosed, ret.Start) != nil {
+		ret.Status = PeriodClosed
+	}
+	return ret, nil
+}
+
+// ListPeriods returns the periods that were closed on their own, in order.
+func (pm *MySQLPeriodManager) ListPeriods(ctx context.Context) ([]*Period, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "ListPeriods")
+
+	recs, err := pm.repo.ListPeriods(ctx)
+	if err != nil {
+		llog.Errorf("error while calling pm.repo.ListPeriods. got %s", err.Error())
+		return nil, err
+	}
+	ret := make([]*Period, 0, len(recs))
+	for _, rec := range recs {
+		period, err := periodFromRecord(rec)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, period)
+	}
+	return ret, nil
+}
+
+// ClosePeriod closes the period and every period before it, posting the closing journals.
+// The journal chain is locked while the period closes, no journal can be posted meanwhile, and the closing journals
+// are posted in the same database transaction as the period, so either both or none are committed.
+func (pm *MySQLPeriodManager) ClosePeriod(ctx context.Context, period, author string) (*Period, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "ClosePeriod")
+
+	ret, err := parsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, author)
+	err = pm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		if _, err := txRepo.GetJournalChainForUpdate(ctx); err != nil {
+			return err
+		}
+		lastClosed, err := lastClosedPeriod(ctx, txRepo)
+		if err != nil {
+			return err
+		}
+		if err = checkPeriodClosing(ret, lastClosed); err != nil {
+			return err
+		}
+		tb, err := NewMySQLReportManager(txRepo, nil).GetTrialBalance(ctx, ret.End.Add(-time.Nanosecond), "")
+		if err != nil {
+			return err
+		}
+		coas, _, err := listCOA(ctx, txRepo)
+		if err != nil {
+			return err
+		}
+		journals, err := newClosingJournals(pm.idGenerator, ret, tb, coas, pm.retainedEarnings, author)
+		if err != nil {
+			return err
+		}
+		journalManager := NewMySQLJournalManager(txRepo)
+		for _, journal := range journals {
+			if err = journalManager.PersistJournal(ctx, journal); err != nil {
+				return err
+			}
+			ret.ClosingJournalIDs = append(ret.ClosingJournalIDs, journal.GetJournalID())
+		}
+		return txRepo.InsertPeriod(ctx, &connector.PeriodRecord{
+			Period:            period,
+			ClosingJournalIDs: strings.Join(ret.ClosingJournalIDs, ","),
+			ClosedAt:          time.Now(),
+			ClosedBy:          author,
+		})
+	})
+	if err != nil {
+		llog.Errorf("error while closing period %s. got %s", period, err.Error())
+		return nil, err
+	}
+	return pm.GetPeriod(ctx, period)
+}
+
+// CheckPostingTime makes sure a posting made at the specified time does not fall in a closed period.
+// The journal manager checks it again when the journal is persisted.
+func (pm *MySQLPeriodManager) CheckPostingTime(ctx context.Context, at time.Time) error {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "CheckPostingTime")
+
+	lastClosed, err := lastClosedPeriod(ctx, pm.repo)
+	if err != nil {
+		llog.Errorf("error while calling pm.repo.GetLastClosedPeriod. got %s", err.Error())
+		return err
+	}
+	return checkPostingTime(lastClosed, at)
+}
+
+// POSTING DATE MANAGER ------------------------------------------------------------------
+
+// NewMySQLPostingDateManager returns a posting date manager on the journals and transactions tables, accepting
+// effective dates up to the backdating window in the past.
+func NewMySQLPostingDateManager(repo connector.DBRepository, backdatingWindow time.Duration) PostingDateManager {
+	return &MySQLPostingDateManager{
+		journalManager:     &MySQLJournalManager{repo: repo},
+		transactionManager: &MySQLTransactionManager{repo: repo},
+		backdatingWindow:   backdatingWindow,
+	}
+}
+
+// MySQLPostingDateManager implementation of PostingDateManager using the journals and transactions tables
+type MySQLPostingDateManager struct {
+	journalManager     *MySQLJournalManager
+	transactionManager *MySQLTransactionManager
+	backdatingWindow   time.Duration
+}
+
+// CheckEffectiveTime makes sure a journal booked now may take effect at the specified time.
+func (pm *MySQLPostingDateManager) CheckEffectiveTime(ctx context.Context, effective time.Time) error {
+	return checkEffectiveTime(effective, time.Now(), pm.backdatingWindow)
+}
+
+// ListJournalsByDate retrieves the journals whose date selected by the basis is within the time range.
+func (pm *MySQLPostingDateManager) ListJournalsByDate(ctx context.Context, basis connector.DateBasis, from, until time.Time, request acccore.PageRequest) (acccore.PageResult, []acccore.Journal, error) {
+	return pm.journalManager.listJournals(ctx, basis, from, until, request)
+}
+
+// ListTransactionsOnAccountByDate retrieves the transactions of the account whose date selected by the basis is within the time range.
+func (pm *MySQLPostingDateManager) ListTransactionsOnAccountByDate(ctx context.Context, basis connector.DateBasis, from, until time.Time, account acccore.Account, request acccore.PageRequest) (acccore.PageResult, []acccore.Transaction, error) {
+	return pm.transactionManager.listTransactionsOnAccount(ctx, basis, from, until, account, request)
+}
+
+// REVERSAL MANAGER ------------------------------------------------------------------
+
+// NewMySQLReversalManager returns a reversal manager summing up the reversal journals in the journals table.
+func NewMySQLReversalManager(repo connector.DBRepository) ReversalManager {
+	return &MySQLReversalManager{repo: repo}
+}
+
+// MySQLReversalManager implementation of ReversalManager using the journals and transactions tables
+type MySQLReversalManager struct {
+	repo connector.DBRepository
+}
+
+// journalReversal reads how much of every leg of the journal its reversal journals took back.
+func journalReversal(ctx context.Context, repo connector.DBRepository, journalID string) (*JournalReversal, error) {
+	trxs, err := repo.ListTransactionByJournalID(ctx, journalID)
+	if err != nil {
+		return nil, err
+	}
+	recs, err := repo.SumReversedAmountByJournalID(ctx, journalID)
+	if err != nil {
+		return nil, err
+	}
+	reversed := make(map[string]int64, len(recs))
+	for _, rec := range recs {
+		reversed[rec.AccountNumber] = rec.Amount
+	}
+	ret := &JournalReversal{JournalID: journalID, Legs: make([]*ReversalLeg, 0, len(trxs))}
+	for _, trx := range trxs {
+		leg := &ReversalLeg{TransactionID: trx.TransactionID, AccountNumber: trx.AccountNumber, Alignment: acccore.CREDIT,
+			Amount: trx.Amount, Reversed: reversed[trx.AccountNumber]}
+		if strings.ToUpper(trx.Alignment) == "DEBIT" {
+			leg.Alignment = acccore.DEBIT
+		}
+		ret.Legs = append(ret.Legs, leg)
+	}
+	return ret, nil
+}
+
+// GetJournalReversal returns how much of every leg of the journal was reversed.
+func (rm *MySQLReversalManager) GetJournalReversal(ctx context.Context, journal acccore.Journal) (*JournalReversal, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetJournalReversal")
+
+	ret, err := journalReversal(ctx, rm.repo, journal.GetJournalID())
+	if err != nil {
+		llog.Errorf("error while reading the reversal of journal %s. got %s", journal.GetJournalID(), err.Error())
+		return nil, err
+	}
+	return ret, nil
+}
+
+// JOURNAL BATCH MANAGER ------------------------------------------------------------------
+
+// NewMySQLJournalBatchManager returns a journal batch manager posting the journals with the sql journal manager,
+// reading the accounts of chunkSize journals at once.
+func NewMySQLJournalBatchManager(repo connector.DBRepository, chunkSize int) JournalBatchManager {
+	return &MySQLJournalBatchManager{repo: repo, chunkSize: chunkSize}
+}
+
+// MySQLJournalBatchManager implementation of JournalBatchManager using the journals and transactions tables
+type MySQLJournalBatchManager struct {
+	repo      connector.DBRepository
+	chunkSize int
+}
+
+// persistChunks persists the journals chunk by chunk with the journal manager, reading the accounts of every chunk
+// in a single query, and sets the error of every journal. If stopOnError, it stops at the first journal refused.
+// It returns the index of that journal, or -1 if it did not stop.
+func (bm *MySQLJournalBatchManager) persistChunks(ctx context.Context, jm *MySQLJournalManager, journals []acccore.Journal, errs []error, stopOnError bool) int {
+	for _, chunk := range batchChunks(len(journals), bm.chunkSize) {
+		accounts, err := journalAccounts(ctx, jm.repo, journals[chunk[0]:chunk[1]])
+		for i := chunk[0]; i < chunk[1]; i++ {
+			if errs[i] = err; err == nil {
+				errs[i] = jm.persistJournal(batchContext(ctx, journals[i]), journals[i], accounts)
+			}
+			if errs[i] != nil && stopOnError {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// PersistJournals persists the journals in order and returns the error of every journal.
+// An all or nothing batch is posted in a single database transaction, either every journal or none is committed.
+// The journal chain stays locked until the transaction ends, no other journal can be posted meanwhile.
+func (bm *MySQLJournalBatchManager) PersistJournals(ctx context.Context, journals []acccore.Journal, allOrNothing bool) []error {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "PersistJournals")
+
+	errs := make([]error, len(journals))
+	if !allOrNothing {
+		bm.persistChunks(ctx, &MySQLJournalManager{repo: bm.repo}, journals, errs, false)
+		return errs
+	}
+	failed := -1
+	err := bm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		if failed = bm.persistChunks(ctx, &MySQLJournalManager{repo: txRepo}, journals, errs, true); failed >= 0 {
+			return errs[failed]
+		}
+		return nil
+	})
+	if err != nil {
+		llog.Errorf("error while posting a batch of %d journals. got %s", len(journals), err.Error())
+		if failed < 0 {
+			// the transaction could not be started or committed, none of the journals is posted
+			for i := range errs {
+				errs[i] = err
+			}
+			return errs
+		}
+		notPosted(errs, 0, failed)
+	}
+	return errs
+}
+
+// TEMPLATE MANAGER ------------------------------------------------------------------
+
+// NewMySQLTemplateManager returns new SQL Template Manager, rounding the amounts of the journals it makes with the
+// rounding mode.
+func NewMySQLTemplateManager(repo connector.DBRepository, rounding RoundingMode) TemplateManager {
+	return &MySQLTemplateManager{repo: repo, rounding: rounding}
+}
+
+// MySQLTemplateManager implementation of TemplateManager using JournalTemplates table in MySQL
+type MySQLTemplateManager struct {
+	repo     connector.DBRepository
+	rounding RoundingMode
+}
+
+// templateFromRecord returns the journal template kept in the template record.
+func templateFromRecord(rec *connector.JournalTemplateRecord) (*JournalTemplate, error) {
+	template := &JournalTemplate{
+		Name:        rec.Name,
+		Description: rec.Description,
+		CreatedAt:   rec.CreatedAt,
+		CreatedBy:   rec.CreatedBy,
+	}
+	if err := unmarshalTemplateDefinition(template, rec.Definition); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// CreateTemplate creates the template and returns it.
+func (tm *MySQLTemplateManager) CreateTemplate(ctx context.Context, template *JournalTemplate, author string) (*JournalTemplate, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "CreateTemplate")
+
+	if err := template.validate(); err != nil {
+		return nil, err
+	}
+	definition, err := marshalTemplateDefinition(template)
+	if err != nil {
+		return nil, err
+	}
+	err = tm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		rec, err := txRepo.GetJournalTemplate(ctx, template.Name)
+		if err != nil {
+			return err
+		}
+		if rec != nil {
+			return fmt.Errorf("%w : %s", hwerrors.ErrTemplateExists, template.Name)
+		}
+		return txRepo.InsertJournalTemplate(ctx, &connector.JournalTemplateRecord{
+			Name:        template.Name,
+			Description: template.Description,
+			Definition:  definition,
+			CreatedAt:   time.Now(),
+			CreatedBy:   author,
+		})
+	})
+	if err != nil {
+		llog.Errorf("error while creating journal template %s. got %s", template.Name, err.Error())
+		return nil, err
+	}
+	return tm.GetTemplate(ctx, template.Name)
+}
+
+// GetTemplate returns the template.
+func (tm *MySQLTemplateManager) GetTemplate(ctx context.Context, name string) (*JournalTemplate, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetTemplate")
+
+	rec, err := tm.repo.GetJournalTemplate(ctx, name)
+	if err != nil {
+		llog.Errorf("error while calling tm.repo.GetJournalTemplate. got %s", err.Error())
+		return nil, err
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("%w : %s", hwerrors.ErrTemplateNotFound, name)
+	}
+	return templateFromRecord(rec)
+}
+
+// ListTemplates returns every template sorted by name.
+func (tm *MySQLTemplateManager) ListTemplates(ctx context.Context) ([]*JournalTemplate, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "ListTemplates")
+
+	recs, err := tm.repo.ListJournalTemplates(ctx)
+	if err != nil {
+		llog.Errorf("error while calling tm.repo.ListJournalTemplates. got %s", err.Error())
+		return nil, err
+	}
+	ret := make([]*JournalTemplate, 0, len(recs))
+	for _, rec := range recs {
+		template, err := templateFromRecord(rec)
+		if err != nil {
+			llog.Errorf("error while reading journal template %s. got %s", rec.Name, err.Error())
+			return nil, err
+		}
+		ret = append(ret, template)
+	}
+	return ret, nil
+}
+
+// DeleteTemplate deletes the template, unless a schedule still posts it.
+func (tm *MySQLTemplateManager) DeleteTemplate(ctx context.Context, name string) error {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "DeleteTemplate")
+
+	err := tm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		rec, err := txRepo.GetJournalTemplate(ctx, name)
+		if err != nil {
+			return err
+		}
+		if rec == nil {
+			return fmt.Errorf("%w : %s", hwerrors.ErrTemplateNotFound, name)
+		}
+		schedules, err := txRepo.CountJournalSchedulesByTemplate(ctx, name)
+		if err != nil {
+			return err
+		}
+		if schedules > 0 {
+			return fmt.Errorf("%w : %d schedules post template %s", hwerrors.ErrTemplateInUse, schedules, name)
+		}
+		return txRepo.DeleteJournalTemplate(ctx, name)
+	})
+	if err != nil {
+		llog.Errorf("error while deleting journal template %s. got %s", name, err.Error())
+	}
+	return err
+}
+
+// NewJournalRequest makes the request of the journal the template makes with the values of its parameters.
+func (tm *MySQLTemplateManager) NewJournalRequest(ctx context.Context, name string, values map[string]string) (*CreateJournalRequest, error) {
+	template, err := tm.GetTemplate(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return template.newJournalRequest(values, tm.rounding)
+}
+
+// SCHEDULE MANAGER ------------------------------------------------------------------
+
+// NewMySQLScheduleManager returns new SQL Schedule Manager, checking the schedules against the templates of the
+// template manager.
+func NewMySQLScheduleManager(repo connector.DBRepository, templateManager TemplateManager, idGenerator acccore.UniqueIDGenerator) ScheduleManager {
+	return &MySQLScheduleManager{repo: repo, templateManager: templateManager, idGenerator: idGenerator}
+}
+
+// MySQLScheduleManager implementation of ScheduleManager using JournalSchedules and ScheduleRuns tables in MySQL.
+// The lease of a due schedule is taken with a conditional update, so only one replica posts an occurrence.
+type MySQLScheduleManager struct {
+	repo            connector.DBRepository
+	templateManager TemplateManager
+	idGenerator     acccore.UniqueIDGenerator
+}
+
+// scheduleFromRecord returns the schedule kept in the schedule record.
+func scheduleFromRecord(rec *connector.JournalScheduleRecord) (*Schedule, error) {
+	params, err := unmarshalScheduleParams(rec.Params)
+	if err != nil {
+		return nil, err
+	}
+	return &Schedule{
+		ScheduleID:   rec.ScheduleID,
+		TemplateName: rec.TemplateName,
+		Cron:         rec.Cron,
+		TimeZone:     rec.TimeZone,
+		Params:       params,
+		NextRunAt:    rec.NextRunAt,
+		CreatedAt:    rec.CreatedAt,
+		CreatedBy:    rec.CreatedBy,
+	}, nil
+}
+
+// scheduleRunFromRecord returns the schedule run kept in the run record.
+func scheduleRunFromRecord(rec *connector.ScheduleRunRecord) *ScheduleRun {
+	return &ScheduleRun{
+		RunID:       rec.RunID,
+		ScheduleID:  rec.ScheduleID,
+		ScheduledAt: rec.ScheduledAt,
+		StartedAt:   rec.StartedAt,
+		FinishedAt:  rec.FinishedAt,
+		Status:      ScheduleRunStatus(rec.Status),
+		JournalID:   rec.JournalID,
+		Error:       rec.Error,
+		Runner:      rec.Runner,
+	}
+}
+
+// CreateSchedule creates the schedule and returns it with its first occurrence after start.
+func (sm *MySQLScheduleManager) CreateSchedule(ctx context.Context, schedule *Schedule, start time.Time, author string) (*Schedule, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "CreateSchedule")
+
+	now := time.Now().UTC()
+	if start.IsZero() {
+		start = now
+	}
+	created, err := prepareSchedule(ctx, sm.templateManager, schedule, start)
+	if err != nil {
+		return nil, err
+	}
+	params, err := marshalScheduleParams(created.Params)
+	if err != nil {
+		return nil, err
+	}
+	created.ScheduleID = sm.idGenerator.NewUniqueID()
+	err = sm.repo.InsertJournalSchedule(ctx, &connector.JournalScheduleRecord{
+		ScheduleID:   created.ScheduleID,
+		TemplateName: created.TemplateName,
+		Cron:         created.Cron,
+		TimeZone:     created.TimeZone,
+		Params:       params,
+		NextRunAt:    created.NextRunAt,
+		LeaseUntil:   now,
+		CreatedAt:    now,
+		CreatedBy:    author,
+	})
+	if err != nil {
+		llog.Errorf("error while creating journal schedule of template %s. got %s", created.TemplateName, err.Error())
+		return nil, err
+	}
+	return sm.GetSchedule(ctx, created.ScheduleID)
+}
+
+// GetSchedule returns the schedule.
+func (sm *MySQLScheduleManager) GetSchedule(ctx context.Context, scheduleID string) (*Schedule, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "GetSchedule")
+
+	rec, err := sm.repo.GetJournalSchedule(ctx, scheduleID)
+	if err != nil {
+		llog.Errorf("error while calling sm.repo.GetJournalSchedule. got %s", err.Error())
+		return nil, err
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("%w : %s", hwerrors.ErrScheduleNotFound, scheduleID)
+	}
+	return scheduleFromRecord(rec)
+}
+
+// ListSchedules returns every schedule sorted by creation time.
+func (sm *MySQLScheduleManager) ListSchedules(ctx context.Context) ([]*Schedule, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "ListSchedules")
+
+	recs, err := sm.repo.ListJournalSchedules(ctx)
+	if err != nil {
+		llog.Errorf("error while calling sm.repo.ListJournalSchedules. got %s", err.Error())
+		return nil, err
+	}
+	ret := make([]*Schedule, 0, len(recs))
+	for _, rec := range recs {
+		schedule, err := scheduleFromRecord(rec)
+		if err != nil {
+			llog.Errorf("error while reading journal schedule %s. got %s", rec.ScheduleID, err.Error())
+			return nil, err
+		}
+		ret = append(ret, schedule)
+	}
+	return ret, nil
+}
+
+// DeleteSchedule deletes the schedule and its run history.
+func (sm *MySQLScheduleManager) DeleteSchedule(ctx context.Context, scheduleID string) error {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "DeleteSchedule")
+
+	err := sm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		rec, err := txRepo.GetJournalSchedule(ctx, scheduleID)
+		if err != nil {
+			return err
+		}
+		if rec == nil {
+			return fmt.Errorf("%w : %s", hwerrors.ErrScheduleNotFound, scheduleID)
+		}
+		if err = txRepo.DeleteScheduleRuns(ctx, scheduleID); err != nil {
+			return err
+		}
+		return txRepo.DeleteJournalSchedule(ctx, scheduleID)
+	})
+	if err != nil {
+		llog.Errorf("error while deleting journal schedule %s. got %s", scheduleID, err.Error())
+	}
+	return err
+}
+
+// ListRuns lists the runs of the schedule in paginated fashion, latest occurrence first.
+func (sm *MySQLScheduleManager) ListRuns(ctx context.Context, scheduleID string, request acccore.PageRequest) (acccore.PageResult, []*ScheduleRun, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "ListRuns")
+
+	if _, err := sm.GetSchedule(ctx, scheduleID); err != nil {
+		return acccore.PageResult{}, nil, err
+	}
+	count, err := sm.repo.CountScheduleRuns(ctx, scheduleID)
+	if err != nil {
+		llog.Errorf("error while calling sm.repo.CountScheduleRuns. got %s", err.Error())
+		return acccore.PageResult{}, nil, err
+	}
+	pResult := acccore.PageResultFor(request, count)
+	recs, err := sm.repo.ListScheduleRuns(ctx, scheduleID, pResult.Offset, pResult.PageSize)
+	if err != nil {
+		llog.Errorf("error while calling sm.repo.ListScheduleRuns. got %s", err.Error())
+		return acccore.PageResult{}, nil, err
+	}
+	ret := make([]*ScheduleRun, 0, len(recs))
+	for _, rec := range recs {
+		ret = append(ret, scheduleRunFromRecord(rec))
+	}
+	return pResult, ret, nil
+}
+
+// ClaimDueSchedules leases to the owner the schedules due at the specified time that are not leased. A schedule
+// another replica leased meanwhile is left out.
+func (sm *MySQLScheduleManager) ClaimDueSchedules(ctx context.Context, owner string, at time.Time, lease time.Duration, limit int) ([]*Schedule, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "ClaimDueSchedules")
+
+	at = at.UTC()
+	recs, err := sm.repo.ListDueJournalSchedules(ctx, at, limit)
+	if err != nil {
+		llog.Errorf("error while calling sm.repo.ListDueJournalSchedules. got %s", err.Error())
+		return nil, err
+	}
+	ret := make([]*Schedule, 0, len(recs))
+	for _, rec := range recs {
+		leased, err := sm.repo.LeaseJournalSchedule(ctx, rec.ScheduleID, owner, at, at.Add(lease))
+		if err != nil {
+			llog.Errorf("error while calling sm.repo.LeaseJournalSchedule. got %s", err.Error())
+			return nil, err
+		}
+		if !leased {
+			continue
+		}
+		schedule, err := scheduleFromRecord(rec)
+		if err != nil {
+			llog.Errorf("error while reading journal schedule %s. got %s", rec.ScheduleID, err.Error())
+			continue
+		}
+		ret = append(ret, schedule)
+	}
+	return ret, nil
+}
+
+// StartRun records a RUNNING run of the due occurrence of the schedule and moves the schedule to its next
+// occurrence in a single transaction, only if the owner still holds its lease. The transaction is committed before
+// the journal is posted, so a replica stopping while posting leaves the occurrence RUNNING rather than posting it again.
+func (sm *MySQLScheduleManager) StartRun(ctx context.Context, schedule *Schedule, owner string) (*ScheduleRun, error) {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "StartRun")
+
+	var run *ScheduleRun
+	err := sm.repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		rec, err := txRepo.GetJournalSchedule(ctx, schedule.ScheduleID)
+		if err != nil || rec == nil || rec.LeaseOwner != owner {
+			return err
+		}
+		current, err := scheduleFromRecord(rec)
+		if err != nil {
+			return err
+		}
+		next, err := current.next(current.NextRunAt)
+		if err != nil {
+			return err
+		}
+		advanced, err := txRepo.AdvanceJournalSchedule(ctx, current.ScheduleID, owner, next)
+		if err != nil || !advanced {
+			return err
+		}
+		runRec := &connector.ScheduleRunRecord{
+			RunID:       sm.idGenerator.NewUniqueID(),
+			ScheduleID:  current.ScheduleID,
+			ScheduledAt: current.NextRunAt.UTC(),
+			StartedAt:   time.Now().UTC(),
+			Status:      string(ScheduleRunRunning),
+			Runner:      owner,
+		}
+		if err = txRepo.InsertScheduleRun(ctx, runRec); err != nil {
+			return err
+		}
+		run = scheduleRunFromRecord(runRec)
+		return nil
+	})
+	if err != nil {
+		llog.Errorf("error while starting a run of journal schedule %s. got %s", schedule.ScheduleID, err.Error())
+		return nil, err
+	}
+	return run, nil
+}
+
+// FinishRun records the status, journal and error of the run and releases the lease of the owner on its schedule.
+func (sm *MySQLScheduleManager) FinishRun(ctx context.Context, run *ScheduleRun, owner string) error {
+	requestID := ctx.Value(contextkeys.XRequestID).(string)
+	llog := dbLog.WithField("RequestID", requestID).WithField("function", "FinishRun")
+
+	err := sm.repo.UpdateScheduleRun(ctx, &connector.ScheduleRunRecord{
+		RunID:      run.RunID,
+		Status:     string(run.Status),
+		FinishedAt: run.FinishedAt.UTC(),
+		JournalID:  run.JournalID,
+		Error:      run.Error,
+	})
+	if err != nil {
+		llog.Errorf("error while calling sm.repo.UpdateScheduleRun. got %s", err.Error())
+		return err
+	}
+	if err = sm.repo.ReleaseJournalSchedule(ctx, run.ScheduleID, owner, time.Now().UTC()); err != nil {
+		llog.Errorf("error while calling sm.repo.ReleaseJournalSchedule. got %s", err.Error())
+		return err
+	}
+	return nil
+}
@@ -2,14 +2,20 @@ package accounting
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
 	"github.com/hyperjumptech/hyperwallet/internal/config"
 	"github.com/hyperjumptech/hyperwallet/internal/connector"
 	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/hyperjumptech/hyperwallet/migrations"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAccounting_CreateNewAccount(t *testing.T) {
@@ -36,30 +42,12 @@ func TestAccounting_CreateNewAccount(t *testing.T) {
 		}
 		acccore.ClearInMemoryTables()
 	} else {
-		config.GetInt("")
-		config.Set("db.host", "localhost")
-		config.Set("db.port", "6603")
-		config.Set("db.user", "devuser")
-		config.Set("db.password", "devuser")
-		config.Set("db.name", "devdb")
-
-		repo := &connector.MySQLDBRepository{}
-		err := repo.Connect(ctx)
-		if err != nil {
-			t.Errorf("cannot connect to db. got %s", err.Error())
-			t.FailNow()
-		}
-
-		err = repo.ClearTables(ctx)
-		if err != nil {
-			t.Errorf("cannot clear tables. got %s", err.Error())
-			t.FailNow()
-		}
+		repo := connectTestRepository(ctx, t)
 
 		journalManager = NewMySQLJournalManager(repo)
 		accountManager = NewMySQLAccountManager(repo)
 		transactionManager = NewMySQLTransactionManager(repo)
-		exchangeManager = NewMySQLExchangeManager(repo)
+		exchangeManager = NewMySQLExchangeManager(repo, RoundHalfEven)
 		uniqueIDGenerator = &acccore.RandomGenUniqueIDGenerator{
 			Length:        16,
 			LowerAlpha:    false,
@@ -128,30 +116,12 @@ func TestAccounting_CreateNewJournal(t *testing.T) {
 		}
 		acccore.ClearInMemoryTables()
 	} else {
-		config.GetInt("")
-		config.Set("db.host", "localhost")
-		config.Set("db.port", "6603")
-		config.Set("db.user", "devuser")
-		config.Set("db.password", "devuser")
-		config.Set("db.name", "devdb")
-
-		repo := &connector.MySQLDBRepository{}
-		err := repo.Connect(ctx)
-		if err != nil {
-			t.Errorf("cannot connect to db. got %s", err.Error())
-			t.FailNow()
-		}
-
-		err = repo.ClearTables(ctx)
-		if err != nil {
-			t.Errorf("cannot clear tables. got %s", err.Error())
-			t.FailNow()
-		}
+		repo := connectTestRepository(ctx, t)
 
 		journalManager = NewMySQLJournalManager(repo)
 		accountManager = NewMySQLAccountManager(repo)
 		transactionManager = NewMySQLTransactionManager(repo)
-		exchangeManager = NewMySQLExchangeManager(repo)
+		exchangeManager = NewMySQLExchangeManager(repo, RoundHalfEven)
 		uniqueIDGenerator = &acccore.RandomGenUniqueIDGenerator{
 			Length:        16,
 			LowerAlpha:    false,
@@ -259,3 +229,1403 @@ func TestAccounting_CreateNewJournal(t *testing.T) {
 		t.Log(render)
 	}
 }
+
+var (
+	errInjectedFailure = errors.New("injected failure")
+
+	// testDatabasePorts are the ports the test database of each driver listens on
+	testDatabasePorts = map[string]string{"mysql": "6603", "postgres": "6604"}
+
+	// testDatabaseNames are the test database of each driver, SQLite tests need no database server
+	testDatabaseNames = map[string]string{"mysql": "devdb", "postgres": "devdb", "sqlite": ":memory:"}
+
+	testIDGenerator = &acccore.RandomGenUniqueIDGenerator{
+		Length:     16,
+		LowerAlpha: false,
+		UpperAlpha: true,
+		Numeric:    true,
+	}
+)
+
+// connectTestRepository connects to the test database, migrates it to the latest schema and clears all of its tables.
+// The database is MySQL unless the DB_DRIVER environment variable selects another one of connector.Drivers.
+func connectTestRepository(ctx context.Context, t *testing.T) connector.DBRepository {
+	config.GetInt("")
+	driver := config.Get("db.driver")
+	config.Set("db.host", "localhost")
+	config.Set("db.port", testDatabasePorts[driver])
+	config.Set("db.user", "devuser")
+	config.Set("db.password", "devuser")
+	config.Set("db.name", testDatabaseNames[driver])
+
+	repo, err := connector.NewDBRepository(driver)
+	if err != nil {
+		t.Fatalf("cannot create repository. got %s", err.Error())
+	}
+	if err = repo.Connect(ctx); err != nil {
+		t.Fatalf("cannot connect to db. got %s", err.Error())
+	}
+	all, err := migrations.ForDriver(driver)
+	if err != nil {
+		t.Fatalf("cannot load migrations. got %s", err.Error())
+	}
+	if _, err = migrations.NewMigrator(repo.DB(), all).Up(ctx, 0); err != nil {
+		t.Fatalf("cannot migrate db. got %s", err.Error())
+	}
+	if err = repo.ClearTables(ctx); err != nil {
+		t.Fatalf("cannot clear tables. got %s", err.Error())
+	}
+	return repo
+}
+
+// limitTestConnections keeps concurrent tests from opening more connections than the test database accepts.
+// Drivers that already limit their connections are left as they are.
+func limitTestConnections(repo connector.DBRepository, max int) {
+	if repo.DB().Stats().MaxOpenConnections == 0 {
+		repo.DB().SetMaxOpenConns(max)
+	}
+}
+
+// createTestAccounts creates the currency and one zero balance account for each of the account numbers.
+func createTestAccounts(ctx context.Context, t *testing.T, repo connector.DBRepository, currency string, accounts map[string]acccore.Alignment) {
+	_, err := NewMySQLExchangeManager(repo, RoundHalfEven).CreateCurrency(ctx, currency, currency+" currency", big.NewFloat(1.0), "TESTING")
+	assert.NoError(t, err)
+	accountManager := NewMySQLAccountManager(repo)
+	for accountNumber, alignment := range accounts {
+		account := &acccore.BaseAccount{}
+		account.SetAccountNumber(accountNumber).SetName(accountNumber).SetDescription(accountNumber + " test account").
+			SetCOA("1.1").SetCurrency(currency).SetAlignment(alignment).SetCreateBy("TESTING").SetUpdateBy("TESTING")
+		assert.NoError(t, accountManager.PersistAccount(ctx, account))
+	}
+}
+
+// makeTestJournal creates an un-persisted two legged journal moving amount from the credited to the debited account.
+func makeTestJournal(description, debitAccount, creditAccount string, amount int64) acccore.Journal {
+	journal := &acccore.BaseJournal{
+		JournalID:      testIDGenerator.NewUniqueID(),
+		JournalingTime: time.Now(),
+		Description:    description,
+		CreateTime:     time.Now(),
+		CreatedBy:      "TESTING",
+	}
+	journal.SetTransactions([]acccore.Transaction{
+		&acccore.BaseTransaction{TransactionID: testIDGenerator.NewUniqueID(), TransactionTime: time.Now(), AccountNumber: debitAccount,
+			JournalID: journal.JournalID, Description: "debit leg", TransactionType: acccore.DEBIT, Amount: amount, CreateBy: "TESTING"},
+		&acccore.BaseTransaction{TransactionID: testIDGenerator.NewUniqueID(), TransactionTime: time.Now(), AccountNumber: creditAccount,
+			JournalID: journal.JournalID, Description: "credit leg", TransactionType: acccore.CREDIT, Amount: amount, CreateBy: "TESTING"},
+	})
+	return journal
+}
+
+// failingRepository wraps a DBRepository and fails the failAt-th call to the named method.
+// The failure is also injected into the transaction bound repository handed out by ExecuteInTransaction,
+// so it can be used to break a journal posting halfway.
+type failingRepository struct {
+	connector.DBRepository
+	method string
+	failAt int
+	calls  *int
+}
+
+func (repo *failingRepository) fail(method string) bool {
+	if method != repo.method {
+		return false
+	}
+	*repo.calls++
+	return *repo.calls == repo.failAt
+}
+
+func (repo *failingRepository) ExecuteInTransaction(ctx context.Context, fn func(ctx context.Context, txRepo connector.DBRepository) error) error {
+	return repo.DBRepository.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo connector.DBRepository) error {
+		return fn(ctx, &failingRepository{DBRepository: txRepo, method: repo.method, failAt: repo.failAt, calls: repo.calls})
+	})
+}
+
+func (repo *failingRepository) InsertTransaction(ctx context.Context, rec *connector.TransactionRecord) (string, error) {
+	if repo.fail("InsertTransaction") {
+		return "", errInjectedFailure
+	}
+	return repo.DBRepository.InsertTransaction(ctx, rec)
+}
+
+func (repo *failingRepository) UpdateAccount(ctx context.Context, rec *connector.AccountRecord) error {
+	if repo.fail("UpdateAccount") {
+		return errInjectedFailure
+	}
+	return repo.DBRepository.UpdateAccount(ctx, rec)
+}
+
+func TestMySQLJournalManager_PersistJournalIsAtomic(t *testing.T) {
+	if testing.Short() {
+		t.Skip("journal atomicity requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"ATOMICDEBIT": acccore.DEBIT, "ATOMICCREDIT": acccore.CREDIT})
+	newJournal := func() acccore.Journal {
+		return makeTestJournal("Atomicity test", "ATOMICDEBIT", "ATOMICCREDIT", 1000)
+	}
+
+	failures := []struct {
+		method string
+		failAt int
+	}{
+		{method: "InsertTransaction", failAt: 2},
+		{method: "UpdateAccount", failAt: 2},
+	}
+	for _, failure := range failures {
+		t.Run(fmt.Sprintf("%s fails on call %d", failure.method, failure.failAt), func(t *testing.T) {
+			calls := 0
+			journalManager := NewMySQLJournalManager(&failingRepository{DBRepository: repo, method: failure.method, failAt: failure.failAt, calls: &calls})
+
+			journal := newJournal()
+			err := journalManager.PersistJournal(ctx, journal)
+			assert.ErrorIs(t, err, errInjectedFailure)
+
+			rec, _ := repo.GetJournal(ctx, journal.GetJournalID())
+			assert.Nil(t, rec)
+			trxs, err := repo.ListTransactionByJournalID(ctx, journal.GetJournalID())
+			assert.NoError(t, err)
+			assert.Empty(t, trxs)
+			for _, accountNumber := range []string{"ATOMICDEBIT", "ATOMICCREDIT"} {
+				account, err := repo.GetAccount(ctx, accountNumber)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(0), account.Balance)
+			}
+		})
+	}
+
+	journal := newJournal()
+	assert.NoError(t, NewMySQLJournalManager(repo).PersistJournal(ctx, journal))
+	trxs, err := repo.ListTransactionByJournalID(ctx, journal.GetJournalID())
+	assert.NoError(t, err)
+	assert.Len(t, trxs, 2)
+	for _, accountNumber := range []string{"ATOMICDEBIT", "ATOMICCREDIT"} {
+		account, err := repo.GetAccount(ctx, accountNumber)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1000), account.Balance)
+	}
+}
+
+func TestMySQLJournalManager_PersistJournalConcurrently(t *testing.T) {
+	if testing.Short() {
+		t.Skip("concurrent journal posting requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	limitTestConnections(repo, 32)
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{
+		"HOTWALLET": acccore.DEBIT,
+		"RESERVE-A": acccore.CREDIT,
+		"RESERVE-B": acccore.CREDIT,
+	})
+	journalManager := NewMySQLJournalManager(repo)
+
+	// Journals alternate between two reserves and list their legs in opposite orders,
+	// so any lock ordering problem shows up as a deadlock or a lost update.
+	const journalCount = 300
+	var wg sync.WaitGroup
+	errs := make(chan error, journalCount)
+	expected := int64(0)
+	for i := 1; i <= journalCount; i++ {
+		amount := int64(i)
+		expected += amount
+		reserve := "RESERVE-A"
+		if i%2 == 0 {
+			reserve = "RESERVE-B"
+		}
+		journal := makeTestJournal(fmt.Sprintf("Concurrent topup %d", i), "HOTWALLET", reserve, amount)
+		if i%3 == 0 {
+			trxs := journal.GetTransactions()
+			journal.SetTransactions([]acccore.Transaction{trxs[1], trxs[0]})
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- journalManager.PersistJournal(ctx, journal)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	hot, err := repo.GetAccount(ctx, "HOTWALLET")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, hot.Balance)
+	reserveA, err := repo.GetAccount(ctx, "RESERVE-A")
+	assert.NoError(t, err)
+	reserveB, err := repo.GetAccount(ctx, "RESERVE-B")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, reserveA.Balance+reserveB.Balance)
+}
+
+func TestMySQLIdempotencyManager_PersistJournalConcurrentlyWithSameKey(t *testing.T) {
+	if testing.Short() {
+		t.Skip("concurrent idempotent posting requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	limitTestConnections(repo, 16)
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"RETRYDEBIT": acccore.DEBIT, "RETRYCREDIT": acccore.CREDIT})
+	idempotencyManager := NewMySQLIdempotencyManager(repo, time.Hour)
+
+	// every retry of the same request carries a fresh journal, only one of them may be posted.
+	const retryCount = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, retryCount)
+	for i := 0; i < retryCount; i++ {
+		journal := makeTestJournal("Retried topup", "RETRYDEBIT", "RETRYCREDIT", 1000)
+		record := &IdempotencyRecord{Key: "RETRIED-KEY", RequestHash: "HASH", JournalID: journal.GetJournalID(),
+			ResponseBody: []byte(journal.GetJournalID()), CreatedAt: time.Now(), CreatedBy: "TESTING"}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- idempotencyManager.PersistJournal(ctx, journal, record)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, hwerrors.ErrIdempotencyKeyExists)
+	}
+	assert.Equal(t, 1, succeeded)
+
+	rec, err := idempotencyManager.GetIdempotencyRecord(ctx, "RETRIED-KEY")
+	assert.NoError(t, err)
+	if assert.NotNil(t, rec) {
+		trxs, err := repo.ListTransactionByJournalID(ctx, rec.JournalID)
+		assert.NoError(t, err)
+		assert.Len(t, trxs, 2)
+	}
+	for _, accountNumber := range []string{"RETRYDEBIT", "RETRYCREDIT"} {
+		account, err := repo.GetAccount(ctx, accountNumber)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1000), account.Balance)
+	}
+}
+
+func TestMySQLIdempotencyManager_PurgeExpired(t *testing.T) {
+	if testing.Short() {
+		t.Skip("purging idempotency keys requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	for key, createdAt := range map[string]time.Time{"EXPIRED-KEY": time.Now().Add(-2 * time.Hour), "LIVE-KEY": time.Now()} {
+		_, err := repo.InsertIdempotencyKey(ctx, &connector.IdempotencyKeyRecord{IdempotencyKey: key, RequestHash: "HASH",
+			JournalID: key, ResponseBody: key, CreatedAt: createdAt, CreatedBy: "TESTING"})
+		assert.NoError(t, err)
+	}
+
+	deleted, err := NewMySQLIdempotencyManager(repo, time.Hour).PurgeExpired(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	expired, err := repo.GetIdempotencyKey(ctx, "EXPIRED-KEY")
+	assert.NoError(t, err)
+	assert.Nil(t, expired)
+	live, err := repo.GetIdempotencyKey(ctx, "LIVE-KEY")
+	assert.NoError(t, err)
+	assert.NotNil(t, live)
+}
+
+func TestMySQLExchangeManager_DenomIsShared(t *testing.T) {
+	if testing.Short() {
+		t.Skip("persisting the common denominator requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	// two instances of the application sharing the database
+	first := NewMySQLExchangeManager(repo, RoundHalfEven)
+	second := NewMySQLExchangeManager(repo, RoundHalfEven)
+
+	assert.NoError(t, second.(DenominatorManager).LoadDenom(ctx))
+	assert.Equal(t, 1.0, floatOf(second.GetDenom(ctx)))
+
+	assert.NoError(t, first.(DenominatorManager).PersistDenom(ctx, big.NewFloat(0.5), "alice"))
+	first.SetDenom(ctx, big.NewFloat(2.5))
+	assert.Equal(t, 2.5, floatOf(second.GetDenom(ctx)))
+
+	// an instance started later loads the persisted value
+	restarted := NewMySQLExchangeManager(repo, RoundHalfEven)
+	assert.NoError(t, restarted.(DenominatorManager).LoadDenom(ctx))
+	assert.Equal(t, 2.5, floatOf(restarted.GetDenom(ctx)))
+
+	pResult, changes, err := second.(DenominatorManager).ListDenomHistory(ctx, acccore.PageRequest{PageNo: 1, ItemSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pResult.TotalEntries)
+	if assert.Len(t, changes, 2) {
+		assert.Equal(t, 2.5, changes[0].Denom)
+		assert.Equal(t, "TESTING", changes[0].ChangedBy)
+		assert.Equal(t, 0.5, changes[1].Denom)
+		assert.Equal(t, "alice", changes[1].ChangedBy)
+	}
+}
+
+func floatOf(f *big.Float) float64 {
+	ret, _ := f.Float64()
+	return ret
+}
+
+func TestMySQLExchangeManager_ExactRates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("persisting exchange units requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	rateManager := NewMySQLExchangeManager(repo, RoundHalfEven).(RateManager)
+
+	for code, exchange := range map[string]string{"TINY": "0.123456789012345678", "HUGE": "999999999999999999.5", "THIRD": "0.3"} {
+		rate, err := ParseRate(exchange)
+		assert.NoError(t, err)
+		_, err = rateManager.SetCurrencyRate(ctx, code, code+" currency", rate, "TESTING")
+		assert.NoError(t, err)
+		cur, err := rateManager.GetCurrencyRate(ctx, code)
+		assert.NoError(t, err)
+		assert.Equal(t, exchange, FormatRat(cur.Exchange), code)
+	}
+
+	rate, err := rateManager.CalculateExactRate(ctx, "THIRD", "TINY", time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, "0.41152263004115226", FormatRat(rate))
+
+	res, err := rateManager.CalculateExactExchange(ctx, "TINY", "THIRD", 1, "", time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res.Amount)
+	assert.Equal(t, "50000000000000000/20576131502057613", FormatRat(res.Exact))
+	assert.Equal(t, RoundHalfEven, res.Rounding)
+
+	_, err = rateManager.CalculateExactExchange(ctx, "THIRD", "HUGE", 1<<40, RoundFloor, time.Time{})
+	assert.True(t, errors.Is(err, hwerrors.ErrAmountOutOfRange))
+}
+
+func TestMySQLExchangeManager_RatesAt(t *testing.T) {
+	if testing.Short() {
+		t.Skip("persisting exchange units requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	rateManager := NewMySQLExchangeManager(repo, RoundHalfEven).(RateManager)
+
+	march := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.Local)
+	rec := &connector.CurrenciesRecord{Code: "USD", Name: "US Dollar", Exchange: "1", CreatedAt: march, CreatedBy: "TESTING", UpdatedAt: march, UpdatedBy: "TESTING"}
+	_, err := repo.InsertCurrency(ctx, rec)
+	assert.NoError(t, err)
+	rec = &connector.CurrenciesRecord{Code: "IDR", Name: "Rupiah", Exchange: "14000", CreatedAt: march, CreatedBy: "TESTING", UpdatedAt: march, UpdatedBy: "TESTING"}
+	_, err = repo.InsertCurrency(ctx, rec)
+	assert.NoError(t, err)
+	rec.Exchange, rec.UpdatedAt = "14500.5", march.AddDate(0, 0, 5)
+	assert.NoError(t, repo.UpdateCurrency(ctx, rec))
+	idr, err := ParseRate("15000")
+	assert.NoError(t, err)
+	_, err = rateManager.SetCurrencyRate(ctx, "IDR", "Rupiah", idr, "TESTING")
+	assert.NoError(t, err)
+
+	for at, expect := range map[time.Time]string{
+		march:                       "14000",
+		march.AddDate(0, 0, 2):      "14000",
+		march.AddDate(0, 0, 5):      "14500.5",
+		march.AddDate(0, 1, 0):      "14500.5",
+		time.Now().Add(time.Minute): "15000",
+		{}:                          "15000",
+	} {
+		rate, err := rateManager.CalculateExactRate(ctx, "USD", "IDR", at)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, FormatRat(rate), at.String())
+	}
+
+	res, err := rateManager.CalculateExactExchange(ctx, "IDR", "USD", 29001, RoundHalfEven, march.AddDate(0, 0, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res.Amount)
+
+	_, err = rateManager.CalculateExactRate(ctx, "USD", "IDR", march.Add(-time.Second))
+	assert.True(t, errors.Is(err, hwerrors.ErrNoEffectiveRate))
+	_, err = rateManager.CalculateExactRate(ctx, "USD", "EUR", march)
+	assert.Equal(t, acccore.ErrCurrencyNotFound, err)
+}
+
+func TestMySQLJournalManager_PersistFXJournal(t *testing.T) {
+	if testing.Short() {
+		t.Skip("persisting multi currency journals requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	createTestAccounts(ctx, t, repo, "USD", map[string]acccore.Alignment{"USDWALLET": acccore.DEBIT, "USDCLEAR": acccore.DEBIT})
+	createTestAccounts(ctx, t, repo, "IDR", map[string]acccore.Alignment{"IDRWALLET": acccore.DEBIT, "IDRCLEAR": acccore.DEBIT, "FXGAINLOSS": acccore.CREDIT})
+	rateManager := NewMySQLExchangeManager(repo, RoundHalfEven).(RateManager)
+	idr, err := ParseRate("14500")
+	assert.NoError(t, err)
+	_, err = rateManager.SetCurrencyRate(ctx, "IDR", "IDR currency", idr, "TESTING")
+	assert.NoError(t, err)
+	fxManager := NewFXManager(NewMySQLAccountManager(repo), rateManager, testIDGenerator, FXConfig{
+		BaseCurrency:     "IDR",
+		ClearingAccounts: map[string]string{"USD": "USDCLEAR", "IDR": "IDRCLEAR"},
+		GainLossAccount:  "FXGAINLOSS",
+		Rounding:         RoundHalfEven,
+	})
+	journalManager := NewMySQLJournalManager(repo)
+
+	// a journal mixing currencies is still refused unless it is prepared as a multi currency journal
+	assert.Equal(t, acccore.ErrJournalTransactionMixCurrency, journalManager.PersistJournal(ctx,
+		makeTestLegsJournal("plain", testLeg{"USDWALLET", acccore.DEBIT, 100}, testLeg{"IDRWALLET", acccore.CREDIT, 100})))
+
+	journal, err := fxManager.PrepareFXJournal(ctx,
+		makeTestLegsJournal("buy dollars", testLeg{"USDWALLET", acccore.DEBIT, 100}, testLeg{"IDRWALLET", acccore.CREDIT, 1440000}))
+	assert.NoError(t, err)
+	assert.NoError(t, journalManager.PersistJournal(ctx, journal))
+
+	rec, err := repo.GetJournal(ctx, journal.GetJournalID())
+	assert.NoError(t, err)
+	assert.Equal(t, journal.BaseAmount, rec.TotalAmount)
+	trxs, err := repo.ListTransactionByJournalID(ctx, journal.GetJournalID())
+	assert.NoError(t, err)
+	assert.Len(t, trxs, 5)
+	for accountNumber, balance := range map[string]int64{"USDWALLET": 100, "USDCLEAR": -100, "IDRWALLET": -1440000, "IDRCLEAR": 1450000, "FXGAINLOSS": 10000} {
+		account, err := repo.GetAccount(ctx, accountNumber)
+		assert.NoError(t, err)
+		assert.Equal(t, balance, account.Balance, accountNumber)
+	}
+
+	// every currency of a multi currency journal has to balance on its own
+	unbalanced := &FXJournal{Journal: makeTestLegsJournal("unbalanced",
+		testLeg{"USDWALLET", acccore.DEBIT, 100}, testLeg{"IDRWALLET", acccore.CREDIT, 1450000}), BaseCurrency: "IDR", BaseAmount: 1450000}
+	assert.Equal(t, acccore.ErrJournalNotBalance, journalManager.PersistJournal(ctx, unbalanced))
+}
+
+func TestMySQLAccountManager_Lifecycle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("account status requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"LIFEDEBIT": acccore.DEBIT, "LIFECREDIT": acccore.CREDIT})
+	accountManager := NewMySQLAccountManager(repo)
+	lifecycleManager := accountManager.(AccountLifecycleManager)
+	journalManager := NewMySQLJournalManager(repo)
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("before freezing", "LIFEDEBIT", "LIFECREDIT", 1000)))
+
+	name := "Renamed & frozen"
+	account, err := lifecycleManager.UpdateAccountDetails(ctx, "LIFEDEBIT", &AccountDetails{Name: &name}, "UPDATER")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), account.GetBalance())
+	assert.Equal(t, "LIFEDEBIT test account", account.GetDescription())
+	assert.Equal(t, "UPDATER", account.GetUpdateBy())
+	coa := "2.1"
+	_, err = lifecycleManager.UpdateAccountDetails(ctx, "LIFEDEBIT", &AccountDetails{COA: &coa}, "UPDATER")
+	assert.NoError(t, err)
+	rec, err := repo.GetAccount(ctx, "LIFEDEBIT")
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed &amp; frozen", rec.Name, "names are escaped once however often the account is updated")
+	assert.Equal(t, "2.1", rec.Coa)
+
+	assert.True(t, errors.Is(lifecycleManager.SetAccountStatus(ctx, "LIFEDEBIT", AccountClosed, "UPDATER"), hwerrors.ErrAccountStatusTransition))
+	assert.NoError(t, lifecycleManager.SetAccountStatus(ctx, "LIFEDEBIT", AccountFrozen, "UPDATER"))
+
+	// a frozen account stays frozen when the whole account is updated, and refuses postings
+	account, err = accountManager.GetAccountByID(ctx, "LIFEDEBIT")
+	assert.NoError(t, err)
+	assert.NoError(t, accountManager.UpdateAccount(ctx, account))
+	status, err := lifecycleManager.GetAccountStatus(ctx, "LIFEDEBIT")
+	assert.NoError(t, err)
+	assert.Equal(t, AccountFrozen, status)
+	journal := makeTestJournal("while frozen", "LIFEDEBIT", "LIFECREDIT", 500)
+	assert.True(t, errors.Is(journalManager.PersistJournal(ctx, journal), hwerrors.ErrAccountFrozen))
+	trxs, err := repo.ListTransactionByJournalID(ctx, journal.GetJournalID())
+	assert.NoError(t, err)
+	assert.Empty(t, trxs)
+
+	assert.True(t, errors.Is(lifecycleManager.SetAccountStatus(ctx, "LIFEDEBIT", AccountClosed, "UPDATER"), hwerrors.ErrAccountBalanceNotZero))
+	assert.NoError(t, lifecycleManager.SetAccountStatus(ctx, "LIFEDEBIT", AccountActive, "UPDATER"))
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("emptying", "LIFECREDIT", "LIFEDEBIT", 1000)))
+	assert.NoError(t, lifecycleManager.SetAccountStatus(ctx, "LIFEDEBIT", AccountFrozen, "UPDATER"))
+
+	// an account is not closed while a hold reserves an amount on it
+	_, err = repo.InsertHold(ctx, &connector.HoldRecord{HoldID: "LIFEHOLD", AccountNumber: "LIFEDEBIT", Amount: 100, Description: "pending",
+		Status: string(HoldHeld), ExpiresAt: time.Now().Add(time.Hour), CreatedBy: "TESTING", UpdatedBy: "TESTING"})
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(lifecycleManager.SetAccountStatus(ctx, "LIFEDEBIT", AccountClosed, "UPDATER"), hwerrors.ErrAccountHasHolds))
+	_, err = NewMySQLHoldManager(repo, testIDGenerator, time.Hour).ReleaseHold(ctx, "LIFEHOLD", "UPDATER")
+	assert.NoError(t, err)
+	assert.NoError(t, lifecycleManager.SetAccountStatus(ctx, "LIFEDEBIT", AccountClosed, "UPDATER"))
+
+	assert.True(t, errors.Is(journalManager.PersistJournal(ctx, makeTestJournal("after closing", "LIFEDEBIT", "LIFECREDIT", 1)), hwerrors.ErrAccountClosed))
+	_, err = lifecycleManager.UpdateAccountDetails(ctx, "LIFEDEBIT", &AccountDetails{Name: &name}, "UPDATER")
+	assert.True(t, errors.Is(err, hwerrors.ErrAccountClosed))
+	assert.True(t, errors.Is(lifecycleManager.SetAccountStatus(ctx, "LIFEDEBIT", AccountActive, "UPDATER"), hwerrors.ErrAccountStatusTransition))
+	_, err = lifecycleManager.GetAccountStatus(ctx, "NOSUCHACCOUNT")
+	assert.Equal(t, acccore.ErrAccountIDNotFound, err)
+}
+
+func TestMySQLJournalManager_PersistJournalBalanceLimits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("balance limits require a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"LIMDEBIT": acccore.DEBIT, "LIMCREDIT": acccore.CREDIT})
+	accountManager := NewMySQLAccountManager(repo)
+	lifecycleManager := accountManager.(AccountLifecycleManager)
+	journalManager := NewMySQLJournalManager(repo)
+
+	limits, err := lifecycleManager.GetAccountLimits(ctx, "LIMCREDIT")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultAccountLimits(), limits)
+
+	maximum := int64(1000)
+	_, err = lifecycleManager.UpdateAccountDetails(ctx, "LIMCREDIT", &AccountDetails{Limits: &AccountLimits{}}, "UPDATER")
+	assert.NoError(t, err)
+	_, err = lifecycleManager.UpdateAccountDetails(ctx, "LIMDEBIT", &AccountDetails{Limits: &AccountLimits{AllowNegative: true, MaxBalance: &maximum}}, "UPDATER")
+	assert.NoError(t, err)
+	_, err = lifecycleManager.UpdateAccountDetails(ctx, "LIMDEBIT", &AccountDetails{Limits: &AccountLimits{MaxBalance: &[]int64{-1}[0]}}, "UPDATER")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidAccountLimits))
+
+	// debiting the credit account would overdraw it
+	journal := makeTestJournal("overdraft", "LIMCREDIT", "LIMDEBIT", 100)
+	err = journalManager.PersistJournal(ctx, journal)
+	limitErr := &BalanceLimitError{}
+	if assert.True(t, errors.As(err, &limitErr)) {
+		assert.Equal(t, "LIMCREDIT", limitErr.AccountNumber)
+		assert.Equal(t, int64(-100), limitErr.Balance)
+		assert.False(t, limitErr.Maximum)
+	}
+	trxs, err := repo.ListTransactionByJournalID(ctx, journal.GetJournalID())
+	assert.NoError(t, err)
+	assert.Empty(t, trxs)
+
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("up to the maximum", "LIMDEBIT", "LIMCREDIT", 1000)))
+	err = journalManager.PersistJournal(ctx, makeTestJournal("beyond the maximum", "LIMDEBIT", "LIMCREDIT", 1))
+	if assert.True(t, errors.As(err, &limitErr)) {
+		assert.Equal(t, "LIMDEBIT", limitErr.AccountNumber)
+		assert.Equal(t, maximum, limitErr.Limit)
+		assert.True(t, limitErr.Maximum)
+	}
+
+	// a lower maximum leaves the balance beyond it, postings bringing it back are still allowed
+	lower := int64(500)
+	_, err = lifecycleManager.UpdateAccountDetails(ctx, "LIMDEBIT", &AccountDetails{Limits: &AccountLimits{AllowNegative: true, MaxBalance: &lower}}, "UPDATER")
+	assert.NoError(t, err)
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("back toward the maximum", "LIMCREDIT", "LIMDEBIT", 200)))
+
+	// the limits stay when the whole account is updated
+	account, err := accountManager.GetAccountByID(ctx, "LIMDEBIT")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(800), account.GetBalance())
+	assert.NoError(t, accountManager.UpdateAccount(ctx, account))
+	limits, err = lifecycleManager.GetAccountLimits(ctx, "LIMDEBIT")
+	assert.NoError(t, err)
+	assert.Equal(t, &AccountLimits{AllowNegative: true, MaxBalance: &lower}, limits)
+
+	// an account persisted with its limits never exists without them, invalid limits persist no account
+	account = &acccore.BaseAccount{}
+	account.SetAccountNumber("LIMNEW").SetName("LIMNEW").SetDescription("LIMNEW test account").
+		SetCOA("1.1").SetCurrency("GOLD").SetAlignment(acccore.DEBIT).SetCreateBy("TESTING").SetUpdateBy("TESTING")
+	assert.True(t, errors.Is(lifecycleManager.PersistAccountWithLimits(ctx, account, &AccountLimits{MaxBalance: &[]int64{-1}[0]}), hwerrors.ErrInvalidAccountLimits))
+	rec, err := repo.GetAccount(ctx, "LIMNEW")
+	assert.NoError(t, err)
+	assert.Nil(t, rec)
+	assert.NoError(t, lifecycleManager.PersistAccountWithLimits(ctx, account, &AccountLimits{MaxBalance: &lower}))
+	limits, err = lifecycleManager.GetAccountLimits(ctx, "LIMNEW")
+	assert.NoError(t, err)
+	assert.Equal(t, &AccountLimits{MaxBalance: &lower}, limits)
+}
+
+func TestMySQLHoldManager(t *testing.T) {
+	if testing.Short() {
+		t.Skip("holds require a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"HOLDWALLET": acccore.DEBIT, "HOLDOPEN": acccore.DEBIT, "HOLDMERCHANT": acccore.DEBIT, "HOLDRESERVE": acccore.CREDIT})
+	accountManager := NewMySQLAccountManager(repo)
+	lifecycleManager := accountManager.(AccountLifecycleManager)
+	journalManager := NewMySQLJournalManager(repo)
+	holdManager := NewMySQLHoldManager(repo, testIDGenerator, time.Hour)
+	_, err := lifecycleManager.UpdateAccountDetails(ctx, "HOLDWALLET", &AccountDetails{Limits: &AccountLimits{}}, "UPDATER")
+	assert.NoError(t, err)
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("topup", "HOLDWALLET", "HOLDRESERVE", 1000)))
+
+	// holds may not reserve more than the available balance
+	captured, err := holdManager.PlaceHold(ctx, "HOLDWALLET", 600, "checkout", time.Time{}, "TESTING")
+	assert.NoError(t, err)
+	_, err = holdManager.PlaceHold(ctx, "HOLDWALLET", 500, "second checkout", time.Time{}, "TESTING")
+	limitErr := &BalanceLimitError{}
+	if assert.True(t, errors.As(err, &limitErr)) {
+		assert.Equal(t, int64(-100), limitErr.Balance)
+	}
+	released, err := holdManager.PlaceHold(ctx, "HOLDWALLET", 300, "cancelled checkout", time.Time{}, "TESTING")
+	assert.NoError(t, err)
+	held, err := holdManager.GetHeldAmount(ctx, "HOLDWALLET")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(900), held)
+
+	// postings may not spend the held funds
+	err = journalManager.PersistJournal(ctx, makeTestJournal("spending held funds", "HOLDRESERVE", "HOLDWALLET", 200))
+	assert.True(t, errors.As(err, &limitErr))
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("spending available funds", "HOLDRESERVE", "HOLDWALLET", 100)))
+
+	// an account whose balance is not limited may not spend its held funds either
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("topup", "HOLDOPEN", "HOLDRESERVE", 1000)))
+	_, err = holdManager.PlaceHold(ctx, "HOLDOPEN", 800, "checkout", time.Time{}, "TESTING")
+	assert.NoError(t, err)
+	_, err = holdManager.PlaceHold(ctx, "HOLDOPEN", 300, "second checkout", time.Time{}, "TESTING")
+	assert.True(t, errors.As(err, &limitErr))
+	err = journalManager.PersistJournal(ctx, makeTestJournal("spending held funds", "HOLDRESERVE", "HOLDOPEN", 300))
+	assert.True(t, errors.As(err, &limitErr))
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("spending available funds", "HOLDRESERVE", "HOLDOPEN", 200)))
+
+	captured, err = holdManager.CaptureHold(ctx, captured.HoldID, "HOLDMERCHANT", 400, "", "TESTING")
+	assert.NoError(t, err)
+	assert.Equal(t, HoldCaptured, captured.Status)
+	assert.Equal(t, int64(400), captured.CapturedAmount)
+	journal, err := journalManager.GetJournalByID(ctx, captured.JournalID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Capture of hold "+captured.HoldID, journal.GetDescription())
+	account, err := accountManager.GetAccountByID(ctx, "HOLDWALLET")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), account.GetBalance())
+	_, err = holdManager.CaptureHold(ctx, captured.HoldID, "HOLDMERCHANT", 0, "", "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrHoldNotHeld))
+
+	released, err = holdManager.ReleaseHold(ctx, released.HoldID, "TESTING")
+	assert.NoError(t, err)
+	assert.Equal(t, HoldReleased, released.Status)
+	_, err = holdManager.ReleaseHold(ctx, released.HoldID, "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrHoldNotHeld))
+	held, err = holdManager.GetHeldAmount(ctx, "HOLDWALLET")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), held)
+
+	// a hold past its expiry reserves nothing before it is marked expired
+	_, err = repo.InsertHold(ctx, &connector.HoldRecord{HoldID: "EXPIREDHOLD", AccountNumber: "HOLDWALLET", Amount: 500, Description: "expired",
+		Status: string(HoldHeld), ExpiresAt: time.Now().Add(-time.Minute), CreatedBy: "TESTING", UpdatedBy: "TESTING"})
+	assert.NoError(t, err)
+	held, err = holdManager.GetHeldAmount(ctx, "HOLDWALLET")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), held)
+	expiring, err := holdManager.GetHold(ctx, "EXPIREDHOLD")
+	assert.NoError(t, err)
+	assert.Equal(t, HoldExpired, expiring.Status)
+	_, err = holdManager.CaptureHold(ctx, "EXPIREDHOLD", "HOLDMERCHANT", 0, "", "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrHoldNotHeld))
+	expired, err := holdManager.ExpireHolds(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), expired)
+	rec, err := repo.GetHold(ctx, "EXPIREDHOLD")
+	assert.NoError(t, err)
+	assert.Equal(t, string(HoldExpired), rec.Status)
+	assert.Equal(t, holdExpiryAuthor, rec.UpdatedBy)
+
+	_, err = holdManager.GetHold(ctx, "NOSUCHHOLD")
+	assert.True(t, errors.Is(err, hwerrors.ErrHoldNotFound))
+}
+
+func TestMySQLCOAManager_Tree(t *testing.T) {
+	if testing.Short() {
+		t.Skip("chart of accounts requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	coaManager := NewMySQLCOAManager(repo)
+	for _, coa := range []*COA{
+		{Code: "1", Name: "Assets & Receivables", Type: COAAsset, Alignment: acccore.DEBIT},
+		{Code: "1.1", ParentCode: "1", Name: "Cash", Type: COAAsset, Alignment: acccore.DEBIT},
+		{Code: "1.2", ParentCode: "1", Name: "Accumulated Depreciation", Type: COAAsset, Alignment: acccore.CREDIT},
+		{Code: "2", Name: "Liabilities", Type: COALiability, Alignment: acccore.CREDIT},
+	} {
+		_, err := coaManager.CreateCOA(ctx, coa, "TESTING")
+		assert.NoError(t, err, coa.Code)
+	}
+	coa, err := coaManager.GetCOA(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Assets & Receivables", coa.Name)
+	assert.Equal(t, "", coa.ParentCode)
+	coa, err = coaManager.GetCOA(ctx, "1.2")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", coa.ParentCode)
+	assert.Equal(t, COAAsset, coa.Type)
+	assert.Equal(t, acccore.CREDIT, coa.Alignment)
+	_, err = coaManager.CreateCOA(ctx, &COA{Code: "1.1", ParentCode: "1", Name: "Again", Type: COAAsset}, "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrCOAExists))
+	_, err = coaManager.CreateCOA(ctx, &COA{Code: "2.1", ParentCode: "1", Name: "Misplaced", Type: COALiability}, "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidCOA))
+
+	assert.NoError(t, coaManager.CheckAccountCOA(ctx, "1.2", acccore.CREDIT))
+	assert.True(t, errors.Is(coaManager.CheckAccountCOA(ctx, "1.2", acccore.DEBIT), hwerrors.ErrCOAAlignmentMismatch))
+	assert.True(t, errors.Is(coaManager.CheckAccountCOA(ctx, "9", acccore.DEBIT), hwerrors.ErrCOANotFound))
+
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"COACASH": acccore.DEBIT, "COALOAN": acccore.CREDIT, "COADEPRECIATION": acccore.CREDIT})
+	lifecycleManager := NewMySQLAccountManager(repo).(AccountLifecycleManager)
+	for accountNumber, code := range map[string]string{"COALOAN": "2", "COADEPRECIATION": "1.2"} {
+		code := code
+		_, err = lifecycleManager.UpdateAccountDetails(ctx, accountNumber, &AccountDetails{COA: &code}, "UPDATER")
+		assert.NoError(t, err)
+	}
+	journalManager := NewMySQLJournalManager(repo)
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("borrowing", "COACASH", "COALOAN", 1000)))
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("writing off", "COALOAN", "COADEPRECIATION", 100)))
+
+	// the contra asset is subtracted from the assets
+	balances, err := coaManager.GetCOABalance(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, []*COABalance{{Currency: "GOLD", Balance: 900, Accounts: 2}}, balances)
+	balances, err = coaManager.GetCOABalance(ctx, "1.1")
+	assert.NoError(t, err)
+	assert.Equal(t, []*COABalance{{Currency: "GOLD", Balance: 1000, Accounts: 1}}, balances)
+	_, err = coaManager.GetCOABalance(ctx, "9")
+	assert.True(t, errors.Is(err, hwerrors.ErrCOANotFound))
+
+	parent := "1.2"
+	_, err = coaManager.UpdateCOA(ctx, "1", &COADetails{ParentCode: &parent}, "UPDATER")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidCOA))
+	coa, err = coaManager.UpdateCOA(ctx, "1.1", &COADetails{ParentCode: &parent}, "UPDATER")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2", coa.ParentCode)
+	assert.Equal(t, "Cash", coa.Name)
+	assert.Equal(t, "UPDATER", coa.UpdatedBy)
+
+	assert.True(t, errors.Is(coaManager.DeleteCOA(ctx, "1.2"), hwerrors.ErrCOAInUse))
+	assert.True(t, errors.Is(coaManager.DeleteCOA(ctx, "2"), hwerrors.ErrCOAInUse))
+	_, err = coaManager.CreateCOA(ctx, &COA{Code: "3", Name: "Equity", Type: COAEquity, Alignment: acccore.CREDIT}, "TESTING")
+	assert.NoError(t, err)
+	assert.NoError(t, coaManager.DeleteCOA(ctx, "3"))
+	assert.True(t, errors.Is(coaManager.DeleteCOA(ctx, "3"), hwerrors.ErrCOANotFound))
+	coas, err := coaManager.ListCOA(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, coas, 4)
+}
+
+func TestMySQLReportManager_TrialBalance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("trial balance requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"TBCASH": acccore.DEBIT, "TBLOAN": acccore.CREDIT, "TBIDLE": acccore.DEBIT})
+	createTestAccounts(ctx, t, repo, "POINT", map[string]acccore.Alignment{"TBPOINT": acccore.DEBIT})
+	_, err := NewMySQLCOAManager(repo).CreateCOA(ctx, &COA{Code: "1.1", Name: "Cash & Bank", Type: COAAsset}, "TESTING")
+	assert.NoError(t, err)
+	journalManager := NewMySQLJournalManager(repo)
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("borrowing", "TBCASH", "TBLOAN", 1000)))
+	before := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("repaying", "TBLOAN", "TBCASH", 400)))
+	reportManager := NewMySQLReportManager(repo, NewMySQLExchangeManager(repo, RoundHalfEven).(RateManager))
+
+	tb, err := reportManager.GetTrialBalance(ctx, time.Now(), "GOLD")
+	assert.NoError(t, err)
+	assert.True(t, tb.Balanced())
+	if assert.Len(t, tb.Currencies, 1) && assert.Len(t, tb.Currencies[0].COAs, 1) {
+		assert.Equal(t, int64(1400), tb.Currencies[0].Debit)
+		assert.Equal(t, "Cash & Bank", tb.Currencies[0].COAs[0].Name)
+		assert.Equal(t, []*TrialBalanceAccount{
+			{AccountNumber: "TBCASH", Name: "TBCASH", COA: "1.1", Currency: "GOLD", Alignment: acccore.DEBIT, Debit: 1000, Credit: 400},
+			{AccountNumber: "TBIDLE", Name: "TBIDLE", COA: "1.1", Currency: "GOLD", Alignment: acccore.DEBIT},
+			{AccountNumber: "TBLOAN", Name: "TBLOAN", COA: "1.1", Currency: "GOLD", Alignment: acccore.CREDIT, Debit: 400, Credit: 1000},
+		}, tb.Currencies[0].COAs[0].Accounts)
+	}
+
+	tb, err = reportManager.GetTrialBalance(ctx, before, "")
+	assert.NoError(t, err)
+	if assert.Len(t, tb.Currencies, 2) {
+		assert.Equal(t, int64(1000), tb.Currencies[0].Debit)
+		assert.Equal(t, int64(1000), tb.Currencies[0].Credit)
+		assert.Equal(t, "POINT", tb.Currencies[1].Currency)
+		assert.Zero(t, tb.Currencies[1].Debit)
+	}
+}
+
+func TestMySQLIntegrityManager(t *testing.T) {
+	if testing.Short() {
+		t.Skip("integrity verification requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"ICASH": acccore.DEBIT, "ILOAN": acccore.CREDIT, "IIDLE": acccore.DEBIT})
+	journalManager := NewMySQLJournalManager(repo)
+	borrowing := makeTestJournal("borrowing", "ICASH", "ILOAN", 1000)
+	assert.NoError(t, journalManager.PersistJournal(ctx, borrowing))
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("repaying", "ILOAN", "ICASH", 400)))
+	integrityManager := NewMySQLIntegrityManager(repo)
+
+	report, err := integrityManager.VerifyIntegrity(ctx)
+	assert.NoError(t, err)
+	assert.True(t, report.Consistent())
+	assert.Equal(t, 3, report.Accounts)
+	assert.Equal(t, 4, report.Transactions)
+
+	// the running balance of ICASH drifts away from its transactions
+	account, err := repo.GetAccount(ctx, "ICASH")
+	assert.NoError(t, err)
+	account.Balance = 650
+	assert.NoError(t, repo.UpdateAccount(ctx, account))
+	// the snapshot of the borrowing on ILOAN is overwritten
+	loanLeg := borrowing.GetTransactions()[1].GetTransactionID()
+	_, err = repo.DB().ExecContext(ctx, repo.DB().Rebind("UPDATE transactions SET balance = ? WHERE transaction_id = ?"), 999, loanLeg)
+	assert.NoError(t, err)
+	// a journal lost its credit leg, the debit leg is reflected in the IIDLE balance
+	_, err = repo.InsertJournal(ctx, &connector.JournalRecord{JournalID: "IHALFJOURNAL", JournalingTime: time.Now(), Description: "half",
+		TotalAmount: 10, CreatedAt: time.Now(), CreatedBy: "TESTING"})
+	assert.NoError(t, err)
+	_, err = repo.InsertTransaction(ctx, &connector.TransactionRecord{TransactionID: "IHALFLEG", TransactionTime: time.Now(), AccountNumber: "IIDLE",
+		JournalID: "IHALFJOURNAL", Description: "debit leg", Alignment: "DEBIT", Amount: 10, Balance: 10, CreatedAt: time.Now(), CreatedBy: "TESTING"})
+	assert.NoError(t, err)
+	account, err = repo.GetAccount(ctx, "IIDLE")
+	assert.NoError(t, err)
+	account.Balance = 10
+	assert.NoError(t, repo.UpdateAccount(ctx, account))
+	// a transaction without account nor journal
+	_, err = repo.InsertTransaction(ctx, &connector.TransactionRecord{TransactionID: "IORPHAN", TransactionTime: time.Now(), AccountNumber: "INOSUCHACCOUNT",
+		JournalID: "INOSUCHJOURNAL", Description: "orphan", Alignment: "CREDIT", Amount: 5, Balance: 5, CreatedAt: time.Now(), CreatedBy: "TESTING"})
+	assert.NoError(t, err)
+
+	report, err = integrityManager.VerifyIntegrity(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, report.Transactions)
+	if assert.Len(t, report.Discrepancies, 4) {
+		assert.Equal(t, DiscrepancyOrphanTransaction, report.Discrepancies[0].Kind)
+		assert.Equal(t, "IORPHAN", report.Discrepancies[0].TransactionID)
+		assert.Equal(t, "transaction IORPHAN belongs to account INOSUCHACCOUNT that does not exist", report.Discrepancies[0].Description)
+		assert.Equal(t, DiscrepancyBalanceMismatch, report.Discrepancies[1].Kind)
+		assert.Equal(t, "ICASH", report.Discrepancies[1].AccountNumber)
+		assert.Equal(t, int64(600), report.Discrepancies[1].Expected)
+		assert.Equal(t, int64(650), report.Discrepancies[1].Actual)
+		assert.Equal(t, DiscrepancySnapshotMismatch, report.Discrepancies[2].Kind)
+		assert.Equal(t, loanLeg, report.Discrepancies[2].TransactionID)
+		assert.Equal(t, int64(1000), report.Discrepancies[2].Expected)
+		assert.Equal(t, &Discrepancy{Kind: DiscrepancyUnbalancedJournal, JournalID: "IHALFJOURNAL", Currency: "GOLD", Expected: 10,
+			Description: "journal IHALFJOURNAL debits 10 and credits 0 GOLD"}, report.Discrepancies[3])
+	}
+}
+
+func TestMySQLJournalChainManager(t *testing.T) {
+	if testing.Short() {
+		t.Skip("journal chain verification requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"CCASH": acccore.DEBIT, "CLOAN": acccore.CREDIT})
+	journalManager := NewMySQLJournalManager(repo)
+	journals := []acccore.Journal{
+		makeTestJournal("borrowing", "CCASH", "CLOAN", 1000),
+		makeTestJournal("repaying", "CLOAN", "CCASH", 400),
+		makeTestJournal("repaying again", "CLOAN", "CCASH", 100),
+	}
+	for _, journal := range journals {
+		assert.NoError(t, journalManager.PersistJournal(ctx, journal))
+	}
+	previousHash := ""
+	for i, journal := range journals {
+		rec, err := repo.GetJournal(ctx, journal.GetJournalID())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i+1), rec.ChainSequence)
+		assert.Equal(t, previousHash, rec.PreviousHash)
+		assert.Len(t, rec.ChainHash, 64)
+		previousHash = rec.ChainHash
+	}
+	chainManager := NewMySQLJournalChainManager(repo)
+	verify := func() *ChainVerification {
+		verification, err := chainManager.VerifyJournalChain(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+		return verification
+	}
+
+	verification := verify()
+	assert.True(t, verification.Valid())
+	assert.Equal(t, int64(1), verification.FirstSequence)
+	assert.Equal(t, int64(3), verification.LastSequence)
+	assert.Equal(t, 3, verification.Journals)
+
+	verification, err := chainManager.VerifyJournalChain(ctx, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, verification.Valid())
+	assert.Zero(t, verification.Journals)
+
+	exec := func(query string, args ...interface{}) {
+		_, err := repo.DB().ExecContext(ctx, repo.DB().Rebind(query), args...)
+		assert.NoError(t, err)
+	}
+	// a transaction of the second journal is edited
+	leg := journals[1].GetTransactions()[0].GetTransactionID()
+	exec("UPDATE transactions SET amount = ? WHERE transaction_id = ?", 40, leg)
+	verification = verify()
+	if assert.NotNil(t, verification.BrokenLink) {
+		assert.Equal(t, int64(2), verification.BrokenLink.Sequence)
+		assert.Equal(t, journals[1].GetJournalID(), verification.BrokenLink.JournalID)
+		assert.Equal(t, ChainBreakHashMismatch, verification.BrokenLink.Reason)
+	}
+	assert.Equal(t, 1, verification.Journals)
+	exec("UPDATE transactions SET amount = ? WHERE transaction_id = ?", 400, leg)
+	assert.True(t, verify().Valid())
+
+	// the third journal is relinked
+	exec("UPDATE journals SET previous_hash = ? WHERE journal_id = ?", "relinked", journals[2].GetJournalID())
+	verification = verify()
+	if assert.NotNil(t, verification.BrokenLink) {
+		assert.Equal(t, int64(3), verification.BrokenLink.Sequence)
+		assert.Equal(t, ChainBreakPreviousHashMismatch, verification.BrokenLink.Reason)
+		assert.Equal(t, "relinked", verification.BrokenLink.Actual)
+	}
+
+	// the second journal is deleted
+	exec("DELETE FROM journals WHERE journal_id = ?", journals[1].GetJournalID())
+	verification = verify()
+	assert.Equal(t, &BrokenChainLink{Sequence: 2, Reason: ChainBreakMissingJournal}, verification.BrokenLink)
+}
+
+func TestMySQLPeriodManager(t *testing.T) {
+	if testing.Short() {
+		t.Skip("period closing requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	coaManager := NewMySQLCOAManager(repo)
+	for _, coa := range []*COA{
+		{Code: "1", Name: "Assets", Type: COAAsset, Alignment: acccore.DEBIT},
+		{Code: "1.1", ParentCode: "1", Name: "Cash", Type: COAAsset, Alignment: acccore.DEBIT},
+		{Code: "3", Name: "Equity", Type: COAEquity, Alignment: acccore.CREDIT},
+		{Code: "4", Name: "Income", Type: COAIncome, Alignment: acccore.CREDIT},
+		{Code: "5", Name: "Expenses", Type: COAExpense, Alignment: acccore.DEBIT},
+	} {
+		_, err := coaManager.CreateCOA(ctx, coa, "TESTING")
+		assert.NoError(t, err, coa.Code)
+	}
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"PCASH": acccore.DEBIT, "PSALES": acccore.CREDIT,
+		"PCOSTS": acccore.DEBIT, "PRETAINED": acccore.CREDIT})
+	exec := func(query string, args ...interface{}) {
+		_, err := repo.DB().ExecContext(ctx, repo.DB().Rebind(query), args...)
+		assert.NoError(t, err)
+	}
+	for accountNumber, coa := range map[string]string{"PSALES": "4", "PCOSTS": "5", "PRETAINED": "3"} {
+		exec("UPDATE accounts SET coa = ? WHERE account_number = ?", coa, accountNumber)
+	}
+	journalManager := NewMySQLJournalManager(repo)
+	// post makes the journal at the specified time
+	post := func(journal acccore.Journal, at time.Time) error {
+		journal.SetJournalingTime(at)
+		for _, trx := range journal.GetTransactions() {
+			trx.SetTransactionTime(at)
+		}
+		return journalManager.PersistJournal(ctx, journal)
+	}
+	assert.NoError(t, post(makeTestJournal("january sale", "PCASH", "PSALES", 1000), time.Date(2021, 1, 15, 0, 0, 0, 0, time.UTC)))
+	assert.NoError(t, post(makeTestJournal("january cost", "PCOSTS", "PCASH", 300), time.Date(2021, 1, 31, 23, 0, 0, 0, time.UTC)))
+	assert.NoError(t, post(makeTestJournal("february sale", "PCASH", "PSALES", 200), time.Date(2021, 2, 10, 0, 0, 0, 0, time.UTC)))
+	balance := func(accountNumber string) int64 {
+		account, err := NewMySQLAccountManager(repo).GetAccountByID(ctx, accountNumber)
+		assert.NoError(t, err)
+		return account.GetBalance()
+	}
+
+	periodManager := NewMySQLPeriodManager(repo, testIDGenerator, map[string]string{"GOLD": "PRETAINED"})
+	period, err := periodManager.GetPeriod(ctx, "2021-01")
+	assert.NoError(t, err)
+	assert.Equal(t, PeriodOpen, period.Status)
+	period, err = periodManager.ClosePeriod(ctx, "2021-01", "CLOSER")
+	assert.NoError(t, err)
+	assert.Equal(t, PeriodClosed, period.Status)
+	assert.Equal(t, "CLOSER", period.ClosedBy)
+	assert.Equal(t, time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), period.End)
+	if assert.Len(t, period.ClosingJournalIDs, 1) {
+		journal, err := journalManager.GetJournalByID(ctx, period.ClosingJournalIDs[0])
+		assert.NoError(t, err)
+		assert.Len(t, journal.GetTransactions(), 3)
+		for _, trx := range journal.GetTransactions() {
+			assert.True(t, period.End.Equal(trx.GetTransactionTime()))
+		}
+	}
+	// the january income is rolled into the retained earnings, the february sale stays
+	assert.Equal(t, int64(200), balance("PSALES"))
+	assert.Equal(t, int64(0), balance("PCOSTS"))
+	assert.Equal(t, int64(700), balance("PRETAINED"))
+	assert.Equal(t, int64(900), balance("PCASH"))
+
+	err = post(makeTestJournal("late january sale", "PCASH", "PSALES", 50), time.Date(2021, 1, 20, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, hwerrors.ErrPeriodClosed)
+	assert.ErrorIs(t, periodManager.CheckPostingTime(ctx, time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)), hwerrors.ErrPeriodClosed)
+	assert.NoError(t, periodManager.CheckPostingTime(ctx, time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)))
+	_, err = periodManager.ClosePeriod(ctx, "2021-01", "CLOSER")
+	assert.ErrorIs(t, err, hwerrors.ErrPeriodClosed)
+	_, err = periodManager.ClosePeriod(ctx, "2020-12", "CLOSER")
+	assert.ErrorIs(t, err, hwerrors.ErrPeriodClosed)
+	_, err = periodManager.ClosePeriod(ctx, periodOf(time.Now()), "CLOSER")
+	assert.ErrorIs(t, err, hwerrors.ErrPeriodNotEnded)
+	_, err = periodManager.ClosePeriod(ctx, "2021-00", "CLOSER")
+	assert.ErrorIs(t, err, hwerrors.ErrInvalidPeriod)
+	period, err = periodManager.GetPeriod(ctx, "2020-12")
+	assert.NoError(t, err)
+	assert.Equal(t, PeriodClosed, period.Status)
+	assert.Empty(t, period.ClosingJournalIDs)
+
+	// closing march also closes february
+	period, err = periodManager.ClosePeriod(ctx, "2021-03", "CLOSER")
+	assert.NoError(t, err)
+	assert.Len(t, period.ClosingJournalIDs, 1)
+	assert.Equal(t, int64(0), balance("PSALES"))
+	assert.Equal(t, int64(900), balance("PRETAINED"))
+	period, err = periodManager.GetPeriod(ctx, "2021-02")
+	assert.NoError(t, err)
+	assert.Equal(t, PeriodClosed, period.Status)
+	periods, err := periodManager.ListPeriods(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, periods, 2) {
+		assert.Equal(t, "2021-01", periods[0].Period)
+		assert.Equal(t, "2021-03", periods[1].Period)
+	}
+	// the closing journals are chained like any other journal
+	verification, err := NewMySQLJournalChainManager(repo).VerifyJournalChain(ctx, time.Time{}, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, verification.Valid())
+	assert.Equal(t, 5, verification.Journals)
+}
+
+func TestMySQLPostingDateManager(t *testing.T) {
+	if testing.Short() {
+		t.Skip("posting dates require a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"DCASH": acccore.DEBIT, "DLOAN": acccore.CREDIT})
+	journalManager := NewMySQLJournalManager(repo)
+	current := makeTestJournal("borrowing", "DCASH", "DLOAN", 1000)
+	assert.NoError(t, journalManager.PersistJournal(ctx, current))
+	// booked after the current journal, it takes effect before it
+	effective := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	backdated := makeTestJournal("backdated repaying", "DLOAN", "DCASH", 400)
+	setEffectiveTime(backdated, effective)
+	assert.NoError(t, journalManager.PersistJournal(ctx, backdated))
+	postingDateManager := NewMySQLPostingDateManager(repo, 24*time.Hour)
+
+	assert.NoError(t, postingDateManager.CheckEffectiveTime(ctx, effective))
+	assert.ErrorIs(t, postingDateManager.CheckEffectiveTime(ctx, time.Now().Add(time.Hour)), hwerrors.ErrInvalidEffectiveTime)
+
+	journalIDs := func(basis connector.DateBasis, from, until time.Time) []string {
+		result, journals, err := postingDateManager.ListJournalsByDate(ctx, basis, from, until, acccore.PageRequest{PageNo: 1, ItemSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, len(journals), result.TotalEntries)
+		ids := make([]string, 0, len(journals))
+		for _, journal := range journals {
+			ids = append(ids, journal.GetJournalID())
+		}
+		return ids
+	}
+	hourAgo, inAnHour := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	assert.Equal(t, []string{current.GetJournalID()}, journalIDs(connector.EffectiveDate, hourAgo, inAnHour))
+	assert.Equal(t, []string{backdated.GetJournalID(), current.GetJournalID()}, journalIDs(connector.EffectiveDate, effective.Add(-time.Minute), inAnHour))
+	assert.ElementsMatch(t, []string{current.GetJournalID(), backdated.GetJournalID()}, journalIDs(connector.BookingDate, hourAgo, inAnHour))
+	assert.Empty(t, journalIDs(connector.BookingDate, effective.Add(-time.Minute), effective.Add(time.Minute)))
+	_, journals, err := journalManager.ListJournals(ctx, effective.Add(-time.Minute), effective.Add(time.Minute), acccore.PageRequest{PageNo: 1, ItemSize: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, journals, 1) {
+		assert.Equal(t, backdated.GetJournalID(), journals[0].GetJournalID())
+		assert.Len(t, journals[0].GetTransactions(), 2)
+	}
+
+	account, err := NewMySQLAccountManager(repo).GetAccountByID(ctx, "DCASH")
+	assert.NoError(t, err)
+	result, transactions, err := postingDateManager.ListTransactionsOnAccountByDate(ctx, connector.EffectiveDate, effective.Add(-time.Minute), inAnHour,
+		account, acccore.PageRequest{PageNo: 1, ItemSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.TotalEntries)
+	if assert.Len(t, transactions, 2) {
+		assert.Equal(t, backdated.GetJournalID(), transactions[0].GetJournalID())
+		assert.True(t, effective.Equal(transactions[0].GetTransactionTime()))
+		assert.Equal(t, current.GetJournalID(), transactions[1].GetJournalID())
+	}
+	result, _, err = postingDateManager.ListTransactionsOnAccountByDate(ctx, connector.BookingDate, effective.Add(-time.Minute), effective.Add(time.Minute),
+		account, acccore.PageRequest{PageNo: 1, ItemSize: 10})
+	assert.NoError(t, err)
+	assert.Zero(t, result.TotalEntries)
+
+	// the balance snapshots follow the booking order, the backdated journal does not break the integrity
+	report, err := NewMySQLIntegrityManager(repo).VerifyIntegrity(ctx)
+	assert.NoError(t, err)
+	assert.True(t, report.Consistent(), report.Discrepancies)
+}
+
+func TestMySQLReversalManager(t *testing.T) {
+	if testing.Short() {
+		t.Skip("reversals require a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"RCASH": acccore.DEBIT, "RLOAN": acccore.CREDIT})
+	journalManager := NewMySQLJournalManager(repo)
+	journal := makeTestJournal("borrowing", "RCASH", "RLOAN", 1000)
+	assert.NoError(t, journalManager.PersistJournal(ctx, journal))
+	journal, err := journalManager.GetJournalByID(ctx, journal.GetJournalID())
+	assert.NoError(t, err)
+	reversalManager := NewMySQLReversalManager(repo)
+	legs := make(map[string]string)
+	for _, trx := range journal.GetTransactions() {
+		legs[trx.GetAccountNumber()] = trx.GetTransactionID()
+	}
+
+	reversal, err := reversalManager.GetJournalReversal(ctx, journal)
+	assert.NoError(t, err)
+	partial, err := reversal.newReversalJournal(testIDGenerator, journal, map[string]int64{legs["RCASH"]: 300, legs["RLOAN"]: 300}, "partial", "TESTING", time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, journalManager.PersistJournal(ctx, partial))
+	reversed, err := journalManager.IsJournalIDReversed(ctx, journal.GetJournalID())
+	assert.NoError(t, err)
+	assert.False(t, reversed)
+
+	// built before the partial reversal was persisted, it is refused under the chain lock
+	stale, err := reversal.newReversalJournal(testIDGenerator, journal, map[string]int64{legs["RCASH"]: 800, legs["RLOAN"]: 800}, "stale", "TESTING", time.Now())
+	assert.NoError(t, err)
+	assert.ErrorIs(t, journalManager.PersistJournal(ctx, stale), hwerrors.ErrReversalExceedsOutstanding)
+
+	reversal, err = reversalManager.GetJournalReversal(ctx, journal)
+	assert.NoError(t, err)
+	assert.False(t, reversal.FullyReversed())
+	for _, leg := range reversal.Legs {
+		assert.Equal(t, int64(300), leg.Reversed)
+		assert.Equal(t, int64(700), leg.Outstanding())
+	}
+	full, err := reversal.newReversalJournal(testIDGenerator, journal, nil, "full", "TESTING", time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, journalManager.PersistJournal(ctx, full))
+	reversed, err = journalManager.IsJournalIDReversed(ctx, journal.GetJournalID())
+	assert.NoError(t, err)
+	assert.True(t, reversed)
+
+	again, err := reversal.newReversalJournal(testIDGenerator, journal, nil, "again", "TESTING", time.Now())
+	assert.NoError(t, err)
+	assert.ErrorIs(t, journalManager.PersistJournal(ctx, again), acccore.ErrJournalCanNotDoubleReverse)
+
+	for _, accountNumber := range []string{"RCASH", "RLOAN"} {
+		account, err := NewMySQLAccountManager(repo).GetAccountByID(ctx, accountNumber)
+		assert.NoError(t, err)
+		assert.Zero(t, account.GetBalance(), accountNumber)
+	}
+	rec, err := repo.GetJournalByReversalID(ctx, journal.GetJournalID())
+	assert.NoError(t, err)
+	if assert.NotNil(t, rec) {
+		assert.Equal(t, partial.GetJournalID(), rec.JournalID)
+	}
+}
+
+func TestMySQLJournalBatchManager(t *testing.T) {
+	if testing.Short() {
+		t.Skip("rolling back a batch requires a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	createTestAccounts(ctx, t, repo, "GOLD", map[string]acccore.Alignment{"BCASH": acccore.DEBIT, "BLOAN": acccore.CREDIT})
+	batchManager := NewMySQLJournalBatchManager(repo, 2)
+	balance := func(accountNumber string) int64 {
+		account, err := NewMySQLAccountManager(repo).GetAccountByID(ctx, accountNumber)
+		assert.NoError(t, err)
+		return account.GetBalance()
+	}
+
+	errs := batchManager.PersistJournals(ctx, []acccore.Journal{
+		makeTestJournal("borrowing", "BCASH", "BLOAN", 1000),
+		makeTestJournal("borrowing elsewhere", "BCASH", "BBANK", 1000),
+		makeTestJournal("repaying", "BLOAN", "BCASH", 300),
+		makeTestJournal("repaying again", "BLOAN", "BCASH", 100),
+	}, false)
+	if assert.Len(t, errs, 4) {
+		assert.NoError(t, errs[0])
+		assert.ErrorIs(t, errs[1], acccore.ErrJournalTransactionAccountNotPersist)
+		assert.NoError(t, errs[2])
+		assert.NoError(t, errs[3])
+	}
+	assert.Equal(t, int64(600), balance("BCASH"))
+
+	// the journals before the one refused are rolled back, the chunks after it are never read
+	posted := makeTestJournal("repaying", "BLOAN", "BCASH", 100)
+	errs = batchManager.PersistJournals(ctx, []acccore.Journal{
+		posted,
+		makeTestJournal("repaying more", "BLOAN", "BCASH", 100),
+		makeTestJournal("borrowing elsewhere", "BCASH", "BBANK", 1000),
+		makeTestJournal("repaying again", "BLOAN", "BCASH", 100),
+	}, true)
+	if assert.Len(t, errs, 4) {
+		assert.ErrorIs(t, errs[0], hwerrors.ErrJournalBatchNotPosted)
+		assert.ErrorIs(t, errs[1], hwerrors.ErrJournalBatchNotPosted)
+		assert.ErrorIs(t, errs[2], acccore.ErrJournalTransactionAccountNotPersist)
+		assert.ErrorIs(t, errs[3], hwerrors.ErrJournalBatchNotPosted)
+	}
+	assert.Equal(t, int64(600), balance("BCASH"))
+	exist, err := NewMySQLJournalManager(repo).IsJournalIDExist(ctx, posted.GetJournalID())
+	assert.NoError(t, err)
+	assert.False(t, exist)
+
+	errs = batchManager.PersistJournals(ctx, []acccore.Journal{
+		makeTestJournal("repaying", "BLOAN", "BCASH", 100),
+		makeTestJournal("repaying more", "BLOAN", "BCASH", 100),
+		makeTestJournal("repaying again", "BLOAN", "BCASH", 100),
+	}, true)
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int64(300), balance("BCASH"))
+	report, err := NewMySQLIntegrityManager(repo).VerifyIntegrity(ctx)
+	assert.NoError(t, err)
+	assert.True(t, report.Consistent(), report.Discrepancies)
+}
+
+func TestMySQLTemplateManager(t *testing.T) {
+	if testing.Short() {
+		t.Skip("journal templates require a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	templateManager := NewMySQLTemplateManager(repo, RoundHalfEven)
+	template := makeTestTemplate("p2p-fee")
+	template.Description = "Transfer <{note}> & more"
+	created, err := templateManager.CreateTemplate(ctx, template, "TESTING")
+	assert.NoError(t, err)
+	assert.Equal(t, "Transfer <{note}> & more", created.Description)
+	assert.Equal(t, template.Params, created.Params)
+	assert.Equal(t, template.Legs, created.Legs)
+	assert.Equal(t, "TESTING", created.CreatedBy)
+	_, err = templateManager.CreateTemplate(ctx, makeTestTemplate("p2p-fee"), "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrTemplateExists))
+	_, err = templateManager.CreateTemplate(ctx, makeTestTemplate("p2p fee"), "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidTemplate))
+	_, err = templateManager.CreateTemplate(ctx, makeTestTemplate("a-p2p-fee"), "TESTING")
+	assert.NoError(t, err)
+
+	templates, err := templateManager.ListTemplates(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, templates, 2)
+	assert.Equal(t, "a-p2p-fee", templates[0].Name)
+	assert.Equal(t, "p2p-fee", templates[1].Name)
+
+	request, err := templateManager.NewJournalRequest(ctx, "p2p-fee", map[string]string{"from": "ALICE", "to": "BOB", "amount": "1000", "fee": "1.5", "note": "rent"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Transfer <rent> & more", request.Description)
+	assert.Equal(t, int64(1015), request.Transactions[0].Amount)
+	assert.Equal(t, int64(15), request.Transactions[2].Amount)
+
+	assert.NoError(t, templateManager.DeleteTemplate(ctx, "p2p-fee"))
+	_, err = templateManager.GetTemplate(ctx, "p2p-fee")
+	assert.True(t, errors.Is(err, hwerrors.ErrTemplateNotFound))
+	assert.True(t, errors.Is(templateManager.DeleteTemplate(ctx, "p2p-fee"), hwerrors.ErrTemplateNotFound))
+}
+
+func TestMySQLScheduleManager(t *testing.T) {
+	if testing.Short() {
+		t.Skip("journal schedules require a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+
+	repo := connectTestRepository(ctx, t)
+	templateManager := NewMySQLTemplateManager(repo, RoundHalfEven)
+	_, err := templateManager.CreateTemplate(ctx, makeTestTemplate("p2p-fee"), "TESTING")
+	assert.NoError(t, err)
+	scheduleManager := NewMySQLScheduleManager(repo, templateManager, &acccore.RandomGenUniqueIDGenerator{Length: 16, UpperAlpha: true, Numeric: true})
+
+	// the first occurrence is the last hour but one, the second the last hour and the third the coming hour
+	start := time.Now().Truncate(time.Hour).Add(-90 * time.Minute)
+	schedule := makeTestSchedule("p2p-fee")
+	schedule.TimeZone = "Asia/Jakarta"
+	created, err := scheduleManager.CreateSchedule(ctx, schedule, start, "TESTING")
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Jakarta", created.TimeZone)
+	assert.Equal(t, schedule.Params, created.Params)
+	assert.True(t, start.Truncate(time.Hour).Add(time.Hour).Equal(created.NextRunAt))
+	_, err = scheduleManager.CreateSchedule(ctx, makeTestSchedule("none"), time.Time{}, "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrTemplateNotFound))
+	assert.True(t, errors.Is(templateManager.DeleteTemplate(ctx, "p2p-fee"), hwerrors.ErrTemplateInUse))
+
+	// a schedule leased to a replica is left alone by the others until the lease expires
+	now := time.Now()
+	claimed, err := scheduleManager.ClaimDueSchedules(ctx, "replica-a", now, time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	claimed2, err := scheduleManager.ClaimDueSchedules(ctx, "replica-b", now, time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed2, 0)
+	run, err := scheduleManager.StartRun(ctx, claimed[0], "replica-b")
+	assert.NoError(t, err)
+	assert.Nil(t, run)
+	run, err = scheduleManager.StartRun(ctx, claimed[0], "replica-a")
+	assert.NoError(t, err)
+	assert.True(t, created.NextRunAt.Equal(run.ScheduledAt))
+	assert.Equal(t, ScheduleRunRunning, run.Status)
+
+	// the lease expires while the run is posted, another replica takes the schedule but only its next occurrence
+	claimed2, err = scheduleManager.ClaimDueSchedules(ctx, "replica-b", now.Add(2*time.Minute), time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed2, 1)
+	assert.True(t, created.NextRunAt.Add(time.Hour).Equal(claimed2[0].NextRunAt))
+	run.Status, run.JournalID, run.FinishedAt = ScheduleRunPosted, "JOURNAL", time.Now()
+	assert.NoError(t, scheduleManager.FinishRun(ctx, run, "replica-a"))
+	run2, err := scheduleManager.StartRun(ctx, claimed2[0], "replica-b")
+	assert.NoError(t, err)
+	assert.True(t, created.NextRunAt.Add(time.Hour).Equal(run2.ScheduledAt))
+	run2.Status, run2.Error, run2.FinishedAt = ScheduleRunFailed, "refused", time.Now()
+	assert.NoError(t, scheduleManager.FinishRun(ctx, run2, "replica-b"))
+
+	pr, runs, err := scheduleManager.ListRuns(ctx, created.ScheduleID, acccore.PageRequest{PageNo: 1, ItemSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pr.TotalEntries)
+	assert.Equal(t, ScheduleRunFailed, runs[0].Status)
+	assert.Equal(t, "refused", runs[0].Error)
+	assert.Equal(t, "replica-b", runs[0].Runner)
+	assert.Equal(t, ScheduleRunPosted, runs[1].Status)
+	assert.Equal(t, "JOURNAL", runs[1].JournalID)
+	assert.False(t, runs[1].FinishedAt.IsZero())
+
+	// the next occurrence is in the future, nothing is due
+	claimed, err = scheduleManager.ClaimDueSchedules(ctx, "replica-a", time.Now(), time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 0)
+
+	schedules, err := scheduleManager.ListSchedules(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, schedules, 1)
+	assert.NoError(t, scheduleManager.DeleteSchedule(ctx, created.ScheduleID))
+	_, err = scheduleManager.GetSchedule(ctx, created.ScheduleID)
+	assert.True(t, errors.Is(err, hwerrors.ErrScheduleNotFound))
+	assert.True(t, errors.Is(scheduleManager.DeleteSchedule(ctx, created.ScheduleID), hwerrors.ErrScheduleNotFound))
+	assert.NoError(t, templateManager.DeleteTemplate(ctx, "p2p-fee"))
+}
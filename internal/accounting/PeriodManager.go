@@ -0,0 +1,267 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+)
+
+// PeriodFormat is the layout of an accounting period, a calendar month in UTC.
+const PeriodFormat = "2006-01"
+
+// PeriodStatus is the status of an accounting period.
+type PeriodStatus string
+
+const (
+	// PeriodOpen periods accept postings
+	PeriodOpen PeriodStatus = "OPEN"
+	// PeriodClosed periods refuse postings, closing a period also closes every period before it
+	PeriodClosed PeriodStatus = "CLOSED"
+)
+
+// Period is an accounting period, a calendar month in UTC.
+type Period struct {
+	// Period is the month written as YYYY-MM
+	Period string
+	Status PeriodStatus
+	// Start is the first instant of the period, End the first instant of the next one
+	Start time.Time
+	End   time.Time
+	// ClosingJournalIDs are the journals rolling the income and expenses balances into retained earnings, one per
+	// currency. Empty if the period was closed along with a later period, or had nothing to roll.
+	ClosingJournalIDs []string
+	// ClosedAt and ClosedBy are zero if the period is open, or was closed along with a later period
+	ClosedAt time.Time
+	ClosedBy string
+}
+
+// parsePeriod returns the open period written as YYYY-MM.
+func parsePeriod(period string) (*Period, error) {
+	start, err := time.Parse(PeriodFormat, period)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s should be written as YYYY-MM", hwerrors.ErrInvalidPeriod, period)
+	}
+	return &Period{Period: period, Status: PeriodOpen, Start: start, End: start.AddDate(0, 1, 0), ClosingJournalIDs: make([]string, 0)}, nil
+}
+
+// periodOf returns the period the time falls in.
+func periodOf(at time.Time) string {
+	return at.UTC().Format(PeriodFormat)
+}
+
+// checkPostingTime refuses postings made in a period up to the last closed period, which is empty if no period is closed.
+func checkPostingTime(lastClosed string, at time.Time) error {
+	if len(lastClosed) > 0 && periodOf(at) <= lastClosed {
+		return fmt.Errorf("%w : %s falls in period %s, periods up to %s are closed", hwerrors.ErrPeriodClosed,
+			at.UTC().Format(time.RFC3339), periodOf(at), lastClosed)
+	}
+	return nil
+}
+
+// earliestPostingTime returns the earliest of the journaling time and the times of the transactions of the journal.
+func earliestPostingTime(journal acccore.Journal) time.Time {
+	earliest := journal.GetJournalingTime()
+	for _, trx := range journal.GetTransactions() {
+		if trx.GetTransactionTime().Before(earliest) {
+			earliest = trx.GetTransactionTime()
+		}
+	}
+	return earliest
+}
+
+// checkPeriodClosing makes sure the period has ended and comes after the last closed period, which is empty if no period is closed.
+func checkPeriodClosing(period *Period, lastClosed string) error {
+	if period.End.After(time.Now()) {
+		return fmt.Errorf("%w : %s ends at %s", hwerrors.ErrPeriodNotEnded, period.Period, period.End.Format(time.RFC3339))
+	}
+	if len(lastClosed) > 0 && period.Period <= lastClosed {
+		return fmt.Errorf("%w : periods up to %s are closed", hwerrors.ErrPeriodClosed, lastClosed)
+	}
+	return nil
+}
+
+// newClosingJournals creates, for each currency of the trial balance at the end of the period, the journal bringing the
+// balance of every income and expenses account to zero against the retained earnings account of the currency.
+// The transactions are made at the first instant of the next period, so the period income statement still shows them.
+// Accounts whose COA is not in the chart of accounts are left out.
+func newClosingJournals(idGenerator acccore.UniqueIDGenerator, period *Period, tb *TrialBalance, coas []*COA, retainedEarnings map[string]string, author string) ([]acccore.Journal, error) {
+	nodes := coaNodes(coas)
+	description := fmt.Sprintf("Closing of period %s into retained earnings", period.Period)
+	journals := make([]acccore.Journal, 0, len(tb.Currencies))
+	for _, currency := range tb.Currencies {
+		journal := &acccore.BaseJournal{
+			JournalID:      idGenerator.NewUniqueID(),
+			JournalingTime: period.End,
+			Description:    description,
+			CreateTime:     time.Now(),
+			CreatedBy:      author,
+		}
+		transactions := make([]acccore.Transaction, 0)
+		addTransaction := func(accountNumber string, alignment acccore.Alignment, amount int64) {
+			transactions = append(transactions, &acccore.BaseTransaction{TransactionID: idGenerator.NewUniqueID(), TransactionTime: period.End,
+				AccountNumber: accountNumber, JournalID: journal.JournalID, Description: description, TransactionType: alignment,
+				Amount: amount, CreateTime: time.Now(), CreateBy: author})
+		}
+		// net is the debit less the credit of the closing transactions, the retained earnings take the other side
+		net := int64(0)
+		for _, group := range currency.COAs {
+			node, ok := nodes[group.Code]
+			if !ok || (node.Type != COAIncome && node.Type != COAExpense) {
+				continue
+			}
+			for _, account := range group.Accounts {
+				switch debit := account.Debit - account.Credit; {
+				case debit > 0:
+					addTransaction(account.AccountNumber, acccore.CREDIT, debit)
+					net -= debit
+				case debit < 0:
+					addTransaction(account.AccountNumber, acccore.DEBIT, -debit)
+					net -= debit
+				}
+			}
+		}
+		if len(transactions) == 0 {
+			continue
+		}
+		retainedAccount, ok := retainedEarnings[currency.Currency]
+		if !ok {
+			return nil, fmt.Errorf("%w : no retained earnings account in %s", hwerrors.ErrRetainedEarningsNotConfigured, currency.Currency)
+		}
+		switch {
+		case net > 0:
+			addTransaction(retainedAccount, acccore.CREDIT, net)
+		case net < 0:
+			addTransaction(retainedAccount, acccore.DEBIT, -net)
+		}
+		journal.SetTransactions(transactions)
+		journals = append(journals, journal)
+	}
+	return journals, nil
+}
+
+// PeriodManager closes the accounting periods. A period is a calendar month in UTC, closing it also closes every
+// period before it, and rolls the income and expenses balances into the retained earnings accounts.
+type PeriodManager interface {
+	// GetPeriod returns the accounting period, CLOSED if it is the last closed period or before it.
+	// Throws ErrInvalidPeriod if the period is not written as YYYY-MM.
+	GetPeriod(ctx context.Context, period string) (*Period, error)
+
+	// ListPeriods returns the periods that were closed on their own, in order.
+	ListPeriods(ctx context.Context) ([]*Period, error)
+
+	// ClosePeriod closes the period and every period before it, posting the closing journals made from the trial
+	// balance at the end of the period.
+	// Throws ErrInvalidPeriod if the period is not written as YYYY-MM, ErrPeriodNotEnded if it has not ended,
+	// ErrPeriodClosed if it is already closed, ErrRetainedEarningsNotConfigured if a currency with income or expenses
+	// has no retained earnings account, or the error of the journal manager if a closing journal is refused.
+	ClosePeriod(ctx context.Context, period, author string) (*Period, error)
+
+	// CheckPostingTime makes sure a posting made at the specified time does not fall in a closed period.
+	// Throws ErrPeriodClosed if it does.
+	CheckPostingTime(ctx context.Context, at time.Time) error
+}
+
+// NewInMemoryPeriodManager returns a period manager that keeps the closed periods in memory, reads the trial balance
+// and the chart of accounts from the report and COA managers, and posts the closing journals with the journal manager.
+func NewInMemoryPeriodManager(journalManager acccore.JournalManager, reportManager ReportManager, coaManager COAManager,
+	idGenerator acccore.UniqueIDGenerator, retainedEarnings map[string]string) PeriodManager {
+	return &InMemoryPeriodManager{
+		journalManager:   journalManager,
+		reportManager:    reportManager,
+		coaManager:       coaManager,
+		idGenerator:      idGenerator,
+		retainedEarnings: retainedEarnings,
+		periods:          make(map[string]*Period),
+	}
+}
+
+// InMemoryPeriodManager implementation of PeriodManager that keeps the closed periods in memory.
+// Suitable for testing, closed periods are lost when the application stops and the journal manager does not check them,
+// the in memory journal manager also dates every transaction at the time it is persisted.
+type InMemoryPeriodManager struct {
+	journalManager   acccore.JournalManager
+	reportManager    ReportManager
+	coaManager       COAManager
+	idGenerator      acccore.UniqueIDGenerator
+	retainedEarnings map[string]string
+	mutex            sync.Mutex
+	periods          map[string]*Period
+	lastClosed       string
+}
+
+// GetPeriod returns the accounting period, CLOSED if it is the last closed period or before it.
+func (im *InMemoryPeriodManager) GetPeriod(ctx context.Context, period string) (*Period, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	ret, err := parsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	if closed, ok := im.periods[period]; ok {
+		return closed, nil
+	}
+	if checkPostingTime(im.lastClosed, ret.Start) != nil {
+		ret.Status = PeriodClosed
+	}
+	return ret, nil
+}
+
+// ListPeriods returns the periods that were closed on their own, in order.
+func (im *InMemoryPeriodManager) ListPeriods(ctx context.Context) ([]*Period, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	ret := make([]*Period, 0, len(im.periods))
+	for _, period := range im.periods {
+		ret = append(ret, period)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Period < ret[j].Period
+	})
+	return ret, nil
+}
+
+// ClosePeriod closes the period and every period before it, posting the closing journals.
+func (im *InMemoryPeriodManager) ClosePeriod(ctx context.Context, period, author string) (*Period, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	ret, err := parsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkPeriodClosing(ret, im.lastClosed); err != nil {
+		return nil, err
+	}
+	tb, err := im.reportManager.GetTrialBalance(ctx, ret.End.Add(-time.Nanosecond), "")
+	if err != nil {
+		return nil, err
+	}
+	coas, err := im.coaManager.ListCOA(ctx)
+	if err != nil {
+		return nil, err
+	}
+	journals, err := newClosingJournals(im.idGenerator, ret, tb, coas, im.retainedEarnings, author)
+	if err != nil {
+		return nil, err
+	}
+	for _, journal := range journals {
+		if err = im.journalManager.PersistJournal(ctx, journal); err != nil {
+			return nil, err
+		}
+		ret.ClosingJournalIDs = append(ret.ClosingJournalIDs, journal.GetJournalID())
+	}
+	ret.Status, ret.ClosedAt, ret.ClosedBy = PeriodClosed, time.Now(), author
+	im.periods[period], im.lastClosed = ret, period
+	return ret, nil
+}
+
+// CheckPostingTime makes sure a posting made at the specified time does not fall in a closed period.
+func (im *InMemoryPeriodManager) CheckPostingTime(ctx context.Context, at time.Time) error {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	return checkPostingTime(im.lastClosed, at)
+}
@@ -0,0 +1,133 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodChecks(t *testing.T) {
+	period, err := parsePeriod("2021-02")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), period.Start)
+	assert.Equal(t, time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), period.End)
+	assert.Equal(t, PeriodOpen, period.Status)
+	for _, invalid := range []string{"", "2021-13", "2021-2", "2021-02-01", "February"} {
+		_, err = parsePeriod(invalid)
+		assert.ErrorIs(t, err, hwerrors.ErrInvalidPeriod, invalid)
+	}
+
+	// periods are calendar months in UTC
+	assert.Equal(t, "2021-01", periodOf(time.Date(2021, 2, 1, 6, 0, 0, 0, time.FixedZone("WIB", 7*60*60))))
+	assert.NoError(t, checkPostingTime("", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.ErrorIs(t, checkPostingTime("2021-02", time.Date(2021, 2, 28, 23, 59, 59, 0, time.UTC)), hwerrors.ErrPeriodClosed)
+	assert.ErrorIs(t, checkPostingTime("2021-02", time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)), hwerrors.ErrPeriodClosed)
+	assert.NoError(t, checkPostingTime("2021-02", period.End))
+
+	assert.NoError(t, checkPeriodClosing(period, ""))
+	assert.NoError(t, checkPeriodClosing(period, "2021-01"))
+	assert.ErrorIs(t, checkPeriodClosing(period, "2021-02"), hwerrors.ErrPeriodClosed)
+	current, err := parsePeriod(periodOf(time.Now()))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, checkPeriodClosing(current, ""), hwerrors.ErrPeriodNotEnded)
+}
+
+func TestNewClosingJournals(t *testing.T) {
+	period, err := parsePeriod("2021-01")
+	assert.NoError(t, err)
+	coas := []*COA{
+		{Code: "1", Name: "Assets", Type: COAAsset, Alignment: acccore.DEBIT},
+		{Code: "4", Name: "Income", Type: COAIncome, Alignment: acccore.CREDIT},
+		{Code: "5", Name: "Expenses", Type: COAExpense, Alignment: acccore.DEBIT},
+		{Code: "5.1", ParentCode: "5", Name: "Salaries", Type: COAExpense, Alignment: acccore.DEBIT},
+	}
+	tb := newTrialBalance(period.End, "", []*TrialBalanceAccount{
+		{AccountNumber: "CASH", COA: "1", Currency: "IDR", Alignment: acccore.DEBIT, Debit: 1000, Credit: 1300},
+		{AccountNumber: "SALES", COA: "4", Currency: "IDR", Alignment: acccore.CREDIT, Credit: 1000},
+		{AccountNumber: "SALARIES", COA: "5.1", Currency: "IDR", Alignment: acccore.DEBIT, Debit: 1300},
+		{AccountNumber: "IDLE", COA: "5", Currency: "IDR", Alignment: acccore.DEBIT, Debit: 50, Credit: 50},
+		{AccountNumber: "WALLET", COA: "1", Currency: "USD", Alignment: acccore.DEBIT, Debit: 5},
+	}, coaNodes(coas))
+
+	journals, err := newClosingJournals(testIDGenerator, period, tb, coas, map[string]string{"IDR": "RETAINED"}, "TESTING")
+	assert.NoError(t, err)
+	// nothing to close in USD
+	if assert.Len(t, journals, 1) {
+		journal := journals[0]
+		assert.Equal(t, "Closing of period 2021-01 into retained earnings", journal.GetDescription())
+		legs := make(map[string]acccore.Transaction)
+		for _, trx := range journal.GetTransactions() {
+			legs[trx.GetAccountNumber()] = trx
+			assert.Equal(t, period.End, trx.GetTransactionTime())
+		}
+		assert.Len(t, legs, 3)
+		assert.Equal(t, acccore.DEBIT, legs["SALES"].GetAlignment())
+		assert.Equal(t, int64(1000), legs["SALES"].GetAmount())
+		assert.Equal(t, acccore.CREDIT, legs["SALARIES"].GetAlignment())
+		assert.Equal(t, int64(1300), legs["SALARIES"].GetAmount())
+		// a loss is taken from the retained earnings
+		assert.Equal(t, acccore.DEBIT, legs["RETAINED"].GetAlignment())
+		assert.Equal(t, int64(300), legs["RETAINED"].GetAmount())
+	}
+
+	_, err = newClosingJournals(testIDGenerator, period, tb, coas, map[string]string{"USD": "RETAINED"}, "TESTING")
+	assert.ErrorIs(t, err, hwerrors.ErrRetainedEarningsNotConfigured)
+}
+
+func TestInMemoryPeriodManager(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	acccore.ClearInMemoryTables()
+	accountManager := &acccore.InMemoryAccountManager{}
+	for number, coa := range map[string]string{"CASH": "1", "SALES": "4", "RETAINED": "3"} {
+		account := &acccore.BaseAccount{}
+		account.SetAccountNumber(number).SetName(number).SetDescription(number + " test account").SetCOA(coa).
+			SetCurrency("IDR").SetAlignment(acccore.CREDIT).SetCreateBy("TESTING")
+		if number == "CASH" {
+			account.SetAlignment(acccore.DEBIT)
+		}
+		assert.NoError(t, accountManager.PersistAccount(ctx, account))
+	}
+	coaManager := NewInMemoryCOAManager(accountManager)
+	for _, coa := range []*COA{
+		{Code: "1", Name: "Assets", Type: COAAsset, Alignment: acccore.DEBIT},
+		{Code: "3", Name: "Equity", Type: COAEquity, Alignment: acccore.CREDIT},
+		{Code: "4", Name: "Income", Type: COAIncome, Alignment: acccore.CREDIT},
+	} {
+		_, err := coaManager.CreateCOA(ctx, coa, "TESTING")
+		assert.NoError(t, err)
+	}
+	journalManager := &acccore.InMemoryJournalManager{}
+	// the in memory journal manager dates the transactions when they are persisted, after the closed period
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("sale", "CASH", "SALES", 1000)))
+	reportManager := NewInMemoryReportManager(accountManager, &acccore.InMemoryTransactionManager{}, coaManager, NewInMemoryRateManager(RoundHalfEven))
+	periodManager := NewInMemoryPeriodManager(journalManager, reportManager, coaManager, testIDGenerator, map[string]string{"IDR": "RETAINED"})
+
+	assert.NoError(t, periodManager.CheckPostingTime(ctx, time.Date(2021, 1, 20, 0, 0, 0, 0, time.UTC)))
+	period, err := periodManager.ClosePeriod(ctx, "2021-01", "TESTING")
+	assert.NoError(t, err)
+	assert.Equal(t, PeriodClosed, period.Status)
+	assert.Equal(t, "TESTING", period.ClosedBy)
+	assert.Empty(t, period.ClosingJournalIDs)
+	retained, err := accountManager.GetAccountByID(ctx, "RETAINED")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), retained.GetBalance())
+
+	assert.ErrorIs(t, periodManager.CheckPostingTime(ctx, time.Date(2021, 1, 20, 0, 0, 0, 0, time.UTC)), hwerrors.ErrPeriodClosed)
+	_, err = periodManager.ClosePeriod(ctx, "2020-12", "TESTING")
+	assert.ErrorIs(t, err, hwerrors.ErrPeriodClosed)
+	period, err = periodManager.GetPeriod(ctx, "2020-12")
+	assert.NoError(t, err)
+	assert.Equal(t, PeriodClosed, period.Status)
+	assert.Empty(t, period.ClosingJournalIDs)
+	period, err = periodManager.GetPeriod(ctx, "2021-02")
+	assert.NoError(t, err)
+	assert.Equal(t, PeriodOpen, period.Status)
+	periods, err := periodManager.ListPeriods(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, periods, 1)
+}
@@ -0,0 +1,145 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/connector"
+)
+
+// ParseDateBasis returns the date basis journals and transactions are listed on, effective if empty.
+func ParseDateBasis(basis string) (connector.DateBasis, error) {
+	switch connector.DateBasis(strings.ToLower(strings.TrimSpace(basis))) {
+	case "", connector.EffectiveDate:
+		return connector.EffectiveDate, nil
+	case connector.BookingDate:
+		return connector.BookingDate, nil
+	}
+	return "", fmt.Errorf("%w : %s, should be one of effective or booking", hwerrors.ErrInvalidDateBasis, basis)
+}
+
+// checkEffectiveTime makes sure a journal booked now may take effect at the specified time, neither in the future
+// nor further in the past than the backdating window.
+func checkEffectiveTime(effective, now time.Time, backdatingWindow time.Duration) error {
+	if effective.After(now) {
+		return fmt.Errorf("%w : %s is in the future", hwerrors.ErrInvalidEffectiveTime, effective.Format(time.RFC3339))
+	}
+	if effective.Before(now.Add(-backdatingWindow)) {
+		return fmt.Errorf("%w : %s is more than %s in the past", hwerrors.ErrInvalidEffectiveTime, effective.Format(time.RFC3339), backdatingWindow)
+	}
+	return nil
+}
+
+// setEffectiveTime makes the journal and every one of its transactions take effect at the specified time.
+func setEffectiveTime(journal acccore.Journal, effective time.Time) {
+	journal.SetJournalingTime(effective)
+	for _, trx := range journal.GetTransactions() {
+		trx.SetTransactionTime(effective)
+	}
+}
+
+// dateOf returns the date of the journal or transaction selected by the basis.
+func dateOf(basis connector.DateBasis, effective, booking time.Time) time.Time {
+	if basis == connector.BookingDate {
+		return booking
+	}
+	return effective
+}
+
+// PostingDateManager checks the effective date of journals, and lists the journals and transactions on either their
+// effective or their booking date. The effective date is the journaling and transaction time, when the journal takes
+// effect on the balances, the booking date is the time the journal was recorded.
+type PostingDateManager interface {
+	// CheckEffectiveTime makes sure a journal booked now may take effect at the specified time.
+	// Throws ErrInvalidEffectiveTime if it is in the future or further in the past than the backdating window.
+	CheckEffectiveTime(ctx context.Context, effective time.Time) error
+
+	// ListJournalsByDate retrieves the journals whose date selected by the basis is between the `from` and `until`
+	// time range, sorted by that date. This function uses pagination.
+	ListJournalsByDate(ctx context.Context, basis connector.DateBasis, from, until time.Time, request acccore.PageRequest) (acccore.PageResult, []acccore.Journal, error)
+
+	// ListTransactionsOnAccountByDate retrieves the transactions of the account whose date selected by the basis is
+	// between the `from` and `until` time range, sorted by that date. This function uses pagination.
+	ListTransactionsOnAccountByDate(ctx context.Context, basis connector.DateBasis, from, until time.Time, account acccore.Account, request acccore.PageRequest) (acccore.PageResult, []acccore.Transaction, error)
+}
+
+// NewInMemoryPostingDateManager returns a posting date manager that reads the journals and transactions from the
+// specified managers, and accepts effective dates up to the backdating window in the past.
+func NewInMemoryPostingDateManager(journalManager acccore.JournalManager, transactionManager acccore.TransactionManager,
+	backdatingWindow time.Duration) PostingDateManager {
+	return &InMemoryPostingDateManager{
+		journalManager:     journalManager,
+		transactionManager: transactionManager,
+		backdatingWindow:   backdatingWindow,
+	}
+}
+
+// InMemoryPostingDateManager implementation of PostingDateManager on top of the in memory managers.
+// Suitable for testing, it reads every journal or every transaction of the account, and the in memory journal
+// manager dates every journal and transaction at the time it is persisted, they all take effect when booked.
+type InMemoryPostingDateManager struct {
+	journalManager     acccore.JournalManager
+	transactionManager acccore.TransactionManager
+	backdatingWindow   time.Duration
+}
+
+// CheckEffectiveTime makes sure a journal booked now may take effect at the specified time.
+func (im *InMemoryPostingDateManager) CheckEffectiveTime(ctx context.Context, effective time.Time) error {
+	return checkEffectiveTime(effective, time.Now(), im.backdatingWindow)
+}
+
+// ListJournalsByDate retrieves the journals whose date selected by the basis is within the time range.
+func (im *InMemoryPostingDateManager) ListJournalsByDate(ctx context.Context, basis connector.DateBasis, from, until time.Time, request acccore.PageRequest) (acccore.PageResult, []acccore.Journal, error) {
+	ever := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	result, journals, err := im.journalManager.ListJournals(ctx, time.Time{}, ever, acccore.PageRequest{PageNo: 1, ItemSize: 100})
+	if err != nil {
+		return acccore.PageResult{}, nil, err
+	}
+	if result.TotalEntries > len(journals) {
+		if _, journals, err = im.journalManager.ListJournals(ctx, time.Time{}, ever, acccore.PageRequest{PageNo: 1, ItemSize: result.TotalEntries}); err != nil {
+			return acccore.PageResult{}, nil, err
+		}
+	}
+	selected := make([]acccore.Journal, 0, len(journals))
+	for _, journal := range journals {
+		if date := dateOf(basis, journal.GetJournalingTime(), journal.GetCreateTime()); date.After(from) && date.Before(until) {
+			selected = append(selected, journal)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return dateOf(basis, selected[i].GetJournalingTime(), selected[i].GetCreateTime()).
+			Before(dateOf(basis, selected[j].GetJournalingTime(), selected[j].GetCreateTime()))
+	})
+	pageResult := acccore.PageResultFor(request, len(selected))
+	return pageResult, selected[pageResult.Offset : pageResult.Offset+pageResult.PageSize], nil
+}
+
+// ListTransactionsOnAccountByDate retrieves the transactions of the account whose date selected by the basis is within the time range.
+func (im *InMemoryPostingDateManager) ListTransactionsOnAccountByDate(ctx context.Context, basis connector.DateBasis, from, until time.Time, account acccore.Account, request acccore.PageRequest) (acccore.PageResult, []acccore.Transaction, error) {
+	result, transactions, err := im.transactionManager.ListTransactionsOnAccount(ctx, from, until, account, acccore.PageRequest{PageNo: 1, ItemSize: 100})
+	if err != nil {
+		return acccore.PageResult{}, nil, err
+	}
+	if result.TotalEntries > len(transactions) {
+		if _, transactions, err = im.transactionManager.ListTransactionsOnAccount(ctx, from, until, account, acccore.PageRequest{PageNo: 1, ItemSize: result.TotalEntries}); err != nil {
+			return acccore.PageResult{}, nil, err
+		}
+	}
+	selected := make([]acccore.Transaction, 0, len(transactions))
+	for _, trx := range transactions {
+		if date := dateOf(basis, trx.GetTransactionTime(), trx.GetCreateTime()); date.After(from) && date.Before(until) {
+			selected = append(selected, trx)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return dateOf(basis, selected[i].GetTransactionTime(), selected[i].GetCreateTime()).
+			Before(dateOf(basis, selected[j].GetTransactionTime(), selected[j].GetCreateTime()))
+	})
+	pageResult := acccore.PageResultFor(request, len(selected))
+	return pageResult, selected[pageResult.Offset : pageResult.Offset+pageResult.PageSize], nil
+}
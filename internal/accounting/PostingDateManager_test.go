@@ -0,0 +1,84 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/connector"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDateBasis(t *testing.T) {
+	for value, expected := range map[string]connector.DateBasis{"": connector.EffectiveDate, "effective": connector.EffectiveDate,
+		" Booking ": connector.BookingDate} {
+		basis, err := ParseDateBasis(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, basis, value)
+	}
+	_, err := ParseDateBasis("posting")
+	assert.ErrorIs(t, err, hwerrors.ErrInvalidDateBasis)
+}
+
+func TestCheckEffectiveTime(t *testing.T) {
+	now := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, checkEffectiveTime(now, now, 0))
+	assert.NoError(t, checkEffectiveTime(now.Add(-time.Hour), now, time.Hour))
+	assert.ErrorIs(t, checkEffectiveTime(now.Add(-time.Hour-time.Second), now, time.Hour), hwerrors.ErrInvalidEffectiveTime)
+	assert.ErrorIs(t, checkEffectiveTime(now.Add(time.Second), now, time.Hour), hwerrors.ErrInvalidEffectiveTime)
+}
+
+func TestInMemoryPostingDateManager(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	acccore.ClearInMemoryTables()
+	accountManager := &acccore.InMemoryAccountManager{}
+	for number, alignment := range map[string]acccore.Alignment{"CASH": acccore.DEBIT, "LOAN": acccore.CREDIT} {
+		account := &acccore.BaseAccount{}
+		account.SetAccountNumber(number).SetName(number).SetDescription(number + " test account").
+			SetCurrency("IDR").SetAlignment(alignment).SetCreateBy("TESTING")
+		assert.NoError(t, accountManager.PersistAccount(ctx, account))
+	}
+	journalManager := &acccore.InMemoryJournalManager{}
+	borrowing := makeTestJournal("borrowing", "CASH", "LOAN", 1000)
+	assert.NoError(t, journalManager.PersistJournal(ctx, borrowing))
+	repaying := makeTestJournal("repaying", "LOAN", "CASH", 400)
+	assert.NoError(t, journalManager.PersistJournal(ctx, repaying))
+	postingDateManager := NewInMemoryPostingDateManager(journalManager, &acccore.InMemoryTransactionManager{}, 24*time.Hour)
+
+	assert.NoError(t, postingDateManager.CheckEffectiveTime(ctx, time.Now().Add(-3*time.Hour)))
+	assert.ErrorIs(t, postingDateManager.CheckEffectiveTime(ctx, time.Now().Add(-25*time.Hour)), hwerrors.ErrInvalidEffectiveTime)
+
+	journalIDs := func(basis connector.DateBasis, from, until time.Time) []string {
+		result, journals, err := postingDateManager.ListJournalsByDate(ctx, basis, from, until, acccore.PageRequest{PageNo: 1, ItemSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, len(journals), result.TotalEntries)
+		ids := make([]string, 0, len(journals))
+		for _, journal := range journals {
+			ids = append(ids, journal.GetJournalID())
+		}
+		return ids
+	}
+	// the in memory journal manager dates the journals when they are persisted, both dates are now
+	minuteAgo, inAMinute := time.Now().Add(-time.Minute), time.Now().Add(time.Minute)
+	for _, basis := range []connector.DateBasis{connector.EffectiveDate, connector.BookingDate} {
+		assert.Equal(t, []string{borrowing.GetJournalID(), repaying.GetJournalID()}, journalIDs(basis, minuteAgo, inAMinute), basis)
+		assert.Empty(t, journalIDs(basis, minuteAgo.Add(-time.Hour), minuteAgo), basis)
+	}
+
+	account, err := accountManager.GetAccountByID(ctx, "CASH")
+	assert.NoError(t, err)
+	result, transactions, err := postingDateManager.ListTransactionsOnAccountByDate(ctx, connector.BookingDate, minuteAgo, inAMinute,
+		account, acccore.PageRequest{PageNo: 2, ItemSize: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.TotalEntries)
+	if assert.Len(t, transactions, 1) {
+		assert.Equal(t, repaying.GetJournalID(), transactions[0].GetJournalID())
+	}
+	result, _, err = postingDateManager.ListTransactionsOnAccountByDate(ctx, connector.EffectiveDate, minuteAgo.Add(-time.Hour), minuteAgo,
+		account, acccore.PageRequest{PageNo: 1, ItemSize: 10})
+	assert.NoError(t, err)
+	assert.Zero(t, result.TotalEntries)
+}
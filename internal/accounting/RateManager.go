@@ -0,0 +1,317 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/connector"
+)
+
+// RoundingMode decides how an exchanged amount that falls between two whole amounts is rounded.
+type RoundingMode string
+
+const (
+	// RoundHalfEven rounds to the nearest whole amount, a tie goes to the even amount
+	RoundHalfEven RoundingMode = "half-even"
+	// RoundHalfUp rounds to the nearest whole amount, a tie goes away from zero
+	RoundHalfUp RoundingMode = "half-up"
+	// RoundFloor rounds down to the whole amount toward negative infinity
+	RoundFloor RoundingMode = "floor"
+)
+
+// ParseRoundingMode returns the rounding mode named by mode.
+// Throws ErrInvalidRoundingMode if mode is not one of half-even, half-up or floor.
+func ParseRoundingMode(mode string) (RoundingMode, error) {
+	switch RoundingMode(strings.ToLower(strings.TrimSpace(mode))) {
+	case RoundHalfEven:
+		return RoundHalfEven, nil
+	case RoundHalfUp:
+		return RoundHalfUp, nil
+	case RoundFloor:
+		return RoundFloor, nil
+	}
+	return "", fmt.Errorf("%w : %s, should be one of half-even, half-up or floor", hwerrors.ErrInvalidRoundingMode, mode)
+}
+
+// Round rounds the exact value into a whole amount.
+func (mode RoundingMode) Round(exact *big.Rat) *big.Int {
+	// DivMod is an euclidean division, with a positive divisor the quotient is the floor of the value.
+	floor, remainder := new(big.Int).DivMod(exact.Num(), exact.Denom(), new(big.Int))
+	if mode == RoundFloor || remainder.Sign() == 0 {
+		return floor
+	}
+	ceil := new(big.Int).Add(floor, big.NewInt(1))
+	switch new(big.Int).Lsh(remainder, 1).Cmp(exact.Denom()) {
+	case -1:
+		return floor
+	case 1:
+		return ceil
+	}
+	if mode == RoundHalfUp {
+		if exact.Sign() < 0 {
+			return floor
+		}
+		return ceil
+	}
+	if floor.Bit(0) == 0 {
+		return floor
+	}
+	return ceil
+}
+
+// maxExchangeInteger bounds the integer part of an exchange unit, the currencies exchange column holds 36 digits.
+var maxExchangeInteger = new(big.Int).Exp(big.NewInt(10), big.NewInt(36-connector.MaxExchangeScale), nil)
+
+// ParseRate parses an exchange unit written as a decimal number, such as "0.123".
+// Throws ErrInvalidExchangeRate if it is not a positive number, or has more digits than the currencies exchange column can store.
+func ParseRate(rate string) (*big.Rat, error) {
+	ret, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || ret.Sign() <= 0 {
+		return nil, fmt.Errorf("%w : %s is not a positive number", hwerrors.ErrInvalidExchangeRate, rate)
+	}
+	scale, finite := decimalScale(ret)
+	if !finite || scale > connector.MaxExchangeScale {
+		return nil, fmt.Errorf("%w : %s has more than %d digits after the decimal point", hwerrors.ErrInvalidExchangeRate, rate, connector.MaxExchangeScale)
+	}
+	if new(big.Int).Quo(ret.Num(), ret.Denom()).Cmp(maxExchangeInteger) >= 0 {
+		return nil, fmt.Errorf("%w : %s is too large", hwerrors.ErrInvalidExchangeRate, rate)
+	}
+	return ret, nil
+}
+
+// FormatRat writes an exact number as a decimal, or as a fraction such as "1/3" when it has no finite decimal form.
+func FormatRat(r *big.Rat) string {
+	scale, finite := decimalScale(r)
+	if !finite {
+		return r.RatString()
+	}
+	return r.FloatString(scale)
+}
+
+// decimalScale returns the number of digits after the decimal point needed to write r exactly,
+// finite is false if r has no finite decimal form.
+func decimalScale(r *big.Rat) (scale int, finite bool) {
+	denom := new(big.Int).Set(r.Denom())
+	twos, fives := 0, 0
+	for denom.Bit(0) == 0 {
+		denom.Rsh(denom, 1)
+		twos++
+	}
+	five := big.NewInt(5)
+	remainder := new(big.Int)
+	for {
+		quotient, rem := new(big.Int).QuoRem(denom, five, remainder)
+		if rem.Sign() != 0 {
+			break
+		}
+		denom = quotient
+		fives++
+	}
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return 0, false
+	}
+	if twos > fives {
+		return twos, true
+	}
+	return fives, true
+}
+
+// CurrencyRate is a currency together with its exact exchange unit toward the common denominator.
+type CurrencyRate struct {
+	// Code is the currency short code
+	Code string
+	// Name is the textual name of the currency
+	Name string
+	// Exchange is the exchange unit of the currency toward the common denominator
+	Exchange *big.Rat
+	// CreatedAt is the time the currency is created
+	CreatedAt time.Time
+	// CreatedBy is the creator of the currency
+	CreatedBy string
+	// UpdatedAt is the time the currency is last updated
+	UpdatedAt time.Time
+	// UpdatedBy is the last updater of the currency
+	UpdatedBy string
+}
+
+// ExchangeResult is an amount exchanged into another currency.
+type ExchangeResult struct {
+	// Amount is the exchanged amount, rounded into a whole amount
+	Amount int64
+	// Exact is the exchanged amount before rounding
+	Exact *big.Rat
+	// Residue is what the rounding took away from the exact amount, Exact minus Amount
+	Residue *big.Rat
+	// Rounding is the rounding mode used
+	Rounding RoundingMode
+}
+
+// exchangeAmount multiplies the amount with the rate and rounds the product into a whole amount.
+// Throws ErrAmountOutOfRange if the rounded amount does not fit into int64.
+func exchangeAmount(amount int64, rate *big.Rat, rounding RoundingMode) (*ExchangeResult, error) {
+	exact := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)
+	rounded := rounding.Round(exact)
+	if !rounded.IsInt64() {
+		return nil, fmt.Errorf("%w : exchanged amount %s", hwerrors.ErrAmountOutOfRange, FormatRat(exact))
+	}
+	return &ExchangeResult{
+		Amount:   rounded.Int64(),
+		Exact:    exact,
+		Residue:  new(big.Rat).Sub(exact, new(big.Rat).SetInt(rounded)),
+		Rounding: rounding,
+	}, nil
+}
+
+// RateManager keeps the exchange units of the currencies as exact numbers. acccore.ExchangeManager only deals in
+// float64 exchange units and truncated amounts, so the currency and exchange rest endpoints go through this manager.
+// An exchange rate is the ratio between the exchange units of two currencies, it does not depend on the common denominator.
+type RateManager interface {
+	// ListCurrencyRates lists all currencies ordered by their code.
+	ListCurrencyRates(ctx context.Context) ([]*CurrencyRate, error)
+
+	// GetCurrencyRate retrieves the currency indicated by the code argument.
+	// Throws acccore.ErrCurrencyNotFound if there is no such currency.
+	GetCurrencyRate(ctx context.Context, code string) (*CurrencyRate, error)
+
+	// SetCurrencyRate creates the currency indicated by the code argument, or updates its name and exchange unit if it exists.
+	SetCurrencyRate(ctx context.Context, code, name string, exchange *big.Rat, author string) (*CurrencyRate, error)
+
+	// CalculateExactRate returns the exact rate for exchanging fromCurrency into toCurrency with the exchange units
+	// in effect at the specified time, or with the current ones if at is the zero time.
+	// Throws acccore.ErrCurrencyNotFound if any of the currency does not exist,
+	// or ErrNoEffectiveRate if any of the currency had no exchange unit at that time.
+	CalculateExactRate(ctx context.Context, fromCurrency, toCurrency string, at time.Time) (*big.Rat, error)
+
+	// CalculateExactExchange exchanges the amount of fromCurrency into toCurrency with the exchange units in effect
+	// at the specified time, or with the current ones if at is the zero time. The exchanged amount is rounded
+	// with the rounding mode, or with the configured rounding mode if rounding is empty.
+	// Throws acccore.ErrCurrencyNotFound if any of the currency does not exist,
+	// or ErrNoEffectiveRate if any of the currency had no exchange unit at that time.
+	CalculateExactExchange(ctx context.Context, fromCurrency, toCurrency string, amount int64, rounding RoundingMode, at time.Time) (*ExchangeResult, error)
+}
+
+// NewInMemoryRateManager returns a rate manager that keeps the currencies in memory,
+// rounding exchanged amounts with the specified rounding mode unless told otherwise.
+func NewInMemoryRateManager(rounding RoundingMode) RateManager {
+	return &InMemoryRateManager{
+		rounding:   rounding,
+		currencies: make(map[string]*CurrencyRate),
+		rates:      make(map[string][]*effectiveRate),
+	}
+}
+
+// effectiveRate is an exchange unit and the time since it is in effect.
+type effectiveRate struct {
+	exchange    *big.Rat
+	effectiveAt time.Time
+}
+
+// InMemoryRateManager implementation of RateManager that keeps currencies in memory.
+// Suitable for testing, currencies are lost when the application stops.
+type InMemoryRateManager struct {
+	rounding   RoundingMode
+	mutex      sync.Mutex
+	currencies map[string]*CurrencyRate
+	// rates are the exchange units each currency was given, oldest first
+	rates map[string][]*effectiveRate
+}
+
+// ListCurrencyRates lists all currencies ordered by their code.
+func (im *InMemoryRateManager) ListCurrencyRates(ctx context.Context) ([]*CurrencyRate, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	ret := make([]*CurrencyRate, 0, len(im.currencies))
+	for _, cur := range im.currencies {
+		ret = append(ret, cur)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Code < ret[j].Code
+	})
+	return ret, nil
+}
+
+// GetCurrencyRate retrieves the currency indicated by the code argument.
+// Throws acccore.ErrCurrencyNotFound if there is no such currency.
+func (im *InMemoryRateManager) GetCurrencyRate(ctx context.Context, code string) (*CurrencyRate, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	cur, ok := im.currencies[code]
+	if !ok {
+		return nil, acccore.ErrCurrencyNotFound
+	}
+	return cur, nil
+}
+
+// SetCurrencyRate creates the currency indicated by the code argument, or updates its name and exchange unit if it exists.
+func (im *InMemoryRateManager) SetCurrencyRate(ctx context.Context, code, name string, exchange *big.Rat, author string) (*CurrencyRate, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	now := time.Now()
+	cur := &CurrencyRate{Code: code, CreatedAt: now, CreatedBy: author}
+	if existing, ok := im.currencies[code]; ok {
+		cur.CreatedAt, cur.CreatedBy = existing.CreatedAt, existing.CreatedBy
+	}
+	cur.Name, cur.Exchange, cur.UpdatedAt, cur.UpdatedBy = name, new(big.Rat).Set(exchange), now, author
+	im.currencies[code] = cur
+	im.rates[code] = append(im.rates[code], &effectiveRate{exchange: cur.Exchange, effectiveAt: now})
+	return cur, nil
+}
+
+// rateAt returns the exchange unit of the currency in effect at the specified time, the current one if at is zero.
+func (im *InMemoryRateManager) rateAt(ctx context.Context, code string, at time.Time) (*big.Rat, error) {
+	cur, err := im.GetCurrencyRate(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if at.IsZero() {
+		return cur.Exchange, nil
+	}
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	rates := im.rates[code]
+	for i := len(rates) - 1; i >= 0; i-- {
+		if !rates[i].effectiveAt.After(at) {
+			return rates[i].exchange, nil
+		}
+	}
+	return nil, fmt.Errorf("%w : currency %s at %s", hwerrors.ErrNoEffectiveRate, code, at.Format(time.RFC3339))
+}
+
+// CalculateExactRate returns the exact rate for exchanging fromCurrency into toCurrency with the exchange units
+// in effect at the specified time, or with the current ones if at is the zero time.
+// Throws acccore.ErrCurrencyNotFound if any of the currency does not exist,
+// or ErrNoEffectiveRate if any of the currency had no exchange unit at that time.
+func (im *InMemoryRateManager) CalculateExactRate(ctx context.Context, fromCurrency, toCurrency string, at time.Time) (*big.Rat, error) {
+	from, err := im.rateAt(ctx, fromCurrency, at)
+	if err != nil {
+		return nil, err
+	}
+	to, err := im.rateAt(ctx, toCurrency, at)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Quo(to, from), nil
+}
+
+// CalculateExactExchange exchanges the amount of fromCurrency into toCurrency with the exchange units in effect
+// at the specified time, or with the current ones if at is the zero time. The exchanged amount is rounded
+// with the rounding mode, or with the configured rounding mode if rounding is empty.
+// Throws acccore.ErrCurrencyNotFound if any of the currency does not exist,
+// or ErrNoEffectiveRate if any of the currency had no exchange unit at that time.
+func (im *InMemoryRateManager) CalculateExactExchange(ctx context.Context, fromCurrency, toCurrency string, amount int64, rounding RoundingMode, at time.Time) (*ExchangeResult, error) {
+	rate, err := im.CalculateExactRate(ctx, fromCurrency, toCurrency, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(rounding) == 0 {
+		rounding = im.rounding
+	}
+	return exchangeAmount(amount, rate, rounding)
+}
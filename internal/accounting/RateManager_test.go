@@ -0,0 +1,99 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundingMode_Round(t *testing.T) {
+	testData := []struct {
+		exact    string
+		halfEven int64
+		halfUp   int64
+		floor    int64
+	}{
+		{"2", 2, 2, 2},
+		{"2.4", 2, 2, 2},
+		{"2.5", 2, 3, 2},
+		{"2.6", 3, 3, 2},
+		{"3.5", 4, 4, 3},
+		{"10/3", 3, 3, 3},
+		{"-2.4", -2, -2, -3},
+		{"-2.5", -2, -3, -3},
+		{"-3.5", -4, -4, -4},
+		{"-10/3", -3, -3, -4},
+	}
+	for _, td := range testData {
+		exact, _ := new(big.Rat).SetString(td.exact)
+		assert.Equal(t, td.halfEven, RoundHalfEven.Round(exact).Int64(), "half-even of %s", td.exact)
+		assert.Equal(t, td.halfUp, RoundHalfUp.Round(exact).Int64(), "half-up of %s", td.exact)
+		assert.Equal(t, td.floor, RoundFloor.Round(exact).Int64(), "floor of %s", td.exact)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for _, rate := range []string{"1", "0.3", " 12.5 ", "0.000000000000000001", "999999999999999999.999999999999999999"} {
+		_, err := ParseRate(rate)
+		assert.NoError(t, err, rate)
+	}
+	for _, rate := range []string{"", "abc", "0", "-1", "1/3", "0.0000000000000000001", "1000000000000000000"} {
+		_, err := ParseRate(rate)
+		assert.True(t, errors.Is(err, hwerrors.ErrInvalidExchangeRate), rate)
+	}
+}
+
+func TestFormatRat(t *testing.T) {
+	testData := map[string]string{
+		"10":     "10",
+		"0.1":    "0.1",
+		"1/8":    "0.125",
+		"-4.50":  "-4.5",
+		"10/3":   "10/3",
+		"-1/6":   "-1/6",
+		"0.0003": "0.0003",
+	}
+	for in, expect := range testData {
+		r, _ := new(big.Rat).SetString(in)
+		assert.Equal(t, expect, FormatRat(r), in)
+	}
+}
+
+func TestExchangeAmount_OutOfRange(t *testing.T) {
+	rate, _ := new(big.Rat).SetString("10")
+	_, err := exchangeAmount(1<<62, rate, RoundHalfEven)
+	assert.True(t, errors.Is(err, hwerrors.ErrAmountOutOfRange))
+}
+
+func TestInMemoryRateManager_RatesAt(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	rateManager := NewInMemoryRateManager(RoundHalfEven).(*InMemoryRateManager)
+	for _, exchange := range []string{"1", "2"} {
+		rate, err := ParseRate(exchange)
+		assert.NoError(t, err)
+		_, err = rateManager.SetCurrencyRate(ctx, "GOLD", "Gold", rate, "TESTING")
+		assert.NoError(t, err)
+	}
+	_, err := rateManager.SetCurrencyRate(ctx, "POINT", "Point", big.NewRat(10, 1), "TESTING")
+	assert.NoError(t, err)
+
+	// pretend the first rates of GOLD and POINT were set a day earlier
+	earlier := time.Now().Add(-24 * time.Hour)
+	rateManager.rates["GOLD"][0].effectiveAt = earlier
+	rateManager.rates["POINT"][0].effectiveAt = earlier
+
+	rate, err := rateManager.CalculateExactRate(ctx, "GOLD", "POINT", earlier.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "10", FormatRat(rate))
+	rate, err = rateManager.CalculateExactRate(ctx, "GOLD", "POINT", time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, "5", FormatRat(rate))
+	_, err = rateManager.CalculateExactRate(ctx, "GOLD", "POINT", earlier.Add(-time.Hour))
+	assert.True(t, errors.Is(err, hwerrors.ErrNoEffectiveRate))
+}
@@ -0,0 +1,488 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	"github.com/olekukonko/tablewriter"
+)
+
+// TrialBalanceAccount is the total debit and credit of the transactions of an account in a trial balance.
+type TrialBalanceAccount struct {
+	AccountNumber string
+	Name          string
+	COA           string
+	Currency      string
+	Alignment     acccore.Alignment
+	Debit         int64
+	Credit        int64
+}
+
+// Balance returns the balance the totals leave on the account, in the account alignment.
+func (a *TrialBalanceAccount) Balance() int64 {
+	if a.Alignment == acccore.DEBIT {
+		return a.Debit - a.Credit
+	}
+	return a.Credit - a.Debit
+}
+
+// TrialBalanceCOA groups the accounts of a trial balance having the same COA code.
+type TrialBalanceCOA struct {
+	// Code is the COA of the accounts
+	Code string
+	// Name is the name of the chart of accounts node, empty if the code is not in the chart of accounts
+	Name     string
+	Accounts []*TrialBalanceAccount
+	Debit    int64
+	Credit   int64
+}
+
+// TrialBalanceCurrency is the trial balance of the accounts in one currency.
+type TrialBalanceCurrency struct {
+	Currency string
+	COAs     []*TrialBalanceCOA
+	Debit    int64
+	Credit   int64
+}
+
+// Balanced tells if the total debit equals the total credit.
+func (c *TrialBalanceCurrency) Balanced() bool {
+	return c.Debit == c.Credit
+}
+
+// TrialBalance is the total debit and credit of every account as of a point in time, grouped by currency and COA.
+// Each currency must balance on its own, multi currency journals are balanced per currency by their clearing transactions.
+type TrialBalance struct {
+	At time.Time
+	// Currency is the only currency of the accounts, empty if the trial balance has all of them
+	Currency   string
+	Currencies []*TrialBalanceCurrency
+}
+
+// Balanced tells if the total debit equals the total credit in every currency.
+func (tb *TrialBalance) Balanced() bool {
+	for _, currency := range tb.Currencies {
+		if !currency.Balanced() {
+			return false
+		}
+	}
+	return true
+}
+
+// newTrialBalance groups the account totals by currency and COA, the chart of accounts nodes name the groups.
+func newTrialBalance(at time.Time, currency string, accounts []*TrialBalanceAccount, nodes map[string]*COA) *TrialBalance {
+	sort.SliceStable(accounts, func(i, j int) bool {
+		if accounts[i].Currency != accounts[j].Currency {
+			return accounts[i].Currency < accounts[j].Currency
+		}
+		if accounts[i].COA != accounts[j].COA {
+			return accounts[i].COA < accounts[j].COA
+		}
+		return accounts[i].AccountNumber < accounts[j].AccountNumber
+	})
+	tb := &TrialBalance{At: at, Currency: currency, Currencies: make([]*TrialBalanceCurrency, 0)}
+	var current *TrialBalanceCurrency
+	var group *TrialBalanceCOA
+	for _, account := range accounts {
+		if current == nil || current.Currency != account.Currency {
+			current = &TrialBalanceCurrency{Currency: account.Currency, COAs: make([]*TrialBalanceCOA, 0)}
+			tb.Currencies = append(tb.Currencies, current)
+			group = nil
+		}
+		if group == nil || group.Code != account.COA {
+			group = &TrialBalanceCOA{Code: account.COA, Accounts: make([]*TrialBalanceAccount, 0)}
+			if node, ok := nodes[account.COA]; ok {
+				group.Name = node.Name
+			}
+			current.COAs = append(current.COAs, group)
+		}
+		group.Accounts = append(group.Accounts, account)
+		group.Debit += account.Debit
+		group.Credit += account.Credit
+		current.Debit += account.Debit
+		current.Credit += account.Credit
+	}
+	return tb
+}
+
+// Render renders the trial balance into string for easy inspection, one table per currency.
+func (tb *TrialBalance) Render() string {
+	var buff bytes.Buffer
+	buff.WriteString(fmt.Sprintf("Trial Balance At : %s\n", tb.At.String()))
+	buff.WriteString(fmt.Sprintf("Balanced         : %t\n", tb.Balanced()))
+	for _, currency := range tb.Currencies {
+		buff.WriteString(fmt.Sprintf("\nCurrency         : %s\n", currency.Currency))
+		table := tablewriter.NewWriter(&buff)
+		table.SetHeader([]string{"COA", "Account", "Name", "DEBIT", "CREDIT", "BALANCE"})
+		table.SetFooter([]string{"", "", fmt.Sprintf("Balanced %t", currency.Balanced()), fmt.Sprintf("%d", currency.Debit), fmt.Sprintf("%d", currency.Credit), ""})
+		for _, group := range currency.COAs {
+			for _, account := range group.Accounts {
+				table.Append([]string{group.Code, account.AccountNumber, account.Name, fmt.Sprintf("%d", account.Debit), fmt.Sprintf("%d", account.Credit),
+					fmt.Sprintf("%d %s", account.Balance(), alignmentName(account.Alignment))})
+			}
+			table.Append([]string{group.Code, "", group.Name, fmt.Sprintf("%d", group.Debit), fmt.Sprintf("%d", group.Credit), ""})
+		}
+		table.Render()
+	}
+	return buff.String()
+}
+
+// WriteCSV writes the trial balance as comma separated values, one record per account and a total record per currency.
+func (tb *TrialBalance) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	records := [][]string{{"currency", "coa", "coa_name", "account_number", "account_name", "alignment", "debit", "credit", "balance"}}
+	for _, currency := range tb.Currencies {
+		for _, group := range currency.COAs {
+			for _, account := range group.Accounts {
+				records = append(records, []string{currency.Currency, group.Code, group.Name, account.AccountNumber, account.Name, alignmentName(account.Alignment),
+					strconv.FormatInt(account.Debit, 10), strconv.FormatInt(account.Credit, 10), strconv.FormatInt(account.Balance(), 10)})
+			}
+		}
+		records = append(records, []string{currency.Currency, "", "", "TOTAL", "", "",
+			strconv.FormatInt(currency.Debit, 10), strconv.FormatInt(currency.Credit, 10), strconv.FormatInt(currency.Debit-currency.Credit, 10)})
+	}
+	return writer.WriteAll(records)
+}
+
+var (
+	// balanceSheetTypes are the chart of accounts types of the balance sheet sections
+	balanceSheetTypes = []COAType{COAAsset, COALiability, COAEquity}
+	// incomeStatementTypes are the chart of accounts types of the income statement sections
+	incomeStatementTypes = []COAType{COAIncome, COAExpense}
+)
+
+// StatementLine is a chart of accounts node of a financial statement.
+type StatementLine struct {
+	Code       string
+	Name       string
+	ParentCode string
+	// Level is the depth of the node in the chart of accounts, 0 for a root
+	Level int
+	// Amount is the balance of the accounts under the node and its descendants, in the normal alignment of the node type
+	Amount int64
+}
+
+// StatementSection is the lines of a financial statement having one chart of accounts type, in chart order.
+type StatementSection struct {
+	Type  COAType
+	Lines []*StatementLine
+	// Total is the sum of the root lines
+	Total int64
+}
+
+// FinancialStatement is a balance sheet or an income statement of the accounts in one currency.
+// Accounts whose COA is not in the chart of accounts are left out.
+type FinancialStatement struct {
+	Currency string
+	Sections []*StatementSection
+	// NetIncome is the total income less the total expenses
+	NetIncome int64
+}
+
+// Total returns the total of the section of the type, 0 if the statement has no such section.
+func (s *FinancialStatement) Total(coaType COAType) int64 {
+	for _, section := range s.Sections {
+		if section.Type == coaType {
+			return section.Total
+		}
+	}
+	return 0
+}
+
+// Balanced tells if the assets equal the liabilities, the equity and the net income not yet closed into the equity.
+func (s *FinancialStatement) Balanced() bool {
+	return s.Total(COAAsset) == s.Total(COALiability)+s.Total(COAEquity)+s.NetIncome
+}
+
+// BalanceSheet is the assets, liabilities and equity as of a point in time, one statement per currency,
+// or a single statement in the reporting currency.
+type BalanceSheet struct {
+	At time.Time
+	// Currency is the only currency of the accounts, empty if the balance sheet has all of them
+	Currency string
+	// ReportingCurrency is the currency every amount is exchanged into, empty if the amounts are not exchanged
+	ReportingCurrency string
+	Statements        []*FinancialStatement
+}
+
+// Balanced tells if every statement of the balance sheet balances.
+// Amounts exchanged into the reporting currency are rounded, they may be off by the rounding.
+func (bs *BalanceSheet) Balanced() bool {
+	for _, statement := range bs.Statements {
+		if !statement.Balanced() {
+			return false
+		}
+	}
+	return true
+}
+
+// IncomeStatement is the income and expenses of the transactions made after From until Until, one statement
+// per currency, or a single statement in the reporting currency.
+type IncomeStatement struct {
+	From  time.Time
+	Until time.Time
+	// Currency is the only currency of the accounts, empty if the income statement has all of them
+	Currency string
+	// ReportingCurrency is the currency every amount is exchanged into, empty if the amounts are not exchanged
+	ReportingCurrency string
+	Statements        []*FinancialStatement
+}
+
+// coaAmounts returns, per currency, the balance of the accounts of each chart of accounts node in the normal alignment
+// of the node type. Accounts whose COA is not in the chart of accounts are left out.
+func coaAmounts(tb *TrialBalance, nodes map[string]*COA) map[string]map[string]int64 {
+	ret := make(map[string]map[string]int64)
+	for _, currency := range tb.Currencies {
+		amounts := make(map[string]int64)
+		for _, group := range currency.COAs {
+			node, ok := nodes[group.Code]
+			if !ok {
+				continue
+			}
+			if node.Type.NormalAlignment() == acccore.DEBIT {
+				amounts[group.Code] += group.Debit - group.Credit
+			} else {
+				amounts[group.Code] += group.Credit - group.Debit
+			}
+		}
+		ret[currency.Currency] = amounts
+	}
+	return ret
+}
+
+// newFinancialStatement builds the statement sections of the types from the amounts of the chart of accounts nodes,
+// rolling the amounts of the descendants up into their ancestors.
+func newFinancialStatement(currency string, coas []*COA, types []COAType, amounts map[string]int64) *FinancialStatement {
+	children := make(map[string][]*COA)
+	codes := make(map[string]bool, len(coas))
+	for _, coa := range coas {
+		codes[coa.Code] = true
+	}
+	roots := make([]*COA, 0)
+	for _, coa := range coas {
+		if len(coa.ParentCode) == 0 || !codes[coa.ParentCode] {
+			roots = append(roots, coa)
+		} else {
+			children[coa.ParentCode] = append(children[coa.ParentCode], coa)
+		}
+	}
+	statement := &FinancialStatement{Currency: currency, Sections: make([]*StatementSection, 0, len(types))}
+	for _, coa := range coas {
+		switch coa.Type {
+		case COAIncome:
+			statement.NetIncome += amounts[coa.Code]
+		case COAExpense:
+			statement.NetIncome -= amounts[coa.Code]
+		}
+	}
+	for _, coaType := range types {
+		section := &StatementSection{Type: coaType, Lines: make([]*StatementLine, 0)}
+		var visit func(coa *COA, level int) int64
+		visit = func(coa *COA, level int) int64 {
+			line := &StatementLine{Code: coa.Code, Name: coa.Name, ParentCode: coa.ParentCode, Level: level, Amount: amounts[coa.Code]}
+			section.Lines = append(section.Lines, line)
+			for _, child := range children[coa.Code] {
+				line.Amount += visit(child, level+1)
+			}
+			return line.Amount
+		}
+		for _, root := range roots {
+			if root.Type == coaType {
+				section.Total += visit(root, 0)
+			}
+		}
+		statement.Sections = append(statement.Sections, section)
+	}
+	return statement
+}
+
+// newFinancialStatements builds a statement per currency from the amounts of the chart of accounts nodes. If the reporting
+// currency is not empty, the amounts are exchanged into it with the exchange units in effect at the specified time
+// and summed into a single statement, so a report of a past time does not change with the later rates.
+func newFinancialStatements(ctx context.Context, coas []*COA, types []COAType, amounts map[string]map[string]int64,
+	reportingCurrency string, at time.Time, rateManager RateManager) ([]*FinancialStatement, error) {
+	if len(reportingCurrency) > 0 {
+		exchanged := make(map[string]int64)
+		for currency, currencyAmounts := range amounts {
+			for code, amount := range currencyAmounts {
+				if currency != reportingCurrency && amount != 0 {
+					exchanged, err := rateManager.CalculateExactExchange(ctx, currency, reportingCurrency, amount, "", at)
+					if err != nil {
+						return nil, err
+					}
+					amount = exchanged.Amount
+				}
+				exchanged[code] += amount
+			}
+		}
+		amounts = map[string]map[string]int64{reportingCurrency: exchanged}
+	}
+	currencies := make([]string, 0, len(amounts))
+	for currency := range amounts {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	ret := make([]*FinancialStatement, 0, len(currencies))
+	for _, currency := range currencies {
+		ret = append(ret, newFinancialStatement(currency, coas, types, amounts[currency]))
+	}
+	return ret, nil
+}
+
+// coaNodes returns the chart of accounts nodes by their code.
+func coaNodes(coas []*COA) map[string]*COA {
+	nodes := make(map[string]*COA, len(coas))
+	for _, coa := range coas {
+		nodes[coa.Code] = coa
+	}
+	return nodes
+}
+
+// newBalanceSheet builds the balance sheet from the trial balance at the time of the balance sheet.
+func newBalanceSheet(ctx context.Context, tb *TrialBalance, coas []*COA, reportingCurrency string, rateManager RateManager) (*BalanceSheet, error) {
+	statements, err := newFinancialStatements(ctx, coas, balanceSheetTypes, coaAmounts(tb, coaNodes(coas)), reportingCurrency, tb.At, rateManager)
+	if err != nil {
+		return nil, err
+	}
+	return &BalanceSheet{At: tb.At, Currency: tb.Currency, ReportingCurrency: reportingCurrency, Statements: statements}, nil
+}
+
+// newIncomeStatement builds the income statement from the difference between the trial balances at its from and until times.
+// The amounts are exchanged into the reporting currency with the exchange units in effect at the until time.
+func newIncomeStatement(ctx context.Context, from, until *TrialBalance, coas []*COA, reportingCurrency string, rateManager RateManager) (*IncomeStatement, error) {
+	nodes := coaNodes(coas)
+	amounts := coaAmounts(until, nodes)
+	for currency, fromAmounts := range coaAmounts(from, nodes) {
+		if _, ok := amounts[currency]; !ok {
+			amounts[currency] = make(map[string]int64)
+		}
+		for code, amount := range fromAmounts {
+			amounts[currency][code] -= amount
+		}
+	}
+	statements, err := newFinancialStatements(ctx, coas, incomeStatementTypes, amounts, reportingCurrency, until.At, rateManager)
+	if err != nil {
+		return nil, err
+	}
+	return &IncomeStatement{From: from.At, Until: until.At, Currency: until.Currency, ReportingCurrency: reportingCurrency, Statements: statements}, nil
+}
+
+// ReportManager produces the reports of the ledger.
+type ReportManager interface {
+	// GetTrialBalance returns the total debit and credit of the transactions of every account made at or before the specified time,
+	// grouped by currency and COA. Only the accounts in the currency are included if it is not empty.
+	GetTrialBalance(ctx context.Context, at time.Time, currency string) (*TrialBalance, error)
+
+	// GetBalanceSheet returns the balance sheet of the transactions made at or before the specified time, the balances of
+	// the assets, liabilities and equity accounts rolled up the chart of accounts. Only the accounts in the currency are
+	// included if it is not empty, and the amounts are exchanged into the reporting currency if it is not empty.
+	GetBalanceSheet(ctx context.Context, at time.Time, currency, reportingCurrency string) (*BalanceSheet, error)
+
+	// GetIncomeStatement returns the income statement of the transactions made after from until the until time, the balances
+	// of the income and expenses accounts rolled up the chart of accounts. Only the accounts in the currency are
+	// included if it is not empty, and the amounts are exchanged into the reporting currency if it is not empty.
+	GetIncomeStatement(ctx context.Context, from, until time.Time, currency, reportingCurrency string) (*IncomeStatement, error)
+}
+
+// NewInMemoryReportManager returns a report manager that reads the accounts, transactions and chart of accounts
+// from the specified managers, and exchanges the amounts with the exchange units of the rate manager.
+func NewInMemoryReportManager(accountManager acccore.AccountManager, transactionManager acccore.TransactionManager, coaManager COAManager,
+	rateManager RateManager) ReportManager {
+	return &InMemoryReportManager{
+		accountManager:     accountManager,
+		transactionManager: transactionManager,
+		coaManager:         coaManager,
+		rateManager:        rateManager,
+	}
+}
+
+// InMemoryReportManager implementation of ReportManager on top of the in memory managers.
+// Suitable for testing, it reads every transaction of every account.
+type InMemoryReportManager struct {
+	accountManager     acccore.AccountManager
+	transactionManager acccore.TransactionManager
+	coaManager         COAManager
+	rateManager        RateManager
+}
+
+// GetTrialBalance returns the total debit and credit of the transactions of every account made at or before the specified time.
+func (im *InMemoryReportManager) GetTrialBalance(ctx context.Context, at time.Time, currency string) (*TrialBalance, error) {
+	result, accounts, err := im.accountManager.ListAccounts(ctx, acccore.PageRequest{PageNo: 1, ItemSize: 100})
+	if err != nil {
+		return nil, err
+	}
+	if result.TotalEntries > len(accounts) {
+		if _, accounts, err = im.accountManager.ListAccounts(ctx, acccore.PageRequest{PageNo: 1, ItemSize: result.TotalEntries}); err != nil {
+			return nil, err
+		}
+	}
+	totals := make([]*TrialBalanceAccount, 0, len(accounts))
+	for _, account := range accounts {
+		if len(currency) > 0 && account.GetCurrency() != currency {
+			continue
+		}
+		total := &TrialBalanceAccount{AccountNumber: account.GetAccountNumber(), Name: account.GetName(), COA: account.GetCOA(),
+			Currency: account.GetCurrency(), Alignment: account.GetAlignment()}
+		result, transactions, err := im.transactionManager.ListTransactionsOnAccount(ctx, time.Time{}, at, account, acccore.PageRequest{PageNo: 1, ItemSize: 100})
+		if err != nil {
+			return nil, err
+		}
+		if result.TotalEntries > len(transactions) {
+			if _, transactions, err = im.transactionManager.ListTransactionsOnAccount(ctx, time.Time{}, at, account, acccore.PageRequest{PageNo: 1, ItemSize: result.TotalEntries}); err != nil {
+				return nil, err
+			}
+		}
+		for _, transaction := range transactions {
+			switch {
+			case transaction.GetTransactionTime().After(at):
+			case transaction.GetAlignment() == acccore.DEBIT:
+				total.Debit += transaction.GetAmount()
+			default:
+				total.Credit += transaction.GetAmount()
+			}
+		}
+		totals = append(totals, total)
+	}
+	coas, err := im.coaManager.ListCOA(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newTrialBalance(at, currency, totals, coaNodes(coas)), nil
+}
+
+// GetBalanceSheet returns the balance sheet of the transactions made at or before the specified time.
+func (im *InMemoryReportManager) GetBalanceSheet(ctx context.Context, at time.Time, currency, reportingCurrency string) (*BalanceSheet, error) {
+	tb, err := im.GetTrialBalance(ctx, at, currency)
+	if err != nil {
+		return nil, err
+	}
+	coas, err := im.coaManager.ListCOA(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newBalanceSheet(ctx, tb, coas, reportingCurrency, im.rateManager)
+}
+
+// GetIncomeStatement returns the income statement of the transactions made after from until the until time.
+func (im *InMemoryReportManager) GetIncomeStatement(ctx context.Context, from, until time.Time, currency, reportingCurrency string) (*IncomeStatement, error) {
+	fromTB, err := im.GetTrialBalance(ctx, from, currency)
+	if err != nil {
+		return nil, err
+	}
+	untilTB, err := im.GetTrialBalance(ctx, until, currency)
+	if err != nil {
+		return nil, err
+	}
+	coas, err := im.coaManager.ListCOA(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newIncomeStatement(ctx, fromTB, untilTB, coas, reportingCurrency, im.rateManager)
+}
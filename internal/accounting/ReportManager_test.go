@@ -0,0 +1,212 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTrialBalance(t *testing.T) {
+	at := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	tb := newTrialBalance(at, "", []*TrialBalanceAccount{
+		{AccountNumber: "LOAN", Name: "Loan", COA: "2", Currency: "IDR", Alignment: acccore.CREDIT, Debit: 100, Credit: 1000},
+		{AccountNumber: "WALLET", Name: "Wallet", COA: "1.1", Currency: "USD", Alignment: acccore.DEBIT, Debit: 5},
+		{AccountNumber: "CASH", Name: "Cash", COA: "1.1", Currency: "IDR", Alignment: acccore.DEBIT, Debit: 1000, Credit: 100},
+		{AccountNumber: "BANK", Name: "Bank", COA: "1.1", Currency: "IDR", Alignment: acccore.DEBIT},
+	}, map[string]*COA{"1.1": {Code: "1.1", Name: "Cash & Bank"}})
+
+	if assert.Len(t, tb.Currencies, 2) {
+		idr := tb.Currencies[0]
+		assert.Equal(t, "IDR", idr.Currency)
+		assert.Equal(t, int64(1100), idr.Debit)
+		assert.Equal(t, int64(1100), idr.Credit)
+		assert.True(t, idr.Balanced())
+		if assert.Len(t, idr.COAs, 2) {
+			assert.Equal(t, "Cash & Bank", idr.COAs[0].Name)
+			assert.Equal(t, "BANK", idr.COAs[0].Accounts[0].AccountNumber)
+			assert.Equal(t, int64(900), idr.COAs[0].Accounts[1].Balance())
+			assert.Equal(t, "", idr.COAs[1].Name)
+			assert.Equal(t, int64(900), idr.COAs[1].Accounts[0].Balance())
+		}
+		assert.False(t, tb.Currencies[1].Balanced())
+	}
+	assert.False(t, tb.Balanced())
+
+	var buff bytes.Buffer
+	assert.NoError(t, tb.WriteCSV(&buff))
+	assert.Equal(t, `currency,coa,coa_name,account_number,account_name,alignment,debit,credit,balance
+IDR,1.1,Cash & Bank,BANK,Bank,DEBIT,0,0,0
+IDR,1.1,Cash & Bank,CASH,Cash,DEBIT,1000,100,900
+IDR,2,,LOAN,Loan,CREDIT,100,1000,900
+IDR,,,TOTAL,,,1100,1100,0
+USD,1.1,Cash & Bank,WALLET,Wallet,DEBIT,5,0,5
+USD,,,TOTAL,,,5,0,5
+`, buff.String())
+	assert.Contains(t, tb.Render(), "Balanced         : false")
+}
+
+// pagedTransactionManager honours the page size the acccore in memory manager ignores.
+type pagedTransactionManager struct {
+	acccore.TransactionManager
+}
+
+func (tm pagedTransactionManager) ListTransactionsOnAccount(ctx context.Context, from, until time.Time, account acccore.Account, request acccore.PageRequest) (acccore.PageResult, []acccore.Transaction, error) {
+	result, transactions, err := tm.TransactionManager.ListTransactionsOnAccount(ctx, from, until, account, request)
+	if err == nil && len(transactions) > request.ItemSize {
+		transactions = transactions[:request.ItemSize]
+	}
+	return result, transactions, err
+}
+
+func TestInMemoryReportManager(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	acccore.ClearInMemoryTables()
+	accountManager := &acccore.InMemoryAccountManager{}
+	for number, alignment := range map[string]acccore.Alignment{"CASH": acccore.DEBIT, "LOAN": acccore.CREDIT} {
+		account := &acccore.BaseAccount{}
+		account.SetAccountNumber(number).SetName(number).SetDescription(number + " test account").SetCOA("1.1").
+			SetCurrency("IDR").SetAlignment(alignment).SetCreateBy("TESTING")
+		assert.NoError(t, accountManager.PersistAccount(ctx, account))
+	}
+	journalManager := &acccore.InMemoryJournalManager{}
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("borrowing", "CASH", "LOAN", 1000)))
+	before := time.Now()
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("repaying", "LOAN", "CASH", 400)))
+	// more transactions than a single page of the account listing
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("installment", "LOAN", "CASH", 1)))
+	}
+	reportManager := NewInMemoryReportManager(accountManager, pagedTransactionManager{&acccore.InMemoryTransactionManager{}}, NewInMemoryCOAManager(accountManager), NewInMemoryRateManager(RoundHalfEven))
+
+	tb, err := reportManager.GetTrialBalance(ctx, time.Now(), "")
+	assert.NoError(t, err)
+	assert.True(t, tb.Balanced())
+	if assert.Len(t, tb.Currencies, 1) && assert.Len(t, tb.Currencies[0].COAs, 1) {
+		assert.Equal(t, int64(1500), tb.Currencies[0].Debit)
+		assert.Equal(t, []*TrialBalanceAccount{
+			{AccountNumber: "CASH", Name: "CASH", COA: "1.1", Currency: "IDR", Alignment: acccore.DEBIT, Debit: 1000, Credit: 500},
+			{AccountNumber: "LOAN", Name: "LOAN", COA: "1.1", Currency: "IDR", Alignment: acccore.CREDIT, Debit: 500, Credit: 1000},
+		}, tb.Currencies[0].COAs[0].Accounts)
+	}
+
+	tb, err = reportManager.GetTrialBalance(ctx, before, "IDR")
+	assert.NoError(t, err)
+	if assert.Len(t, tb.Currencies, 1) {
+		assert.Equal(t, int64(1000), tb.Currencies[0].Debit)
+		assert.Equal(t, int64(1000), tb.Currencies[0].Credit)
+	}
+	tb, err = reportManager.GetTrialBalance(ctx, time.Now(), "USD")
+	assert.NoError(t, err)
+	assert.Empty(t, tb.Currencies)
+}
+
+func TestNewFinancialStatements(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	rateManager := NewInMemoryRateManager(RoundHalfEven)
+	_, err := rateManager.SetCurrencyRate(ctx, "IDR", "Rupiah", big.NewRat(1, 1), "TESTING")
+	assert.NoError(t, err)
+	_, err = rateManager.SetCurrencyRate(ctx, "USD", "Dollar", big.NewRat(1, 10), "TESTING")
+	assert.NoError(t, err)
+	// the rates were in effect since 2020, the dollar unit halved after the reports
+	for _, rates := range rateManager.(*InMemoryRateManager).rates {
+		rates[0].effectiveAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	_, err = rateManager.SetCurrencyRate(ctx, "USD", "Dollar", big.NewRat(1, 20), "TESTING")
+	assert.NoError(t, err)
+	coas := []*COA{
+		{Code: "1", Name: "Assets", Type: COAAsset, Alignment: acccore.DEBIT},
+		{Code: "1.1", ParentCode: "1", Name: "Cash", Type: COAAsset, Alignment: acccore.DEBIT},
+		{Code: "1.2", ParentCode: "1", Name: "Accumulated Depreciation", Type: COAAsset, Alignment: acccore.CREDIT},
+		{Code: "2", Name: "Liabilities", Type: COALiability, Alignment: acccore.CREDIT},
+		{Code: "4", Name: "Income", Type: COAIncome, Alignment: acccore.CREDIT},
+		{Code: "5", Name: "Expenses", Type: COAExpense, Alignment: acccore.DEBIT},
+	}
+	trialBalance := func(at time.Time, accounts ...*TrialBalanceAccount) *TrialBalance {
+		return newTrialBalance(at, "", accounts, coaNodes(coas))
+	}
+	from := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	fromTB := trialBalance(from,
+		&TrialBalanceAccount{AccountNumber: "CASH", COA: "1.1", Currency: "IDR", Alignment: acccore.DEBIT, Debit: 1000},
+		&TrialBalanceAccount{AccountNumber: "LOAN", COA: "2", Currency: "IDR", Alignment: acccore.CREDIT, Credit: 1000})
+	untilTB := trialBalance(until,
+		&TrialBalanceAccount{AccountNumber: "CASH", COA: "1.1", Currency: "IDR", Alignment: acccore.DEBIT, Debit: 1500, Credit: 50},
+		&TrialBalanceAccount{AccountNumber: "DEPRECIATION", COA: "1.2", Currency: "IDR", Alignment: acccore.CREDIT, Credit: 100},
+		&TrialBalanceAccount{AccountNumber: "LOAN", COA: "2", Currency: "IDR", Alignment: acccore.CREDIT, Credit: 1000},
+		&TrialBalanceAccount{AccountNumber: "SALES", COA: "4", Currency: "IDR", Alignment: acccore.CREDIT, Credit: 500},
+		&TrialBalanceAccount{AccountNumber: "COSTS", COA: "5", Currency: "IDR", Alignment: acccore.DEBIT, Debit: 150},
+		&TrialBalanceAccount{AccountNumber: "OUTSIDE", COA: "9", Currency: "IDR", Alignment: acccore.DEBIT, Debit: 7},
+		&TrialBalanceAccount{AccountNumber: "WALLET", COA: "1.1", Currency: "USD", Alignment: acccore.DEBIT, Debit: 20},
+		&TrialBalanceAccount{AccountNumber: "USDSALES", COA: "4", Currency: "USD", Alignment: acccore.CREDIT, Credit: 20})
+
+	bs, err := newBalanceSheet(ctx, untilTB, coas, "", rateManager)
+	assert.NoError(t, err)
+	assert.True(t, bs.Balanced())
+	if assert.Len(t, bs.Statements, 2) {
+		idr := bs.Statements[0]
+		assert.Equal(t, "IDR", idr.Currency)
+		assert.Equal(t, int64(350), idr.NetIncome)
+		if assert.Len(t, idr.Sections, 3) {
+			// the contra asset is subtracted, the account outside the chart is left out
+			assert.Equal(t, []*StatementLine{
+				{Code: "1", Name: "Assets", Amount: 1350},
+				{Code: "1.1", Name: "Cash", ParentCode: "1", Level: 1, Amount: 1450},
+				{Code: "1.2", Name: "Accumulated Depreciation", ParentCode: "1", Level: 1, Amount: -100},
+			}, idr.Sections[0].Lines)
+			assert.Equal(t, int64(1350), idr.Sections[0].Total)
+			assert.Equal(t, int64(1000), idr.Total(COALiability))
+			assert.Empty(t, idr.Sections[2].Lines)
+		}
+		assert.Equal(t, int64(20), bs.Statements[1].Total(COAAsset))
+	}
+
+	bs, err = newBalanceSheet(ctx, untilTB, coas, "IDR", rateManager)
+	assert.NoError(t, err)
+	if assert.Len(t, bs.Statements, 1) {
+		assert.Equal(t, "IDR", bs.Statements[0].Currency)
+		assert.Equal(t, int64(1550), bs.Statements[0].Total(COAAsset))
+		assert.Equal(t, int64(550), bs.Statements[0].NetIncome)
+	}
+	_, err = newBalanceSheet(ctx, untilTB, coas, "EUR", rateManager)
+	assert.Equal(t, acccore.ErrCurrencyNotFound, err)
+
+	is, err := newIncomeStatement(ctx, fromTB, untilTB, coas, "", rateManager)
+	assert.NoError(t, err)
+	assert.Equal(t, from, is.From)
+	if assert.Len(t, is.Statements, 2) && assert.Len(t, is.Statements[0].Sections, 2) {
+		assert.Equal(t, int64(500), is.Statements[0].Total(COAIncome))
+		assert.Equal(t, int64(150), is.Statements[0].Total(COAExpense))
+		assert.Equal(t, int64(350), is.Statements[0].NetIncome)
+		assert.Equal(t, int64(20), is.Statements[1].NetIncome)
+	}
+	is, err = newIncomeStatement(ctx, fromTB, untilTB, coas, "IDR", rateManager)
+	assert.NoError(t, err)
+	if assert.Len(t, is.Statements, 1) {
+		assert.Equal(t, int64(550), is.Statements[0].NetIncome)
+	}
+
+	// a currency only in the trial balance at the from time
+	fromTB = trialBalance(from, &TrialBalanceAccount{AccountNumber: "USDSALES", COA: "4", Currency: "USD", Alignment: acccore.CREDIT, Credit: 5})
+	untilTB = trialBalance(until, &TrialBalanceAccount{AccountNumber: "SALES", COA: "4", Currency: "IDR", Alignment: acccore.CREDIT, Credit: 500})
+	is, err = newIncomeStatement(ctx, fromTB, untilTB, coas, "", rateManager)
+	assert.NoError(t, err)
+	if assert.Len(t, is.Statements, 2) {
+		assert.Equal(t, int64(-5), is.Statements[1].NetIncome)
+	}
+
+	// no exchange unit was in effect before 2020
+	_, err = newBalanceSheet(ctx, trialBalance(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)), coas, "IDR", rateManager)
+	assert.NoError(t, err, "nothing to exchange")
+	_, err = newBalanceSheet(ctx, trialBalance(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		&TrialBalanceAccount{AccountNumber: "WALLET", COA: "1.1", Currency: "USD", Alignment: acccore.DEBIT, Debit: 20}), coas, "IDR", rateManager)
+	assert.True(t, errors.Is(err, hwerrors.ErrNoEffectiveRate))
+}
@@ -0,0 +1,191 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+)
+
+// ReversalLeg is a transaction of a journal with the amount the reversal journals of the journal took back.
+type ReversalLeg struct {
+	TransactionID string
+	AccountNumber string
+	Alignment     acccore.Alignment
+	Amount        int64
+	// Reversed is the sum of the amount of the reversal transactions on the account of the leg
+	Reversed int64
+}
+
+// Outstanding returns the amount of the leg left to reverse.
+func (l *ReversalLeg) Outstanding() int64 {
+	return l.Amount - l.Reversed
+}
+
+// JournalReversal tells how much of every leg of a journal was reversed. A journal may be reversed in full at once,
+// or in part by several reversal journals each taking back some amount of some legs.
+type JournalReversal struct {
+	JournalID string
+	Legs      []*ReversalLeg
+}
+
+// FullyReversed tells if nothing of the journal is left to reverse.
+func (r *JournalReversal) FullyReversed() bool {
+	for _, leg := range r.Legs {
+		if leg.Outstanding() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// newJournalReversal returns the reversal status of the journal made of the transactions, reversed is the amount
+// taken back per account. A journal has a single leg on an account, so the account tells which leg was reversed.
+func newJournalReversal(journalID string, transactions []acccore.Transaction, reversed map[string]int64) *JournalReversal {
+	ret := &JournalReversal{JournalID: journalID, Legs: make([]*ReversalLeg, 0, len(transactions))}
+	for _, trx := range transactions {
+		ret.Legs = append(ret.Legs, &ReversalLeg{
+			TransactionID: trx.GetTransactionID(),
+			AccountNumber: trx.GetAccountNumber(),
+			Alignment:     trx.GetAlignment(),
+			Amount:        trx.GetAmount(),
+			Reversed:      reversed[trx.GetAccountNumber()],
+		})
+	}
+	return ret
+}
+
+// checkReversal makes sure every transaction of the reversal journal takes back a positive amount, at most the
+// outstanding amount, of a leg of the journal on the same account with the opposite alignment.
+// Throws acccore.ErrJournalCanNotDoubleReverse if the journal is fully reversed, ErrInvalidReversal if a transaction
+// does not mirror a leg, or ErrReversalExceedsOutstanding if it takes back more than is left of its leg.
+func (r *JournalReversal) checkReversal(reversal acccore.Journal) error {
+	if r.FullyReversed() {
+		return fmt.Errorf("%w : journal %s is fully reversed", acccore.ErrJournalCanNotDoubleReverse, r.JournalID)
+	}
+	legs := make(map[string]*ReversalLeg, len(r.Legs))
+	for _, leg := range r.Legs {
+		legs[leg.AccountNumber] = leg
+	}
+	for _, trx := range reversal.GetTransactions() {
+		leg, ok := legs[trx.GetAccountNumber()]
+		if !ok || leg.Alignment == trx.GetAlignment() {
+			return fmt.Errorf("%w : journal %s has no leg on account %s with the opposite alignment", hwerrors.ErrInvalidReversal, r.JournalID,
+				trx.GetAccountNumber())
+		}
+		if trx.GetAmount() <= 0 {
+			return fmt.Errorf("%w : the reversal of transaction %s should be positive", hwerrors.ErrInvalidReversal, leg.TransactionID)
+		}
+		if trx.GetAmount() > leg.Outstanding() {
+			return fmt.Errorf("%w : %d of transaction %s is left to reverse, not %d", hwerrors.ErrReversalExceedsOutstanding,
+				leg.Outstanding(), leg.TransactionID, trx.GetAmount())
+		}
+	}
+	return nil
+}
+
+// newReversalJournal creates the journal taking back the outstanding amount of every leg of the journal, or for a
+// partial reversal, the amounts specified per transaction ID, leaving out the legs without an amount.
+// The reversal takes effect at the specified time. Throws the errors of checkReversal.
+func (r *JournalReversal) newReversalJournal(idGenerator acccore.UniqueIDGenerator, reversed acccore.Journal, amounts map[string]int64,
+	description, author string, effective time.Time) (acccore.Journal, error) {
+	journal := &acccore.BaseJournal{
+		JournalID:       idGenerator.NewUniqueID(),
+		JournalingTime:  effective,
+		Reversal:        true,
+		ReversedJournal: reversed,
+		Description:     description,
+		CreatedBy:       author,
+		CreateTime:      time.Now(),
+	}
+	descriptions := make(map[string]string)
+	for _, trx := range reversed.GetTransactions() {
+		descriptions[trx.GetTransactionID()] = trx.GetDescription()
+	}
+	legs := make(map[string]bool, len(r.Legs))
+	transactions := make([]acccore.Transaction, 0, len(r.Legs))
+	for _, leg := range r.Legs {
+		legs[leg.TransactionID] = true
+		amount := leg.Outstanding()
+		if amounts != nil {
+			var ok bool
+			if amount, ok = amounts[leg.TransactionID]; !ok {
+				continue
+			}
+		} else if amount == 0 {
+			continue
+		}
+		transactions = append(transactions, &acccore.BaseTransaction{
+			TransactionID:   idGenerator.NewUniqueID(),
+			TransactionTime: effective,
+			AccountNumber:   leg.AccountNumber,
+			JournalID:       journal.JournalID,
+			Description:     fmt.Sprintf("%s - reversed", descriptions[leg.TransactionID]),
+			TransactionType: oppositeAlignment(leg.Alignment),
+			Amount:          amount,
+			CreateTime:      time.Now(),
+			CreateBy:        author,
+		})
+	}
+	for transactionID := range amounts {
+		if !legs[transactionID] {
+			return nil, fmt.Errorf("%w : transaction %s is not part of journal %s", hwerrors.ErrInvalidReversal, transactionID, r.JournalID)
+		}
+	}
+	journal.SetTransactions(transactions)
+	if err := r.checkReversal(journal); err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+// oppositeAlignment returns CREDIT for DEBIT and DEBIT for CREDIT.
+func oppositeAlignment(alignment acccore.Alignment) acccore.Alignment {
+	if alignment == acccore.DEBIT {
+		return acccore.CREDIT
+	}
+	return acccore.DEBIT
+}
+
+// ReversalManager tracks how much of every journal its reversal journals took back.
+type ReversalManager interface {
+	// GetJournalReversal returns how much of every leg of the journal was reversed.
+	GetJournalReversal(ctx context.Context, journal acccore.Journal) (*JournalReversal, error)
+}
+
+// NewInMemoryReversalManager returns a reversal manager that reads the reversal journals from the journal manager.
+func NewInMemoryReversalManager(journalManager acccore.JournalManager) ReversalManager {
+	return &InMemoryReversalManager{journalManager: journalManager}
+}
+
+// InMemoryReversalManager implementation of ReversalManager on top of the in memory journal manager.
+// Suitable for testing, it reads every journal to find the reversal journals.
+type InMemoryReversalManager struct {
+	journalManager acccore.JournalManager
+}
+
+// GetJournalReversal returns how much of every leg of the journal was reversed.
+func (im *InMemoryReversalManager) GetJournalReversal(ctx context.Context, journal acccore.Journal) (*JournalReversal, error) {
+	ever := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	result, journals, err := im.journalManager.ListJournals(ctx, time.Time{}, ever, acccore.PageRequest{PageNo: 1, ItemSize: 100})
+	if err != nil {
+		return nil, err
+	}
+	if result.TotalEntries > len(journals) {
+		if _, journals, err = im.journalManager.ListJournals(ctx, time.Time{}, ever, acccore.PageRequest{PageNo: 1, ItemSize: result.TotalEntries}); err != nil {
+			return nil, err
+		}
+	}
+	reversed := make(map[string]int64)
+	for _, reversal := range journals {
+		if reversal.GetReversedJournal() == nil || reversal.GetReversedJournal().GetJournalID() != journal.GetJournalID() {
+			continue
+		}
+		for _, trx := range reversal.GetTransactions() {
+			reversed[trx.GetAccountNumber()] += trx.GetAmount()
+		}
+	}
+	return newJournalReversal(journal.GetJournalID(), journal.GetTransactions(), reversed), nil
+}
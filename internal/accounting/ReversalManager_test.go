@@ -0,0 +1,87 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReversalJournal(t *testing.T) {
+	journal := makeTestJournal("borrowing", "CASH", "LOAN", 1000)
+	debit, credit := journal.GetTransactions()[0], journal.GetTransactions()[1]
+	effective := time.Now().Add(-time.Hour)
+
+	reversal := newJournalReversal(journal.GetJournalID(), journal.GetTransactions(), map[string]int64{"CASH": 300, "LOAN": 300})
+	assert.False(t, reversal.FullyReversed())
+	full, err := reversal.newReversalJournal(testIDGenerator, journal, nil, "reversing", "TESTING", effective)
+	assert.NoError(t, err)
+	assert.True(t, full.IsReversal())
+	assert.Equal(t, journal.GetJournalID(), full.GetReversedJournal().GetJournalID())
+	assert.True(t, effective.Equal(full.GetJournalingTime()))
+	if assert.Len(t, full.GetTransactions(), 2) {
+		for _, trx := range full.GetTransactions() {
+			assert.Equal(t, int64(700), trx.GetAmount())
+			assert.True(t, effective.Equal(trx.GetTransactionTime()))
+			assert.Equal(t, full.GetJournalID(), trx.GetJournalID())
+		}
+		assert.Equal(t, "CASH", full.GetTransactions()[0].GetAccountNumber())
+		assert.Equal(t, acccore.CREDIT, full.GetTransactions()[0].GetAlignment())
+		assert.Equal(t, "debit leg - reversed", full.GetTransactions()[0].GetDescription())
+		assert.Equal(t, acccore.DEBIT, full.GetTransactions()[1].GetAlignment())
+	}
+
+	partial, err := reversal.newReversalJournal(testIDGenerator, journal, map[string]int64{debit.GetTransactionID(): 200, credit.GetTransactionID(): 200},
+		"reversing", "TESTING", effective)
+	assert.NoError(t, err)
+	if assert.Len(t, partial.GetTransactions(), 2) {
+		assert.Equal(t, int64(200), partial.GetTransactions()[0].GetAmount())
+	}
+	_, err = reversal.newReversalJournal(testIDGenerator, journal, map[string]int64{debit.GetTransactionID(): 800, credit.GetTransactionID(): 800},
+		"reversing", "TESTING", effective)
+	assert.ErrorIs(t, err, hwerrors.ErrReversalExceedsOutstanding)
+	_, err = reversal.newReversalJournal(testIDGenerator, journal, map[string]int64{debit.GetTransactionID(): -1}, "reversing", "TESTING", effective)
+	assert.ErrorIs(t, err, hwerrors.ErrInvalidReversal)
+	_, err = reversal.newReversalJournal(testIDGenerator, journal, map[string]int64{"UNKNOWN": 100}, "reversing", "TESTING", effective)
+	assert.ErrorIs(t, err, hwerrors.ErrInvalidReversal)
+
+	// a transaction on the account of a leg with the same alignment does not reverse it
+	sameSide := makeTestJournal("not a reversal", "CASH", "LOAN", 100)
+	assert.ErrorIs(t, reversal.checkReversal(sameSide), hwerrors.ErrInvalidReversal)
+
+	reversed := newJournalReversal(journal.GetJournalID(), journal.GetTransactions(), map[string]int64{"CASH": 1000, "LOAN": 1000})
+	assert.True(t, reversed.FullyReversed())
+	_, err = reversed.newReversalJournal(testIDGenerator, journal, nil, "reversing", "TESTING", effective)
+	assert.ErrorIs(t, err, acccore.ErrJournalCanNotDoubleReverse)
+}
+
+func TestInMemoryReversalManager(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	acccore.ClearInMemoryTables()
+	accountManager := &acccore.InMemoryAccountManager{}
+	for number, alignment := range map[string]acccore.Alignment{"CASH": acccore.DEBIT, "LOAN": acccore.CREDIT} {
+		account := &acccore.BaseAccount{}
+		account.SetAccountNumber(number).SetName(number).SetDescription(number + " test account").
+			SetCurrency("IDR").SetAlignment(alignment).SetCreateBy("TESTING")
+		assert.NoError(t, accountManager.PersistAccount(ctx, account))
+	}
+	journalManager := &acccore.InMemoryJournalManager{}
+	journal := makeTestJournal("borrowing", "CASH", "LOAN", 1000)
+	assert.NoError(t, journalManager.PersistJournal(ctx, journal))
+	assert.NoError(t, journalManager.PersistJournal(ctx, makeTestJournal("repaying", "LOAN", "CASH", 400)))
+
+	// the in memory journal manager refuses reversals, the journal is never reversed
+	reversal, err := NewInMemoryReversalManager(journalManager).GetJournalReversal(ctx, journal)
+	assert.NoError(t, err)
+	assert.Equal(t, journal.GetJournalID(), reversal.JournalID)
+	assert.False(t, reversal.FullyReversed())
+	if assert.Len(t, reversal.Legs, 2) {
+		for _, leg := range reversal.Legs {
+			assert.Equal(t, int64(1000), leg.Outstanding())
+		}
+	}
+}
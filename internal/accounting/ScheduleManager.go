@@ -0,0 +1,483 @@
+package accounting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+)
+
+// cronField is a field of a cron expression and the values it may take.
+type cronField struct {
+	name     string
+	min, max int
+}
+
+// cronFields are the fields of a cron expression in order. A day of week of 7 is sunday, like 0.
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7},
+}
+
+// cronShortcuts are the cron expressions written as a single word.
+var cronShortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSearchLimit is how far ahead the next occurrence of a cron expression is searched for.
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// CronExpression is a parsed cron expression, the minutes, hours, days of month, months and days of week it matches,
+// every field a bit set of its values.
+type CronExpression struct {
+	fields [5]uint64
+	// anyDayOfMonth and anyDayOfWeek tell if the day fields are *, when both are restricted a day matching either
+	// of them matches
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+// ParseCron parses a cron expression of five fields, minute hour day-of-month month day-of-week, every field being
+// *, a number, a range like 1-5, a list like 1,15 or any of them with a step like */15. It may also be one of
+// @yearly, @monthly, @weekly, @daily or @hourly.
+// Throws ErrInvalidSchedule if the expression is not well written.
+func ParseCron(expr string) (*CronExpression, error) {
+	expr = strings.TrimSpace(expr)
+	if shortcut, ok := cronShortcuts[strings.ToLower(expr)]; ok {
+		expr = shortcut
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("%w : cron expression %s should have %d fields", hwerrors.ErrInvalidSchedule, expr, len(cronFields))
+	}
+	ret := &CronExpression{
+		anyDayOfMonth: strings.HasPrefix(parts[2], "*"),
+		anyDayOfWeek:  strings.HasPrefix(parts[4], "*"),
+	}
+	for i, field := range cronFields {
+		bits, err := parseCronField(parts[i], field)
+		if err != nil {
+			return nil, err
+		}
+		ret.fields[i] = bits
+	}
+	// sunday is both 0 and 7
+	if ret.fields[4]&(1<<7) != 0 {
+		ret.fields[4] = ret.fields[4]&^(1<<7) | 1
+	}
+	return ret, nil
+}
+
+// parseCronField parses a field of a cron expression into the bit set of its values.
+func parseCronField(value string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		lo, hi, step := field.min, field.max, 1
+		valueRange := part
+		if i := strings.Index(part, "/"); i >= 0 {
+			valueRange = part[:i]
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%w : step of %s %s should be a positive number", hwerrors.ErrInvalidSchedule, field.name, part)
+			}
+			step = n
+		}
+		if valueRange != "*" {
+			bounds := strings.SplitN(valueRange, "-", 2)
+			n, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf("%w : %s %s should be a number", hwerrors.ErrInvalidSchedule, field.name, part)
+			}
+			lo = n
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("%w : %s %s should be a number", hwerrors.ErrInvalidSchedule, field.name, part)
+				}
+			} else if step == 1 {
+				hi = lo
+			}
+		}
+		if lo < field.min || hi > field.max || lo > hi {
+			return 0, fmt.Errorf("%w : %s %s should be within %d and %d", hwerrors.ErrInvalidSchedule, field.name, part, field.min, field.max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// matches tells if the value is one of the values of the field at index i.
+func (c *CronExpression) matches(i, value int) bool {
+	return c.fields[i]&(1<<uint(value)) != 0
+}
+
+// matchesDay tells if the day matches the day of month and day of week fields.
+func (c *CronExpression) matchesDay(t time.Time) bool {
+	dayOfMonth, dayOfWeek := c.matches(2, t.Day()), c.matches(4, int(t.Weekday()))
+	if c.anyDayOfMonth || c.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// Next returns the first time the expression matches strictly after t, read in the location of t.
+// It returns the zero time if the expression does not match within the next five years, such as on February 30th.
+func (c *CronExpression) Next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.Add(cronSearchLimit)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for t.Before(limit) {
+		switch {
+		case !c.matches(3, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !c.matches(1, t.Hour()):
+			// added rather than set, so the hours repeated or skipped when daylight saving changes are handled
+			t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+		case !c.matches(0, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ScheduleRunStatus is the status of a run of a journal schedule.
+type ScheduleRunStatus string
+
+const (
+	// ScheduleRunRunning runs are started and their journal is being posted. A run left RUNNING by a replica that
+	// stopped meanwhile is never posted again, the journal may or may not have been posted.
+	ScheduleRunRunning ScheduleRunStatus = "RUNNING"
+	// ScheduleRunPosted runs posted their journal
+	ScheduleRunPosted ScheduleRunStatus = "POSTED"
+	// ScheduleRunFailed runs were refused, their journal is not posted
+	ScheduleRunFailed ScheduleRunStatus = "FAILED"
+)
+
+// Schedule posts a journal template with the same parameter values on the times of a cron expression.
+type Schedule struct {
+	ScheduleID   string
+	TemplateName string
+	// Cron is the cron expression of the times the template is posted
+	Cron string
+	// TimeZone is the IANA time zone the cron expression is read in, UTC if empty
+	TimeZone string
+	// Params are the values of the template parameters
+	Params map[string]string
+	// NextRunAt is the next occurrence of the schedule
+	NextRunAt time.Time
+	CreatedAt time.Time
+	// CreatedBy is the author of the schedule, who the journals it posts are created by
+	CreatedBy string
+}
+
+// next returns the first occurrence of the schedule strictly after t.
+// Throws ErrInvalidSchedule if the cron expression or the time zone are not valid, or the expression never matches.
+func (s *Schedule) next(t time.Time) (time.Time, error) {
+	cron, err := ParseCron(s.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w : unknown time zone %s", hwerrors.ErrInvalidSchedule, s.TimeZone)
+	}
+	next := cron.Next(t.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w : cron expression %s never matches", hwerrors.ErrInvalidSchedule, s.Cron)
+	}
+	return next.UTC(), nil
+}
+
+// ScheduleRun is the posting of an occurrence of a journal schedule.
+type ScheduleRun struct {
+	RunID      string
+	ScheduleID string
+	// ScheduledAt is the occurrence of the schedule, the effective time of the journal posted
+	ScheduledAt time.Time
+	StartedAt   time.Time
+	// FinishedAt is zero while the run is RUNNING
+	FinishedAt time.Time
+	Status     ScheduleRunStatus
+	// JournalID is the journal the run posts
+	JournalID string
+	// Error is why a FAILED run was refused
+	Error string
+	// Runner is the replica that started the run
+	Runner string
+}
+
+// prepareSchedule checks the schedule is valid and posts a template that takes its parameter values, and sets its
+// first occurrence after start.
+// Throws ErrInvalidSchedule if the cron expression or time zone are not valid, ErrTemplateNotFound if the template
+// does not exist, or ErrInvalidTemplateParams if the parameter values are not valid.
+func prepareSchedule(ctx context.Context, templateManager TemplateManager, schedule *Schedule, start time.Time) (*Schedule, error) {
+	prepared := *schedule
+	if len(prepared.TimeZone) == 0 {
+		prepared.TimeZone = "UTC"
+	}
+	if prepared.Params == nil {
+		prepared.Params = make(map[string]string)
+	}
+	next, err := prepared.next(start)
+	if err != nil {
+		return nil, err
+	}
+	prepared.NextRunAt = next
+	if _, err = templateManager.NewJournalRequest(ctx, prepared.TemplateName, prepared.Params); err != nil {
+		return nil, err
+	}
+	return &prepared, nil
+}
+
+// ScheduleManager keeps the journal schedules and the history of their runs, and leases the due schedules to the
+// replicas posting them so an occurrence is posted once at most.
+type ScheduleManager interface {
+	// CreateSchedule creates the schedule and returns it with its first occurrence after start, or after now if start
+	// is zero. A start in the past makes the schedule catch up the occurrences since then.
+	// Throws ErrInvalidSchedule if the cron expression or time zone are not valid, ErrTemplateNotFound if the
+	// template does not exist, or ErrInvalidTemplateParams if the parameter values are not valid.
+	CreateSchedule(ctx context.Context, schedule *Schedule, start time.Time, author string) (*Schedule, error)
+
+	// GetSchedule returns the schedule.
+	// Throws ErrScheduleNotFound if the schedule does not exist.
+	GetSchedule(ctx context.Context, scheduleID string) (*Schedule, error)
+
+	// ListSchedules returns every schedule sorted by creation time.
+	ListSchedules(ctx context.Context) ([]*Schedule, error)
+
+	// DeleteSchedule deletes the schedule and its run history, the journals it posted are left as they are.
+	// Throws ErrScheduleNotFound if the schedule does not exist.
+	DeleteSchedule(ctx context.Context, scheduleID string) error
+
+	// ListRuns lists the runs of the schedule in paginated fashion, latest occurrence first.
+	// Throws ErrScheduleNotFound if the schedule does not exist.
+	ListRuns(ctx context.Context, scheduleID string, request acccore.PageRequest) (acccore.PageResult, []*ScheduleRun, error)
+
+	// ClaimDueSchedules leases to the owner, for the lease duration, at most limit schedules due at the specified
+	// time that are released or whose lease has expired, and returns them.
+	ClaimDueSchedules(ctx context.Context, owner string, at time.Time, lease time.Duration, limit int) ([]*Schedule, error)
+
+	// StartRun records a RUNNING run of the due occurrence of the schedule and moves the schedule to its next
+	// occurrence, only if the owner still holds its lease. Once started, the occurrence is never run again.
+	// It returns nil if the owner no longer holds the lease.
+	StartRun(ctx context.Context, schedule *Schedule, owner string) (*ScheduleRun, error)
+
+	// FinishRun records the status, journal and error of the run and releases the lease of the owner on its schedule.
+	FinishRun(ctx context.Context, run *ScheduleRun, owner string) error
+}
+
+// NewInMemoryScheduleManager returns a schedule manager that keeps the schedules in memory, checking them against
+// the templates of the template manager.
+func NewInMemoryScheduleManager(templateManager TemplateManager, idGenerator acccore.UniqueIDGenerator) ScheduleManager {
+	return &InMemoryScheduleManager{
+		templateManager: templateManager,
+		idGenerator:     idGenerator,
+		schedules:       make(map[string]*inMemorySchedule),
+	}
+}
+
+// inMemorySchedule is a schedule kept in memory together with its lease and runs.
+type inMemorySchedule struct {
+	schedule   Schedule
+	leaseOwner string
+	leaseUntil time.Time
+	runs       []*ScheduleRun
+}
+
+// InMemoryScheduleManager implementation of ScheduleManager that keeps the schedules in memory.
+// Suitable for testing, the schedules are lost when the application stops and are only leased within the process.
+type InMemoryScheduleManager struct {
+	templateManager TemplateManager
+	idGenerator     acccore.UniqueIDGenerator
+	mutex           sync.Mutex
+	schedules       map[string]*inMemorySchedule
+}
+
+// copySchedule returns a copy of the schedule that does not share its parameter values.
+func copySchedule(schedule *Schedule) *Schedule {
+	ret := *schedule
+	ret.Params = make(map[string]string, len(schedule.Params))
+	for name, value := range schedule.Params {
+		ret.Params[name] = value
+	}
+	return &ret
+}
+
+// CreateSchedule creates the schedule and returns it with its first occurrence after start.
+func (im *InMemoryScheduleManager) CreateSchedule(ctx context.Context, schedule *Schedule, start time.Time, author string) (*Schedule, error) {
+	now := time.Now()
+	if start.IsZero() {
+		start = now
+	}
+	created, err := prepareSchedule(ctx, im.templateManager, schedule, start)
+	if err != nil {
+		return nil, err
+	}
+	created.ScheduleID = im.idGenerator.NewUniqueID()
+	created.CreatedAt, created.CreatedBy = now, author
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	im.schedules[created.ScheduleID] = &inMemorySchedule{schedule: *copySchedule(created)}
+	return created, nil
+}
+
+// GetSchedule returns the schedule.
+func (im *InMemoryScheduleManager) GetSchedule(ctx context.Context, scheduleID string) (*Schedule, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	kept, ok := im.schedules[scheduleID]
+	if !ok {
+		return nil, fmt.Errorf("%w : %s", hwerrors.ErrScheduleNotFound, scheduleID)
+	}
+	return copySchedule(&kept.schedule), nil
+}
+
+// ListSchedules returns every schedule sorted by creation time.
+func (im *InMemoryScheduleManager) ListSchedules(ctx context.Context) ([]*Schedule, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	ret := make([]*Schedule, 0, len(im.schedules))
+	for _, kept := range im.schedules {
+		ret = append(ret, copySchedule(&kept.schedule))
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].CreatedAt.Equal(ret[j].CreatedAt) {
+			return ret[i].ScheduleID < ret[j].ScheduleID
+		}
+		return ret[i].CreatedAt.Before(ret[j].CreatedAt)
+	})
+	return ret, nil
+}
+
+// DeleteSchedule deletes the schedule and its run history.
+func (im *InMemoryScheduleManager) DeleteSchedule(ctx context.Context, scheduleID string) error {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	if _, ok := im.schedules[scheduleID]; !ok {
+		return fmt.Errorf("%w : %s", hwerrors.ErrScheduleNotFound, scheduleID)
+	}
+	delete(im.schedules, scheduleID)
+	return nil
+}
+
+// ListRuns lists the runs of the schedule in paginated fashion, latest occurrence first.
+func (im *InMemoryScheduleManager) ListRuns(ctx context.Context, scheduleID string, request acccore.PageRequest) (acccore.PageResult, []*ScheduleRun, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	kept, ok := im.schedules[scheduleID]
+	if !ok {
+		return acccore.PageResult{}, nil, fmt.Errorf("%w : %s", hwerrors.ErrScheduleNotFound, scheduleID)
+	}
+	pResult := acccore.PageResultFor(request, len(kept.runs))
+	ret := make([]*ScheduleRun, 0)
+	for i := len(kept.runs) - 1 - pResult.Offset; i >= 0 && len(ret) < pResult.PageSize; i-- {
+		run := *kept.runs[i]
+		ret = append(ret, &run)
+	}
+	return pResult, ret, nil
+}
+
+// ClaimDueSchedules leases to the owner the schedules due at the specified time that are not leased.
+func (im *InMemoryScheduleManager) ClaimDueSchedules(ctx context.Context, owner string, at time.Time, lease time.Duration, limit int) ([]*Schedule, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	due := make([]*inMemorySchedule, 0)
+	for _, kept := range im.schedules {
+		if !kept.schedule.NextRunAt.After(at) && (len(kept.leaseOwner) == 0 || kept.leaseUntil.Before(at)) {
+			due = append(due, kept)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].schedule.NextRunAt.Before(due[j].schedule.NextRunAt) })
+	ret := make([]*Schedule, 0, len(due))
+	for _, kept := range due {
+		if len(ret) == limit {
+			break
+		}
+		kept.leaseOwner, kept.leaseUntil = owner, at.Add(lease)
+		ret = append(ret, copySchedule(&kept.schedule))
+	}
+	return ret, nil
+}
+
+// StartRun records a RUNNING run of the due occurrence of the schedule and moves the schedule to its next occurrence.
+func (im *InMemoryScheduleManager) StartRun(ctx context.Context, schedule *Schedule, owner string) (*ScheduleRun, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	kept, ok := im.schedules[schedule.ScheduleID]
+	if !ok || kept.leaseOwner != owner {
+		return nil, nil
+	}
+	next, err := kept.schedule.next(kept.schedule.NextRunAt)
+	if err != nil {
+		return nil, err
+	}
+	run := &ScheduleRun{
+		RunID:       im.idGenerator.NewUniqueID(),
+		ScheduleID:  schedule.ScheduleID,
+		ScheduledAt: kept.schedule.NextRunAt,
+		StartedAt:   time.Now(),
+		Status:      ScheduleRunRunning,
+		Runner:      owner,
+	}
+	kept.runs = append(kept.runs, run)
+	kept.schedule.NextRunAt = next
+	ret := *run
+	return &ret, nil
+}
+
+// FinishRun records the status, journal and error of the run and releases the lease of the owner on its schedule.
+func (im *InMemoryScheduleManager) FinishRun(ctx context.Context, run *ScheduleRun, owner string) error {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	kept, ok := im.schedules[run.ScheduleID]
+	if !ok {
+		return nil
+	}
+	for _, kr := range kept.runs {
+		if kr.RunID == run.RunID {
+			kr.Status, kr.FinishedAt, kr.JournalID, kr.Error = run.Status, run.FinishedAt, run.JournalID, run.Error
+		}
+	}
+	if kept.leaseOwner == owner {
+		kept.leaseOwner, kept.leaseUntil = "", time.Now()
+	}
+	return nil
+}
+
+// marshalScheduleParams returns the parameter values of the schedule written as json.
+func marshalScheduleParams(params map[string]string) (string, error) {
+	bytes, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// unmarshalScheduleParams reads the parameter values of a schedule from their json.
+func unmarshalScheduleParams(paramsJSON string) (map[string]string, error) {
+	params := make(map[string]string)
+	if err := json.Unmarshal([]byte(paramsJSON), &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
@@ -0,0 +1,144 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeTestSchedule returns an hourly schedule of the makeTestTemplate template.
+func makeTestSchedule(templateName string) *Schedule {
+	return &Schedule{
+		TemplateName: templateName,
+		Cron:         "0 * * * *",
+		Params:       map[string]string{"from": "ALICE", "to": "BOB", "amount": "1000", "fee": "1", "note": "rent"},
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	for _, valid := range []string{"* * * * *", "*/15 0-6,18 1 * 1-5", "5/20 * * 1,6,12 7", " @Daily ", "0 0 29 2 *"} {
+		_, err := ParseCron(valid)
+		assert.NoError(t, err, valid)
+	}
+	for _, invalid := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"*/0 * * * *", "5-1 * * * *", "a * * * *", "1-a * * * *", "@every 5m", "* * * * * *"} {
+		_, err := ParseCron(invalid)
+		assert.True(t, errors.Is(err, hwerrors.ErrInvalidSchedule), invalid)
+	}
+}
+
+func TestCronExpression_Next(t *testing.T) {
+	from := time.Date(2024, 1, 31, 10, 7, 30, 0, time.UTC)
+	for expr, expected := range map[string]time.Time{
+		"* * * * *":      time.Date(2024, 1, 31, 10, 8, 0, 0, time.UTC),
+		"*/15 * * * *":   time.Date(2024, 1, 31, 10, 15, 0, 0, time.UTC),
+		"0 9 * * *":      time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC),
+		"@monthly":       time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 31 * *":     time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":     time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		"30 8 * * 1-5":   time.Date(2024, 2, 1, 8, 30, 0, 0, time.UTC),
+		"0 0 * * 7":      time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC),
+		"0 0 15 * 5":     time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC),
+		"7 10 31 1 *":    time.Date(2025, 1, 31, 10, 7, 0, 0, time.UTC),
+		"0 0 1 1,7 *":    time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		"0 12 1-7 * */7": time.Date(2024, 2, 4, 12, 0, 0, 0, time.UTC),
+	} {
+		cron, err := ParseCron(expr)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, expected, cron.Next(from), expr)
+	}
+	never, err := ParseCron("0 0 30 2 *")
+	assert.NoError(t, err)
+	assert.True(t, never.Next(from).IsZero())
+}
+
+func TestSchedule_Next(t *testing.T) {
+	schedule := &Schedule{Cron: "0 1 * * *", TimeZone: "Asia/Jakarta"}
+	next, err := schedule.next(time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	// 01:00 in Jakarta is 18:00 UTC the day before
+	assert.Equal(t, time.Date(2024, 1, 31, 18, 0, 0, 0, time.UTC), next)
+
+	// the occurrence in the hour skipped when daylight saving starts is skipped rather than looped on
+	schedule = &Schedule{Cron: "30 2 * * *", TimeZone: "America/New_York"}
+	next, err = schedule.next(time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 11, 6, 30, 0, 0, time.UTC), next)
+
+	_, err = (&Schedule{Cron: "@daily", TimeZone: "Mars/Olympus"}).next(time.Now())
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidSchedule))
+	_, err = (&Schedule{Cron: "0 0 31 4 *", TimeZone: "UTC"}).next(time.Now())
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidSchedule))
+}
+
+func TestInMemoryScheduleManager(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	templateManager := NewInMemoryTemplateManager(RoundHalfEven)
+	_, err := templateManager.CreateTemplate(ctx, makeTestTemplate("p2p-fee"), "TESTING")
+	assert.NoError(t, err)
+	scheduleManager := NewInMemoryScheduleManager(templateManager, &acccore.RandomGenUniqueIDGenerator{Length: 16, UpperAlpha: true, Numeric: true})
+
+	start := time.Now().Add(-150 * time.Minute)
+	created, err := scheduleManager.CreateSchedule(ctx, makeTestSchedule("p2p-fee"), start, "TESTING")
+	assert.NoError(t, err)
+	assert.Equal(t, "UTC", created.TimeZone)
+	assert.Equal(t, start.Truncate(time.Hour).Add(time.Hour).UTC(), created.NextRunAt)
+	_, err = scheduleManager.CreateSchedule(ctx, makeTestSchedule("none"), time.Time{}, "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrTemplateNotFound))
+	invalid := makeTestSchedule("p2p-fee")
+	delete(invalid.Params, "fee")
+	_, err = scheduleManager.CreateSchedule(ctx, invalid, time.Time{}, "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidTemplateParams))
+	invalid = makeTestSchedule("p2p-fee")
+	invalid.Cron = "every hour"
+	_, err = scheduleManager.CreateSchedule(ctx, invalid, time.Time{}, "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidSchedule))
+
+	// a schedule leased to a replica is left alone by the others until the lease expires
+	now := time.Now()
+	claimed, err := scheduleManager.ClaimDueSchedules(ctx, "replica-a", now, time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	claimed2, err := scheduleManager.ClaimDueSchedules(ctx, "replica-b", now, time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed2, 0)
+	run, err := scheduleManager.StartRun(ctx, claimed[0], "replica-b")
+	assert.NoError(t, err)
+	assert.Nil(t, run)
+	run, err = scheduleManager.StartRun(ctx, claimed[0], "replica-a")
+	assert.NoError(t, err)
+	assert.Equal(t, created.NextRunAt, run.ScheduledAt)
+	assert.Equal(t, ScheduleRunRunning, run.Status)
+	run.Status, run.JournalID, run.FinishedAt = ScheduleRunPosted, "JOURNAL", time.Now()
+	assert.NoError(t, scheduleManager.FinishRun(ctx, run, "replica-a"))
+
+	// the next occurrence is still in the past, the released schedule is due again
+	claimed2, err = scheduleManager.ClaimDueSchedules(ctx, "replica-b", now, time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed2, 1)
+	assert.Equal(t, created.NextRunAt.Add(time.Hour), claimed2[0].NextRunAt)
+	claimed2, err = scheduleManager.ClaimDueSchedules(ctx, "replica-a", now.Add(2*time.Minute), time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed2, 1)
+
+	pr, runs, err := scheduleManager.ListRuns(ctx, created.ScheduleID, acccore.PageRequest{PageNo: 1, ItemSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pr.TotalEntries)
+	assert.Equal(t, "JOURNAL", runs[0].JournalID)
+	assert.Equal(t, ScheduleRunPosted, runs[0].Status)
+
+	schedules, err := scheduleManager.ListSchedules(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, schedules, 1)
+	assert.NoError(t, scheduleManager.DeleteSchedule(ctx, created.ScheduleID))
+	_, err = scheduleManager.GetSchedule(ctx, created.ScheduleID)
+	assert.True(t, errors.Is(err, hwerrors.ErrScheduleNotFound))
+	_, _, err = scheduleManager.ListRuns(ctx, created.ScheduleID, acccore.PageRequest{PageNo: 1, ItemSize: 10})
+	assert.True(t, errors.Is(err, hwerrors.ErrScheduleNotFound))
+}
@@ -0,0 +1,138 @@
+package accounting
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	schedLog = logrus.WithField("file", "Scheduler.go")
+)
+
+// SchedulerConfig configures the scheduler posting the journal schedules.
+type SchedulerConfig struct {
+	// Owner identifies the replica, the schedules it posts are leased to it
+	Owner string
+	// PollInterval is how often the due schedules are looked for
+	PollInterval time.Duration
+	// Lease is how long a replica holds a due schedule before another replica may take it, longer than posting the
+	// journals of a batch takes
+	Lease time.Duration
+	// BatchSize is the most schedules claimed at once
+	BatchSize int
+}
+
+// Scheduler posts the due occurrences of the journal schedules in the background. Every replica runs its own
+// scheduler, the schedule manager leases every due schedule to a single replica and moves the schedule to its next
+// occurrence before its journal is posted, so an occurrence is posted once at most.
+type Scheduler struct {
+	scheduleManager ScheduleManager
+	config          SchedulerConfig
+	cancel          context.CancelFunc
+	done            chan struct{}
+}
+
+// NewScheduler returns a scheduler posting the schedules of the schedule manager with TemplateMgr and JournalMgr.
+// The owner is cut to the 64 characters the schedule leases keep.
+func NewScheduler(scheduleManager ScheduleManager, config SchedulerConfig) *Scheduler {
+	if len(config.Owner) > 64 {
+		config.Owner = config.Owner[:64]
+	}
+	return &Scheduler{scheduleManager: scheduleManager, config: config}
+}
+
+// Start polls the due schedules every poll interval until Stop is called.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel, s.done = cancel, make(chan struct{})
+	go s.poll(ctx)
+}
+
+// Stop stops polling and waits for the batch being posted to finish. The schedules claimed but not run yet are run
+// by any replica once their lease expires.
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+	s.cancel = nil
+}
+
+// poll runs the due schedules every poll interval until the context is canceled.
+func (s *Scheduler) poll(ctx context.Context) {
+	logf := schedLog.WithField("fn", "poll")
+	defer close(s.done)
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// a batch is never canceled halfway, so a run started is always finished
+			runContext := context.WithValue(context.Background(), contextkeys.XRequestID, "scheduler")
+			runs, err := s.RunDue(runContext, time.Now())
+			if err != nil {
+				logf.Error("could not run the due journal schedules: ", err)
+				continue
+			}
+			logf.Debugf("ran %d journal schedules", len(runs))
+		}
+	}
+}
+
+// RunDue claims the schedules due at the specified time and posts the journal of their due occurrence, returning
+// the runs finished. A schedule behind by several occurrences catches up one occurrence every call.
+func (s *Scheduler) RunDue(ctx context.Context, at time.Time) ([]*ScheduleRun, error) {
+	logf := schedLog.WithField("fn", "RunDue")
+	schedules, err := s.scheduleManager.ClaimDueSchedules(ctx, s.config.Owner, at, s.config.Lease, s.config.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*ScheduleRun, 0, len(schedules))
+	for _, schedule := range schedules {
+		run, err := s.scheduleManager.StartRun(ctx, schedule, s.config.Owner)
+		if err != nil {
+			return ret, err
+		}
+		if run == nil {
+			logf.Warnf("lease of journal schedule %s expired before its run started", schedule.ScheduleID)
+			continue
+		}
+		run.JournalID, err = s.postJournal(ctx, schedule, run.ScheduledAt)
+		run.Status, run.FinishedAt = ScheduleRunPosted, time.Now()
+		if err != nil {
+			logf.Errorf("could not post the journal of schedule %s due at %s: %s", schedule.ScheduleID, run.ScheduledAt.Format(time.RFC3339), err.Error())
+			run.Status, run.Error = ScheduleRunFailed, err.Error()
+		}
+		if err = s.scheduleManager.FinishRun(ctx, run, s.config.Owner); err != nil {
+			return ret, err
+		}
+		ret = append(ret, run)
+	}
+	return ret, nil
+}
+
+// postJournal posts the journal the template of the schedule makes, taking effect at the occurrence and created by
+// the author of the schedule, and returns its journal ID.
+func (s *Scheduler) postJournal(ctx context.Context, schedule *Schedule, scheduledAt time.Time) (string, error) {
+	request, err := TemplateMgr.NewJournalRequest(ctx, schedule.TemplateName, schedule.Params)
+	if err != nil {
+		return "", err
+	}
+	request.Creator = schedule.CreatedBy
+	request.EffectiveTime = scheduledAt.UTC().Format(RestTimeFormat)
+	journalContext := context.WithValue(ctx, contextkeys.UserIDContextKey, schedule.CreatedBy)
+	journal, err := newJournalFromRequest(journalContext, request)
+	if err != nil {
+		return "", err
+	}
+	if err = JournalMgr.PersistJournal(journalContext, journal); err != nil {
+		return "", err
+	}
+	return journal.GetJournalID(), nil
+}
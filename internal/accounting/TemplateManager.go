@@ -0,0 +1,471 @@
+package accounting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+)
+
+// TemplateParamType is the type of a journal template parameter.
+type TemplateParamType string
+
+const (
+	// TemplateParamAccount parameters are account numbers
+	TemplateParamAccount TemplateParamType = "ACCOUNT"
+	// TemplateParamAmount parameters are positive whole amounts
+	TemplateParamAmount TemplateParamType = "AMOUNT"
+	// TemplateParamPercentage parameters are decimal percentages from 0 to 100, such as 1.5
+	TemplateParamPercentage TemplateParamType = "PERCENTAGE"
+	// TemplateParamText parameters are free text, used in the descriptions
+	TemplateParamText TemplateParamType = "TEXT"
+)
+
+// ParseTemplateParamType parses a journal template parameter type, case insensitive.
+// Throws ErrInvalidTemplate if it is not ACCOUNT, AMOUNT, PERCENTAGE or TEXT.
+func ParseTemplateParamType(paramType string) (TemplateParamType, error) {
+	switch t := TemplateParamType(strings.ToUpper(strings.TrimSpace(paramType))); t {
+	case TemplateParamAccount, TemplateParamAmount, TemplateParamPercentage, TemplateParamText:
+		return t, nil
+	}
+	return "", fmt.Errorf("%w : unknown parameter type %s", hwerrors.ErrInvalidTemplate, paramType)
+}
+
+// TemplateParam is a named parameter of a journal template, referred to as {name} in the template.
+type TemplateParam struct {
+	Name        string
+	Type        TemplateParamType
+	Description string
+}
+
+// TemplateLeg is a transaction of the journals made from a template.
+type TemplateLeg struct {
+	// Account is an account number, or the {name} of an ACCOUNT parameter
+	Account   string
+	Alignment acccore.Alignment
+	// Amount adds up terms, such as {amount} + {amount} * {fee}%, each term multiplies whole numbers, AMOUNT
+	// parameters and percentages, a percentage being a number or a PERCENTAGE parameter followed by %. Every term is
+	// rounded to a whole amount
+	Amount string
+	// Description may refer to any parameter, {name} is replaced by its value
+	Description string
+}
+
+// JournalTemplate is a named recurring posting pattern, executing it with the values of its parameters makes a journal.
+type JournalTemplate struct {
+	Name string
+	// Description is the description of the journals made from the template, it may refer to any parameter
+	Description string
+	Params      []*TemplateParam
+	Legs        []*TemplateLeg
+	CreatedAt   time.Time
+	CreatedBy   string
+}
+
+var (
+	// validTemplateName matches the template names, they are part of the template URL
+	validTemplateName = regexp.MustCompile(`^[a-z0-9][a-z0-9_\-]{0,39}$`)
+	// validParamName matches the parameter names
+	validParamName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+	// paramReference matches the references to a parameter
+	paramReference = regexp.MustCompile(`\{([a-z][a-z0-9_]*)\}`)
+	// amountFactor matches a factor of an amount term, a number or a parameter reference, optionally a percentage
+	amountFactor = regexp.MustCompile(`^(?:([0-9]+(?:\.[0-9]+)?)|\{([a-z][a-z0-9_]*)\})(%?)$`)
+)
+
+// templateFactor is a factor of a term of a leg amount.
+type templateFactor struct {
+	// Param is the name of the parameter, empty for a number
+	Param   string
+	Value   *big.Rat
+	Percent bool
+}
+
+// templateTerm is a term of a leg amount, the product of its factors, subtracted if negative.
+type templateTerm struct {
+	Negative bool
+	Factors  []*templateFactor
+}
+
+// parseTemplateAmount parses the amount of a leg into its terms.
+// Throws ErrInvalidTemplate if it is not well written.
+func parseTemplateAmount(amount string) ([]*templateTerm, error) {
+	expression := strings.ReplaceAll(amount, " ", "")
+	if len(expression) == 0 {
+		return nil, fmt.Errorf("%w : leg amount is missing", hwerrors.ErrInvalidTemplate)
+	}
+	terms := make([]*templateTerm, 0)
+	for len(expression) > 0 {
+		term := &templateTerm{Factors: make([]*templateFactor, 0)}
+		if expression[0] == '-' || expression[0] == '+' {
+			term.Negative = expression[0] == '-'
+			expression = expression[1:]
+		} else if len(terms) > 0 {
+			return nil, fmt.Errorf("%w : amount %s is not well written", hwerrors.ErrInvalidTemplate, amount)
+		}
+		end := strings.IndexAny(expression, "+-")
+		if end < 0 {
+			end = len(expression)
+		}
+		for _, factor := range strings.Split(expression[:end], "*") {
+			match := amountFactor.FindStringSubmatch(factor)
+			if match == nil {
+				return nil, fmt.Errorf("%w : amount %s is not well written", hwerrors.ErrInvalidTemplate, amount)
+			}
+			parsed := &templateFactor{Param: match[2], Percent: match[3] == "%"}
+			if len(match[1]) > 0 {
+				parsed.Value, _ = new(big.Rat).SetString(match[1])
+				if !parsed.Percent && !parsed.Value.IsInt() {
+					return nil, fmt.Errorf("%w : %s in amount %s is not a whole number", hwerrors.ErrInvalidTemplate, match[1], amount)
+				}
+			}
+			term.Factors = append(term.Factors, parsed)
+		}
+		terms = append(terms, term)
+		expression = expression[end:]
+	}
+	return terms, nil
+}
+
+// validate makes sure the template has a name and two legs at least, one of each alignment, and its legs only refer
+// to parameters of the right type.
+func (t *JournalTemplate) validate() error {
+	if !validTemplateName.MatchString(t.Name) {
+		return fmt.Errorf("%w : name %s should be 1 to 40 lower case letters, digits, _ or -", hwerrors.ErrInvalidTemplate, t.Name)
+	}
+	params := make(map[string]TemplateParamType, len(t.Params))
+	for _, param := range t.Params {
+		if !validParamName.MatchString(param.Name) {
+			return fmt.Errorf("%w : parameter name %s should be 1 to 32 lower case letters, digits or _", hwerrors.ErrInvalidTemplate, param.Name)
+		}
+		if _, ok := params[param.Name]; ok {
+			return fmt.Errorf("%w : parameter %s is declared twice", hwerrors.ErrInvalidTemplate, param.Name)
+		}
+		if _, err := ParseTemplateParamType(string(param.Type)); err != nil {
+			return err
+		}
+		params[param.Name] = param.Type
+	}
+	checkReferences := func(text string) error {
+		for _, match := range paramReference.FindAllStringSubmatch(text, -1) {
+			if _, ok := params[match[1]]; !ok {
+				return fmt.Errorf("%w : parameter %s is not declared", hwerrors.ErrInvalidTemplate, match[1])
+			}
+		}
+		return nil
+	}
+	if err := checkReferences(t.Description); err != nil {
+		return err
+	}
+	if len(t.Legs) < 2 {
+		return fmt.Errorf("%w : a template needs two legs at least", hwerrors.ErrInvalidTemplate)
+	}
+	alignments := make(map[acccore.Alignment]bool)
+	for _, leg := range t.Legs {
+		if match := paramReference.FindStringSubmatch(leg.Account); match != nil {
+			if match[0] != leg.Account || params[match[1]] != TemplateParamAccount {
+				return fmt.Errorf("%w : leg account %s should be an account number or an ACCOUNT parameter", hwerrors.ErrInvalidTemplate, leg.Account)
+			}
+		} else if len(strings.TrimSpace(leg.Account)) == 0 || strings.ContainsAny(leg.Account, "{}") {
+			return fmt.Errorf("%w : leg account %s should be an account number or an ACCOUNT parameter", hwerrors.ErrInvalidTemplate, leg.Account)
+		}
+		if leg.Alignment != acccore.DEBIT && leg.Alignment != acccore.CREDIT {
+			return fmt.Errorf("%w : leg on account %s : %s", hwerrors.ErrInvalidTemplate, leg.Account, hwerrors.ErrInvalidAlignment)
+		}
+		alignments[leg.Alignment] = true
+		terms, err := parseTemplateAmount(leg.Amount)
+		if err != nil {
+			return err
+		}
+		for _, term := range terms {
+			for _, factor := range term.Factors {
+				if len(factor.Param) == 0 {
+					continue
+				}
+				switch params[factor.Param] {
+				case TemplateParamAmount:
+					if factor.Percent {
+						return fmt.Errorf("%w : AMOUNT parameter %s is used as a percentage", hwerrors.ErrInvalidTemplate, factor.Param)
+					}
+				case TemplateParamPercentage:
+					if !factor.Percent {
+						return fmt.Errorf("%w : PERCENTAGE parameter %s should be followed by %%", hwerrors.ErrInvalidTemplate, factor.Param)
+					}
+				default:
+					return fmt.Errorf("%w : parameter %s of leg amount %s should be an AMOUNT or a PERCENTAGE", hwerrors.ErrInvalidTemplate, factor.Param, leg.Amount)
+				}
+			}
+		}
+		if err = checkReferences(leg.Description); err != nil {
+			return err
+		}
+	}
+	if !alignments[acccore.DEBIT] || !alignments[acccore.CREDIT] {
+		return fmt.Errorf("%w : a template needs a DEBIT leg and a CREDIT leg", hwerrors.ErrInvalidTemplate)
+	}
+	return nil
+}
+
+// paramValues checks the values of the parameters of the template and returns the numeric value of its AMOUNT and
+// PERCENTAGE parameters.
+// Throws ErrInvalidTemplateParams if a parameter has no value, a value is not valid for the type of its parameter,
+// or there is a value for a parameter the template does not declare.
+func (t *JournalTemplate) paramValues(values map[string]string) (map[string]*big.Rat, error) {
+	numbers := make(map[string]*big.Rat)
+	for _, param := range t.Params {
+		value, ok := values[param.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w : parameter %s is missing", hwerrors.ErrInvalidTemplateParams, param.Name)
+		}
+		switch param.Type {
+		case TemplateParamAccount:
+			if len(strings.TrimSpace(value)) == 0 {
+				return nil, fmt.Errorf("%w : ACCOUNT parameter %s is empty", hwerrors.ErrInvalidTemplateParams, param.Name)
+			}
+		case TemplateParamAmount:
+			amount, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || amount <= 0 {
+				return nil, fmt.Errorf("%w : AMOUNT parameter %s should be a positive whole amount, not %s", hwerrors.ErrInvalidTemplateParams, param.Name, value)
+			}
+			numbers[param.Name] = new(big.Rat).SetInt64(amount)
+		case TemplateParamPercentage:
+			percentage, ok := new(big.Rat).SetString(value)
+			if !ok || percentage.Sign() < 0 || percentage.Cmp(big.NewRat(100, 1)) > 0 {
+				return nil, fmt.Errorf("%w : PERCENTAGE parameter %s should be from 0 to 100, not %s", hwerrors.ErrInvalidTemplateParams, param.Name, value)
+			}
+			numbers[param.Name] = percentage
+		}
+	}
+	if len(values) > len(t.Params) {
+		declared := make(map[string]bool, len(t.Params))
+		for _, param := range t.Params {
+			declared[param.Name] = true
+		}
+		for name := range values {
+			if !declared[name] {
+				return nil, fmt.Errorf("%w : parameter %s is not declared", hwerrors.ErrInvalidTemplateParams, name)
+			}
+		}
+	}
+	return numbers, nil
+}
+
+// newJournalRequest makes the request of the journal the template makes with the values of its parameters. Every
+// term of a leg amount is rounded to a whole amount before adding them up, so the legs sharing a term, such as a fee
+// charged on one leg and credited on another, round it the same way. Legs whose amount is zero are left out.
+// Throws ErrInvalidTemplateParams if the values are not valid, or a leg amount is negative.
+func (t *JournalTemplate) newJournalRequest(values map[string]string, rounding RoundingMode) (*CreateJournalRequest, error) {
+	numbers, err := t.paramValues(values)
+	if err != nil {
+		return nil, err
+	}
+	replace := func(text string) string {
+		return paramReference.ReplaceAllStringFunc(text, func(reference string) string {
+			return values[reference[1:len(reference)-1]]
+		})
+	}
+	ret := &CreateJournalRequest{Description: replace(t.Description), Transactions: make([]*TransactionRequest, 0, len(t.Legs))}
+	for _, leg := range t.Legs {
+		terms, err := parseTemplateAmount(leg.Amount)
+		if err != nil {
+			return nil, err
+		}
+		amount := new(big.Int)
+		for _, term := range terms {
+			product := big.NewRat(1, 1)
+			for _, factor := range term.Factors {
+				value := factor.Value
+				if len(factor.Param) > 0 {
+					value = numbers[factor.Param]
+				}
+				product.Mul(product, value)
+				if factor.Percent {
+					product.Quo(product, big.NewRat(100, 1))
+				}
+			}
+			rounded := rounding.Round(product)
+			if term.Negative {
+				rounded.Neg(rounded)
+			}
+			amount.Add(amount, rounded)
+		}
+		if amount.Sign() < 0 || !amount.IsInt64() {
+			return nil, fmt.Errorf("%w : amount %s of the leg on %s is %s", hwerrors.ErrInvalidTemplateParams, leg.Amount, replace(leg.Account), amount.String())
+		}
+		if amount.Sign() == 0 {
+			continue
+		}
+		ret.Transactions = append(ret.Transactions, &TransactionRequest{
+			AccountNumber: replace(leg.Account),
+			Description:   replace(leg.Description),
+			Alignment:     alignmentName(leg.Alignment),
+			Amount:        amount.Int64(),
+		})
+	}
+	return ret, nil
+}
+
+// templateDefinition is how the parameters and legs of a template are kept in the database.
+type templateDefinition struct {
+	Params []*templateParamDefinition `json:"params"`
+	Legs   []*templateLegDefinition   `json:"legs"`
+}
+
+// templateParamDefinition is how a template parameter is kept in the database.
+type templateParamDefinition struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// templateLegDefinition is how a template leg is kept in the database.
+type templateLegDefinition struct {
+	Account     string `json:"account"`
+	Alignment   string `json:"alignment"`
+	Amount      string `json:"amount"`
+	Description string `json:"description"`
+}
+
+// marshalTemplateDefinition returns the parameters and legs of the template written as json.
+func marshalTemplateDefinition(template *JournalTemplate) (string, error) {
+	definition := &templateDefinition{Params: make([]*templateParamDefinition, 0, len(template.Params)), Legs: make([]*templateLegDefinition, 0, len(template.Legs))}
+	for _, param := range template.Params {
+		definition.Params = append(definition.Params, &templateParamDefinition{Name: param.Name, Type: string(param.Type), Description: param.Description})
+	}
+	for _, leg := range template.Legs {
+		definition.Legs = append(definition.Legs, &templateLegDefinition{Account: leg.Account, Alignment: alignmentName(leg.Alignment),
+			Amount: leg.Amount, Description: leg.Description})
+	}
+	bytes, err := json.Marshal(definition)
+	return string(bytes), err
+}
+
+// unmarshalTemplateDefinition sets the parameters and legs of the template from their json.
+func unmarshalTemplateDefinition(template *JournalTemplate, definitionJSON string) error {
+	definition := &templateDefinition{}
+	if err := json.Unmarshal([]byte(definitionJSON), definition); err != nil {
+		return err
+	}
+	template.Params = make([]*TemplateParam, 0, len(definition.Params))
+	for _, param := range definition.Params {
+		template.Params = append(template.Params, &TemplateParam{Name: param.Name, Type: TemplateParamType(param.Type), Description: param.Description})
+	}
+	template.Legs = make([]*TemplateLeg, 0, len(definition.Legs))
+	for _, leg := range definition.Legs {
+		alignment, err := ParseAlignment(leg.Alignment)
+		if err != nil {
+			return err
+		}
+		template.Legs = append(template.Legs, &TemplateLeg{Account: leg.Account, Alignment: alignment, Amount: leg.Amount, Description: leg.Description})
+	}
+	return nil
+}
+
+// TemplateManager keeps the journal templates and makes journals from them.
+type TemplateManager interface {
+	// CreateTemplate creates the template and returns it.
+	// Throws ErrInvalidTemplate if the template is not valid, or ErrTemplateExists if the name is taken.
+	CreateTemplate(ctx context.Context, template *JournalTemplate, author string) (*JournalTemplate, error)
+
+	// GetTemplate returns the template.
+	// Throws ErrTemplateNotFound if there is no template with the name.
+	GetTemplate(ctx context.Context, name string) (*JournalTemplate, error)
+
+	// ListTemplates returns every template sorted by name.
+	ListTemplates(ctx context.Context) ([]*JournalTemplate, error)
+
+	// DeleteTemplate deletes the template, the journals made from it are left as they are.
+	// Throws ErrTemplateNotFound if there is no template with the name, or ErrTemplateInUse if a schedule posts it.
+	DeleteTemplate(ctx context.Context, name string) error
+
+	// NewJournalRequest makes the request of the journal the template makes with the values of its parameters.
+	// Throws ErrTemplateNotFound if there is no template with the name, or ErrInvalidTemplateParams if the values
+	// are not valid.
+	NewJournalRequest(ctx context.Context, name string, values map[string]string) (*CreateJournalRequest, error)
+}
+
+// NewInMemoryTemplateManager returns a template manager that keeps the templates in memory, rounding the amounts
+// of the journals it makes with the rounding mode.
+func NewInMemoryTemplateManager(rounding RoundingMode) TemplateManager {
+	return &InMemoryTemplateManager{rounding: rounding, templates: make(map[string]*JournalTemplate)}
+}
+
+// InMemoryTemplateManager implementation of TemplateManager that keeps the templates in memory.
+// Suitable for testing, the templates are lost when the application stops and are deleted whether a schedule posts
+// them or not.
+type InMemoryTemplateManager struct {
+	rounding  RoundingMode
+	mutex     sync.Mutex
+	templates map[string]*JournalTemplate
+}
+
+// CreateTemplate creates the template and returns it.
+func (im *InMemoryTemplateManager) CreateTemplate(ctx context.Context, template *JournalTemplate, author string) (*JournalTemplate, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	if err := template.validate(); err != nil {
+		return nil, err
+	}
+	if _, ok := im.templates[template.Name]; ok {
+		return nil, fmt.Errorf("%w : %s", hwerrors.ErrTemplateExists, template.Name)
+	}
+	created := *template
+	created.CreatedAt, created.CreatedBy = time.Now(), author
+	im.templates[template.Name] = &created
+	ret := created
+	return &ret, nil
+}
+
+// GetTemplate returns the template.
+func (im *InMemoryTemplateManager) GetTemplate(ctx context.Context, name string) (*JournalTemplate, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	template, ok := im.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w : %s", hwerrors.ErrTemplateNotFound, name)
+	}
+	ret := *template
+	return &ret, nil
+}
+
+// ListTemplates returns every template sorted by name.
+func (im *InMemoryTemplateManager) ListTemplates(ctx context.Context) ([]*JournalTemplate, error) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	ret := make([]*JournalTemplate, 0, len(im.templates))
+	for _, template := range im.templates {
+		copied := *template
+		ret = append(ret, &copied)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret, nil
+}
+
+// DeleteTemplate deletes the template.
+func (im *InMemoryTemplateManager) DeleteTemplate(ctx context.Context, name string) error {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	if _, ok := im.templates[name]; !ok {
+		return fmt.Errorf("%w : %s", hwerrors.ErrTemplateNotFound, name)
+	}
+	delete(im.templates, name)
+	return nil
+}
+
+// NewJournalRequest makes the request of the journal the template makes with the values of its parameters.
+func (im *InMemoryTemplateManager) NewJournalRequest(ctx context.Context, name string, values map[string]string) (*CreateJournalRequest, error) {
+	template, err := im.GetTemplate(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return template.newJournalRequest(values, im.rounding)
+}
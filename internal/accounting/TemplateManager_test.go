@@ -0,0 +1,158 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeTestTemplate returns a transfer template charging a fee on top of the amount, credited to the FEE account.
+func makeTestTemplate(name string) *JournalTemplate {
+	return &JournalTemplate{
+		Name:        name,
+		Description: "Transfer {note}",
+		Params: []*TemplateParam{
+			{Name: "from", Type: TemplateParamAccount},
+			{Name: "to", Type: TemplateParamAccount},
+			{Name: "amount", Type: TemplateParamAmount},
+			{Name: "fee", Type: TemplateParamPercentage},
+			{Name: "note", Type: TemplateParamText},
+		},
+		Legs: []*TemplateLeg{
+			{Account: "{from}", Alignment: acccore.CREDIT, Amount: "{amount} + {amount} * {fee}%", Description: "Send {note}"},
+			{Account: "{to}", Alignment: acccore.DEBIT, Amount: "{amount}", Description: "Receive {note}"},
+			{Account: "FEE", Alignment: acccore.DEBIT, Amount: "{amount} * {fee}%", Description: "Fee on {note}"},
+		},
+	}
+}
+
+func TestParseTemplateAmount(t *testing.T) {
+	terms, err := parseTemplateAmount("{amount} - 2 * {amount} * {fee}% + 0.5%")
+	assert.NoError(t, err)
+	assert.Len(t, terms, 3)
+	assert.False(t, terms[0].Negative)
+	assert.True(t, terms[1].Negative)
+	assert.Len(t, terms[1].Factors, 3)
+	assert.Equal(t, "fee", terms[1].Factors[2].Param)
+	assert.True(t, terms[1].Factors[2].Percent)
+	assert.True(t, terms[2].Factors[0].Percent)
+
+	for _, invalid := range []string{"", "{amount} {fee}", "{amount} *", "1.5", "{Amount}", "{amount} / 2", "{amount} ++ 1"} {
+		_, err = parseTemplateAmount(invalid)
+		assert.True(t, errors.Is(err, hwerrors.ErrInvalidTemplate), invalid)
+	}
+}
+
+func TestJournalTemplate_Validate(t *testing.T) {
+	assert.NoError(t, makeTestTemplate("p2p-fee").validate())
+	for name, change := range map[string]func(*JournalTemplate){
+		"name": func(tpl *JournalTemplate) { tpl.Name = "P2P Fee" },
+		"duplicate param": func(tpl *JournalTemplate) {
+			tpl.Params = append(tpl.Params, &TemplateParam{Name: "fee", Type: TemplateParamAmount})
+		},
+		"param type": func(tpl *JournalTemplate) { tpl.Params[3].Type = "RATE" },
+		"undeclared": func(tpl *JournalTemplate) { tpl.Description = "Transfer {memo}" },
+		"one leg":    func(tpl *JournalTemplate) { tpl.Legs = tpl.Legs[:1] },
+		"no debit": func(tpl *JournalTemplate) {
+			tpl.Legs[1].Alignment, tpl.Legs[2].Alignment = acccore.CREDIT, acccore.CREDIT
+		},
+		"alignment":          func(tpl *JournalTemplate) { tpl.Legs[2].Alignment = 7 },
+		"account param type": func(tpl *JournalTemplate) { tpl.Legs[1].Account = "{note}" },
+		"account pattern":    func(tpl *JournalTemplate) { tpl.Legs[1].Account = "X{to}" },
+		"amount as percent":  func(tpl *JournalTemplate) { tpl.Legs[1].Amount = "{amount}%" },
+		"percent as amount":  func(tpl *JournalTemplate) { tpl.Legs[2].Amount = "{amount} * {fee}" },
+		"text in amount":     func(tpl *JournalTemplate) { tpl.Legs[2].Amount = "{note}" },
+	} {
+		template := makeTestTemplate("p2p-fee")
+		change(template)
+		assert.True(t, errors.Is(template.validate(), hwerrors.ErrInvalidTemplate), name)
+	}
+}
+
+func TestJournalTemplate_NewJournalRequest(t *testing.T) {
+	template := makeTestTemplate("p2p-fee")
+	values := map[string]string{"from": "ALICE", "to": "BOB", "amount": "1001", "fee": "1.25", "note": "rent"}
+	request, err := template.newJournalRequest(values, RoundHalfEven)
+	assert.NoError(t, err)
+	assert.Equal(t, "Transfer rent", request.Description)
+	assert.Len(t, request.Transactions, 3)
+	// the 12.5125 fee is rounded once, the legs stay balanced
+	assert.Equal(t, &TransactionRequest{AccountNumber: "ALICE", Description: "Send rent", Alignment: "CREDIT", Amount: 1014}, request.Transactions[0])
+	assert.Equal(t, &TransactionRequest{AccountNumber: "BOB", Description: "Receive rent", Alignment: "DEBIT", Amount: 1001}, request.Transactions[1])
+	assert.Equal(t, &TransactionRequest{AccountNumber: "FEE", Description: "Fee on rent", Alignment: "DEBIT", Amount: 13}, request.Transactions[2])
+
+	values["amount"], values["fee"] = "1001", "1.2487"
+	request, err = template.newJournalRequest(values, RoundHalfEven)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1013), request.Transactions[0].Amount)
+	assert.Equal(t, int64(12), request.Transactions[2].Amount)
+
+	// a zero fee leaves the fee leg out
+	values["fee"] = "0"
+	request, err = template.newJournalRequest(values, RoundHalfEven)
+	assert.NoError(t, err)
+	assert.Len(t, request.Transactions, 2)
+
+	for name, invalid := range map[string]map[string]string{
+		"missing":      {"from": "ALICE", "to": "BOB", "amount": "1000", "note": "rent"},
+		"undeclared":   {"from": "ALICE", "to": "BOB", "amount": "1000", "fee": "1", "note": "rent", "tip": "1"},
+		"amount":       {"from": "ALICE", "to": "BOB", "amount": "10.5", "fee": "1", "note": "rent"},
+		"zero amount":  {"from": "ALICE", "to": "BOB", "amount": "0", "fee": "1", "note": "rent"},
+		"percentage":   {"from": "ALICE", "to": "BOB", "amount": "1000", "fee": "100.5", "note": "rent"},
+		"empty":        {"from": "", "to": "BOB", "amount": "1000", "fee": "1", "note": "rent"},
+		"not a number": {"from": "ALICE", "to": "BOB", "amount": "1000", "fee": "one", "note": "rent"},
+	} {
+		_, err = template.newJournalRequest(invalid, RoundHalfEven)
+		assert.True(t, errors.Is(err, hwerrors.ErrInvalidTemplateParams), name)
+	}
+
+	// a leg amount may not turn negative
+	template.Legs[1].Amount = "{amount} - 2 * {amount}"
+	_, err = template.newJournalRequest(map[string]string{"from": "ALICE", "to": "BOB", "amount": "1000", "fee": "1", "note": "rent"}, RoundHalfEven)
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidTemplateParams))
+}
+
+func TestTemplateDefinition(t *testing.T) {
+	template := makeTestTemplate("p2p-fee")
+	definition, err := marshalTemplateDefinition(template)
+	assert.NoError(t, err)
+	read := &JournalTemplate{Name: template.Name, Description: template.Description}
+	assert.NoError(t, unmarshalTemplateDefinition(read, definition))
+	assert.Equal(t, template, read)
+}
+
+func TestInMemoryTemplateManager(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	templateManager := NewInMemoryTemplateManager(RoundHalfEven)
+
+	created, err := templateManager.CreateTemplate(ctx, makeTestTemplate("p2p-fee"), "TESTING")
+	assert.NoError(t, err)
+	assert.Equal(t, "TESTING", created.CreatedBy)
+	_, err = templateManager.CreateTemplate(ctx, makeTestTemplate("p2p-fee"), "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrTemplateExists))
+	_, err = templateManager.CreateTemplate(ctx, makeTestTemplate("P2P"), "TESTING")
+	assert.True(t, errors.Is(err, hwerrors.ErrInvalidTemplate))
+	_, err = templateManager.CreateTemplate(ctx, makeTestTemplate("a-p2p-fee"), "TESTING")
+	assert.NoError(t, err)
+
+	templates, err := templateManager.ListTemplates(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, templates, 2)
+	assert.Equal(t, "a-p2p-fee", templates[0].Name)
+
+	request, err := templateManager.NewJournalRequest(ctx, "p2p-fee", map[string]string{"from": "ALICE", "to": "BOB", "amount": "200", "fee": "5", "note": "rent"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(210), request.Transactions[0].Amount)
+	_, err = templateManager.NewJournalRequest(ctx, "none", map[string]string{})
+	assert.True(t, errors.Is(err, hwerrors.ErrTemplateNotFound))
+
+	assert.NoError(t, templateManager.DeleteTemplate(ctx, "p2p-fee"))
+	_, err = templateManager.GetTemplate(ctx, "p2p-fee")
+	assert.True(t, errors.Is(err, hwerrors.ErrTemplateNotFound))
+	assert.True(t, errors.Is(templateManager.DeleteTemplate(ctx, "p2p-fee"), hwerrors.ErrTemplateNotFound))
+}
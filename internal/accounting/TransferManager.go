@@ -0,0 +1,173 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+)
+
+// TransferConfig configures the transfers.
+type TransferConfig struct {
+	// FeeAccounts maps a currency code to the revenue account that receives the transfer fees in that currency
+	FeeAccounts map[string]string
+	// Rounding is the rounding mode of the percentage fees
+	Rounding RoundingMode
+}
+
+// FeeSchedule tells the fee a transfer charges on top of its amount.
+type FeeSchedule struct {
+	// Fixed is charged on every transfer
+	Fixed int64
+	// Percentage of the amount is charged on top of the fixed fee, from 0 to 100, nil for none
+	Percentage *big.Rat
+	// Minimum is the lowest fee charged, 0 for no minimum
+	Minimum int64
+	// Maximum is the highest fee charged, 0 for no maximum
+	Maximum int64
+}
+
+// validate makes sure the fee schedule amounts are not negative, the percentage is from 0 to 100 and the minimum is
+// not above the maximum.
+func (f *FeeSchedule) validate() error {
+	if f.Fixed < 0 || f.Minimum < 0 || f.Maximum < 0 {
+		return fmt.Errorf("%w : fee amounts should not be negative", hwerrors.ErrInvalidTransfer)
+	}
+	if f.Percentage != nil && (f.Percentage.Sign() < 0 || f.Percentage.Cmp(big.NewRat(100, 1)) > 0) {
+		return fmt.Errorf("%w : fee percentage should be from 0 to 100, not %s", hwerrors.ErrInvalidTransfer, f.Percentage.FloatString(2))
+	}
+	if f.Maximum > 0 && f.Minimum > f.Maximum {
+		return fmt.Errorf("%w : fee minimum %d is above the maximum %d", hwerrors.ErrInvalidTransfer, f.Minimum, f.Maximum)
+	}
+	return nil
+}
+
+// Fee returns the fee charged on a transfer of the amount, the percentage fee rounded to a whole amount.
+func (f *FeeSchedule) Fee(amount int64, rounding RoundingMode) int64 {
+	fee := f.Fixed
+	if f.Percentage != nil {
+		percent := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), f.Percentage)
+		fee += rounding.Round(percent.Quo(percent, big.NewRat(100, 1))).Int64()
+	}
+	if fee < f.Minimum {
+		fee = f.Minimum
+	}
+	if f.Maximum > 0 && fee > f.Maximum {
+		fee = f.Maximum
+	}
+	return fee
+}
+
+// Transfer moves an amount from an account to another of the same currency and alignment.
+type Transfer struct {
+	FromAccount string
+	ToAccount   string
+	Amount      int64
+	// Fee is charged to the from account on top of the amount, nil for no fee
+	Fee *FeeSchedule
+	// Description is the description of the journal, a description naming both accounts if empty
+	Description string
+}
+
+// TransferManager prepares the journals of the transfers.
+type TransferManager interface {
+	// PrepareTransfer makes the request of the journal taking the amount and the fee from the from account, giving
+	// the amount to the to account and the fee to the fee account of their currency. Legs whose amount is zero are
+	// left out.
+	// Throws acccore.ErrAccountIDNotFound if an account does not exist, ErrInvalidTransfer if the amount or the fee
+	// schedule is not valid or the accounts differ in currency or alignment, or ErrTransferFeeNotConfigured if there
+	// is a fee but no fee account for the currency.
+	PrepareTransfer(ctx context.Context, transfer *Transfer) (*CreateJournalRequest, error)
+}
+
+// NewTransferManager returns a transfer manager that finds the accounts with the account manager.
+func NewTransferManager(accountManager acccore.AccountManager, config TransferConfig) TransferManager {
+	return &transferManager{accountManager: accountManager, config: config}
+}
+
+type transferManager struct {
+	accountManager acccore.AccountManager
+	config         TransferConfig
+}
+
+// getAccount returns the account. Throws acccore.ErrAccountIDNotFound if it does not exist.
+func (tm *transferManager) getAccount(ctx context.Context, accountNumber string) (acccore.Account, error) {
+	account, err := tm.accountManager.GetAccountByID(ctx, accountNumber)
+	if (err == nil && account == nil) || errors.Is(err, acccore.ErrAccountIDNotFound) {
+		return nil, fmt.Errorf("%w : %s", acccore.ErrAccountIDNotFound, accountNumber)
+	}
+	return account, err
+}
+
+// PrepareTransfer makes the request of the journal of the transfer.
+func (tm *transferManager) PrepareTransfer(ctx context.Context, transfer *Transfer) (*CreateJournalRequest, error) {
+	if transfer.Amount <= 0 {
+		return nil, fmt.Errorf("%w : amount should be positive, not %d", hwerrors.ErrInvalidTransfer, transfer.Amount)
+	}
+	if transfer.FromAccount == transfer.ToAccount {
+		return nil, fmt.Errorf("%w : can not transfer from account %s to itself", hwerrors.ErrInvalidTransfer, transfer.FromAccount)
+	}
+	from, err := tm.getAccount(ctx, transfer.FromAccount)
+	if err != nil {
+		return nil, err
+	}
+	to, err := tm.getAccount(ctx, transfer.ToAccount)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkTransferAccount(from, to); err != nil {
+		return nil, err
+	}
+
+	fee := int64(0)
+	if transfer.Fee != nil {
+		if err = transfer.Fee.validate(); err != nil {
+			return nil, err
+		}
+		fee = transfer.Fee.Fee(transfer.Amount, tm.config.Rounding)
+	}
+	description := transfer.Description
+	if len(description) == 0 {
+		description = fmt.Sprintf("Transfer from %s to %s", from.GetAccountNumber(), to.GetAccountNumber())
+	}
+	// the from account goes down and the others go up by the alignment of the from account, which they share
+	ret := &CreateJournalRequest{Description: description, Transactions: []*TransactionRequest{
+		{AccountNumber: from.GetAccountNumber(), Description: "Transfer to " + to.GetAccountNumber(),
+			Alignment: alignmentName(oppositeAlignment(from.GetAlignment())), Amount: transfer.Amount + fee},
+		{AccountNumber: to.GetAccountNumber(), Description: "Transfer from " + from.GetAccountNumber(),
+			Alignment: alignmentName(from.GetAlignment()), Amount: transfer.Amount},
+	}}
+	if fee == 0 {
+		return ret, nil
+	}
+	feeAccountNumber, ok := tm.config.FeeAccounts[from.GetCurrency()]
+	if !ok {
+		return nil, fmt.Errorf("%w : no fee account in %s", hwerrors.ErrTransferFeeNotConfigured, from.GetCurrency())
+	}
+	feeAccount, err := tm.accountManager.GetAccountByID(ctx, feeAccountNumber)
+	if err != nil || feeAccount == nil || checkTransferAccount(from, feeAccount) != nil {
+		return nil, fmt.Errorf("%w : fee account %s should be an account in %s aligned %s", hwerrors.ErrTransferFeeNotConfigured,
+			feeAccountNumber, from.GetCurrency(), alignmentName(from.GetAlignment()))
+	}
+	ret.Transactions = append(ret.Transactions, &TransactionRequest{AccountNumber: feeAccountNumber,
+		Description: "Transfer fee", Alignment: alignmentName(from.GetAlignment()), Amount: fee})
+	return ret, nil
+}
+
+// checkTransferAccount makes sure the account receiving from the from account has the same currency and alignment,
+// so the same posting takes from one and gives to the other.
+// Throws ErrInvalidTransfer if it does not.
+func checkTransferAccount(from, to acccore.Account) error {
+	if from.GetCurrency() != to.GetCurrency() {
+		return fmt.Errorf("%w : account %s is in %s, account %s in %s", hwerrors.ErrInvalidTransfer, from.GetAccountNumber(),
+			from.GetCurrency(), to.GetAccountNumber(), to.GetCurrency())
+	}
+	if from.GetAlignment() != to.GetAlignment() {
+		return fmt.Errorf("%w : account %s is aligned %s, account %s %s", hwerrors.ErrInvalidTransfer, from.GetAccountNumber(),
+			alignmentName(from.GetAlignment()), to.GetAccountNumber(), alignmentName(to.GetAlignment()))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/hyperjumptech/acccore"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeSchedule_Fee(t *testing.T) {
+	assert.Equal(t, int64(0), (&FeeSchedule{}).Fee(1000, RoundHalfEven))
+	assert.Equal(t, int64(5), (&FeeSchedule{Fixed: 5}).Fee(1000, RoundHalfEven))
+	// 0.25% of 1,000 is 2.5
+	assert.Equal(t, int64(7), (&FeeSchedule{Fixed: 5, Percentage: big.NewRat(1, 4)}).Fee(1000, RoundHalfEven))
+	assert.Equal(t, int64(8), (&FeeSchedule{Fixed: 5, Percentage: big.NewRat(1, 4)}).Fee(1000, RoundHalfUp))
+	assert.Equal(t, int64(10), (&FeeSchedule{Percentage: big.NewRat(1, 4), Minimum: 10}).Fee(1000, RoundHalfEven))
+	assert.Equal(t, int64(50), (&FeeSchedule{Percentage: big.NewRat(10, 1), Maximum: 50}).Fee(1000, RoundHalfEven))
+
+	assert.NoError(t, (&FeeSchedule{Fixed: 1, Percentage: big.NewRat(100, 1), Minimum: 2, Maximum: 2}).validate())
+	for _, invalid := range []*FeeSchedule{{Fixed: -1}, {Percentage: big.NewRat(-1, 1)}, {Percentage: big.NewRat(201, 2)}, {Minimum: 3, Maximum: 2}} {
+		assert.True(t, errors.Is(invalid.validate(), hwerrors.ErrInvalidTransfer))
+	}
+}
+
+func TestTransferManager_PrepareTransfer(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	acccore.ClearInMemoryTables()
+	accountManager := &acccore.InMemoryAccountManager{}
+	for number, account := range map[string]struct {
+		currency  string
+		alignment acccore.Alignment
+	}{"ALICE": {"IDR", acccore.CREDIT}, "BOB": {"IDR", acccore.CREDIT}, "REVENUE": {"IDR", acccore.CREDIT}, "CASH": {"IDR", acccore.DEBIT},
+		"CAROL": {"USD", acccore.CREDIT}, "DAVE": {"USD", acccore.CREDIT}} {
+		persisted := &acccore.BaseAccount{}
+		persisted.SetAccountNumber(number).SetName(number).SetDescription(number + " test account").SetCOA("1.1").
+			SetCurrency(account.currency).SetAlignment(account.alignment).SetCreateBy("TESTING")
+		assert.NoError(t, accountManager.PersistAccount(ctx, persisted))
+	}
+	transferManager := NewTransferManager(accountManager, TransferConfig{FeeAccounts: map[string]string{"IDR": "REVENUE", "USD": "CASH"}, Rounding: RoundHalfEven})
+
+	request, err := transferManager.PrepareTransfer(ctx, &Transfer{FromAccount: "ALICE", ToAccount: "BOB", Amount: 1000, Fee: &FeeSchedule{Fixed: 10}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Transfer from ALICE to BOB", request.Description)
+	assert.Equal(t, []*TransactionRequest{
+		{AccountNumber: "ALICE", Description: "Transfer to BOB", Alignment: "DEBIT", Amount: 1010},
+		{AccountNumber: "BOB", Description: "Transfer from ALICE", Alignment: "CREDIT", Amount: 1000},
+		{AccountNumber: "REVENUE", Description: "Transfer fee", Alignment: "CREDIT", Amount: 10},
+	}, request.Transactions)
+
+	// a fee rounded to zero leaves the fee leg out, the fee account is not needed
+	request, err = transferManager.PrepareTransfer(ctx, &Transfer{FromAccount: "CAROL", ToAccount: "DAVE", Amount: 10, Description: "Rent",
+		Fee: &FeeSchedule{Percentage: big.NewRat(1, 1)}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Rent", request.Description)
+	assert.Len(t, request.Transactions, 2)
+
+	for name, transfer := range map[string]*Transfer{
+		"amount":    {FromAccount: "ALICE", ToAccount: "BOB", Amount: 0},
+		"itself":    {FromAccount: "ALICE", ToAccount: "ALICE", Amount: 10},
+		"currency":  {FromAccount: "ALICE", ToAccount: "CAROL", Amount: 10},
+		"alignment": {FromAccount: "ALICE", ToAccount: "CASH", Amount: 10},
+		"fee":       {FromAccount: "ALICE", ToAccount: "BOB", Amount: 10, Fee: &FeeSchedule{Fixed: -1}},
+	} {
+		_, err = transferManager.PrepareTransfer(ctx, transfer)
+		assert.True(t, errors.Is(err, hwerrors.ErrInvalidTransfer), name)
+	}
+	_, err = transferManager.PrepareTransfer(ctx, &Transfer{FromAccount: "ALICE", ToAccount: "NOBODY", Amount: 10})
+	assert.True(t, errors.Is(err, acccore.ErrAccountIDNotFound))
+	// the USD fee account is not aligned as the USD accounts
+	_, err = transferManager.PrepareTransfer(ctx, &Transfer{FromAccount: "CAROL", ToAccount: "DAVE", Amount: 10, Fee: &FeeSchedule{Fixed: 1}})
+	assert.True(t, errors.Is(err, hwerrors.ErrTransferFeeNotConfigured))
+	transferManager = NewTransferManager(accountManager, TransferConfig{Rounding: RoundHalfEven})
+	_, err = transferManager.PrepareTransfer(ctx, &Transfer{FromAccount: "ALICE", ToAccount: "BOB", Amount: 10, Fee: &FeeSchedule{Fixed: 1}})
+	assert.True(t, errors.Is(err, hwerrors.ErrTransferFeeNotConfigured))
+}
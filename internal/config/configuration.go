@@ -39,11 +39,14 @@ func LoadConfig() {
 
 	defCfg["server.context.timeout"] = "30" // seconds
 
+	defCfg["db.driver"] = "mysql" // mysql, postgres or sqlite
 	defCfg["db.host"] = "localhost"
 	defCfg["db.port"] = "3306"
 	defCfg["db.user"] = "wallet_user"
 	defCfg["db.password"] = "wallet"
-	defCfg["db.name"] = "wallet"
+	defCfg["db.name"] = "wallet"     // for sqlite, the database file or :memory:
+	defCfg["db.sslmode"] = "disable" // postgres only
+	defCfg["db.migrate"] = "false"   // apply pending migrations on start up, a :memory: sqlite database needs it
 
 	defCfg["health.local"] = "https://httpbin.org/status/200"
 	defCfg["health.delay"] = "1"     // seconds
@@ -52,6 +55,33 @@ func LoadConfig() {
 	defCfg["hmac.secret"] = "th1s?MusT#b3!4*veRY%d33p#53creT"
 	defCfg["hmac.age.minute"] = "10"
 
+	defCfg["idempotency.window.minute"] = "1440"       // how long an Idempotency-Key is remembered
+	defCfg["idempotency.purge.interval.minute"] = "60" // how often expired Idempotency-Keys are deleted
+
+	defCfg["hold.expiry.minute"] = "10080"      // how long a hold placed without expiry reserves its amount
+	defCfg["hold.expire.interval.minute"] = "5" // how often holds past their expiry are marked expired
+
+	defCfg["exchange.rounding"] = "half-even" // how exchanged amounts are rounded : half-even, half-up or floor
+
+	defCfg["fx.base.currency"] = ""     // currency multi currency journals are valued in, empty disables them
+	defCfg["fx.clearing.accounts"] = "" // clearing account of each currency, such as USD:1000001,IDR:1000002
+	defCfg["fx.gainloss.account"] = ""  // account in the base currency receiving the FX gain or loss
+	defCfg["fx.gainloss.limit"] = "0"   // largest FX gain or loss a journal may generate in the base currency, 0 for no limit
+
+	defCfg["journal.backdating.window.minute"] = "44640" // how far in the past a journal may take effect, its effective time can not be in the future
+	defCfg["journal.batch.max.size"] = "1000"            // most journals a batch may post
+	defCfg["journal.batch.chunk.size"] = "100"           // journals whose accounts are read at once when posting a batch
+
+	defCfg["period.retained.earnings.accounts"] = "" // retained earnings account of each currency closed periods roll into, such as USD:3000001,IDR:3000002
+
+	defCfg["transfer.fee.accounts"] = "" // revenue account of each currency transfer fees go into, such as USD:4000001,IDR:4000002
+
+	defCfg["schedule.enabled"] = "true"            // whether this replica posts the due journal schedules
+	defCfg["schedule.poll.interval.second"] = "30" // how often the due journal schedules are looked for
+	defCfg["schedule.lease.minute"] = "5"          // how long a replica holds the due schedules it claimed, longer than posting a batch takes
+	defCfg["schedule.batch.size"] = "10"           // most due schedules a replica claims at once
+	defCfg["schedule.owner"] = ""                  // identifies this replica in the schedule leases and run history, the host name and process ID if empty
+
 	for k := range defCfg {
 		err := viper.BindEnv(k)
 		if err != nil {
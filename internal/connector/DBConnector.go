@@ -6,8 +6,6 @@ import (
 
 	"github.com/jmoiron/sqlx"
 
-	//Anonymous import for mysql initialization
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,6 +13,17 @@ var (
 	log = logrus.WithField("module", "DBConnector")
 )
 
+// DateBasis tells which of their dates journals and transactions are listed on.
+type DateBasis string
+
+const (
+	// EffectiveDate lists on the journaling time of journals and the transaction time of transactions,
+	// the time they take effect on the balances
+	EffectiveDate DateBasis = "effective"
+	// BookingDate lists on the time journals and transactions were recorded
+	BookingDate DateBasis = "booking"
+)
+
 // AccountRecord an entity representative of Account table
 type AccountRecord struct {
 	// AccountNumber related to account_number column
@@ -39,6 +48,14 @@ type AccountRecord struct {
 	UpdatedAt time.Time
 	// UpdatedBy related to updated_by column
 	UpdatedBy string
+	// Status related to status column, ACTIVE, FROZEN or CLOSED
+	Status string
+	// AllowNegative related to allow_negative column
+	AllowNegative bool
+	// MinBalance related to min_balance column, nil if the balance has no minimum
+	MinBalance *int64
+	// MaxBalance related to max_balance column, nil if the balance has no maximum
+	MaxBalance *int64
 }
 
 // JournalRecord an entity representative of Journal table
@@ -59,6 +76,21 @@ type JournalRecord struct {
 	CreatedAt time.Time
 	// CreatedBy related to created_by column
 	CreatedBy string
+	// ChainSequence related to chain_sequence column, the position of the journal in the hash chain.
+	// zero for the journals persisted before the chain.
+	ChainSequence int64
+	// PreviousHash related to previous_hash column, the chain hash of the journal before this one in the chain
+	PreviousHash string
+	// ChainHash related to chain_hash column, the hash of the journal, its transactions and the previous hash
+	ChainHash string
+}
+
+// JournalChainRecord an entity representative of the single row of the Journal Chain table, the last journal of the chain
+type JournalChainRecord struct {
+	// LastSequence related to last_sequence column, zero if no journal is chained yet
+	LastSequence int64
+	// LastHash related to last_hash column
+	LastHash string
 }
 
 // TransactionRecord an entity representative of Transaction table
@@ -91,8 +123,97 @@ type CurrenciesRecord struct {
 	Code string
 	// Name related to name column
 	Name string
-	// Exchange related to exchange column
-	Exchange float64
+	// Exchange related to exchange column. is the exact decimal exchange unit, at most MaxExchangeScale digits after the decimal point.
+	Exchange string
+	// CreatedAt related to created_at column
+	CreatedAt time.Time
+	// CreatedBy related to created_by column
+	CreatedBy string
+	// UpdatedAt related to updated_at column
+	UpdatedAt time.Time
+	// UpdatedBy related to updated_by column
+	UpdatedBy string
+}
+
+// CurrencyRateRecord an entity representative of CurrencyRates table, an exchange unit a currency was given.
+type CurrencyRateRecord struct {
+	// Code related to code column
+	Code string
+	// Exchange related to exchange column. is the exact decimal exchange unit, at most MaxExchangeScale digits after the decimal point.
+	Exchange string
+	// EffectiveAt related to effective_at column. is the time since the exchange unit is in effect
+	EffectiveAt time.Time
+	// CreatedBy related to created_by column
+	CreatedBy string
+}
+
+// MaxExchangeScale is the scale of the currencies exchange column, the most digits an exchange unit can have after the decimal point.
+const MaxExchangeScale = 18
+
+// MaxIdempotencyKeyLength is the size of the idempotency_key column, the longest idempotency key that can be stored.
+const MaxIdempotencyKeyLength = 64
+
+// IdempotencyKeyRecord an entity representative of IdempotencyKey table
+type IdempotencyKeyRecord struct {
+	// IdempotencyKey related to idempotency_key column
+	IdempotencyKey string
+	// RequestHash related to request_hash column. is the hash of the request that first used the key.
+	RequestHash string
+	// JournalID related to journal_id column. is the journal created by the request that first used the key.
+	JournalID string
+	// ResponseBody related to response_body column. is the response body sent to the request that first used the key.
+	ResponseBody string
+	// CreatedAt related to created_at column
+	CreatedAt time.Time
+	// CreatedBy related to created_by column
+	CreatedBy string
+}
+
+// MaxSettingValueLength is the size of the settings value column, the longest setting value that can be stored.
+const MaxSettingValueLength = 255
+
+// SettingRecord an entity representative of Settings table
+type SettingRecord struct {
+	// Name related to name column
+	Name string
+	// Value related to value column
+	Value string
+	// UpdatedAt related to updated_at column
+	UpdatedAt time.Time
+	// UpdatedBy related to updated_by column
+	UpdatedBy string
+}
+
+// SettingHistoryRecord an entity representative of SettingHistory table. is one value a setting was given.
+type SettingHistoryRecord struct {
+	// Name related to name column
+	Name string
+	// Value related to value column
+	Value string
+	// CreatedAt related to created_at column. is the time the setting was given the value.
+	CreatedAt time.Time
+	// CreatedBy related to created_by column. is who gave the setting the value.
+	CreatedBy string
+}
+
+// HoldRecord an entity representative of AccountHolds table
+type HoldRecord struct {
+	// HoldID related to hold_id column
+	HoldID string
+	// AccountNumber related to account_number column
+	AccountNumber string
+	// Amount related to amount column. is the amount reserved on the account.
+	Amount int64
+	// Description related to description column
+	Description string
+	// Status related to status column, HELD, CAPTURED, RELEASED or EXPIRED
+	Status string
+	// ExpiresAt related to expires_at column. is the time a HELD hold stops reserving its amount.
+	ExpiresAt time.Time
+	// CapturedAmount related to captured_amount column. is the amount posted when the hold is captured.
+	CapturedAmount int64
+	// JournalID related to journal_id column. is the journal posted when the hold is captured.
+	JournalID string
 	// CreatedAt related to created_at column
 	CreatedAt time.Time
 	// CreatedBy related to created_by column
@@ -103,6 +224,150 @@ type CurrenciesRecord struct {
 	UpdatedBy string
 }
 
+// CoaRecord an entity representative of ChartOfAccounts table, a node of the chart of accounts tree
+type CoaRecord struct {
+	// Code related to code column
+	Code string
+	// ParentCode related to parent_code column. is empty for the root nodes.
+	ParentCode string
+	// Name related to name column
+	Name string
+	// Type related to coa_type column, ASSET, LIABILITY, EQUITY, INCOME or EXPENSE
+	Type string
+	// Alignment related to alignment column. is the normal alignment of the accounts under the node.
+	Alignment string
+	// CreatedAt related to created_at column
+	CreatedAt time.Time
+	// CreatedBy related to created_by column
+	CreatedBy string
+	// UpdatedAt related to updated_at column
+	UpdatedAt time.Time
+	// UpdatedBy related to updated_by column
+	UpdatedBy string
+}
+
+// CoaBalanceRecord is the total balance of the accounts of some COA sharing a currency and an alignment
+type CoaBalanceRecord struct {
+	// CurrencyCode related to the accounts currency_code column
+	CurrencyCode string
+	// Alignment related to the accounts alignment column
+	Alignment string
+	// Balance is the sum of the accounts balance
+	Balance int64
+	// Accounts is the number of accounts summed
+	Accounts int
+}
+
+// AccountTotalRecord is the total debit and credit of the transactions of an account
+type AccountTotalRecord struct {
+	// AccountNumber related to the accounts account_number column
+	AccountNumber string
+	// Name related to the accounts name column
+	Name string
+	// Coa related to the accounts coa column
+	Coa string
+	// CurrencyCode related to the accounts currency_code column
+	CurrencyCode string
+	// Alignment related to the accounts alignment column
+	Alignment string
+	// Debit is the sum of the amount of the DEBIT transactions
+	Debit int64
+	// Credit is the sum of the amount of the CREDIT transactions
+	Credit int64
+}
+
+// JournalTotalRecord is the total debit and credit of the transactions of a journal in a currency
+type JournalTotalRecord struct {
+	// JournalID related to the transactions journal_id column
+	JournalID string
+	// CurrencyCode related to the accounts currency_code column
+	CurrencyCode string
+	// Debit is the sum of the amount of the DEBIT transactions
+	Debit int64
+	// Credit is the sum of the amount of the CREDIT transactions
+	Credit int64
+}
+
+// ReversedAmountRecord is the amount of a journal leg reversed by the reversal journals of the journal
+type ReversedAmountRecord struct {
+	// AccountNumber is the account of the leg, a journal has a single leg on an account
+	AccountNumber string
+	// Amount is the sum of the amount of the reversal transactions on the account
+	Amount int64
+}
+
+// PeriodRecord an entity representative of the Accounting Periods table, a closed accounting period
+type PeriodRecord struct {
+	// Period related to period_code column, the month written as YYYY-MM
+	Period string
+	// ClosingJournalIDs related to closing_journal_ids column, the comma separated closing journals
+	ClosingJournalIDs string
+	// ClosedAt related to closed_at column
+	ClosedAt time.Time
+	// ClosedBy related to closed_by column
+	ClosedBy string
+}
+
+// JournalTemplateRecord an entity representative of the Journal Templates table
+type JournalTemplateRecord struct {
+	// Name related to template_name column
+	Name string
+	// Description related to description column
+	Description string
+	// Definition related to definition column, the parameters and legs of the template written as json
+	Definition string
+	// CreatedAt related to created_at column
+	CreatedAt time.Time
+	// CreatedBy related to created_by column
+	CreatedBy string
+}
+
+// JournalScheduleRecord an entity representative of the Journal Schedules table
+type JournalScheduleRecord struct {
+	// ScheduleID related to schedule_id column
+	ScheduleID string
+	// TemplateName related to template_name column. is the journal template the schedule posts.
+	TemplateName string
+	// Cron related to cron column. is the cron expression of the times the template is posted.
+	Cron string
+	// TimeZone related to time_zone column. is the time zone the cron expression is read in.
+	TimeZone string
+	// Params related to params column, the values of the template parameters written as json
+	Params string
+	// NextRunAt related to next_run_at column. is the next occurrence of the schedule.
+	NextRunAt time.Time
+	// LeaseOwner related to lease_owner column. is the replica posting the schedule, empty if none.
+	LeaseOwner string
+	// LeaseUntil related to lease_until column. is the time the lease of the owner expires.
+	LeaseUntil time.Time
+	// CreatedAt related to created_at column
+	CreatedAt time.Time
+	// CreatedBy related to created_by column
+	CreatedBy string
+}
+
+// ScheduleRunRecord an entity representative of the Schedule Runs table
+type ScheduleRunRecord struct {
+	// RunID related to run_id column
+	RunID string
+	// ScheduleID related to schedule_id column
+	ScheduleID string
+	// ScheduledAt related to scheduled_at column. is the occurrence of the schedule the run posts.
+	ScheduledAt time.Time
+	// StartedAt related to started_at column
+	StartedAt time.Time
+	// FinishedAt related to finished_at column. zero while the run is RUNNING.
+	FinishedAt time.Time
+	// Status related to status column, RUNNING, POSTED or FAILED
+	Status string
+	// JournalID related to journal_id column. is the journal the run posts.
+	JournalID string
+	// Error related to error column. is why a FAILED run was refused.
+	Error string
+	// Runner related to runner column. is the replica that started the run.
+	Runner string
+}
+
 // DBRepository is the database structure
 type DBRepository interface {
 	// Connect connect there repository to the database, it uses the configuration internally for connection arguments and parameters.
@@ -120,17 +385,23 @@ type DBRepository interface {
 	// ClearTables clear all table for testing purpose
 	ClearTables(ctx context.Context) error
 
+	// ExecuteInTransaction runs fn as a single unit of work. Every call made on the txRepo handed to fn is
+	// executed inside the same database transaction, which is committed when fn returns nil and rolled back
+	// when fn returns an error or panics. If the repository is already bound to a transaction, fn joins it.
+	ExecuteInTransaction(ctx context.Context, fn func(ctx context.Context, txRepo DBRepository) error) error
+
 	// InsertAccount insert an entity record of account into database.
 	// Throws error if the underlying connection have problem.
 	// The rec argument contains the Account information to be written.
 	// It returns the account number that written into database.
-	// The AccountNumber contained within the rec MUST NOT be perstited before.
+	// The AccountNumber contained within the rec MUST NOT be perstited before. An account without Status is ACTIVE.
 	InsertAccount(ctx context.Context, rec *AccountRecord) (string, error)
 
 	// UpdateAccount update an account entity record in the database.
 	// Throws error if the underlying database connection has problem.
 	// The rec argument contains the Account information to be updated.
 	// The AccountNumber contained within the rec MUST be already persisted before.
+	// The Status and balance limits are written as they are, rec should be read from the database to keep them.
 	UpdateAccount(ctx context.Context, rec *AccountRecord) error
 
 	// DeleteAccount soft/logical delete an account.
@@ -144,6 +415,18 @@ type DBRepository interface {
 	// It returns an instance of AccountRecord
 	GetAccount(ctx context.Context, accountNumber string) (*AccountRecord, error)
 
+	// GetAccountForUpdate retrieves an AccountRecord like GetAccount does and places an exclusive lock on its row
+	// (SELECT ... FOR UPDATE) that is held until the surrounding transaction is committed or rolled back.
+	// Throws ErrNotInTransaction if the repository is not bound to a transaction (see ExecuteInTransaction).
+	// It returns an instance of AccountRecord or nil if there is no Account with specified accountNumber.
+	GetAccountForUpdate(ctx context.Context, accountNumber string) (*AccountRecord, error)
+
+	// ListAccountByNumbers retrieves, in a single query, the AccountRecords from database whose account number
+	// is one of the specified accountNumbers.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of AccountRecord sorted by account number, leaving out the account numbers not found
+	ListAccountByNumbers(ctx context.Context, accountNumbers []string) ([]*AccountRecord, error)
+
 	// ListAccount will list account in paginated fashion.
 	// Throws error if the underlying database connection has problem.
 	// It will return AccountRecords sorted, starting from the offset with total maximum number or item, specified
@@ -210,23 +493,29 @@ type DBRepository interface {
 	// It returns an instance of JournalRecord
 	GetJournal(ctx context.Context, journalID string) (*JournalRecord, error)
 
-	// GetJournalByReversalID retrieves an JournalRecord from database where the reversedJournalID is specified.
-	// Throws error if  the underlying database connection has problem. or, if there is no Journal with
-	// specified reversedJournalID.
-	// It returns an instance of JournalRecord
+	// GetJournalByReversalID retrieves the first JournalRecord from database where the reversedJournalID is specified.
+	// Throws error if  the underlying database connection has problem.
+	// It returns an instance of JournalRecord or nil if the journal was never reversed
 	GetJournalByReversalID(ctx context.Context, journalID string) (*JournalRecord, error)
 
-	// ListJournalByTimeRange will list journals in paginated fashion where journal is in the specified time range.
+	// SumReversedAmountByJournalID will return, for every account of the journal, the amount reversed by the
+	// reversal journals of the specified journalID.
 	// Throws error if the underlying database connection has problem.
-	// It will return JournalRecord sorted, starting from the offset with total maximum number or item, specified
+	// It returns list of ReversedAmountRecord, empty if the journal was never reversed
+	SumReversedAmountByJournalID(ctx context.Context, journalID string) ([]*ReversedAmountRecord, error)
+
+	// ListJournalByTimeRange will list journals in paginated fashion where the date of the journal selected by
+	// the basis is in the specified time range.
+	// Throws error if the underlying database connection has problem.
+	// It will return JournalRecord sorted by that date, starting from the offset with total maximum number or item, specified
 	// in the length argument.
 	// It returns list of JournalRecord
-	ListJournalByTimeRange(ctx context.Context, timeFrom, timeTo time.Time, sort string, offset, length int) ([]*JournalRecord, error)
+	ListJournalByTimeRange(ctx context.Context, basis DateBasis, timeFrom, timeTo time.Time, offset, length int) ([]*JournalRecord, error)
 
-	// CountJournalByTimeRange will return a number of journals in database that been created within the time range.
+	// CountJournalByTimeRange will return a number of journals in database whose date selected by the basis is within the time range.
 	// Throws error if the underlying database connection has problem.
 	// It will returns total number of journals in the database.
-	CountJournalByTimeRange(ctx context.Context, timeFrom, timeTo time.Time) (int, error)
+	CountJournalByTimeRange(ctx context.Context, basis DateBasis, timeFrom, timeTo time.Time) (int, error)
 
 	// InsertTransaction will insert the data specified in the rec argument into database
 	// will return error if the underlying database connection has problem. or if the
@@ -259,18 +548,18 @@ type DBRepository interface {
 	GetTransaction(ctx context.Context, transactionID string) (*TransactionRecord, error)
 
 	// ListTransactionByAccountNumber will list transactions in paginated fashion, the transaction must belong to the
-	// specified accountNumber arguments and created within the time rage.
+	// specified accountNumber arguments and its date selected by the basis must be within the time rage.
 	// Throws error if the underlying database connection has problem.
-	// It will return TransactionRecord sorted, starting from the offset with total maximum number or item, specified
+	// It will return TransactionRecord sorted by that date, starting from the offset with total maximum number or item, specified
 	// in the length argument.
 	// It returns list of TransactionRecord
-	ListTransactionByAccountNumber(ctx context.Context, accountNumber string, timeFrom, timeTo time.Time, offset, length int) ([]*TransactionRecord, error)
+	ListTransactionByAccountNumber(ctx context.Context, accountNumber string, basis DateBasis, timeFrom, timeTo time.Time, offset, length int) ([]*TransactionRecord, error)
 
 	// CountTransactionByAccountNumber will return a number of accounts in database that belong to a speciffic
-	// accountNumber andbeen created within the time range.
+	// accountNumber and whose date selected by the basis is within the time range.
 	// Throws error if the underlying database connection has problem.
 	// It will returns total number of transaction in the database as specified in the argument.
-	CountTransactionByAccountNumber(ctx context.Context, accountNumber string, timeFrom, timeTo time.Time) (int, error)
+	CountTransactionByAccountNumber(ctx context.Context, accountNumber string, basis DateBasis, timeFrom, timeTo time.Time) (int, error)
 
 	// ListTransactionByJournalID will list transactions , the transaction must belong to the
 	// specified journalID arguments.
@@ -279,13 +568,15 @@ type DBRepository interface {
 	// It returns list of TransactionRecord
 	ListTransactionByJournalID(ctx context.Context, journalID string) ([]*TransactionRecord, error)
 
-	// InsertCurrency will insert the data specified in the rec argument into database
+	// InsertCurrency will insert the data specified in the rec argument into database and record its exchange
+	// unit in the currency rates, effective since rec.UpdatedAt, both in the same database transaction.
 	// will return error if the underlying database connection has problem. or if the
 	// Currency Code already in the database.
 	// Will return the Currency Code saved if successful.
 	InsertCurrency(ctx context.Context, rec *CurrenciesRecord) (string, error)
 
-	// UpdateCurrency update an currency entity record in the database.
+	// UpdateCurrency update an currency entity record in the database and record its exchange unit in the
+	// currency rates, effective since rec.UpdatedAt, both in the same database transaction.
 	// Throws error if the underlying database connection has problem.
 	// The rec argument contains the Currency information to be updated.
 	// The Currency Code contained within the rec MUST be already persisted before.
@@ -308,4 +599,255 @@ type DBRepository interface {
 	// specified code.
 	// It returns an instance of CurrenciesRecord
 	GetCurrency(ctx context.Context, code string) (*CurrenciesRecord, error)
+
+	// GetCurrencyRateAt retrieves the CurrencyRateRecord of the specified currency code that is in effect at the
+	// specified time, the latest one that became effective at or before it.
+	// Throws error if the underlying database connection has problem.
+	// It returns an instance of CurrencyRateRecord or nil if the currency had no exchange unit at that time.
+	GetCurrencyRateAt(ctx context.Context, code string, at time.Time) (*CurrencyRateRecord, error)
+
+	// InsertIdempotencyKey will insert the data specified in the rec argument into database
+	// will return error if the underlying database connection has problem. or ErrIdempotencyKeyExists if the
+	// IdempotencyKey already in the database.
+	// Will return the IdempotencyKey saved if successful.
+	InsertIdempotencyKey(ctx context.Context, rec *IdempotencyKeyRecord) (string, error)
+
+	// GetIdempotencyKey retrieves an IdempotencyKeyRecord from database where the key is specified.
+	// Throws error if the underlying database connection has problem.
+	// It returns an instance of IdempotencyKeyRecord or nil if there is no record with specified key.
+	GetIdempotencyKey(ctx context.Context, key string) (*IdempotencyKeyRecord, error)
+
+	// DeleteIdempotencyKey permanently delete an idempotency key record, so the key can be used again.
+	// Throws error if the underlying database connection has problem.
+	// If the key not exist, it will do nothing and return nil.
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+
+	// DeleteIdempotencyKeysBefore permanently delete all idempotency key records created before the specified time.
+	// Throws error if the underlying database connection has problem.
+	// It returns the number of records deleted.
+	DeleteIdempotencyKeysBefore(ctx context.Context, before time.Time) (int64, error)
+
+	// GetSetting retrieves a SettingRecord from database where the name is specified.
+	// Throws error if the underlying database connection has problem.
+	// It returns an instance of SettingRecord or nil if the setting was never set.
+	GetSetting(ctx context.Context, name string) (*SettingRecord, error)
+
+	// SetSetting insert or update the setting specified in the rec argument and record the value in the setting
+	// history, both in the same database transaction.
+	// Throws error if the underlying database connection has problem.
+	SetSetting(ctx context.Context, rec *SettingRecord) error
+
+	// ListSettingHistory will list the values a setting was given in paginated fashion, latest first.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of SettingHistoryRecord
+	ListSettingHistory(ctx context.Context, name string, offset, length int) ([]*SettingHistoryRecord, error)
+
+	// CountSettingHistory will return the number of values a setting was given.
+	// Throws error if the underlying database connection has problem.
+	CountSettingHistory(ctx context.Context, name string) (int, error)
+
+	// InsertHold will insert the data specified in the rec argument into database
+	// will return error if the underlying database connection has problem. or if the
+	// HoldID already in the database.
+	// Will return the HoldID saved if successful.
+	InsertHold(ctx context.Context, rec *HoldRecord) (string, error)
+
+	// GetHold retrieves a HoldRecord from database where the holdID is specified.
+	// Throws error if the underlying database connection has problem.
+	// It returns an instance of HoldRecord or nil if there is no hold with specified holdID.
+	GetHold(ctx context.Context, holdID string) (*HoldRecord, error)
+
+	// UpdateHoldStatus writes the status, captured amount, journal ID and update time and author of rec,
+	// only if the hold is still in the fromStatus.
+	// Throws error if the underlying database connection has problem.
+	// It returns false if the hold is not in the fromStatus, so two concurrent changes of a hold can not both succeed.
+	UpdateHoldStatus(ctx context.Context, rec *HoldRecord, fromStatus string) (bool, error)
+
+	// SumAccountHolds will return the total amount of the HELD holds of the account that expire after the specified time.
+	// Throws error if the underlying database connection has problem.
+	SumAccountHolds(ctx context.Context, accountNumber string, at time.Time) (int64, error)
+
+	// ExpireHolds moves every HELD hold that expires at or before the specified time into EXPIRED.
+	// Throws error if the underlying database connection has problem.
+	// It returns the number of holds expired.
+	ExpireHolds(ctx context.Context, at time.Time, updatedBy string) (int64, error)
+
+	// InsertCoa will insert the data specified in the rec argument into database
+	// will return error if the underlying database connection has problem. or if the
+	// Code already in the database.
+	// Will return the Code saved if successful.
+	InsertCoa(ctx context.Context, rec *CoaRecord) (string, error)
+
+	// UpdateCoa update the parent code, name and update time and author of a COA entity record in the database.
+	// Throws error if the underlying database connection has problem.
+	// The Code contained within the rec MUST be already persisted before.
+	UpdateCoa(ctx context.Context, rec *CoaRecord) error
+
+	// DeleteCoa permanently delete a COA entity record.
+	// Throws error if the underlying database connection has problem.
+	// If the Code not exist, it will do nothing and return nil.
+	DeleteCoa(ctx context.Context, code string) error
+
+	// GetCoa retrieves a CoaRecord from database where the code is specified.
+	// Throws error if the underlying database connection has problem.
+	// It returns an instance of CoaRecord or nil if there is no COA with specified code.
+	GetCoa(ctx context.Context, code string) (*CoaRecord, error)
+
+	// ListCoa will list the whole chart of accounts sorted by code.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of CoaRecord
+	ListCoa(ctx context.Context) ([]*CoaRecord, error)
+
+	// SumAccountBalanceByCoa will return the total balance of the accounts having one of the specified COA,
+	// per currency and alignment.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of CoaBalanceRecord
+	SumAccountBalanceByCoa(ctx context.Context, coas []string) ([]*CoaBalanceRecord, error)
+
+	// ListAccountTotals will return the total debit and credit of the transactions of every account made at or before the specified time,
+	// accounts without transactions have zero totals. Only the accounts in the currency are listed if it is not empty.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of AccountTotalRecord sorted by currency, coa and account number
+	ListAccountTotals(ctx context.Context, at time.Time, currency string) ([]*AccountTotalRecord, error)
+
+	// ListAccountTransactions will list every transaction of the account in the order they were posted.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of TransactionRecord sorted by creation time and transaction time
+	ListAccountTransactions(ctx context.Context, accountNumber string) ([]*TransactionRecord, error)
+
+	// ListUnbalancedJournalTotals will return the total debit and credit of the journals whose debit and credit
+	// differ in some currency, the transactions of each journal are summed per currency of their accounts.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of JournalTotalRecord sorted by journal ID and currency
+	ListUnbalancedJournalTotals(ctx context.Context) ([]*JournalTotalRecord, error)
+
+	// ListOrphanTransactions will list the transactions whose account or journal does not exist or has been deleted.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of TransactionRecord sorted by transaction ID
+	ListOrphanTransactions(ctx context.Context) ([]*TransactionRecord, error)
+
+	// GetJournalChainForUpdate retrieves the last journal of the hash chain and places an exclusive lock on it
+	// until the transaction the repository is bound to ends, journals are chained one at a time.
+	// Throws ErrNotInTransaction if the repository is not bound to a transaction (see ExecuteInTransaction).
+	// Throws error if the underlying database connection has problem.
+	GetJournalChainForUpdate(ctx context.Context) (*JournalChainRecord, error)
+
+	// UpdateJournalChain moves the last journal of the hash chain.
+	// Throws error if the underlying database connection has problem.
+	UpdateJournalChain(ctx context.Context, rec *JournalChainRecord) error
+
+	// UpdateJournalHash sets the chain hash of a journal.
+	// Throws error if the underlying database connection has problem.
+	UpdateJournalHash(ctx context.Context, journalID, chainHash string) error
+
+	// GetJournalChainRange will return the first and last chain sequence of the journals booked within the time range, inclusive.
+	// Throws error if the underlying database connection has problem.
+	// It returns zeros if no chained journal was booked within the time range.
+	GetJournalChainRange(ctx context.Context, timeFrom, timeTo time.Time) (int64, int64, error)
+
+	// ListJournalBySequence will list the chained journals whose chain sequence is within the range, inclusive.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of JournalRecord sorted by chain sequence
+	ListJournalBySequence(ctx context.Context, sequenceFrom, sequenceTo int64) ([]*JournalRecord, error)
+
+	// InsertPeriod will insert a closed accounting period.
+	// Throws error if the underlying database connection has problem, or the period is already closed.
+	InsertPeriod(ctx context.Context, rec *PeriodRecord) error
+
+	// GetPeriod retrieves a closed accounting period.
+	// Throws error if the underlying database connection has problem.
+	// It returns an instance of PeriodRecord or nil if the period was not closed on its own.
+	GetPeriod(ctx context.Context, period string) (*PeriodRecord, error)
+
+	// GetLastClosedPeriod retrieves the latest closed accounting period.
+	// Throws error if the underlying database connection has problem.
+	// It returns an instance of PeriodRecord or nil if no period is closed.
+	GetLastClosedPeriod(ctx context.Context) (*PeriodRecord, error)
+
+	// ListPeriods will list the closed accounting periods.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of PeriodRecord sorted by period
+	ListPeriods(ctx context.Context) ([]*PeriodRecord, error)
+
+	// InsertJournalTemplate will insert a journal template.
+	// Throws error if the underlying database connection has problem, or the template name is taken.
+	InsertJournalTemplate(ctx context.Context, rec *JournalTemplateRecord) error
+
+	// GetJournalTemplate retrieves a journal template by its name.
+	// Throws error if the underlying database connection has problem.
+	// It returns an instance of JournalTemplateRecord or nil if there is no template with the name.
+	GetJournalTemplate(ctx context.Context, name string) (*JournalTemplateRecord, error)
+
+	// ListJournalTemplates will list all the journal templates.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of JournalTemplateRecord sorted by name
+	ListJournalTemplates(ctx context.Context) ([]*JournalTemplateRecord, error)
+
+	// DeleteJournalTemplate permanently delete a journal template.
+	// Throws error if the underlying database connection has problem.
+	DeleteJournalTemplate(ctx context.Context, name string) error
+
+	// InsertJournalSchedule will insert a journal schedule.
+	// Throws error if the underlying database connection has problem, or the schedule ID is taken.
+	InsertJournalSchedule(ctx context.Context, rec *JournalScheduleRecord) error
+
+	// GetJournalSchedule retrieves a journal schedule by its ID.
+	// Throws error if the underlying database connection has problem.
+	// It returns an instance of JournalScheduleRecord or nil if the schedule does not exist.
+	GetJournalSchedule(ctx context.Context, scheduleID string) (*JournalScheduleRecord, error)
+
+	// ListJournalSchedules will list all the journal schedules.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of JournalScheduleRecord sorted by creation time
+	ListJournalSchedules(ctx context.Context) ([]*JournalScheduleRecord, error)
+
+	// DeleteJournalSchedule permanently delete a journal schedule.
+	// Throws error if the underlying database connection has problem.
+	DeleteJournalSchedule(ctx context.Context, scheduleID string) error
+
+	// CountJournalSchedulesByTemplate will count the journal schedules posting the template.
+	// Throws error if the underlying database connection has problem.
+	CountJournalSchedulesByTemplate(ctx context.Context, templateName string) (int, error)
+
+	// ListDueJournalSchedules will list the journal schedules whose next occurrence is at or before the specified
+	// time and that are released or whose lease has expired.
+	// Throws error if the underlying database connection has problem.
+	// It returns at most limit JournalScheduleRecord sorted by next occurrence
+	ListDueJournalSchedules(ctx context.Context, at time.Time, limit int) ([]*JournalScheduleRecord, error)
+
+	// LeaseJournalSchedule gives the lease of the journal schedule to the owner until the specified time,
+	// only if the schedule is due and released or its lease has expired at the time specified by at.
+	// Throws error if the underlying database connection has problem.
+	// It returns false if the schedule is not due or another owner holds its lease.
+	LeaseJournalSchedule(ctx context.Context, scheduleID, owner string, at, until time.Time) (bool, error)
+
+	// AdvanceJournalSchedule moves the journal schedule to its next occurrence, only if the owner holds its lease.
+	// Throws error if the underlying database connection has problem.
+	// It returns false if the owner does not hold the lease.
+	AdvanceJournalSchedule(ctx context.Context, scheduleID, owner string, nextRunAt time.Time) (bool, error)
+
+	// ReleaseJournalSchedule ends the lease of the owner on the journal schedule at the specified time.
+	// Throws error if the underlying database connection has problem.
+	ReleaseJournalSchedule(ctx context.Context, scheduleID, owner string, at time.Time) error
+
+	// InsertScheduleRun will insert a run of a journal schedule.
+	// Throws error if the underlying database connection has problem, or the occurrence already has a run.
+	InsertScheduleRun(ctx context.Context, rec *ScheduleRunRecord) error
+
+	// UpdateScheduleRun writes the status, finish time, journal ID and error of the schedule run.
+	// Throws error if the underlying database connection has problem.
+	UpdateScheduleRun(ctx context.Context, rec *ScheduleRunRecord) error
+
+	// ListScheduleRuns will list the runs of the journal schedule in paginated fashion.
+	// Throws error if the underlying database connection has problem.
+	// It returns list of ScheduleRunRecord sorted by occurrence, latest first
+	ListScheduleRuns(ctx context.Context, scheduleID string, offset, length int) ([]*ScheduleRunRecord, error)
+
+	// CountScheduleRuns will count the runs of the journal schedule.
+	// Throws error if the underlying database connection has problem.
+	CountScheduleRuns(ctx context.Context, scheduleID string) (int, error)
+
+	// DeleteScheduleRuns permanently delete the runs of the journal schedule.
+	// Throws error if the underlying database connection has problem.
+	DeleteScheduleRuns(ctx context.Context, scheduleID string) error
 }
@@ -0,0 +1,38 @@
+package connector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dialect holds what differs between the SQL databases a sqlDBRepository can work with.
+type dialect struct {
+	// driverName is the database/sql driver used to connect
+	driverName string
+	// dataSourceName builds the driver's connection string from the configuration
+	dataSourceName func() string
+	// versionQuery selects the database server version
+	versionQuery string
+	// lockForUpdate is appended to a select to lock the selected rows until the transaction ends
+	lockForUpdate string
+	// maxOpenConns limits the connections opened to the database, zero means no limit
+	maxOpenConns int
+	// isDuplicateKeyError tells whether the error is the database refusing a row that violates a primary or unique key
+	isDuplicateKeyError func(err error) bool
+}
+
+// Drivers lists the values accepted by the db.driver configuration.
+var Drivers = []string{"mysql", "postgres", "sqlite"}
+
+// NewDBRepository returns a not yet connected repository for the database driver, one of Drivers.
+func NewDBRepository(driver string) (DBRepository, error) {
+	switch strings.ToLower(driver) {
+	case "mysql":
+		return &MySQLDBRepository{}, nil
+	case "postgres":
+		return &PostgresDBRepository{}, nil
+	case "sqlite":
+		return &SQLiteDBRepository{}, nil
+	}
+	return nil, fmt.Errorf("unknown db.driver %s, should be one of %s", driver, strings.Join(Drivers, ", "))
+}
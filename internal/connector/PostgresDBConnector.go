@@ -0,0 +1,38 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hyperjumptech/hyperwallet/internal/config"
+	"github.com/lib/pq"
+)
+
+// postgresErrUniqueViolation is the PostgreSQL error code for unique_violation
+const postgresErrUniqueViolation = "23505"
+
+var postgresDialect = &dialect{
+	driverName: "postgres",
+	dataSourceName: func() string {
+		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			config.Get("db.host"), config.Get("db.port"), config.Get("db.user"), config.Get("db.password"), config.Get("db.name"), config.Get("db.sslmode"))
+	},
+	versionQuery:  "SELECT VERSION()",
+	lockForUpdate: " FOR UPDATE",
+	isDuplicateKeyError: func(err error) bool {
+		var pqErr *pq.Error
+		return errors.As(err, &pqErr) && pqErr.Code == postgresErrUniqueViolation
+	},
+}
+
+// PostgresDBRepository is implementation of DBRepository specified for PostgreSQL database
+type PostgresDBRepository struct {
+	sqlDBRepository
+}
+
+// Connect connect the repository to the database, it uses the configuration internally for connection arguments and parameters.
+func (repo *PostgresDBRepository) Connect(ctx context.Context) error {
+	repo.dialect = postgresDialect
+	return repo.sqlDBRepository.Connect(ctx)
+}
@@ -0,0 +1,2495 @@
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"time"
+
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+
+	"github.com/hyperjumptech/acccore"
+	"github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	sqlLog = log.WithField("file", "SQLDBConnector.go")
+)
+
+// sqlDBRepository is the implementation of DBRepository shared by the SQL databases,
+// what differs between them is kept in its dialect.
+type sqlDBRepository struct {
+	dialect   *dialect
+	db        *sqlx.DB
+	tx        *sqlx.Tx
+	connected bool
+}
+
+// conn returns the executor every statement should run on. When the repository is bound to a transaction
+// (see ExecuteInTransaction) this is the transaction, otherwise it is the connection pool.
+// Statements are written with ? placeholders, the executor rebinds them to the placeholders of the driver.
+func (repo *sqlDBRepository) conn() sqlx.ExtContext {
+	if repo.tx != nil {
+		return reboundExecutor{repo.tx}
+	}
+	return reboundExecutor{repo.db}
+}
+
+// reboundExecutor rebinds the placeholders of every statement before handing it to the underlying executor.
+type reboundExecutor struct {
+	sqlx.ExtContext
+}
+
+func (e reboundExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return e.ExtContext.QueryContext(ctx, e.Rebind(query), args...)
+}
+
+func (e reboundExecutor) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return e.ExtContext.QueryxContext(ctx, e.Rebind(query), args...)
+}
+
+func (e reboundExecutor) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	return e.ExtContext.QueryRowxContext(ctx, e.Rebind(query), args...)
+}
+
+func (e reboundExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return e.ExtContext.ExecContext(ctx, e.Rebind(query), args...)
+}
+
+// accountColumns are the accounts table columns read into an AccountRecord, in the order scanAccount expects them.
+const accountColumns = "account_number, name, currency_code, description, alignment, balance, coa, created_at, created_by, updated_at, updated_by, status, allow_negative, min_balance, max_balance"
+
+// rowScanner is satisfied by both *sqlx.Row and *sqlx.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAccount reads a row selected with accountColumns into a new AccountRecord.
+func scanAccount(row rowScanner) (*AccountRecord, error) {
+	ar := &AccountRecord{}
+	err := row.Scan(&ar.AccountNumber, &ar.Name, &ar.CurrencyCode, &ar.Description, &ar.Alignment, &ar.Balance, &ar.Coa, &ar.CreatedAt, &ar.CreatedBy, &ar.UpdatedAt, &ar.UpdatedBy, &ar.Status, &ar.AllowNegative, &ar.MinBalance, &ar.MaxBalance)
+	if err != nil {
+		return nil, err
+	}
+	return ar, nil
+}
+
+// journalColumns are the journals table columns read into a JournalRecord, in the order scanJournal expects them.
+// journals persisted before the hash chain have zero chain columns.
+const journalColumns = "journal_id, journaling_time, description, is_reversal, reversed_journal_id, total_amount, created_at, created_by," +
+	" COALESCE(chain_sequence, 0), COALESCE(previous_hash, ''), COALESCE(chain_hash, '')"
+
+// scanJournal reads a row selected with journalColumns into a new JournalRecord.
+func scanJournal(row rowScanner) (*JournalRecord, error) {
+	ar := &JournalRecord{}
+	err := row.Scan(&ar.JournalID, &ar.JournalingTime, &ar.Description, &ar.IsReversal, &ar.ReversedJournalID, &ar.TotalAmount, &ar.CreatedAt, &ar.CreatedBy,
+		&ar.ChainSequence, &ar.PreviousHash, &ar.ChainHash)
+	if err != nil {
+		return nil, err
+	}
+	return ar, nil
+}
+
+// ClearTables clear all table for testing purpose
+func (repo *sqlDBRepository) ClearTables(ctx context.Context) error {
+	lLog := sqlLog.WithField("function", "ClearTables")
+	tablesToDrop := []string{"accounts", "currencies", "journals", "transactions", "idempotency_keys", "settings", "setting_history", "currency_rates", "account_holds", "chart_of_accounts", "accounting_periods", "journal_templates", "journal_schedules", "schedule_runs"}
+	for _, t := range tablesToDrop {
+		_, err := repo.conn().ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", t))
+		if err != nil {
+			lLog.Errorf("error dropping table %s. got %s", t, err.Error())
+			return err
+		}
+	}
+	// every journal posting locks the single journal chain row, it is emptied rather than deleted
+	_, err := repo.conn().ExecContext(ctx, "UPDATE journal_chain SET last_sequence=0, last_hash=''")
+	if err != nil {
+		lLog.Errorf("error resetting the journal chain. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// Connect connect the repository to the database, it uses the configuration internally for connection arguments and parameters.
+func (repo *sqlDBRepository) Connect(ctx context.Context) error {
+	lLog := sqlLog.WithField("function", "Connect")
+
+	if repo.dialect == nil {
+		lLog.Errorf("repository has no dialect, it should be created by one of the database specific repositories")
+		return errors.ErrDBConnectingFailed
+	}
+	db, err := sqlx.ConnectContext(ctx, repo.dialect.driverName, repo.dialect.dataSourceName())
+	if err != nil {
+		lLog.Errorf("Connection to database error. got %s", err)
+		return errors.ErrDBConnectingFailed
+	}
+	lLog.Info("DB opened and PINGed successfully")
+
+	// Connect and check the server version
+	var version string
+	err = db.QueryRowContext(ctx, repo.dialect.versionQuery).Scan(&version)
+	if err != nil {
+		lLog.Warnf("unable to obtain DB server version")
+		version = "UNKNOWN"
+	}
+	lLog.Info("DB server version:", version)
+	if repo.dialect.maxOpenConns > 0 {
+		db.SetMaxOpenConns(repo.dialect.maxOpenConns)
+	}
+	repo.db = db
+	repo.connected = true
+	return nil
+}
+
+// Disconnect the already establshed connection. Throws error if the underlying database connection yield an error
+func (repo *sqlDBRepository) Disconnect() error {
+	lLog := sqlLog.WithField("function", "Disconnect")
+
+	defer func() {
+		repo.connected = false
+		repo.db = nil
+	}()
+	err := repo.db.Close()
+	if err != nil {
+		lLog.Errorf("error while disconnecting. Got %s", err.Error())
+	}
+	return err
+}
+
+// IsConnected check if the connection is already established
+func (repo *sqlDBRepository) IsConnected() bool {
+	if repo.db == nil || !repo.connected {
+		return false
+	}
+	return true
+}
+
+// DB the database connection object.
+func (repo *sqlDBRepository) DB() *sqlx.DB {
+	return repo.db
+}
+
+// ExecuteInTransaction runs fn as a single unit of work. Every call made on the txRepo handed to fn is executed
+// inside the same database transaction, which is committed when fn returns nil and rolled back when fn returns
+// an error or panics. If the repository is already bound to a transaction, fn simply joins it.
+func (repo *sqlDBRepository) ExecuteInTransaction(ctx context.Context, fn func(ctx context.Context, txRepo DBRepository) error) error {
+	lLog := sqlLog.WithField("function", "ExecuteInTransaction")
+
+	if repo.tx != nil {
+		return fn(ctx, repo)
+	}
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		lLog.Errorf("error creating transaction. got %s", err.Error())
+		return err
+	}
+	txRepo := &sqlDBRepository{dialect: repo.dialect, db: repo.db, tx: tx, connected: repo.connected}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				lLog.Errorf("error rolling back transaction. got %s", rbErr.Error())
+			}
+			panic(p)
+		}
+	}()
+
+	err = fn(ctx, txRepo)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			lLog.Errorf("error rolling back transaction. got %s", rbErr.Error())
+		}
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		lLog.Errorf("error committing transaction. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// InsertAccount insert an entity record of account into database.
+// Throws error if the underlying connection have problem.
+// The rec argument contains the Account information to be written.
+// It returns the account number that written into database.
+// The AccountNumber contained within the rec MUST NOT be persisted before.
+func (repo *sqlDBRepository) InsertAccount(ctx context.Context, rec *AccountRecord) (string, error) {
+	lLog := sqlLog.WithField("function", "InsertAccount")
+
+	if len(rec.CurrencyCode) > 10 {
+		lLog.Errorf("Currency code %s is too long. Should not more than 10 digit", rec.CurrencyCode)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.Name) > 128 {
+		lLog.Errorf("Account name %s is too long. Should not more than 128 digit", rec.Name)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.AccountNumber) > 20 {
+		lLog.Errorf("Account Number %s is too long. Should not more than 20 digit", rec.AccountNumber)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.Coa) > 10 {
+		lLog.Errorf("COA %s is too long. Should not more than 10 digit", rec.Coa)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+	if len(rec.UpdatedBy) > 16 {
+		rec.UpdatedBy = rec.UpdatedBy[:16]
+	}
+
+	theUser, ok := ctx.Value(contextkeys.UserIDContextKey).(string)
+	if !ok {
+		lLog.Errorf("UserContext Key %s is not in context", contextkeys.UserIDContextKey)
+		return "", errors.ErrUserContextKeyMissing
+	}
+
+	rec.Alignment = html.EscapeString(rec.Alignment)
+	rec.AccountNumber = html.EscapeString(rec.AccountNumber)
+	rec.Name = html.EscapeString(rec.Name)
+	rec.Description = html.EscapeString(rec.Description)
+	rec.Coa = html.EscapeString(rec.Coa)
+	rec.CurrencyCode = html.EscapeString(rec.CurrencyCode)
+	rec.UpdatedBy = html.EscapeString(theUser)
+	rec.UpdatedAt = time.Now()
+	rec.CreatedBy = html.EscapeString(theUser)
+	rec.CreatedAt = time.Now()
+	if len(rec.Status) == 0 {
+		rec.Status = "ACTIVE"
+	}
+
+	q := "INSERT INTO accounts(" +
+		"account_number, name, currency_code, description, alignment, balance, coa, created_at, created_by, updated_at, updated_by, status, allow_negative, min_balance, max_balance, is_deleted" +
+		") VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, false)"
+	args := []interface{}{
+		rec.AccountNumber, rec.Name, rec.CurrencyCode, rec.Description, rec.Alignment, rec.Balance, rec.Coa, rec.CreatedAt, rec.CreatedBy, rec.UpdatedAt, rec.UpdatedBy, rec.Status,
+		rec.AllowNegative, rec.MinBalance, rec.MaxBalance,
+	}
+	_, err := repo.conn().ExecContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error when inserting account. got %s", err.Error())
+		return "", err
+	}
+	return rec.AccountNumber, nil
+}
+
+// UpdateAccount update an account entity record in the database.
+// Throws error if the underlying database connection has problem.
+// The rec argument contains the Account information to be updated.
+// The AccountNumber contained within the rec MUST be already persisted before.
+func (repo *sqlDBRepository) UpdateAccount(ctx context.Context, rec *AccountRecord) error {
+	lLog := sqlLog.WithField("function", "UpdateAccount")
+
+	if len(rec.CurrencyCode) > 10 {
+		lLog.Errorf("Currency code %s is too long. Should not more than 10 digit", rec.CurrencyCode)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.Name) > 128 {
+		lLog.Errorf("Account name %s is too long. Should not more than 128 digit", rec.Name)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.AccountNumber) > 20 {
+		lLog.Errorf("Account Number %s is too long. Should not more than 20 digit", rec.AccountNumber)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.Coa) > 10 {
+		lLog.Errorf("COA %s is too long. Should not more than 10 digit", rec.Coa)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+	if len(rec.UpdatedBy) > 16 {
+		rec.CreatedBy = rec.UpdatedBy[:16]
+	}
+
+	theUser, ok := ctx.Value(contextkeys.UserIDContextKey).(string)
+	if !ok {
+		lLog.Errorf("UserContext Key %s is not in context", contextkeys.UserIDContextKey)
+		return errors.ErrUserContextKeyMissing
+	}
+
+	rec.Alignment = html.EscapeString(rec.Alignment)
+	rec.Name = html.EscapeString(rec.Name)
+	rec.Description = html.EscapeString(rec.Description)
+	rec.Coa = html.EscapeString(rec.Coa)
+	rec.CurrencyCode = html.EscapeString(rec.CurrencyCode)
+	rec.UpdatedBy = html.EscapeString(theUser)
+	rec.UpdatedAt = time.Now()
+	q := "UPDATE accounts set" +
+		" name=?, currency_code=?, description=?, alignment=?, balance=?, coa=?, created_at=?, created_by=?, updated_at=?, updated_by=?, status=?," +
+		" allow_negative=?, min_balance=?, max_balance=?" +
+		" WHERE account_number=? AND is_deleted=false"
+	args := []interface{}{
+		rec.Name, rec.CurrencyCode, rec.Description, rec.Alignment, rec.Balance, rec.Coa, rec.CreatedAt, rec.CreatedBy, rec.UpdatedAt, rec.UpdatedBy, rec.Status,
+		rec.AllowNegative, rec.MinBalance, rec.MaxBalance, rec.AccountNumber,
+	}
+	_, err := repo.conn().ExecContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while updating account. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// DeleteAccount soft/logical delete an account.
+// Throws error if the underlying database connection has problem.
+// If the account number not exist, it will do nothing and return nil.
+func (repo *sqlDBRepository) DeleteAccount(ctx context.Context, accountNumber string) error {
+	lLog := sqlLog.WithField("function", "DeleteAccount")
+	q := "UPDATE accounts " +
+		"set is_deleted=true" +
+		" WHERE account_number=? AND is_deleted=true"
+	args := []interface{}{
+		accountNumber,
+	}
+	_, err := repo.conn().ExecContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while deleting account. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// ListAccount will list account in paginated fashion.
+// Throws error if the underlying database connection has problem.
+// It will return AccountRecords sorted, starting from the offset with total maximum number or item, specified
+// in the length argument.
+// It returns list of AcccountRecords
+func (repo *sqlDBRepository) ListAccount(ctx context.Context, sort string, offset, length int) ([]*AccountRecord, error) {
+	lLog := sqlLog.WithField("function", "ListAccount")
+	q := "SELECT " + accountColumns +
+		" FROM accounts WHERE is_deleted=false ORDER BY " + sort + " ASC LIMIT ? OFFSET ?"
+	lLog.Infof("Q = %s", q)
+	rows, err := repo.conn().QueryxContext(ctx, q, length, offset)
+	if err != nil {
+		lLog.Errorf("error while listing account. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*AccountRecord, 0)
+	for rows.Next() {
+		ar, err := scanAccount(rows)
+		if err != nil {
+			lLog.Errorf("error while scanning rows in ListAccount function. got %s", err.Error())
+		} else {
+			ret = append(ret, ar)
+		}
+	}
+	return ret, nil
+}
+
+// CountAccounts will return a number of accounts in database.
+// Throws error if the underlying database connection has problem.
+// It will returns total number of accounts in the database.
+func (repo *sqlDBRepository) CountAccounts(ctx context.Context) (int, error) {
+	lLog := sqlLog.WithField("function", "CountAccounts")
+	q := "SELECT COUNT(*) as accountCounts" +
+		" FROM accounts WHERE is_deleted=false"
+	row := repo.conn().QueryRowxContext(ctx, q)
+	if row.Err() != nil {
+		lLog.Errorf("error while counting account. got %s", row.Err().Error())
+		return 0, row.Err()
+	}
+	count := 0
+	err := row.Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListAccountByCoa will list all account that have the specified COA, the list presented in paginated fashion.
+// Throws error if the underlying database connection has problem.
+// It will return AccountRecords sorted, starting from the offset with total maximum number or item, specified
+// in the length argument.
+// It returns list of AcccountRecords
+func (repo *sqlDBRepository) ListAccountByCoa(ctx context.Context, coa string, sort string, offset, length int) ([]*AccountRecord, error) {
+	lLog := sqlLog.WithField("function", "ListAccountByCoa")
+	q := "SELECT " + accountColumns +
+		" FROM accounts WHERE coa LIKE ? AND is_deleted=false ORDER BY " + sort + " ASC LIMIT ? OFFSET ?"
+	rows, err := repo.conn().QueryxContext(ctx, q, coa, length, offset)
+	if err != nil {
+		lLog.Errorf("error while listing account by coa. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*AccountRecord, 0)
+	for rows.Next() {
+		ar, err := scanAccount(rows)
+		if err != nil {
+			lLog.Errorf("error while scanning rows in ListAccount function. got %s", err.Error())
+		} else {
+			ret = append(ret, ar)
+		}
+	}
+	return ret, nil
+}
+
+// CountAccountByCoa will return a number of accounts in database that belong to the specified COA number.
+// Throws error if the underlying database connection has problem.
+// It will returns total number of accounts in the database.
+func (repo *sqlDBRepository) CountAccountByCoa(ctx context.Context, coa string) (int, error) {
+	lLog := sqlLog.WithField("function", "CountAccountByCoa")
+	q := "SELECT COUNT(*) as accountCounts" +
+		" FROM accounts WHERE coa LIKE ? AND is_deleted=false"
+	row := repo.conn().QueryRowxContext(ctx, q, coa)
+	if row.Err() != nil {
+		lLog.Errorf("error while counting account by coa. got %s", row.Err().Error())
+		return 0, row.Err()
+	}
+	count := 0
+	err := row.Scan(&count)
+	if err != nil {
+		lLog.Errorf("error while scanning count of account by coa. got %s", err.Error())
+		return 0, err
+	}
+	return count, nil
+}
+
+// FindAccountByName will list all account that have the specified name, the list presented in paginated fashion.
+// Throws error if the underlying database connection has problem.
+// It will return AccountRecords sorted, starting from the offset with total maximum number or item, specified
+// in the length argument.
+// It returns list of AcccountRecords
+func (repo *sqlDBRepository) FindAccountByName(ctx context.Context, nameLike string, sort string, offset, length int) ([]*AccountRecord, error) {
+	lLog := sqlLog.WithField("function", "FindAccountByName")
+	q := "SELECT " + accountColumns +
+		" FROM accounts WHERE (name LIKE ? OR account_number LIKE ?) AND is_deleted=false ORDER BY " + sort + " ASC LIMIT ? OFFSET ?"
+	rows, err := repo.conn().QueryxContext(ctx, q, html.EscapeString(nameLike), html.EscapeString(nameLike), length, offset)
+	if err != nil {
+		lLog.Errorf("error while finding accounts by name. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*AccountRecord, 0)
+	for rows.Next() {
+		ar, err := scanAccount(rows)
+		if err != nil {
+			lLog.Errorf("error while scanning rows in ListAccount function. got %s", err.Error())
+		} else {
+			ret = append(ret, ar)
+		}
+	}
+	return ret, nil
+}
+
+// CountAccountByName will return a number of accounts in database that have the name like the specified in the argument..
+// Throws error if the underlying database connection has problem.
+// It will returns total number of accounts in the database.
+func (repo *sqlDBRepository) CountAccountByName(ctx context.Context, nameLike string) (int, error) {
+	lLog := sqlLog.WithField("function", "CountAccountByName")
+	q := "SELECT COUNT(*) as accountCounts" +
+		" FROM accounts WHERE (name LIKE ? OR account_number LIKE ?) AND is_deleted=false"
+	row := repo.conn().QueryRowxContext(ctx, q, nameLike, nameLike)
+	if row.Err() != nil {
+		lLog.Errorf("error while counting account by name. got %s", row.Err().Error())
+		return 0, row.Err()
+	}
+	count := 0
+	err := row.Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetAccount retrieves an AccountRecord from database where the account number is specified.
+// Throws error if  the underlying database connection has problem.
+// It returns an instance of AccountRecord or nil if there is no Account with
+// specified accountNumber.
+func (repo *sqlDBRepository) GetAccount(ctx context.Context, accountNumber string) (*AccountRecord, error) {
+	return repo.getAccount(ctx, "GetAccount", accountNumber, "")
+}
+
+// GetAccountForUpdate retrieves an AccountRecord like GetAccount does and places an exclusive lock on its row
+// (SELECT ... FOR UPDATE) that is held until the surrounding transaction is committed or rolled back.
+// Databases without row locks rely on their transaction holding the write lock of the whole database instead.
+// Throws ErrNotInTransaction if the repository is not bound to a transaction (see ExecuteInTransaction).
+// It returns an instance of AccountRecord or nil if there is no Account with specified accountNumber.
+func (repo *sqlDBRepository) GetAccountForUpdate(ctx context.Context, accountNumber string) (*AccountRecord, error) {
+	if repo.tx == nil {
+		sqlLog.WithField("function", "GetAccountForUpdate").Errorf("error locking account %s. repository is not bound to a transaction", accountNumber)
+		return nil, errors.ErrNotInTransaction
+	}
+	return repo.getAccount(ctx, "GetAccountForUpdate", accountNumber, repo.dialect.lockForUpdate)
+}
+
+// ListAccountByNumbers retrieves, in a single query, the AccountRecords from database whose account number
+// is one of the specified accountNumbers.
+// Throws error if the underlying database connection has problem.
+// It returns list of AccountRecord sorted by account number, leaving out the account numbers not found
+func (repo *sqlDBRepository) ListAccountByNumbers(ctx context.Context, accountNumbers []string) ([]*AccountRecord, error) {
+	lLog := sqlLog.WithField("function", "ListAccountByNumbers")
+	ret := make([]*AccountRecord, 0, len(accountNumbers))
+	if len(accountNumbers) == 0 {
+		return ret, nil
+	}
+	escaped := make([]string, 0, len(accountNumbers))
+	for _, accountNumber := range accountNumbers {
+		escaped = append(escaped, html.EscapeString(accountNumber))
+	}
+	q, args, err := sqlx.In("SELECT "+accountColumns+
+		" FROM accounts WHERE account_number IN (?) AND is_deleted=false ORDER BY account_number", escaped)
+	if err != nil {
+		lLog.Errorf("error while building the account numbers query. got %s", err.Error())
+		return nil, err
+	}
+	rows, err := repo.conn().QueryxContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while listing account by account numbers. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		ar, err := scanAccount(rows)
+		if err != nil {
+			lLog.Errorf("error while scanning account. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, ar)
+	}
+	return ret, rows.Err()
+}
+
+// getAccount retrieves a single not deleted account, lockClause is appended to the query as is.
+func (repo *sqlDBRepository) getAccount(ctx context.Context, function, accountNumber, lockClause string) (*AccountRecord, error) {
+	lLog := sqlLog.WithField("function", function)
+	q := "SELECT " + accountColumns +
+		" FROM accounts WHERE account_number=? AND is_deleted=false" + lockClause
+	row := repo.conn().QueryRowxContext(ctx, q, html.EscapeString(accountNumber))
+	if row.Err() != nil {
+		lLog.Errorf("error while retrieving account by account number. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	ar, err := scanAccount(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while scanning account by account number. got %s", err.Error())
+		return nil, err
+	}
+	return ar, nil
+}
+
+// InsertJournal will insert the data specified in the rec argument into database
+// will return error if the underlying database connection has problem. or if the
+// journalID, or Transaction ID in the journal already in the database.
+// Will return the JournalID saved if successful.
+func (repo *sqlDBRepository) InsertJournal(ctx context.Context, rec *JournalRecord) (string, error) {
+	lLog := sqlLog.WithField("function", "InsertJournal")
+
+	theUser, ok := ctx.Value(contextkeys.UserIDContextKey).(string)
+	if !ok {
+		lLog.Errorf("UserContext Key %s is not in context", contextkeys.UserIDContextKey)
+		return "", errors.ErrUserContextKeyMissing
+	}
+
+	if len(rec.JournalID) > 20 {
+		lLog.Errorf("JournalID %s is too long. Should not more than 20 digit", rec.JournalID)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.ReversedJournalID) > 128 {
+		lLog.Errorf("Reversed journal id %s is too long. Should not more than 20 digit", rec.ReversedJournalID)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+
+	rec.CreatedBy = theUser
+	rec.CreatedAt = time.Now()
+	// journals outside the chain have no chain columns, the unique chain sequence allows any number of NULLs
+	var chainSequence, previousHash, chainHash interface{}
+	if rec.ChainSequence > 0 {
+		chainSequence, previousHash, chainHash = rec.ChainSequence, rec.PreviousHash, rec.ChainHash
+	}
+	q := "INSERT INTO journals(" +
+		"journal_id, journaling_time, description, is_reversal, reversed_journal_id, total_amount, created_at, created_by, updated_at, updated_by, is_deleted, chain_sequence, previous_hash, chain_hash" +
+		") VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	args := []interface{}{
+		html.EscapeString(rec.JournalID), rec.JournalingTime, html.EscapeString(rec.Description),
+		rec.IsReversal, html.EscapeString(rec.ReversedJournalID), rec.TotalAmount, rec.CreatedAt, html.EscapeString(rec.CreatedBy), rec.CreatedAt, html.EscapeString(rec.CreatedBy), false,
+		chainSequence, previousHash, chainHash,
+	}
+	_, err := repo.conn().ExecContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while inserting journal. got %s", err.Error())
+		return "", err
+	}
+	return rec.JournalID, nil
+}
+
+// UpdateJournal update an journal entity record in the database.
+// Throws error if the underlying database connection has problem.
+// The rec argument contains the Journal information to be updated.
+// The JournalID contained within the rec MUST be already persisted before.
+func (repo *sqlDBRepository) UpdateJournal(ctx context.Context, rec *JournalRecord) error {
+	lLog := sqlLog.WithField("function", "UpdateJournal")
+	theUser, ok := ctx.Value(contextkeys.UserIDContextKey).(string)
+	if !ok {
+		lLog.Errorf("UserContext Key %s is not in context", contextkeys.UserIDContextKey)
+		return errors.ErrUserContextKeyMissing
+	}
+
+	if len(rec.JournalID) > 20 {
+		lLog.Errorf("JournalID %s is too long. Should not more than 20 digit", rec.JournalID)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.ReversedJournalID) > 128 {
+		lLog.Errorf("Reversed journal id %s is too long. Should not more than 20 digit", rec.ReversedJournalID)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+
+	rec.CreatedBy = theUser
+	rec.CreatedAt = time.Now()
+	q := "UPDATE journals " +
+		"set journaling_time=?, description=?, is_reversal=?, reversed_journal_id=?, total_amount=?, updated_at=?, updated_by=?" +
+		" WHERE journal_id=? AND is_deleted=false"
+	args := []interface{}{
+		rec.JournalingTime, html.EscapeString(rec.Description), rec.IsReversal, html.EscapeString(rec.ReversedJournalID), rec.TotalAmount, time.Now(), html.EscapeString(theUser), html.EscapeString(rec.JournalID),
+	}
+	_, err := repo.conn().ExecContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while updating journal. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// DeleteJournal soft/logical delete an journal.
+// Throws error if the underlying database connection has problem.
+// If the JournalID not exist, it will do nothing and return nil.
+func (repo *sqlDBRepository) DeleteJournal(ctx context.Context, journalID string) error {
+	lLog := sqlLog.WithField("function", "DeleteJournal")
+	q := "UPDATE journals " +
+		"set is_deleted=true" +
+		" WHERE journal_id=? AND is_deleted=true"
+	args := []interface{}{
+		html.EscapeString(journalID),
+	}
+	_, err := repo.conn().ExecContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while deleting journal. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// ListJournal will list journals in paginated fashion.
+// Throws error if the underlying database connection has problem.
+// It will return JournalRecord sorted, starting from the offset with total maximum number or item, specified
+// in the length argument.
+// It returns list of JournalRecord
+func (repo *sqlDBRepository) ListJournal(ctx context.Context, sort string, offset, length int) ([]*JournalRecord, error) {
+	lLog := sqlLog.WithField("function", "ListJournal")
+	q := "SELECT journal_id, journaling_time, description, is_reversal, reversed_journal_id, total_amount, created_at, created_by" +
+		" FROM journals WHERE is_deleted=false ORDER BY " + sort + " ASC LIMIT ? OFFSET ?"
+	rows, err := repo.conn().QueryxContext(ctx, q, length, offset)
+	if err != nil {
+		lLog.Errorf("error while listing journals. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*JournalRecord, 0)
+	for rows.Next() {
+		ar := &JournalRecord{}
+		err := rows.Scan(&ar.JournalID, &ar.JournalingTime, &ar.Description, &ar.Description, &ar.IsReversal, &ar.ReversedJournalID, &ar.TotalAmount, &ar.CreatedAt, &ar.CreatedBy)
+		if err != nil {
+			lLog.Errorf("error while scanning rows in ListAccount function. got %s", err.Error())
+		} else {
+			ret = append(ret, ar)
+		}
+	}
+	return ret, nil
+}
+
+// GetJournal retrieves an JournalRecord from database where the journalID is specified.
+// Throws error if  the underlying database connection has problem.
+// It returns an instance of JournalRecord or nil if there is no Journal with
+// specified journalID.
+func (repo *sqlDBRepository) GetJournal(ctx context.Context, journalID string) (*JournalRecord, error) {
+	lLog := sqlLog.WithField("function", "GetJournal")
+	q := "SELECT " + journalColumns + " FROM journals WHERE journal_id=? AND is_deleted=false"
+	row := repo.conn().QueryRowxContext(ctx, q, journalID)
+	if row.Err() != nil {
+		lLog.Errorf("error while retrieving journal by journalID. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	ar, err := scanJournal(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		lLog.Errorf("error while scanning listing row. got %s", err.Error())
+		return nil, err
+	}
+	return ar, nil
+}
+
+// GetJournalByReversalID retrieves the first JournalRecord from database where the reversedJournalID is specified.
+// Throws error if  the underlying database connection has problem.
+// It returns an instance of JournalRecord or nil if there is no Journal with
+// specified reversedJournalID.
+func (repo *sqlDBRepository) GetJournalByReversalID(ctx context.Context, journalID string) (*JournalRecord, error) {
+	lLog := sqlLog.WithField("function", "GetJournalByReversalID")
+	q := "SELECT " + journalColumns +
+		" FROM journals WHERE reversed_journal_id=? AND is_deleted=false ORDER BY created_at ASC, journal_id ASC"
+	row := repo.conn().QueryRowxContext(ctx, q, journalID)
+	if row.Err() != nil {
+		lLog.Errorf("error while retriving journals by reversal id. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	ar, err := scanJournal(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while scanning record when retrieving journal. got %s", err.Error())
+		return nil, err
+	}
+	return ar, nil
+}
+
+// SumReversedAmountByJournalID will return, for every account of the journal, the amount reversed by the
+// reversal journals of the specified journalID.
+// Throws error if the underlying database connection has problem.
+// It returns list of ReversedAmountRecord, empty if the journal was never reversed
+func (repo *sqlDBRepository) SumReversedAmountByJournalID(ctx context.Context, journalID string) ([]*ReversedAmountRecord, error) {
+	lLog := sqlLog.WithField("function", "SumReversedAmountByJournalID")
+	q := "SELECT t.account_number, COALESCE(SUM(t.amount), 0) FROM transactions t" +
+		" INNER JOIN journals j ON j.journal_id = t.journal_id AND j.is_deleted=false" +
+		" WHERE j.reversed_journal_id=? AND t.is_deleted=false GROUP BY t.account_number ORDER BY t.account_number"
+	rows, err := repo.conn().QueryxContext(ctx, q, journalID)
+	if err != nil {
+		lLog.Errorf("error while summing reversed amount by journalID. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*ReversedAmountRecord, 0)
+	for rows.Next() {
+		rr := &ReversedAmountRecord{}
+		if err := rows.Scan(&rr.AccountNumber, &rr.Amount); err != nil {
+			lLog.Errorf("error while scanning reversed amount. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, rr)
+	}
+	return ret, rows.Err()
+}
+
+// dateColumn returns the column holding the date selected by the basis, the effective column of the table or created_at.
+func dateColumn(basis DateBasis, effectiveColumn string) string {
+	if basis == BookingDate {
+		return "created_at"
+	}
+	return effectiveColumn
+}
+
+// ListJournalByTimeRange will list journals in paginated fashion where the date of the journal selected by
+// the basis is in the specified time range.
+// Throws error if the underlying database connection has problem.
+// It will return JournalRecord sorted by that date, starting from the offset with total maximum number or item, specified
+// in the length argument.
+// It returns list of JournalRecord
+func (repo *sqlDBRepository) ListJournalByTimeRange(ctx context.Context, basis DateBasis, timeFrom, timeTo time.Time, offset, length int) ([]*JournalRecord, error) {
+	lLog := sqlLog.WithField("function", "ListJournalByTimeRange")
+	column := dateColumn(basis, "journaling_time")
+	q := "SELECT " + journalColumns + " FROM journals WHERE " + column + " > ? AND " + column + " < ? AND is_deleted=false" +
+		" ORDER BY " + column + " ASC, journal_id ASC LIMIT ? OFFSET ?"
+	rows, err := repo.conn().QueryxContext(ctx, q, timeFrom, timeTo, length, offset)
+	if err != nil {
+		lLog.Errorf("error while listing journals by time range. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*JournalRecord, 0)
+	for rows.Next() {
+		ar, err := scanJournal(rows)
+		if err != nil {
+			lLog.Errorf("error while scanning rows in ListJournalByTimeRange function. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, ar)
+	}
+	return ret, rows.Err()
+}
+
+// CountJournalByTimeRange will return a number of journals in database whose date selected by the basis is within the time range.
+// Throws error if the underlying database connection has problem.
+// It will returns total number of journals in the database.
+func (repo *sqlDBRepository) CountJournalByTimeRange(ctx context.Context, basis DateBasis, timeFrom, timeTo time.Time) (int, error) {
+	lLog := sqlLog.WithField("function", "CountJournalByTimeRange")
+	column := dateColumn(basis, "journaling_time")
+	q := "SELECT COUNT(*) as journalCount" +
+		" FROM journals WHERE " + column + " > ? AND " + column + " < ? AND is_deleted=false"
+	row := repo.conn().QueryRowxContext(ctx, q, timeFrom, timeTo)
+	if row.Err() != nil {
+		lLog.Errorf("error while counting journals by time range. got %s", row.Err().Error())
+		return 0, row.Err()
+	}
+	count := 0
+	err := row.Scan(&count)
+	if err != nil {
+		lLog.Errorf("error while scanning journals count when finding journal by time range. got %s", err.Error())
+		return 0, err
+	}
+	return count, nil
+}
+
+// InsertTransaction will insert the data specified in the rec argument into database
+// will return error if the underlying database connection has problem. or if the
+// Transaction ID in the journal already in the database.
+// Will return the TransactionID saved if successful.
+func (repo *sqlDBRepository) InsertTransaction(ctx context.Context, rec *TransactionRecord) (string, error) {
+	lLog := sqlLog.WithField("function", "InsertTransaction")
+
+	if len(rec.TransactionID) > 20 {
+		lLog.Errorf("TransactionID %s is too long. Should not more than 20 digit", rec.TransactionID)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.JournalID) > 20 {
+		lLog.Errorf("JournalID %s is too long. Should not more than 20 digit", rec.JournalID)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.AccountNumber) > 20 {
+		lLog.Errorf("AccountNumber %s is too long. Should not more than 20 digit", rec.AccountNumber)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+
+	q := "INSERT INTO transactions(" +
+		"transaction_id, transaction_time, account_number, journal_id, description, alignment, amount, balance, created_at, created_by, is_deleted" +
+		") VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, false)"
+	args := []interface{}{
+		html.EscapeString(rec.TransactionID),
+		rec.TransactionTime,
+		html.EscapeString(rec.AccountNumber),
+		html.EscapeString(rec.JournalID),
+		html.EscapeString(rec.Description),
+		html.EscapeString(rec.Alignment),
+		rec.Amount,
+		rec.Balance,
+		rec.CreatedAt,
+		html.EscapeString(rec.CreatedBy),
+	}
+	_, err := repo.conn().ExecContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while inserting transaction. got %s", err.Error())
+		return "", err
+	}
+	return rec.JournalID, nil
+}
+
+// UpdateTransaction update an transaction entity record in the database.
+// Throws error if the underlying database connection has problem.
+// The rec argument contains the Transaction information to be updated.
+// The TransactionID contained within the rec MUST be already persisted before.
+func (repo *sqlDBRepository) UpdateTransaction(ctx context.Context, rec *TransactionRecord) error {
+	lLog := sqlLog.WithField("function", "UpdateTransaction")
+
+	if len(rec.TransactionID) > 20 {
+		lLog.Errorf("TransactionID %s is too long. Should not more than 20 digit", rec.TransactionID)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.JournalID) > 20 {
+		lLog.Errorf("JournalID %s is too long. Should not more than 20 digit", rec.JournalID)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.AccountNumber) > 20 {
+		lLog.Errorf("AccountNumber %s is too long. Should not more than 20 digit", rec.AccountNumber)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+
+	q := "UPDATE transactions " +
+		"set transaction_time=?, account_number=?, journal_id=?, description=?, alignment=?, amount=?, balance=?, created_at=?, created_by=?" +
+		" WHERE journal_id=? and is_deleted=false"
+	args := []interface{}{
+		rec.TransactionTime,
+		html.EscapeString(rec.AccountNumber),
+		html.EscapeString(rec.JournalID),
+		html.EscapeString(rec.Description),
+		html.EscapeString(rec.Alignment),
+		rec.Amount,
+		rec.Balance,
+		rec.CreatedAt,
+		html.EscapeString(rec.CreatedBy),
+		html.EscapeString(rec.JournalID),
+	}
+	_, err := repo.conn().ExecContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while updating transaction. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// DeleteTransaction soft/logical delete a transaction.
+// Throws error if the underlying database connection has problem.
+// If the TransactionID not exist, it will do nothing and return nil.
+func (repo *sqlDBRepository) DeleteTransaction(ctx context.Context, transactionID string) error {
+	lLog := sqlLog.WithField("function", "DeleteTransaction")
+	q := "UPDATE transactions " +
+		"set is_deleted=true" +
+		" WHERE transaction_id=? AND is_deleted=true"
+	args := []interface{}{
+		transactionID,
+	}
+	_, err := repo.conn().ExecContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while deleting transaction. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// ListTransaction will list journals in paginated fashion.
+// Throws error if the underlying database connection has problem.
+// It will return TransactionRecord sorted, starting from the offset with total maximum number or item, specified
+// in the length argument.
+// It returns list of TransactionRecord
+func (repo *sqlDBRepository) ListTransaction(ctx context.Context, sort string, offset, length int) ([]*TransactionRecord, error) {
+	lLog := sqlLog.WithField("function", "ListTransaction")
+	q := "SELECT transaction_id, transaction_time, account_number, journal_id, description, alignment, amount, balance, created_at, created_by" +
+		" FROM transactions WHERE is_deleted=false ORDER BY " + sort + " ASC LIMIT ? OFFSET ?"
+	rows, err := repo.conn().QueryxContext(ctx, q, length, offset)
+	if err != nil {
+		lLog.Errorf("error while listing transaction in time-range. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*TransactionRecord, 0)
+	for rows.Next() {
+		ar := &TransactionRecord{}
+		err := rows.Scan(&ar.TransactionID, &ar.TransactionTime, &ar.AccountNumber, &ar.JournalID, &ar.Description, &ar.Alignment, &ar.Amount, &ar.Balance, &ar.CreatedAt, &ar.CreatedBy)
+		if err != nil {
+			lLog.Errorf("error while scanning rows in ListAccount function. got %s", err.Error())
+		} else {
+			ret = append(ret, ar)
+		}
+	}
+	return ret, nil
+}
+
+// GetTransaction retrieves an TransactionRecord from database where the transactionID is specified.
+// Throws error if  the underlying database connection has problem.
+// It returns an instance of TransactionRecord  or nil if there is no Transaction with
+// specified transactionID.
+func (repo *sqlDBRepository) GetTransaction(ctx context.Context, transactionID string) (*TransactionRecord, error) {
+	lLog := sqlLog.WithField("function", "GetTransaction")
+	q := "SELECT  transaction_id, transaction_time, account_number, journal_id, description, alignment, amount, balance, created_at, created_by" +
+		" FROM transactions WHERE transaction_id=? and is_deleted=false"
+	row := repo.conn().QueryRowxContext(ctx, q, transactionID)
+	if row.Err() != nil {
+		lLog.Errorf("error while retrieving transaction. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	ar := &TransactionRecord{}
+	err := row.Scan(&ar.TransactionID, &ar.TransactionTime, &ar.AccountNumber, &ar.JournalID, &ar.Description, &ar.Alignment, &ar.Amount, &ar.Balance, &ar.CreatedAt, &ar.CreatedBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while scanning transaction record. got %s", err.Error())
+		return nil, err
+	}
+	return ar, nil
+}
+
+// ListTransactionByAccountNumber will list transactions in paginated fashion, the transaction must belong to the
+// specified accountNumber arguments and its date selected by the basis must be within the time rage.
+// Throws error if the underlying database connection has problem.
+// It will return TransactionRecord sorted by that date, starting from the offset with total maximum number or item, specified
+// in the length argument.
+// It returns list of TransactionRecord
+func (repo *sqlDBRepository) ListTransactionByAccountNumber(ctx context.Context, accountNumber string, basis DateBasis, timeFrom, timeTo time.Time, offset, length int) ([]*TransactionRecord, error) {
+	lLog := sqlLog.WithField("function", "ListTransactionByAccountNumber")
+	column := dateColumn(basis, "transaction_time")
+	q := "SELECT transaction_id, transaction_time, account_number, journal_id, description, alignment, amount, balance, created_at, created_by" +
+		" FROM transactions WHERE account_number=? AND " + column + " > ? AND " + column + " < ? AND is_deleted=false ORDER BY " + column + " ASC LIMIT ? OFFSET ?"
+	rows, err := repo.conn().QueryxContext(ctx, q, accountNumber, timeFrom, timeTo, length, offset)
+	if err != nil {
+		lLog.Errorf("error while listing transaction by account number. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*TransactionRecord, 0)
+	for rows.Next() {
+		ar := &TransactionRecord{}
+		err := rows.Scan(&ar.TransactionID, &ar.TransactionTime, &ar.AccountNumber, &ar.JournalID, &ar.Description, &ar.Alignment, &ar.Amount, &ar.Balance, &ar.CreatedAt, &ar.CreatedBy)
+		if err != nil {
+			lLog.Errorf("error while scanning rows in ListAccount function. got %s", err.Error())
+		} else {
+			ret = append(ret, ar)
+		}
+	}
+	return ret, nil
+}
+
+// CountTransactionByAccountNumber will return a number of accounts in database that belong to a specific
+// accountNumber and whose date selected by the basis is within the time range.
+// Throws error if the underlying database connection has problem.
+// It will returns total number of transaction in the database as specified in the argument.
+func (repo *sqlDBRepository) CountTransactionByAccountNumber(ctx context.Context, accountNumber string, basis DateBasis, timeFrom, timeTo time.Time) (int, error) {
+	lLog := sqlLog.WithField("function", "CountTransactionByAccountNumber")
+	column := dateColumn(basis, "transaction_time")
+	q := "SELECT COUNT(*) as trxCount" +
+		" FROM transactions WHERE account_number = ? AND " + column + " > ? AND " + column + " < ? AND is_deleted=false"
+	row := repo.conn().QueryRowxContext(ctx, q, accountNumber, timeFrom, timeTo)
+	if row.Err() != nil {
+		lLog.Errorf("error while counting transaction by account number. got %s", row.Err().Error())
+		return 0, row.Err()
+	}
+	count := 0
+	err := row.Scan(&count)
+	if err != nil {
+		lLog.Errorf("error while counting transactions by account number. got %s", err.Error())
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListTransactionByJournalID will list transactions , the transaction must belong to the
+// specified journalID arguments.
+// Throws error if the underlying database connection has problem.
+// It will return TransactionRecord sorted.
+// It returns list of TransactionRecord
+func (repo *sqlDBRepository) ListTransactionByJournalID(ctx context.Context, journalID string) ([]*TransactionRecord, error) {
+	lLog := sqlLog.WithField("function", "ListTransactionByJournalID")
+	q := "SELECT transaction_id, transaction_time, account_number, journal_id, description, alignment, amount, balance, created_at, created_by" +
+		" FROM transactions WHERE journal_id=? AND is_deleted=false"
+	rows, err := repo.conn().QueryxContext(ctx, q, journalID)
+	if err != nil {
+		lLog.Errorf("error while listing transaction by journalID. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*TransactionRecord, 0)
+	for rows.Next() {
+		ar := &TransactionRecord{}
+		err := rows.Scan(&ar.TransactionID, &ar.TransactionTime, &ar.AccountNumber, &ar.JournalID, &ar.Description, &ar.Alignment, &ar.Amount, &ar.Balance, &ar.CreatedAt, &ar.CreatedBy)
+		if err != nil {
+			lLog.Errorf("error while scanning rows in ListTransactionByJournalID function. got %s", err.Error())
+		} else {
+			ret = append(ret, ar)
+		}
+	}
+	return ret, nil
+}
+
+// InsertCurrency will insert the data specified in the rec argument into database
+// will return error if the underlying database connection has problem. or if the
+// Currency Code already in the database.
+// Will return the Currency Code saved if successful.
+func (repo *sqlDBRepository) InsertCurrency(ctx context.Context, rec *CurrenciesRecord) (string, error) {
+	lLog := sqlLog.WithField("function", "InsertCurrency")
+	if len(rec.Code) > 10 {
+		lLog.Errorf("Currency code %s is too long. Should not more than 10 digit", rec.Code)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.Name) > 30 {
+		lLog.Errorf("Currency name %s is too long. Should not more than 30 digit", rec.Name)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+	if len(rec.UpdatedBy) > 16 {
+		rec.CreatedBy = rec.UpdatedBy[:16]
+	}
+	q := "INSERT INTO currencies(" +
+		"code, name, exchange, created_at, created_by, updated_at, updated_by, is_deleted" +
+		") VALUES(?, ?, ?, ?, ?, ?, ?, false)"
+	args := []interface{}{
+		html.EscapeString(rec.Code),
+		html.EscapeString(rec.Name),
+		rec.Exchange, rec.CreatedAt,
+		html.EscapeString(rec.CreatedBy),
+		rec.UpdatedAt,
+		html.EscapeString(rec.UpdatedBy),
+	}
+	err := repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo DBRepository) error {
+		tx := txRepo.(*sqlDBRepository)
+		_, err := tx.conn().ExecContext(ctx, q, args...)
+		if err != nil {
+			lLog.Errorf("error while listing transaction by journalID. got %s", err.Error())
+			return err
+		}
+		return tx.insertCurrencyRate(ctx, rec)
+	})
+	if err != nil {
+		return "", err
+	}
+	return rec.Code, nil
+}
+
+// UpdateCurrency update an currency entity record in the database.
+// Throws error if the underlying database connection has problem.
+// The rec argument contains the Currency information to be updated.
+// The Currency Code contained within the rec MUST be already persisted before.
+func (repo *sqlDBRepository) UpdateCurrency(ctx context.Context, rec *CurrenciesRecord) error {
+	lLog := sqlLog.WithField("function", "UpdateCurrency")
+	if len(rec.Code) > 10 {
+		lLog.Errorf("Currency code %s is too long. Should not more than 10 digit", rec.Code)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.Name) > 30 {
+		lLog.Errorf("Currency name %s is too long. Should not more than 30 digit", rec.Code)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+	if len(rec.UpdatedBy) > 16 {
+		rec.CreatedBy = rec.UpdatedBy[:16]
+	}
+	q := "UPDATE currencies " +
+		"set name=?, exchange=?, created_at=?, created_by=?, updated_at=?, updated_by=?" +
+		" WHERE code=? AND is_deleted=false"
+	args := []interface{}{
+		html.EscapeString(rec.Name),
+		rec.Exchange,
+		rec.CreatedAt,
+		html.EscapeString(rec.CreatedBy),
+		rec.UpdatedAt,
+		html.EscapeString(rec.UpdatedBy),
+		html.EscapeString(rec.Code),
+	}
+	return repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo DBRepository) error {
+		tx := txRepo.(*sqlDBRepository)
+		_, err := tx.conn().ExecContext(ctx, q, args...)
+		if err != nil {
+			lLog.Errorf("error while listing transaction by journalID. got %s", err.Error())
+			return err
+		}
+		return tx.insertCurrencyRate(ctx, rec)
+	})
+}
+
+// insertCurrencyRate appends the exchange unit of the currency record to the currency rates, effective since it is updated.
+func (repo *sqlDBRepository) insertCurrencyRate(ctx context.Context, rec *CurrenciesRecord) error {
+	lLog := sqlLog.WithField("function", "insertCurrencyRate")
+	q := "INSERT INTO currency_rates(code, exchange, effective_at, created_by) VALUES(?, ?, ?, ?)"
+	// the effective_at column keeps whole seconds, MySQL would round a fraction up and put the rate in effect
+	// after the currency is read with it.
+	_, err := repo.conn().ExecContext(ctx, q, html.EscapeString(rec.Code), rec.Exchange, rec.UpdatedAt.Truncate(time.Second), html.EscapeString(rec.UpdatedBy))
+	if err != nil {
+		lLog.Errorf("error while inserting currency rate. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// DeleteCurrency soft/logical delete a currency entity.
+// Throws error if the underlying database connection has problem.
+// If the Currency Code not exist, it will do nothing and return nil.
+func (repo *sqlDBRepository) DeleteCurrency(ctx context.Context, currencyCode string) error {
+	lLog := sqlLog.WithField("function", "DeleteCurrency")
+	q := "UPDATE currencies " +
+		"set is_deleted=true" +
+		" WHERE code=? AND is_deleted=true"
+	args := []interface{}{
+		currencyCode,
+	}
+	_, err := repo.conn().ExecContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while deleting currency. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// ListCurrency will list currencies in paginated fashion.
+// Throws error if the underlying database connection has problem.
+// It will return CurrenciesRecord sorted, starting from the offset with total maximum number or item, specified
+// in the length argument.
+// It returns list of CurrenciesRecord
+func (repo *sqlDBRepository) ListCurrency(ctx context.Context, sort string, offset, length int) ([]*CurrenciesRecord, error) {
+	lLog := sqlLog.WithField("function", "ListCurrency")
+	q := "SELECT code, name, exchange, created_at, created_by, updated_at, updated_by" +
+		" FROM currencies WHERE is_deleted=false ORDER BY " + sort + " ASC LIMIT ? OFFSET ?"
+	rows, err := repo.conn().QueryxContext(ctx, q, length, offset)
+	if err != nil {
+		lLog.Errorf("error while listing currencies. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*CurrenciesRecord, 0)
+	for rows.Next() {
+		ar := &CurrenciesRecord{}
+		err := rows.Scan(&ar.Code, &ar.Name, &ar.Exchange, &ar.CreatedAt, &ar.CreatedBy, &ar.UpdatedAt, &ar.UpdatedBy)
+		if err != nil {
+			lLog.Errorf("error while scanning rows in ListCurrency function. got %s", err.Error())
+		} else {
+			ret = append(ret, ar)
+		}
+	}
+	return ret, nil
+}
+
+// GetCurrency retrieves an Currency Record from database where the code is specified.
+// Throws error if  the underlying database connection has problem.
+// It returns an instance of CurrenciesRecord or nil if record not found
+func (repo *sqlDBRepository) GetCurrency(ctx context.Context, code string) (*CurrenciesRecord, error) {
+	lLog := sqlLog.WithField("function", "GetCurrency")
+	q := "SELECT  code, name, exchange, created_at, created_by, updated_at, updated_by" +
+		" FROM currencies WHERE code=? AND is_deleted=false"
+	row := repo.conn().QueryRowxContext(ctx, q, code)
+	if row.Err() != nil {
+		if row.Err() == sql.ErrNoRows {
+			return nil, acccore.ErrCurrencyNotFound
+		}
+		lLog.Errorf("error while retrieving currencies. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	ar := &CurrenciesRecord{}
+	err := row.Scan(&ar.Code, &ar.Name, &ar.Exchange, &ar.CreatedAt, &ar.CreatedBy, &ar.UpdatedAt, &ar.UpdatedBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while scanning currency record. got %s", err.Error())
+		return nil, err
+	}
+	return ar, nil
+}
+
+// GetCurrencyRateAt retrieves the CurrencyRateRecord of the specified currency code that is in effect at the
+// specified time, the latest one that became effective at or before it.
+// Throws error if the underlying database connection has problem.
+// It returns an instance of CurrencyRateRecord or nil if the currency had no exchange unit at that time.
+func (repo *sqlDBRepository) GetCurrencyRateAt(ctx context.Context, code string, at time.Time) (*CurrencyRateRecord, error) {
+	lLog := sqlLog.WithField("function", "GetCurrencyRateAt")
+	q := "SELECT code, exchange, effective_at, created_by FROM currency_rates WHERE code=? AND effective_at <= ?" +
+		" ORDER BY effective_at DESC, id DESC LIMIT 1"
+	row := repo.conn().QueryRowxContext(ctx, q, code, at)
+	if row.Err() != nil {
+		if row.Err() == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while retrieving currency rate. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	rr := &CurrencyRateRecord{}
+	err := row.Scan(&rr.Code, &rr.Exchange, &rr.EffectiveAt, &rr.CreatedBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while scanning currency rate record. got %s", err.Error())
+		return nil, err
+	}
+	return rr, nil
+}
+
+// InsertIdempotencyKey will insert the data specified in the rec argument into database
+// will return error if the underlying database connection has problem. or ErrIdempotencyKeyExists if the
+// IdempotencyKey already in the database.
+// Will return the IdempotencyKey saved if successful.
+func (repo *sqlDBRepository) InsertIdempotencyKey(ctx context.Context, rec *IdempotencyKeyRecord) (string, error) {
+	lLog := sqlLog.WithField("function", "InsertIdempotencyKey")
+	if len(rec.IdempotencyKey) > MaxIdempotencyKeyLength {
+		lLog.Errorf("Idempotency key %s is too long. Should not more than %d digit", rec.IdempotencyKey, MaxIdempotencyKeyLength)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+	q := "INSERT INTO idempotency_keys(" +
+		"idempotency_key, request_hash, journal_id, response_body, created_at, created_by" +
+		") VALUES(?, ?, ?, ?, ?, ?)"
+	args := []interface{}{
+		rec.IdempotencyKey,
+		rec.RequestHash,
+		rec.JournalID,
+		rec.ResponseBody,
+		rec.CreatedAt,
+		html.EscapeString(rec.CreatedBy),
+	}
+	_, err := repo.conn().ExecContext(ctx, q, args...)
+	if err != nil {
+		// a concurrent request holding the same key makes this insert wait for its transaction,
+		// then fail on the primary key once it commits.
+		if repo.dialect.isDuplicateKeyError(err) {
+			return "", errors.ErrIdempotencyKeyExists
+		}
+		lLog.Errorf("error while inserting idempotency key. got %s", err.Error())
+		return "", err
+	}
+	return rec.IdempotencyKey, nil
+}
+
+// GetIdempotencyKey retrieves an IdempotencyKeyRecord from database where the key is specified.
+// Throws error if the underlying database connection has problem.
+// It returns an instance of IdempotencyKeyRecord or nil if record not found
+func (repo *sqlDBRepository) GetIdempotencyKey(ctx context.Context, key string) (*IdempotencyKeyRecord, error) {
+	lLog := sqlLog.WithField("function", "GetIdempotencyKey")
+	q := "SELECT idempotency_key, request_hash, journal_id, response_body, created_at, created_by" +
+		" FROM idempotency_keys WHERE idempotency_key=?"
+	row := repo.conn().QueryRowxContext(ctx, q, key)
+	if row.Err() != nil {
+		if row.Err() == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while retrieving idempotency key. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	ir := &IdempotencyKeyRecord{}
+	err := row.Scan(&ir.IdempotencyKey, &ir.RequestHash, &ir.JournalID, &ir.ResponseBody, &ir.CreatedAt, &ir.CreatedBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while scanning idempotency key record. got %s", err.Error())
+		return nil, err
+	}
+	return ir, nil
+}
+
+// DeleteIdempotencyKey permanently delete an idempotency key record, so the key can be used again.
+// Throws error if the underlying database connection has problem.
+// If the key not exist, it will do nothing and return nil.
+func (repo *sqlDBRepository) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	lLog := sqlLog.WithField("function", "DeleteIdempotencyKey")
+	q := "DELETE FROM idempotency_keys WHERE idempotency_key=?"
+	_, err := repo.conn().ExecContext(ctx, q, key)
+	if err != nil {
+		lLog.Errorf("error while deleting idempotency key. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// DeleteIdempotencyKeysBefore permanently delete all idempotency key records created before the specified time.
+// Throws error if the underlying database connection has problem.
+// It returns the number of records deleted.
+func (repo *sqlDBRepository) DeleteIdempotencyKeysBefore(ctx context.Context, before time.Time) (int64, error) {
+	lLog := sqlLog.WithField("function", "DeleteIdempotencyKeysBefore")
+	q := "DELETE FROM idempotency_keys WHERE created_at < ?"
+	res, err := repo.conn().ExecContext(ctx, q, before)
+	if err != nil {
+		lLog.Errorf("error while deleting idempotency keys. got %s", err.Error())
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		lLog.Errorf("error while counting deleted idempotency keys. got %s", err.Error())
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// GetSetting retrieves a SettingRecord from database where the name is specified.
+// Throws error if the underlying database connection has problem.
+// It returns an instance of SettingRecord or nil if the setting was never set.
+func (repo *sqlDBRepository) GetSetting(ctx context.Context, name string) (*SettingRecord, error) {
+	lLog := sqlLog.WithField("function", "GetSetting")
+	q := "SELECT name, value, updated_at, updated_by FROM settings WHERE name=?"
+	row := repo.conn().QueryRowxContext(ctx, q, name)
+	if row.Err() != nil {
+		if row.Err() == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while retrieving setting. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	sr := &SettingRecord{}
+	err := row.Scan(&sr.Name, &sr.Value, &sr.UpdatedAt, &sr.UpdatedBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while scanning setting record. got %s", err.Error())
+		return nil, err
+	}
+	return sr, nil
+}
+
+// SetSetting insert or update the setting specified in the rec argument and record the value in the setting
+// history, both in the same database transaction.
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) SetSetting(ctx context.Context, rec *SettingRecord) error {
+	lLog := sqlLog.WithField("function", "SetSetting")
+	if len(rec.Value) > MaxSettingValueLength {
+		lLog.Errorf("Setting value %s is too long. Should not more than %d digit", rec.Value, MaxSettingValueLength)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.UpdatedBy) > 16 {
+		rec.UpdatedBy = rec.UpdatedBy[:16]
+	}
+	return repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo DBRepository) error {
+		tx := txRepo.(*sqlDBRepository)
+		existing, err := tx.GetSetting(ctx, rec.Name)
+		if err != nil {
+			return err
+		}
+		q := "INSERT INTO settings(value, updated_at, updated_by, name) VALUES(?, ?, ?, ?)"
+		if existing != nil {
+			q = "UPDATE settings set value=?, updated_at=?, updated_by=? WHERE name=?"
+		}
+		_, err = tx.conn().ExecContext(ctx, q, rec.Value, rec.UpdatedAt, html.EscapeString(rec.UpdatedBy), rec.Name)
+		if err != nil {
+			lLog.Errorf("error while saving setting. got %s", err.Error())
+			return err
+		}
+		q = "INSERT INTO setting_history(name, value, created_at, created_by) VALUES(?, ?, ?, ?)"
+		_, err = tx.conn().ExecContext(ctx, q, rec.Name, rec.Value, rec.UpdatedAt, html.EscapeString(rec.UpdatedBy))
+		if err != nil {
+			lLog.Errorf("error while inserting setting history. got %s", err.Error())
+			return err
+		}
+		return nil
+	})
+}
+
+// ListSettingHistory will list the values a setting was given in paginated fashion, latest first.
+// Throws error if the underlying database connection has problem.
+// It returns list of SettingHistoryRecord
+func (repo *sqlDBRepository) ListSettingHistory(ctx context.Context, name string, offset, length int) ([]*SettingHistoryRecord, error) {
+	lLog := sqlLog.WithField("function", "ListSettingHistory")
+	q := "SELECT name, value, created_at, created_by FROM setting_history WHERE name=?" +
+		" ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?"
+	rows, err := repo.conn().QueryxContext(ctx, q, name, length, offset)
+	if err != nil {
+		lLog.Errorf("error while listing setting history. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*SettingHistoryRecord, 0)
+	for rows.Next() {
+		hr := &SettingHistoryRecord{}
+		err := rows.Scan(&hr.Name, &hr.Value, &hr.CreatedAt, &hr.CreatedBy)
+		if err != nil {
+			lLog.Errorf("error while scanning rows in ListSettingHistory function. got %s", err.Error())
+		} else {
+			ret = append(ret, hr)
+		}
+	}
+	return ret, nil
+}
+
+// CountSettingHistory will return the number of values a setting was given.
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) CountSettingHistory(ctx context.Context, name string) (int, error) {
+	lLog := sqlLog.WithField("function", "CountSettingHistory")
+	q := "SELECT COUNT(*) as historyCounts FROM setting_history WHERE name=?"
+	row := repo.conn().QueryRowxContext(ctx, q, name)
+	if row.Err() != nil {
+		lLog.Errorf("error while counting setting history. got %s", row.Err().Error())
+		return 0, row.Err()
+	}
+	count := 0
+	err := row.Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// holdColumns are the account_holds table columns read into a HoldRecord, in the order GetHold scans them.
+const holdColumns = "hold_id, account_number, amount, description, status, expires_at, captured_amount, journal_id, created_at, created_by, updated_at, updated_by"
+
+// InsertHold will insert the data specified in the rec argument into database
+// will return error if the underlying database connection has problem. or if the
+// HoldID already in the database.
+// Will return the HoldID saved if successful.
+func (repo *sqlDBRepository) InsertHold(ctx context.Context, rec *HoldRecord) (string, error) {
+	lLog := sqlLog.WithField("function", "InsertHold")
+	if len(rec.HoldID) > 20 {
+		lLog.Errorf("HoldID %s is too long. Should not more than 20 digit", rec.HoldID)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+	q := "INSERT INTO account_holds(" + holdColumns + ") VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	args := []interface{}{
+		rec.HoldID,
+		html.EscapeString(rec.AccountNumber),
+		rec.Amount,
+		html.EscapeString(rec.Description),
+		rec.Status,
+		rec.ExpiresAt.Truncate(time.Second),
+		rec.CapturedAmount,
+		rec.JournalID,
+		rec.CreatedAt,
+		html.EscapeString(rec.CreatedBy),
+		rec.CreatedAt,
+		html.EscapeString(rec.CreatedBy),
+	}
+	_, err := repo.conn().ExecContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while inserting hold. got %s", err.Error())
+		return "", err
+	}
+	return rec.HoldID, nil
+}
+
+// GetHold retrieves a HoldRecord from database where the holdID is specified.
+// Throws error if the underlying database connection has problem.
+// It returns an instance of HoldRecord or nil if record not found
+func (repo *sqlDBRepository) GetHold(ctx context.Context, holdID string) (*HoldRecord, error) {
+	lLog := sqlLog.WithField("function", "GetHold")
+	q := "SELECT " + holdColumns + " FROM account_holds WHERE hold_id=?"
+	row := repo.conn().QueryRowxContext(ctx, q, holdID)
+	if row.Err() != nil {
+		if row.Err() == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while retrieving hold. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	hr := &HoldRecord{}
+	var journalID sql.NullString
+	err := row.Scan(&hr.HoldID, &hr.AccountNumber, &hr.Amount, &hr.Description, &hr.Status, &hr.ExpiresAt, &hr.CapturedAmount,
+		&journalID, &hr.CreatedAt, &hr.CreatedBy, &hr.UpdatedAt, &hr.UpdatedBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while scanning hold record. got %s", err.Error())
+		return nil, err
+	}
+	hr.JournalID = journalID.String
+	return hr, nil
+}
+
+// UpdateHoldStatus writes the status, captured amount, journal ID and update time and author of rec,
+// only if the hold is still in the fromStatus.
+// Throws error if the underlying database connection has problem.
+// It returns false if the hold is not in the fromStatus.
+func (repo *sqlDBRepository) UpdateHoldStatus(ctx context.Context, rec *HoldRecord, fromStatus string) (bool, error) {
+	lLog := sqlLog.WithField("function", "UpdateHoldStatus")
+	if len(rec.UpdatedBy) > 16 {
+		rec.UpdatedBy = rec.UpdatedBy[:16]
+	}
+	q := "UPDATE account_holds SET status=?, captured_amount=?, journal_id=?, updated_at=?, updated_by=? WHERE hold_id=? AND status=?"
+	res, err := repo.conn().ExecContext(ctx, q, rec.Status, rec.CapturedAmount, rec.JournalID, rec.UpdatedAt, html.EscapeString(rec.UpdatedBy), rec.HoldID, fromStatus)
+	if err != nil {
+		lLog.Errorf("error while updating hold status. got %s", err.Error())
+		return false, err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		lLog.Errorf("error while counting updated holds. got %s", err.Error())
+		return false, err
+	}
+	return updated > 0, nil
+}
+
+// SumAccountHolds will return the total amount of the HELD holds of the account that expire after the specified time.
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) SumAccountHolds(ctx context.Context, accountNumber string, at time.Time) (int64, error) {
+	lLog := sqlLog.WithField("function", "SumAccountHolds")
+	q := "SELECT COALESCE(SUM(amount), 0) FROM account_holds WHERE account_number=? AND status='HELD' AND expires_at > ?"
+	row := repo.conn().QueryRowxContext(ctx, q, html.EscapeString(accountNumber), at)
+	if row.Err() != nil {
+		lLog.Errorf("error while summing account holds. got %s", row.Err().Error())
+		return 0, row.Err()
+	}
+	var sum int64
+	err := row.Scan(&sum)
+	if err != nil {
+		lLog.Errorf("error while scanning account holds sum. got %s", err.Error())
+		return 0, err
+	}
+	return sum, nil
+}
+
+// ExpireHolds moves every HELD hold that expires at or before the specified time into EXPIRED.
+// Throws error if the underlying database connection has problem.
+// It returns the number of holds expired.
+func (repo *sqlDBRepository) ExpireHolds(ctx context.Context, at time.Time, updatedBy string) (int64, error) {
+	lLog := sqlLog.WithField("function", "ExpireHolds")
+	if len(updatedBy) > 16 {
+		updatedBy = updatedBy[:16]
+	}
+	q := "UPDATE account_holds SET status='EXPIRED', updated_at=?, updated_by=? WHERE status='HELD' AND expires_at <= ?"
+	res, err := repo.conn().ExecContext(ctx, q, at, html.EscapeString(updatedBy), at)
+	if err != nil {
+		lLog.Errorf("error while expiring holds. got %s", err.Error())
+		return 0, err
+	}
+	expired, err := res.RowsAffected()
+	if err != nil {
+		lLog.Errorf("error while counting expired holds. got %s", err.Error())
+		return 0, err
+	}
+	return expired, nil
+}
+
+// coaColumns are the chart_of_accounts table columns read into a CoaRecord, in the order scanCoa scans them.
+const coaColumns = "code, parent_code, name, coa_type, alignment, created_at, created_by, updated_at, updated_by"
+
+// scanCoa scans a chart_of_accounts row selected with coaColumns.
+func scanCoa(row rowScanner) (*CoaRecord, error) {
+	cr := &CoaRecord{}
+	var parentCode sql.NullString
+	err := row.Scan(&cr.Code, &parentCode, &cr.Name, &cr.Type, &cr.Alignment, &cr.CreatedAt, &cr.CreatedBy, &cr.UpdatedAt, &cr.UpdatedBy)
+	if err != nil {
+		return nil, err
+	}
+	cr.ParentCode = parentCode.String
+	return cr, nil
+}
+
+// nullableCoa returns the code to write into a nullable code column, nil when it is empty.
+func nullableCoa(code string) interface{} {
+	if len(code) == 0 {
+		return nil
+	}
+	return code
+}
+
+// InsertCoa will insert the data specified in the rec argument into database
+// will return error if the underlying database connection has problem. or if the
+// Code already in the database.
+// Will return the Code saved if successful.
+func (repo *sqlDBRepository) InsertCoa(ctx context.Context, rec *CoaRecord) (string, error) {
+	lLog := sqlLog.WithField("function", "InsertCoa")
+	if len(rec.Code) > 10 || len(rec.ParentCode) > 10 {
+		lLog.Errorf("COA code %s or its parent %s is too long. Should not more than 10 digit", rec.Code, rec.ParentCode)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.Name) > 128 {
+		lLog.Errorf("COA name %s is too long. Should not more than 128 characters", rec.Name)
+		return "", errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+	q := "INSERT INTO chart_of_accounts(" + coaColumns + ") VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err := repo.conn().ExecContext(ctx, q, rec.Code, nullableCoa(rec.ParentCode), html.EscapeString(rec.Name), rec.Type, rec.Alignment,
+		rec.CreatedAt, html.EscapeString(rec.CreatedBy), rec.CreatedAt, html.EscapeString(rec.CreatedBy))
+	if err != nil {
+		lLog.Errorf("error while inserting coa. got %s", err.Error())
+		return "", err
+	}
+	return rec.Code, nil
+}
+
+// UpdateCoa update the parent code, name and update time and author of a COA entity record in the database.
+// Throws error if the underlying database connection has problem.
+// The Code contained within the rec MUST be already persisted before.
+func (repo *sqlDBRepository) UpdateCoa(ctx context.Context, rec *CoaRecord) error {
+	lLog := sqlLog.WithField("function", "UpdateCoa")
+	if len(rec.ParentCode) > 10 {
+		lLog.Errorf("COA parent code %s is too long. Should not more than 10 digit", rec.ParentCode)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.Name) > 128 {
+		lLog.Errorf("COA name %s is too long. Should not more than 128 characters", rec.Name)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.UpdatedBy) > 16 {
+		rec.UpdatedBy = rec.UpdatedBy[:16]
+	}
+	q := "UPDATE chart_of_accounts SET parent_code=?, name=?, updated_at=?, updated_by=? WHERE code=?"
+	_, err := repo.conn().ExecContext(ctx, q, nullableCoa(rec.ParentCode), html.EscapeString(rec.Name), rec.UpdatedAt, html.EscapeString(rec.UpdatedBy), rec.Code)
+	if err != nil {
+		lLog.Errorf("error while updating coa. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// DeleteCoa permanently delete a COA entity record.
+// Throws error if the underlying database connection has problem.
+// If the Code not exist, it will do nothing and return nil.
+func (repo *sqlDBRepository) DeleteCoa(ctx context.Context, code string) error {
+	lLog := sqlLog.WithField("function", "DeleteCoa")
+	_, err := repo.conn().ExecContext(ctx, "DELETE FROM chart_of_accounts WHERE code=?", code)
+	if err != nil {
+		lLog.Errorf("error while deleting coa. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// GetCoa retrieves a CoaRecord from database where the code is specified.
+// Throws error if the underlying database connection has problem.
+// It returns an instance of CoaRecord or nil if record not found
+func (repo *sqlDBRepository) GetCoa(ctx context.Context, code string) (*CoaRecord, error) {
+	lLog := sqlLog.WithField("function", "GetCoa")
+	q := "SELECT " + coaColumns + " FROM chart_of_accounts WHERE code=?"
+	row := repo.conn().QueryRowxContext(ctx, q, code)
+	if row.Err() != nil {
+		if row.Err() == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while retrieving coa. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	cr, err := scanCoa(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while scanning coa record. got %s", err.Error())
+		return nil, err
+	}
+	return cr, nil
+}
+
+// ListCoa will list the whole chart of accounts sorted by code.
+// Throws error if the underlying database connection has problem.
+// It returns list of CoaRecord
+func (repo *sqlDBRepository) ListCoa(ctx context.Context) ([]*CoaRecord, error) {
+	lLog := sqlLog.WithField("function", "ListCoa")
+	q := "SELECT " + coaColumns + " FROM chart_of_accounts ORDER BY code ASC"
+	rows, err := repo.conn().QueryxContext(ctx, q)
+	if err != nil {
+		lLog.Errorf("error while listing coa. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*CoaRecord, 0)
+	for rows.Next() {
+		cr, err := scanCoa(rows)
+		if err != nil {
+			lLog.Errorf("error while scanning coa record. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, cr)
+	}
+	return ret, rows.Err()
+}
+
+// SumAccountBalanceByCoa will return the total balance of the accounts having one of the specified COA,
+// per currency and alignment.
+// Throws error if the underlying database connection has problem.
+// It returns list of CoaBalanceRecord
+func (repo *sqlDBRepository) SumAccountBalanceByCoa(ctx context.Context, coas []string) ([]*CoaBalanceRecord, error) {
+	lLog := sqlLog.WithField("function", "SumAccountBalanceByCoa")
+	ret := make([]*CoaBalanceRecord, 0)
+	if len(coas) == 0 {
+		return ret, nil
+	}
+	q, args, err := sqlx.In("SELECT currency_code, alignment, COALESCE(SUM(balance), 0), COUNT(*) FROM accounts"+
+		" WHERE coa IN (?) AND is_deleted=false GROUP BY currency_code, alignment ORDER BY currency_code, alignment", coas)
+	if err != nil {
+		lLog.Errorf("error while building the coa balance query. got %s", err.Error())
+		return nil, err
+	}
+	rows, err := repo.conn().QueryxContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while summing account balance by coa. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		br := &CoaBalanceRecord{}
+		if err := rows.Scan(&br.CurrencyCode, &br.Alignment, &br.Balance, &br.Accounts); err != nil {
+			lLog.Errorf("error while scanning coa balance. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, br)
+	}
+	return ret, rows.Err()
+}
+
+// ListAccountTotals will return the total debit and credit of the transactions of every account made at or before the specified time,
+// accounts without transactions have zero totals. Only the accounts in the currency are listed if it is not empty.
+// Throws error if the underlying database connection has problem.
+// It returns list of AccountTotalRecord sorted by currency, coa and account number
+func (repo *sqlDBRepository) ListAccountTotals(ctx context.Context, at time.Time, currency string) ([]*AccountTotalRecord, error) {
+	lLog := sqlLog.WithField("function", "ListAccountTotals")
+	q := "SELECT a.account_number, a.name, COALESCE(a.coa, ''), a.currency_code, a.alignment," +
+		" COALESCE(SUM(CASE WHEN t.alignment = 'DEBIT' THEN t.amount ELSE 0 END), 0)," +
+		" COALESCE(SUM(CASE WHEN t.alignment = 'CREDIT' THEN t.amount ELSE 0 END), 0)" +
+		" FROM accounts a LEFT JOIN transactions t ON t.account_number = a.account_number AND t.transaction_time <= ? AND t.is_deleted=false" +
+		" WHERE a.is_deleted=false"
+	args := []interface{}{at}
+	if len(currency) > 0 {
+		q += " AND a.currency_code = ?"
+		args = append(args, html.EscapeString(currency))
+	}
+	q += " GROUP BY a.account_number, a.name, a.coa, a.currency_code, a.alignment ORDER BY a.currency_code, a.coa, a.account_number"
+	rows, err := repo.conn().QueryxContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while listing account totals. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*AccountTotalRecord, 0)
+	for rows.Next() {
+		tr := &AccountTotalRecord{}
+		if err := rows.Scan(&tr.AccountNumber, &tr.Name, &tr.Coa, &tr.CurrencyCode, &tr.Alignment, &tr.Debit, &tr.Credit); err != nil {
+			lLog.Errorf("error while scanning account totals. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, tr)
+	}
+	return ret, rows.Err()
+}
+
+// ListAccountTransactions will list every transaction of the account in the order they were posted.
+// Throws error if the underlying database connection has problem.
+// It returns list of TransactionRecord sorted by creation time and transaction time
+func (repo *sqlDBRepository) ListAccountTransactions(ctx context.Context, accountNumber string) ([]*TransactionRecord, error) {
+	lLog := sqlLog.WithField("function", "ListAccountTransactions")
+	q := "SELECT transaction_id, transaction_time, account_number, journal_id, description, alignment, amount, balance, created_at, created_by" +
+		" FROM transactions WHERE account_number=? AND is_deleted=false ORDER BY created_at ASC, transaction_time ASC"
+	rows, err := repo.conn().QueryxContext(ctx, q, accountNumber)
+	if err != nil {
+		lLog.Errorf("error while listing transactions of account %s. got %s", accountNumber, err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*TransactionRecord, 0)
+	for rows.Next() {
+		ar := &TransactionRecord{}
+		if err := rows.Scan(&ar.TransactionID, &ar.TransactionTime, &ar.AccountNumber, &ar.JournalID, &ar.Description, &ar.Alignment, &ar.Amount, &ar.Balance, &ar.CreatedAt, &ar.CreatedBy); err != nil {
+			lLog.Errorf("error while scanning transactions of account %s. got %s", accountNumber, err.Error())
+			return nil, err
+		}
+		ret = append(ret, ar)
+	}
+	return ret, rows.Err()
+}
+
+// ListUnbalancedJournalTotals will return the total debit and credit of the journals whose debit and credit
+// differ in some currency, the transactions of each journal are summed per currency of their accounts.
+// Throws error if the underlying database connection has problem.
+// It returns list of JournalTotalRecord sorted by journal ID and currency
+func (repo *sqlDBRepository) ListUnbalancedJournalTotals(ctx context.Context) ([]*JournalTotalRecord, error) {
+	lLog := sqlLog.WithField("function", "ListUnbalancedJournalTotals")
+	debit := "SUM(CASE WHEN t.alignment = 'DEBIT' THEN t.amount ELSE 0 END)"
+	credit := "SUM(CASE WHEN t.alignment = 'CREDIT' THEN t.amount ELSE 0 END)"
+	q := "SELECT t.journal_id, a.currency_code, " + debit + ", " + credit +
+		" FROM transactions t JOIN accounts a ON a.account_number = t.account_number" +
+		" WHERE t.is_deleted=false GROUP BY t.journal_id, a.currency_code" +
+		" HAVING " + debit + " <> " + credit + " ORDER BY t.journal_id, a.currency_code"
+	rows, err := repo.conn().QueryxContext(ctx, q)
+	if err != nil {
+		lLog.Errorf("error while listing unbalanced journal totals. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*JournalTotalRecord, 0)
+	for rows.Next() {
+		tr := &JournalTotalRecord{}
+		if err := rows.Scan(&tr.JournalID, &tr.CurrencyCode, &tr.Debit, &tr.Credit); err != nil {
+			lLog.Errorf("error while scanning unbalanced journal totals. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, tr)
+	}
+	return ret, rows.Err()
+}
+
+// ListOrphanTransactions will list the transactions whose account or journal does not exist or has been deleted.
+// Throws error if the underlying database connection has problem.
+// It returns list of TransactionRecord sorted by transaction ID
+func (repo *sqlDBRepository) ListOrphanTransactions(ctx context.Context) ([]*TransactionRecord, error) {
+	lLog := sqlLog.WithField("function", "ListOrphanTransactions")
+	q := "SELECT t.transaction_id, t.transaction_time, t.account_number, t.journal_id, t.description, t.alignment, t.amount, t.balance, t.created_at, t.created_by" +
+		" FROM transactions t" +
+		" LEFT JOIN accounts a ON a.account_number = t.account_number AND a.is_deleted=false" +
+		" LEFT JOIN journals j ON j.journal_id = t.journal_id AND j.is_deleted=false" +
+		" WHERE t.is_deleted=false AND (a.account_number IS NULL OR j.journal_id IS NULL) ORDER BY t.transaction_id"
+	rows, err := repo.conn().QueryxContext(ctx, q)
+	if err != nil {
+		lLog.Errorf("error while listing orphan transactions. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*TransactionRecord, 0)
+	for rows.Next() {
+		ar := &TransactionRecord{}
+		if err := rows.Scan(&ar.TransactionID, &ar.TransactionTime, &ar.AccountNumber, &ar.JournalID, &ar.Description, &ar.Alignment, &ar.Amount, &ar.Balance, &ar.CreatedAt, &ar.CreatedBy); err != nil {
+			lLog.Errorf("error while scanning orphan transactions. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, ar)
+	}
+	return ret, rows.Err()
+}
+
+// GetJournalChainForUpdate retrieves the last journal of the hash chain and places an exclusive lock on it
+// until the transaction the repository is bound to ends, journals are chained one at a time.
+// Throws ErrNotInTransaction if the repository is not bound to a transaction (see ExecuteInTransaction).
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) GetJournalChainForUpdate(ctx context.Context) (*JournalChainRecord, error) {
+	lLog := sqlLog.WithField("function", "GetJournalChainForUpdate")
+	if repo.tx == nil {
+		lLog.Errorf("error locking the journal chain. repository is not bound to a transaction")
+		return nil, errors.ErrNotInTransaction
+	}
+	q := "SELECT last_sequence, last_hash FROM journal_chain WHERE id=1" + repo.dialect.lockForUpdate
+	row := repo.conn().QueryRowxContext(ctx, q)
+	if row.Err() != nil {
+		lLog.Errorf("error while locking the journal chain. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	rec := &JournalChainRecord{}
+	if err := row.Scan(&rec.LastSequence, &rec.LastHash); err != nil {
+		lLog.Errorf("error while scanning the journal chain. got %s", err.Error())
+		return nil, err
+	}
+	return rec, nil
+}
+
+// UpdateJournalChain moves the last journal of the hash chain.
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) UpdateJournalChain(ctx context.Context, rec *JournalChainRecord) error {
+	lLog := sqlLog.WithField("function", "UpdateJournalChain")
+	q := "UPDATE journal_chain SET last_sequence=?, last_hash=? WHERE id=1"
+	if _, err := repo.conn().ExecContext(ctx, q, rec.LastSequence, rec.LastHash); err != nil {
+		lLog.Errorf("error while updating the journal chain. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// UpdateJournalHash sets the chain hash of a journal.
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) UpdateJournalHash(ctx context.Context, journalID, chainHash string) error {
+	lLog := sqlLog.WithField("function", "UpdateJournalHash")
+	q := "UPDATE journals SET chain_hash=? WHERE journal_id=?"
+	if _, err := repo.conn().ExecContext(ctx, q, chainHash, html.EscapeString(journalID)); err != nil {
+		lLog.Errorf("error while updating the hash of journal %s. got %s", journalID, err.Error())
+		return err
+	}
+	return nil
+}
+
+// GetJournalChainRange will return the first and last chain sequence of the journals booked within the time range, inclusive.
+// Throws error if the underlying database connection has problem.
+// It returns zeros if no chained journal was booked within the time range.
+func (repo *sqlDBRepository) GetJournalChainRange(ctx context.Context, timeFrom, timeTo time.Time) (int64, int64, error) {
+	lLog := sqlLog.WithField("function", "GetJournalChainRange")
+	q := "SELECT COALESCE(MIN(chain_sequence), 0), COALESCE(MAX(chain_sequence), 0)" +
+		" FROM journals WHERE created_at >= ? AND created_at <= ? AND chain_sequence IS NOT NULL"
+	row := repo.conn().QueryRowxContext(ctx, q, timeFrom, timeTo)
+	if row.Err() != nil {
+		lLog.Errorf("error while retrieving the journal chain range. got %s", row.Err().Error())
+		return 0, 0, row.Err()
+	}
+	var first, last int64
+	if err := row.Scan(&first, &last); err != nil {
+		lLog.Errorf("error while scanning the journal chain range. got %s", err.Error())
+		return 0, 0, err
+	}
+	return first, last, nil
+}
+
+// ListJournalBySequence will list the chained journals whose chain sequence is within the range, inclusive.
+// Throws error if the underlying database connection has problem.
+// It returns list of JournalRecord sorted by chain sequence
+func (repo *sqlDBRepository) ListJournalBySequence(ctx context.Context, sequenceFrom, sequenceTo int64) ([]*JournalRecord, error) {
+	lLog := sqlLog.WithField("function", "ListJournalBySequence")
+	q := "SELECT " + journalColumns +
+		" FROM journals WHERE chain_sequence >= ? AND chain_sequence <= ? AND is_deleted=false ORDER BY chain_sequence ASC"
+	rows, err := repo.conn().QueryxContext(ctx, q, sequenceFrom, sequenceTo)
+	if err != nil {
+		lLog.Errorf("error while listing journals by chain sequence. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*JournalRecord, 0)
+	for rows.Next() {
+		ar, err := scanJournal(rows)
+		if err != nil {
+			lLog.Errorf("error while scanning journals by chain sequence. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, ar)
+	}
+	return ret, rows.Err()
+}
+
+// periodColumns are the columns of the accounting_periods table, in the order scanPeriod reads them.
+const periodColumns = "period_code, COALESCE(closing_journal_ids, ''), closed_at, closed_by"
+
+// scanPeriod reads a period record from a row selecting the periodColumns.
+func scanPeriod(row rowScanner) (*PeriodRecord, error) {
+	pr := &PeriodRecord{}
+	if err := row.Scan(&pr.Period, &pr.ClosingJournalIDs, &pr.ClosedAt, &pr.ClosedBy); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// InsertPeriod will insert a closed accounting period.
+// Throws error if the underlying database connection has problem, or the period is already closed.
+func (repo *sqlDBRepository) InsertPeriod(ctx context.Context, rec *PeriodRecord) error {
+	lLog := sqlLog.WithField("function", "InsertPeriod")
+	if len(rec.ClosedBy) > 16 {
+		rec.ClosedBy = rec.ClosedBy[:16]
+	}
+	q := "INSERT INTO accounting_periods(period_code, closing_journal_ids, closed_at, closed_by) VALUES(?, ?, ?, ?)"
+	_, err := repo.conn().ExecContext(ctx, q, rec.Period, rec.ClosingJournalIDs, rec.ClosedAt, html.EscapeString(rec.ClosedBy))
+	if err != nil {
+		lLog.Errorf("error while inserting period %s. got %s", rec.Period, err.Error())
+		return err
+	}
+	return nil
+}
+
+// GetPeriod retrieves a closed accounting period.
+// Throws error if the underlying database connection has problem.
+// It returns an instance of PeriodRecord or nil if the period was not closed on its own.
+func (repo *sqlDBRepository) GetPeriod(ctx context.Context, period string) (*PeriodRecord, error) {
+	return repo.getPeriod(ctx, "GetPeriod", "SELECT "+periodColumns+" FROM accounting_periods WHERE period_code=?", period)
+}
+
+// GetLastClosedPeriod retrieves the latest closed accounting period.
+// Throws error if the underlying database connection has problem.
+// It returns an instance of PeriodRecord or nil if no period is closed.
+func (repo *sqlDBRepository) GetLastClosedPeriod(ctx context.Context) (*PeriodRecord, error) {
+	return repo.getPeriod(ctx, "GetLastClosedPeriod", "SELECT "+periodColumns+" FROM accounting_periods ORDER BY period_code DESC LIMIT 1")
+}
+
+// getPeriod retrieves the period record selected by the query, or nil if there is none.
+func (repo *sqlDBRepository) getPeriod(ctx context.Context, function, q string, args ...interface{}) (*PeriodRecord, error) {
+	lLog := sqlLog.WithField("function", function)
+	row := repo.conn().QueryRowxContext(ctx, q, args...)
+	if row.Err() != nil {
+		if row.Err() == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while retrieving period. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	pr, err := scanPeriod(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while scanning period record. got %s", err.Error())
+		return nil, err
+	}
+	return pr, nil
+}
+
+// ListPeriods will list the closed accounting periods.
+// Throws error if the underlying database connection has problem.
+// It returns list of PeriodRecord sorted by period
+func (repo *sqlDBRepository) ListPeriods(ctx context.Context) ([]*PeriodRecord, error) {
+	lLog := sqlLog.WithField("function", "ListPeriods")
+	rows, err := repo.conn().QueryxContext(ctx, "SELECT "+periodColumns+" FROM accounting_periods ORDER BY period_code ASC")
+	if err != nil {
+		lLog.Errorf("error while listing periods. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*PeriodRecord, 0)
+	for rows.Next() {
+		pr, err := scanPeriod(rows)
+		if err != nil {
+			lLog.Errorf("error while scanning periods. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, pr)
+	}
+	return ret, rows.Err()
+}
+
+// journalTemplateColumns are the columns of the journal_templates table, in the order scanJournalTemplate reads them.
+const journalTemplateColumns = "template_name, COALESCE(description, ''), definition, created_at, created_by"
+
+// scanJournalTemplate reads a journal template record from a row selecting the journalTemplateColumns.
+func scanJournalTemplate(row rowScanner) (*JournalTemplateRecord, error) {
+	tr := &JournalTemplateRecord{}
+	if err := row.Scan(&tr.Name, &tr.Description, &tr.Definition, &tr.CreatedAt, &tr.CreatedBy); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// InsertJournalTemplate will insert a journal template.
+// Throws error if the underlying database connection has problem, or the template name is taken.
+func (repo *sqlDBRepository) InsertJournalTemplate(ctx context.Context, rec *JournalTemplateRecord) error {
+	lLog := sqlLog.WithField("function", "InsertJournalTemplate")
+	if len(rec.Name) > 40 {
+		lLog.Errorf("journal template name %s is too long. Should not more than 40 characters", rec.Name)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.Description) > 255 {
+		lLog.Errorf("journal template description is too long. Should not more than 255 characters")
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+	q := "INSERT INTO journal_templates(template_name, description, definition, created_at, created_by) VALUES(?, ?, ?, ?, ?)"
+	_, err := repo.conn().ExecContext(ctx, q, rec.Name, rec.Description, rec.Definition, rec.CreatedAt, html.EscapeString(rec.CreatedBy))
+	if err != nil {
+		lLog.Errorf("error while inserting journal template %s. got %s", rec.Name, err.Error())
+		return err
+	}
+	return nil
+}
+
+// GetJournalTemplate retrieves a journal template by its name.
+// Throws error if the underlying database connection has problem.
+// It returns an instance of JournalTemplateRecord or nil if there is no template with the name.
+func (repo *sqlDBRepository) GetJournalTemplate(ctx context.Context, name string) (*JournalTemplateRecord, error) {
+	lLog := sqlLog.WithField("function", "GetJournalTemplate")
+	row := repo.conn().QueryRowxContext(ctx, "SELECT "+journalTemplateColumns+" FROM journal_templates WHERE template_name=?", name)
+	if row.Err() != nil {
+		if row.Err() == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while retrieving journal template. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	tr, err := scanJournalTemplate(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while scanning journal template record. got %s", err.Error())
+		return nil, err
+	}
+	return tr, nil
+}
+
+// ListJournalTemplates will list all the journal templates.
+// Throws error if the underlying database connection has problem.
+// It returns list of JournalTemplateRecord sorted by name
+func (repo *sqlDBRepository) ListJournalTemplates(ctx context.Context) ([]*JournalTemplateRecord, error) {
+	lLog := sqlLog.WithField("function", "ListJournalTemplates")
+	rows, err := repo.conn().QueryxContext(ctx, "SELECT "+journalTemplateColumns+" FROM journal_templates ORDER BY template_name ASC")
+	if err != nil {
+		lLog.Errorf("error while listing journal templates. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*JournalTemplateRecord, 0)
+	for rows.Next() {
+		tr, err := scanJournalTemplate(rows)
+		if err != nil {
+			lLog.Errorf("error while scanning journal templates. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, tr)
+	}
+	return ret, rows.Err()
+}
+
+// DeleteJournalTemplate permanently delete a journal template.
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) DeleteJournalTemplate(ctx context.Context, name string) error {
+	lLog := sqlLog.WithField("function", "DeleteJournalTemplate")
+	_, err := repo.conn().ExecContext(ctx, "DELETE FROM journal_templates WHERE template_name=?", name)
+	if err != nil {
+		lLog.Errorf("error while deleting journal template. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// journalScheduleColumns are the columns of the journal_schedules table, in the order scanJournalSchedule reads them.
+const journalScheduleColumns = "schedule_id, template_name, cron, time_zone, params, next_run_at, lease_owner, lease_until, created_at, created_by"
+
+// scanJournalSchedule reads a journal schedule record from a row selecting the journalScheduleColumns.
+func scanJournalSchedule(row rowScanner) (*JournalScheduleRecord, error) {
+	sr := &JournalScheduleRecord{}
+	if err := row.Scan(&sr.ScheduleID, &sr.TemplateName, &sr.Cron, &sr.TimeZone, &sr.Params, &sr.NextRunAt,
+		&sr.LeaseOwner, &sr.LeaseUntil, &sr.CreatedAt, &sr.CreatedBy); err != nil {
+		return nil, err
+	}
+	return sr, nil
+}
+
+// listJournalSchedules lists the journal schedules the query selects with the journalScheduleColumns.
+func (repo *sqlDBRepository) listJournalSchedules(ctx context.Context, q string, args ...interface{}) ([]*JournalScheduleRecord, error) {
+	lLog := sqlLog.WithField("function", "listJournalSchedules")
+	rows, err := repo.conn().QueryxContext(ctx, q, args...)
+	if err != nil {
+		lLog.Errorf("error while listing journal schedules. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*JournalScheduleRecord, 0)
+	for rows.Next() {
+		sr, err := scanJournalSchedule(rows)
+		if err != nil {
+			lLog.Errorf("error while scanning journal schedules. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, sr)
+	}
+	return ret, rows.Err()
+}
+
+// InsertJournalSchedule will insert a journal schedule.
+// Throws error if the underlying database connection has problem, or the schedule ID is taken.
+func (repo *sqlDBRepository) InsertJournalSchedule(ctx context.Context, rec *JournalScheduleRecord) error {
+	lLog := sqlLog.WithField("function", "InsertJournalSchedule")
+	if len(rec.ScheduleID) > 20 {
+		lLog.Errorf("journal schedule ID %s is too long. Should not more than 20 characters", rec.ScheduleID)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.Cron) > 100 {
+		lLog.Errorf("journal schedule cron expression %s is too long. Should not more than 100 characters", rec.Cron)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.TimeZone) > 64 {
+		lLog.Errorf("journal schedule time zone %s is too long. Should not more than 64 characters", rec.TimeZone)
+		return errors.ErrStringDataTooLong
+	}
+	if len(rec.CreatedBy) > 16 {
+		rec.CreatedBy = rec.CreatedBy[:16]
+	}
+	q := "INSERT INTO journal_schedules(schedule_id, template_name, cron, time_zone, params, next_run_at, lease_owner, lease_until, created_at, created_by)" +
+		" VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err := repo.conn().ExecContext(ctx, q, rec.ScheduleID, rec.TemplateName, rec.Cron, rec.TimeZone, rec.Params, rec.NextRunAt,
+		rec.LeaseOwner, rec.LeaseUntil, rec.CreatedAt, html.EscapeString(rec.CreatedBy))
+	if err != nil {
+		lLog.Errorf("error while inserting journal schedule %s. got %s", rec.ScheduleID, err.Error())
+		return err
+	}
+	return nil
+}
+
+// GetJournalSchedule retrieves a journal schedule by its ID.
+// Throws error if the underlying database connection has problem.
+// It returns an instance of JournalScheduleRecord or nil if the schedule does not exist.
+func (repo *sqlDBRepository) GetJournalSchedule(ctx context.Context, scheduleID string) (*JournalScheduleRecord, error) {
+	lLog := sqlLog.WithField("function", "GetJournalSchedule")
+	row := repo.conn().QueryRowxContext(ctx, "SELECT "+journalScheduleColumns+" FROM journal_schedules WHERE schedule_id=?", scheduleID)
+	if row.Err() != nil {
+		if row.Err() == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while retrieving journal schedule. got %s", row.Err().Error())
+		return nil, row.Err()
+	}
+	sr, err := scanJournalSchedule(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		lLog.Errorf("error while scanning journal schedule record. got %s", err.Error())
+		return nil, err
+	}
+	return sr, nil
+}
+
+// ListJournalSchedules will list all the journal schedules.
+// Throws error if the underlying database connection has problem.
+// It returns list of JournalScheduleRecord sorted by creation time
+func (repo *sqlDBRepository) ListJournalSchedules(ctx context.Context) ([]*JournalScheduleRecord, error) {
+	return repo.listJournalSchedules(ctx, "SELECT "+journalScheduleColumns+" FROM journal_schedules ORDER BY created_at ASC, schedule_id ASC")
+}
+
+// DeleteJournalSchedule permanently delete a journal schedule.
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) DeleteJournalSchedule(ctx context.Context, scheduleID string) error {
+	lLog := sqlLog.WithField("function", "DeleteJournalSchedule")
+	_, err := repo.conn().ExecContext(ctx, "DELETE FROM journal_schedules WHERE schedule_id=?", scheduleID)
+	if err != nil {
+		lLog.Errorf("error while deleting journal schedule. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// CountJournalSchedulesByTemplate will count the journal schedules posting the template.
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) CountJournalSchedulesByTemplate(ctx context.Context, templateName string) (int, error) {
+	lLog := sqlLog.WithField("function", "CountJournalSchedulesByTemplate")
+	row := repo.conn().QueryRowxContext(ctx, "SELECT COUNT(*) FROM journal_schedules WHERE template_name=?", templateName)
+	if row.Err() != nil {
+		lLog.Errorf("error while counting journal schedules. got %s", row.Err().Error())
+		return 0, row.Err()
+	}
+	count := 0
+	if err := row.Scan(&count); err != nil {
+		lLog.Errorf("error while scanning journal schedule count. got %s", err.Error())
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListDueJournalSchedules will list the journal schedules whose next occurrence is at or before the specified
+// time and that are released or whose lease has expired.
+// Throws error if the underlying database connection has problem.
+// It returns at most limit JournalScheduleRecord sorted by next occurrence
+func (repo *sqlDBRepository) ListDueJournalSchedules(ctx context.Context, at time.Time, limit int) ([]*JournalScheduleRecord, error) {
+	q := "SELECT " + journalScheduleColumns + " FROM journal_schedules WHERE next_run_at <= ? AND (lease_owner='' OR lease_until < ?)" +
+		" ORDER BY next_run_at ASC, schedule_id ASC LIMIT ?"
+	return repo.listJournalSchedules(ctx, q, at, at, limit)
+}
+
+// LeaseJournalSchedule gives the lease of the journal schedule to the owner until the specified time,
+// only if the schedule is due and released or its lease has expired at the time specified by at.
+// Throws error if the underlying database connection has problem.
+// It returns false if the schedule is not due or another owner holds its lease.
+func (repo *sqlDBRepository) LeaseJournalSchedule(ctx context.Context, scheduleID, owner string, at, until time.Time) (bool, error) {
+	lLog := sqlLog.WithField("function", "LeaseJournalSchedule")
+	q := "UPDATE journal_schedules SET lease_owner=?, lease_until=? WHERE schedule_id=? AND next_run_at <= ? AND (lease_owner='' OR lease_until < ?)"
+	res, err := repo.conn().ExecContext(ctx, q, owner, until, scheduleID, at, at)
+	if err != nil {
+		lLog.Errorf("error while leasing journal schedule. got %s", err.Error())
+		return false, err
+	}
+	leased, err := res.RowsAffected()
+	if err != nil {
+		lLog.Errorf("error while counting leased journal schedules. got %s", err.Error())
+		return false, err
+	}
+	return leased > 0, nil
+}
+
+// AdvanceJournalSchedule moves the journal schedule to its next occurrence, only if the owner holds its lease.
+// Throws error if the underlying database connection has problem.
+// It returns false if the owner does not hold the lease.
+func (repo *sqlDBRepository) AdvanceJournalSchedule(ctx context.Context, scheduleID, owner string, nextRunAt time.Time) (bool, error) {
+	lLog := sqlLog.WithField("function", "AdvanceJournalSchedule")
+	q := "UPDATE journal_schedules SET next_run_at=? WHERE schedule_id=? AND lease_owner=?"
+	res, err := repo.conn().ExecContext(ctx, q, nextRunAt, scheduleID, owner)
+	if err != nil {
+		lLog.Errorf("error while advancing journal schedule. got %s", err.Error())
+		return false, err
+	}
+	advanced, err := res.RowsAffected()
+	if err != nil {
+		lLog.Errorf("error while counting advanced journal schedules. got %s", err.Error())
+		return false, err
+	}
+	return advanced > 0, nil
+}
+
+// ReleaseJournalSchedule ends the lease of the owner on the journal schedule at the specified time.
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) ReleaseJournalSchedule(ctx context.Context, scheduleID, owner string, at time.Time) error {
+	lLog := sqlLog.WithField("function", "ReleaseJournalSchedule")
+	q := "UPDATE journal_schedules SET lease_owner='', lease_until=? WHERE schedule_id=? AND lease_owner=?"
+	_, err := repo.conn().ExecContext(ctx, q, at, scheduleID, owner)
+	if err != nil {
+		lLog.Errorf("error while releasing journal schedule. got %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// scheduleRunColumns are the columns of the schedule_runs table, in the order scanScheduleRun reads them.
+const scheduleRunColumns = "run_id, schedule_id, scheduled_at, started_at, finished_at, status, COALESCE(journal_id, ''), COALESCE(error, ''), runner"
+
+// scanScheduleRun reads a schedule run record from a row selecting the scheduleRunColumns.
+func scanScheduleRun(row rowScanner) (*ScheduleRunRecord, error) {
+	rr := &ScheduleRunRecord{}
+	var finishedAt sql.NullTime
+	if err := row.Scan(&rr.RunID, &rr.ScheduleID, &rr.ScheduledAt, &rr.StartedAt, &finishedAt, &rr.Status, &rr.JournalID,
+		&rr.Error, &rr.Runner); err != nil {
+		return nil, err
+	}
+	rr.FinishedAt = finishedAt.Time
+	return rr, nil
+}
+
+// nullableTime returns the time to write into a nullable time column, nil when it is zero.
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// InsertScheduleRun will insert a run of a journal schedule.
+// Throws error if the underlying database connection has problem, or the occurrence already has a run.
+func (repo *sqlDBRepository) InsertScheduleRun(ctx context.Context, rec *ScheduleRunRecord) error {
+	lLog := sqlLog.WithField("function", "InsertScheduleRun")
+	if len(rec.Runner) > 64 {
+		rec.Runner = rec.Runner[:64]
+	}
+	if len(rec.Error) > 255 {
+		rec.Error = rec.Error[:255]
+	}
+	q := "INSERT INTO schedule_runs(run_id, schedule_id, scheduled_at, started_at, finished_at, status, journal_id, error, runner)" +
+		" VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err := repo.conn().ExecContext(ctx, q, rec.RunID, rec.ScheduleID, rec.ScheduledAt, rec.StartedAt, nullableTime(rec.FinishedAt),
+		rec.Status, rec.JournalID, rec.Error, rec.Runner)
+	if err != nil {
+		lLog.Errorf("error while inserting schedule run %s. got %s", rec.RunID, err.Error())
+		return err
+	}
+	return nil
+}
+
+// UpdateScheduleRun writes the status, finish time, journal ID and error of the schedule run.
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) UpdateScheduleRun(ctx context.Context, rec *ScheduleRunRecord) error {
+	lLog := sqlLog.WithField("function", "UpdateScheduleRun")
+	if len(rec.Error) > 255 {
+		rec.Error = rec.Error[:255]
+	}
+	q := "UPDATE schedule_runs SET status=?, finished_at=?, journal_id=?, error=? WHERE run_id=?"
+	_, err := repo.conn().ExecContext(ctx, q, rec.Status, nullableTime(rec.FinishedAt), rec.JournalID, rec.Error, rec.RunID)
+	if err != nil {
+		lLog.Errorf("error while updating schedule run %s. got %s", rec.RunID, err.Error())
+		return err
+	}
+	return nil
+}
+
+// ListScheduleRuns will list the runs of the journal schedule in paginated fashion.
+// Throws error if the underlying database connection has problem.
+// It returns list of ScheduleRunRecord sorted by occurrence, latest first
+func (repo *sqlDBRepository) ListScheduleRuns(ctx context.Context, scheduleID string, offset, length int) ([]*ScheduleRunRecord, error) {
+	lLog := sqlLog.WithField("function", "ListScheduleRuns")
+	q := "SELECT " + scheduleRunColumns + " FROM schedule_runs WHERE schedule_id=? ORDER BY scheduled_at DESC LIMIT ? OFFSET ?"
+	rows, err := repo.conn().QueryxContext(ctx, q, scheduleID, length, offset)
+	if err != nil {
+		lLog.Errorf("error while listing schedule runs. got %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ret := make([]*ScheduleRunRecord, 0)
+	for rows.Next() {
+		rr, err := scanScheduleRun(rows)
+		if err != nil {
+			lLog.Errorf("error while scanning schedule runs. got %s", err.Error())
+			return nil, err
+		}
+		ret = append(ret, rr)
+	}
+	return ret, rows.Err()
+}
+
+// CountScheduleRuns will count the runs of the journal schedule.
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) CountScheduleRuns(ctx context.Context, scheduleID string) (int, error) {
+	lLog := sqlLog.WithField("function", "CountScheduleRuns")
+	row := repo.conn().QueryRowxContext(ctx, "SELECT COUNT(*) FROM schedule_runs WHERE schedule_id=?", scheduleID)
+	if row.Err() != nil {
+		lLog.Errorf("error while counting schedule runs. got %s", row.Err().Error())
+		return 0, row.Err()
+	}
+	count := 0
+	if err := row.Scan(&count); err != nil {
+		lLog.Errorf("error while scanning schedule run count. got %s", err.Error())
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteScheduleRuns permanently delete the runs of the journal schedule.
+// Throws error if the underlying database connection has problem.
+func (repo *sqlDBRepository) DeleteScheduleRuns(ctx context.Context, scheduleID string) error {
+	lLog := sqlLog.WithField("function", "DeleteScheduleRuns")
+	_, err := repo.conn().ExecContext(ctx, "DELETE FROM schedule_runs WHERE schedule_id=?", scheduleID)
+	if err != nil {
+		lLog.Errorf("error while deleting schedule runs. got %s", err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	hwerrors "github.com/hyperjumptech/hyperwallet/errors"
+	"github.com/hyperjumptech/hyperwallet/internal/config"
+	"github.com/hyperjumptech/hyperwallet/internal/contextkeys"
+	"github.com/hyperjumptech/hyperwallet/migrations"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errRollback = errors.New("rollback please")
+
+	// testDatabasePorts are the ports the test database of each driver listens on
+	testDatabasePorts = map[string]string{"mysql": "6603", "postgres": "6604"}
+
+	// testDatabaseNames are the test database of each driver, SQLite tests need no database server
+	testDatabaseNames = map[string]string{"mysql": "devdb", "postgres": "devdb", "sqlite": ":memory:"}
+)
+
+// connectTestRepository connects to the test database, migrates it to the latest schema and clears all of its tables.
+// The database is MySQL unless the DB_DRIVER environment variable selects another one of Drivers.
+func connectTestRepository(ctx context.Context, t *testing.T) DBRepository {
+	config.GetInt("")
+	driver := config.Get("db.driver")
+	config.Set("db.host", "localhost")
+	config.Set("db.port", testDatabasePorts[driver])
+	config.Set("db.user", "devuser")
+	config.Set("db.password", "devuser")
+	config.Set("db.name", testDatabaseNames[driver])
+
+	repo, err := NewDBRepository(driver)
+	if err != nil {
+		t.Fatalf("cannot create repository. got %s", err.Error())
+	}
+	if err = repo.Connect(ctx); err != nil {
+		t.Fatalf("cannot connect to db. got %s", err.Error())
+	}
+	all, err := migrations.ForDriver(driver)
+	if err != nil {
+		t.Fatalf("cannot load migrations. got %s", err.Error())
+	}
+	if _, err = migrations.NewMigrator(repo.DB(), all).Up(ctx, 0); err != nil {
+		t.Fatalf("cannot migrate db. got %s", err.Error())
+	}
+	if err = repo.ClearTables(ctx); err != nil {
+		t.Fatalf("cannot clear tables. got %s", err.Error())
+	}
+	return repo
+}
+
+func testCurrency(code string) *CurrenciesRecord {
+	return &CurrenciesRecord{
+		Code:      code,
+		Name:      code + " currency",
+		Exchange:  "1",
+		CreatedAt: time.Now(),
+		CreatedBy: "TESTING",
+		UpdatedAt: time.Now(),
+		UpdatedBy: "TESTING",
+	}
+}
+
+func TestSQLDBRepository_ExecuteInTransaction(t *testing.T) {
+	if testing.Short() {
+		t.Skip("database transactions require a database")
+	}
+	ctx := context.WithValue(context.Background(), contextkeys.XRequestID, "1234567890")
+	ctx = context.WithValue(ctx, contextkeys.UserIDContextKey, "TESTING")
+	repo := connectTestRepository(ctx, t)
+	defer repo.Disconnect()
+
+	t.Run("commits when fn succeeds", func(t *testing.T) {
+		err := repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo DBRepository) error {
+			_, err := txRepo.InsertCurrency(ctx, testCurrency("COMMIT"))
+			return err
+		})
+		assert.NoError(t, err)
+		cur, err := repo.GetCurrency(ctx, "COMMIT")
+		assert.NoError(t, err)
+		assert.NotNil(t, cur)
+	})
+
+	t.Run("rolls back when fn fails", func(t *testing.T) {
+		err := repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo DBRepository) error {
+			if _, err := txRepo.InsertCurrency(ctx, testCurrency("FAILED")); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		assert.ErrorIs(t, err, errRollback)
+		cur, err := repo.GetCurrency(ctx, "FAILED")
+		assert.NoError(t, err)
+		assert.Nil(t, cur)
+	})
+
+	t.Run("rolls back and re-panics when fn panics", func(t *testing.T) {
+		assert.PanicsWithValue(t, "boom", func() {
+			_ = repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo DBRepository) error {
+				if _, err := txRepo.InsertCurrency(ctx, testCurrency("PANIC")); err != nil {
+					return err
+				}
+				panic("boom")
+			})
+		})
+		cur, err := repo.GetCurrency(ctx, "PANIC")
+		assert.NoError(t, err)
+		assert.Nil(t, cur)
+	})
+
+	t.Run("nested call joins the surrounding transaction", func(t *testing.T) {
+		err := repo.ExecuteInTransaction(ctx, func(ctx context.Context, txRepo DBRepository) error {
+			err := txRepo.ExecuteInTransaction(ctx, func(ctx context.Context, innerRepo DBRepository) error {
+				assert.Same(t, txRepo, innerRepo)
+				_, err := innerRepo.InsertCurrency(ctx, testCurrency("JOINED"))
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			// the inner call must not have committed on its own, its insert is only visible inside the transaction.
+			cur, err := txRepo.GetCurrency(ctx, "JOINED")
+			assert.NoError(t, err)
+			assert.NotNil(t, cur)
+			// a database with a single connection can not be read outside of the transaction holding it.
+			if repo.DB().Stats().MaxOpenConnections != 1 {
+				outside, err := repo.GetCurrency(ctx, "JOINED")
+				assert.NoError(t, err)
+				assert.Nil(t, outside)
+			}
+			return errRollback
+		})
+		assert.ErrorIs(t, err, errRollback)
+		cur, err := repo.GetCurrency(ctx, "JOINED")
+		assert.NoError(t, err)
+		assert.Nil(t, cur)
+	})
+	t.Run("locking read outside a transaction is refused", func(t *testing.T) {
+		_, err := repo.GetAccountForUpdate(ctx, "NOACCOUNT")
+		assert.ErrorIs(t, err, hwerrors.ErrNotInTransaction)
+	})
+}
+
+func TestNewDBRepository(t *testing.T) {
+	for _, driver := range Drivers {
+		repo, err := NewDBRepository(driver)
+		assert.NoError(t, err)
+		assert.NotNil(t, repo)
+	}
+	_, err := NewDBRepository("oracle")
+	assert.Error(t, err)
+}
+
+func TestDialect_IsDuplicateKeyError(t *testing.T) {
+	assert.True(t, mysqlDialect.isDuplicateKeyError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}))
+	assert.False(t, mysqlDialect.isDuplicateKeyError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"}))
+	assert.True(t, postgresDialect.isDuplicateKeyError(&pq.Error{Code: "23505"}))
+	assert.False(t, postgresDialect.isDuplicateKeyError(&pq.Error{Code: "40P01"}))
+	assert.False(t, postgresDialect.isDuplicateKeyError(errRollback))
+}
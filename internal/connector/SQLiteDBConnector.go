@@ -0,0 +1,44 @@
+//go:build cgo
+// +build cgo
+
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hyperjumptech/hyperwallet/internal/config"
+	"github.com/mattn/go-sqlite3"
+)
+
+var sqliteDialect = &dialect{
+	driverName: "sqlite3",
+	dataSourceName: func() string {
+		// transactions take the write lock when they begin, so two of them never wait on each other's lock upgrade.
+		return fmt.Sprintf("file:%s?_busy_timeout=5000&_txlock=immediate", config.Get("db.name"))
+	},
+	versionQuery: "SELECT sqlite_version()",
+	// SQLite has no row locks, a transaction holds the write lock of the whole database.
+	lockForUpdate: "",
+	// a :memory: database lives in a single connection, and SQLite allows one writer at a time anyway.
+	maxOpenConns: 1,
+	isDuplicateKeyError: func(err error) bool {
+		var sqliteErr sqlite3.Error
+		return errors.As(err, &sqliteErr) &&
+			(sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey || sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique)
+	},
+}
+
+// SQLiteDBRepository is implementation of DBRepository specified for SQLite database.
+// The db.name configuration is the database file, or :memory: for a database that only lives as long as the connection.
+// It is meant for local development and tests, there is no database server to run.
+type SQLiteDBRepository struct {
+	sqlDBRepository
+}
+
+// Connect connect the repository to the database, it uses the configuration internally for connection arguments and parameters.
+func (repo *SQLiteDBRepository) Connect(ctx context.Context) error {
+	repo.dialect = sqliteDialect
+	return repo.sqlDBRepository.Connect(ctx)
+}
@@ -0,0 +1,20 @@
+//go:build !cgo
+// +build !cgo
+
+package connector
+
+import (
+	"context"
+	"errors"
+)
+
+// SQLiteDBRepository is implementation of DBRepository specified for SQLite database.
+// The SQLite driver is built with cgo, this binary is built without cgo so it can not connect to SQLite.
+type SQLiteDBRepository struct {
+	sqlDBRepository
+}
+
+// Connect always fails, the SQLite driver is not part of a binary built without cgo.
+func (repo *SQLiteDBRepository) Connect(ctx context.Context) error {
+	return errors.New("db.driver sqlite needs a binary built with cgo")
+}
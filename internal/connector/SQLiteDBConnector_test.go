@@ -0,0 +1,18 @@
+//go:build cgo
+// +build cgo
+
+package connector
+
+import (
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteDialect_IsDuplicateKeyError(t *testing.T) {
+	assert.True(t, sqliteDialect.isDuplicateKeyError(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintPrimaryKey}))
+	assert.True(t, sqliteDialect.isDuplicateKeyError(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique}))
+	assert.False(t, sqliteDialect.isDuplicateKeyError(sqlite3.Error{Code: sqlite3.ErrBusy}))
+	assert.False(t, sqliteDialect.isDuplicateKeyError(errRollback))
+}
@@ -20,7 +20,7 @@ var (
 )
 
 // InitializeHealthCheck initializes health monitors
-func InitializeHealthCheck(ctx context.Context, repo *connector.MySQLDBRepository) error {
+func InitializeHealthCheck(ctx context.Context, repo connector.DBRepository) error {
 	logf := healthLog.WithField("fn", "InitializeHealthCheck")
 
 	if ctx.Err() != nil {
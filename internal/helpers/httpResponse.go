@@ -2,6 +2,7 @@
 package helpers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -45,10 +46,9 @@ type ResponseJSON struct {
 	ErrorCode int         `json:"error_code,omitempty"`
 }
 
-// HTTPResponseBuilder builds the response headers and payloads
-func HTTPResponseBuilder(ctx context.Context, w http.ResponseWriter, r *http.Request, httpStatus int, message string, data interface{}, errorCode int) {
-
-	resp := ResponseJSON{
+// NewResponseJSON builds the response payload sent along with the specified http status
+func NewResponseJSON(httpStatus int, message string, data interface{}, errorCode int) *ResponseJSON {
+	resp := &ResponseJSON{
 		Data:    data,
 		Message: message,
 	}
@@ -59,8 +59,29 @@ func HTTPResponseBuilder(ctx context.Context, w http.ResponseWriter, r *http.Req
 		resp.Status = "FAIL"
 		resp.ErrorCode = errorCode
 	}
+	return resp
+}
+
+// HTTPResponseBuilder builds the response headers and payloads
+func HTTPResponseBuilder(ctx context.Context, w http.ResponseWriter, r *http.Request, httpStatus int, message string, data interface{}, errorCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	json.NewEncoder(w).Encode(NewResponseJSON(httpStatus, message, data, errorCode))
+}
+
+// EncodeResponseJSON encodes the response payload exactly the way HTTPResponseBuilder writes it
+func EncodeResponseJSON(httpStatus int, message string, data interface{}, errorCode int) ([]byte, error) {
+	buff := &bytes.Buffer{}
+	err := json.NewEncoder(buff).Encode(NewResponseJSON(httpStatus, message, data, errorCode))
+	if err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
 
+// HTTPRawResponseBuilder writes an already encoded json payload, such as a stored response being replayed
+func HTTPRawResponseBuilder(ctx context.Context, w http.ResponseWriter, r *http.Request, httpStatus int, body []byte) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(httpStatus)
-	json.NewEncoder(w).Encode(resp)
+	w.Write(body)
 }
@@ -42,21 +42,63 @@ func InitRoutes(router *Router) {
 	r.HandleFunc("/devkey", middlewares.DevKey).Methods("PUT", "OPTIONS")
 
 	r.HandleFunc("/api/v1/accounts/{AccountNumber}", accounting.GetAccount).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/accounts/{AccountNumber}", accounting.UpdateAccount).Methods("PATCH", "OPTIONS")
+	r.HandleFunc("/api/v1/accounts/{AccountNumber}/status", accounting.SetAccountStatus).Methods("PUT", "OPTIONS")
 	r.HandleFunc("/api/v1/accounts/{accountNumber}/draw", accounting.DrawAccount).Methods("GET", "OPTIONS")
 	r.HandleFunc("/api/v1/accounts/{AccountNumber}/transactions", accounting.ListTransactionByAccount).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/accounts/{AccountNumber}/holds", accounting.PlaceHold).Methods("POST", "OPTIONS")
 	r.HandleFunc("/api/v1/accounts", accounting.FindAccount).Methods("GET", "OPTIONS")
 	r.HandleFunc("/api/v1/accounts", accounting.CreateAccount).Methods("POST", "OPTIONS")
 
 	r.HandleFunc("/api/v1/journals", accounting.CreateJournal).Methods("POST", "OPTIONS")
 	r.HandleFunc("/api/v1/journals", accounting.ListJournal).Methods("GET", "OPTIONS")
 	r.HandleFunc("/api/v1/journals/reversal", accounting.CreateReversalJournal).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/v1/journals/batch", accounting.CreateJournalBatch).Methods("POST", "OPTIONS")
 	r.HandleFunc("/api/v1/journals/{JournalID}", accounting.GetJournal).Methods("GET", "OPTIONS")
 	r.HandleFunc("/api/v1/journals/{JournalID}/draw", accounting.DrawJournal).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/journals/{JournalID}/reversal", accounting.GetJournalReversal).Methods("GET", "OPTIONS")
 
 	r.HandleFunc("/api/v1/transactions/{TransactionID}", accounting.GetTransaction).Methods("GET", "OPTIONS")
 
+	r.HandleFunc("/api/v1/transfers", accounting.CreateTransfer).Methods("POST", "OPTIONS")
+
+	r.HandleFunc("/api/v1/templates", accounting.ListTemplates).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/templates", accounting.CreateTemplate).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/v1/templates/{TemplateName}", accounting.GetTemplate).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/templates/{TemplateName}", accounting.DeleteTemplate).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/api/v1/templates/{TemplateName}/execute", accounting.ExecuteTemplate).Methods("POST", "OPTIONS")
+
+	r.HandleFunc("/api/v1/schedules", accounting.ListSchedules).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/schedules", accounting.CreateSchedule).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/v1/schedules/{ScheduleID}", accounting.GetSchedule).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/schedules/{ScheduleID}", accounting.DeleteSchedule).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/api/v1/schedules/{ScheduleID}/runs", accounting.ListScheduleRuns).Methods("GET", "OPTIONS")
+
+	r.HandleFunc("/api/v1/holds/{HoldID}", accounting.GetHold).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/holds/{HoldID}/capture", accounting.CaptureHold).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/v1/holds/{HoldID}/release", accounting.ReleaseHold).Methods("POST", "OPTIONS")
+
+	r.HandleFunc("/api/v1/coa", accounting.ListCOA).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/coa", accounting.CreateCOA).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/v1/coa/{COACode}", accounting.GetCOA).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/coa/{COACode}", accounting.UpdateCOA).Methods("PATCH", "OPTIONS")
+	r.HandleFunc("/api/v1/coa/{COACode}", accounting.DeleteCOA).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/api/v1/coa/{COACode}/balance", accounting.GetCOABalance).Methods("GET", "OPTIONS")
+
+	r.HandleFunc("/api/v1/reports/trial-balance", accounting.GetTrialBalance).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/reports/balance-sheet", accounting.GetBalanceSheet).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/reports/income-statement", accounting.GetIncomeStatement).Methods("GET", "OPTIONS")
+
+	r.HandleFunc("/api/v1/periods", accounting.ListPeriods).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/periods/{Period}", accounting.GetPeriod).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/periods/{Period}/close", accounting.ClosePeriod).Methods("POST", "OPTIONS")
+
+	r.HandleFunc("/api/v1/admin/integrity", accounting.GetIntegrity).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/v1/admin/journal-chain", accounting.GetJournalChain).Methods("GET", "OPTIONS")
+
 	r.HandleFunc("/api/v1/exchange/denom", accounting.GetCommonDenominator).Methods("GET", "OPTIONS")
 	r.HandleFunc("/api/v1/exchange/denom", accounting.SetCommonDenominator).Methods("PUT", "OPTIONS")
+	r.HandleFunc("/api/v1/exchange/denom/history", accounting.ListCommonDenominatorHistory).Methods("GET", "OPTIONS")
 
 	r.HandleFunc("/api/v1/currencies", accounting.ListCurrencies).Methods("GET", "OPTIONS")
 	r.HandleFunc("/api/v1/currencies/{code}", accounting.GetCurrency).Methods("GET", "OPTIONS")
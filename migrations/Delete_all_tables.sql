@@ -3,4 +3,12 @@ use wallet;
 DELETE FROM accounts;
 DELETE FROM currencies;
 DELETE FROM journals;
-DELETE FROM transactions;
\ No newline at end of file
+DELETE FROM transactions;
+DELETE FROM idempotency_keys;
+DELETE FROM settings;
+DELETE FROM setting_history;
+DELETE FROM currency_rates;
+DELETE FROM account_holds;
+DELETE FROM chart_of_accounts;
+UPDATE journal_chain SET last_sequence = 0, last_hash = '';
+DELETE FROM accounting_periods;
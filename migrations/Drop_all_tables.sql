@@ -1,6 +0,0 @@
-use wallet;
-
-DROP TABLE accounts;
-DROP TABLE currencies;
-DROP TABLE journals;
-DROP TABLE transactions;
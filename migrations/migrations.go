@@ -0,0 +1,243 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// The migration scripts are the only definition of the database schema, there is one directory per db.driver.
+// Each script is named <version>_<name>.up.sql or <version>_<name>.down.sql, the down script undoes its up script.
+// A version makes the same change in every directory.
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var scripts embed.FS
+
+var (
+	migrationLog = logrus.WithField("file", "migrations.go")
+
+	scriptNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+)
+
+const createSchemaMigrations = "CREATE TABLE IF NOT EXISTS schema_migrations (" +
+	"version BIGINT NOT NULL, " +
+	"name VARCHAR(128) NOT NULL, " +
+	"applied_at TIMESTAMP NOT NULL, " +
+	"PRIMARY KEY (version))"
+
+// Migration is one versioned change of the database schema.
+type Migration struct {
+	// Version orders the migrations, they are applied in ascending and rolled back in descending version.
+	Version int64
+	// Name describes the change
+	Name string
+	// Up is the script applying the change
+	Up string
+	// Down is the script rolling the change back
+	Down string
+}
+
+// ForDriver returns the embedded migrations of the db.driver's schema, ordered by version.
+func ForDriver(driver string) ([]*Migration, error) {
+	driver = strings.ToLower(driver)
+	if _, err := fs.Stat(scripts, driver); err != nil {
+		return nil, fmt.Errorf("there are no migrations for db.driver %s", driver)
+	}
+	return Load(scripts, driver)
+}
+
+// Load reads the migration scripts in the dir directory of fsys, ordered by version.
+// Throws error if a script name is not recognized, or a version is missing either its up or its down script.
+func Load(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := scriptNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration script %s is not named <version>_<name>.<up|down>.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration script %s has invalid version. got %w", entry.Name(), err)
+		}
+		script, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	ret := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if len(migration.Up) == 0 || len(migration.Down) == 0 {
+			return nil, fmt.Errorf("migration %d_%s should have both an up and a down script", migration.Version, migration.Name)
+		}
+		ret = append(ret, migration)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Version < ret[j].Version
+	})
+	return ret, nil
+}
+
+// Statements splits a script into its statements. Statements end with a semicolon at the end of a line,
+// lines starting with -- are comments.
+func Statements(script string) []string {
+	ret := make([]string, 0)
+	current := make([]string, 0)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) == 0 || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current = append(current, line)
+		if strings.HasSuffix(trimmed, ";") {
+			statement := strings.TrimSpace(strings.Join(current, "\n"))
+			ret = append(ret, strings.TrimSuffix(statement, ";"))
+			current = current[:0]
+		}
+	}
+	if len(current) > 0 {
+		ret = append(ret, strings.TrimSpace(strings.Join(current, "\n")))
+	}
+	return ret
+}
+
+// NewMigrator returns a migrator applying the migrations to the database.
+func NewMigrator(db *sqlx.DB, migrations []*Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Migrator applies and rolls back migrations, keeping the applied versions in the schema_migrations table.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []*Migration
+}
+
+// Applied returns the versions already applied to the database, in ascending order.
+func (m *Migrator) Applied(ctx context.Context) ([]int64, error) {
+	lLog := migrationLog.WithField("function", "Applied")
+	_, err := m.db.ExecContext(ctx, createSchemaMigrations)
+	if err != nil {
+		lLog.Errorf("error while creating schema_migrations table. got %s", err.Error())
+		return nil, err
+	}
+	versions := make([]int64, 0)
+	err = m.db.SelectContext(ctx, &versions, "SELECT version FROM schema_migrations ORDER BY version ASC")
+	if err != nil {
+		lLog.Errorf("error while listing applied migrations. got %s", err.Error())
+		return nil, err
+	}
+	return versions, nil
+}
+
+// Up applies the pending migrations in ascending version, at most steps of them, or all of them if steps is not positive.
+// It returns the migrations applied.
+func (m *Migrator) Up(ctx context.Context, steps int) ([]*Migration, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isApplied := make(map[int64]bool)
+	for _, version := range applied {
+		isApplied[version] = true
+	}
+	ret := make([]*Migration, 0)
+	for _, migration := range m.migrations {
+		if steps > 0 && len(ret) == steps {
+			break
+		}
+		if isApplied[migration.Version] {
+			continue
+		}
+		err = m.run(ctx, migration, migration.Up, "INSERT INTO schema_migrations(version, name, applied_at) VALUES (?, ?, ?)",
+			migration.Version, migration.Name, time.Now())
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, migration)
+	}
+	return ret, nil
+}
+
+// Down rolls back the applied migrations in descending version, at most steps of them, or all of them if steps is not positive.
+// It returns the migrations rolled back.
+// Throws error if an applied version has no migration, which happens when the binary is older than the database.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+	ret := make([]*Migration, 0)
+	for i := len(applied) - 1; i >= 0; i-- {
+		if steps > 0 && len(ret) == steps {
+			break
+		}
+		migration, ok := byVersion[applied[i]]
+		if !ok {
+			return ret, fmt.Errorf("applied migration version %d is unknown to this binary", applied[i])
+		}
+		err = m.run(ctx, migration, migration.Down, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, migration)
+	}
+	return ret, nil
+}
+
+// run executes the statements of the script then records the outcome in schema_migrations.
+// MySQL commits every schema change implicitly, so a failing script may be left half applied;
+// the error names the statement so it can be fixed by hand.
+func (m *Migrator) run(ctx context.Context, migration *Migration, script, record string, args ...interface{}) error {
+	lLog := migrationLog.WithField("function", "run")
+	for _, statement := range Statements(script) {
+		_, err := m.db.ExecContext(ctx, statement)
+		if err != nil {
+			lLog.Errorf("error while running migration %d_%s. got %s", migration.Version, migration.Name, err.Error())
+			return fmt.Errorf("migration %d_%s failed on statement %q. got %w", migration.Version, migration.Name, statement, err)
+		}
+	}
+	result, err := m.db.ExecContext(ctx, m.db.Rebind(record), args...)
+	if err != nil {
+		lLog.Errorf("error while recording migration %d_%s. got %s", migration.Version, migration.Name, err.Error())
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected != 1 {
+		return fmt.Errorf("migration %d_%s was recorded %d times", migration.Version, migration.Name, affected)
+	}
+	migrationLog.Infof("migration %d_%s done", migration.Version, migration.Name)
+	return nil
+}
@@ -0,0 +1,150 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hyperjumptech/hyperwallet/internal/config"
+	"github.com/hyperjumptech/hyperwallet/internal/connector"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	// testDatabasePorts are the ports the test database of each driver listens on
+	testDatabasePorts = map[string]string{"mysql": "6603", "postgres": "6604"}
+
+	// testDatabaseNames are the test database of each driver, SQLite tests need no database server
+	testDatabaseNames = map[string]string{"mysql": "devdb", "postgres": "devdb", "sqlite": ":memory:"}
+
+	// balanceTypeQueries select the data type of the accounts.balance column
+	balanceTypeQueries = map[string]string{
+		"mysql": "SELECT LOWER(data_type) FROM information_schema.columns " +
+			"WHERE table_schema = DATABASE() AND table_name = 'accounts' AND column_name = 'balance'",
+		"postgres": "SELECT LOWER(data_type) FROM information_schema.columns " +
+			"WHERE table_schema = current_schema() AND table_name = 'accounts' AND column_name = 'balance'",
+		"sqlite": "SELECT LOWER(type) FROM pragma_table_info('accounts') WHERE name = 'balance'",
+	}
+
+	// widenedBalanceTypes are the data type of the accounts.balance column once the monetary columns are widened
+	widenedBalanceTypes = map[string]string{"mysql": "bigint", "postgres": "bigint", "sqlite": "integer"}
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("orders migrations by version", func(t *testing.T) {
+		migrations, err := Load(fstest.MapFS{
+			"db/0010_second.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+			"db/0010_second.down.sql": {Data: []byte("DROP TABLE b;")},
+			"db/0002_first.up.sql":    {Data: []byte("CREATE TABLE a (id INT);")},
+			"db/0002_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+		}, "db")
+		assert.NoError(t, err)
+		if assert.Len(t, migrations, 2) {
+			assert.Equal(t, int64(2), migrations[0].Version)
+			assert.Equal(t, "first", migrations[0].Name)
+			assert.Equal(t, "DROP TABLE a;", migrations[0].Down)
+			assert.Equal(t, int64(10), migrations[1].Version)
+		}
+	})
+	t.Run("refuses a migration without down script", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{"db/0001_first.up.sql": {Data: []byte("CREATE TABLE a (id INT);")}}, "db")
+		assert.Error(t, err)
+	})
+	t.Run("refuses an unrecognized script name", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{"db/first.sql": {Data: []byte("CREATE TABLE a (id INT);")}}, "db")
+		assert.Error(t, err)
+	})
+	t.Run("refuses two migrations with the same version", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{
+			"db/0001_first.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+			"db/0001_first.down.sql": {Data: []byte("DROP TABLE a;")},
+			"db/0001_other.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+		}, "db")
+		assert.Error(t, err)
+	})
+}
+
+func TestStatements(t *testing.T) {
+	statements := Statements("-- a comment;\nCREATE TABLE a (\n  id INT\n);\n\nALTER TABLE a ADD name TEXT;\nDROP TABLE b")
+	assert.Equal(t, []string{"CREATE TABLE a (\n  id INT\n)", "ALTER TABLE a ADD name TEXT", "DROP TABLE b"}, statements)
+}
+
+func TestForDriver(t *testing.T) {
+	var names []string
+	for _, driver := range connector.Drivers {
+		migrations, err := ForDriver(driver)
+		assert.NoError(t, err)
+		driverNames := make([]string, 0, len(migrations))
+		for i, migration := range migrations {
+			assert.Equal(t, int64(i+1), migration.Version, "versions should have no gap")
+			assert.NotEmpty(t, Statements(migration.Up))
+			assert.NotEmpty(t, Statements(migration.Down))
+			driverNames = append(driverNames, migration.Name)
+		}
+		// a version makes the same change for every driver
+		if names == nil {
+			names = driverNames
+		}
+		assert.Equal(t, names, driverNames, driver)
+	}
+	_, err := ForDriver("oracle")
+	assert.Error(t, err)
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	if testing.Short() {
+		t.Skip("running migrations requires a database")
+	}
+	ctx := context.Background()
+	config.GetInt("")
+	driver := config.Get("db.driver")
+	config.Set("db.host", "localhost")
+	config.Set("db.port", testDatabasePorts[driver])
+	config.Set("db.user", "devuser")
+	config.Set("db.password", "devuser")
+	config.Set("db.name", testDatabaseNames[driver])
+	repo, err := connector.NewDBRepository(driver)
+	if err != nil {
+		t.Fatalf("cannot create repository. got %s", err.Error())
+	}
+	if err = repo.Connect(ctx); err != nil {
+		t.Fatalf("cannot connect to db. got %s", err.Error())
+	}
+	defer repo.Disconnect()
+
+	migrations, err := ForDriver(driver)
+	assert.NoError(t, err)
+	migrator := NewMigrator(repo.DB(), migrations)
+	columnType := func() string {
+		var dataType string
+		err := repo.DB().GetContext(ctx, &dataType, balanceTypeQueries[driver])
+		assert.NoError(t, err)
+		return dataType
+	}
+
+	_, err = migrator.Up(ctx, 0)
+	assert.NoError(t, err)
+	applied, err := migrator.Applied(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+	assert.Equal(t, widenedBalanceTypes[driver], columnType())
+
+	// applying again is a no-op
+	done, err := migrator.Up(ctx, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, done)
+
+	done, err = migrator.Down(ctx, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, done, 1) {
+		assert.Equal(t, migrations[len(migrations)-1].Version, done[0].Version)
+	}
+	applied, err = migrator.Applied(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrations)-1)
+
+	done, err = migrator.Up(ctx, 0)
+	assert.NoError(t, err)
+	assert.Len(t, done, 1)
+	assert.Equal(t, widenedBalanceTypes[driver], columnType())
+}
@@ -1,5 +1,5 @@
-use wallet;
-
+-- The schema as it was before migrations were introduced. Every statement is guarded with IF NOT EXISTS,
+-- so a database created before then is adopted as it is and only receives the later migrations.
 CREATE TABLE IF NOT EXISTS accounts (
   `account_number` VARCHAR(20) NOT NULL,
   `name` VARCHAR(128) NOT NULL,
@@ -62,4 +62,12 @@ CREATE TABLE IF NOT EXISTS transactions (
   INDEX(`account_number`, `journal_id`)
 );
 
-
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  `idempotency_key` VARCHAR(64) NOT NULL,
+  `request_hash` VARCHAR(64) NOT NULL,
+  `journal_id` VARCHAR(20) NOT NULL,
+  `response_body` TEXT,
+  `created_at` TIMESTAMP,
+  `created_by` VARCHAR(16),
+  PRIMARY KEY (`idempotency_key`)
+);
@@ -0,0 +1,4 @@
+-- fails if any value no longer fits into INT.
+ALTER TABLE transactions MODIFY `amount` INT NOT NULL, MODIFY `balance` INT NOT NULL;
+ALTER TABLE journals MODIFY `total_amount` INT NOT NULL;
+ALTER TABLE accounts MODIFY `balance` INT NOT NULL;
@@ -0,0 +1,4 @@
+-- INT overflows above 2,147,483,647 minor units, the records already carry these values as int64.
+ALTER TABLE accounts MODIFY `balance` BIGINT NOT NULL;
+ALTER TABLE journals MODIFY `total_amount` BIGINT NOT NULL;
+ALTER TABLE transactions MODIFY `amount` BIGINT NOT NULL, MODIFY `balance` BIGINT NOT NULL;
@@ -0,0 +1,19 @@
+-- Settings shared by every instance of the application, such as the exchange common denominator.
+CREATE TABLE settings (
+  `name` VARCHAR(64) NOT NULL,
+  `value` VARCHAR(255) NOT NULL,
+  `updated_at` TIMESTAMP,
+  `updated_by` VARCHAR(16),
+  PRIMARY KEY (`name`)
+);
+
+-- Every value a setting is given, who gave it and when.
+CREATE TABLE setting_history (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(64) NOT NULL,
+  `value` VARCHAR(255) NOT NULL,
+  `created_at` TIMESTAMP,
+  `created_by` VARCHAR(16),
+  PRIMARY KEY (`id`),
+  INDEX(`name`, `created_at`)
+);
@@ -0,0 +1 @@
+ALTER TABLE currencies MODIFY `exchange` FLOAT NOT NULL;
@@ -0,0 +1,4 @@
+-- Exchange units are exact decimals. Going through VARCHAR turns each FLOAT into its shortest decimal form,
+-- so a rate entered as 0.123 becomes 0.123 and not the 0.1230000033974647 the FLOAT actually holds.
+ALTER TABLE currencies MODIFY `exchange` VARCHAR(64) NOT NULL;
+ALTER TABLE currencies MODIFY `exchange` DECIMAL(36,18) NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE accounts DROP COLUMN `max_balance`;
+ALTER TABLE accounts DROP COLUMN `min_balance`;
+ALTER TABLE accounts DROP COLUMN `allow_negative`;
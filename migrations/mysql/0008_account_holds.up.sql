@@ -0,0 +1,18 @@
+-- Funds reserved on an account until they are captured into a journal, released or expired.
+CREATE TABLE account_holds (
+  `hold_id` VARCHAR(20) NOT NULL,
+  `account_number` VARCHAR(20) NOT NULL,
+  `amount` BIGINT NOT NULL,
+  `description` TEXT,
+  `status` VARCHAR(8) NOT NULL,
+  `expires_at` TIMESTAMP NOT NULL,
+  `captured_amount` BIGINT NOT NULL DEFAULT 0,
+  `journal_id` VARCHAR(20),
+  `created_at` TIMESTAMP,
+  `created_by` VARCHAR(16),
+  `updated_at` TIMESTAMP,
+  `updated_by` VARCHAR(16),
+  PRIMARY KEY (`hold_id`),
+  INDEX(`account_number`, `status`),
+  INDEX(`status`, `expires_at`)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS journal_chain;
+DROP INDEX journals_chain_sequence ON journals;
+ALTER TABLE journals DROP COLUMN `chain_hash`, DROP COLUMN `previous_hash`, DROP COLUMN `chain_sequence`;
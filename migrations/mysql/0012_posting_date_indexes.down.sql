@@ -0,0 +1,4 @@
+DROP INDEX transactions_account_created_at ON transactions;
+DROP INDEX transactions_account_time ON transactions;
+DROP INDEX journals_created_at ON journals;
+DROP INDEX journals_journaling_time ON journals;
@@ -0,0 +1,6 @@
+-- Journals and transactions are listed on either their effective date, the journaling and transaction time,
+-- or their booking date, the time they were recorded.
+CREATE INDEX journals_journaling_time ON journals (`journaling_time`);
+CREATE INDEX journals_created_at ON journals (`created_at`);
+CREATE INDEX transactions_account_time ON transactions (`account_number`, `transaction_time`);
+CREATE INDEX transactions_account_created_at ON transactions (`account_number`, `created_at`);
@@ -0,0 +1 @@
+DROP INDEX journals_reversed_journal_id ON journals;
@@ -0,0 +1,2 @@
+-- The reversal journals of a journal are summed up to tell how much of it is left to reverse.
+CREATE INDEX journals_reversed_journal_id ON journals (`reversed_journal_id`);
@@ -0,0 +1,32 @@
+-- Journal schedules post a journal template on the times of their cron expression. A replica posting a schedule
+-- holds its lease until lease_until, so the other replicas leave it alone.
+CREATE TABLE journal_schedules (
+  `schedule_id` VARCHAR(20) NOT NULL,
+  `template_name` VARCHAR(40) NOT NULL,
+  `cron` VARCHAR(100) NOT NULL,
+  `time_zone` VARCHAR(64) NOT NULL,
+  `params` TEXT NOT NULL,
+  `next_run_at` TIMESTAMP NOT NULL,
+  `lease_owner` VARCHAR(64) NOT NULL DEFAULT '',
+  `lease_until` TIMESTAMP NOT NULL,
+  `created_at` TIMESTAMP,
+  `created_by` VARCHAR(16),
+  PRIMARY KEY (`schedule_id`),
+  INDEX(`next_run_at`),
+  INDEX(`template_name`)
+);
+
+-- The runs of the journal schedules, one per occurrence at most.
+CREATE TABLE schedule_runs (
+  `run_id` VARCHAR(20) NOT NULL,
+  `schedule_id` VARCHAR(20) NOT NULL,
+  `scheduled_at` TIMESTAMP NOT NULL,
+  `started_at` TIMESTAMP NOT NULL,
+  `finished_at` TIMESTAMP NULL,
+  `status` VARCHAR(8) NOT NULL,
+  `journal_id` VARCHAR(20),
+  `error` VARCHAR(255),
+  `runner` VARCHAR(64) NOT NULL,
+  PRIMARY KEY (`run_id`),
+  UNIQUE (`schedule_id`, `scheduled_at`)
+);
@@ -0,0 +1,74 @@
+-- The schema as it was before migrations were introduced, kept identical to the MySQL one version by version.
+CREATE TABLE IF NOT EXISTS accounts (
+  account_number VARCHAR(20) NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  currency_code VARCHAR(10) NOT NULL,
+  description TEXT,
+  alignment VARCHAR(6) NOT NULL,
+  balance INTEGER NOT NULL,
+  coa VARCHAR(10),
+  created_at TIMESTAMPTZ,
+  created_by VARCHAR(16),
+  updated_at TIMESTAMPTZ,
+  updated_by VARCHAR(16),
+  is_deleted BOOLEAN DEFAULT false,
+  PRIMARY KEY (account_number)
+);
+
+CREATE INDEX IF NOT EXISTS accounts_coa_name ON accounts (coa, name);
+
+CREATE TABLE IF NOT EXISTS currencies (
+  code VARCHAR(10) NOT NULL,
+  name VARCHAR(30) NOT NULL,
+  exchange DOUBLE PRECISION NOT NULL,
+  created_at TIMESTAMPTZ,
+  created_by VARCHAR(16),
+  updated_at TIMESTAMPTZ,
+  updated_by VARCHAR(16),
+  is_deleted BOOLEAN DEFAULT false,
+  PRIMARY KEY (code)
+);
+
+CREATE TABLE IF NOT EXISTS journals (
+  journal_id VARCHAR(20) NOT NULL,
+  journaling_time TIMESTAMPTZ NOT NULL,
+  description TEXT,
+  is_reversal BOOLEAN,
+  reversed_journal_id VARCHAR(20),
+  total_amount INTEGER NOT NULL,
+  created_at TIMESTAMPTZ,
+  created_by VARCHAR(16),
+  updated_at TIMESTAMPTZ,
+  updated_by VARCHAR(16),
+  is_deleted BOOLEAN DEFAULT false,
+  PRIMARY KEY (journal_id)
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+  transaction_id VARCHAR(20) NOT NULL,
+  account_number VARCHAR(20) NOT NULL,
+  transaction_time TIMESTAMPTZ NOT NULL,
+  journal_id VARCHAR(20) NOT NULL,
+  description TEXT,
+  alignment VARCHAR(6) NOT NULL,
+  amount INTEGER NOT NULL,
+  balance INTEGER NOT NULL,
+  created_at TIMESTAMPTZ,
+  created_by VARCHAR(16),
+  updated_at TIMESTAMPTZ,
+  updated_by VARCHAR(16),
+  is_deleted BOOLEAN DEFAULT false,
+  PRIMARY KEY (transaction_id)
+);
+
+CREATE INDEX IF NOT EXISTS transactions_account_journal ON transactions (account_number, journal_id);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  idempotency_key VARCHAR(64) NOT NULL,
+  request_hash VARCHAR(64) NOT NULL,
+  journal_id VARCHAR(20) NOT NULL,
+  response_body TEXT,
+  created_at TIMESTAMPTZ,
+  created_by VARCHAR(16),
+  PRIMARY KEY (idempotency_key)
+);
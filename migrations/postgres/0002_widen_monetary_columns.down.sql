@@ -0,0 +1,4 @@
+-- fails if any value no longer fits into INTEGER.
+ALTER TABLE transactions ALTER COLUMN amount TYPE INTEGER, ALTER COLUMN balance TYPE INTEGER;
+ALTER TABLE journals ALTER COLUMN total_amount TYPE INTEGER;
+ALTER TABLE accounts ALTER COLUMN balance TYPE INTEGER;
@@ -0,0 +1,4 @@
+-- INTEGER overflows above 2,147,483,647 minor units, the records already carry these values as int64.
+ALTER TABLE accounts ALTER COLUMN balance TYPE BIGINT;
+ALTER TABLE journals ALTER COLUMN total_amount TYPE BIGINT;
+ALTER TABLE transactions ALTER COLUMN amount TYPE BIGINT, ALTER COLUMN balance TYPE BIGINT;
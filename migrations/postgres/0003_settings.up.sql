@@ -0,0 +1,20 @@
+-- Settings shared by every instance of the application, such as the exchange common denominator.
+CREATE TABLE settings (
+  name VARCHAR(64) NOT NULL,
+  value VARCHAR(255) NOT NULL,
+  updated_at TIMESTAMPTZ,
+  updated_by VARCHAR(16),
+  PRIMARY KEY (name)
+);
+
+-- Every value a setting is given, who gave it and when.
+CREATE TABLE setting_history (
+  id BIGSERIAL NOT NULL,
+  name VARCHAR(64) NOT NULL,
+  value VARCHAR(255) NOT NULL,
+  created_at TIMESTAMPTZ,
+  created_by VARCHAR(16),
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX setting_history_name_created ON setting_history (name, created_at);
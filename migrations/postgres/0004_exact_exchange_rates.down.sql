@@ -0,0 +1 @@
+ALTER TABLE currencies ALTER COLUMN exchange TYPE DOUBLE PRECISION USING exchange::double precision;
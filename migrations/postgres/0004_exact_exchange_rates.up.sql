@@ -0,0 +1,2 @@
+-- Exchange units are exact decimals. Going through text turns each DOUBLE PRECISION into its shortest decimal form.
+ALTER TABLE currencies ALTER COLUMN exchange TYPE NUMERIC(36,18) USING exchange::text::numeric;
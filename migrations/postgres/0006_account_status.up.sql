@@ -0,0 +1,2 @@
+-- Accounts are ACTIVE, FROZEN or CLOSED. Existing accounts stay ACTIVE.
+ALTER TABLE accounts ADD COLUMN status VARCHAR(6) NOT NULL DEFAULT 'ACTIVE';
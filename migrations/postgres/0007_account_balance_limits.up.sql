@@ -0,0 +1,4 @@
+-- Balance limits of each account. Existing accounts may still go negative and have no minimum nor maximum.
+ALTER TABLE accounts ADD COLUMN allow_negative BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE accounts ADD COLUMN min_balance BIGINT NULL;
+ALTER TABLE accounts ADD COLUMN max_balance BIGINT NULL;
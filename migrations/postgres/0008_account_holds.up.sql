@@ -0,0 +1,19 @@
+-- Funds reserved on an account until they are captured into a journal, released or expired.
+CREATE TABLE account_holds (
+  hold_id VARCHAR(20) NOT NULL,
+  account_number VARCHAR(20) NOT NULL,
+  amount BIGINT NOT NULL,
+  description TEXT,
+  status VARCHAR(8) NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  captured_amount BIGINT NOT NULL DEFAULT 0,
+  journal_id VARCHAR(20),
+  created_at TIMESTAMPTZ,
+  created_by VARCHAR(16),
+  updated_at TIMESTAMPTZ,
+  updated_by VARCHAR(16),
+  PRIMARY KEY (hold_id)
+);
+
+CREATE INDEX account_holds_account_status ON account_holds (account_number, status);
+CREATE INDEX account_holds_status_expires ON account_holds (status, expires_at);
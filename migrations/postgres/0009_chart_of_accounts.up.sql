@@ -0,0 +1,15 @@
+-- The chart of accounts tree, accounts refer to one of its nodes through their coa column.
+CREATE TABLE chart_of_accounts (
+  code VARCHAR(10) NOT NULL,
+  parent_code VARCHAR(10),
+  name VARCHAR(128) NOT NULL,
+  coa_type VARCHAR(10) NOT NULL,
+  alignment VARCHAR(6) NOT NULL,
+  created_at TIMESTAMPTZ,
+  created_by VARCHAR(16),
+  updated_at TIMESTAMPTZ,
+  updated_by VARCHAR(16),
+  PRIMARY KEY (code)
+);
+
+CREATE INDEX chart_of_accounts_parent ON chart_of_accounts (parent_code);
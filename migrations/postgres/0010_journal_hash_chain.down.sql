@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS journal_chain;
+DROP INDEX IF EXISTS journals_chain_sequence;
+ALTER TABLE journals DROP COLUMN chain_hash;
+ALTER TABLE journals DROP COLUMN previous_hash;
+ALTER TABLE journals DROP COLUMN chain_sequence;
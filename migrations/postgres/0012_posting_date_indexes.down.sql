@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS transactions_account_created_at;
+DROP INDEX IF EXISTS transactions_account_time;
+DROP INDEX IF EXISTS journals_created_at;
+DROP INDEX IF EXISTS journals_journaling_time;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS journals_reversed_journal_id;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS journals;
+DROP TABLE IF EXISTS currencies;
+DROP TABLE IF EXISTS accounts;
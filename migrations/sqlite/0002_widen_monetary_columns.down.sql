@@ -0,0 +1,2 @@
+-- SQLite INTEGER columns always hold 64 bit values, there is nothing to narrow.
+SELECT 1;
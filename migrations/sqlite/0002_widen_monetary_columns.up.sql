@@ -0,0 +1,2 @@
+-- SQLite INTEGER columns already hold 64 bit values, there is nothing to widen.
+SELECT 1;
@@ -0,0 +1,18 @@
+CREATE TABLE currencies_real (
+  code VARCHAR(10) NOT NULL,
+  name VARCHAR(30) NOT NULL,
+  exchange REAL NOT NULL,
+  created_at TIMESTAMP,
+  created_by VARCHAR(16),
+  updated_at TIMESTAMP,
+  updated_by VARCHAR(16),
+  is_deleted BOOLEAN DEFAULT false,
+  PRIMARY KEY (code)
+);
+
+INSERT INTO currencies_real (code, name, exchange, created_at, created_by, updated_at, updated_by, is_deleted)
+  SELECT code, name, CAST(exchange AS REAL), created_at, created_by, updated_at, updated_by, is_deleted FROM currencies;
+
+DROP TABLE currencies;
+
+ALTER TABLE currencies_real RENAME TO currencies;
@@ -0,0 +1,20 @@
+-- Exchange units are exact decimals. SQLite has no exact decimal type, a NUMERIC column would store them as REAL,
+-- so they are kept as TEXT. SQLite can not change the type of a column, the table is rebuilt instead.
+CREATE TABLE currencies_exact (
+  code VARCHAR(10) NOT NULL,
+  name VARCHAR(30) NOT NULL,
+  exchange TEXT NOT NULL,
+  created_at TIMESTAMP,
+  created_by VARCHAR(16),
+  updated_at TIMESTAMP,
+  updated_by VARCHAR(16),
+  is_deleted BOOLEAN DEFAULT false,
+  PRIMARY KEY (code)
+);
+
+INSERT INTO currencies_exact (code, name, exchange, created_at, created_by, updated_at, updated_by, is_deleted)
+  SELECT code, name, CAST(exchange AS TEXT), created_at, created_by, updated_at, updated_by, is_deleted FROM currencies;
+
+DROP TABLE currencies;
+
+ALTER TABLE currencies_exact RENAME TO currencies;
@@ -0,0 +1,14 @@
+-- Every exchange unit a currency is given and since when it is effective, never updated nor deleted.
+CREATE TABLE currency_rates (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  code VARCHAR(10) NOT NULL,
+  exchange TEXT NOT NULL,
+  effective_at TIMESTAMP NOT NULL,
+  created_by VARCHAR(16)
+);
+
+CREATE INDEX currency_rates_code_effective ON currency_rates (code, effective_at);
+
+-- Earlier exchange units were overwritten, the current one is only known to be effective since the last update.
+INSERT INTO currency_rates(code, exchange, effective_at, created_by)
+  SELECT code, exchange, COALESCE(updated_at, created_at, CURRENT_TIMESTAMP), COALESCE(updated_by, created_by, '') FROM currencies;
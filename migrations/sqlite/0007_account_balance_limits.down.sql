@@ -0,0 +1,26 @@
+-- The bundled SQLite can not drop a column, the table is rebuilt without them instead.
+CREATE TABLE accounts_without_limits (
+  account_number VARCHAR(20) NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  currency_code VARCHAR(10) NOT NULL,
+  description TEXT,
+  alignment VARCHAR(6) NOT NULL,
+  balance INTEGER NOT NULL,
+  coa VARCHAR(10),
+  created_at TIMESTAMP,
+  created_by VARCHAR(16),
+  updated_at TIMESTAMP,
+  updated_by VARCHAR(16),
+  is_deleted BOOLEAN DEFAULT false,
+  status VARCHAR(6) NOT NULL DEFAULT 'ACTIVE',
+  PRIMARY KEY (account_number)
+);
+
+INSERT INTO accounts_without_limits (account_number, name, currency_code, description, alignment, balance, coa, created_at, created_by, updated_at, updated_by, is_deleted, status)
+  SELECT account_number, name, currency_code, description, alignment, balance, coa, created_at, created_by, updated_at, updated_by, is_deleted, status FROM accounts;
+
+DROP TABLE accounts;
+
+ALTER TABLE accounts_without_limits RENAME TO accounts;
+
+CREATE INDEX IF NOT EXISTS accounts_coa_name ON accounts (coa, name);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS chart_of_accounts;
@@ -0,0 +1,24 @@
+DROP TABLE IF EXISTS journal_chain;
+DROP INDEX IF EXISTS journals_chain_sequence;
+-- The bundled SQLite can not drop a column, the table is rebuilt without them instead.
+CREATE TABLE journals_without_chain (
+  journal_id VARCHAR(20) NOT NULL,
+  journaling_time TIMESTAMP NOT NULL,
+  description TEXT,
+  is_reversal BOOLEAN,
+  reversed_journal_id VARCHAR(20),
+  total_amount INTEGER NOT NULL,
+  created_at TIMESTAMP,
+  created_by VARCHAR(16),
+  updated_at TIMESTAMP,
+  updated_by VARCHAR(16),
+  is_deleted BOOLEAN DEFAULT false,
+  PRIMARY KEY (journal_id)
+);
+
+INSERT INTO journals_without_chain (journal_id, journaling_time, description, is_reversal, reversed_journal_id, total_amount, created_at, created_by, updated_at, updated_by, is_deleted)
+  SELECT journal_id, journaling_time, description, is_reversal, reversed_journal_id, total_amount, created_at, created_by, updated_at, updated_by, is_deleted FROM journals;
+
+DROP TABLE journals;
+
+ALTER TABLE journals_without_chain RENAME TO journals;
@@ -0,0 +1,15 @@
+-- Journals are chained in chain_sequence order, chain_hash covers the journal, its transactions and previous_hash,
+-- the chain_hash of the journal before it. Journals persisted before the chain have no chain_sequence.
+ALTER TABLE journals ADD COLUMN chain_sequence BIGINT NULL;
+ALTER TABLE journals ADD COLUMN previous_hash VARCHAR(64) NULL;
+ALTER TABLE journals ADD COLUMN chain_hash VARCHAR(64) NULL;
+CREATE UNIQUE INDEX journals_chain_sequence ON journals (chain_sequence);
+
+-- The last journal of the chain. Its single row is locked by every journal posting, the chain can not fork.
+CREATE TABLE journal_chain (
+  id INTEGER NOT NULL,
+  last_sequence BIGINT NOT NULL,
+  last_hash VARCHAR(64) NOT NULL,
+  PRIMARY KEY (id)
+);
+INSERT INTO journal_chain (id, last_sequence, last_hash) VALUES (1, 0, '');
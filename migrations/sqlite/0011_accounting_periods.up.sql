@@ -0,0 +1,8 @@
+-- Closed accounting periods. A period is a calendar month, closing it also closes every period before it.
+CREATE TABLE accounting_periods (
+  period_code VARCHAR(7) NOT NULL,
+  closing_journal_ids TEXT,
+  closed_at TIMESTAMP,
+  closed_by VARCHAR(16),
+  PRIMARY KEY (period_code)
+);
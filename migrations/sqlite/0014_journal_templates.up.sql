@@ -0,0 +1,9 @@
+-- Journal templates, the recurring posting patterns. definition holds the parameters and legs written as json.
+CREATE TABLE journal_templates (
+  template_name VARCHAR(40) NOT NULL,
+  description VARCHAR(255),
+  definition TEXT NOT NULL,
+  created_at TIMESTAMP,
+  created_by VARCHAR(16),
+  PRIMARY KEY (template_name)
+);
@@ -400,7 +400,7 @@ function CreateNewCurrency() {
         contentType: "application/json",
         data : JSON.stringify({
             name: name,
-            exchange: exchangeNum,
+            exchange: exchange.trim(),
             creator: "Dashboard"
         }),
         success: function (data) {
@@ -529,7 +529,7 @@ function CalculateExchange() {
             if (data.status !== "SUCCESS") {
                 window.alert("Error while calculating exchange : " + data.message);
             } else {
-                $("#exchangeResult").text(samount + " " + source + " is equal to " + data.data + " " + target);
+                $("#exchangeResult").text(samount + " " + source + " is equal to " + data.data.amount + " " + target + " (exactly " + data.data.exact + ", rounded " + data.data.rounding + ")");
             }
         },
         error: function(data, errorThrown) {